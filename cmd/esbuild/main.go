@@ -39,8 +39,8 @@ var helpText = func(colors logger.Colors) string {
                         is browser and cjs when platform is node)
   --loader:X=L          Use loader L to load file extension X, where L is
                         one of: base64 | binary | copy | css | dataurl |
-                        empty | file | global-css | js | json | jsx |
-                        local-css | text | ts | tsx
+                        empty | file | flow | flow-jsx | global-css | js |
+                        json | jsx | local-css | text | ts | tsx
   --minify              Minify the output (sets all --minify-* flags)
   --outdir=...          The output directory (for multiple entry points)
   --outfile=...         The output file (for one entry point)
@@ -58,6 +58,9 @@ var helpText = func(colors logger.Colors) string {
 ` + colors.Bold + `Advanced options:` + colors.Reset + `
   --allow-overwrite         Allow output files to overwrite input files
   --analyze                 Print a report about the contents of the bundle
+  --collapse-properties     Turn properties of top-level objects that never
+                            escape into separate variables (requires --bundle
+                            and --minify-syntax)
                             (use "--analyze=verbose" for a detailed report)
   --asset-names=...         Path template to use for "file" loader files
                             (default "[name]-[hash]")
@@ -68,25 +71,41 @@ var helpText = func(colors logger.Colors) string {
   --chunk-names=...         Path template to use for code splitting chunks
                             (default "[name]-[hash]")
   --color=...               Force use of color terminal escapes (true | false)
+  --comments=preserve       Keep all comments when not minifying whitespace
+  --declarations            Emit a ".d.ts" file next to each TypeScript entry
+                            point (requires isolatedDeclarations-style types)
+  --declarations-inline:M   Inline the types of package M when bundling
+                            declarations (can use * wildcards)
   --drop:...                Remove certain constructs (console | debugger)
   --drop-labels=...         Remove labeled statements with these label names
   --entry-names=...         Path template to use for entry point output paths
                             (default "[dir]/[name]", can also use "[hash]")
+  --erasable-syntax-only    Report TypeScript syntax that generates code and
+                            imports that are missing "type"
   --footer:T=...            Text to be appended to each output file of type T
                             where T is one of: css | js
   --global-name=...         The name of the global for the IIFE format
   --ignore-annotations      Enable this to work with packages that have
                             incorrect tree-shaking annotations
+  --indent-width=...        Number of spaces per indent level (default 2)
   --inject:F                Import the file F into all input files and
                             automatically replace matching globals with imports
+  --inline-budget=...       Maximum size of functions to inline at every call
+                            site with --inline-functions (default 16)
+  --inline-functions        Inline small functions and functions that are only
+                            called once (requires --bundle and --minify-syntax)
+  --jsx-constant-elements   Reuse JSX elements that never change between renders
   --jsx-dev                 Use React's automatic runtime in development mode
   --jsx-factory=...         What to use for JSX instead of React.createElement
   --jsx-fragment=...        What to use for JSX instead of React.Fragment
   --jsx-import-source=...   Override the package name for the automatic runtime
                             (default "react")
+  --jsx-inline-elements     Create React elements using object literals instead
+                            of calling jsx() when possible
   --jsx-side-effects        Do not remove unused JSX expressions
   --jsx=...                 Set to "automatic" to use React's automatic runtime
                             or to "preserve" to disable transforming JSX to JS
+                            (also: solid | vue | htm for other frameworks)
   --keep-names              Preserve "name" on functions and classes
   --keyfile=...             Key for serving HTTPS (see also "--certfile")
   --legal-comments=...      Where to place legal comments (none | inline |
@@ -97,10 +116,14 @@ var helpText = func(colors logger.Colors) string {
                             error | silent, default info)
   --log-limit=...           Maximum message count or 0 to disable (default 6)
   --log-override:X=Y        Use log level Y for log messages with identifier X
+  --loose-iteration         Assume iterables are array-like when lowering
+                            destructuring, spread, and for-of loops
   --main-fields=...         Override the main file order in package.json
                             (default "browser,module,main" when platform is
                             browser and "main,module" when platform is node)
   --mangle-cache=...        Save "mangle props" decisions to a JSON file
+  --mangle-private          Rename TypeScript private and protected members and
+                            #private names
   --mangle-props=...        Rename all properties matching a regular expression
   --mangle-quoted=...       Enable renaming of quoted properties (true | false)
   --metafile=...            Write metadata about the build to a JSON file
@@ -111,12 +134,19 @@ var helpText = func(colors logger.Colors) string {
   --out-extension:.js=.mjs  Use a custom output extension instead of ".js"
   --outbase=...             The base path used to determine entry point output
                             paths (for multiple entry points)
+  --polyfill=...            Import polyfills from this package (e.g. "core-js")
+                            for built-ins that the target is missing
   --preserve-symlinks       Disable symlink resolution for module lookup
+  --print-width=...         Put each item of a list on its own line if the list
+                            would extend past this column
   --public-path=...         Set the base URL for the "file" loader
   --pure:N                  Mark the name N as a pure function for tree shaking
+  --quotes=single           Prefer single quotes for strings (default double)
   --reserve-props=...       Do not mangle these properties
   --resolve-extensions=...  A comma-separated list of implicit extensions
                             (default ".tsx,.ts,.jsx,.js,.css,.json")
+  --semicolons=asi          Omit semicolons where automatic semicolon insertion
+                            makes them unnecessary
   --serve-fallback=...      Serve this HTML page when the request doesn't match
   --servedir=...            What to serve in addition to generated output files
   --source-root=...         Sets the "sourceRoot" field in generated source maps
@@ -124,9 +154,14 @@ var helpText = func(colors logger.Colors) string {
   --sourcemap=external      Do not link to the source map with a comment
   --sourcemap=inline        Emit the source map with an inline data URL
   --sources-content=false   Omit "sourcesContent" in generated source maps
+  --strip-types-only        Only replace TypeScript types with whitespace when
+                            transforming (keeps all line and column numbers)
   --supported:F=...         Consider syntax F to be supported (true | false)
+  --trailing-commas=...     Add trailing commas to multi-line lists (none | es5
+                            | all, default none)
   --tree-shaking=...        Force tree shaking on or off (false | true)
   --tsconfig=...            Use this tsconfig.json file instead of other ones
+  --use-tabs                Indent using tabs instead of spaces
   --version                 Print the current version (` + esbuildVersion + `) and exit
 
 ` + colors.Bold + `Examples:` + colors.Reset + `
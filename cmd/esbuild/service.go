@@ -793,6 +793,8 @@ func resolveKindToString(kind api.ResolveKind) string {
 		return "composes-from"
 	case api.ResolveCSSURLToken:
 		return "url-token"
+	case api.ResolveTSTypeImport:
+		return "type-import"
 
 	default:
 		panic("Internal error")
@@ -821,6 +823,8 @@ func stringToResolveKind(kind string) (api.ResolveKind, bool) {
 		return api.ResolveCSSComposesFrom, true
 	case "url-token":
 		return api.ResolveCSSURLToken, true
+	case "type-import":
+		return api.ResolveTSTypeImport, true
 	}
 
 	return api.ResolveNone, false
@@ -919,6 +923,13 @@ func (service *serviceType) convertPlugins(key int, jsPlugins interface{}, activ
 				if value, ok := request["pluginData"]; ok {
 					options.PluginData = value.(int)
 				}
+				if value, ok := request["with"]; ok {
+					value := value.(map[string]interface{})
+					options.With = make(map[string]string, len(value))
+					for k, v := range value {
+						options.With[k] = v.(string)
+					}
+				}
 
 				result := build.Resolve(path, options)
 				return encodePacket(packet{
@@ -980,6 +991,7 @@ func (service *serviceType) convertPlugins(key int, jsPlugins interface{}, activ
 						"resolveDir": args.ResolveDir,
 						"kind":       resolveKindToString(args.Kind),
 						"pluginData": args.PluginData,
+						"with":       encodeStringMap(args.With),
 					}).(map[string]interface{})
 					if !ok {
 						return result, errors.New("The service was stopped")
@@ -1063,6 +1075,7 @@ func (service *serviceType) convertPlugins(key int, jsPlugins interface{}, activ
 						"namespace":  args.Namespace,
 						"suffix":     args.Suffix,
 						"pluginData": args.PluginData,
+						"with":       encodeStringMap(args.With),
 					}).(map[string]interface{})
 					if !ok {
 						return result, errors.New("The service was stopped")
@@ -1257,6 +1270,14 @@ func decodeStringArray(values []interface{}) []string {
 	return strings
 }
 
+func encodeStringMap(strings map[string]string) map[string]interface{} {
+	values := make(map[string]interface{}, len(strings))
+	for k, v := range strings {
+		values[k] = v
+	}
+	return values
+}
+
 func encodeOutputFiles(outputFiles []api.OutputFile) []interface{} {
 	values := make([]interface{}, len(outputFiles))
 	for i, outputFile := range outputFiles {
@@ -57,6 +57,7 @@ export const jsFeatures = {
   Generator: true,
   Hashbang: true,
   ImportAssertions: true,
+  ImportAttributes: true,
   ImportMeta: true,
   InlineScript: true,
   LogicalAssignment: true,
@@ -465,6 +466,13 @@ import('./kangax').then(kangax => {
     delete js.ImportAssertions.Safari
   }
 
+  // Import attributes (the standardized replacement for import assertions)
+  {
+    // From https://github.com/nodejs/node/blob/main/doc/changelogs/CHANGELOG_V18.md#18.20.0
+    // and https://github.com/nodejs/node/blob/main/doc/changelogs/CHANGELOG_V20.md#20.10.0
+    js.ImportAttributes.Node = { '18.20': { force: true }, 19: { force: false }, '20.10': { force: true } }
+  }
+
   // MDN data is wrong here: https://www.chromestatus.com/feature/6482797915013120
   js.ClassStaticBlocks.Chrome = { 91: { force: true } }
 
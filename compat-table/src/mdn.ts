@@ -22,6 +22,7 @@ const jsFeatures: Partial<Record<JSFeature, string>> = {
   ImportMeta: 'javascript.operators.import_meta',
   ExportStarAs: 'javascript.statements.export.namespace',
   ImportAssertions: 'javascript.statements.import.import_assertions',
+  ImportAttributes: 'javascript.statements.import.import_attributes',
 }
 
 const cssFeatures: Partial<Record<CSSFeature, string | string[]>> = {
@@ -40,6 +40,10 @@ const (
 
 	// A CSS "url(...)" token
 	ImportURL
+
+	// A TypeScript import or re-export in a declaration file. These only
+	// import types, so they aren't part of the JavaScript module graph.
+	ImportTypes
 )
 
 func (kind ImportKind) StringForMetafile() string {
@@ -58,6 +62,8 @@ func (kind ImportKind) StringForMetafile() string {
 		return "composes-from"
 	case ImportURL:
 		return "url-token"
+	case ImportTypes:
+		return "type-import"
 	case ImportEntryPoint:
 		return "entry-point"
 	default:
@@ -152,9 +158,9 @@ func (flags ImportRecordFlags) Has(flag ImportRecordFlags) bool {
 }
 
 type ImportRecord struct {
-	Assertions *ImportAssertions
-	Path       logger.Path
-	Range      logger.Range
+	AssertOrWith *ImportAssertOrWith
+	Path         logger.Path
+	Range        logger.Range
 
 	// If the "HandlesImportErrors" flag is present, then this is the location
 	// of the error handler. This is used for error reporting.
@@ -172,16 +178,34 @@ type ImportRecord struct {
 	Kind  ImportKind
 }
 
-type ImportAssertions struct {
-	Entries            []AssertEntry
-	AssertLoc          logger.Loc
+type AssertOrWithKeyword uint8
+
+const (
+	AssertKeyword AssertOrWithKeyword = iota
+	WithKeyword
+)
+
+func (kw AssertOrWithKeyword) String() string {
+	if kw == AssertKeyword {
+		return "assert"
+	}
+	return "with"
+}
+
+// This holds either import assertions (the older "assert" keyword) or import
+// attributes (the newer "with" keyword). Only import attributes can affect
+// how the imported module is resolved and loaded.
+type ImportAssertOrWith struct {
+	Entries            []AssertOrWithEntry
+	KeywordLoc         logger.Loc
 	InnerOpenBraceLoc  logger.Loc
 	InnerCloseBraceLoc logger.Loc
 	OuterOpenBraceLoc  logger.Loc
 	OuterCloseBraceLoc logger.Loc
+	Keyword            AssertOrWithKeyword
 }
 
-type AssertEntry struct {
+type AssertOrWithEntry struct {
 	Key             []uint16 // An identifier or a string
 	Value           []uint16 // Always a string
 	KeyLoc          logger.Loc
@@ -189,7 +213,7 @@ type AssertEntry struct {
 	PreferQuotedKey bool
 }
 
-func FindAssertion(assertions []AssertEntry, name string) *AssertEntry {
+func FindAssertOrWithEntry(assertions []AssertOrWithEntry, name string) *AssertOrWithEntry {
 	for _, assertion := range assertions {
 		if helpers.UTF16EqualsString(assertion.Key, name) {
 			return &assertion
@@ -462,6 +486,11 @@ const (
 	// it's not safe to make assumptions about this symbol from the initializer.
 	CouldPotentiallyBeMutated
 
+	// If this is present, a property of this symbol could potentially be
+	// overwritten or deleted (e.g. "x.y = z"). This is only tracked for imports.
+	// It means it's not safe to inline property accesses off of this symbol.
+	PropertiesCouldPotentiallyBeMutated
+
 	// This flags all symbols that were exported from the module using the ES6
 	// "export" keyword, either directly on the declaration or using "export {}".
 	WasExported
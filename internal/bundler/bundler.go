@@ -98,10 +98,11 @@ type parseArgs struct {
 }
 
 type parseResult struct {
-	resolveResults []*resolver.ResolveResult
-	file           scannerFile
-	tlaCheck       tlaCheck
-	ok             bool
+	resolveResults    []*resolver.ResolveResult
+	dtsResolveResults []*resolver.ResolveResult // For type imports in generated declarations
+	file              scannerFile
+	tlaCheck          tlaCheck
+	ok                bool
 }
 
 type tlaCheck struct {
@@ -161,6 +162,33 @@ func parseFile(args parseArgs) {
 
 	_, base, ext := logger.PlatformIndependentPathDirBaseExt(source.KeyPath.Text)
 
+	// Import attributes (e.g. "with { type: 'json' }") take precedence over the
+	// file extension. But only do this if the file wasn't loaded by a plugin or
+	// if the plugin asked for the default loader. Plugins are allowed to assign
+	// whatever semantics they want to import attributes.
+	if attrs := source.KeyPath.ImportAttributes.DecodeIntoArray(); len(attrs) > 0 &&
+		loader != config.LoaderNone && loader != config.LoaderEmpty && (pluginName == "" || loader == config.LoaderDefault) {
+		tracker := logger.MakeLineColumnTracker(args.importSource)
+		for _, attr := range attrs {
+			if attr.Key != "type" {
+				args.log.AddError(&tracker, args.importPathRange,
+					fmt.Sprintf("Importing with the %q attribute is not supported", attr.Key))
+				continue
+			}
+			switch attr.Value {
+			case "json":
+				loader = config.LoaderJSON
+			case "text":
+				loader = config.LoaderText
+			case "bytes":
+				loader = config.LoaderBinary
+			default:
+				args.log.AddError(&tracker, args.importPathRange,
+					fmt.Sprintf("Importing with a type attribute of %q is not supported", attr.Value))
+			}
+		}
+	}
+
 	// The special "default" loader determines the loader from the file path
 	if loader == config.LoaderDefault {
 		loader = loaderFromFileExtension(args.options.ExtensionToLoader, base+ext)
@@ -170,6 +198,14 @@ func parseFile(args parseArgs) {
 		source.Contents = ""
 	}
 
+	// Files with a "@flow" pragma comment are parsed as Flow instead of as
+	// JavaScript. This lets Flow code bases be built without any extra setup.
+	// Flow code bases put JSX in ".js" files, so JSX is always enabled here
+	// like it is with Babel's Flow preset.
+	if (loader == config.LoaderJS || loader == config.LoaderJSX) && js_parser.HasFlowPragma(source.Contents) {
+		loader = config.LoaderFlowJSX
+	}
+
 	result := parseResult{
 		file: scannerFile{
 			inputFile: graph.InputFile{
@@ -209,6 +245,16 @@ func parseFile(args parseArgs) {
 		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
 		result.ok = ok
 
+	case config.LoaderFlow, config.LoaderFlowJSX:
+		args.options.Flow.Parse = true
+		args.options.JSX.Parse = loader == config.LoaderFlowJSX
+		ast, ok := args.caches.JSCache.Parse(args.log, source, js_parser.OptionsFromConfig(&args.options))
+		if len(ast.Parts) <= 1 { // Ignore the implicitly-generated namespace export part
+			result.file.inputFile.SideEffects.Kind = graph.NoSideEffects_EmptyAST
+		}
+		result.file.inputFile.Repr = &graph.JSRepr{AST: ast}
+		result.ok = ok
+
 	case config.LoaderTS, config.LoaderTSNoAmbiguousLessThan:
 		args.options.TS.Parse = true
 		args.options.TS.NoAmbiguousLessThan = loader == config.LoaderTSNoAmbiguousLessThan
@@ -359,105 +405,148 @@ func parseFile(args parseArgs) {
 			*recordsPtr = records
 			result.resolveResults = make([]*resolver.ResolveResult, len(records))
 
-			if len(records) > 0 {
+			// Type imports in declaration files are resolved too, but they are kept
+			// separate from the import records for the JavaScript code
+			var dtsRecords []ast.ImportRecord
+			if repr, ok := result.file.inputFile.Repr.(*graph.JSRepr); ok && repr.AST.Declarations != nil && len(repr.AST.Declarations.ImportRecords) > 0 {
+				clone := *repr.AST.Declarations
+				clone.ImportRecords = append([]ast.ImportRecord{}, clone.ImportRecords...)
+				repr.AST.Declarations = &clone
+				dtsRecords = clone.ImportRecords
+				result.dtsResolveResults = make([]*resolver.ResolveResult, len(dtsRecords))
+			}
+
+			if len(records) > 0 || dtsRecords != nil {
 				type cacheEntry struct {
 					resolveResult *resolver.ResolveResult
 					debug         resolver.DebugMeta
 					didLogError   bool
 				}
 
-				resolverCache := make(map[ast.ImportKind]map[string]cacheEntry)
+				type cacheKey struct {
+					path  string
+					attrs logger.ImportAttributes
+				}
+
+				resolverCache := make(map[ast.ImportKind]map[cacheKey]cacheEntry)
 				tracker := logger.MakeLineColumnTracker(&source)
 
-				for importRecordIndex := range records {
-					// Don't try to resolve imports that are already resolved
-					record := &records[importRecordIndex]
-					if record.SourceIndex.IsValid() {
-						continue
-					}
+				resolveImportRecords := func(records []ast.ImportRecord, resolveResults []*resolver.ResolveResult) {
+					for importRecordIndex := range records {
+						// Don't try to resolve imports that are already resolved
+						record := &records[importRecordIndex]
+						if record.SourceIndex.IsValid() {
+							continue
+						}
 
-					// Ignore records that the parser has discarded. This is used to remove
-					// type-only imports in TypeScript files.
-					if record.Flags.Has(ast.IsUnused) {
-						continue
-					}
+						// Ignore records that the parser has discarded. This is used to remove
+						// type-only imports in TypeScript files.
+						if record.Flags.Has(ast.IsUnused) {
+							continue
+						}
 
-					// Cache the path in case it's imported multiple times in this file
-					cache, ok := resolverCache[record.Kind]
-					if !ok {
-						cache = make(map[string]cacheEntry)
-						resolverCache[record.Kind] = cache
-					}
+						// Only import attributes (the "with" keyword) affect how the imported
+						// module is resolved and loaded. Import assertions (the "assert" keyword)
+						// are only checked after the fact.
+						var attrs logger.ImportAttributes
+						if record.AssertOrWith != nil && record.AssertOrWith.Keyword == ast.WithKeyword {
+							data := make(map[string]string, len(record.AssertOrWith.Entries))
+							for _, entry := range record.AssertOrWith.Entries {
+								data[helpers.UTF16ToString(entry.Key)] = helpers.UTF16ToString(entry.Value)
+							}
+							attrs = logger.EncodeImportAttributes(data)
+						}
 
-					entry, ok := cache[record.Path.Text]
-					if ok {
-						result.resolveResults[importRecordIndex] = entry.resolveResult
-					} else {
-						// Run the resolver and log an error if the path couldn't be resolved
-						resolveResult, didLogError, debug := RunOnResolvePlugins(
-							args.options.Plugins,
-							args.res,
-							args.log,
-							args.fs,
-							&args.caches.FSCache,
-							&source,
-							record.Range,
-							source.KeyPath,
-							record.Path.Text,
-							record.Kind,
-							absResolveDir,
-							pluginData,
-						)
-						entry = cacheEntry{
-							resolveResult: resolveResult,
-							debug:         debug,
-							didLogError:   didLogError,
+						// Cache the path in case it's imported multiple times in this file
+						cache, ok := resolverCache[record.Kind]
+						if !ok {
+							cache = make(map[cacheKey]cacheEntry)
+							resolverCache[record.Kind] = cache
 						}
-						cache[record.Path.Text] = entry
-
-						// All "require.resolve()" imports should be external because we don't
-						// want to waste effort traversing into them
-						if record.Kind == ast.ImportRequireResolve {
-							if resolveResult != nil && resolveResult.IsExternal {
-								// Allow path substitution as long as the result is external
-								result.resolveResults[importRecordIndex] = resolveResult
-							} else if !record.Flags.Has(ast.HandlesImportErrors) {
-								args.log.AddID(logger.MsgID_Bundler_RequireResolveNotExternal, logger.Warning, &tracker, record.Range,
-									fmt.Sprintf("%q should be marked as external for use with \"require.resolve\"", record.Path.Text))
+
+						key := cacheKey{path: record.Path.Text, attrs: attrs}
+						entry, ok := cache[key]
+						if ok {
+							resolveResults[importRecordIndex] = entry.resolveResult
+						} else {
+							// Run the resolver and log an error if the path couldn't be resolved
+							resolveResult, didLogError, debug := RunOnResolvePlugins(
+								args.options.Plugins,
+								args.res,
+								args.log,
+								args.fs,
+								&args.caches.FSCache,
+								&source,
+								record.Range,
+								source.KeyPath,
+								record.Path.Text,
+								attrs,
+								record.Kind,
+								absResolveDir,
+								pluginData,
+							)
+							entry = cacheEntry{
+								resolveResult: resolveResult,
+								debug:         debug,
+								didLogError:   didLogError,
+							}
+							cache[key] = entry
+
+							// All "require.resolve()" imports should be external because we don't
+							// want to waste effort traversing into them
+							if record.Kind == ast.ImportRequireResolve {
+								if resolveResult != nil && resolveResult.IsExternal {
+									// Allow path substitution as long as the result is external
+									resolveResults[importRecordIndex] = resolveResult
+								} else if !record.Flags.Has(ast.HandlesImportErrors) {
+									args.log.AddID(logger.MsgID_Bundler_RequireResolveNotExternal, logger.Warning, &tracker, record.Range,
+										fmt.Sprintf("%q should be marked as external for use with \"require.resolve\"", record.Path.Text))
+								}
+								continue
 							}
-							continue
 						}
-					}
 
-					// Check whether we should log an error every time the result is nil,
-					// even if it's from the cache. Do this because the error may not
-					// have been logged for nil entries if the previous instances had
-					// the "HandlesImportErrors" flag.
-					if entry.resolveResult == nil {
-						// Failed imports inside a try/catch are silently turned into
-						// external imports instead of causing errors. This matches a common
-						// code pattern for conditionally importing a module with a graceful
-						// fallback.
-						if !entry.didLogError && !record.Flags.Has(ast.HandlesImportErrors) {
-							// Report an error
-							text, suggestion, notes := ResolveFailureErrorTextSuggestionNotes(args.res, record.Path.Text, record.Kind,
-								pluginName, args.fs, absResolveDir, args.options.Platform, source.PrettyPath, entry.debug.ModifiedImportPath)
-							entry.debug.LogErrorMsg(args.log, &source, record.Range, text, suggestion, notes)
-
-							// Only report this error once per unique import path in the file
-							entry.didLogError = true
-							cache[record.Path.Text] = entry
-						} else if !entry.didLogError && record.Flags.Has(ast.HandlesImportErrors) {
-							// Report a debug message about why there was no error
-							args.log.AddIDWithNotes(logger.MsgID_Bundler_IgnoredDynamicImport, logger.Debug, &tracker, record.Range,
-								fmt.Sprintf("Importing %q was allowed even though it could not be resolved because dynamic import failures appear to be handled here:",
-									record.Path.Text), []logger.MsgData{tracker.MsgData(js_lexer.RangeOfIdentifier(source, record.ErrorHandlerLoc),
-									"The handler for dynamic import failures is here:")})
+						// Check whether we should log an error every time the result is nil,
+						// even if it's from the cache. Do this because the error may not
+						// have been logged for nil entries if the previous instances had
+						// the "HandlesImportErrors" flag.
+						if entry.resolveResult == nil {
+							// Type imports that can't be resolved are kept as imports in the
+							// generated declarations. They may refer to types that are only
+							// available to the TypeScript compiler (e.g. from "@types/node").
+							if record.Kind == ast.ImportTypes {
+								continue
+							}
+
+							// Failed imports inside a try/catch are silently turned into
+							// external imports instead of causing errors. This matches a common
+							// code pattern for conditionally importing a module with a graceful
+							// fallback.
+							if !entry.didLogError && !record.Flags.Has(ast.HandlesImportErrors) {
+								// Report an error
+								text, suggestion, notes := ResolveFailureErrorTextSuggestionNotes(args.res, record.Path.Text, record.Kind,
+									pluginName, args.fs, absResolveDir, args.options.Platform, source.PrettyPath, entry.debug.ModifiedImportPath)
+								entry.debug.LogErrorMsg(args.log, &source, record.Range, text, suggestion, notes)
+
+								// Only report this error once per unique import path in the file
+								entry.didLogError = true
+								cache[key] = entry
+							} else if !entry.didLogError && record.Flags.Has(ast.HandlesImportErrors) {
+								// Report a debug message about why there was no error
+								args.log.AddIDWithNotes(logger.MsgID_Bundler_IgnoredDynamicImport, logger.Debug, &tracker, record.Range,
+									fmt.Sprintf("Importing %q was allowed even though it could not be resolved because dynamic import failures appear to be handled here:",
+										record.Path.Text), []logger.MsgData{tracker.MsgData(js_lexer.RangeOfIdentifier(source, record.ErrorHandlerLoc),
+										"The handler for dynamic import failures is here:")})
+							}
+							continue
 						}
-						continue
-					}
 
-					result.resolveResults[importRecordIndex] = entry.resolveResult
+						resolveResults[importRecordIndex] = entry.resolveResult
+					}
+				}
+				resolveImportRecords(records, result.resolveResults)
+				if dtsRecords != nil {
+					resolveImportRecords(dtsRecords, result.dtsResolveResults)
 				}
 			}
 		}
@@ -804,6 +893,7 @@ func RunOnResolvePlugins(
 	importPathRange logger.Range,
 	importer logger.Path,
 	path string,
+	importAttributes logger.ImportAttributes,
 	kind ast.ImportKind,
 	absResolveDir string,
 	pluginData interface{},
@@ -814,6 +904,7 @@ func RunOnResolvePlugins(
 		Kind:       kind,
 		PluginData: pluginData,
 		Importer:   importer,
+		With:       importAttributes,
 	}
 	applyPath := logger.Path{
 		Text:      path,
@@ -886,6 +977,11 @@ func RunOnResolvePlugins(
 				}
 			}
 
+			// Import attributes are part of the identity of the resolved module
+			if !result.External {
+				result.Path.ImportAttributes = importAttributes
+			}
+
 			return &resolver.ResolveResult{
 				PathPair:               resolver.PathPair{Primary: result.Path},
 				IsExternal:             result.External,
@@ -900,6 +996,14 @@ func RunOnResolvePlugins(
 	// can also configure a custom resolve directory for files in other namespaces.
 	result, debug := res.Resolve(absResolveDir, path, kind)
 
+	// Import attributes are part of the identity of the resolved module
+	if result != nil && !result.IsExternal {
+		result.PathPair.Primary.ImportAttributes = importAttributes
+		if result.PathPair.HasSecondary() {
+			result.PathPair.Secondary.ImportAttributes = importAttributes
+		}
+	}
+
 	// Warn when the case used for importing differs from the actual file name
 	if result != nil && result.DifferentCase != nil && !helpers.IsInsideNodeModules(absResolveDir) {
 		diffCase := *result.DifferentCase
@@ -1514,6 +1618,7 @@ func (s *scanner) preprocessInjectedFiles() {
 				logger.Range{},
 				importer,
 				importPath,
+				logger.ImportAttributes{},
 				ast.ImportEntryPoint,
 				injectAbsResolveDir,
 				nil,
@@ -1660,6 +1765,7 @@ func (s *scanner) addEntryPoints(entryPoints []EntryPoint) []graph.EntryPoint {
 				logger.Range{},
 				importer,
 				entryPoint.InputPath,
+				logger.ImportAttributes{},
 				ast.ImportEntryPoint,
 				entryPointAbsResolveDir,
 				nil,
@@ -1669,6 +1775,14 @@ func (s *scanner) addEntryPoints(entryPoints []EntryPoint) []graph.EntryPoint {
 					s.log.AddError(nil, logger.Range{}, fmt.Sprintf("The entry point %q cannot be marked as external", entryPoint.InputPath))
 				} else {
 					entryPointResolveResults[i] = resolveResult
+
+					// Declaration files don't contain any code, so the output would be empty
+					if !s.options.Declarations && resolveResult.PathPair.Primary.Namespace == "file" &&
+						js_parser.IsDeclarationFile(resolveResult.PathPair.Primary.Text) {
+						s.log.AddErrorWithNotes(nil, logger.Range{},
+							fmt.Sprintf("The entry point %q is a declaration file, which doesn't contain any code", resolver.PrettyPath(s.fs, resolveResult.PathPair.Primary)),
+							[]logger.MsgData{{Text: "Enable the \"declarations\" setting to generate a declaration file from this entry point instead."}})
+					}
 				}
 			} else if !didLogError {
 				var notes []logger.MsgData
@@ -1862,49 +1976,58 @@ func (s *scanner) scanAllDependencies() {
 
 		// Don't try to resolve paths if we're not bundling
 		if recordsPtr := result.file.inputFile.Repr.ImportRecords(); s.options.Mode == config.ModeBundle && recordsPtr != nil {
-			records := *recordsPtr
-			for importRecordIndex := range records {
-				record := &records[importRecordIndex]
+			s.scanImportRecords(&result, *recordsPtr, result.resolveResults)
+			if result.dtsResolveResults != nil {
+				repr := result.file.inputFile.Repr.(*graph.JSRepr)
+				s.scanImportRecords(&result, repr.AST.Declarations.ImportRecords, result.dtsResolveResults)
+			}
+		}
 
-				// Skip this import record if the previous resolver call failed
-				resolveResult := result.resolveResults[importRecordIndex]
-				if resolveResult == nil {
-					continue
-				}
+		s.results[result.file.inputFile.Source.Index] = result
+	}
+}
 
-				path := resolveResult.PathPair.Primary
-				if !resolveResult.IsExternal {
-					// Handle a path within the bundle
-					sourceIndex := s.maybeParseFile(*resolveResult, resolver.PrettyPath(s.fs, path),
-						&result.file.inputFile.Source, record.Range, resolveResult.PluginData, inputKindNormal, nil)
-					record.SourceIndex = ast.MakeIndex32(sourceIndex)
-				} else {
-					// Allow this import statement to be removed if something marked it as "sideEffects: false"
-					if resolveResult.PrimarySideEffectsData != nil {
-						record.Flags |= ast.IsExternalWithoutSideEffects
-					}
+// This schedules the files imported by these import records for parsing. It's
+// used both for the JavaScript code and for the generated declarations.
+func (s *scanner) scanImportRecords(result *parseResult, records []ast.ImportRecord, resolveResults []*resolver.ResolveResult) {
+	for importRecordIndex := range records {
+		record := &records[importRecordIndex]
 
-					// If the path to the external module is relative to the source
-					// file, rewrite the path to be relative to the working directory
-					if path.Namespace == "file" {
-						if relPath, ok := s.fs.Rel(s.options.AbsOutputDir, path.Text); ok {
-							// Prevent issues with path separators being different on Windows
-							relPath = strings.ReplaceAll(relPath, "\\", "/")
-							if resolver.IsPackagePath(relPath) {
-								relPath = "./" + relPath
-							}
-							record.Path.Text = relPath
-						} else {
-							record.Path = path
-						}
-					} else {
-						record.Path = path
+		// Skip this import record if the previous resolver call failed
+		resolveResult := resolveResults[importRecordIndex]
+		if resolveResult == nil {
+			continue
+		}
+
+		path := resolveResult.PathPair.Primary
+		if !resolveResult.IsExternal {
+			// Handle a path within the bundle
+			sourceIndex := s.maybeParseFile(*resolveResult, resolver.PrettyPath(s.fs, path),
+				&result.file.inputFile.Source, record.Range, resolveResult.PluginData, inputKindNormal, nil)
+			record.SourceIndex = ast.MakeIndex32(sourceIndex)
+		} else {
+			// Allow this import statement to be removed if something marked it as "sideEffects: false"
+			if resolveResult.PrimarySideEffectsData != nil {
+				record.Flags |= ast.IsExternalWithoutSideEffects
+			}
+
+			// If the path to the external module is relative to the source
+			// file, rewrite the path to be relative to the working directory
+			if path.Namespace == "file" {
+				if relPath, ok := s.fs.Rel(s.options.AbsOutputDir, path.Text); ok {
+					// Prevent issues with path separators being different on Windows
+					relPath = strings.ReplaceAll(relPath, "\\", "/")
+					if resolver.IsPackagePath(relPath) {
+						relPath = "./" + relPath
 					}
+					record.Path.Text = relPath
+				} else {
+					record.Path = path
 				}
+			} else {
+				record.Path = path
 			}
 		}
-
-		s.results[result.file.inputFile.Source.Index] = result
 	}
 }
 
@@ -1993,19 +2116,23 @@ func (s *scanner) processScannedFiles(entryPointMeta []graph.EntryPoint) []scann
 						helpers.QuoteForJSON(record.Path.Text, s.options.ASCIIOnly)))
 				}
 
-				// Validate that imports with "assert { type: 'json' }" were imported
-				// with the JSON loader. This is done to match the behavior of these
-				// import assertions in a real JavaScript runtime. In addition, we also
-				// allow the copy loader since this is sort of like marking the path
-				// as external (the import assertions are kept and the real JavaScript
-				// runtime evaluates them, not us).
+				// Validate that imports with "assert { type: 'json' }" or "with { type:
+				// 'json' }" were imported with the JSON loader. This is done to match
+				// the behavior of these imports in a real JavaScript runtime. In
+				// addition, we also allow the copy loader since this is sort of like
+				// marking the path as external (the import assertions and attributes
+				// are kept and the real JavaScript runtime evaluates them, not us).
 				if record.Flags.Has(ast.AssertTypeJSON) && otherResult.ok && otherFile.inputFile.Loader != config.LoaderJSON && otherFile.inputFile.Loader != config.LoaderCopy {
+					what := "attribute"
+					if record.AssertOrWith.Keyword == ast.AssertKeyword {
+						what = "assertion"
+					}
 					s.log.AddErrorWithNotes(&tracker, record.Range,
 						fmt.Sprintf("The file %q was loaded with the %q loader", otherFile.inputFile.Source.PrettyPath, config.LoaderToString[otherFile.inputFile.Loader]),
 						[]logger.MsgData{
-							tracker.MsgData(js_lexer.RangeOfImportAssertion(result.file.inputFile.Source, *ast.FindAssertion(record.Assertions.Entries, "type")),
-								"This import assertion requires the loader to be \"json\" instead:"),
-							{Text: "You need to either reconfigure esbuild to ensure that the loader for this file is \"json\" or you need to remove this import assertion."}})
+							tracker.MsgData(js_lexer.RangeOfImportAssertOrWith(result.file.inputFile.Source, *ast.FindAssertOrWithEntry(record.AssertOrWith.Entries, "type")),
+								fmt.Sprintf("This import %s requires the loader to be \"json\" instead:", what)),
+							{Text: fmt.Sprintf("You need to either reconfigure esbuild to ensure that the loader for this file is \"json\" or you need to remove this import %s.", what)}})
 				}
 
 				switch record.Kind {
@@ -2602,8 +2729,19 @@ func findReachableFiles(files []graph.InputFile, entryPoints []graph.EntryPoint)
 		if !visited[sourceIndex] {
 			visited[sourceIndex] = true
 			file := &files[sourceIndex]
-			if repr, ok := file.Repr.(*graph.JSRepr); ok && repr.CSSSourceIndex.IsValid() {
-				visit(repr.CSSSourceIndex.GetIndex())
+			if repr, ok := file.Repr.(*graph.JSRepr); ok {
+				if repr.CSSSourceIndex.IsValid() {
+					visit(repr.CSSSourceIndex.GetIndex())
+				}
+
+				// Also include the files that generated declarations import types from
+				if repr.AST.Declarations != nil {
+					for _, record := range repr.AST.Declarations.ImportRecords {
+						if record.SourceIndex.IsValid() {
+							visit(record.SourceIndex.GetIndex())
+						}
+					}
+				}
 			}
 			if recordsPtr := file.Repr.ImportRecords(); recordsPtr != nil {
 				for _, record := range *recordsPtr {
@@ -2713,6 +2713,261 @@ func TestInlineFunctionCallForInitDecl(t *testing.T) {
 	})
 }
 
+func TestInlineSmallFunctionCallsAcrossFiles(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { add_REMOVE, big_keep, once_REMOVE, usesThis_keep, usesArgs_keep, recursive_keep, mutated_keep } from './lib'
+				let x = 1, y = 2
+				console.log(add_REMOVE(x, y), add_REMOVE(1, 'a'), add_REMOVE(x))
+				console.log(big_keep(x, y), big_keep(y, x))
+				console.log(once_REMOVE(x, y))
+				console.log(usesThis_keep(x), usesArgs_keep(x), recursive_keep(x), mutated_keep(x))
+				console.log(delete add_REMOVE(x, y).foo)
+			`,
+			"/lib.js": `
+				export function add_REMOVE(a, b) { return a + b }
+				export function big_keep(a, b) { return { x: a.x + b.y * 2, y: a.y - b.x / 3, z: [a, b] } }
+				export function once_REMOVE(a, b) { return { x: a.x + b.y * 2, y: a.y - b.x / 3, z: [a, b] } }
+				export function usesThis_keep(a) { return this.x + a }
+				export function usesArgs_keep(a) { return arguments.length + a }
+				export function recursive_keep(a) { return a ? recursive_keep(a - 1) : 0 }
+				export function mutated_keep(a) { return a * 2 }
+				export function mutate() { mutated_keep = null }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:            config.ModeBundle,
+			AbsOutputFile:   "/out.js",
+			MinifySyntax:    true,
+			InlineFunctions: true,
+		},
+	})
+}
+
+func TestInlineSmallFunctionCallsNonInlinableArgs(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { add_keep, once_keep } from './lib'
+				console.log(add_keep(1, 2), add_keep(foo(), 2), add_keep(unbound, 2), add_keep(...args), add_keep?.(1, 2))
+				console.log(once_keep(1, 2))
+				export { once_keep }
+			`,
+			"/lib.js": `
+				export function add_keep(a, b) { return a + b }
+				export function once_keep(a, b) { return { x: a.x + b.y * 2, y: a.y - b.x / 3, z: [a, b] } }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:            config.ModeBundle,
+			AbsOutputFile:   "/out.js",
+			OutputFormat:    config.FormatESModule,
+			MinifySyntax:    true,
+			InlineFunctions: true,
+		},
+	})
+}
+
+// Functions with other statements in their body are only inlined if they are
+// called once, and that call is an entire top-level statement
+func TestInlineSmallFunctionCallsMultipleStatements(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import {
+					once_REMOVE, expr_keep, twice_keep, usesThis_keep, usesArgs_keep, recursive_keep,
+					usesVar_keep, earlyReturn_keep, usesImport_keep, nested_keep,
+				} from './lib'
+				let x = 1, y = 2, c = 3
+				once_REMOVE(x, y)
+				console.log(expr_keep(x, y), c)
+				twice_keep(x)
+				if (y) twice_keep(y)
+				usesThis_keep(x)
+				usesArgs_keep(x)
+				recursive_keep(x)
+				usesVar_keep(x)
+				earlyReturn_keep(x)
+				usesImport_keep(x)
+				nested_keep(x)
+			`,
+			"/lib.js": `
+				import { dep } from './dep'
+				let total = 0
+				export function once_REMOVE(a, b) {
+					let c = a + b
+					for (let i = 0; i < b; i++) total += c * i
+					console.log(c, total)
+					return c
+				}
+				export function expr_keep(a, b) { let c = a + b; return c * c }
+				export function twice_keep(a) { let c = a * 2; console.log(c) }
+				export function usesThis_keep(a) { let c = this; console.log(c, a) }
+				export function usesArgs_keep(a) { let c = arguments; console.log(c, a) }
+				export function recursive_keep(a) { if (a) recursive_keep(a - 1); console.log(a) }
+				export function usesVar_keep(a) { var c = a; console.log(c) }
+				export function earlyReturn_keep(a) { for (let b of a) if (b) return b; console.log(a) }
+				export function usesImport_keep(a) { console.log(dep, a) }
+				export function nested_keep(a) { [a].forEach(b => console.log(b)) }
+			`,
+			"/dep.js": `
+				export let dep = 1
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:            config.ModeBundle,
+			AbsOutputFile:   "/out.js",
+			MinifySyntax:    true,
+			InlineFunctions: true,
+		},
+	})
+}
+
+// The local variables of an inlined function body must not be given the same
+// names as top-level symbols, even if nothing else from its file is included
+func TestInlineSmallFunctionCallsMultipleStatementsMinifyIdentifiers(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { once } from './lib'
+				let x = 1, y = 2
+				once(x)
+				console.log(x, y)
+			`,
+			"/lib.js": `
+				export function once(a) {
+					let b = [a, a]
+					for (let c of b) console.log(b, c)
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:              config.ModeBundle,
+			AbsOutputFile:     "/out.js",
+			MinifySyntax:      true,
+			MinifyIdentifiers: true,
+			InlineFunctions:   true,
+		},
+	})
+}
+
+func TestInlineSmallFunctionCallsBudget(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				function add_keep(a, b) { return a + b }
+				function not_REMOVE(a) { return !a }
+				console.log(add_keep(1, 2), add_keep(3, 4), not_REMOVE(5), not_REMOVE(6))
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:            config.ModeBundle,
+			AbsOutputFile:   "/out.js",
+			MinifySyntax:    true,
+			InlineFunctions: true,
+			InlineBudget:    2,
+		},
+	})
+}
+
+func TestCollapsePropertiesAcrossFiles(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import * as ns from './lib'
+				import { Utils, escapesBare, escapesComputed, escapesAssign, escapesThis, escapesIntermediate, escapesOptional, escapesName } from './lib'
+				console.log(Utils.string.pad('x', 3), Utils.string.upper('y'), Utils.VERSION, ns.Config.debug)
+				console.log(escapesBare, escapesComputed[Math.random() < 0.5 ? 'a' : 'b'], escapesThis.get())
+				escapesAssign.a = 2
+				console.log(escapesIntermediate.nested, escapesIntermediate.nested.a, escapesOptional?.a)
+				console.log(escapesName.fn(), escapesName.fn.name, Utils.string.pad.length)
+			`,
+			"/lib.js": `
+				export const Utils = {
+					string: {
+						pad: (s, n) => s.padStart(n),
+						upper: s => s.toUpperCase(),
+						unused: s => s,
+					},
+					VERSION: 1,
+					sideEffect: foo(),
+				}
+				export const Config = { debug: false }
+				export const escapesBare = { a: 1 }
+				export const escapesComputed = { a: 1, b: 2 }
+				export const escapesAssign = { a: 1 }
+				export const escapesThis = { get: function() { return this } }
+				export const escapesIntermediate = { nested: { a: 1 } }
+				export const escapesOptional = { a: 1 }
+				export const escapesName = { fn: () => 1 }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:               config.ModeBundle,
+			AbsOutputFile:      "/out.js",
+			MinifySyntax:       true,
+			CollapseProperties: true,
+		},
+	})
+}
+
+func TestCollapsePropertiesEntryPointExports(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { collapsed } from './lib'
+				export const exported = { a: 1 }
+				console.log(collapsed.a, exported.a)
+			`,
+			"/lib.js": `
+				export const collapsed = { a: 1 }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:               config.ModeBundle,
+			AbsOutputFile:      "/out.js",
+			OutputFormat:       config.FormatESModule,
+			MinifySyntax:       true,
+			CollapseProperties: true,
+		},
+	})
+}
+
+func TestCollapsePropertiesCodeSplitting(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/a.js": `
+				import { Shared } from './shared'
+				console.log(Shared.fn(), Shared.value)
+			`,
+			"/b.js": `
+				import { Shared } from './shared'
+				console.log(Shared.fn())
+			`,
+			"/shared.js": `
+				export const Shared = { fn: () => 1, value: 2 }
+			`,
+		},
+		entryPaths: []string{"/a.js", "/b.js"},
+		options: config.Options{
+			Mode:               config.ModeBundle,
+			AbsOutputDir:       "/out",
+			OutputFormat:       config.FormatESModule,
+			CodeSplitting:      true,
+			MinifySyntax:       true,
+			CollapseProperties: true,
+		},
+	})
+}
+
 func TestConstValueInliningNoBundle(t *testing.T) {
 	dce_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -3027,6 +3282,90 @@ func TestConstValueInliningBundle(t *testing.T) {
 	})
 }
 
+func TestConstValueInliningBundleAfterImport(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				import { FLAG_REMOVE, NAME_keep, Colors_REMOVE, Frozen_keep, Mutated_keep } from './constants'
+				import * as ns from './constants'
+				import { cycle_keep } from './cycle'
+				console.log(FLAG_REMOVE, NAME_keep, { FLAG_REMOVE })
+				console.log(Colors_REMOVE.Red, Colors_REMOVE['Green'], ns.Colors_REMOVE.Red)
+				console.log(Frozen_keep.a, Frozen_keep.b, Frozen_keep.c)
+				Mutated_keep.a = 2 // This prevents inlining into this file
+				console.log(Mutated_keep.a, cycle_keep)
+			`,
+			"/constants.ts": `
+				import { other } from './other'
+				other() // This ends the const local prefix
+				export const FLAG_REMOVE = 1
+				export const NAME_keep = 'strings are not inlined'
+				export const Colors_REMOVE = { Red: 0, Green: 'green' } as const
+				export const Frozen_keep = Object.freeze({ a: 1, b: true, c: 'c' })
+				export const Mutated_keep = Object.freeze({ a: 1 })
+			`,
+			"/other.ts": `
+				export function other() {}
+			`,
+			"/cycle.ts": `
+				import './cycle-2'
+				export const cycle_keep = 1 // Inlining should be prevented by the cycle
+			`,
+			"/cycle-2.ts": `
+				import { cycle_keep } from './cycle'
+				console.log(cycle_keep)
+			`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			MinifySyntax:  true,
+		},
+	})
+}
+
+// "as const" doesn't freeze the object at run-time, so it must not be inlined
+// if any code could mutate it
+func TestConstValueInliningConstAssertedMutation(t *testing.T) {
+	dce_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				import { Mutated_keep, Escaped_keep, Assigned_keep, Computed_keep, Unmutated_REMOVE } from './constants'
+				import { mutate, assign } from './mutate'
+				mutate(Escaped_keep)
+				assign()
+				console.log(Mutated_keep.Red, Escaped_keep.Red, Assigned_keep.Red, Computed_keep.Red, Unmutated_REMOVE.Red)
+			`,
+			"/constants.ts": `
+				import { other } from './other'
+				other() // This ends the const local prefix
+				export const Mutated_keep = { Red: 0 } as const
+				export const Escaped_keep = { Red: 0 } as const
+				export const Assigned_keep = { Red: 0 } as const
+				export const Computed_keep = { Red: 0 } as const
+				export const Unmutated_REMOVE = { Red: 0 } as const
+				;(Mutated_keep as any).Red = 1
+				;(Computed_keep as any)[other()] = 1
+			`,
+			"/mutate.ts": `
+				import { Assigned_keep } from './constants'
+				export function mutate(o: any) { o.Red = 1 }
+				export function assign() { (Assigned_keep as any).Red = 1 }
+			`,
+			"/other.ts": `
+				export function other() {}
+			`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			MinifySyntax:  true,
+		},
+	})
+}
+
 // Assignment to an inlined constant is not allowed since that would cause a
 // syntax error in the output. We don't just keep the reference there because
 // the declaration may actually have been completely removed already by the
@@ -622,6 +622,145 @@ NOTE: The esbuild loader for this file is currently set to "js" but it must be s
 	})
 }
 
+func TestJSXConstantAndInlineElements(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.jsx": `
+				import { Header } from './header'
+				export function App({ items }) {
+					return <main>
+						<Header title="Items" />
+						<ul>{items.map(item => <li key={item.id}>{item.name}</li>)}</ul>
+					</main>
+				}
+			`,
+			"/header.jsx": `
+				export function Header({ title }) {
+					return <header><h1>{title}</h1><hr /></header>
+				}
+				export function Unused() {
+					return <footer><p>Unused</p></footer>
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.jsx"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			JSX: config.JSXOptions{
+				AutomaticRuntime: true,
+				ConstantElements: true,
+				InlineElements:   true,
+			},
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"react/jsx-runtime": true,
+				}},
+			},
+		},
+	})
+}
+
+func TestJSXSolid(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.jsx": `
+				import { Header } from './header'
+				export function App(props) {
+					return <main class="app">
+						<Header title="Items" />
+						<ul>{props.items.map(item => <li onClick={() => props.select(item)}>{item.name}</li>)}</ul>
+					</main>
+				}
+			`,
+			"/header.jsx": `
+				export function Header(props) {
+					return <header><h1>{props.title}</h1><hr /></header>
+				}
+				export function Unused() {
+					return <footer><p>Unused</p></footer>
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.jsx"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			JSX: config.JSXOptions{
+				Target: config.JSXTargetSolid,
+			},
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"solid-js/web": true,
+				}},
+			},
+		},
+	})
+}
+
+func TestJSXVue(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.jsx": `
+				import { Item } from './item'
+				export const List = (props) => <ul class={props.class}>{props.items.map(item => <Item key={item.id} {...item} />)}</ul>
+			`,
+			"/item.jsx": `
+				export const Item = (props) => <li>{props.name}</li>
+				export const Unused = () => <p>Unused</p>
+			`,
+		},
+		entryPaths: []string{"/entry.jsx"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			JSX: config.JSXOptions{
+				Target: config.JSXTargetVue,
+			},
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"vue": true,
+				}},
+			},
+		},
+	})
+}
+
+func TestJSXVuePatchFlags(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.jsx": `
+				export const Counter = (props) => (
+					<div class={props.class} ref={props.el}>
+						<span>{props.count * 2}</span>
+						<span>Count: {props.count}!</span>
+						<span title={props.title}>{` + "`" + `${props.first} ${props.last}` + "`" + `}</span>
+						<span>{props.child}</span>
+						<span {...props}>{typeof props.count}</span>
+					</div>
+				)
+			`,
+		},
+		entryPaths: []string{"/entry.jsx"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatESModule,
+			AbsOutputFile: "/out.js",
+			JSX: config.JSXOptions{
+				Target: config.JSXTargetVue,
+			},
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"vue": true,
+				}},
+			},
+		},
+	})
+}
+
 func TestNodeModules(t *testing.T) {
 	default_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -3261,6 +3400,62 @@ func TestImportMetaNoBundle(t *testing.T) {
 	})
 }
 
+func TestImportMetaCommonJSNode(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { dir } from './dir.js'
+				console.log(import.meta.url, import.meta.filename, dir, import.meta.resolve('./dir.js'))
+			`,
+			"/dir.js": `
+				export const dir = import.meta.dirname
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			Platform:      config.PlatformNode,
+			OutputFormat:  config.FormatCommonJS,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestImportMetaCommonJSNodeNoBundle(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				var __filename = import.meta.filename
+				console.log(import.meta.url, __filename)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeConvertFormat,
+			Platform:      config.PlatformNode,
+			OutputFormat:  config.FormatCommonJS,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestImportMetaIIFEBrowser(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				console.log(import.meta.url, new URL('./image.png', import.meta.url), import.meta.resolve('./image.png'))
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			Platform:      config.PlatformBrowser,
+			OutputFormat:  config.FormatIIFE,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
 func TestLegalCommentsNone(t *testing.T) {
 	default_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -3816,9 +4011,6 @@ func TestTopLevelAwaitIIFE(t *testing.T) {
 			OutputFormat:  config.FormatIIFE,
 			AbsOutputFile: "/out.js",
 		},
-		expectedScanLog: `entry.js: ERROR: Top-level await is currently not supported with the "iife" output format
-entry.js: ERROR: Top-level await is currently not supported with the "iife" output format
-`,
 	})
 }
 
@@ -3853,9 +4045,6 @@ func TestTopLevelAwaitCJS(t *testing.T) {
 			OutputFormat:  config.FormatCommonJS,
 			AbsOutputFile: "/out.js",
 		},
-		expectedScanLog: `entry.js: ERROR: Top-level await is currently not supported with the "cjs" output format
-entry.js: ERROR: Top-level await is currently not supported with the "cjs" output format
-`,
 	})
 }
 
@@ -3876,6 +4065,96 @@ func TestTopLevelAwaitCJSDeadBranch(t *testing.T) {
 	})
 }
 
+func TestTopLevelAwaitCJSTransitive(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { value } from './a.js'
+				import { other } from './c.js'
+				export const result = value + other
+			`,
+			"/a.js": `
+				import { b } from './b.js'
+				export const value = await Promise.resolve(b + 1)
+			`,
+			"/b.js": `
+				export const b = 1
+			`,
+			"/c.js": `
+				export const other = 10
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatCommonJS,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestTopLevelAwaitIIFETransitiveGlobalName(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { value } from './a.js'
+				export const result = value * 2
+			`,
+			"/a.js": `
+				export const value = await Promise.resolve(1)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			OutputFormat:  config.FormatIIFE,
+			GlobalName:    []string{"lib"},
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestTopLevelAwaitCJSNoAsyncFunctions(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { value } from './a.js'
+				for await (const x of y) z(x)
+				export const result = value * 2
+			`,
+			"/a.js": `
+				export const value = await Promise.resolve(1)
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatCommonJS,
+			UnsupportedJSFeatures: es(2016),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestTopLevelAwaitIIFENoGenerators(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				await foo;
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatIIFE,
+			UnsupportedJSFeatures: es(5),
+			AbsOutputFile:         "/out.js",
+		},
+		expectedScanLog: `entry.js: ERROR: Top-level await with the "iife" output format requires generator functions, which are not available in the configured target environment
+`,
+	})
+}
+
 func TestTopLevelAwaitESM(t *testing.T) {
 	default_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -7855,6 +8134,75 @@ NOTE: You can either keep the import assertion and only use the "default" import
 	})
 }
 
+func TestImportAttributesTypeSelectsLoader(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import json from './data.js' with { type: 'json' }
+				import text from './data.js' with { type: 'text' }
+				import bytes from './data.js' with { type: 'bytes' }
+				import ext from 'ext' with { type: 'json' }
+				console.log(json, text, bytes, ext, import('ext', { with: { type: 'json' } }))
+			`,
+			"/data.js": `[1, 2, 3]`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"ext": true,
+				}},
+			},
+		},
+	})
+}
+
+func TestImportAttributesUnsupportedTarget(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import ext1 from 'ext' with { type: 'json' }
+				import ext2 from 'ext' assert { type: 'json' }
+				console.log(ext1, ext2, import('ext', { with: { type: 'json' } }))
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			AbsOutputFile:         "/out.js",
+			UnsupportedJSFeatures: compat.ImportAttributes,
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"ext": true,
+				}},
+			},
+		},
+	})
+}
+
+func TestImportAttributesUnsupportedType(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import a from './foo.js' with { type: 'css' }
+				import b from './foo.js' with { kind: 'json' }
+				console.log(a, b)
+			`,
+			"/foo.js": `export default 123`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+		expectedScanLog: `entry.js: ERROR: Importing with a type attribute of "css" is not supported
+entry.js: ERROR: Importing with the "kind" attribute is not supported
+`,
+	})
+}
+
 func TestExternalPackages(t *testing.T) {
 	default_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -8418,6 +8766,47 @@ func TestLineLimitMinified(t *testing.T) {
 	})
 }
 
+func TestFormatting(t *testing.T) {
+	default_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				'use strict'
+				import './a.js'
+				import './b.js'
+				import { longFunctionName } from './c.js'
+				longFunctionName(['first item', 'second item'], { key: "value" }, 'last argument')
+			`,
+			"/a.js": `
+				console.log('a')
+			`,
+			"/b.js": `
+				;[1, 2].forEach(x => console.log(x))
+			`,
+			"/c.js": `
+				export function longFunctionName(firstParameter, secondParameter, thirdParameter) {
+					return [firstParameter, secondParameter, thirdParameter]
+				}
+			`,
+			"/style.css": `
+				a { content: "b"; @media (x) { color: red } }
+			`,
+		},
+		entryPaths: []string{"/entry.js", "/style.css"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			OutputFormat: config.FormatIIFE,
+			AbsOutputDir: "/out",
+			Formatting: config.Formatting{
+				UseTabs:        true,
+				Quotes:         config.QuotesSingle,
+				TrailingCommas: config.TrailingCommasAll,
+				Semicolons:     config.SemicolonsASI,
+				PrintWidth:     60,
+			},
+		},
+	})
+}
+
 func TestBadImportErrorMessageWithHandlesImportErrorsFlag(t *testing.T) {
 	default_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -1537,3 +1537,78 @@ func TestLoaderCopyWithInjectedFileBundle(t *testing.T) {
 		},
 	})
 }
+
+func TestLoaderFlowPragma(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				// @flow
+				import type { Props } from './types'
+				import { render } from './render'
+				export default function app(props: Props): string {
+					return render((props: any))
+				}
+			`,
+			"/render.jsx": `
+				/* @flow strict */
+				export type Element = Object
+				export function render(props: Object): Element {
+					return <div {...props} />
+				}
+			`,
+			"/types.js": `
+				// @flow
+				export type Props = {| name: string |}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestLoaderFlowPragmaJSXInJS(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				// @flow
+				import type { Node } from 'react'
+				const identity = <T>(x: T): T => x
+				const wrap = <T>(x: T) => [x]
+				export default function App(): Node {
+					return <div title={identity('x')}>(wrapped) {wrap(1)}</div>
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestLoaderFlowExplicit(t *testing.T) {
+	loader_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js.flow": `
+				class Foo {
+					+x: number;
+					y: ?string = null;
+					static create(): Foo { return new Foo() }
+				}
+				export default (Foo.create(): Foo)
+			`,
+		},
+		entryPaths: []string{"/entry.js.flow"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			ExtensionToLoader: map[string]config.Loader{
+				".flow": config.LoaderFlow,
+			},
+		},
+	})
+}
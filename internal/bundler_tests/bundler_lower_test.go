@@ -891,17 +891,57 @@ func TestLowerAsyncES5(t *testing.T) {
 				import './fn-expr'
 				import './arrow-1'
 				import './arrow-2'
-				import './export-def-1'
-				import './export-def-2'
-				import './obj-method'
-			`,
-			"/fn-stmt.js":      `async function foo() {}`,
-			"/fn-expr.js":      `(async function() {})`,
-			"/arrow-1.js":      `(async () => {})`,
-			"/arrow-2.js":      `(async x => {})`,
+				import def1 from './export-def-1'
+				import def2 from './export-def-2'
+				import './generator'
+				use(def1, def2)
+			`,
+			"/fn-stmt.js":      `async function foo() { await 1 } use(foo)`,
+			"/fn-expr.js":      `use(async function() { await 2 })`,
+			"/arrow-1.js":      `use(async () => { await 3 })`,
+			"/arrow-2.js":      `use(async x => { await x })`,
 			"/export-def-1.js": `export default async function foo() {}`,
 			"/export-def-2.js": `export default async function() {}`,
-			"/obj-method.js":   `({async foo() {}})`,
+			"/generator.js":    `use(function* () { yield* [] })`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			UnsupportedJSFeatures: es(5),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerClassES5(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import Base from './base'
+				import { Derived } from './derived'
+				import './expr'
+				use(Base, Derived)
+			`,
+			"/base.js": `
+				export default class {
+					constructor(x) { this.x = x }
+					get value() { return this.x }
+					static create() { return new this(1) }
+				}
+			`,
+			"/derived.js": `
+				import Base from './base'
+				export class Derived extends Base {
+					y = 2
+					constructor() { super(0); this.z = () => this.y }
+					get value() { return super.value + 1 }
+					static create() { return super.create() }
+				}
+			`,
+			"/expr.js": `
+				use(class extends Error {})
+				use(class Foo { [key]() { return Foo } })
+			`,
 		},
 		entryPaths: []string{"/entry.js"},
 		options: config.Options{
@@ -909,14 +949,6 @@ func TestLowerAsyncES5(t *testing.T) {
 			UnsupportedJSFeatures: es(5),
 			AbsOutputFile:         "/out.js",
 		},
-		expectedScanLog: `arrow-1.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-arrow-2.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-export-def-1.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-export-def-2.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-fn-expr.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-fn-stmt.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-obj-method.js: ERROR: Transforming async functions to the configured target environment is not supported yet
-`,
 	})
 }
 
@@ -2423,6 +2455,59 @@ func TestJavaScriptDecoratorsESNext(t *testing.T) {
 	})
 }
 
+func TestLowerJavaScriptDecorators(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				@x.y()
+				@(new y.x)
+				export default class Foo {
+					@x @y mUndef
+					@x @y mDef = 1
+					@x @y method() { return new Foo }
+					@x @y static sUndef
+					@x @y static sDef = new Foo
+					@x @y static sMethod() { return new Foo }
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModePassThrough,
+			AbsOutputFile:         "/out.js",
+			UnsupportedJSFeatures: compat.Decorators,
+		},
+	})
+}
+
+func TestLowerJavaScriptDecoratorsPrivate(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				class Foo {
+					@dec #method() {}
+					@dec get #getter() { return 1 }
+					@dec set #setter(x) {}
+					@dec #field = 1
+					@dec accessor #accessor = 2
+					@dec static #staticMethod() {}
+					@dec static accessor #staticAccessor = 3
+					@dec [computed()] = 4
+				}
+				const Bar = @dec class extends Foo {
+					@dec accessor one = 1
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModePassThrough,
+			AbsOutputFile:         "/out.js",
+			UnsupportedJSFeatures: compat.Decorators,
+		},
+	})
+}
+
 func TestJavaScriptAutoAccessorESNext(t *testing.T) {
 	lower_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -3162,3 +3247,171 @@ func TestLowerAsyncGeneratorNoAwait(t *testing.T) {
 		},
 	})
 }
+
+func TestLowerLetAndConst(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { fns } from './foo'
+				let x = 1
+				{
+					let x = 2
+					fns.push(() => x)
+				}
+				for (let i = 0; i < 3; i++) {
+					const x = i * 2
+					fns.push(() => i + x)
+				}
+				export { x }
+			`,
+			"/foo.js": `
+				export const fns = []
+				for (const fn of fns) {
+					let x = fn()
+					if (x) break
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			OutputFormat:          config.FormatESModule,
+			AbsOutputFile:         "/out.js",
+			UnsupportedJSFeatures: compat.ConstAndLet,
+		},
+	})
+}
+
+func TestLowerDestructuringAndSpreadES5(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { swap } from './swap'
+				function f(a, [b, c] = [], { d, ...e } = {}, ...f) {
+					return [a, b, c, d, e, ...f]
+				}
+				for (const [x, y] of swap([1, 2])) console.log(x, y)
+				console.log(f(...swap([3, 4])), { [f]: 1, g() {} })
+			`,
+			"/swap.js": `
+				export let swap = ([a, b]) => [b, a]
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			UnsupportedJSFeatures: es(5),
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerDestructuringAndSpreadLooseIterationES5(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				let [a, ...b] = c
+				for (const [x, y] of b) console.log(x, y)
+				console.log([a, ...b], f(...b))
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			UnsupportedJSFeatures: es(5),
+			LooseIteration:        true,
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerRegExpES5(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				let date = /(?<year>\d{4})-(?<month>\d{2})/
+				let emoji = /^[😀-😂]+.$/su
+				let ogham = /\p{Script=Ogham}+/u
+				let unused = /(?<unused>x)/
+				console.log(date.exec(x).groups.year, emoji.test(x), ogham.test(x))
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                  config.ModeBundle,
+			UnsupportedJSFeatures: es(5),
+			MinifySyntax:          true,
+			AbsOutputFile:         "/out.js",
+		},
+	})
+}
+
+func TestLowerPolyfillNoBundle(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				console.log([1, 2].at(-1), str?.at(0))
+				console.log(Object.hasOwn(obj, 'key'), structuredClone(obj))
+				console.log(str.padStart(3), Object.entries(obj))
+				if (false) console.log(str.replaceAll('a', 'b'))
+				function shadowed(Object, structuredClone) {
+					return [Object.fromEntries(obj), structuredClone(obj)]
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			UnsupportedBuiltIns: compat.ArrayPrototypeAt | compat.StringPrototypeAt | compat.ObjectHasOwn |
+				compat.ObjectFromEntries | compat.StringPrototypeReplaceAll | compat.StructuredClone,
+			Polyfill:      "core-js",
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
+func TestLowerPolyfillBundle(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				import { last } from './last.js'
+				console.log(last([1, 2, 3]), globalThis.foo)
+			`,
+			"/last.js": `
+				export const last = array => array.at(-1)
+			`,
+			"/node_modules/core-js/modules/es.array.at.js": `
+				if (!Array.prototype.at) Array.prototype.at = function (i) { return this[i < 0 ? this.length + i : i] }
+			`,
+			"/node_modules/core-js/modules/es.string.at-alternative.js": `
+				if (!String.prototype.at) String.prototype.at = function (i) { return this.charAt(i < 0 ? this.length + i : i) }
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                config.ModeBundle,
+			UnsupportedBuiltIns: compat.ArrayPrototypeAt | compat.StringPrototypeAt,
+			Polyfill:            "core-js",
+			AbsOutputFile:       "/out.js",
+		},
+	})
+}
+
+func TestLowerPolyfillMissingPackage(t *testing.T) {
+	lower_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.js": `
+				console.log(Object.hasOwn(obj, 'key'))
+			`,
+		},
+		entryPaths: []string{"/entry.js"},
+		options: config.Options{
+			Mode:                config.ModeBundle,
+			UnsupportedBuiltIns: compat.ObjectHasOwn,
+			Polyfill:            "core-js",
+			AbsOutputFile:       "/out.js",
+		},
+		expectedScanLog: `entry.js: ERROR: Could not resolve "core-js/modules/es.object.has-own.js"
+NOTE: You can mark the path "core-js/modules/es.object.has-own.js" as external to exclude it from the bundle, which will remove this error.
+`,
+	})
+}
@@ -1202,6 +1202,39 @@ func TestTSExperimentalDecoratorsKeepNames(t *testing.T) {
 	})
 }
 
+func TestTSEmitDecoratorMetadata(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				import { Injectable } from './di'
+				import { Logger, Options } from './logger'
+				@Injectable()
+				export class Service {
+					constructor(private logger: Logger, private options: Options) {}
+				}
+			`,
+			"/di.ts": `
+				export const Injectable = () => (target: any) => target
+			`,
+			"/logger.ts": `
+				export class Logger {}
+				export interface Options {}
+			`,
+			"/tsconfig.json": `{
+				"compilerOptions": {
+					"experimentalDecorators": true,
+					"emitDecoratorMetadata": true
+				}
+			}`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+	})
+}
+
 // See: https://github.com/evanw/esbuild/issues/2147
 func TestTSExperimentalDecoratorScopeIssue2147(t *testing.T) {
 	ts_suite.expectBundled(t, bundled{
@@ -2890,3 +2923,370 @@ func TestTSExperimentalDecoratorsManglePropsStaticMethods(t *testing.T) {
 		},
 	})
 }
+
+func TestTSDeclarations(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				import { Options } from './types'
+				export { Options }
+				export const version: string = '1.0.0'
+				export function parse(input: string, options?: Options): Result { return { ok: true } }
+				interface Result { ok: boolean }
+				interface Unused {}
+			`,
+			"/types.ts": `
+				export interface Options { strict?: boolean }
+			`,
+			"/other.mts": `
+				export default class Foo { x: number = 1 }
+			`,
+		},
+		entryPaths: []string{"/entry.ts", "/other.mts"},
+		options: config.Options{
+			Mode:              config.ModeBundle,
+			AbsOutputDir:      "/out",
+			Declarations:      true,
+			NeedsMetafile:     true,
+			OutputExtensionJS: ".mjs",
+		},
+	})
+}
+
+func TestTSDeclarationsError(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				export function add(a: number, b: number) { return a + b }
+				export const value = compute()
+				function compute(): number { return 1 }
+			`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			Declarations:  true,
+		},
+		expectedScanLog: `entry.ts: ERROR: Function must have an explicit return type annotation when generating declarations
+entry.ts: ERROR: Variable must have an explicit type annotation when generating declarations
+`,
+	})
+}
+
+func TestTSDeclarationsRollUp(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.d.ts": `
+				import { Config } from './config'
+				import * as util from './util'
+				import type { Request } from 'http'
+				export * from './shapes'
+				export { Config, util }
+				export interface Handler { (req: Request, config: Config): void }
+			`,
+			"/config.d.ts": `
+				interface Options { debug: boolean }
+				export interface Config { options: Options }
+				export interface Unused { options: Options }
+			`,
+			"/util.d.ts": `
+				interface Options { indent: number }
+				export declare function format(value: unknown, options?: Options): string
+			`,
+			"/shapes.d.ts": `
+				export declare class Circle { radius: number }
+				export * from 'geometry'
+			`,
+		},
+		entryPaths: []string{"/entry.d.ts"},
+		options: config.Options{
+			Mode:         config.ModeBundle,
+			AbsOutputDir: "/out",
+			Declarations: true,
+		},
+	})
+}
+
+func TestTSDeclarationsRollUpTSConfigPaths(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/entry.ts": `
+				import type { Theme } from '@lib/theme'
+				export function render(theme: Theme): void {}
+			`,
+			"/Users/user/project/lib/theme.d.ts": `
+				export interface Theme { color: string }
+			`,
+			"/Users/user/project/tsconfig.json": `{
+				"compilerOptions": {
+					"paths": { "@lib/*": ["./lib/*"] }
+				}
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+			Declarations:  true,
+		},
+	})
+}
+
+func TestTSDeclarationsRollUpPackageTypes(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/entry.ts": `
+				import type { A } from 'pkg-a'
+				import type { B } from 'pkg-b'
+				import type { C } from 'pkg-c'
+				export type All = A | B | C
+			`,
+			"/Users/user/project/node_modules/pkg-a/package.json": `{ "main": "index.js", "types": "types/index.d.ts" }`,
+			"/Users/user/project/node_modules/pkg-a/index.js":     `module.exports = {}`,
+			"/Users/user/project/node_modules/pkg-a/types/index.d.ts": `
+				export interface A { a: string }
+			`,
+			"/Users/user/project/node_modules/pkg-b/package.json": `{
+				"exports": { ".": { "types": "./b.d.ts", "default": "./b.js" } }
+			}`,
+			"/Users/user/project/node_modules/pkg-b/b.js": `export {}`,
+			"/Users/user/project/node_modules/pkg-b/b.d.ts": `
+				export interface B { b: string }
+			`,
+			"/Users/user/project/node_modules/pkg-c/package.json": `{ "main": "c.js" }`,
+			"/Users/user/project/node_modules/pkg-c/c.js":         `export {}`,
+			"/Users/user/project/node_modules/pkg-c/c.d.ts": `
+				export interface C { c: string }
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+			Declarations:  true,
+		},
+	})
+}
+
+func TestTSDeclarationsRollUpInlinePackageTypes(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/entry.ts": `
+				import type { A } from 'pkg-a'
+				import type { B } from 'pkg-b'
+				import type { Sub } from '@workspace/pkg-c/sub'
+				import type { D } from 'pkg-d'
+				export type All = A | B | Sub | D
+			`,
+			"/Users/user/project/node_modules/pkg-a/package.json": `{ "main": "index.js", "types": "types/index.d.ts" }`,
+			"/Users/user/project/node_modules/pkg-a/index.js":     `module.exports = {}`,
+			"/Users/user/project/node_modules/pkg-a/types/index.d.ts": `
+				export interface A { a: string }
+			`,
+			"/Users/user/project/node_modules/pkg-b/package.json": `{
+				"exports": { ".": { "types": "./types/b.d.ts", "default": "./b.js" } }
+			}`,
+			"/Users/user/project/node_modules/pkg-b/b.js": `export {}`,
+			"/Users/user/project/node_modules/pkg-b/types/b.d.ts": `
+				export interface B { b: string }
+			`,
+			"/Users/user/project/node_modules/@workspace/pkg-c/package.json": `{
+				"exports": { "./sub": { "types": "./types/sub.d.ts", "import": "./dist/sub.js" } }
+			}`,
+			"/Users/user/project/node_modules/@workspace/pkg-c/dist/sub.js": `export {}`,
+			"/Users/user/project/node_modules/@workspace/pkg-c/types/sub.d.ts": `
+				export interface Sub { sub: string }
+			`,
+			"/Users/user/project/node_modules/pkg-d/package.json": `{ "types": "d.d.ts" }`,
+			"/Users/user/project/node_modules/pkg-d/d.d.ts": `
+				export interface D { d: string }
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+			Declarations:  true,
+			DeclarationsInline: config.ExternalMatchers{
+				Exact:    map[string]bool{"pkg-a": true, "pkg-b": true},
+				Patterns: []config.WildcardPattern{{Prefix: "pkg-a/"}, {Prefix: "pkg-b/"}, {Prefix: "@workspace/"}},
+			},
+		},
+	})
+}
+
+func TestTSDeclarationsEntryPointWithoutDeclarations(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.d.ts": `
+				export interface Foo { foo: string }
+			`,
+		},
+		entryPaths: []string{"/entry.d.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+		},
+		expectedScanLog: `ERROR: The entry point "entry.d.ts" is a declaration file, which doesn't contain any code
+NOTE: Enable the "declarations" setting to generate a declaration file from this entry point instead.
+`,
+	})
+}
+
+func TestTSDeclarationsRollUpPackageTypesWithPaths(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/entry.ts": `
+				import type { A } from '@lib/a'
+				import type { B } from 'pkg-b'
+				export type All = A | B
+			`,
+			"/Users/user/project/tsconfig.json": `{
+				"compilerOptions": {
+					"paths": { "@lib/*": ["./packages/*"] }
+				}
+			}`,
+			"/Users/user/project/packages/a/package.json": `{ "main": "index.js", "types": "types/index.d.ts" }`,
+			"/Users/user/project/packages/a/index.js":     `module.exports = {}`,
+			"/Users/user/project/packages/a/types/index.d.ts": `
+				export interface A { a: string }
+			`,
+			"/Users/user/project/node_modules/pkg-b/package.json": `{ "types": "b.d.ts" }`,
+			"/Users/user/project/node_modules/pkg-b/b.d.ts": `
+				export interface B { b: string }
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+			Declarations:  true,
+		},
+	})
+}
+
+func TestTSDeclarationsRollUpDefaultAndCollisions(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				import Logger, { type Level as LoggerLevel } from './logger'
+				import React from 'react'
+				export interface Level { name: string }
+				export function create(level: Level | LoggerLevel): Logger { return new Logger }
+				export function render(): React.ReactNode { return null }
+			`,
+			"/logger.ts": `
+				export type Level = 'info' | 'error'
+				export default class { log(level: Level): void {} }
+			`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			Declarations:  true,
+			ExternalSettings: config.ExternalSettings{
+				PreResolve: config.ExternalMatchers{Exact: map[string]bool{
+					"react": true,
+				}},
+			},
+		},
+	})
+}
+
+func TestTSManglePrivate(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				import { Base, readLabel } from './base'
+				export class Foo extends Base {
+					useBeforeDeclare() { return this.count + this.helper() }
+					private count = 0
+					protected label = 'foo'
+					#secret = 1
+					constructor(private readonly value: number, public other: number) { super() }
+					private helper() { return this.value + this.#secret + this.shared }
+					private static create() { return new Foo(1, 2) }
+					private keep_ = 0
+					private 'quoted' = 0
+					private ['computed'] = 0
+				}
+				console.log(readLabel(new Foo(1, 2)))
+			`,
+			"/base.ts": `
+				export class Base {
+					protected shared = 1
+					#secret = 2
+					get secret() { return this.#secret }
+				}
+				export function readLabel(x: any) { return x.label }
+			`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/out.js",
+			ManglePrivate: true,
+			ReserveProps:  regexp.MustCompile("_$"),
+		},
+	})
+}
+
+func TestTSManglePrivateOnlyThisAccesses(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				export class Foo {
+					private parsed = 0
+					protected other = 1
+					private quoted = 2
+					private nested = 3
+					private inBar = 4
+					constructor(private param: number) {}
+					method(s: string, other: Foo) {
+						const arrow = () => this.param + super.toString()
+						function fn(this: any) { return this.nested }
+						return arrow() + JSON.parse(s).parsed + other.other + this['quoted'] + fn.call(this)
+					}
+				}
+				export class Bar {
+					inBar = 5
+					get() { return this.inBar }
+				}
+				export class Baz {
+					private renamed = 0
+					method() { return this.renamed + this?.renamed }
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModePassThrough,
+			AbsOutputFile: "/out.js",
+			ManglePrivate: true,
+		},
+	})
+}
+
+func TestTSManglePrivateTransform(t *testing.T) {
+	ts_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/entry.ts": `
+				export class Foo {
+					private a = 0
+					private b = 1
+					protected visible = 2
+					constructor() { console.log(this.a, this.b, this.visible, this.c) }
+					c() { return { a: 1 } }
+				}
+			`,
+		},
+		entryPaths: []string{"/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModePassThrough,
+			AbsOutputFile: "/out.js",
+			ManglePrivate: true,
+		},
+	})
+}
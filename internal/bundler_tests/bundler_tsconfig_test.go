@@ -2327,6 +2327,48 @@ func TestTsconfigVerbatimModuleSyntaxFalse(t *testing.T) {
 	})
 }
 
+func TestTsconfigErasableSyntaxOnly(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/entry.ts": `
+				import { Config } from "./config"
+				import { legacy } from "./legacy/legacy"
+				enum Color { Red, Green }
+				export class Foo {
+					constructor(private config: Config) {}
+				}
+				console.log(Color, legacy)
+			`,
+			"/Users/user/project/src/config.ts": `
+				export interface Config {}
+			`,
+			"/Users/user/project/src/legacy/legacy.ts": `
+				export namespace legacy { export let x = 1 }
+			`,
+			"/Users/user/project/src/legacy/tsconfig.json": `{
+				"extends": "../../tsconfig.json",
+				"compilerOptions": {
+					"erasableSyntaxOnly": false
+				}
+			}`,
+			"/Users/user/project/tsconfig.json": `{
+				"compilerOptions": {
+					"erasableSyntaxOnly": true
+				}
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/src/entry.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+		expectedScanLog: `Users/user/project/src/entry.ts: ERROR: "Config" is only used as a type and must be imported using a type-only import when "erasableSyntaxOnly" is enabled
+Users/user/project/src/entry.ts: ERROR: Enums are not allowed when "erasableSyntaxOnly" is enabled
+Users/user/project/src/entry.ts: ERROR: Parameter properties are not allowed when "erasableSyntaxOnly" is enabled
+`,
+	})
+}
+
 func TestTsconfigExtendsArray(t *testing.T) {
 	tsconfig_suite.expectBundled(t, bundled{
 		files: map[string]string{
@@ -2457,3 +2499,191 @@ func TestTsconfigIgnoreInsideNodeModules(t *testing.T) {
 		},
 	})
 }
+
+func TestTsconfigRootDirs(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/views/main.ts": `
+				import { template } from './template'
+				import { style } from '../styles/main.css'
+				import { local } from './local'
+				console.log(template, style, local)
+			`,
+			"/Users/user/project/src/views/local.ts": `
+				export let local = 'local'
+			`,
+			"/Users/user/project/generated/views/template.ts": `
+				export let template = 'template'
+			`,
+			"/Users/user/project/generated/styles/main.css.ts": `
+				export let style = 'style'
+			`,
+			"/Users/user/project/tsconfig.json": `{
+				"compilerOptions": {
+					"rootDirs": ["src", "./generated"],
+				},
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/src/views/main.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestTsconfigRootDirsExtends(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/app/src/main.ts": `
+				import { template } from './template'
+				console.log(template)
+			`,
+			"/Users/user/project/app/generated/template.ts": `
+				export let template = 'template'
+			`,
+			"/Users/user/project/app/tsconfig.json": `{
+				"extends": "../configs/base.json",
+			}`,
+			"/Users/user/project/configs/base.json": `{
+				"compilerOptions": {
+					"rootDirs": ["../app/src", "../app/generated"],
+				},
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/app/src/main.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestTsconfigModuleSuffixes(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/main.ts": `
+				import { button } from './button'
+				import { text } from './text.js'
+				import { view } from './view'
+				import { platform } from './platform'
+				console.log(button, text, view, platform)
+			`,
+			"/Users/user/project/src/button.ts": `
+				export let button = 'button'
+			`,
+			"/Users/user/project/src/button.ios.ts": `
+				export let button = 'button.ios'
+			`,
+			"/Users/user/project/src/text.native.ts": `
+				export let text = 'text.native'
+			`,
+			"/Users/user/project/src/text.ts": `
+				export let text = 'text'
+			`,
+			"/Users/user/project/src/view.ts": `
+				export let view = 'view'
+			`,
+			"/Users/user/project/src/platform/index.ts": `
+				export let platform = 'platform'
+			`,
+			"/Users/user/project/src/platform/index.ios.ts": `
+				export let platform = 'platform.ios'
+			`,
+			"/Users/user/project/tsconfig.json": `{
+				"compilerOptions": {
+					"moduleSuffixes": [".ios", ".native", ""],
+				},
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/src/main.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
+
+func TestTsconfigModuleSuffixesWithoutEmptySuffix(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/src/main.ts": `
+				import { button } from './button'
+				import { view } from './view'
+				console.log(button, view)
+			`,
+			"/Users/user/project/src/button.ios.ts": `
+				export let button = 'button.ios'
+			`,
+			"/Users/user/project/src/view.ts": `
+				export let view = 'view'
+			`,
+			"/Users/user/project/tsconfig.json": `{
+				"compilerOptions": {
+					"moduleSuffixes": [".ios"],
+				},
+			}`,
+		},
+		entryPaths: []string{"/Users/user/project/src/main.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+		expectedScanLog: `Users/user/project/src/main.ts: ERROR: Could not resolve "./view"
+`,
+	})
+}
+
+func TestTsconfigReferences(t *testing.T) {
+	tsconfig_suite.expectBundled(t, bundled{
+		files: map[string]string{
+			"/Users/user/project/packages/app/src/main.ts": `
+				import { shared } from '@org/shared'
+				import { util } from '../../shared/dist/util.js'
+				import { types } from '../../types/lib/index.js'
+				console.log(shared, util, types)
+			`,
+			"/Users/user/project/packages/app/tsconfig.json": `{
+				"compilerOptions": {
+					"paths": {
+						"@org/shared": ["../shared"],
+					},
+				},
+				"references": [
+					{ "path": "../shared" },
+					{ "path": "../types/tsconfig.lib.json" },
+				],
+			}`,
+			"/Users/user/project/packages/shared/package.json": `{
+				"main": "dist/index.js"
+			}`,
+			"/Users/user/project/packages/shared/tsconfig.json": `{
+				"compilerOptions": {
+					"composite": true,
+					"rootDir": "src",
+					"outDir": "dist",
+				},
+			}`,
+			"/Users/user/project/packages/shared/src/index.ts": `
+				export let shared: string = 'shared'
+			`,
+			"/Users/user/project/packages/shared/src/util.ts": `
+				export let util: string = 'util'
+			`,
+			"/Users/user/project/packages/types/tsconfig.lib.json": `{
+				"compilerOptions": {
+					"composite": true,
+					"outDir": "lib",
+				},
+			}`,
+			"/Users/user/project/packages/types/index.ts": `
+				export let types: string = 'types'
+			`,
+		},
+		entryPaths: []string{"/Users/user/project/packages/app/src/main.ts"},
+		options: config.Options{
+			Mode:          config.ModeBundle,
+			AbsOutputFile: "/Users/user/project/out.js",
+		},
+	})
+}
@@ -39,6 +39,10 @@ func ParseLoader(text string) (api.Loader, *ErrorWithNote) {
 		return api.LoaderEmpty, nil
 	case "file":
 		return api.LoaderFile, nil
+	case "flow":
+		return api.LoaderFlow, nil
+	case "flow-jsx":
+		return api.LoaderFlowJSX, nil
 	case "global-css":
 		return api.LoaderGlobalCSS, nil
 	case "js":
@@ -58,7 +62,7 @@ func ParseLoader(text string) (api.Loader, *ErrorWithNote) {
 	default:
 		return api.LoaderNone, MakeErrorWithNote(
 			fmt.Sprintf("Invalid loader value: %q", text),
-			"Valid values are \"base64\", \"binary\", \"copy\", \"css\", \"dataurl\", \"empty\", \"file\", \"global-css\", \"js\", \"json\", \"jsx\", \"local-css\", \"text\", \"ts\", or \"tsx\".",
+			"Valid values are \"base64\", \"binary\", \"copy\", \"css\", \"dataurl\", \"empty\", \"file\", \"flow\", \"flow-jsx\", \"global-css\", \"js\", \"json\", \"jsx\", \"local-css\", \"text\", \"ts\", or \"tsx\".",
 		)
 	}
 }
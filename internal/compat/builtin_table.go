@@ -0,0 +1,455 @@
+package compat
+
+// This table is maintained by hand (unlike "js_table.go", which is generated).
+// It lists built-in globals, static methods, and prototype methods that can
+// be polyfilled. Each entry records the module from the polyfill package (in
+// the layout used by "core-js") that provides it, along with the versions of
+// each engine that implement it natively.
+
+type BuiltIn uint64
+
+const (
+	ArrayFrom BuiltIn = 1 << iota
+	ArrayPrototypeAt
+	ArrayPrototypeFindLast
+	ArrayPrototypeFindLastIndex
+	ArrayPrototypeFlat
+	ArrayPrototypeFlatMap
+	ArrayPrototypeIncludes
+	GlobalThis
+	ObjectAssign
+	ObjectEntries
+	ObjectFromEntries
+	ObjectHasOwn
+	ObjectValues
+	PromiseAllSettled
+	PromiseAny
+	StringPrototypeAt
+	StringPrototypeIncludes
+	StringPrototypePadEnd
+	StringPrototypePadStart
+	StringPrototypeReplaceAll
+	StringPrototypeTrimEnd
+	StringPrototypeTrimStart
+	StructuredClone
+)
+
+func (builtIns BuiltIn) Has(builtIn BuiltIn) bool {
+	return (builtIns & builtIn) != 0
+}
+
+type builtInKind uint8
+
+const (
+	// A global variable such as "globalThis"
+	builtInGlobal builtInKind = iota
+
+	// A property on a global variable such as "Object.hasOwn"
+	builtInStatic
+
+	// A property on an instance such as "[].at". We can't know the type of the
+	// instance in general, so every property access with a matching name counts.
+	builtInInstance
+)
+
+type builtInInfo struct {
+	engines map[Engine][]versionRange
+	object  string
+	name    string
+	module  string
+	kind    builtInKind
+
+	// Some built-ins come from web standards instead of from the ECMAScript
+	// specification, so an "esXXXX" target says nothing about whether they
+	// are present or not.
+	notInES bool
+}
+
+var builtInTable = map[BuiltIn]builtInInfo{
+	ArrayFrom: {
+		kind: builtInStatic, object: "Array", name: "from", module: "es.array.from",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{45, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{12, 0, 0}}},
+			ES:      {{start: v{2015, 0, 0}}},
+			Firefox: {{start: v{32, 0, 0}}},
+			IOS:     {{start: v{9, 0, 0}}},
+			Node:    {{start: v{4, 0, 0}}},
+			Opera:   {{start: v{32, 0, 0}}},
+			Safari:  {{start: v{9, 0, 0}}},
+		},
+	},
+	ArrayPrototypeAt: {
+		kind: builtInInstance, name: "at", module: "es.array.at",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{92, 0, 0}}},
+			Deno:    {{start: v{1, 12, 0}}},
+			Edge:    {{start: v{92, 0, 0}}},
+			ES:      {{start: v{2022, 0, 0}}},
+			Firefox: {{start: v{90, 0, 0}}},
+			IOS:     {{start: v{15, 4, 0}}},
+			Node:    {{start: v{16, 6, 0}}},
+			Opera:   {{start: v{78, 0, 0}}},
+			Safari:  {{start: v{15, 4, 0}}},
+		},
+	},
+	ArrayPrototypeFindLast: {
+		kind: builtInInstance, name: "findLast", module: "es.array.find-last",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{97, 0, 0}}},
+			Deno:    {{start: v{1, 16, 0}}},
+			Edge:    {{start: v{97, 0, 0}}},
+			ES:      {{start: v{2023, 0, 0}}},
+			Firefox: {{start: v{104, 0, 0}}},
+			IOS:     {{start: v{15, 4, 0}}},
+			Node:    {{start: v{18, 0, 0}}},
+			Opera:   {{start: v{83, 0, 0}}},
+			Safari:  {{start: v{15, 4, 0}}},
+		},
+	},
+	ArrayPrototypeFindLastIndex: {
+		kind: builtInInstance, name: "findLastIndex", module: "es.array.find-last-index",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{97, 0, 0}}},
+			Deno:    {{start: v{1, 16, 0}}},
+			Edge:    {{start: v{97, 0, 0}}},
+			ES:      {{start: v{2023, 0, 0}}},
+			Firefox: {{start: v{104, 0, 0}}},
+			IOS:     {{start: v{15, 4, 0}}},
+			Node:    {{start: v{18, 0, 0}}},
+			Opera:   {{start: v{83, 0, 0}}},
+			Safari:  {{start: v{15, 4, 0}}},
+		},
+	},
+	ArrayPrototypeFlat: {
+		kind: builtInInstance, name: "flat", module: "es.array.flat",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{69, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{79, 0, 0}}},
+			ES:      {{start: v{2019, 0, 0}}},
+			Firefox: {{start: v{62, 0, 0}}},
+			IOS:     {{start: v{12, 0, 0}}},
+			Node:    {{start: v{11, 0, 0}}},
+			Opera:   {{start: v{56, 0, 0}}},
+			Safari:  {{start: v{12, 0, 0}}},
+		},
+	},
+	ArrayPrototypeFlatMap: {
+		kind: builtInInstance, name: "flatMap", module: "es.array.flat-map",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{69, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{79, 0, 0}}},
+			ES:      {{start: v{2019, 0, 0}}},
+			Firefox: {{start: v{62, 0, 0}}},
+			IOS:     {{start: v{12, 0, 0}}},
+			Node:    {{start: v{11, 0, 0}}},
+			Opera:   {{start: v{56, 0, 0}}},
+			Safari:  {{start: v{12, 0, 0}}},
+		},
+	},
+	ArrayPrototypeIncludes: {
+		kind: builtInInstance, name: "includes", module: "es.array.includes",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{47, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{14, 0, 0}}},
+			ES:      {{start: v{2016, 0, 0}}},
+			Firefox: {{start: v{43, 0, 0}}},
+			IOS:     {{start: v{9, 0, 0}}},
+			Node:    {{start: v{6, 0, 0}}},
+			Opera:   {{start: v{34, 0, 0}}},
+			Safari:  {{start: v{9, 0, 0}}},
+		},
+	},
+	GlobalThis: {
+		kind: builtInGlobal, name: "globalThis", module: "es.global-this",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{71, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{79, 0, 0}}},
+			ES:      {{start: v{2020, 0, 0}}},
+			Firefox: {{start: v{65, 0, 0}}},
+			IOS:     {{start: v{12, 2, 0}}},
+			Node:    {{start: v{12, 0, 0}}},
+			Opera:   {{start: v{58, 0, 0}}},
+			Safari:  {{start: v{12, 1, 0}}},
+		},
+	},
+	ObjectAssign: {
+		kind: builtInStatic, object: "Object", name: "assign", module: "es.object.assign",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{45, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{12, 0, 0}}},
+			ES:      {{start: v{2015, 0, 0}}},
+			Firefox: {{start: v{34, 0, 0}}},
+			IOS:     {{start: v{9, 0, 0}}},
+			Node:    {{start: v{4, 0, 0}}},
+			Opera:   {{start: v{32, 0, 0}}},
+			Safari:  {{start: v{9, 0, 0}}},
+		},
+	},
+	ObjectEntries: {
+		kind: builtInStatic, object: "Object", name: "entries", module: "es.object.entries",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{54, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{14, 0, 0}}},
+			ES:      {{start: v{2017, 0, 0}}},
+			Firefox: {{start: v{47, 0, 0}}},
+			IOS:     {{start: v{10, 3, 0}}},
+			Node:    {{start: v{7, 0, 0}}},
+			Opera:   {{start: v{41, 0, 0}}},
+			Safari:  {{start: v{10, 1, 0}}},
+		},
+	},
+	ObjectFromEntries: {
+		kind: builtInStatic, object: "Object", name: "fromEntries", module: "es.object.from-entries",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{73, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{79, 0, 0}}},
+			ES:      {{start: v{2019, 0, 0}}},
+			Firefox: {{start: v{63, 0, 0}}},
+			IOS:     {{start: v{12, 2, 0}}},
+			Node:    {{start: v{12, 0, 0}}},
+			Opera:   {{start: v{60, 0, 0}}},
+			Safari:  {{start: v{12, 1, 0}}},
+		},
+	},
+	ObjectHasOwn: {
+		kind: builtInStatic, object: "Object", name: "hasOwn", module: "es.object.has-own",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{93, 0, 0}}},
+			Deno:    {{start: v{1, 13, 0}}},
+			Edge:    {{start: v{93, 0, 0}}},
+			ES:      {{start: v{2022, 0, 0}}},
+			Firefox: {{start: v{92, 0, 0}}},
+			IOS:     {{start: v{15, 4, 0}}},
+			Node:    {{start: v{16, 9, 0}}},
+			Opera:   {{start: v{79, 0, 0}}},
+			Safari:  {{start: v{15, 4, 0}}},
+		},
+	},
+	ObjectValues: {
+		kind: builtInStatic, object: "Object", name: "values", module: "es.object.values",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{54, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{14, 0, 0}}},
+			ES:      {{start: v{2017, 0, 0}}},
+			Firefox: {{start: v{47, 0, 0}}},
+			IOS:     {{start: v{10, 3, 0}}},
+			Node:    {{start: v{7, 0, 0}}},
+			Opera:   {{start: v{41, 0, 0}}},
+			Safari:  {{start: v{10, 1, 0}}},
+		},
+	},
+	PromiseAllSettled: {
+		kind: builtInStatic, object: "Promise", name: "allSettled", module: "es.promise.all-settled",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{76, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{79, 0, 0}}},
+			ES:      {{start: v{2020, 0, 0}}},
+			Firefox: {{start: v{71, 0, 0}}},
+			IOS:     {{start: v{13, 0, 0}}},
+			Node:    {{start: v{12, 9, 0}}},
+			Opera:   {{start: v{63, 0, 0}}},
+			Safari:  {{start: v{13, 0, 0}}},
+		},
+	},
+	PromiseAny: {
+		kind: builtInStatic, object: "Promise", name: "any", module: "es.promise.any",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{85, 0, 0}}},
+			Deno:    {{start: v{1, 2, 0}}},
+			Edge:    {{start: v{85, 0, 0}}},
+			ES:      {{start: v{2021, 0, 0}}},
+			Firefox: {{start: v{79, 0, 0}}},
+			IOS:     {{start: v{14, 0, 0}}},
+			Node:    {{start: v{15, 0, 0}}},
+			Opera:   {{start: v{71, 0, 0}}},
+			Safari:  {{start: v{14, 0, 0}}},
+		},
+	},
+	StringPrototypeAt: {
+		kind: builtInInstance, name: "at", module: "es.string.at-alternative",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{92, 0, 0}}},
+			Deno:    {{start: v{1, 12, 0}}},
+			Edge:    {{start: v{92, 0, 0}}},
+			ES:      {{start: v{2022, 0, 0}}},
+			Firefox: {{start: v{90, 0, 0}}},
+			IOS:     {{start: v{15, 4, 0}}},
+			Node:    {{start: v{16, 6, 0}}},
+			Opera:   {{start: v{78, 0, 0}}},
+			Safari:  {{start: v{15, 4, 0}}},
+		},
+	},
+	StringPrototypeIncludes: {
+		kind: builtInInstance, name: "includes", module: "es.string.includes",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{41, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{12, 0, 0}}},
+			ES:      {{start: v{2015, 0, 0}}},
+			Firefox: {{start: v{40, 0, 0}}},
+			IOS:     {{start: v{9, 0, 0}}},
+			Node:    {{start: v{4, 0, 0}}},
+			Opera:   {{start: v{28, 0, 0}}},
+			Safari:  {{start: v{9, 0, 0}}},
+		},
+	},
+	StringPrototypePadEnd: {
+		kind: builtInInstance, name: "padEnd", module: "es.string.pad-end",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{57, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{15, 0, 0}}},
+			ES:      {{start: v{2017, 0, 0}}},
+			Firefox: {{start: v{48, 0, 0}}},
+			IOS:     {{start: v{10, 0, 0}}},
+			Node:    {{start: v{8, 0, 0}}},
+			Opera:   {{start: v{44, 0, 0}}},
+			Safari:  {{start: v{10, 0, 0}}},
+		},
+	},
+	StringPrototypePadStart: {
+		kind: builtInInstance, name: "padStart", module: "es.string.pad-start",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{57, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{15, 0, 0}}},
+			ES:      {{start: v{2017, 0, 0}}},
+			Firefox: {{start: v{48, 0, 0}}},
+			IOS:     {{start: v{10, 0, 0}}},
+			Node:    {{start: v{8, 0, 0}}},
+			Opera:   {{start: v{44, 0, 0}}},
+			Safari:  {{start: v{10, 0, 0}}},
+		},
+	},
+	StringPrototypeReplaceAll: {
+		kind: builtInInstance, name: "replaceAll", module: "es.string.replace-all",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{85, 0, 0}}},
+			Deno:    {{start: v{1, 2, 0}}},
+			Edge:    {{start: v{85, 0, 0}}},
+			ES:      {{start: v{2021, 0, 0}}},
+			Firefox: {{start: v{77, 0, 0}}},
+			IOS:     {{start: v{13, 4, 0}}},
+			Node:    {{start: v{15, 0, 0}}},
+			Opera:   {{start: v{71, 0, 0}}},
+			Safari:  {{start: v{13, 1, 0}}},
+		},
+	},
+	StringPrototypeTrimEnd: {
+		kind: builtInInstance, name: "trimEnd", module: "es.string.trim-end",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{66, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{79, 0, 0}}},
+			ES:      {{start: v{2019, 0, 0}}},
+			Firefox: {{start: v{61, 0, 0}}},
+			IOS:     {{start: v{12, 0, 0}}},
+			Node:    {{start: v{10, 0, 0}}},
+			Opera:   {{start: v{53, 0, 0}}},
+			Safari:  {{start: v{12, 0, 0}}},
+		},
+	},
+	StringPrototypeTrimStart: {
+		kind: builtInInstance, name: "trimStart", module: "es.string.trim-start",
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{66, 0, 0}}},
+			Deno:    {{start: v{1, 0, 0}}},
+			Edge:    {{start: v{79, 0, 0}}},
+			ES:      {{start: v{2019, 0, 0}}},
+			Firefox: {{start: v{61, 0, 0}}},
+			IOS:     {{start: v{12, 0, 0}}},
+			Node:    {{start: v{10, 0, 0}}},
+			Opera:   {{start: v{53, 0, 0}}},
+			Safari:  {{start: v{12, 0, 0}}},
+		},
+	},
+	StructuredClone: {
+		kind: builtInGlobal, name: "structuredClone", module: "web.structured-clone", notInES: true,
+		engines: map[Engine][]versionRange{
+			Chrome:  {{start: v{98, 0, 0}}},
+			Deno:    {{start: v{1, 14, 0}}},
+			Edge:    {{start: v{98, 0, 0}}},
+			Firefox: {{start: v{94, 0, 0}}},
+			IOS:     {{start: v{15, 4, 0}}},
+			Node:    {{start: v{17, 0, 0}}},
+			Opera:   {{start: v{84, 0, 0}}},
+			Safari:  {{start: v{15, 4, 0}}},
+		},
+	},
+}
+
+// Return all built-ins that are not available in at least one environment
+func UnsupportedBuiltIns(constraints map[Engine][]int) (unsupported BuiltIn) {
+	for builtIn, info := range builtInTable {
+		for engine, version := range constraints {
+			if engine == ES && info.notInES {
+				continue
+			}
+			if versionRanges, ok := info.engines[engine]; !ok || !isVersionSupported(versionRanges, version) {
+				unsupported |= builtIn
+			}
+		}
+	}
+	return
+}
+
+var builtInGlobals = make(map[string]BuiltIn)
+var builtInStatics = make(map[string]map[string]BuiltIn)
+var builtInInstances = make(map[string]BuiltIn)
+
+func init() {
+	for builtIn, info := range builtInTable {
+		switch info.kind {
+		case builtInGlobal:
+			builtInGlobals[info.name] |= builtIn
+		case builtInStatic:
+			statics := builtInStatics[info.object]
+			if statics == nil {
+				statics = make(map[string]BuiltIn)
+				builtInStatics[info.object] = statics
+			}
+			statics[info.name] |= builtIn
+		case builtInInstance:
+			builtInInstances[info.name] |= builtIn
+		}
+	}
+}
+
+// Returns the built-ins that a reference to the unbound global "name" uses
+func BuiltInsForGlobal(name string) BuiltIn {
+	return builtInGlobals[name]
+}
+
+// Returns the built-ins that "object.name" uses where "object" is an unbound
+// global
+func BuiltInsForStaticMember(object string, name string) BuiltIn {
+	return builtInStatics[object][name]
+}
+
+// Returns the built-ins that a property access of "name" on an arbitrary
+// value may use. This may return several built-ins since the same name is
+// used by more than one prototype (e.g. "at" is on both arrays and strings).
+func BuiltInsForInstanceMember(name string) BuiltIn {
+	return builtInInstances[name]
+}
+
+// Returns the polyfill modules for these built-ins in a deterministic order
+func (builtIns BuiltIn) PolyfillModules() (modules []string) {
+	for builtIn := BuiltIn(1); builtIn != 0 && builtIn <= builtIns; builtIn <<= 1 {
+		if builtIns.Has(builtIn) {
+			modules = append(modules, builtInTable[builtIn].module)
+		}
+	}
+	return
+}
@@ -14,15 +14,27 @@ import (
 	"github.com/evanw/esbuild/internal/logger"
 )
 
+type JSXTarget uint8
+
+const (
+	JSXTargetReact JSXTarget = iota
+	JSXTargetSolid           // DOM expressions with "solid-js/web"
+	JSXTargetVue             // "createVNode" calls with patch flags
+	JSXTargetHTM             // Tagged template literals for "htm"
+)
+
 type JSXOptions struct {
 	Factory          DefineExpr
 	Fragment         DefineExpr
 	Parse            bool
 	Preserve         bool
 	AutomaticRuntime bool
+	Target           JSXTarget
 	ImportSource     string
 	Development      bool
 	SideEffects      bool
+	ConstantElements bool
+	InlineElements   bool
 }
 
 type TSJSX uint8
@@ -36,10 +48,22 @@ const (
 	TSJSXReactJSXDev
 )
 
+type FlowOptions struct {
+	Parse bool
+}
+
 type TSOptions struct {
 	Config              TSConfig
 	Parse               bool
 	NoAmbiguousLessThan bool
+
+	// This is set by the API and is in addition to "erasableSyntaxOnly" in
+	// "tsconfig.json", which may be different for each file
+	ErasableSyntaxOnly bool
+}
+
+func (ts *TSOptions) IsErasableSyntaxOnly() bool {
+	return ts.ErasableSyntaxOnly || ts.Config.ErasableSyntaxOnly == True
 }
 
 type TSConfigJSX struct {
@@ -106,6 +130,8 @@ func (tsConfig *TSConfigJSX) ApplyTo(jsxOptions *JSXOptions) {
 // Note: This can currently only contain primitive values. It's compared
 // for equality using a structural equality comparison by the JS parser.
 type TSConfig struct {
+	EmitDecoratorMetadata   MaybeBool
+	ErasableSyntaxOnly      MaybeBool
 	ExperimentalDecorators  MaybeBool
 	ImportsNotUsedAsValues  TSImportsNotUsedAsValues
 	PreserveValueImports    MaybeBool
@@ -116,6 +142,12 @@ type TSConfig struct {
 
 // This is used for "extends" in "tsconfig.json"
 func (derived *TSConfig) ApplyExtendedConfig(base TSConfig) {
+	if base.EmitDecoratorMetadata != Unspecified {
+		derived.EmitDecoratorMetadata = base.EmitDecoratorMetadata
+	}
+	if base.ErasableSyntaxOnly != Unspecified {
+		derived.ErasableSyntaxOnly = base.ErasableSyntaxOnly
+	}
 	if base.ExperimentalDecorators != Unspecified {
 		derived.ExperimentalDecorators = base.ExperimentalDecorators
 	}
@@ -181,6 +213,61 @@ func (lc LegalComments) HasExternalFile() bool {
 	return lc == LegalCommentsLinkedWithComment || lc == LegalCommentsExternalWithoutComment
 }
 
+// These settings only affect output that isn't minified
+type Formatting struct {
+	IndentWidth    int // Zero means the default of two spaces
+	UseTabs        bool
+	Quotes         Quotes
+	TrailingCommas TrailingCommas
+	Semicolons     Semicolons
+
+	// Lists that would extend past this column are printed with one item per
+	// line instead. Zero means lists are never wrapped.
+	PrintWidth int
+}
+
+func (f Formatting) IndentText() string {
+	if f.UseTabs {
+		return "\t"
+	}
+	if f.IndentWidth > 0 {
+		return strings.Repeat(" ", f.IndentWidth)
+	}
+	return "  "
+}
+
+type Quotes uint8
+
+const (
+	QuotesDouble Quotes = iota
+	QuotesSingle
+)
+
+type TrailingCommas uint8
+
+const (
+	TrailingCommasNone TrailingCommas = iota
+
+	// Trailing commas are added to multi-line arrays, objects, and import and
+	// export clauses, which are all allowed in ES5
+	TrailingCommasES5
+
+	// Trailing commas are also added to multi-line argument and parameter
+	// lists, which requires ES2017
+	TrailingCommasAll
+)
+
+type Semicolons uint8
+
+const (
+	SemicolonsAlways Semicolons = iota
+
+	// Semicolons are omitted at the end of statements. A semicolon is printed
+	// at the start of a statement instead if automatic semicolon insertion
+	// would otherwise join it with the previous statement.
+	SemicolonsASI
+)
+
 type Loader uint8
 
 const (
@@ -193,6 +280,8 @@ const (
 	LoaderDefault
 	LoaderEmpty
 	LoaderFile
+	LoaderFlow
+	LoaderFlowJSX
 	LoaderGlobalCSS
 	LoaderJS
 	LoaderJSON
@@ -214,6 +303,8 @@ var LoaderToString = []string{
 	"default",
 	"empty",
 	"file",
+	"flow",
+	"flow-jsx",
 	"global-css",
 	"js",
 	"json",
@@ -246,6 +337,7 @@ func (loader Loader) CanHaveSourceMap() bool {
 	switch loader {
 	case
 		LoaderJS, LoaderJSX,
+		LoaderFlow, LoaderFlowJSX,
 		LoaderTS, LoaderTSNoAmbiguousLessThan, LoaderTSX,
 		LoaderCSS, LoaderGlobalCSS, LoaderLocalCSS,
 		LoaderJSON, LoaderText:
@@ -371,6 +463,8 @@ func (flag *CancelFlag) DidCancel() bool {
 	return flag != nil && atomic.LoadUint32(&flag.uint32) != 0
 }
 
+const DefaultInlineBudget = 16
+
 type Options struct {
 	ModuleTypeData js_ast.ModuleTypeData
 	Defines        *ProcessedDefines
@@ -436,6 +530,12 @@ type Options struct {
 	Stdin      *StdinInfo
 	JSX        JSXOptions
 	LineLimit  int
+	Formatting Formatting
+
+	// Functions whose bodies are at most this many AST nodes are inlined at all
+	// of their call sites when "InlineFunctions" is enabled. Functions that are
+	// only called once are always inlined. Zero means "DefaultInlineBudget".
+	InlineBudget int
 
 	CSSPrefixData          map[css_ast.D]compat.CSSPrefix
 	UnsupportedJSFeatures  compat.JSFeature
@@ -446,17 +546,27 @@ type Options struct {
 	UnsupportedCSSFeatureOverrides     compat.CSSFeature
 	UnsupportedCSSFeatureOverridesMask compat.CSSFeature
 
+	// If this is set, the parser generates imports from this package (which is
+	// expected to have the same layout as "core-js") for any built-ins in
+	// "UnsupportedBuiltIns" that the code uses. These built-ins are computed
+	// from the target environment like "UnsupportedJSFeatures" is.
+	Polyfill            string
+	UnsupportedBuiltIns compat.BuiltIn
+
 	TS                TSOptions
+	Flow              FlowOptions
 	Mode              Mode
 	PreserveSymlinks  bool
 	MinifyWhitespace  bool
 	MinifyIdentifiers bool
 	MinifySyntax      bool
+	InlineFunctions   bool
 	ProfilerNames     bool
 	CodeSplitting     bool
 	WatchMode         bool
 	AllowOverwrite    bool
 	LegalComments     LegalComments
+	PreserveComments  bool
 
 	// If true, make sure to generate a single file that can be written to stdout
 	WriteToStdout bool
@@ -465,15 +575,29 @@ type Options struct {
 	OmitJSXRuntimeForTests bool
 	ASCIIOnly              bool
 	KeepNames              bool
+	LooseIteration         bool
 	IgnoreDCEAnnotations   bool
+	CollapseProperties     bool
 	TreeShaking            bool
 	DropDebugger           bool
 	MangleQuoted           bool
+	ManglePrivate          bool
 	Platform               Platform
 	OutputFormat           Format
 	NeedsMetafile          bool
 	SourceMap              SourceMap
 	ExcludeSourcesContent  bool
+
+	// If true, TypeScript entry points also generate a ".d.ts" file. This only
+	// supports code that is compatible with TypeScript's "isolatedDeclarations"
+	// setting (i.e. declarations that can be generated without type inference).
+	Declarations bool
+
+	// When bundling declarations, type imports of packages are kept as imports
+	// since the package will be installed wherever the declarations are used.
+	// The types of packages that match these are inlined instead, which is
+	// useful for packages in the same monorepo that aren't published.
+	DeclarationsInline ExternalMatchers
 }
 
 type TSImportsNotUsedAsValues uint8
@@ -765,6 +889,7 @@ type OnResolveArgs struct {
 	ResolveDir string
 	PluginData interface{}
 	Importer   logger.Path
+	With       logger.ImportAttributes
 	Kind       ast.ImportKind
 }
 
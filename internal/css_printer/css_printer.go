@@ -26,6 +26,7 @@ type printer struct {
 	extractedLegalComments []string
 	jsonMetadataImports    []string
 	builder                sourcemap.ChunkBuilder
+	indentText             string
 	oldLineStart           int
 	oldLineEnd             int
 }
@@ -43,6 +44,7 @@ type Options struct {
 	LocalNames map[ast.Ref]string
 
 	LineLimit           int
+	Formatting          config.Formatting
 	InputSourceIndex    uint32
 	UnsupportedFeatures compat.CSSFeature
 	MinifyWhitespace    bool
@@ -70,6 +72,7 @@ func Print(tree css_ast.AST, symbols ast.SymbolMap, options Options) PrintResult
 		symbols:       symbols,
 		importRecords: tree.ImportRecords,
 		builder:       sourcemap.MakeChunkBuilder(options.InputSourceMap, options.LineOffsetTables, options.ASCIIOnly),
+		indentText:    options.Formatting.IndentText(),
 	}
 	for _, rule := range tree.Rules {
 		p.printRule(rule, 0, false)
@@ -600,7 +603,7 @@ func (p *printer) print(text string) {
 	p.css = append(p.css, text...)
 }
 
-func bestQuoteCharForString(text string, forURL bool) byte {
+func (p *printer) bestQuoteCharForString(text string, forURL bool) byte {
 	forURLCost := 0
 	singleCost := 2
 	doubleCost := 2
@@ -630,11 +633,16 @@ func bestQuoteCharForString(text string, forURL bool) byte {
 		return quoteForURL
 	}
 
-	// Prefer double quotes to single quotes if there is no cost difference
+	// Prefer the configured quote character if there is no cost difference
+	if p.options.Formatting.Quotes == config.QuotesSingle {
+		if doubleCost < singleCost {
+			return '"'
+		}
+		return '\''
+	}
 	if singleCost < doubleCost {
 		return '\''
 	}
-
 	return '"'
 }
 
@@ -645,7 +653,7 @@ const (
 )
 
 func (p *printer) printQuoted(text string, flags printQuotedFlags) {
-	p.printQuotedWithQuote(text, bestQuoteCharForString(text, false), flags)
+	p.printQuotedWithQuote(text, p.bestQuoteCharForString(text, false), flags)
 }
 
 type escapeKind uint8
@@ -911,11 +919,11 @@ func (p *printer) printSymbol(loc logger.Loc, ref ast.Ref, mode identMode, white
 
 func (p *printer) printIndent(indent int32) {
 	n := int(indent)
-	if p.options.LineLimit > 0 && n*2 >= p.options.LineLimit {
-		n = p.options.LineLimit / 2
+	if width := len(p.indentText); p.options.LineLimit > 0 && n*width >= p.options.LineLimit {
+		n = p.options.LineLimit / width
 	}
 	for i := 0; i < n; i++ {
-		p.css = append(p.css, "  "...)
+		p.css = append(p.css, p.indentText...)
 	}
 }
 
@@ -1007,7 +1015,7 @@ func (p *printer) printTokens(tokens []css_ast.Token, opts printTokensOpts) bool
 				tryToAvoidQuote = false
 			}
 			p.print("url(")
-			p.printQuotedWithQuote(text, bestQuoteCharForString(text, tryToAvoidQuote), flags)
+			p.printQuotedWithQuote(text, p.bestQuoteCharForString(text, tryToAvoidQuote), flags)
 			p.print(")")
 			p.recordImportPathForMetafile(t.PayloadIndex)
 
@@ -52,6 +52,13 @@ func expectPrintedASCII(t *testing.T, contents string, expected string) {
 	})
 }
 
+func expectPrintedFormatting(t *testing.T, formatting config.Formatting, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents+" [formatting]", contents, expected, Options{
+		Formatting: formatting,
+	})
+}
+
 func expectPrintedString(t *testing.T, stringValue string, expected string) {
 	t.Helper()
 	t.Run(stringValue, func(t *testing.T) {
@@ -419,3 +426,18 @@ func TestASCII(t *testing.T) {
 	// This character should always be escaped
 	expectPrinted(t, ".\\FEFF:after { content: '\uFEFF' }", ".\\feff:after {\n  content: \"\\feff\";\n}\n")
 }
+
+func TestFormatting(t *testing.T) {
+	four := config.Formatting{IndentWidth: 4}
+	tabs := config.Formatting{UseTabs: true}
+	single := config.Formatting{Quotes: config.QuotesSingle}
+
+	expectPrintedFormatting(t, four, "a { b: c }", "a {\n    b: c;\n}\n")
+	expectPrintedFormatting(t, four, "@media x { a { b: c } }", "@media x {\n    a {\n        b: c;\n    }\n}\n")
+	expectPrintedFormatting(t, tabs, "@media x { a { b: c } }", "@media x {\n\ta {\n\t\tb: c;\n\t}\n}\n")
+
+	expectPrintedFormatting(t, single, "a { content: \"b\" }", "a {\n  content: 'b';\n}\n")
+	expectPrintedFormatting(t, single, "a { content: \"'\" }", "a {\n  content: \"'\";\n}\n")
+	expectPrintedFormatting(t, single, "a { b: url(\"c (d)\") }", "a {\n  b: url('c (d)');\n}\n")
+	expectPrintedFormatting(t, single, "@import \"a.css\";", "@import 'a.css';\n")
+}
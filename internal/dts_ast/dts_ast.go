@@ -0,0 +1,97 @@
+package dts_ast
+
+import (
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// This is the AST for a TypeScript declaration file (i.e. a ".d.ts" file).
+// Declaration files only contain types, so unlike the JavaScript AST there
+// is no need to represent expressions or control flow. Each declaration is
+// stored as text that has already been converted to declaration form (e.g.
+// "declare function foo(): void;"). This is mostly taken verbatim from the
+// original source code since type syntax doesn't need to change.
+//
+// Imports and exports are kept in structured form instead so that they can
+// be rewritten and so that unused ones can be removed.
+
+type AST struct {
+	Stmts []Stmt
+
+	// These are the type imports of this file. They are separate from the
+	// import records of the JavaScript AST because type imports are removed
+	// from the JavaScript code.
+	ImportRecords []ast.ImportRecord
+}
+
+type Stmt struct {
+	Data S
+	Loc  logger.Loc
+}
+
+type S interface{ isStmt() }
+
+// This follows the conventions of "js_ast.ClauseItem". For imports, "Alias"
+// is the name in the imported module and "Name" is the local name. For
+// exports, "Name" is the local name and "Alias" is the exported name.
+type ClauseItem struct {
+	Alias      string
+	Name       string
+	IsTypeOnly bool
+}
+
+// "import a, { b, type c } from 'path'"
+// "import * as ns from 'path'"
+type SImport struct {
+	DefaultName       string
+	NamespaceName     string
+	Items             []ClauseItem
+	ImportRecordIndex uint32
+	IsTypeOnly        bool
+}
+
+// "export { a, b as c }"
+type SExportClause struct {
+	Items      []ClauseItem
+	IsTypeOnly bool
+}
+
+// "export { a, b as c } from 'path'"
+type SExportFrom struct {
+	Items             []ClauseItem
+	ImportRecordIndex uint32
+	IsTypeOnly        bool
+}
+
+// "export * from 'path'"
+// "export * as ns from 'path'"
+type SExportStar struct {
+	Alias             string
+	ImportRecordIndex uint32
+	IsTypeOnly        bool
+}
+
+// "export default a"
+type SExportDefault struct {
+	Name string
+}
+
+// A declaration such as "declare const x: number;" or "interface Foo {}". The
+// text is printed as-is and includes the trailing semicolon (if any), but not
+// the "export" or "default" keywords. The names are the top-level names that
+// this declares, which is empty for anonymous default exports and for things
+// like "declare global".
+type SDecl struct {
+	DocComment string
+	Text       string
+	Names      []string
+	IsExport   bool
+	IsDefault  bool
+}
+
+func (*SImport) isStmt()        {}
+func (*SExportClause) isStmt()  {}
+func (*SExportFrom) isStmt()    {}
+func (*SExportStar) isStmt()    {}
+func (*SExportDefault) isStmt() {}
+func (*SDecl) isStmt()          {}
@@ -0,0 +1,166 @@
+package dts_lexer
+
+// Declaration text is stored as source code instead of as an AST, so finding
+// the names that it refers to means tokenizing it again. This uses the
+// JavaScript lexer since TypeScript type syntax uses the same tokens.
+
+import (
+	"github.com/evanw/esbuild/internal/config"
+	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+type Token struct {
+	Value            string
+	Range            logger.Range
+	Kind             js_lexer.T
+	IsReference      bool
+	hasNewlineBefore bool
+}
+
+// Keywords that can come before the name of a class or interface member
+var memberModifiers = map[string]bool{
+	"abstract":  true,
+	"accessor":  true,
+	"async":     true,
+	"declare":   true,
+	"get":       true,
+	"override":  true,
+	"private":   true,
+	"protected": true,
+	"public":    true,
+	"readonly":  true,
+	"set":       true,
+	"static":    true,
+}
+
+// This splits declaration text into tokens using the JavaScript lexer. The
+// text comes from code that already parsed successfully, so this shouldn't
+// fail.
+//
+// Identifiers that could refer to a declaration are marked as references.
+// This excludes property accesses, parameter names, and the names of members
+// in object types, classes, and enums. Type parameters and names that are
+// local to a namespace are still marked because telling them apart would
+// need a real parser, so callers must be prepared for false positives.
+func Tokenize(text string) (tokens []Token) {
+	log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+	source := logger.Source{Contents: text}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isLexerPanic := r.(js_lexer.LexerPanic); !isLexerPanic {
+				panic(r)
+			}
+		}
+		markReferences(tokens)
+	}()
+
+	lexer := js_lexer.NewLexer(log, source, config.TSOptions{Parse: true})
+	var braceDepths []int
+	for lexer.Token != js_lexer.TEndOfFile {
+		t := Token{Kind: lexer.Token, Range: lexer.Range(), hasNewlineBefore: lexer.HasNewlineBefore}
+		switch lexer.Token {
+		case js_lexer.TStringLiteral, js_lexer.TNoSubstitutionTemplateLiteral:
+			t.Value = helpers.UTF16ToString(lexer.StringLiteral())
+
+		case js_lexer.TTemplateHead:
+			braceDepths = append(braceDepths, 0)
+
+		case js_lexer.TOpenBrace:
+			if n := len(braceDepths); n > 0 {
+				braceDepths[n-1]++
+			}
+
+		case js_lexer.TCloseBrace:
+			// Template literal types need to be rescanned like in the parser
+			if n := len(braceDepths); n > 0 {
+				if braceDepths[n-1] == 0 {
+					lexer.RescanCloseBraceAsTemplateToken()
+					if lexer.Token == js_lexer.TTemplateTail {
+						braceDepths = braceDepths[:n-1]
+					}
+					t.Kind = lexer.Token
+				} else {
+					braceDepths[n-1]--
+				}
+			}
+
+		default:
+			if lexer.IsIdentifierOrKeyword() {
+				t.Value = lexer.Identifier.String
+			}
+		}
+		tokens = append(tokens, t)
+		lexer.Next()
+	}
+	return
+}
+
+func markReferences(tokens []Token) {
+	var brackets []js_lexer.T
+	isMemberStart := false
+	prev := js_lexer.TEndOfFile
+
+	for i := range tokens {
+		t := &tokens[i]
+
+		// Figure out whether this token starts a member of an object type, class,
+		// or enum. Members either follow a separator or a newline.
+		if len(brackets) > 0 && brackets[len(brackets)-1] == js_lexer.TOpenBrace {
+			switch prev {
+			case js_lexer.TOpenBrace, js_lexer.TSemicolon, js_lexer.TComma:
+				isMemberStart = true
+			case js_lexer.TIdentifier, js_lexer.TCloseParen, js_lexer.TCloseBracket, js_lexer.TCloseBrace,
+				js_lexer.TGreaterThan, js_lexer.TStringLiteral, js_lexer.TNumericLiteral, js_lexer.TNoSubstitutionTemplateLiteral:
+				isMemberStart = isMemberStart || t.hasNewlineBefore
+			}
+		}
+
+		switch t.Kind {
+		case js_lexer.TOpenBrace, js_lexer.TOpenBracket, js_lexer.TOpenParen, js_lexer.TLessThan:
+			brackets = append(brackets, t.Kind)
+		case js_lexer.TCloseBrace, js_lexer.TCloseBracket, js_lexer.TCloseParen, js_lexer.TGreaterThan:
+			if len(brackets) > 0 {
+				brackets = brackets[:len(brackets)-1]
+			}
+		}
+
+		if t.Kind == js_lexer.TIdentifier && prev != js_lexer.TDot {
+			var next Token
+			var afterNext Token
+			if i+1 < len(tokens) {
+				next = tokens[i+1]
+			}
+			if i+2 < len(tokens) {
+				afterNext = tokens[i+2]
+			}
+
+			switch {
+			case isMemberStart && memberModifiers[t.Value] && (next.Kind == js_lexer.TIdentifier ||
+				next.Kind == js_lexer.TOpenBracket || next.Kind == js_lexer.TPrivateIdentifier):
+				// "readonly a: T" (the following token is still a member name)
+				prev = t.Kind
+				continue
+
+			case isMemberStart:
+				// "a: T" or "a(): T" or "A = 1" in an enum
+
+			case next.Kind == js_lexer.TColon && prev != js_lexer.TQuestion:
+				// "(a: T)" or "[a: T]"
+
+			case next.Kind == js_lexer.TQuestion && afterNext.Kind == js_lexer.TColon:
+				// "(a?: T)"
+
+			case next.Kind == js_lexer.TIdentifier && next.Value == "is":
+				// "(a: unknown): a is T"
+
+			default:
+				t.IsReference = true
+			}
+		}
+
+		isMemberStart = false
+		prev = t.Kind
+	}
+}
@@ -0,0 +1,148 @@
+package dts_printer
+
+import (
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/dts_ast"
+	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/js_ast"
+)
+
+type printer struct {
+	importRecords []ast.ImportRecord
+	dts           []byte
+}
+
+type PrintResult struct {
+	DTS []byte
+}
+
+func Print(tree dts_ast.AST) PrintResult {
+	p := printer{importRecords: tree.ImportRecords}
+	for _, stmt := range tree.Stmts {
+		p.printStmt(stmt)
+	}
+	return PrintResult{DTS: p.dts}
+}
+
+func (p *printer) print(text string) {
+	p.dts = append(p.dts, text...)
+}
+
+func (p *printer) printStmt(stmt dts_ast.Stmt) {
+	switch s := stmt.Data.(type) {
+	case *dts_ast.SImport:
+		p.print("import ")
+		if s.IsTypeOnly {
+			p.print("type ")
+		}
+		if s.DefaultName != "" {
+			p.print(s.DefaultName)
+			if s.NamespaceName != "" || s.Items != nil {
+				p.print(", ")
+			}
+		}
+		if s.NamespaceName != "" {
+			p.print("* as ")
+			p.print(s.NamespaceName)
+		} else if s.Items != nil {
+			p.printClause(s.Items, true)
+		}
+		p.print(" from ")
+		p.printPath(s.ImportRecordIndex)
+
+	case *dts_ast.SExportClause:
+		p.print("export ")
+		if s.IsTypeOnly {
+			p.print("type ")
+		}
+		p.printClause(s.Items, false)
+
+	case *dts_ast.SExportFrom:
+		p.print("export ")
+		if s.IsTypeOnly {
+			p.print("type ")
+		}
+		p.printClause(s.Items, false)
+		p.print(" from ")
+		p.printPath(s.ImportRecordIndex)
+
+	case *dts_ast.SExportStar:
+		p.print("export ")
+		if s.IsTypeOnly {
+			p.print("type ")
+		}
+		p.print("*")
+		if s.Alias != "" {
+			p.print(" as ")
+			p.printName(s.Alias)
+		}
+		p.print(" from ")
+		p.printPath(s.ImportRecordIndex)
+
+	case *dts_ast.SExportDefault:
+		p.print("export default ")
+		p.print(s.Name)
+
+	case *dts_ast.SDecl:
+		if s.DocComment != "" {
+			p.print(s.DocComment)
+			p.print("\n")
+		}
+		if s.IsExport {
+			p.print("export ")
+			if s.IsDefault {
+				p.print("default ")
+			}
+		}
+		p.print(s.Text)
+		p.print("\n")
+		return
+	}
+
+	p.print(";\n")
+}
+
+func (p *printer) printClause(items []dts_ast.ClauseItem, isImport bool) {
+	if len(items) == 0 {
+		p.print("{}")
+		return
+	}
+	p.print("{ ")
+	for i, item := range items {
+		if i > 0 {
+			p.print(", ")
+		}
+		if item.IsTypeOnly {
+			p.print("type ")
+		}
+
+		// Imports are "alias as name" and exports are "name as alias"
+		if isImport {
+			p.printName(item.Alias)
+			if item.Name != item.Alias {
+				p.print(" as ")
+				p.print(item.Name)
+			}
+		} else {
+			p.printName(item.Name)
+			if item.Name != item.Alias {
+				p.print(" as ")
+				p.printName(item.Alias)
+			}
+		}
+	}
+	p.print(" }")
+}
+
+// Import and export aliases can be arbitrary strings
+func (p *printer) printName(name string) {
+	if js_ast.IsIdentifier(name) {
+		p.print(name)
+	} else {
+		p.dts = append(p.dts, helpers.QuoteForJSON(name, false)...)
+	}
+}
+
+func (p *printer) printPath(importRecordIndex uint32) {
+	p.dts = append(p.dts, helpers.QuoteForJSON(p.importRecords[importRecordIndex].Path.Text, false)...)
+}
@@ -108,6 +108,25 @@ type LinkerGraph struct {
 	// This is for cross-module inlining of detected inlinable constants
 	ConstValues map[ast.Ref]js_ast.ConstValue
 
+	// This is for cross-module inlining of property accesses off of frozen
+	// objects. It's filled in by the linker once it knows which objects are
+	// safe to inline (i.e. which ones are always initialized before use).
+	ConstObjects map[ast.Ref]map[string]js_ast.TSEnumValue
+
+	// This is for cross-module inlining of small functions. It's filled in by
+	// the linker once it knows how many times each function is called.
+	InlinedFunctions map[ast.Ref]js_ast.InlinableFunction
+
+	// Functions with a statement body are only inlined at a single call site.
+	// This maps each one to the source index of the file with that call site,
+	// so the chunk containing that file can rename the function's variables.
+	InlinedFunctionCallSites map[ast.Ref]uint32
+
+	// This maps top-level object literals whose properties were collapsed into
+	// separate top-level variables to the symbols for those variables, keyed by
+	// the property path (e.g. "b.c" for "a.b.c").
+	CollapsedObjects map[ast.Ref]map[string]ast.Ref
+
 	// We should avoid traversing all files in the bundle, because the linker
 	// should be able to run a linking operation on a large bundle where only
 	// a few files are needed (e.g. an incremental compilation scenario). This
@@ -190,12 +209,12 @@ func CloneLinkerGraph(
 							dynamicImportEntryPoints = append(dynamicImportEntryPoints, record.SourceIndex.GetIndex())
 							dynamicImportEntryPointsMutex.Unlock()
 
-							// Remove import assertions for dynamic imports of additional
-							// entry points so that they don't mess with the run-time behavior.
-							// For example, "import('./foo.json', { assert: { type: 'json' } })"
+							// Remove import assertions and attributes for dynamic imports of
+							// additional entry points so that they don't mess with the run-time
+							// behavior. For example, "import('./foo.json', { with: { type: 'json' } })"
 							// will likely be converted into an import of a JavaScript file and
-							// leaving the import assertion there will prevent it from working.
-							record.Assertions = nil
+							// leaving the import attribute there will prevent it from working.
+							record.AssertOrWith = nil
 						}
 					}
 				}
@@ -130,7 +130,9 @@ type JSReprMeta struct {
 	// This is true if this file is affected by top-level await, either by having
 	// a top-level await inside this file or by having an import/export statement
 	// that transitively imports such a file. It is forbidden to call "require()"
-	// on these files since they are evaluated asynchronously.
+	// on these files since they are evaluated asynchronously. When the output
+	// format doesn't support top-level await (i.e. "cjs" and "iife"), these
+	// files are wrapped in async initializers that their importers await.
 	IsAsyncOrHasAsyncDependency bool
 
 	Wrap WrapKind
@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/dts_ast"
 	"github.com/evanw/esbuild/internal/logger"
 )
 
@@ -600,6 +601,11 @@ type ECall struct {
 	// call itself is removed due to this annotation, the arguments must remain
 	// if they have side effects.
 	CanBeUnwrappedIfUnused bool
+
+	// If true, every argument is a primitive literal or a bound identifier. This
+	// is determined by the parser so that the linker and the printer agree on
+	// which calls can be replaced by the body of the called function.
+	HasInlinableArgs bool
 }
 
 func (a *ECall) HasSameFlagsAs(b *ECall) bool {
@@ -768,6 +774,10 @@ type EObject struct {
 	CloseBraceLoc    logger.Loc
 	IsSingleLine     bool
 	IsParenthesized  bool
+
+	// This is true if this object literal was followed by a TypeScript "as
+	// const" assertion. The assertion itself is erased from the AST.
+	IsConstAsserted bool
 }
 
 type ESpread struct{ Value Expr }
@@ -1482,9 +1492,17 @@ type AST struct {
 	ModuleScope    *Scope
 	CharFreq       *ast.CharFreq
 
+	// This is only present when all comments are being preserved. It holds the
+	// comments after a statement, class member, or object property on the same
+	// line, keyed by the location of the thing they come after.
+	TrailingComments map[logger.Loc][]string
+
 	// This is internal-only data used for the implementation of Yarn PnP
 	ManifestForYarnPnP Expr
 
+	// This is only present for TypeScript files when declarations are enabled
+	Declarations *dts_ast.AST
+
 	Hashbang   string
 	Directives []string
 	URLForCSS  string
@@ -1504,6 +1522,45 @@ type AST struct {
 	// to enable cross-module inlining of these constants.
 	ConstValues map[ast.Ref]ConstValue
 
+	// This contains the values of top-level constants that are not part of the
+	// const local prefix. They can't be inlined within this file because they
+	// may be referenced before they are initialized, but the linker may still
+	// inline them into other files that import them.
+	ConstValueCandidates map[ast.Ref]ConstValue
+
+	// This contains all top-level functions that are small enough to be inlined
+	// at their call sites, or that can be inlined at their only call site. It
+	// exists to enable cross-module function inlining.
+	InlinableFunctions map[ast.Ref]InlinableFunction
+
+	// This contains top-level constants that are frozen object literals with
+	// number and string property values (e.g. "Object.freeze({ A: 0 })" or
+	// "{ A: 0 } as const"). These are used like TypeScript enums, and the
+	// linker may inline property accesses off of them into other files.
+	ConstObjectCandidates map[ast.Ref]map[string]TSEnumValue
+
+	// Objects in "ConstObjectCandidates" that use "as const" aren't actually
+	// frozen at run-time. They are only inlined if no file in the bundle could
+	// mutate them, which is determined using "EscapingSymbols".
+	UnfrozenConstObjectCandidates map[ast.Ref]bool
+
+	// This contains top-level constants that are object literals whose
+	// properties could be turned into separate top-level variables. The linker
+	// only does this if the object never escapes, which it determines using
+	// "PropertyChainUses" and "EscapingSymbols" from every file in the bundle.
+	CollapsibleObjects map[ast.Ref][]CollapsibleLeaf
+
+	// This contains all static property access chains (e.g. "a.b.c") off of
+	// imports and top-level constants, keyed by the symbol at the root of the
+	// chain. Only the longest chain is recorded, so "a.b.c" only records the
+	// path "b.c" and not the path "b".
+	PropertyChainUses map[ast.Ref]map[string]PropertyChainUse
+
+	// Imports and top-level constants in here are used in some way other than
+	// as the root of a property access chain that isn't written to, so their
+	// properties can't be collapsed into separate variables or inlined.
+	EscapingSymbols map[ast.Ref]bool
+
 	// Properties in here are represented as symbols instead of strings, which
 	// allows them to be renamed to smaller names.
 	MangledProps map[string]ast.Ref
@@ -1553,6 +1610,50 @@ type TSEnumValue struct {
 	Number float64  // Use this if "String" is nil
 }
 
+// This is a function whose body is a single "return" of an expression that
+// only reads the function's arguments (see "InlinableFunctionBodySize"). Calls
+// to it can be replaced with that expression (see "InlineFunctionCall").
+//
+// Alternatively, this is a function with a longer body that can be inlined at
+// a single call site if that call is an entire top-level statement. In that
+// case "Stmts" is the function body and "Body" is nil. The statements are
+// moved into a block in place of the call with the arguments declared using
+// "let", so they must not declare anything outside of that block or return
+// anywhere except at the end. They may also reference top-level symbols from
+// the function's file, which are listed in "FreeRefs".
+type InlinableFunction struct {
+	Args     []ast.Ref
+	Body     Expr
+	Stmts    []Stmt
+	FreeRefs []ast.Ref
+	Size     int // The number of AST nodes in "Body"
+}
+
+// This is a property of a collapsible object literal that is not itself a
+// collapsible object literal. The path is the sequence of property names
+// leading to this property, joined with ".".
+type CollapsibleLeaf struct {
+	Path string
+
+	// If false, calling this property as a method may observe the value of
+	// "this", so it must stay a property access when it's called
+	CanBeCalledWithoutThis bool
+
+	// If true, this property can be dropped if nothing ever reads it
+	CanBeRemovedIfUnused bool
+
+	// If true, this property is an anonymous function or class that gets its
+	// "name" property from the property key. Collapsing it changes that name.
+	NameIsInferred bool
+}
+
+type PropertyChainUse struct {
+	CountEstimate uint32
+
+	// If true, this chain is the target of a call or a tagged template literal
+	IsCalled bool
+}
+
 type ConstValueKind uint8
 
 const (
@@ -1644,6 +1745,11 @@ type NamedImport struct {
 	// It's useful to flag exported imports because if they are in a TypeScript
 	// file, we can't tell if they are a type or a value.
 	IsExported bool
+
+	// TypeScript's "emitDecoratorMetadata" setting references imports that may
+	// only be types. Each reference is guarded by a "typeof" check, so it's not
+	// an error if these imports turn out to be missing.
+	IsOnlyUsedByDecoratorMetadata bool
 }
 
 type NamedExport struct {
@@ -1719,6 +1825,7 @@ type SymbolUse struct {
 type SymbolCallUse struct {
 	CallCountEstimate                   uint32
 	SingleArgNonSpreadCallCountEstimate uint32
+	InlinableArgsCallCountEstimate      uint32
 }
 
 // For readability, the names of certain automatically-generated symbols are
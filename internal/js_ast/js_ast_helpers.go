@@ -2606,3 +2606,192 @@ func ForEachIdentifierBinding(binding Binding, callback func(loc logger.Loc, b *
 		panic("Internal error")
 	}
 }
+
+// This returns the number of AST nodes in the expression if it can be used as
+// the body of an inlined function, or false if it can't. The expression must
+// not contain calls or assignments, must not reference any symbols other than
+// the function's arguments, and must not contain any nested scopes. That way
+// it can be copied into another scope (or another file) without having to
+// care about the order in which the arguments are evaluated or about renaming.
+func InlinableFunctionBodySize(expr Expr, args []ast.Ref) (int, bool) {
+	switch e := expr.Data.(type) {
+	case *ENull, *EUndefined, *EBoolean, *ENumber, *EBigInt, *EString:
+		return 1, true
+
+	case *EIdentifier:
+		for _, arg := range args {
+			if e.Ref == arg {
+				return 1, true
+			}
+		}
+
+	case *EUnary:
+		if e.Op < UnOpDelete {
+			if size, ok := InlinableFunctionBodySize(e.Value, args); ok {
+				return size + 1, true
+			}
+		}
+
+	case *EBinary:
+		if e.Op.BinaryAssignTarget() == AssignTargetNone {
+			if left, ok := InlinableFunctionBodySize(e.Left, args); ok {
+				if right, ok := InlinableFunctionBodySize(e.Right, args); ok {
+					return left + right + 1, true
+				}
+			}
+		}
+
+	case *EIf:
+		if test, ok := InlinableFunctionBodySize(e.Test, args); ok {
+			if yes, ok := InlinableFunctionBodySize(e.Yes, args); ok {
+				if no, ok := InlinableFunctionBodySize(e.No, args); ok {
+					return test + yes + no + 1, true
+				}
+			}
+		}
+
+	case *EDot:
+		if size, ok := InlinableFunctionBodySize(e.Target, args); ok {
+			return size + 1, true
+		}
+
+	case *EIndex:
+		if target, ok := InlinableFunctionBodySize(e.Target, args); ok {
+			if index, ok := InlinableFunctionBodySize(e.Index, args); ok {
+				return target + index + 1, true
+			}
+		}
+
+	case *EArray:
+		total := 1
+		for _, item := range e.Items {
+			size, ok := InlinableFunctionBodySize(item, args)
+			if !ok {
+				return 0, false
+			}
+			total += size
+		}
+		return total, true
+
+	case *EObject:
+		total := 1
+		for _, property := range e.Properties {
+			if property.Kind != PropertyNormal || property.Flags.Has(PropertyIsComputed) || property.Flags.Has(PropertyIsMethod) {
+				return 0, false
+			}
+			if _, ok := property.Key.Data.(*EString); !ok {
+				return 0, false
+			}
+			size, ok := InlinableFunctionBodySize(property.ValueOrNil, args)
+			if !ok {
+				return 0, false
+			}
+			total += size
+		}
+		return total, true
+
+	case *ETemplate:
+		if e.TagOrNil.Data == nil {
+			total := 1
+			for _, part := range e.Parts {
+				size, ok := InlinableFunctionBodySize(part.Value, args)
+				if !ok {
+					return 0, false
+				}
+				total += size
+			}
+			return total, true
+		}
+	}
+
+	return 0, false
+}
+
+// This returns a copy of the body of an inlined function with references to
+// the function's arguments replaced by the values passed at the call site.
+// The values must be side-effect free (i.e. "HasInlinableArgs" must be true).
+// Everything is given the location of the call since the body may be from a
+// different file.
+func InlineFunctionCall(fn InlinableFunction, values []Expr, loc logger.Loc) Expr {
+	var visit func(expr Expr) Expr
+	visit = func(expr Expr) Expr {
+		switch e := expr.Data.(type) {
+		case *EIdentifier:
+			for i, arg := range fn.Args {
+				if e.Ref == arg {
+					if i < len(values) {
+						return values[i]
+					}
+					return Expr{Loc: loc, Data: EUndefinedShared}
+				}
+			}
+
+		case *EUnary:
+			clone := *e
+			clone.Value = visit(e.Value)
+			return Expr{Loc: loc, Data: &clone}
+
+		case *EBinary:
+			clone := *e
+			clone.Left = visit(e.Left)
+			clone.Right = visit(e.Right)
+			return Expr{Loc: loc, Data: &clone}
+
+		case *EIf:
+			clone := *e
+			clone.Test = visit(e.Test)
+			clone.Yes = visit(e.Yes)
+			clone.No = visit(e.No)
+			return Expr{Loc: loc, Data: &clone}
+
+		case *EDot:
+			clone := *e
+			clone.Target = visit(e.Target)
+			clone.NameLoc = loc
+			return Expr{Loc: loc, Data: &clone}
+
+		case *EIndex:
+			clone := *e
+			clone.Target = visit(e.Target)
+			clone.Index = visit(e.Index)
+			clone.CloseBracketLoc = loc
+			return Expr{Loc: loc, Data: &clone}
+
+		case *EArray:
+			clone := *e
+			clone.Items = make([]Expr, len(e.Items))
+			for i, item := range e.Items {
+				clone.Items[i] = visit(item)
+			}
+			clone.CloseBracketLoc = loc
+			return Expr{Loc: loc, Data: &clone}
+
+		case *EObject:
+			clone := *e
+			clone.Properties = make([]Property, len(e.Properties))
+			for i, property := range e.Properties {
+				property.Key.Loc = loc
+				property.ValueOrNil = visit(property.ValueOrNil)
+				property.Loc = loc
+				clone.Properties[i] = property
+			}
+			clone.CloseBraceLoc = loc
+			return Expr{Loc: loc, Data: &clone}
+
+		case *ETemplate:
+			clone := *e
+			clone.Parts = make([]TemplatePart, len(e.Parts))
+			for i, part := range e.Parts {
+				part.Value = visit(part.Value)
+				part.TailLoc = loc
+				clone.Parts[i] = part
+			}
+			clone.HeadLoc = loc
+			return Expr{Loc: loc, Data: &clone}
+		}
+
+		// Literals are immutable so they can be shared
+		return Expr{Loc: loc, Data: expr.Data}
+	}
+	return visit(fn.Body)
+}
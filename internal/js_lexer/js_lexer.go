@@ -252,6 +252,11 @@ type Lexer struct {
 	SourceMappingURL             logger.Span
 	BadArrowInTSXSuggestion      string
 
+	// The parser records the ranges of TypeScript types here when it's only
+	// stripping types. They live on the lexer so that they are automatically
+	// discarded when the parser backtracks by restoring an old lexer.
+	StrippedTypeRanges []logger.Range
+
 	// Escape sequences in string literals are decoded lazily because they are
 	// not interpreted inside tagged templates, and tagged templates can contain
 	// invalid escape sequences. If the decoded array is nil, the encoded value
@@ -268,6 +273,7 @@ type Lexer struct {
 	current                         int
 	start                           int
 	end                             int
+	prevTokenEnd                    int
 	ApproximateNewlineCount         int
 	CouldBeBadArrowInTSX            int
 	BadArrowInTSXRange              logger.Range
@@ -371,6 +377,12 @@ func (lexer *Lexer) Range() logger.Range {
 	return logger.Range{Loc: logger.Loc{Start: int32(lexer.start)}, Len: int32(lexer.end - lexer.start)}
 }
 
+// This is the end of the previous token, which doesn't include any comments
+// or whitespace between that token and the current token
+func (lexer *Lexer) PrevTokenEnd() logger.Loc {
+	return logger.Loc{Start: int32(lexer.prevTokenEnd)}
+}
+
 func (lexer *Lexer) Raw() string {
 	return lexer.source.Contents[lexer.start:lexer.end]
 }
@@ -554,15 +566,18 @@ func (lexer *Lexer) ExpectLessThan(isInsideJSXElement bool) {
 
 	case TLessThanEquals:
 		lexer.Token = TEquals
+		lexer.prevTokenEnd = lexer.start + 1
 		lexer.start++
 		lexer.maybeExpandEquals()
 
 	case TLessThanLessThan:
 		lexer.Token = TLessThan
+		lexer.prevTokenEnd = lexer.start + 1
 		lexer.start++
 
 	case TLessThanLessThanEquals:
 		lexer.Token = TLessThanEquals
+		lexer.prevTokenEnd = lexer.start + 1
 		lexer.start++
 
 	default:
@@ -584,23 +599,28 @@ func (lexer *Lexer) ExpectGreaterThan(isInsideJSXElement bool) {
 
 	case TGreaterThanEquals:
 		lexer.Token = TEquals
+		lexer.prevTokenEnd = lexer.start + 1
 		lexer.start++
 		lexer.maybeExpandEquals()
 
 	case TGreaterThanGreaterThan:
 		lexer.Token = TGreaterThan
+		lexer.prevTokenEnd = lexer.start + 1
 		lexer.start++
 
 	case TGreaterThanGreaterThanEquals:
 		lexer.Token = TGreaterThanEquals
+		lexer.prevTokenEnd = lexer.start + 1
 		lexer.start++
 
 	case TGreaterThanGreaterThanGreaterThan:
 		lexer.Token = TGreaterThanGreaterThan
+		lexer.prevTokenEnd = lexer.start + 1
 		lexer.start++
 
 	case TGreaterThanGreaterThanGreaterThanEquals:
 		lexer.Token = TGreaterThanGreaterThanEquals
+		lexer.prevTokenEnd = lexer.start + 1
 		lexer.start++
 
 	default:
@@ -673,7 +693,7 @@ func RangeOfIdentifier(source logger.Source, loc logger.Loc) logger.Range {
 	return source.RangeOfString(loc)
 }
 
-func RangeOfImportAssertion(source logger.Source, assertion ast.AssertEntry) logger.Range {
+func RangeOfImportAssertOrWith(source logger.Source, assertion ast.AssertOrWithEntry) logger.Range {
 	loc := RangeOfIdentifier(source, assertion.KeyLoc).Loc
 	return logger.Range{Loc: loc, Len: source.RangeOfString(assertion.ValueLoc).End() - loc.Start}
 }
@@ -686,6 +706,7 @@ func (lexer *Lexer) ExpectJSXElementChild(token T) {
 }
 
 func (lexer *Lexer) NextJSXElementChild() {
+	lexer.prevTokenEnd = lexer.end
 	lexer.HasNewlineBefore = false
 	originalStart := lexer.end
 
@@ -809,6 +830,7 @@ func (lexer *Lexer) ExpectInsideJSXElement(token T) {
 }
 
 func (lexer *Lexer) NextInsideJSXElement() {
+	lexer.prevTokenEnd = lexer.end
 	lexer.HasNewlineBefore = false
 
 	for {
@@ -989,6 +1011,7 @@ func (lexer *Lexer) NextInsideJSXElement() {
 }
 
 func (lexer *Lexer) Next() {
+	lexer.prevTokenEnd = lexer.end
 	lexer.HasNewlineBefore = lexer.end == 0
 	lexer.HasCommentBefore = 0
 	lexer.PrevTokenWasAwaitKeyword = false
@@ -0,0 +1,555 @@
+// This file contains code for parsing Flow syntax. Flow type annotations look
+// a lot like TypeScript type annotations, so the Flow parser reuses the code
+// for skipping over TypeScript types and only the parts that are specific to
+// Flow live here. Like with TypeScript, types are skipped over as if they are
+// whitespace and no AST is generated for them.
+
+package js_parser
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// Both TypeScript and Flow files can contain type annotations
+func (p *parser) hasTypeSyntax() bool {
+	return p.options.ts.Parse || p.options.flow.Parse
+}
+
+// Returns true if the code starts with a comment containing a "@flow" or
+// "@noflow" pragma. Like Babel, this only considers comments that come before
+// the first token in the file.
+func HasFlowPragma(contents string) bool {
+	text := strings.TrimPrefix(contents, "\uFEFF")
+
+	// Skip over a hashbang comment
+	if strings.HasPrefix(text, "#!") {
+		if end := strings.IndexAny(text, "\r\n"); end != -1 {
+			text = text[end:]
+		} else {
+			return false
+		}
+	}
+
+	for {
+		var comment string
+		text = strings.TrimLeft(text, " \t\r\n")
+
+		if strings.HasPrefix(text, "//") {
+			if end := strings.IndexAny(text, "\r\n"); end != -1 {
+				comment, text = text[2:end], text[end:]
+			} else {
+				comment, text = text[2:], ""
+			}
+		} else if strings.HasPrefix(text, "/*") {
+			if end := strings.Index(text[2:], "*/"); end != -1 {
+				comment, text = text[2:end+2], text[end+4:]
+			} else {
+				comment, text = text[2:], ""
+			}
+		} else {
+			return false
+		}
+
+		if hasFlowPragmaInComment(comment) {
+			return true
+		}
+	}
+}
+
+func hasFlowPragmaInComment(text string) bool {
+	for {
+		i := strings.IndexByte(text, '@')
+		if i == -1 {
+			return false
+		}
+
+		// The pragma must not be part of a larger word (e.g. "a@flow.com")
+		if i > 0 {
+			if c, _ := utf8.DecodeLastRuneInString(text[:i]); js_ast.IsIdentifierContinue(c) {
+				text = text[i+1:]
+				continue
+			}
+		}
+		text = text[i+1:]
+
+		for _, pragma := range []string{"flow", "noflow"} {
+			if strings.HasPrefix(text, pragma) {
+				// The pragma must also be a whole word (e.g. not "@flowtype")
+				if c, _ := utf8.DecodeRuneInString(text[len(pragma):]); !js_ast.IsIdentifierContinue(c) && c != '-' {
+					return true
+				}
+			}
+		}
+	}
+}
+
+func (p *parser) flowArrowReturnTypeFlags(flags skipTypeFlags) skipTypeFlags {
+	if p.options.flow.Parse {
+		return flags & isFlowArrowReturnTypeFlag
+	}
+	return 0
+}
+
+// Parameter names are optional in Flow function types:
+//
+//	"(string, number) => void"
+//	"(x: string, y?: number) => void"
+//	"(...Array<string>) => void"
+func (p *parser) skipFlowFnTypeParams() {
+	p.lexer.Expect(js_lexer.TOpenParen)
+
+	for p.lexer.Token != js_lexer.TCloseParen {
+		if p.lexer.Token == js_lexer.TDotDotDot {
+			p.lexer.Next()
+		}
+
+		// Skip over the parameter name if there is one
+		if p.lexer.IsIdentifierOrKeyword() {
+			oldLexer := p.lexer
+			p.lexer.Next()
+			if p.lexer.Token == js_lexer.TQuestion {
+				p.lexer.Next()
+			}
+			if p.lexer.Token == js_lexer.TColon {
+				p.lexer.Next()
+			} else {
+				p.lexer = oldLexer
+			}
+		}
+
+		p.skipTypeScriptType(js_ast.LLowest)
+		if p.lexer.Token != js_lexer.TComma {
+			break
+		}
+		p.lexer.Next()
+	}
+
+	p.lexer.Expect(js_lexer.TCloseParen)
+}
+
+// This handles both parenthesized types and function types:
+//
+//	"(number | string)[]"
+//	"(number, string) => void"
+func (p *parser) skipFlowParenOrFnType(flags skipTypeFlags) {
+	p.skipFlowFnTypeParams()
+
+	// Like Babel, don't allow function types in the return type of an arrow
+	// function because the "=>" could also be the start of the arrow body:
+	//
+	//   let fn = (x): (string) => x
+	//
+	if p.lexer.Token == js_lexer.TEqualsGreaterThan && !flags.has(isFlowArrowReturnTypeFlag) {
+		p.lexer.Next()
+		p.skipTypeScriptType(js_ast.LLowest)
+	}
+}
+
+func (p *parser) skipFlowArrowReturnType() {
+	p.skipTypeScriptTypeWithFlags(js_ast.LLowest, isReturnTypeFlag|isFlowArrowReturnTypeFlag)
+	if p.lexer.Token == js_lexer.TPercent {
+		p.skipFlowPredicate()
+	}
+}
+
+// "function isString(x: mixed): boolean %checks { ... }"
+// "declare function isString(x: mixed): boolean %checks(typeof x === 'string')"
+func (p *parser) skipFlowPredicate() {
+	p.lexer.Expect(js_lexer.TPercent)
+	p.lexer.ExpectContextualKeyword("checks")
+	if p.lexer.Token == js_lexer.TOpenParen && !p.lexer.HasNewlineBefore {
+		p.skipFlowBalancedTokens()
+	}
+}
+
+// This skips over a parenthesized, bracketed, or braced group of tokens
+func (p *parser) skipFlowBalancedTokens() {
+	depth := 0
+	for {
+		switch p.lexer.Token {
+		case js_lexer.TOpenParen, js_lexer.TOpenBracket, js_lexer.TOpenBrace:
+			depth++
+		case js_lexer.TCloseParen, js_lexer.TCloseBracket, js_lexer.TCloseBrace:
+			depth--
+		case js_lexer.TEndOfFile:
+			p.lexer.Unexpected()
+		}
+		p.lexer.Next()
+		if depth <= 0 {
+			return
+		}
+	}
+}
+
+// In files with both Flow and JSX syntax, "<T>(x) => x" could also be the
+// start of a JSX element. Babel tries parsing a JSX element first and falls
+// back to an arrow function if that fails. Instead of backtracking over a JSX
+// element, this looks ahead for the "=>" after the argument list (and after
+// the return type, if any) to tell them apart.
+func (p *parser) isFlowArrowFnJSX() (isArrowFn bool) {
+	oldLexer := p.lexer
+	p.lexer.IsLogDisabled = true
+
+	// Always restore the lexer since this is only a lookahead
+	defer func() {
+		r := recover()
+		if _, isLexerPanic := r.(js_lexer.LexerPanic); isLexerPanic {
+			isArrowFn = false
+		} else if r != nil {
+			panic(r)
+		}
+		p.lexer = oldLexer
+	}()
+
+	p.skipTypeScriptTypeParameters(allowConstModifier)
+	if p.lexer.Token != js_lexer.TOpenParen {
+		return false
+	}
+	p.skipFlowBalancedTokens()
+	if p.lexer.Token == js_lexer.TColon {
+		p.lexer.Next()
+		p.skipFlowArrowReturnType()
+	}
+	return p.lexer.Token == js_lexer.TEqualsGreaterThan
+}
+
+// The lexer is on a "|" token inside an object type. Check if it's the start
+// of the "|}" token sequence that ends an exact object type.
+func (p *parser) isFlowExactObjectTypeEnd() bool {
+	oldLexer := p.lexer
+	p.lexer.Next()
+	isEnd := p.lexer.Token == js_lexer.TCloseBrace
+	p.lexer = oldLexer
+	return isEnd
+}
+
+// Class fields can have a variance sigil before the key, including after
+// modifiers such as "static" and "declare". The sigil is dropped just like the
+// variance of object type members.
+func (p *parser) isFlowClassPropertyVariance(kind js_ast.PropertyKind, opts propertyOpts) bool {
+	return p.options.flow.Parse && opts.isClass && kind == js_ast.PropertyNormal && !opts.isAsync && !opts.isGenerator &&
+		(p.lexer.Token == js_lexer.TPlus || p.lexer.Token == js_lexer.TMinus)
+}
+
+// This is called after an identifier statement was parsed. It returns true if
+// the statement turned out to be a Flow type declaration and was skipped.
+func (p *parser) trySkipFlowStmt(name string, opts parseStmtOpts) bool {
+	switch name {
+	case "type":
+		// "type Foo = any"
+		if p.lexer.Token == js_lexer.TIdentifier {
+			p.skipTypeScriptTypeStmt(parseStmtOpts{isModuleScope: opts.isModuleScope})
+			return true
+		}
+
+	case "opaque":
+		// "opaque type Foo = string"
+		if p.lexer.IsContextualKeyword("type") {
+			p.lexer.Next()
+			p.skipFlowOpaqueTypeStmt(opts, false /* isDeclare */)
+			return true
+		}
+
+	case "interface":
+		// "interface Foo {}"
+		if p.lexer.Token == js_lexer.TIdentifier {
+			p.skipTypeScriptInterfaceStmt(parseStmtOpts{isModuleScope: opts.isModuleScope})
+			return true
+		}
+
+	case "declare":
+		// "declare var foo: number"
+		if p.isFlowDeclareStmt() {
+			p.skipFlowDeclareStmt(opts)
+			return true
+		}
+	}
+
+	return false
+}
+
+// "opaque type Foo = string"
+// "opaque type Foo: Super = string"
+// "declare opaque type Foo: Super"
+func (p *parser) skipFlowOpaqueTypeStmt(opts parseStmtOpts, isDeclare bool) {
+	name := p.lexer.Identifier.String
+	p.lexer.Expect(js_lexer.TIdentifier)
+
+	if opts.isModuleScope {
+		p.localTypeNames[name] = true
+	}
+
+	p.skipTypeScriptTypeParameters(0)
+
+	if p.lexer.Token == js_lexer.TColon {
+		p.lexer.Next()
+		p.skipTypeScriptType(js_ast.LLowest)
+	}
+
+	if !isDeclare || p.lexer.Token == js_lexer.TEquals {
+		p.lexer.Expect(js_lexer.TEquals)
+		p.skipTypeScriptType(js_ast.LLowest)
+	}
+
+	p.lexer.ExpectOrInsertSemicolon()
+}
+
+func (p *parser) isFlowDeclareStmt() bool {
+	switch p.lexer.Token {
+	case js_lexer.TVar, js_lexer.TConst, js_lexer.TFunction, js_lexer.TClass, js_lexer.TExport:
+		return true
+
+	case js_lexer.TIdentifier:
+		switch p.lexer.Identifier.String {
+		case "let", "module", "type", "opaque", "interface":
+			return true
+		}
+	}
+
+	return false
+}
+
+// Everything after "declare" is a type, so none of it generates any code
+func (p *parser) skipFlowDeclareStmt(opts parseStmtOpts) {
+	switch p.lexer.Token {
+	case js_lexer.TVar, js_lexer.TConst:
+		// "declare var foo: number"
+		p.lexer.Next()
+		p.skipFlowDeclareBinding()
+
+	case js_lexer.TFunction:
+		// "declare function foo(x: number): string"
+		p.lexer.Next()
+		p.skipFlowDeclareFunction(false /* isNameOptional */)
+
+	case js_lexer.TClass:
+		// "declare class Foo extends Bar mixins Baz { x: number }"
+		p.lexer.Next()
+		p.skipFlowDeclareClass(false /* isNameOptional */)
+
+	case js_lexer.TExport:
+		p.lexer.Next()
+		switch p.lexer.Token {
+		case js_lexer.TDefault:
+			p.lexer.Next()
+			switch p.lexer.Token {
+			case js_lexer.TFunction:
+				// "declare export default function foo(): void"
+				p.lexer.Next()
+				p.skipFlowDeclareFunction(true /* isNameOptional */)
+
+			case js_lexer.TClass:
+				// "declare export default class Foo {}"
+				p.lexer.Next()
+				p.skipFlowDeclareClass(true /* isNameOptional */)
+
+			default:
+				// "declare export default string"
+				p.skipTypeScriptType(js_ast.LLowest)
+				p.lexer.ExpectOrInsertSemicolon()
+			}
+
+		case js_lexer.TAsterisk:
+			// "declare export * from 'foo'"
+			// "declare export * as ns from 'foo'"
+			p.lexer.Next()
+			if p.lexer.IsContextualKeyword("as") {
+				p.lexer.Next()
+				p.parseClauseAlias("export")
+				p.lexer.Next()
+			}
+			p.lexer.ExpectContextualKeyword("from")
+			p.parsePath()
+			p.lexer.ExpectOrInsertSemicolon()
+
+		case js_lexer.TOpenBrace:
+			// "declare export { foo }"
+			// "declare export { foo } from 'bar'"
+			p.parseExportClause()
+			if p.lexer.IsContextualKeyword("from") {
+				p.lexer.Next()
+				p.parsePath()
+			}
+			p.lexer.ExpectOrInsertSemicolon()
+
+		default:
+			// "declare export var foo: number"
+			if !p.isFlowDeclareStmt() {
+				p.lexer.Unexpected()
+			}
+			p.skipFlowDeclareStmt(opts)
+		}
+
+	case js_lexer.TIdentifier:
+		switch p.lexer.Identifier.String {
+		case "let":
+			// "declare let foo: number"
+			p.lexer.Next()
+			p.skipFlowDeclareBinding()
+
+		case "module":
+			p.lexer.Next()
+
+			// "declare module.exports: { foo: number }"
+			if p.lexer.Token == js_lexer.TDot {
+				p.lexer.Next()
+				p.lexer.ExpectContextualKeyword("exports")
+				p.lexer.Expect(js_lexer.TColon)
+				p.skipTypeScriptType(js_ast.LLowest)
+				p.lexer.ExpectOrInsertSemicolon()
+				return
+			}
+
+			// "declare module 'foo' { ... }"
+			// "declare module Foo { ... }"
+			if p.lexer.Token != js_lexer.TStringLiteral {
+				p.lexer.Expect(js_lexer.TIdentifier)
+			} else {
+				p.lexer.Next()
+			}
+			if p.lexer.Token != js_lexer.TOpenBrace {
+				p.lexer.Expect(js_lexer.TOpenBrace)
+			}
+			p.skipFlowBalancedTokens()
+
+		case "type":
+			// "declare type Foo = number"
+			p.lexer.Next()
+			p.skipTypeScriptTypeStmt(parseStmtOpts{isModuleScope: opts.isModuleScope})
+
+		case "opaque":
+			// "declare opaque type Foo"
+			p.lexer.Next()
+			p.lexer.ExpectContextualKeyword("type")
+			p.skipFlowOpaqueTypeStmt(opts, true /* isDeclare */)
+
+		case "interface":
+			// "declare interface Foo {}"
+			p.lexer.Next()
+			p.skipTypeScriptInterfaceStmt(parseStmtOpts{isModuleScope: opts.isModuleScope})
+
+		default:
+			p.lexer.Unexpected()
+		}
+
+	default:
+		p.lexer.Unexpected()
+	}
+}
+
+func (p *parser) skipFlowDeclareBinding() {
+	p.lexer.Expect(js_lexer.TIdentifier)
+	if p.lexer.Token == js_lexer.TColon {
+		p.lexer.Next()
+		p.skipTypeScriptType(js_ast.LLowest)
+	}
+	p.lexer.ExpectOrInsertSemicolon()
+}
+
+func (p *parser) skipFlowDeclareFunction(isNameOptional bool) {
+	if !isNameOptional || p.lexer.Token == js_lexer.TIdentifier {
+		p.lexer.Expect(js_lexer.TIdentifier)
+	}
+	p.skipTypeScriptTypeParameters(0)
+	p.skipFlowFnTypeParams()
+	p.lexer.Expect(js_lexer.TColon)
+	p.skipTypeScriptReturnType()
+	p.lexer.ExpectOrInsertSemicolon()
+}
+
+func (p *parser) skipFlowDeclareClass(isNameOptional bool) {
+	if !isNameOptional || (p.lexer.Token == js_lexer.TIdentifier && !p.lexer.IsContextualKeyword("mixins") &&
+		!p.lexer.IsContextualKeyword("implements")) {
+		p.lexer.Expect(js_lexer.TIdentifier)
+	}
+	p.skipTypeScriptTypeParameters(0)
+
+	// "declare class Foo extends Bar<T> {}"
+	if p.lexer.Token == js_lexer.TExtends {
+		p.lexer.Next()
+		p.skipTypeScriptType(js_ast.LLowest)
+	}
+
+	// "declare class Foo mixins Bar, Baz {}"
+	// "declare class Foo implements Bar, Baz {}"
+	for p.lexer.IsContextualKeyword("mixins") || p.lexer.IsContextualKeyword("implements") {
+		p.lexer.Next()
+		for {
+			p.skipTypeScriptType(js_ast.LLowest)
+			if p.lexer.Token != js_lexer.TComma {
+				break
+			}
+			p.lexer.Next()
+		}
+	}
+
+	p.skipTypeScriptObjectType()
+}
+
+// This is called after the "type" or "typeof" keyword in an import statement.
+// It returns false if this turned out to not be a type-only import after all
+// (e.g. "import type from 'foo'" imports a default export called "type").
+//
+//	"import type Foo from 'bar'"
+//	"import type Foo, { Bar } from 'bar'"
+//	"import type { Foo } from 'bar'"
+//	"import typeof * as ns from 'bar'"
+func (p *parser) trySkipFlowTypeImport() bool {
+	switch p.lexer.Token {
+	case js_lexer.TIdentifier:
+		if p.lexer.IsContextualKeyword("from") {
+			return false
+		}
+		p.lexer.Next()
+		if p.lexer.Token == js_lexer.TComma {
+			p.lexer.Next()
+			if p.lexer.Token == js_lexer.TAsterisk {
+				p.lexer.Next()
+				p.lexer.ExpectContextualKeyword("as")
+				p.lexer.Expect(js_lexer.TIdentifier)
+			} else {
+				p.parseImportClause()
+			}
+		}
+
+	case js_lexer.TOpenBrace:
+		p.parseImportClause()
+
+	case js_lexer.TAsterisk:
+		p.lexer.Next()
+		p.lexer.ExpectContextualKeyword("as")
+		p.lexer.Expect(js_lexer.TIdentifier)
+
+	default:
+		return false
+	}
+
+	p.lexer.ExpectContextualKeyword("from")
+	p.parsePath()
+	p.lexer.ExpectOrInsertSemicolon()
+	return true
+}
+
+// Flow removes import statements that only import types, but an import
+// statement with an empty import clause is still kept for its side effects:
+//
+//	// Remove this
+//	import { type Foo } from 'bar'
+//
+//	// Keep this
+//	import {} from 'bar'
+func (p *parser) isFlowTypeOnlyImportClause(openBraceLoc logger.Loc, items []js_ast.ClauseItem) bool {
+	if len(items) > 0 {
+		return false
+	}
+	text := p.source.Contents[openBraceLoc.Start+1:]
+	if end := strings.IndexByte(text, '}'); end != -1 {
+		text = text[:end]
+	}
+	return strings.TrimSpace(text) != ""
+}
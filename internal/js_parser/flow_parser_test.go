@@ -0,0 +1,240 @@
+package js_parser
+
+import (
+	"testing"
+
+	"github.com/evanw/esbuild/internal/config"
+)
+
+func expectParseErrorFlow(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectParseErrorCommon(t, contents, expected, config.Options{
+		Flow: config.FlowOptions{
+			Parse: true,
+		},
+	})
+}
+
+func expectPrintedFlow(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		Flow: config.FlowOptions{
+			Parse: true,
+		},
+	})
+}
+
+func expectPrintedFlowJSX(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		Flow: config.FlowOptions{
+			Parse: true,
+		},
+		JSX: config.JSXOptions{
+			Parse: true,
+		},
+	})
+}
+
+func TestFlowPragma(t *testing.T) {
+	check := func(contents string, expected bool) {
+		t.Helper()
+		t.Run(contents, func(t *testing.T) {
+			t.Helper()
+			if HasFlowPragma(contents) != expected {
+				t.Fatalf("Expected HasFlowPragma to return %v", expected)
+			}
+		})
+	}
+
+	check("// @flow\nlet x", true)
+	check("/* @flow */ let x", true)
+	check("/**\n * @flow strict\n */\nlet x", true)
+	check("// @noflow\nlet x", true)
+	check("\uFEFF// @flow\nlet x", true)
+	check("#!/usr/bin/env node\n// @flow\nlet x", true)
+	check("// Copyright\n\n/* @flow */\nlet x", true)
+	check("// @flow", true)
+
+	check("", false)
+	check("let x // @flow", false)
+	check("let x\n// @flow", false)
+	check("'use strict'\n// @flow", false)
+	check("// @flowtype\nlet x", false)
+	check("// @flow-typed\nlet x", false)
+	check("// me@flow.org\nlet x", false)
+	check("// flow\nlet x", false)
+}
+
+func TestFlowTypes(t *testing.T) {
+	expectPrintedFlow(t, "let x: number = 1", "let x = 1;\n")
+	expectPrintedFlow(t, "let x: ?number", "let x;\n")
+	expectPrintedFlow(t, "let x: ?number[]", "let x;\n")
+	expectPrintedFlow(t, "let x: *", "let x;\n")
+	expectPrintedFlow(t, "let x: Array<?string>", "let x;\n")
+	expectPrintedFlow(t, "let x: $ReadOnly<{| a: number |}>", "let x;\n")
+	expectPrintedFlow(t, "let x: {| a: number, b?: string |}", "let x;\n")
+	expectPrintedFlow(t, "let x: {||}", "let x;\n")
+	expectPrintedFlow(t, "let x: {| a: number | string |}", "let x;\n")
+	expectPrintedFlow(t, "let x: { a: number, ...B, ... }", "let x;\n")
+	expectPrintedFlow(t, "let x: { +a: number, -b: string }", "let x;\n")
+	expectPrintedFlow(t, "let x: { [key: string]: number }", "let x;\n")
+	expectPrintedFlow(t, "let x: { m(string, number): void }", "let x;\n")
+	expectPrintedFlow(t, "let x: Obj?.['a']", "let x;\n")
+	expectPrintedFlow(t, "let x: typeof y", "let x;\n")
+
+	// Function types
+	expectPrintedFlow(t, "let x: (string, number) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (a: string, b?: number) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (...Array<string>) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (...rest: Array<string>) => void", "let x;\n")
+	expectPrintedFlow(t, "let x: <T>(T) => T", "let x;\n")
+	expectPrintedFlow(t, "let x: string => void", "let x;\n")
+	expectPrintedFlow(t, "let x: (string | number)[]", "let x;\n")
+	expectPrintedFlow(t, "let x: ?() => void", "let x;\n")
+	expectPrintedFlow(t, "let x: A | (B) => C", "let x;\n")
+
+	// Type casts
+	expectPrintedFlow(t, "x = (y: any)", "x = y;\n")
+	expectPrintedFlow(t, "x = ((y: any): string)", "x = y;\n")
+	expectPrintedFlow(t, "f((y: any), (z: number))", "f(y, z);\n")
+	expectPrintedFlow(t, "x = y as any", "x = y;\n")
+
+	// TypeScript-only syntax is not allowed
+	expectParseErrorFlow(t, "x = y satisfies any", "<stdin>: ERROR: Expected \";\" but found \"satisfies\"\n")
+	expectParseErrorFlow(t, "let x!: number", "<stdin>: ERROR: Expected \";\" but found \"!\"\n")
+	expectParseErrorFlow(t, "enum Foo {}", "<stdin>: ERROR: Unexpected \"enum\"\n")
+}
+
+func TestFlowFunctions(t *testing.T) {
+	expectPrintedFlow(t, "function f(x: number, y?: string): void {}", "function f(x, y) {\n}\n")
+	expectPrintedFlow(t, "function f<T: Object, +U, -V = string>(x: T): U {}", "function f(x) {\n}\n")
+	expectPrintedFlow(t, "function f(x: mixed): boolean %checks { return !!x }", "function f(x) {\n  return !!x;\n}\n")
+	expectPrintedFlow(t, "function f(): () => void {}", "function f() {\n}\n")
+	expectPrintedFlow(t, "function f(this: Foo) {}", "function f() {\n}\n")
+	expectPrintedFlow(t, "async function f(): Promise<void> {}", "async function f() {\n}\n")
+
+	// Arrow functions
+	expectPrintedFlow(t, "let f = (x: number): string => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = (x): ?string => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = (x): string | number => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = (x): (string => void) => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = (x: mixed): boolean %checks => !!x", "let f = (x) => !!x;\n")
+	expectPrintedFlow(t, "let f = <T>(x: T): T => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = async <T>(x: T): Promise<T> => x", "let f = async (x) => x;\n")
+	expectPrintedFlow(t, "let f = (x?: number) => x", "let f = (x) => x;\n")
+	expectPrintedFlow(t, "let f = (x: number = 1, ...y: Array<any>) => x", "let f = (x = 1, ...y) => x;\n")
+	expectPrintedFlow(t, "let f = async (x: number): Promise<number> => x", "let f = async (x) => x;\n")
+	expectPrintedFlow(t, "let o = { m(x: number): void {} }", "let o = { m(x) {\n} };\n")
+	expectPrintedFlow(t, "x ? (y) : (z)", "x ? y : z;\n")
+	expectPrintedFlow(t, "x ? (y: any) : (z)", "x ? y : z;\n")
+	expectPrintedFlow(t, "a < b > c", "a < b > c;\n")
+	expectPrintedFlow(t, "f<T>(x)", "f(x);\n")
+}
+
+func TestFlowClasses(t *testing.T) {
+	expectPrintedFlow(t, "class Foo { x: number }", "class Foo {\n}\n")
+	expectPrintedFlow(t, "class Foo { x: number = 1 }", "class Foo {\n  x = 1;\n}\n")
+	expectPrintedFlow(t, "class Foo { x }", "class Foo {\n  x;\n}\n")
+	expectPrintedFlow(t, "class Foo { static x: number }", "class Foo {\n}\n")
+	expectPrintedFlow(t, "class Foo { #x: number }", "class Foo {\n  #x;\n}\n")
+	expectPrintedFlow(t, "class Foo { +x: number; -y: string = '' }", "class Foo {\n  y = \"\";\n}\n")
+	expectPrintedFlow(t, "class Foo { static +p: number = 1; -q: T }", "class Foo {\n  static p = 1;\n}\n")
+	expectPrintedFlow(t, "class Foo { static -x: number; +[y]: number = 1 }", "class Foo {\n  [y] = 1;\n}\n")
+	expectPrintedFlow(t, "class Foo { declare +x: number; static declare -y: number }", "class Foo {\n}\n")
+	expectPrintedFlow(t, "class Foo { static +#x: number = 1 }", "class Foo {\n  static #x = 1;\n}\n")
+	expectParseErrorFlow(t, "class Foo { async +x() {} }", "<stdin>: ERROR: Expected identifier but found \"+\"\n")
+	expectParseErrorFlow(t, "class Foo { get +x() {} }", "<stdin>: ERROR: Expected identifier but found \"+\"\n")
+	expectPrintedFlow(t, "class Foo { declare x: number }", "class Foo {\n}\n")
+	expectPrintedFlow(t, "class Foo { [key: string]: number }", "class Foo {\n}\n")
+	expectPrintedFlow(t, "class Foo<+T, -U> extends Bar<T> implements Baz, Qux {}", "class Foo extends Bar {\n}\n")
+	expectPrintedFlow(t, "class Foo { m<T>(x: T): T { return x } }", "class Foo {\n  m(x) {\n    return x;\n  }\n}\n")
+	expectPrintedFlow(t, "class Foo { constructor(x: number) {} }", "class Foo {\n  constructor(x) {\n  }\n}\n")
+
+	// Parameter properties are TypeScript-only
+	expectParseErrorFlow(t, "class Foo { constructor(private x) {} }", "<stdin>: ERROR: Expected \")\" but found \"x\"\n")
+}
+
+func TestFlowTypeDeclarations(t *testing.T) {
+	expectPrintedFlow(t, "type Foo = number", "")
+	expectPrintedFlow(t, "type Foo<T> = { x: T }", "")
+	expectPrintedFlow(t, "opaque type Foo = number", "")
+	expectPrintedFlow(t, "opaque type Foo: Super = number", "")
+	expectPrintedFlow(t, "interface Foo { x: number }", "")
+	expectPrintedFlow(t, "interface Foo extends Bar { x: number }", "")
+	expectPrintedFlow(t, "export type Foo = number", "")
+	expectPrintedFlow(t, "export opaque type Foo = number", "")
+	expectPrintedFlow(t, "export interface Foo {}", "")
+	expectPrintedFlow(t, "type Foo = number; export type { Foo }", "")
+	expectPrintedFlow(t, "type Foo = number; export { Foo }", "export {};\n")
+
+	// These are still identifiers when not followed by a type declaration
+	expectPrintedFlow(t, "type = 1", "type = 1;\n")
+	expectPrintedFlow(t, "opaque = 1", "opaque = 1;\n")
+	expectPrintedFlow(t, "interface\nFoo", "interface;\nFoo;\n")
+	expectPrintedFlow(t, "declare = 1", "declare = 1;\n")
+	expectPrintedFlow(t, "declare\nvar x", "declare;\nvar x;\n")
+}
+
+func TestFlowDeclare(t *testing.T) {
+	expectPrintedFlow(t, "declare var x: number", "")
+	expectPrintedFlow(t, "declare let x: number", "")
+	expectPrintedFlow(t, "declare const x: number", "")
+	expectPrintedFlow(t, "declare function f(x: number): string", "")
+	expectPrintedFlow(t, "declare function f<T>(T): T;", "")
+	expectPrintedFlow(t, "declare function f(x: mixed): boolean %checks(typeof x === 'string')", "")
+	expectPrintedFlow(t, "declare class Foo extends Bar mixins Baz { x: number; m(): void }", "")
+	expectPrintedFlow(t, "declare type Foo = number", "")
+	expectPrintedFlow(t, "declare opaque type Foo", "")
+	expectPrintedFlow(t, "declare opaque type Foo: Super", "")
+	expectPrintedFlow(t, "declare interface Foo {}", "")
+	expectPrintedFlow(t, "declare module 'foo' { declare module.exports: { x: number }; }", "")
+	expectPrintedFlow(t, "declare module Foo { declare var x: number }", "")
+	expectPrintedFlow(t, "declare module.exports: { x: number }", "")
+	expectPrintedFlow(t, "declare export var x: number", "")
+	expectPrintedFlow(t, "declare export function f(): void", "")
+	expectPrintedFlow(t, "declare export class Foo {}", "")
+	expectPrintedFlow(t, "declare export default class {}", "")
+	expectPrintedFlow(t, "declare export default function (): void", "")
+	expectPrintedFlow(t, "declare export default string", "")
+	expectPrintedFlow(t, "declare export * from 'foo'", "")
+	expectPrintedFlow(t, "declare export { x } from 'foo'", "")
+}
+
+func TestFlowImports(t *testing.T) {
+	expectPrintedFlow(t, "import type Foo from 'bar'", "")
+	expectPrintedFlow(t, "import type { Foo } from 'bar'", "")
+	expectPrintedFlow(t, "import type Foo, { Bar } from 'bar'", "")
+	expectPrintedFlow(t, "import type * as ns from 'bar'", "")
+	expectPrintedFlow(t, "import typeof Foo from 'bar'", "")
+	expectPrintedFlow(t, "import typeof { Foo } from 'bar'", "")
+	expectPrintedFlow(t, "import typeof * as ns from 'bar'", "")
+	expectPrintedFlow(t, "import { type Foo } from 'bar'", "")
+	expectPrintedFlow(t, "import { typeof Foo } from 'bar'", "")
+	expectPrintedFlow(t, "import { type Foo, typeof Bar } from 'bar'", "")
+	expectPrintedFlow(t, "import { type Foo, Bar } from 'bar'; Bar()", "import { Bar } from \"bar\";\nBar();\n")
+	expectPrintedFlow(t, "import {} from 'bar'", "import {} from \"bar\";\n")
+
+	// These import values named "type" and "typeof"
+	expectPrintedFlow(t, "import type from 'bar'; type()", "import type from \"bar\";\ntype();\n")
+	expectPrintedFlow(t, "import type, { Foo } from 'bar'; type(Foo)", "import type, { Foo } from \"bar\";\ntype(Foo);\n")
+	expectPrintedFlow(t, "import { type } from 'bar'; type()", "import { type } from \"bar\";\ntype();\n")
+	expectPrintedFlow(t, "import { type as foo } from 'bar'; foo()", "import { type as foo } from \"bar\";\nfoo();\n")
+
+	// Unlike TypeScript, Flow doesn't remove unused imports
+	expectPrintedFlow(t, "import Foo from 'bar'", "import Foo from \"bar\";\n")
+}
+
+func TestFlowJSX(t *testing.T) {
+	expectPrintedFlowJSX(t, "let x: number = <div />", "let x = /* @__PURE__ */ React.createElement(\"div\", null);\n")
+	expectPrintedFlowJSX(t, "let f = <T: mixed>(x: T): T => x", "let f = (x) => x;\n")
+	expectPrintedFlowJSX(t, "let f = <T,>(x: T): T => x", "let f = (x) => x;\n")
+	expectPrintedFlowJSX(t, "let f = <T>(x: T): T => x", "let f = (x) => x;\n")
+	expectPrintedFlowJSX(t, "let f = <T>(x: T, { y }: { y: T } = {}) => x", "let f = (x, { y } = {}) => x;\n")
+	expectPrintedFlowJSX(t, "let f = <T>(x: T): Array<T> %checks => [x]", "let f = (x) => [x];\n")
+	expectPrintedFlowJSX(t, "let x = <T>(y)</T>", "let x = /* @__PURE__ */ React.createElement(T, null, \"(y)\");\n")
+	expectPrintedFlowJSX(t, "let x = <b>(y): z</b>", "let x = /* @__PURE__ */ React.createElement(\"b\", null, \"(y): z\");\n")
+	expectPrintedFlowJSX(t, "let x = <b>(it's)</b>", "let x = /* @__PURE__ */ React.createElement(\"b\", null, \"(it's)\");\n")
+	expectPrintedFlowJSX(t, "class Foo extends React.Component<Props> { props: Props; render() { return <div /> } }",
+		"class Foo extends React.Component {\n  render() {\n    return /* @__PURE__ */ React.createElement(\"div\", null);\n  }\n}\n")
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/evanw/esbuild/internal/ast"
 	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/config"
+	"github.com/evanw/esbuild/internal/dts_ast"
 	"github.com/evanw/esbuild/internal/helpers"
 	"github.com/evanw/esbuild/internal/js_ast"
 	"github.com/evanw/esbuild/internal/js_lexer"
@@ -50,8 +51,13 @@ type parser struct {
 	injectedDotNames           map[string][]injectedDotName
 	dropLabelsMap              map[string]struct{}
 	exprComments               map[logger.Loc][]string
+	removedStmtComments        []string
+	trailingComments           map[logger.Loc][]string
 	mangledProps               map[string]ast.Ref
 	reservedProps              map[string]bool
+	tsPrivateProps             map[string]bool
+	tsPrivatePropsByClass      map[logger.Loc]map[string]bool
+	tsPrivatePropsUsedNormally map[string]bool
 	symbolUses                 map[ast.Ref]js_ast.SymbolUse
 	importSymbolPropertyUses   map[ast.Ref]map[string]js_ast.SymbolUse
 	symbolCallUses             map[ast.Ref]js_ast.SymbolCallUse
@@ -93,8 +99,17 @@ type parser struct {
 	localTypeNames             map[string]bool
 	tsEnums                    map[ast.Ref]map[string]js_ast.TSEnumValue
 	constValues                map[ast.Ref]js_ast.ConstValue
+	constValueCandidates       map[ast.Ref]js_ast.ConstValue
+	inlinableFunctions         map[ast.Ref]js_ast.InlinableFunction
+	collapsibleObjects         map[ast.Ref][]js_ast.CollapsibleLeaf
+	propertyChainUses          map[ast.Ref]map[string]js_ast.PropertyChainUse
+	escapingSymbols            map[ast.Ref]bool
+	constObjectCandidates      map[ast.Ref]map[string]js_ast.TSEnumValue
+	unfrozenConstObjects       map[ast.Ref]bool
 	propMethodValue            js_ast.E
 	propMethodDecoratorScope   *js_ast.Scope
+	propDerivedCtorValue       js_ast.E
+	propDerivedCtorThisRef     ast.Ref
 
 	// This is the reference to the generated function argument for the namespace,
 	// which is different than the reference to the namespace itself:
@@ -181,6 +196,12 @@ type parser struct {
 	// with the guarantee that all will be found.
 	relocatedTopLevelVars []ast.LocRef
 
+	// When "let" and "const" are unsupported, these bindings are converted into
+	// "var" bindings. We need to track some extra information about them to be
+	// able to preserve their block scoping semantics. This is populated during
+	// parsing so that references before the declaration can be detected.
+	loweredLetOrConstRefs map[ast.Ref]*loweredLetOrConst
+
 	// We need to lower private names such as "#foo" if they are used in a brand
 	// check such as "#foo in x" even if the private name syntax would otherwise
 	// be supported. This is because private names are a newly-added feature.
@@ -205,6 +226,11 @@ type parser struct {
 	tempRefsToDeclare         []tempRef
 	topLevelTempRefsToDeclare []tempRef
 
+	// JSX elements that were cached in a top-level variable because they never
+	// change. A constant parent element caches itself instead of its children,
+	// so this is used to undo the caching for the children.
+	jsxHoistedElements map[*js_ast.EBinary]js_ast.Expr
+
 	lexer js_lexer.Lexer
 
 	// Temporary variables used for lowering
@@ -228,6 +254,30 @@ type parser struct {
 	// (Or whatever was specified in the "importSource" option)
 	jsxRuntimeImports map[string]ast.LocRef
 	jsxLegacyImports  map[string]ast.LocRef
+	jsxTargetImports  map[string]ast.LocRef
+
+	// Built-ins that are used by this file but that are missing from the
+	// target environment. Each one will get a polyfill import.
+	usedBuiltIns compat.BuiltIn
+
+	// This is only present when generating declarations for TypeScript files.
+	// It records the type annotations that are otherwise thrown away.
+	dts *dtsRecorder
+
+	// This is only present for TypeScript's "emitDecoratorMetadata" setting.
+	// Class fields are keyed by property location, arguments by binding
+	// location, and return types by the location of the "(" token.
+	metadataTypes      map[logger.Loc]logger.Range
+	metadataImportUses map[ast.Ref]uint32
+
+	// This is only present when stripping types without printing the AST. It
+	// maps source offsets to the characters that should be written there after
+	// the types have been replaced with whitespace (see "StripTypes").
+	strippedTypeFixups map[int32]byte
+
+	// This is only present when "erasableSyntaxOnly" is enabled. It contains
+	// the names of all identifiers that were referenced from a type.
+	namesUsedInTypes map[string]bool
 
 	// For lowering private methods
 	weakMapRef ast.Ref
@@ -243,6 +293,7 @@ type parser struct {
 	latestArrowArgLoc      logger.Loc
 	forbidSuffixAfterAsLoc logger.Loc
 	firstJSXElementLoc     logger.Loc
+	enclosingClassBodyLoc  logger.Loc
 
 	fnOrArrowDataVisit fnOrArrowDataVisit
 
@@ -454,23 +505,32 @@ type optionsThatSupportStructuralEquality struct {
 	unsupportedJSFeatures             compat.JSFeature
 	unsupportedJSFeatureOverrides     compat.JSFeature
 	unsupportedJSFeatureOverridesMask compat.JSFeature
+	unsupportedBuiltIns               compat.BuiltIn
+	polyfill                          string
 
 	// Byte-sized values go here (gathered together here to keep this object compact)
 	ts                     config.TSOptions
+	flow                   config.FlowOptions
 	mode                   config.Mode
 	platform               config.Platform
 	outputFormat           config.Format
 	asciiOnly              bool
 	keepNames              bool
+	looseIteration         bool
+	declarations           bool
 	minifySyntax           bool
 	minifyIdentifiers      bool
+	inlineFunctions        bool
+	collapseProperties     bool
 	minifyWhitespace       bool
+	preserveComments       bool
 	omitRuntimeForTests    bool
 	omitJSXRuntimeForTests bool
 	ignoreDCEAnnotations   bool
 	treeShaking            bool
 	dropDebugger           bool
 	mangleQuoted           bool
+	manglePrivate          bool
 
 	// This is an internal-only option used for the implementation of Yarn PnP
 	decodeHydrateRuntimeStateYarnPnP bool
@@ -498,23 +558,32 @@ func OptionsFromConfig(options *config.Options) Options {
 			unsupportedJSFeatures:             options.UnsupportedJSFeatures,
 			unsupportedJSFeatureOverrides:     options.UnsupportedJSFeatureOverrides,
 			unsupportedJSFeatureOverridesMask: options.UnsupportedJSFeatureOverridesMask,
+			unsupportedBuiltIns:               options.UnsupportedBuiltIns,
+			polyfill:                          options.Polyfill,
 			originalTargetEnv:                 options.OriginalTargetEnv,
 			ts:                                options.TS,
+			flow:                              options.Flow,
 			mode:                              options.Mode,
 			platform:                          options.Platform,
 			outputFormat:                      options.OutputFormat,
 			moduleTypeData:                    options.ModuleTypeData,
 			asciiOnly:                         options.ASCIIOnly,
 			keepNames:                         options.KeepNames,
+			looseIteration:                    options.LooseIteration,
+			declarations:                      options.Declarations,
 			minifySyntax:                      options.MinifySyntax,
 			minifyIdentifiers:                 options.MinifyIdentifiers,
+			inlineFunctions:                   options.InlineFunctions,
+			collapseProperties:                options.CollapseProperties,
 			minifyWhitespace:                  options.MinifyWhitespace,
+			preserveComments:                  options.PreserveComments,
 			omitRuntimeForTests:               options.OmitRuntimeForTests,
 			omitJSXRuntimeForTests:            options.OmitJSXRuntimeForTests,
 			ignoreDCEAnnotations:              options.IgnoreDCEAnnotations,
 			treeShaking:                       options.TreeShaking,
 			dropDebugger:                      options.DropDebugger,
 			mangleQuoted:                      options.MangleQuoted,
+			manglePrivate:                     options.ManglePrivate,
 		},
 	}
 }
@@ -662,6 +731,10 @@ type fnOrArrowDataVisit struct {
 	isInsideSwitch                 bool
 	isOutsideFnOrArrow             bool
 	shouldLowerSuperPropertyAccess bool
+
+	// This is the innermost loop in this function or arrow function when "let"
+	// and "const" are being lowered. It's used to detect "yield" and "await".
+	letOrConstLoop *letOrConstLoop
 }
 
 // This is function-specific information used during visiting. It is saved and
@@ -684,6 +757,13 @@ type fnOnlyDataVisit struct {
 	// should be replaced with the class name.
 	shouldReplaceThisWithInnerClassNameRef bool
 
+	// If non-nil, we're inside the constructor (or an instance field initializer)
+	// of a derived class that's being lowered to a constructor function. The base
+	// class constructor may return a different object, so "this" expressions
+	// must be replaced with this variable, which holds the lowered "super()"
+	// call's return value.
+	derivedClassThisRef *ast.Ref
+
 	// This is true if "this" is equal to the class name. It's true if we're in a
 	// static class field initializer, a static class method, or a static class
 	// block.
@@ -694,6 +774,11 @@ type fnOnlyDataVisit struct {
 	// if one is missing so this will always be present inside a class body.
 	innerClassNameRef *ast.Ref
 
+	// These are the TypeScript "private" and "protected" members of the
+	// enclosing class. Only "this" and "super" property accesses to these
+	// members are mangled when "manglePrivate" is enabled.
+	classTSPrivateProps map[string]bool
+
 	// If we're inside an async arrow function and async functions are not
 	// supported, then we will have to convert that arrow function to a generator
 	// function. That means references to "arguments" inside the arrow function
@@ -727,6 +812,11 @@ type fnOnlyDataVisit struct {
 	//   };
 	//
 	silenceMessageAboutThisBeingUndefined bool
+
+	// This is the innermost loop in this function when "let" and "const" are
+	// being lowered. Unlike the one in "fnOrArrowDataVisit", this one is also
+	// visible inside arrow functions since they inherit "this" and "arguments".
+	letOrConstLoop *letOrConstLoop
 }
 
 const bloomFilterSize = 251
@@ -1014,6 +1104,11 @@ func (p *parser) selectLocalKind(kind js_ast.LocalKind) js_ast.LocalKind {
 		return js_ast.LocalVar
 	}
 
+	// Generated code must not use "let" and "const" if they aren't supported
+	if (kind == js_ast.LocalLet || kind == js_ast.LocalConst) && p.options.unsupportedJSFeatures.Has(compat.ConstAndLet) {
+		return js_ast.LocalVar
+	}
+
 	// Optimization: use "let" instead of "const" because it's shorter. This is
 	// only done when bundling because assigning to "const" is only an error when
 	// bundling.
@@ -1789,7 +1884,6 @@ type deferredErrors struct {
 	// These are errors for expressions
 	invalidExprDefaultValue  logger.Range
 	invalidExprAfterQuestion logger.Range
-	arraySpreadFeature       logger.Range
 
 	// These errors are for arrow functions
 	invalidParens []logger.Range
@@ -1802,9 +1896,6 @@ func (from *deferredErrors) mergeInto(to *deferredErrors) {
 	if from.invalidExprAfterQuestion.Len > 0 {
 		to.invalidExprAfterQuestion = from.invalidExprAfterQuestion
 	}
-	if from.arraySpreadFeature.Len > 0 {
-		to.arraySpreadFeature = from.arraySpreadFeature
-	}
 	if len(from.invalidParens) > 0 {
 		if len(to.invalidParens) > 0 {
 			to.invalidParens = append(to.invalidParens, from.invalidParens...)
@@ -1823,10 +1914,6 @@ func (p *parser) logExprErrors(errors *deferredErrors) {
 		r := errors.invalidExprAfterQuestion
 		p.log.AddError(&p.tracker, r, fmt.Sprintf("Unexpected %q", p.source.Contents[r.Loc.Start:r.Loc.Start+r.Len]))
 	}
-
-	if errors.arraySpreadFeature.Len > 0 {
-		p.markSyntaxFeature(compat.ArraySpread, errors.arraySpreadFeature)
-	}
 }
 
 func (p *parser) logDeferredArrowArgErrors(errors *deferredErrors) {
@@ -1960,11 +2047,15 @@ func (p *parser) checkForLegacyOctalLiteral(e js_ast.E) {
 }
 
 func (p *parser) notesForAssertTypeJSON(record *ast.ImportRecord, alias string) []logger.MsgData {
+	what := "attribute"
+	if record.AssertOrWith.Keyword == ast.AssertKeyword {
+		what = "assertion"
+	}
 	return []logger.MsgData{p.tracker.MsgData(
-		js_lexer.RangeOfImportAssertion(p.source, *ast.FindAssertion(record.Assertions.Entries, "type")),
-		"This is considered an import of a standard JSON module because of the import assertion here:"),
-		{Text: fmt.Sprintf("You can either keep the import assertion and only use the \"default\" import, "+
-			"or you can remove the import assertion and use the %q import (which is non-standard behavior).", alias)}}
+		js_lexer.RangeOfImportAssertOrWith(p.source, *ast.FindAssertOrWithEntry(record.AssertOrWith.Entries, "type")),
+		fmt.Sprintf("This is considered an import of a standard JSON module because of the import %s here:", what)),
+		{Text: fmt.Sprintf("You can either keep the import %s and only use the \"default\" import, "+
+			"or you can remove the import %s and use the %q import (which is non-standard behavior).", what, what, alias)}}
 }
 
 // This assumes the caller has already checked for TStringLiteral or TNoSubstitutionTemplateLiteral
@@ -2022,6 +2113,13 @@ func (p *parser) parseProperty(startLoc logger.Loc, kind js_ast.PropertyKind, op
 	var flags js_ast.PropertyFlags
 	var key js_ast.Expr
 	var closeBracketLoc logger.Loc
+
+	// "class Foo { +x: number }"
+	// "class Foo { static -x: number }"
+	if p.isFlowClassPropertyVariance(kind, opts) {
+		p.lexer.Next()
+	}
+
 	keyRange := p.lexer.Range()
 
 	switch p.lexer.Token {
@@ -2053,13 +2151,12 @@ func (p *parser) parseProperty(startLoc logger.Loc, kind js_ast.PropertyKind, op
 
 	case js_lexer.TOpenBracket:
 		flags |= js_ast.PropertyIsComputed
-		p.markSyntaxFeature(compat.ObjectExtensions, p.lexer.Range())
 		p.lexer.Next()
 		wasIdentifier := p.lexer.Token == js_lexer.TIdentifier
 		expr := p.parseExpr(js_ast.LComma)
 
 		// Handle index signatures
-		if p.options.ts.Parse && p.lexer.Token == js_lexer.TColon && wasIdentifier && opts.isClass {
+		if p.hasTypeSyntax() && p.lexer.Token == js_lexer.TColon && wasIdentifier && opts.isClass {
 			if _, ok := expr.Data.(*js_ast.EIdentifier); ok {
 				if opts.tsDeclareRange.Len != 0 {
 					p.log.AddError(&p.tracker, opts.tsDeclareRange, "\"declare\" cannot be used with an index signature")
@@ -2109,6 +2206,9 @@ func (p *parser) parseProperty(startLoc logger.Loc, kind js_ast.PropertyKind, op
 				case js_lexer.TOpenBracket, js_lexer.TNumericLiteral, js_lexer.TStringLiteral,
 					js_lexer.TAsterisk, js_lexer.TPrivateIdentifier:
 					couldBeModifierKeyword = true
+
+				case js_lexer.TPlus, js_lexer.TMinus:
+					couldBeModifierKeyword = p.isFlowClassPropertyVariance(kind, opts)
 				}
 			}
 
@@ -2146,7 +2246,7 @@ func (p *parser) parseProperty(startLoc logger.Loc, kind js_ast.PropertyKind, op
 					}
 
 				case "declare":
-					if !p.lexer.HasNewlineBefore && opts.isClass && p.options.ts.Parse && opts.tsDeclareRange.Len == 0 && raw == name.String {
+					if !p.lexer.HasNewlineBefore && opts.isClass && p.hasTypeSyntax() && opts.tsDeclareRange.Len == 0 && raw == name.String {
 						opts.tsDeclareRange = nameRange
 						scopeIndex := len(p.scopesInOrder)
 
@@ -2184,7 +2284,14 @@ func (p *parser) parseProperty(startLoc logger.Loc, kind js_ast.PropertyKind, op
 				case "private", "protected", "public", "readonly", "override":
 					// Skip over TypeScript keywords
 					if opts.isClass && p.options.ts.Parse && raw == name.String {
-						return p.parseProperty(startLoc, kind, opts, nil)
+						p.stripTypesInRange(nameRange)
+						prop, ok := p.parseProperty(startLoc, kind, opts, nil)
+						if ok && (name.String == "private" || name.String == "protected") && !prop.Flags.Has(js_ast.PropertyIsComputed) {
+							if str, ok := prop.Key.Data.(*js_ast.EString); ok {
+								p.recordTSPrivateProp(helpers.UTF16ToString(str.Value))
+							}
+						}
+						return prop, ok
 					}
 				}
 			} else if p.lexer.Token == js_lexer.TOpenBrace && name.String == "static" && len(opts.decorators) == 0 {
@@ -2258,16 +2365,26 @@ func (p *parser) parseProperty(startLoc logger.Loc, kind js_ast.PropertyKind, op
 
 	hasTypeParameters := false
 	hasDefiniteAssignmentAssertionOperator := false
+	var dtsInfo *dtsMemberInfo
+	if p.dts != nil && opts.isClass {
+		dtsInfo = &dtsMemberInfo{key: p.dtsRangeFrom(keyRange.Loc)}
+		p.dts.members[startLoc] = dtsInfo
+	}
 
-	if p.options.ts.Parse {
+	if p.hasTypeSyntax() {
 		if opts.isClass {
 			if p.lexer.Token == js_lexer.TQuestion {
 				// "class X { foo?: number }"
 				// "class X { foo?(): number }"
+				p.stripTypesInRange(p.lexer.Range())
 				p.lexer.Next()
+				if dtsInfo != nil {
+					dtsInfo.isOptional = true
+				}
 			} else if p.lexer.Token == js_lexer.TExclamation && !p.lexer.HasNewlineBefore && !opts.isAsync &&
 				!opts.isGenerator && (kind == js_ast.PropertyNormal || kind == js_ast.PropertyAutoAccessor) {
 				// "class X { foo!: number }"
+				p.stripTypesInRange(p.lexer.Range())
 				p.lexer.Next()
 				hasDefiniteAssignmentAssertionOperator = true
 			}
@@ -2294,9 +2411,20 @@ func (p *parser) parseProperty(startLoc logger.Loc, kind js_ast.PropertyKind, op
 		}
 
 		// Skip over types
-		if p.options.ts.Parse && p.lexer.Token == js_lexer.TColon {
+		hasTypeAnnotation := false
+		if p.hasTypeSyntax() && p.lexer.Token == js_lexer.TColon {
+			colonLoc := p.lexer.Loc()
 			p.lexer.Next()
+			typeLoc := p.lexer.Loc()
 			p.skipTypeScriptType(js_ast.LLowest)
+			p.stripTypesFrom(colonLoc)
+			hasTypeAnnotation = true
+			if dtsInfo != nil {
+				dtsInfo.fieldType = p.dtsRangeFrom(typeLoc)
+			}
+			if p.metadataTypes != nil {
+				p.metadataTypes[startLoc] = p.dtsRangeFrom(typeLoc)
+			}
 		}
 
 		if p.lexer.Token == js_lexer.TEquals {
@@ -2341,6 +2469,17 @@ func (p *parser) parseProperty(startLoc logger.Loc, kind js_ast.PropertyKind, op
 		}
 
 		p.lexer.ExpectOrInsertSemicolon()
+
+		// Like Babel, treat Flow fields that have a type annotation but no value
+		// as type declarations. Otherwise "class Foo extends React.Component {
+		// props: Props }" would overwrite "props" with undefined.
+		if p.options.flow.Parse && hasTypeAnnotation && initializerOrNil.Data == nil && kind == js_ast.PropertyNormal &&
+			!flags.Has(js_ast.PropertyIsComputed) && len(opts.decorators) == 0 {
+			if _, ok := key.Data.(*js_ast.EPrivateIdentifier); !ok {
+				return js_ast.Property{}, false
+			}
+		}
+
 		if opts.isStatic {
 			flags |= js_ast.PropertyIsStatic
 		}
@@ -2371,18 +2510,6 @@ func (p *parser) parseProperty(startLoc logger.Loc, kind js_ast.PropertyKind, op
 			hasError = true
 		}
 
-		if opts.isAsync && p.markAsyncFn(opts.asyncRange, opts.isGenerator) {
-			hasError = true
-		}
-
-		if !hasError && opts.isGenerator && p.markSyntaxFeature(compat.Generator, opts.generatorRange) {
-			hasError = true
-		}
-
-		if !hasError && p.lexer.Token == js_lexer.TOpenParen && kind != js_ast.PropertyGet && kind != js_ast.PropertySet && p.markSyntaxFeature(compat.ObjectExtensions, p.lexer.Range()) {
-			hasError = true
-		}
-
 		loc := p.lexer.Loc()
 		scopeIndex := p.pushScopeForParsePass(js_ast.ScopeFunctionArgs, loc)
 		isConstructor := false
@@ -2434,6 +2561,10 @@ func (p *parser) parseProperty(startLoc logger.Loc, kind js_ast.PropertyKind, op
 
 		// "class Foo { foo(): void; foo(): void {} }"
 		if !hadBody {
+			if dtsInfo != nil {
+				dtsInfo.isMethodSignature = true
+			}
+
 			// Skip this property entirely
 			p.popAndDiscardScope(scopeIndex)
 			return js_ast.Property{}, false
@@ -2640,10 +2771,10 @@ var permanentReservedProps = map[string]bool{
 }
 
 func (p *parser) isMangledProp(name string) bool {
-	if p.options.mangleProps == nil {
+	if p.options.mangleProps == nil && !p.options.manglePrivate {
 		return false
 	}
-	if p.options.mangleProps.MatchString(name) && !permanentReservedProps[name] && (p.options.reserveProps == nil || !p.options.reserveProps.MatchString(name)) {
+	if p.options.mangleProps != nil && p.options.mangleProps.MatchString(name) && !permanentReservedProps[name] && (p.options.reserveProps == nil || !p.options.reserveProps.MatchString(name)) {
 		return true
 	}
 	reservedProps := p.reservedProps
@@ -2655,6 +2786,82 @@ func (p *parser) isMangledProp(name string) bool {
 	return false
 }
 
+// When "manglePrivate" is enabled, the names of TypeScript "private" and
+// "protected" class members are mangled as if they matched "mangleProps".
+// Only the member declarations and "this" and "super" property accesses in
+// the declaring class are mangled. The name is left alone everywhere if it's
+// also used as a normal property somewhere else, since that may be the same
+// property (e.g. "other.x" where "other" is another instance of the class).
+func (p *parser) recordTSPrivateProp(name string) {
+	if !p.options.manglePrivate || permanentReservedProps[name] ||
+		(p.options.reserveProps != nil && p.options.reserveProps.MatchString(name)) ||
+		(p.options.mangleProps != nil && p.options.mangleProps.MatchString(name)) {
+		return
+	}
+	if p.tsPrivateProps == nil {
+		p.tsPrivateProps = make(map[string]bool)
+		p.tsPrivatePropsByClass = make(map[logger.Loc]map[string]bool)
+	}
+	p.tsPrivateProps[name] = true
+	classProps := p.tsPrivatePropsByClass[p.enclosingClassBodyLoc]
+	if classProps == nil {
+		classProps = make(map[string]bool)
+		p.tsPrivatePropsByClass[p.enclosingClassBodyLoc] = classProps
+	}
+	classProps[name] = true
+}
+
+func (p *parser) markTSPrivatePropUsedNormally(name string) {
+	if p.tsPrivateProps[name] {
+		if p.tsPrivatePropsUsedNormally == nil {
+			p.tsPrivatePropsUsedNormally = make(map[string]bool)
+		}
+		p.tsPrivatePropsUsedNormally[name] = true
+	}
+}
+
+// A TypeScript member may be used before it's declared as "private", so keys
+// are mangled when they are visited instead of when they are parsed. The
+// "classProps" argument is nil for keys that aren't class members.
+func (p *parser) maybeMangleTSPrivateKey(key js_ast.Expr, isComputedOrQuoted bool, classProps map[string]bool) js_ast.Expr {
+	if p.tsPrivateProps != nil {
+		if str, ok := key.Data.(*js_ast.EString); ok {
+			if name := helpers.UTF16ToString(str.Value); p.tsPrivateProps[name] {
+				if !isComputedOrQuoted && classProps[name] {
+					return js_ast.Expr{Loc: key.Loc, Data: &js_ast.ENameOfSymbol{Ref: p.storeNameInRef(js_lexer.MaybeSubstring{String: name})}}
+				}
+				p.markTSPrivatePropUsedNormally(name)
+			}
+		}
+	}
+	return key
+}
+
+// This must be called before the target is visited since visiting may replace
+// "this" with something else
+func (p *parser) isTSPrivatePropAccess(target js_ast.Expr, name string) bool {
+	if !p.tsPrivateProps[name] {
+		return false
+	}
+	switch target.Data.(type) {
+	case *js_ast.EThis, *js_ast.ESuper:
+		if p.fnOnlyDataVisit.classTSPrivateProps[name] {
+			return true
+		}
+	}
+	p.markTSPrivatePropUsedNormally(name)
+	return false
+}
+
+// This is for property accesses that have already been visited
+func (p *parser) mangleTSPrivateDot(e *js_ast.EDot) *js_ast.EIndex {
+	return &js_ast.EIndex{
+		Target:        e.Target,
+		Index:         js_ast.Expr{Loc: e.NameLoc, Data: &js_ast.ENameOfSymbol{Ref: p.symbolForMangledProp(e.Name)}},
+		OptionalChain: e.OptionalChain,
+	}
+}
+
 func (p *parser) symbolForMangledProp(name string) ast.Ref {
 	mangledProps := p.mangledProps
 	if mangledProps == nil {
@@ -2717,6 +2924,19 @@ func (p *parser) dotOrMangledPropVisit(target js_ast.Expr, name string, nameLoc
 	}
 }
 
+// This is for assignments to TypeScript parameter properties, which belong to
+// the class that is being lowered
+func (p *parser) dotOrMangledTSPrivatePropVisit(target js_ast.Expr, name string, nameLoc logger.Loc, classProps map[string]bool) js_ast.E {
+	if classProps[name] {
+		return &js_ast.EIndex{
+			Target: target,
+			Index:  js_ast.Expr{Loc: nameLoc, Data: &js_ast.ENameOfSymbol{Ref: p.symbolForMangledProp(name)}},
+		}
+	}
+	p.markTSPrivatePropUsedNormally(name)
+	return p.dotOrMangledPropVisit(target, name, nameLoc)
+}
+
 func (p *parser) parseArrowBody(args []js_ast.Arg, data fnOrArrowDataParse) *js_ast.EArrow {
 	arrowLoc := p.lexer.Loc()
 
@@ -2823,7 +3043,6 @@ func (p *parser) parseAsyncPrefixExpr(asyncRange logger.Range, level js_ast.L, f
 				}
 
 				if isArrowFn {
-					p.markAsyncFn(asyncRange, false)
 					ref := p.storeNameInRef(p.lexer.Identifier)
 					arg := js_ast.Arg{Binding: js_ast.Binding{Loc: p.lexer.Loc(), Data: &js_ast.BIdentifier{Ref: ref}}}
 					p.lexer.Next()
@@ -2849,7 +3068,7 @@ func (p *parser) parseAsyncPrefixExpr(asyncRange logger.Range, level js_ast.L, f
 		// "async<T>()"
 		// "async <T>() => {}"
 		case js_lexer.TLessThan:
-			if p.options.ts.Parse && (!p.options.jsx.Parse || p.isTSArrowFnJSX()) {
+			if p.hasTypeSyntax() && (!p.options.jsx.Parse || p.isTSArrowFnJSX()) {
 				if result := p.trySkipTypeScriptTypeParametersThenOpenParenWithBacktracking(); result != didNotSkipAnything {
 					p.lexer.Next()
 					return p.parseParenExpr(asyncRange.Loc, level, parenExprOpts{
@@ -2870,14 +3089,7 @@ func (p *parser) parseAsyncPrefixExpr(asyncRange logger.Range, level js_ast.L, f
 func (p *parser) parseFnExpr(loc logger.Loc, isAsync bool, asyncRange logger.Range) js_ast.Expr {
 	p.lexer.Next()
 	isGenerator := p.lexer.Token == js_lexer.TAsterisk
-	hasError := false
-	if isAsync {
-		hasError = p.markAsyncFn(asyncRange, isGenerator)
-	}
 	if isGenerator {
-		if !hasError {
-			p.markSyntaxFeature(compat.Generator, p.lexer.Range())
-		}
 		p.lexer.Next()
 	}
 	var name *ast.LocRef
@@ -2898,7 +3110,7 @@ func (p *parser) parseFnExpr(loc logger.Loc, isAsync bool, asyncRange logger.Ran
 	}
 
 	// Even anonymous functions can have TypeScript type parameters
-	if p.options.ts.Parse {
+	if p.hasTypeSyntax() {
 		p.skipTypeScriptTypeParameters(allowConstModifier)
 	}
 
@@ -2936,6 +3148,9 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 	commaAfterSpread := logger.Loc{}
 	isAsync := opts.asyncRange.Len > 0
 
+	// The "(" token has already been parsed by the caller
+	openParenLoc := logger.Loc{Start: p.lexer.PrevTokenEnd().Start - 1}
+
 	// Push a scope assuming this is an arrow function. It may not be, in which
 	// case we'll need to roll this change back. This has to be done ahead of
 	// parsing the arguments instead of later on when we hit the "=>" token and
@@ -2960,7 +3175,6 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 
 		if isSpread {
 			spreadRange = p.lexer.Range()
-			p.markSyntaxFeature(compat.RestArgument, spreadRange)
 			p.lexer.Next()
 		}
 
@@ -2970,19 +3184,25 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 		p.latestArrowArgLoc = p.lexer.Loc()
 		item := p.parseExprOrBindings(js_ast.LComma, &errors)
 
+		valueLoc := item.Loc
 		if isSpread {
 			item = js_ast.Expr{Loc: itemLoc, Data: &js_ast.ESpread{Value: item}}
 		}
 
 		// Skip over types
-		if p.options.ts.Parse && p.lexer.Token == js_lexer.TColon {
+		if p.hasTypeSyntax() && p.lexer.Token == js_lexer.TColon {
 			typeColonRange = p.lexer.Range()
 			p.lexer.Next()
+			typeLoc := p.lexer.Loc()
 			p.skipTypeScriptType(js_ast.LLowest)
+			p.stripTypesFrom(typeColonRange.Loc)
+			if p.dts != nil {
+				p.dts.types[valueLoc] = p.dtsRangeFrom(typeLoc)
+			}
 		}
 
 		// There may be a "=" after the type (but not after an "as" cast)
-		if p.options.ts.Parse && p.lexer.Token == js_lexer.TEquals && p.lexer.Loc() != p.forbidSuffixAfterAsLoc {
+		if p.hasTypeSyntax() && p.lexer.Token == js_lexer.TEquals && p.lexer.Loc() != p.forbidSuffixAfterAsLoc {
 			p.lexer.Next()
 			item = js_ast.Assign(item, p.parseExpr(js_ast.LComma))
 		}
@@ -3003,6 +3223,7 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 	}
 
 	// The parenthetical construct must end with a close parenthesis
+	closeParenLoc := p.lexer.Loc()
 	p.lexer.Expect(js_lexer.TCloseParen)
 
 	// Restore "in" operator status before we parse the arrow function body
@@ -3012,7 +3233,7 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 	p.fnOrArrowDataParse = oldFnOrArrowData
 
 	// Are these arguments to an arrow function?
-	if p.lexer.Token == js_lexer.TEqualsGreaterThan || opts.forceArrowFn || (p.options.ts.Parse && p.lexer.Token == js_lexer.TColon) {
+	if p.lexer.Token == js_lexer.TEqualsGreaterThan || opts.forceArrowFn || (p.hasTypeSyntax() && p.lexer.Token == js_lexer.TColon) {
 		// Arrow functions are not allowed inside certain expressions
 		if level > js_ast.LAssign {
 			p.lexer.Unexpected()
@@ -3021,10 +3242,6 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 		var invalidLog invalidLog
 		args := []js_ast.Arg{}
 
-		if isAsync {
-			p.markAsyncFn(opts.asyncRange, false)
-		}
-
 		// First, try converting the expressions to bindings
 		for _, item := range items {
 			isSpread := false
@@ -3042,8 +3259,15 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 		// attempt to convert the expressions to bindings first before deciding
 		// whether this is an arrow function, and only pick an arrow function if
 		// there were no conversion errors.
+		returnTypeLoc := p.lexer.Loc()
 		if p.lexer.Token == js_lexer.TEqualsGreaterThan || (len(invalidLog.invalidTokens) == 0 &&
 			p.trySkipTypeScriptArrowReturnTypeWithBacktracking()) || opts.forceArrowFn {
+			if p.lexer.Loc() != returnTypeLoc {
+				p.stripArrowReturnType(closeParenLoc)
+			}
+			if p.dts != nil {
+				p.dtsRecordArrow(loc, openParenLoc, returnTypeLoc)
+			}
 			if commaAfterSpread.Start != 0 {
 				p.log.AddError(&p.tracker, logger.Range{Loc: commaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
 			}
@@ -3059,11 +3283,6 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 				panic(js_lexer.LexerPanic{})
 			}
 
-			// Also report syntax features used in bindings
-			for _, entry := range invalidLog.syntaxFeatures {
-				p.markSyntaxFeature(entry.feature, entry.token)
-			}
-
 			await := allowIdent
 			if isAsync {
 				await = allowExpr
@@ -3085,8 +3304,9 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 	// parent scope as if the scope was never pushed in the first place.
 	p.popAndFlattenScope(scopeIndex)
 
-	// If this isn't an arrow function, then types aren't allowed
-	if typeColonRange.Len > 0 {
+	// If this isn't an arrow function, then types aren't allowed. The only
+	// exception is a Flow type cast such as "(x: any)".
+	if typeColonRange.Len > 0 && (!p.options.flow.Parse || isAsync || len(items) != 1 || spreadRange.Len > 0) {
 		p.log.AddError(&p.tracker, typeColonRange, "Unexpected \":\"")
 		panic(js_lexer.LexerPanic{})
 	}
@@ -3120,13 +3340,7 @@ func (p *parser) parseParenExpr(loc logger.Loc, level js_ast.L, opts parenExprOp
 }
 
 type invalidLog struct {
-	invalidTokens  []logger.Range
-	syntaxFeatures []syntaxFeature
-}
-
-type syntaxFeature struct {
-	feature compat.JSFeature
-	token   logger.Range
+	invalidTokens []logger.Range
 }
 
 func (p *parser) convertExprToBindingAndInitializer(
@@ -3138,16 +3352,8 @@ func (p *parser) convertExprToBindingAndInitializer(
 		expr = assign.Left
 	}
 	binding, invalidLog := p.convertExprToBinding(expr, invalidLog)
-	if initializerOrNil.Data != nil {
-		equalsRange := p.source.RangeOfOperatorBefore(initializerOrNil.Loc, "=")
-		if isSpread {
-			p.log.AddError(&p.tracker, equalsRange, "A rest argument cannot have a default initializer")
-		} else {
-			invalidLog.syntaxFeatures = append(invalidLog.syntaxFeatures, syntaxFeature{
-				feature: compat.DefaultArgument,
-				token:   equalsRange,
-			})
-		}
+	if initializerOrNil.Data != nil && isSpread {
+		p.log.AddError(&p.tracker, p.source.RangeOfOperatorBefore(initializerOrNil.Loc, "="), "A rest argument cannot have a default initializer")
 	}
 	return binding, initializerOrNil, invalidLog
 }
@@ -3168,17 +3374,12 @@ func (p *parser) convertExprToBinding(expr js_ast.Expr, invalidLog invalidLog) (
 		if e.CommaAfterSpread.Start != 0 {
 			invalidLog.invalidTokens = append(invalidLog.invalidTokens, logger.Range{Loc: e.CommaAfterSpread, Len: 1})
 		}
-		invalidLog.syntaxFeatures = append(invalidLog.syntaxFeatures,
-			syntaxFeature{feature: compat.Destructuring, token: p.source.RangeOfOperatorAfter(expr.Loc, "[")})
 		items := []js_ast.ArrayBinding{}
 		isSpread := false
 		for _, item := range e.Items {
 			if i, ok := item.Data.(*js_ast.ESpread); ok {
 				isSpread = true
 				item = i.Value
-				if _, ok := item.Data.(*js_ast.EIdentifier); !ok {
-					p.markSyntaxFeature(compat.NestedRestBinding, p.source.RangeOfOperatorAfter(item.Loc, "["))
-				}
 			}
 			binding, initializerOrNil, log := p.convertExprToBindingAndInitializer(item, invalidLog, isSpread)
 			invalidLog = log
@@ -3199,8 +3400,6 @@ func (p *parser) convertExprToBinding(expr js_ast.Expr, invalidLog invalidLog) (
 		if e.CommaAfterSpread.Start != 0 {
 			invalidLog.invalidTokens = append(invalidLog.invalidTokens, logger.Range{Loc: e.CommaAfterSpread, Len: 1})
 		}
-		invalidLog.syntaxFeatures = append(invalidLog.syntaxFeatures,
-			syntaxFeature{feature: compat.Destructuring, token: p.source.RangeOfOperatorAfter(expr.Loc, "{")})
 		properties := []js_ast.PropertyBinding{}
 		for _, property := range e.Properties {
 			if property.Flags.Has(js_ast.PropertyIsMethod) || property.Kind == js_ast.PropertyGet || property.Kind == js_ast.PropertySet {
@@ -3246,6 +3445,99 @@ func (p *parser) saveExprCommentsHere() logger.Loc {
 	return loc
 }
 
+// When all comments are being preserved, comments that start on the same line
+// as the end of a statement, class member, or object property are attached to
+// it. They are printed after it even if it's moved somewhere else.
+func (p *parser) saveTrailingComments(loc logger.Loc) {
+	comments := p.lexer.CommentsBeforeToken
+	n := 0
+	for n < len(comments) && !p.hasNewlineBeforeComment(comments[n]) {
+		n++
+	}
+	if n > 0 {
+		for _, comment := range comments[:n] {
+			if !p.isLegalCommentBeforeToken(comment) {
+				p.trailingComments[loc] = append(p.trailingComments[loc], p.source.CommentTextWithoutIndent(comment))
+			}
+		}
+		p.lexer.CommentsBeforeToken = comments[n:]
+	}
+}
+
+func (p *parser) hasNewlineBeforeComment(comment logger.Range) bool {
+	for i := comment.Loc.Start - 1; i >= 0; i-- {
+		switch p.source.Contents[i] {
+		case ' ', '\t':
+			continue
+		case '\r', '\n':
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// This is like "saveExprCommentsHere" but is only used when all comments are
+// being preserved. Legal comments are excluded because they are already kept.
+func (p *parser) saveLeadingComments() {
+	var comments []string
+	for _, comment := range p.lexer.CommentsBeforeToken {
+		if !p.isLegalCommentBeforeToken(comment) {
+			comments = append(comments, p.source.CommentTextWithoutIndent(comment))
+		}
+	}
+	if comments != nil {
+		p.exprComments[p.lexer.Loc()] = comments
+	}
+}
+
+// Exported declarations start after the "export" keyword, so comments before
+// that keyword have to be moved to where the declaration starts
+func (p *parser) moveLeadingComments(from logger.Loc, to logger.Loc) {
+	if comments, ok := p.exprComments[from]; ok && from != to {
+		delete(p.exprComments, from)
+		p.exprComments[to] = append(comments, p.exprComments[to]...)
+	}
+}
+
+// Comments before a statement that is removed, such as a TypeScript type
+// declaration, must not be removed along with it. They are moved to the next
+// statement instead, or become separate statements if there isn't one. Any
+// trailing comment on the same line is about the removed statement itself, so
+// that one is removed too.
+func (p *parser) takeStmtComments(loc logger.Loc, comments []string) []string {
+	if p.trailingComments == nil {
+		return comments
+	}
+	if leading, ok := p.exprComments[loc]; ok {
+		delete(p.exprComments, loc)
+		comments = append(comments, leading...)
+	}
+	delete(p.trailingComments, loc)
+	return comments
+}
+
+func (p *parser) attachStmtComments(loc logger.Loc, comments []string) {
+	p.exprComments[loc] = append(comments, p.exprComments[loc]...)
+}
+
+func appendCommentStmts(stmts []js_ast.Stmt, loc logger.Loc, comments []string) []js_ast.Stmt {
+	for _, text := range comments {
+		stmts = append(stmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SComment{Text: text}})
+	}
+	return stmts
+}
+
+// Legal comments are already kept as separate statements
+func (p *parser) isLegalCommentBeforeToken(comment logger.Range) bool {
+	for _, legal := range p.lexer.LegalCommentsBeforeToken {
+		if legal == comment {
+			return true
+		}
+	}
+	return false
+}
+
 type exprFlag uint8
 
 const (
@@ -3618,11 +3910,6 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 				items = append(items, js_ast.Expr{Loc: p.lexer.Loc(), Data: js_ast.EMissingShared})
 
 			case js_lexer.TDotDotDot:
-				if errors != nil {
-					errors.arraySpreadFeature = p.lexer.Range()
-				} else {
-					p.markSyntaxFeature(compat.ArraySpread, p.lexer.Range())
-				}
 				dotsLoc := p.saveExprCommentsHere()
 				p.lexer.Next()
 				item := p.parseExprOrBindings(js_ast.LComma, &selfErrors)
@@ -3706,6 +3993,9 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 					properties = append(properties, property)
 				}
 			}
+			if p.trailingComments != nil && len(properties) > 0 {
+				p.saveTrailingComments(properties[len(properties)-1].Loc)
+			}
 
 			if p.lexer.Token != js_lexer.TComma {
 				break
@@ -3717,6 +4007,9 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 			if p.lexer.HasNewlineBefore {
 				isSingleLine = false
 			}
+			if p.trailingComments != nil && len(properties) > 0 {
+				p.saveTrailingComments(properties[len(properties)-1].Loc)
+			}
 		}
 
 		if p.lexer.HasNewlineBefore {
@@ -3786,25 +4079,37 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 		//     <A[]>(x)
 		//     <A>(x) => {}
 
-		if p.options.ts.Parse && p.options.jsx.Parse && p.isTSArrowFnJSX() {
+		if p.hasTypeSyntax() && p.options.jsx.Parse && (p.isTSArrowFnJSX() || (p.options.flow.Parse && p.isFlowArrowFnJSX())) {
 			p.skipTypeScriptTypeParameters(allowConstModifier)
 			p.lexer.Expect(js_lexer.TOpenParen)
 			return p.parseParenExpr(loc, level, parenExprOpts{forceArrowFn: true})
 		}
 
+		// "<T>(x) => {}"
+		if p.options.flow.Parse && !p.options.jsx.Parse {
+			if result := p.trySkipTypeScriptTypeParametersThenOpenParenWithBacktracking(); result != didNotSkipAnything {
+				p.lexer.Expect(js_lexer.TOpenParen)
+				return p.parseParenExpr(loc, level, parenExprOpts{forceArrowFn: true})
+			}
+		}
+
 		// Print a friendly error message when parsing JSX as JavaScript
 		if !p.options.jsx.Parse && !p.options.ts.Parse {
+			loader, jsxLoader, jsxLoaderConst := "js", "jsx", "LoaderJSX"
+			if p.options.flow.Parse {
+				loader, jsxLoader, jsxLoaderConst = "flow", "flow-jsx", "LoaderFlowJSX"
+			}
 			var how string
 			switch logger.API {
 			case logger.CLIAPI:
-				how = " You can use \"--loader:.js=jsx\" to do that."
+				how = fmt.Sprintf(" You can use \"--loader:.js=%s\" to do that.", jsxLoader)
 			case logger.JSAPI:
-				how = " You can use \"loader: { '.js': 'jsx' }\" to do that."
+				how = fmt.Sprintf(" You can use \"loader: { '.js': '%s' }\" to do that.", jsxLoader)
 			case logger.GoAPI:
-				how = " You can use 'Loader: map[string]api.Loader{\".js\": api.LoaderJSX}' to do that."
+				how = fmt.Sprintf(" You can use 'Loader: map[string]api.Loader{\".js\": api.%s}' to do that.", jsxLoaderConst)
 			}
 			p.log.AddErrorWithNotes(&p.tracker, p.lexer.Range(), "The JSX syntax extension is not currently enabled", []logger.MsgData{{
-				Text: "The esbuild loader for this file is currently set to \"js\" but it must be set to \"jsx\" to be able to parse JSX syntax." + how}})
+				Text: fmt.Sprintf("The esbuild loader for this file is currently set to %q but it must be set to %q to be able to parse JSX syntax.", loader, jsxLoader) + how}})
 			p.options.jsx.Parse = true
 		}
 
@@ -3843,9 +4148,19 @@ func (p *parser) parsePrefix(level js_ast.L, errors *deferredErrors, flags exprF
 
 			// "<T>x"
 			p.lexer.Next()
+			typeLoc := p.lexer.Loc()
+			isConst := p.lexer.Token == js_lexer.TConst
 			p.skipTypeScriptType(js_ast.LLowest)
+			typeRange := logger.Range{}
+			if p.dts != nil {
+				typeRange = p.dtsRangeFrom(typeLoc)
+			}
 			p.lexer.ExpectGreaterThan(false /* isInsideJSXElement */)
+			p.stripTypesFrom(loc)
 			value := p.parsePrefix(level, errors, flags)
+			if p.dts != nil {
+				p.dts.typeAssertions[value.Loc] = dtsTypeAssertion{data: value.Data, typeRange: typeRange, isConst: isConst}
+			}
 			return value
 		}
 
@@ -4123,7 +4438,7 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			case js_lexer.TLessThan, js_lexer.TLessThanLessThan:
 				// "a?.<T>()"
 				// "a?.<<T>() => T>()"
-				if !p.options.ts.Parse {
+				if !p.hasTypeSyntax() {
 					p.lexer.Expected(js_lexer.TIdentifier)
 				}
 				p.skipTypeScriptTypeArguments(skipTypeScriptTypeArgumentsOpts{})
@@ -4269,12 +4584,16 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			// "(a?) => {}"
 			// "(a?: b) => {}"
 			// "(a?, b?) => {}"
-			if p.options.ts.Parse && left.Loc == p.latestArrowArgLoc && (p.lexer.Token == js_lexer.TColon ||
+			if p.hasTypeSyntax() && left.Loc == p.latestArrowArgLoc && (p.lexer.Token == js_lexer.TColon ||
 				p.lexer.Token == js_lexer.TCloseParen || p.lexer.Token == js_lexer.TComma) {
 				if errors == nil {
 					p.lexer.Unexpected()
 				}
 				errors.invalidExprAfterQuestion = p.lexer.Range()
+				p.stripTypesInRange(logger.Range{Loc: logger.Loc{Start: p.lexer.PrevTokenEnd().Start - 1}, Len: 1})
+				if p.dts != nil {
+					p.dts.optionals[left.Loc] = true
+				}
 				return left
 			}
 
@@ -4298,6 +4617,7 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			if !p.options.ts.Parse {
 				p.lexer.Unexpected()
 			}
+			p.stripTypesInRange(p.lexer.Range())
 			p.lexer.Next()
 			optionalChain = oldOptionalChain
 
@@ -4438,7 +4758,7 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			// TypeScript allows type arguments to be specified with angle brackets
 			// inside an expression. Unlike in other languages, this unfortunately
 			// appears to require backtracking to parse.
-			if p.options.ts.Parse && p.trySkipTypeArgumentsInExpressionWithBacktracking() {
+			if p.hasTypeSyntax() && p.trySkipTypeArgumentsInExpressionWithBacktracking() {
 				optionalChain = oldOptionalChain
 				continue
 			}
@@ -4474,7 +4794,7 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			// TypeScript allows type arguments to be specified with angle brackets
 			// inside an expression. Unlike in other languages, this unfortunately
 			// appears to require backtracking to parse.
-			if p.options.ts.Parse && p.trySkipTypeArgumentsInExpressionWithBacktracking() {
+			if p.hasTypeSyntax() && p.trySkipTypeArgumentsInExpressionWithBacktracking() {
 				optionalChain = oldOptionalChain
 				continue
 			}
@@ -4692,10 +5012,24 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 			left = js_ast.Expr{Loc: left.Loc, Data: &js_ast.EBinary{Op: js_ast.BinOpInstanceof, Left: left, Right: p.parseExpr(js_ast.LCompare)}}
 
 		default:
-			// Handle the TypeScript "as"/"satisfies" operator
-			if p.options.ts.Parse && level < js_ast.LCompare && !p.lexer.HasNewlineBefore && (p.lexer.IsContextualKeyword("as") || p.lexer.IsContextualKeyword("satisfies")) {
+			// Handle the TypeScript "as"/"satisfies" operator (Flow only has "as")
+			if level < js_ast.LCompare && !p.lexer.HasNewlineBefore && ((p.hasTypeSyntax() && p.lexer.IsContextualKeyword("as")) ||
+				(p.options.ts.Parse && p.lexer.IsContextualKeyword("satisfies"))) {
+				asLoc := p.lexer.Loc()
+				isSatisfies := p.lexer.IsContextualKeyword("satisfies")
 				p.lexer.Next()
+				isConst := p.lexer.Token == js_lexer.TConst
+				if isConst {
+					if object, ok := left.Data.(*js_ast.EObject); ok {
+						object.IsConstAsserted = true
+					}
+				}
+				typeLoc := p.lexer.Loc()
 				p.skipTypeScriptType(js_ast.LLowest)
+				p.stripTypesFrom(asLoc)
+				if p.dts != nil && !isSatisfies {
+					p.dts.typeAssertions[left.Loc] = dtsTypeAssertion{data: left.Data, typeRange: p.dtsRangeFrom(typeLoc), isConst: isConst}
+				}
 
 				// These tokens are not allowed to follow a cast expression. This isn't
 				// an outright error because it may be on a new line, in which case it's
@@ -4708,6 +5042,11 @@ func (p *parser) parseSuffix(left js_ast.Expr, level js_ast.L, errors *deferredE
 				case js_lexer.TPlusPlus, js_lexer.TMinusMinus, js_lexer.TNoSubstitutionTemplateLiteral,
 					js_lexer.TTemplateHead, js_lexer.TOpenParen, js_lexer.TOpenBracket, js_lexer.TQuestionDot:
 					p.forbidSuffixAfterAsLoc = p.lexer.Loc()
+
+					// Stripping the cast would make this a suffix of the expression
+					if p.strippedTypeFixups != nil && p.lexer.HasNewlineBefore {
+						p.strippedTypeFixups[asLoc.Start] = ';'
+					}
 					return left
 				}
 				if p.lexer.Token.IsAssign() {
@@ -4762,8 +5101,8 @@ func (p *parser) parseExprOrLetOrUsingStmt(opts parseStmtOpts) (js_ast.Expr, js_
 			if opts.lexicalDecl != lexicalDeclAllowAll {
 				p.forbidLexicalDecl(tokenRange.Loc)
 			}
-			p.markSyntaxFeature(compat.ConstAndLet, tokenRange)
 			decls := p.parseAndDeclareDecls(ast.SymbolOther, opts)
+			p.markLetOrConstForLowering(decls)
 			return js_ast.Expr{}, js_ast.Stmt{Loc: tokenRange.Loc, Data: &js_ast.SLocal{
 				Kind:     js_ast.LocalLet,
 				Decls:    decls,
@@ -4842,7 +5181,6 @@ func (p *parser) parseCallArgs() (args []js_ast.Expr, closeParenLoc logger.Loc,
 		loc := p.lexer.Loc()
 		isSpread := p.lexer.Token == js_lexer.TDotDotDot
 		if isSpread {
-			p.markSyntaxFeature(compat.RestArgument, p.lexer.Range())
 			p.lexer.Next()
 		}
 		arg := p.parseExpr(js_ast.LComma)
@@ -5161,7 +5499,6 @@ func (p *parser) parseJSXElement(loc logger.Loc) js_ast.Expr {
 					// behavior. Note that TypeScript's behavior changed in TypeScript 4.5.
 					// Before that, the "..." was omitted instead of being preserved.
 					itemLoc := p.lexer.Loc()
-					p.markSyntaxFeature(compat.RestArgument, p.lexer.Range())
 					p.lexer.Next()
 					nullableChildren = append(nullableChildren, js_ast.Expr{Loc: itemLoc, Data: &js_ast.ESpread{Value: p.parseExpr(js_ast.LLowest)}})
 				} else {
@@ -5281,9 +5618,11 @@ func (p *parser) parseAndDeclareDecls(kind ast.SymbolKind, opts parseStmtOpts) [
 		p.declareBinding(kind, local, opts)
 
 		// Skip over types
-		if p.options.ts.Parse {
+		if p.hasTypeSyntax() {
+			typeStartLoc := p.lexer.Loc()
+
 			// "let foo!"
-			isDefiniteAssignmentAssertion := p.lexer.Token == js_lexer.TExclamation && !p.lexer.HasNewlineBefore
+			isDefiniteAssignmentAssertion := p.options.ts.Parse && p.lexer.Token == js_lexer.TExclamation && !p.lexer.HasNewlineBefore
 			if isDefiniteAssignmentAssertion {
 				p.lexer.Next()
 			}
@@ -5291,7 +5630,12 @@ func (p *parser) parseAndDeclareDecls(kind ast.SymbolKind, opts parseStmtOpts) [
 			// "let foo: number"
 			if isDefiniteAssignmentAssertion || p.lexer.Token == js_lexer.TColon {
 				p.lexer.Expect(js_lexer.TColon)
+				typeLoc := p.lexer.Loc()
 				p.skipTypeScriptType(js_ast.LLowest)
+				p.stripTypesFrom(typeStartLoc)
+				if p.dts != nil {
+					p.dts.types[local.Loc] = p.dtsRangeFrom(typeLoc)
+				}
 			}
 		}
 
@@ -5409,6 +5753,7 @@ func (p *parser) parseImportClause() ([]js_ast.ClauseItem, bool) {
 	isSingleLine := !p.lexer.HasNewlineBefore
 
 	for p.lexer.Token != js_lexer.TCloseBrace {
+		itemCount := len(items)
 		isIdentifier := p.lexer.Token == js_lexer.TIdentifier
 		aliasLoc := p.lexer.Loc()
 		alias := p.parseClauseAlias("import")
@@ -5422,7 +5767,9 @@ func (p *parser) parseImportClause() ([]js_ast.ClauseItem, bool) {
 		// "import { type as } from 'mod'"
 		// "import { type as as } from 'mod'"
 		// "import { type as as as } from 'mod'"
-		if p.options.ts.Parse && alias.String == "type" && p.lexer.Token != js_lexer.TComma && p.lexer.Token != js_lexer.TCloseBrace {
+		// "import { typeof xx } from 'mod'"
+		if p.hasTypeSyntax() && (alias.String == "type" || (p.options.flow.Parse && alias.String == "typeof")) &&
+			p.lexer.Token != js_lexer.TComma && p.lexer.Token != js_lexer.TCloseBrace {
 			if p.lexer.IsContextualKeyword("as") {
 				p.lexer.Next()
 				if p.lexer.IsContextualKeyword("as") {
@@ -5506,6 +5853,9 @@ func (p *parser) parseImportClause() ([]js_ast.ClauseItem, bool) {
 		}
 
 		if p.lexer.Token != js_lexer.TComma {
+			if len(items) == itemCount {
+				p.stripTypesFrom(aliasLoc)
+			}
 			break
 		}
 		if p.lexer.HasNewlineBefore {
@@ -5515,6 +5865,11 @@ func (p *parser) parseImportClause() ([]js_ast.ClauseItem, bool) {
 		if p.lexer.HasNewlineBefore {
 			isSingleLine = false
 		}
+
+		// Type-only items are stripped along with their trailing comma
+		if len(items) == itemCount {
+			p.stripTypesFrom(aliasLoc)
+		}
 	}
 
 	if p.lexer.HasNewlineBefore {
@@ -5531,6 +5886,8 @@ func (p *parser) parseExportClause() ([]js_ast.ClauseItem, bool) {
 	isSingleLine := !p.lexer.HasNewlineBefore
 
 	for p.lexer.Token != js_lexer.TCloseBrace {
+		itemCount := len(items)
+		itemLoc := p.lexer.Loc()
 		alias := p.parseClauseAlias("export")
 		aliasLoc := p.lexer.Loc()
 		name := ast.LocRef{Loc: aliasLoc, Ref: p.storeNameInRef(alias)}
@@ -5551,7 +5908,7 @@ func (p *parser) parseExportClause() ([]js_ast.ClauseItem, bool) {
 		}
 		p.lexer.Next()
 
-		if p.options.ts.Parse && alias.String == "type" && p.lexer.Token != js_lexer.TComma && p.lexer.Token != js_lexer.TCloseBrace {
+		if p.hasTypeSyntax() && alias.String == "type" && p.lexer.Token != js_lexer.TComma && p.lexer.Token != js_lexer.TCloseBrace {
 			if p.lexer.IsContextualKeyword("as") {
 				p.lexer.Next()
 				if p.lexer.IsContextualKeyword("as") {
@@ -5636,6 +5993,9 @@ func (p *parser) parseExportClause() ([]js_ast.ClauseItem, bool) {
 		}
 
 		if p.lexer.Token != js_lexer.TComma {
+			if len(items) == itemCount {
+				p.stripTypesFrom(itemLoc)
+			}
 			break
 		}
 		if p.lexer.HasNewlineBefore {
@@ -5645,6 +6005,11 @@ func (p *parser) parseExportClause() ([]js_ast.ClauseItem, bool) {
 		if p.lexer.HasNewlineBefore {
 			isSingleLine = false
 		}
+
+		// Type-only items are stripped along with their trailing comma
+		if len(items) == itemCount {
+			p.stripTypesFrom(itemLoc)
+		}
 	}
 
 	if p.lexer.HasNewlineBefore {
@@ -5688,7 +6053,6 @@ func (p *parser) parseBinding(opts parseBindingOpts) js_ast.Binding {
 		if opts.isUsingStmt {
 			break
 		}
-		p.markSyntaxFeature(compat.Destructuring, p.lexer.Range())
 		p.lexer.Next()
 		isSingleLine := !p.lexer.HasNewlineBefore
 		items := []js_ast.ArrayBinding{}
@@ -5711,11 +6075,6 @@ func (p *parser) parseBinding(opts parseBindingOpts) js_ast.Binding {
 				if p.lexer.Token == js_lexer.TDotDotDot {
 					p.lexer.Next()
 					hasSpread = true
-
-					// This was a bug in the ES2015 spec that was fixed in ES2016
-					if p.lexer.Token != js_lexer.TIdentifier {
-						p.markSyntaxFeature(compat.NestedRestBinding, p.lexer.Range())
-					}
 				}
 
 				p.saveExprCommentsHere()
@@ -5770,7 +6129,6 @@ func (p *parser) parseBinding(opts parseBindingOpts) js_ast.Binding {
 		if opts.isUsingStmt {
 			break
 		}
-		p.markSyntaxFeature(compat.Destructuring, p.lexer.Range())
 		p.lexer.Next()
 		isSingleLine := !p.lexer.HasNewlineBefore
 		properties := []js_ast.PropertyBinding{}
@@ -5856,16 +6214,22 @@ func (p *parser) parseFn(
 
 	for p.lexer.Token != js_lexer.TCloseParen {
 		// Skip over "this" type annotations
-		if p.options.ts.Parse && p.lexer.Token == js_lexer.TThis {
+		if p.hasTypeSyntax() && p.lexer.Token == js_lexer.TThis {
+			thisLoc := p.lexer.Loc()
 			p.lexer.Next()
 			if p.lexer.Token == js_lexer.TColon {
 				p.lexer.Next()
 				p.skipTypeScriptType(js_ast.LLowest)
 			}
+			if p.dts != nil {
+				p.dts.thisArgs[fn.OpenParenLoc] = p.dtsRangeFrom(thisLoc)
+			}
 			if p.lexer.Token != js_lexer.TComma {
+				p.stripTypesFrom(thisLoc)
 				break
 			}
 			p.lexer.Next()
+			p.stripTypesFrom(thisLoc)
 			continue
 		}
 
@@ -5928,25 +6292,32 @@ func (p *parser) parseFn(
 		}
 
 		if !fn.HasRestArg && p.lexer.Token == js_lexer.TDotDotDot {
-			p.markSyntaxFeature(compat.RestArgument, p.lexer.Range())
 			p.lexer.Next()
 			fn.HasRestArg = true
 		}
 
 		isTypeScriptCtorField := false
+		isTypeScriptPrivateCtorField := false
 		isIdentifier := p.lexer.Token == js_lexer.TIdentifier
 		text := p.lexer.Identifier.String
+		argLoc := p.lexer.Loc()
 		arg := p.parseBinding(parseBindingOpts{})
 
-		if p.options.ts.Parse {
+		if p.hasTypeSyntax() {
 			// Skip over TypeScript accessibility modifiers, which turn this argument
 			// into a class field when used inside a class constructor. This is known
 			// as a "parameter property" in TypeScript.
-			if isIdentifier && data.isConstructor {
+			if p.options.ts.Parse && isIdentifier && data.isConstructor {
 				for p.lexer.Token == js_lexer.TIdentifier || p.lexer.Token == js_lexer.TOpenBrace || p.lexer.Token == js_lexer.TOpenBracket {
 					if text != "public" && text != "private" && text != "protected" && text != "readonly" && text != "override" {
 						break
 					}
+					if !isTypeScriptCtorField {
+						p.markNonErasableTypeScriptSyntax(js_lexer.RangeOfIdentifier(p.source, argLoc), "Parameter properties")
+					}
+					if text == "private" || text == "protected" {
+						isTypeScriptPrivateCtorField = true
+					}
 					isTypeScriptCtorField = true
 
 					// TypeScript requires an identifier binding
@@ -5958,17 +6329,38 @@ func (p *parser) parseFn(
 					// Re-parse the binding (the current binding is the TypeScript keyword)
 					arg = p.parseBinding(parseBindingOpts{})
 				}
+				if isTypeScriptPrivateCtorField {
+					p.recordTSPrivateProp(text)
+				}
 			}
 
 			// "function foo(a?) {}"
 			if p.lexer.Token == js_lexer.TQuestion {
+				p.stripTypesInRange(p.lexer.Range())
 				p.lexer.Next()
+				if p.dts != nil {
+					p.dts.optionals[arg.Loc] = true
+				}
 			}
 
 			// "function foo(a: any) {}"
 			if p.lexer.Token == js_lexer.TColon {
+				colonLoc := p.lexer.Loc()
 				p.lexer.Next()
+				typeLoc := p.lexer.Loc()
 				p.skipTypeScriptType(js_ast.LLowest)
+				p.stripTypesFrom(colonLoc)
+				if p.dts != nil {
+					p.dts.types[arg.Loc] = p.dtsRangeFrom(typeLoc)
+				}
+				if p.metadataTypes != nil {
+					p.metadataTypes[arg.Loc] = p.dtsRangeFrom(typeLoc)
+				}
+			}
+
+			// Remember the modifiers for parameter properties
+			if p.dts != nil && isTypeScriptCtorField {
+				p.dts.ctorFields[arg.Loc] = argLoc
 			}
 		}
 
@@ -5976,7 +6368,6 @@ func (p *parser) parseFn(
 
 		var defaultValueOrNil js_ast.Expr
 		if !fn.HasRestArg && p.lexer.Token == js_lexer.TEquals {
-			p.markSyntaxFeature(compat.DefaultArgument, p.lexer.Range())
 			p.lexer.Next()
 			defaultValueOrNil = p.parseExpr(js_ast.LComma)
 		}
@@ -6019,9 +6410,18 @@ func (p *parser) parseFn(
 	p.fnOrArrowDataParse = oldFnOrArrowData
 
 	// "function foo(): any {}"
-	if p.options.ts.Parse && p.lexer.Token == js_lexer.TColon {
+	if p.hasTypeSyntax() && p.lexer.Token == js_lexer.TColon {
+		colonLoc := p.lexer.Loc()
 		p.lexer.Next()
+		typeLoc := p.lexer.Loc()
 		p.skipTypeScriptReturnType()
+		p.stripTypesFrom(colonLoc)
+		if p.dts != nil {
+			p.dts.returnTypes[fn.OpenParenLoc] = p.dtsRangeFrom(typeLoc)
+		}
+		if p.metadataTypes != nil {
+			p.metadataTypes[fn.OpenParenLoc] = p.dtsRangeFrom(typeLoc)
+		}
 	}
 
 	// "function foo(): any;"
@@ -6067,13 +6467,12 @@ func (p *parser) parseClassStmt(loc logger.Loc, opts parseStmtOpts) js_ast.Stmt
 	var name *ast.LocRef
 	classKeyword := p.lexer.Range()
 	if p.lexer.Token == js_lexer.TClass {
-		p.markSyntaxFeature(compat.Class, classKeyword)
 		p.lexer.Next()
 	} else {
 		p.lexer.Expected(js_lexer.TClass)
 	}
 
-	if !opts.isNameOptional || (p.lexer.Token == js_lexer.TIdentifier && (!p.options.ts.Parse || p.lexer.Identifier.String != "implements")) {
+	if !opts.isNameOptional || (p.lexer.Token == js_lexer.TIdentifier && (!p.hasTypeSyntax() || p.lexer.Identifier.String != "implements")) {
 		nameLoc := p.lexer.Loc()
 		nameText := p.lexer.Identifier.String
 		p.lexer.Expect(js_lexer.TIdentifier)
@@ -6087,7 +6486,7 @@ func (p *parser) parseClassStmt(loc logger.Loc, opts parseStmtOpts) js_ast.Stmt
 	}
 
 	// Even anonymous classes can have TypeScript type parameters
-	if p.options.ts.Parse {
+	if p.hasTypeSyntax() {
 		p.skipTypeScriptTypeParameters(allowInOutVarianceAnnotations | allowConstModifier)
 	}
 
@@ -6117,7 +6516,6 @@ func (p *parser) parseClassStmt(loc logger.Loc, opts parseStmtOpts) js_ast.Stmt
 
 func (p *parser) parseClassExpr(decorators []js_ast.Decorator) js_ast.Expr {
 	classKeyword := p.lexer.Range()
-	p.markSyntaxFeature(compat.Class, classKeyword)
 	p.lexer.Next()
 	var name *ast.LocRef
 
@@ -6130,7 +6528,7 @@ func (p *parser) parseClassExpr(decorators []js_ast.Decorator) js_ast.Expr {
 
 	// Parse an optional class name
 	if p.lexer.Token == js_lexer.TIdentifier {
-		if nameText := p.lexer.Identifier.String; !p.options.ts.Parse || nameText != "implements" {
+		if nameText := p.lexer.Identifier.String; !p.hasTypeSyntax() || nameText != "implements" {
 			if p.fnOrArrowDataParse.await != allowIdent && nameText == "await" {
 				p.log.AddError(&p.tracker, p.lexer.Range(), "Cannot use \"await\" as an identifier here:")
 			}
@@ -6140,7 +6538,7 @@ func (p *parser) parseClassExpr(decorators []js_ast.Decorator) js_ast.Expr {
 	}
 
 	// Even anonymous classes can have TypeScript type parameters
-	if p.options.ts.Parse {
+	if p.hasTypeSyntax() {
 		p.skipTypeScriptTypeParameters(allowInOutVarianceAnnotations | allowConstModifier)
 	}
 
@@ -6161,6 +6559,7 @@ type parseClassOpts struct {
 // been parsed. We need to start parsing from the "extends" clause.
 func (p *parser) parseClass(classKeyword logger.Range, name *ast.LocRef, classOpts parseClassOpts) js_ast.Class {
 	var extendsOrNil js_ast.Expr
+	heritageLoc := p.lexer.Loc()
 
 	if p.lexer.Token == js_lexer.TExtends {
 		p.lexer.Next()
@@ -6173,12 +6572,13 @@ func (p *parser) parseClass(classKeyword logger.Range, name *ast.LocRef, classOp
 		// This seems kind of wasteful to me but it's what the official compiler
 		// does and it probably doesn't have that high of a performance overhead
 		// because "extends" clauses aren't that frequent, so it should be ok.
-		if p.options.ts.Parse {
+		if p.hasTypeSyntax() {
 			p.skipTypeScriptTypeArguments(skipTypeScriptTypeArgumentsOpts{})
 		}
 	}
 
-	if p.options.ts.Parse && p.lexer.IsContextualKeyword("implements") {
+	if p.hasTypeSyntax() && p.lexer.IsContextualKeyword("implements") {
+		implementsLoc := p.lexer.Loc()
 		p.lexer.Next()
 		for {
 			p.skipTypeScriptType(js_ast.LLowest)
@@ -6187,9 +6587,15 @@ func (p *parser) parseClass(classKeyword logger.Range, name *ast.LocRef, classOp
 			}
 			p.lexer.Next()
 		}
+		p.stripTypesFrom(implementsLoc)
 	}
 
 	bodyLoc := p.lexer.Loc()
+	var dtsInfo *dtsClass
+	if p.dts != nil {
+		dtsInfo = &dtsClass{heritage: p.dtsRangeFrom(heritageLoc)}
+		p.dts.classes[bodyLoc] = dtsInfo
+	}
 	p.lexer.Expect(js_lexer.TOpenBrace)
 	properties := []js_ast.Property{}
 
@@ -6201,6 +6607,8 @@ func (p *parser) parseClass(classKeyword logger.Range, name *ast.LocRef, classOp
 
 	// A scope is needed for private identifiers
 	scopeIndex := p.pushScopeForParsePass(js_ast.ScopeClassBody, bodyLoc)
+	oldEnclosingClassBodyLoc := p.enclosingClassBodyLoc
+	p.enclosingClassBodyLoc = bodyLoc
 
 	opts := propertyOpts{
 		isClass:          true,
@@ -6210,8 +6618,15 @@ func (p *parser) parseClass(classKeyword logger.Range, name *ast.LocRef, classOp
 		classKeyword:     classKeyword,
 	}
 	hasConstructor := false
+	prevMemberLoc := logger.Loc{Start: -1}
 
-	for p.lexer.Token != js_lexer.TCloseBrace {
+	for {
+		if p.trailingComments != nil && prevMemberLoc.Start != -1 {
+			p.saveTrailingComments(prevMemberLoc)
+		}
+		if p.lexer.Token == js_lexer.TCloseBrace {
+			break
+		}
 		if p.lexer.Token == js_lexer.TSemicolon {
 			p.lexer.Next()
 			continue
@@ -6219,10 +6634,20 @@ func (p *parser) parseClass(classKeyword logger.Range, name *ast.LocRef, classOp
 
 		// Parse decorators for this property
 		firstDecoratorLoc := p.lexer.Loc()
+		docComment := p.dtsDocComment()
 		opts.decorators = p.parseDecorators(opts.decoratorScope, classKeyword, opts.decoratorContext)
 
 		// This property may turn out to be a type in TypeScript, which should be ignored
-		if property, ok := p.parseProperty(p.saveExprCommentsHere(), js_ast.PropertyNormal, opts, nil); ok {
+		memberLoc := p.lexer.Loc()
+		prevMemberLoc = memberLoc
+		property, ok := p.parseProperty(p.saveExprCommentsHere(), js_ast.PropertyNormal, opts, nil)
+		if !ok || property.Kind == js_ast.PropertyDeclare {
+			p.stripTypeOnlyStmtFrom(firstDecoratorLoc)
+		}
+		if dtsInfo != nil {
+			dtsInfo.members = append(dtsInfo.members, dtsMember{r: p.dtsRangeFrom(memberLoc), docComment: docComment, isKept: ok})
+		}
+		if ok {
 			properties = append(properties, property)
 
 			// Forbid decorators on class constructors
@@ -6251,6 +6676,7 @@ func (p *parser) parseClass(classKeyword logger.Range, name *ast.LocRef, classOp
 
 	p.allowIn = oldAllowIn
 	p.allowPrivateIdentifiers = oldAllowPrivateIdentifiers
+	p.enclosingClassBodyLoc = oldEnclosingClassBodyLoc
 
 	closeBraceLoc := p.saveExprCommentsHere()
 	p.lexer.Expect(js_lexer.TCloseBrace)
@@ -6301,7 +6727,7 @@ func (p *parser) parseLabelName() *ast.LocRef {
 	return &name
 }
 
-func (p *parser) parsePath() (logger.Loc, string, *ast.ImportAssertions, ast.ImportRecordFlags) {
+func (p *parser) parsePath() (logger.Loc, string, *ast.ImportAssertOrWith, ast.ImportRecordFlags) {
 	var flags ast.ImportRecordFlags
 	pathLoc := p.lexer.Loc()
 	pathText := helpers.UTF16ToString(p.lexer.StringLiteral())
@@ -6311,13 +6737,18 @@ func (p *parser) parsePath() (logger.Loc, string, *ast.ImportAssertions, ast.Imp
 		p.lexer.Expect(js_lexer.TStringLiteral)
 	}
 
-	// See https://github.com/tc39/proposal-import-assertions for more info
-	var assertions *ast.ImportAssertions
-	if !p.lexer.HasNewlineBefore && p.lexer.IsContextualKeyword("assert") {
+	// See https://github.com/tc39/proposal-import-attributes for more info
+	var assertOrWith *ast.ImportAssertOrWith
+	if p.lexer.Token == js_lexer.TWith || (!p.lexer.HasNewlineBefore && p.lexer.IsContextualKeyword("assert")) {
 		// "import './foo.json' assert { type: 'json' }"
-		var entries []ast.AssertEntry
+		// "import './foo.json' with { type: 'json' }"
+		var entries []ast.AssertOrWithEntry
 		duplicates := make(map[string]logger.Range)
-		assertLoc := p.saveExprCommentsHere()
+		keyword := ast.WithKeyword
+		if p.lexer.Token != js_lexer.TWith {
+			keyword = ast.AssertKeyword
+		}
+		keywordLoc := p.saveExprCommentsHere()
 		p.lexer.Next()
 		openBraceLoc := p.saveExprCommentsHere()
 		p.lexer.Expect(js_lexer.TOpenBrace)
@@ -6339,7 +6770,11 @@ func (p *parser) parsePath() (logger.Loc, string, *ast.ImportAssertions, ast.Imp
 				p.lexer.Expect(js_lexer.TIdentifier)
 			}
 			if prevRange, ok := duplicates[keyText]; ok {
-				p.log.AddErrorWithNotes(&p.tracker, p.lexer.Range(), fmt.Sprintf("Duplicate import assertion %q", keyText),
+				what := "attribute"
+				if keyword == ast.AssertKeyword {
+					what = "assertion"
+				}
+				p.log.AddErrorWithNotes(&p.tracker, p.lexer.Range(), fmt.Sprintf("Duplicate import %s %q", what, keyText),
 					[]logger.MsgData{p.tracker.MsgData(prevRange, fmt.Sprintf("The first %q was here:", keyText))})
 			}
 			duplicates[keyText] = p.lexer.Range()
@@ -6351,7 +6786,7 @@ func (p *parser) parsePath() (logger.Loc, string, *ast.ImportAssertions, ast.Imp
 			value := p.lexer.StringLiteral()
 			p.lexer.Expect(js_lexer.TStringLiteral)
 
-			entries = append(entries, ast.AssertEntry{
+			entries = append(entries, ast.AssertOrWithEntry{
 				Key:             key,
 				KeyLoc:          keyLoc,
 				Value:           value,
@@ -6359,7 +6794,7 @@ func (p *parser) parsePath() (logger.Loc, string, *ast.ImportAssertions, ast.Imp
 				PreferQuotedKey: preferQuotedKey,
 			})
 
-			// Using "assert: { type: 'json' }" triggers special behavior
+			// Using "assert { type: 'json' }" or "with { type: 'json' }" triggers special behavior
 			if helpers.UTF16EqualsString(key, "type") && helpers.UTF16EqualsString(value, "json") {
 				flags |= ast.AssertTypeJSON
 			}
@@ -6372,28 +6807,22 @@ func (p *parser) parsePath() (logger.Loc, string, *ast.ImportAssertions, ast.Imp
 
 		closeBraceLoc := p.saveExprCommentsHere()
 		p.lexer.Expect(js_lexer.TCloseBrace)
-		assertions = &ast.ImportAssertions{
+		assertOrWith = &ast.ImportAssertOrWith{
 			Entries:            entries,
-			AssertLoc:          assertLoc,
+			KeywordLoc:         keywordLoc,
 			InnerOpenBraceLoc:  openBraceLoc,
 			InnerCloseBraceLoc: closeBraceLoc,
+			Keyword:            keyword,
 		}
 	}
 
-	return pathLoc, pathText, assertions, flags
+	return pathLoc, pathText, assertOrWith, flags
 }
 
 // This assumes the "function" token has already been parsed
 func (p *parser) parseFnStmt(loc logger.Loc, opts parseStmtOpts, isAsync bool, asyncRange logger.Range) js_ast.Stmt {
 	isGenerator := p.lexer.Token == js_lexer.TAsterisk
-	hasError := false
-	if isAsync {
-		hasError = p.markAsyncFn(asyncRange, isGenerator)
-	}
 	if isGenerator {
-		if !hasError {
-			p.markSyntaxFeature(compat.Generator, p.lexer.Range())
-		}
 		p.lexer.Next()
 	}
 
@@ -6423,7 +6852,7 @@ func (p *parser) parseFnStmt(loc logger.Loc, opts parseStmtOpts, isAsync bool, a
 	}
 
 	// Even anonymous functions can have TypeScript type parameters
-	if p.options.ts.Parse {
+	if p.hasTypeSyntax() {
 		p.skipTypeScriptTypeParameters(allowConstModifier)
 	}
 
@@ -6526,27 +6955,22 @@ func (p *parser) parseDecorators(decoratorScope *js_ast.Scope, classKeyword logg
 	if p.lexer.Token == js_lexer.TAt {
 		if p.options.ts.Parse {
 			if p.options.ts.Config.ExperimentalDecorators == config.True {
+				if p.strippedTypeFixups != nil {
+					p.log.AddError(&p.tracker, p.lexer.Range(), "Experimental decorators are not supported when only stripping types because they generate code")
+				}
 				if (context & decoratorInClassExpr) != 0 {
 					p.lexer.AddRangeErrorWithNotes(p.lexer.Range(), "Experimental decorators can only be used with class declarations in TypeScript",
 						[]logger.MsgData{p.tracker.MsgData(classKeyword, "This is a class expression, not a class declaration:")})
 				} else if (context & decoratorBeforeClassExpr) != 0 {
 					p.log.AddError(&p.tracker, p.lexer.Range(), "Experimental decorators cannot be used in expression position in TypeScript")
 				}
-			} else {
-				if (context&decoratorInFnArgs) != 0 && p.options.ts.Config.ExperimentalDecorators != config.True {
-					p.log.AddErrorWithNotes(&p.tracker, p.lexer.Range(), "Parameter decorators only work when experimental decorators are enabled", []logger.MsgData{{
-						Text: "You can enable experimental decorators by adding \"experimentalDecorators\": true to your \"tsconfig.json\" file.",
-					}})
-				} else {
-					p.markSyntaxFeature(compat.Decorators, p.lexer.Range())
-				}
-			}
-		} else {
-			if (context & decoratorInFnArgs) != 0 {
-				p.log.AddError(&p.tracker, p.lexer.Range(), "Parameter decorators are not allowed in JavaScript")
-			} else {
-				p.markSyntaxFeature(compat.Decorators, p.lexer.Range())
+			} else if (context & decoratorInFnArgs) != 0 {
+				p.log.AddErrorWithNotes(&p.tracker, p.lexer.Range(), "Parameter decorators only work when experimental decorators are enabled", []logger.MsgData{{
+					Text: "You can enable experimental decorators by adding \"experimentalDecorators\": true to your \"tsconfig.json\" file.",
+				}})
 			}
+		} else if (context & decoratorInFnArgs) != 0 {
+			p.log.AddError(&p.tracker, p.lexer.Range(), "Parameter decorators are not allowed in JavaScript")
 		}
 	}
 
@@ -6768,6 +7192,23 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 				return p.parseFnStmt(loc, opts, true /* isAsync */, asyncRange)
 			}
 
+			if p.options.flow.Parse {
+				switch p.lexer.Identifier.String {
+				case "type":
+					// "export type Foo = ..."
+					// "export type {Foo} from 'bar'"
+					p.lexer.Next()
+					p.skipTypeScriptTypeStmt(parseStmtOpts{isModuleScope: opts.isModuleScope, isExport: true})
+					return js_ast.Stmt{Loc: loc, Data: js_ast.STypeScriptShared}
+
+				case "opaque", "interface":
+					// "export opaque type Foo = ..."
+					// "export interface Foo {}"
+					opts.isExport = true
+					return p.parseStmt(opts)
+				}
+			}
+
 			if p.options.ts.Parse {
 				switch p.lexer.Identifier.String {
 				case "type":
@@ -6903,6 +7344,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			// Handle the default export of an abstract class in TypeScript
 			if p.options.ts.Parse && isIdentifier && name == "abstract" {
 				if _, ok := expr.Data.(*js_ast.EIdentifier); ok && (p.lexer.Token == js_lexer.TClass || opts.deferredDecorators != nil) {
+					p.stripTypesInRange(js_lexer.RangeOfIdentifier(p.source, expr.Loc))
 					stmt := p.parseClassStmt(loc, parseStmtOpts{
 						deferredDecorators: opts.deferredDecorators,
 						isNameOptional:     true,
@@ -6935,7 +7377,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			var alias *js_ast.ExportStarAlias
 			var pathLoc logger.Loc
 			var pathText string
-			var assertions *ast.ImportAssertions
+			var assertOrWith *ast.ImportAssertOrWith
 			var flags ast.ImportRecordFlags
 
 			if p.lexer.IsContextualKeyword("as") {
@@ -6946,15 +7388,15 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 				alias = &js_ast.ExportStarAlias{Loc: p.lexer.Loc(), OriginalName: name.String}
 				p.lexer.Next()
 				p.lexer.ExpectContextualKeyword("from")
-				pathLoc, pathText, assertions, flags = p.parsePath()
+				pathLoc, pathText, assertOrWith, flags = p.parsePath()
 			} else {
 				// "export * from 'path'"
 				p.lexer.ExpectContextualKeyword("from")
-				pathLoc, pathText, assertions, flags = p.parsePath()
+				pathLoc, pathText, assertOrWith, flags = p.parsePath()
 				name := js_ast.GenerateNonUniqueNameFromPath(pathText) + "_star"
 				namespaceRef = p.storeNameInRef(js_lexer.MaybeSubstring{String: name})
 			}
-			importRecordIndex := p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertions, flags)
+			importRecordIndex := p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertOrWith, flags)
 
 			// Export-star statements anywhere in the file disable top-level const
 			// local prefix because import cycles can be used to trigger TDZ
@@ -6976,8 +7418,8 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			if p.lexer.IsContextualKeyword("from") {
 				// "export {} from 'path'"
 				p.lexer.Next()
-				pathLoc, pathText, assertions, flags := p.parsePath()
-				importRecordIndex := p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertions, flags)
+				pathLoc, pathText, assertOrWith, flags := p.parsePath()
+				importRecordIndex := p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertOrWith, flags)
 				name := "import_" + js_ast.GenerateNonUniqueNameFromPath(pathText)
 				namespaceRef := p.storeNameInRef(js_lexer.MaybeSubstring{String: name})
 
@@ -7081,7 +7523,6 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		if opts.lexicalDecl != lexicalDeclAllowAll {
 			p.forbidLexicalDecl(loc)
 		}
-		p.markSyntaxFeature(compat.ConstAndLet, p.lexer.Range())
 		p.lexer.Next()
 
 		if p.options.ts.Parse && p.lexer.Token == js_lexer.TEnum {
@@ -7089,6 +7530,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		}
 
 		decls := p.parseAndDeclareDecls(ast.SymbolConst, opts)
+		p.markLetOrConstForLowering(decls)
 		p.lexer.ExpectOrInsertSemicolon()
 		if !opts.isTypeScriptDeclare {
 			p.requireInitializers(js_ast.LocalConst, decls)
@@ -7104,17 +7546,17 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		p.lexer.Expect(js_lexer.TOpenParen)
 		test := p.parseExpr(js_ast.LLowest)
 		p.lexer.Expect(js_lexer.TCloseParen)
-		yes := p.parseStmt(parseStmtOpts{lexicalDecl: lexicalDeclAllowFnInsideIf})
+		yes := p.parseNestedStmt(parseStmtOpts{lexicalDecl: lexicalDeclAllowFnInsideIf})
 		var noOrNil js_ast.Stmt
 		if p.lexer.Token == js_lexer.TElse {
 			p.lexer.Next()
-			noOrNil = p.parseStmt(parseStmtOpts{lexicalDecl: lexicalDeclAllowFnInsideIf})
+			noOrNil = p.parseNestedStmt(parseStmtOpts{lexicalDecl: lexicalDeclAllowFnInsideIf})
 		}
 		return js_ast.Stmt{Loc: loc, Data: &js_ast.SIf{Test: test, Yes: yes, NoOrNil: noOrNil}}
 
 	case js_lexer.TDo:
 		p.lexer.Next()
-		body := p.parseStmt(parseStmtOpts{})
+		body := p.parseNestedStmt(parseStmtOpts{})
 		p.lexer.Expect(js_lexer.TWhile)
 		p.lexer.Expect(js_lexer.TOpenParen)
 		test := p.parseExpr(js_ast.LLowest)
@@ -7132,7 +7574,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		p.lexer.Expect(js_lexer.TOpenParen)
 		test := p.parseExpr(js_ast.LLowest)
 		p.lexer.Expect(js_lexer.TCloseParen)
-		body := p.parseStmt(parseStmtOpts{})
+		body := p.parseNestedStmt(parseStmtOpts{})
 		return js_ast.Stmt{Loc: loc, Data: &js_ast.SWhile{Test: test, Body: body}}
 
 	case js_lexer.TWith:
@@ -7146,7 +7588,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		// within the body from being renamed. Renaming them might change the
 		// semantics of the code.
 		p.pushScopeForParsePass(js_ast.ScopeWith, bodyLoc)
-		body := p.parseStmt(parseStmtOpts{})
+		body := p.parseNestedStmt(parseStmtOpts{})
 		p.popScope()
 
 		return js_ast.Stmt{Loc: loc, Data: &js_ast.SWith{Value: test, BodyLoc: bodyLoc, Body: body}}
@@ -7169,6 +7611,9 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			var value js_ast.Expr
 			body := []js_ast.Stmt{}
 			caseLoc := p.lexer.Loc()
+			if p.trailingComments != nil {
+				p.saveLeadingComments()
+			}
 
 			if p.lexer.Token == js_lexer.TDefault {
 				if foundDefault {
@@ -7186,11 +7631,23 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 
 		caseBody:
 			for {
+				if p.trailingComments != nil && len(body) > 0 {
+					p.saveTrailingComments(body[len(body)-1].Loc)
+				}
+
 				switch p.lexer.Token {
 				case js_lexer.TCloseBrace, js_lexer.TCase, js_lexer.TDefault:
 					break caseBody
 
 				default:
+					if p.trailingComments != nil {
+						stmtLoc := p.lexer.Loc()
+						p.saveLeadingComments()
+						stmt := p.parseStmt(parseStmtOpts{lexicalDecl: lexicalDeclAllowAll})
+						p.moveLeadingComments(stmtLoc, stmt.Loc)
+						body = append(body, stmt)
+						continue
+					}
 					body = append(body, p.parseStmt(parseStmtOpts{lexicalDecl: lexicalDeclAllowAll}))
 				}
 			}
@@ -7299,17 +7756,8 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			if p.fnOrArrowDataParse.await != allowExpr {
 				p.log.AddError(&p.tracker, awaitRange, "Cannot use \"await\" outside an async function")
 				awaitRange = logger.Range{}
-			} else {
-				didGenerateError := false
-				if p.fnOrArrowDataParse.isTopLevel {
-					p.topLevelAwaitKeyword = awaitRange
-				}
-				if !didGenerateError && p.options.unsupportedJSFeatures.Has(compat.AsyncAwait) && p.options.unsupportedJSFeatures.Has(compat.Generator) {
-					// If for-await loops aren't supported, then we only support lowering
-					// if either async/await or generators is supported. Otherwise we
-					// cannot lower for-await loops.
-					p.markSyntaxFeature(compat.ForAwait, awaitRange)
-				}
+			} else if p.fnOrArrowDataParse.isTopLevel {
+				p.topLevelAwaitKeyword = awaitRange
 			}
 			p.lexer.Next()
 		}
@@ -7338,9 +7786,9 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			initOrNil = js_ast.Stmt{Loc: initLoc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: decls}}
 
 		case js_lexer.TConst:
-			p.markSyntaxFeature(compat.ConstAndLet, p.lexer.Range())
 			p.lexer.Next()
 			decls = p.parseAndDeclareDecls(ast.SymbolConst, parseStmtOpts{})
+			p.markLetOrConstForLowering(decls)
 			initOrNil = js_ast.Stmt{Loc: initLoc, Data: &js_ast.SLocal{Kind: js_ast.LocalConst, Decls: decls}}
 
 		case js_lexer.TSemicolon:
@@ -7377,11 +7825,10 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 				}
 			}
 			p.forbidInitializers(decls, "of", false)
-			p.markSyntaxFeature(compat.ForOf, p.lexer.Range())
 			p.lexer.Next()
 			value := p.parseExpr(js_ast.LComma)
 			p.lexer.Expect(js_lexer.TCloseParen)
-			body := p.parseStmt(parseStmtOpts{})
+			body := p.parseNestedStmt(parseStmtOpts{})
 			return js_ast.Stmt{Loc: loc, Data: &js_ast.SForOf{Await: awaitRange, Init: initOrNil, Value: value, Body: body}}
 		}
 
@@ -7400,7 +7847,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			p.lexer.Next()
 			value := p.parseExpr(js_ast.LLowest)
 			p.lexer.Expect(js_lexer.TCloseParen)
-			body := p.parseStmt(parseStmtOpts{})
+			body := p.parseNestedStmt(parseStmtOpts{})
 			return js_ast.Stmt{Loc: loc, Data: &js_ast.SForIn{Init: initOrNil, Value: value, Body: body}}
 		}
 
@@ -7427,7 +7874,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		}
 
 		p.lexer.Expect(js_lexer.TCloseParen)
-		body := p.parseStmt(parseStmtOpts{})
+		body := p.parseNestedStmt(parseStmtOpts{})
 		return js_ast.Stmt{Loc: loc, Data: &js_ast.SFor{
 			InitOrNil:   initOrNil,
 			TestOrNil:   testOrNil,
@@ -7488,11 +7935,29 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 				return js_ast.Stmt{}
 			}
 
+			openBraceLoc := p.lexer.Loc()
 			items, isSingleLine := p.parseImportClause()
+			if p.options.flow.Parse && p.isFlowTypeOnlyImportClause(openBraceLoc, items) {
+				p.lexer.ExpectContextualKeyword("from")
+				p.parsePath()
+				p.lexer.ExpectOrInsertSemicolon()
+				return js_ast.Stmt{Loc: loc, Data: js_ast.STypeScriptShared}
+			}
 			stmt.Items = &items
 			stmt.IsSingleLine = isSingleLine
 			p.lexer.ExpectContextualKeyword("from")
 
+		case js_lexer.TTypeof:
+			// "import typeof Foo from 'bar'"
+			if p.options.flow.Parse && opts.isModuleScope {
+				p.lexer.Next()
+				if p.trySkipFlowTypeImport() {
+					return js_ast.Stmt{Loc: loc, Data: js_ast.STypeScriptShared}
+				}
+			}
+			p.lexer.Unexpected()
+			return js_ast.Stmt{}
+
 		case js_lexer.TIdentifier:
 			// "import defaultItem from 'path'"
 			// "import foo = bar"
@@ -7505,6 +7970,12 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			stmt.DefaultName = &ast.LocRef{Loc: p.lexer.Loc(), Ref: p.storeNameInRef(defaultName)}
 			p.lexer.Next()
 
+			// "import type Foo from 'bar'"
+			// "import type {Foo} from 'bar'"
+			if p.options.flow.Parse && defaultName.String == "type" && p.trySkipFlowTypeImport() {
+				return js_ast.Stmt{Loc: loc, Data: js_ast.STypeScriptShared}
+			}
+
 			if p.options.ts.Parse {
 				// Skip over type-only imports
 				if defaultName.String == "type" {
@@ -7585,7 +8056,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 			return js_ast.Stmt{}
 		}
 
-		pathLoc, pathText, assertions, flags := p.parsePath()
+		pathLoc, pathText, assertOrWith, flags := p.parsePath()
 		p.lexer.ExpectOrInsertSemicolon()
 
 		// If TypeScript's "preserveValueImports": true setting is active, TypeScript's
@@ -7617,7 +8088,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 		if wasOriginallyBareImport {
 			flags |= ast.WasOriginallyBareImport
 		}
-		stmt.ImportRecordIndex = p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertions, flags)
+		stmt.ImportRecordIndex = p.addImportRecord(ast.ImportStmt, pathLoc, pathText, assertOrWith, flags)
 
 		if stmt.StarNameLoc != nil {
 			name := p.loadNameFromRef(stmt.NamespaceRef)
@@ -7752,10 +8223,17 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 					if opts.lexicalDecl == lexicalDeclAllowAll || opts.lexicalDecl == lexicalDeclAllowFnInsideLabel {
 						nestedOpts.lexicalDecl = lexicalDeclAllowFnInsideLabel
 					}
-					stmt := p.parseStmt(nestedOpts)
+					stmt := p.parseNestedStmt(nestedOpts)
 					return js_ast.Stmt{Loc: loc, Data: &js_ast.SLabel{Name: name, Stmt: stmt}}
 				}
 
+				// "type Foo = any"
+				// "opaque type Foo = any"
+				// "declare var foo: any"
+				if p.options.flow.Parse && !p.lexer.HasNewlineBefore && p.trySkipFlowStmt(name, opts) {
+					return js_ast.Stmt{Loc: loc, Data: js_ast.STypeScriptShared}
+				}
+
 				if p.options.ts.Parse {
 					switch name {
 					case "type":
@@ -7784,6 +8262,7 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 
 					case "abstract":
 						if !p.lexer.HasNewlineBefore && (p.lexer.Token == js_lexer.TClass || opts.deferredDecorators != nil) {
+							p.stripTypesInRange(js_lexer.RangeOfIdentifier(p.source, expr.Loc))
 							return p.parseClassStmt(loc, opts)
 						}
 
@@ -7898,14 +8377,14 @@ func (p *parser) parseStmt(opts parseStmtOpts) js_ast.Stmt {
 	}
 }
 
-func (p *parser) addImportRecord(kind ast.ImportKind, loc logger.Loc, text string, assertions *ast.ImportAssertions, flags ast.ImportRecordFlags) uint32 {
+func (p *parser) addImportRecord(kind ast.ImportKind, loc logger.Loc, text string, assertOrWith *ast.ImportAssertOrWith, flags ast.ImportRecordFlags) uint32 {
 	index := uint32(len(p.importRecords))
 	p.importRecords = append(p.importRecords, ast.ImportRecord{
-		Kind:       kind,
-		Range:      p.source.RangeOfString(loc),
-		Path:       logger.Path{Text: text},
-		Assertions: assertions,
-		Flags:      flags,
+		Kind:         kind,
+		Range:        p.source.RangeOfString(loc),
+		Path:         logger.Path{Text: text},
+		AssertOrWith: assertOrWith,
+		Flags:        flags,
 	})
 	return index
 }
@@ -7943,6 +8422,16 @@ func (p *parser) parseStmtsUpTo(end js_lexer.T, opts parseStmtOpts) []js_ast.Stm
 	opts.lexicalDecl = lexicalDeclAllowAll
 	isDirectivePrologue := opts.allowDirectivePrologue
 
+	// Declarations are only generated for module and namespace scopes
+	var dtsStmts *[]dtsStmt
+	if p.dts != nil && (opts.isModuleScope || opts.isNamespaceScope) {
+		dtsStmts = &[]dtsStmt{}
+	}
+
+	prevStmtLoc := logger.Loc{Start: -1}
+	prevStmtWasRemoved := false
+	var removedComments []string
+
 	for {
 		// Preserve some statement-level comments
 		comments := p.lexer.LegalCommentsBeforeToken
@@ -7958,18 +8447,67 @@ func (p *parser) parseStmtsUpTo(end js_lexer.T, opts parseStmtOpts) []js_ast.Stm
 			}
 		}
 
+		// Optionally preserve all other comments too. Comments are attached to
+		// the statement before or after them so that they are removed when that
+		// statement is removed. Comments at the end of a block can't be attached
+		// to anything, so they become separate statements instead.
+		if p.trailingComments != nil {
+			if prevStmtLoc.Start != -1 {
+				p.saveTrailingComments(prevStmtLoc)
+				if prevStmtWasRemoved {
+					removedComments = p.takeStmtComments(prevStmtLoc, removedComments)
+					prevStmtWasRemoved = false
+				}
+			}
+			if p.lexer.Token == end {
+				stmts = appendCommentStmts(stmts, prevStmtLoc, removedComments)
+				for _, comment := range p.lexer.CommentsBeforeToken {
+					if !p.isLegalCommentBeforeToken(comment) {
+						stmts = append(stmts, js_ast.Stmt{
+							Loc:  comment.Loc,
+							Data: &js_ast.SComment{Text: p.source.CommentTextWithoutIndent(comment)},
+						})
+					}
+				}
+			} else {
+				p.saveLeadingComments()
+			}
+		}
+
 		if p.lexer.Token == end {
 			break
 		}
 
+		stmtLoc := p.lexer.Loc()
+		docComment := p.dtsDocComment()
 		stmt := p.parseStmt(opts)
 
-		// Skip TypeScript types entirely
-		if p.options.ts.Parse {
+		if p.trailingComments != nil {
+			p.moveLeadingComments(stmtLoc, stmt.Loc)
+			prevStmtLoc = stmt.Loc
+		}
+
+		// Remember where each statement is for generating declarations
+		if dtsStmts != nil {
+			*dtsStmts = append(*dtsStmts, dtsStmt{data: stmt.Data, r: p.dtsRangeFrom(stmtLoc), docComment: docComment})
+		}
+
+		// Check for TypeScript syntax that can't be replaced with whitespace
+		if p.strippedTypeFixups != nil || p.options.ts.IsErasableSyntaxOnly() {
+			p.checkForNonErasableTypeScriptStmt(stmtLoc, stmt)
+		}
+
+		// Skip TypeScript types entirely
+		if p.hasTypeSyntax() {
 			if _, ok := stmt.Data.(*js_ast.STypeScript); ok {
+				prevStmtWasRemoved = true
 				continue
 			}
 		}
+		if removedComments != nil {
+			p.attachStmtComments(stmt.Loc, removedComments)
+			removedComments = nil
+		}
 
 		// Parse one or more directives at the beginning
 		if isDirectivePrologue {
@@ -8039,6 +8577,9 @@ func (p *parser) parseStmtsUpTo(end js_lexer.T, opts parseStmtOpts) []js_ast.Stm
 		}
 	}
 
+	if dtsStmts != nil {
+		p.dts.stmts = *dtsStmts
+	}
 	return stmts
 }
 
@@ -8082,9 +8623,9 @@ func (p *parser) generateTempRef(declare generateTempRefArg, optionalName string
 	return ref
 }
 
-func (p *parser) generateTopLevelTempRef() ast.Ref {
+func (p *parser) generateTopLevelTempRef(valueOrNil js_ast.Expr) ast.Ref {
 	ref := p.newSymbol(ast.SymbolOther, "_"+ast.DefaultNameMinifierJS.NumberToMinifiedName(p.topLevelTempRefCount))
-	p.topLevelTempRefsToDeclare = append(p.topLevelTempRefsToDeclare, tempRef{ref: ref})
+	p.topLevelTempRefsToDeclare = append(p.topLevelTempRefsToDeclare, tempRef{ref: ref, valueOrNil: valueOrNil})
 	p.moduleScope.Generated = append(p.moduleScope.Generated, ref)
 	p.topLevelTempRefCount++
 	return ref
@@ -9373,8 +9914,14 @@ func (p *parser) substituteSingleUseSymbolInExpr(
 func (p *parser) visitLoopBody(stmt js_ast.Stmt) js_ast.Stmt {
 	oldIsInsideLoop := p.fnOrArrowDataVisit.isInsideLoop
 	p.fnOrArrowDataVisit.isInsideLoop = true
+	if loop := p.fnOrArrowDataVisit.letOrConstLoop; loop != nil {
+		loop.isVisitingBody = true
+	}
 	p.loopBody = stmt.Data
 	stmt = p.visitSingleStmt(stmt, stmtsLoopBody)
+	if loop := p.fnOrArrowDataVisit.letOrConstLoop; loop != nil {
+		loop.isVisitingBody = false
+	}
 	p.fnOrArrowDataVisit.isInsideLoop = oldIsInsideLoop
 	return stmt
 }
@@ -9441,6 +9988,9 @@ func (p *parser) visitForLoopInit(stmt js_ast.Stmt, isInOrOf bool) js_ast.Stmt {
 			}
 		}
 		s.Decls = p.lowerObjectRestInDecls(s.Decls)
+		if s.Kind == js_ast.LocalLet || s.Kind == js_ast.LocalConst {
+			p.lowerLetOrConstDecls(s, true)
+		}
 		s.Kind = p.selectLocalKind(s.Kind)
 
 	default:
@@ -9500,6 +10050,7 @@ func (p *parser) visitBinding(binding js_ast.Binding, opts bindingOpts) {
 	case *js_ast.BObject:
 		for i, property := range b.Properties {
 			if !property.IsSpread {
+				property.Key = p.maybeMangleTSPrivateKey(property.Key, property.IsComputed || property.PreferQuotedKey, nil)
 				property.Key, _ = p.visitExprInOut(property.Key, exprIn{
 					shouldMangleStringsAsProps: true,
 				})
@@ -9824,8 +10375,9 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			if p.symbols[ref.InnerIndex].Kind == ast.SymbolUnbound {
 				// Silently strip exports of non-local symbols in TypeScript, since
 				// those likely correspond to type-only exports. But report exports of
-				// non-local symbols as errors in JavaScript.
-				if !p.options.ts.Parse {
+				// non-local symbols as errors in JavaScript. Exports of local Flow
+				// types are also stripped.
+				if !p.options.ts.Parse && (!p.options.flow.Parse || !p.localTypeNames[name]) {
 					r := js_lexer.RangeOfIdentifier(p.source, item.Name.Loc)
 					p.log.AddError(&p.tracker, r, fmt.Sprintf("%q is not declared in this file", name))
 				}
@@ -9904,7 +10456,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			s2.Value = p.visitExpr(s2.Value)
 
 			// Discard type-only export default statements
-			if p.options.ts.Parse {
+			if p.hasTypeSyntax() {
 				if id, ok := s2.Value.Data.(*js_ast.EIdentifier); ok {
 					symbol := p.symbols[id.Ref.InnerIndex]
 					if symbol.Kind == ast.SymbolUnbound && p.localTypeNames[symbol.OriginalName] {
@@ -10067,9 +10619,19 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			return stmts
 		}
 
+		_, isForOf := s.Stmt.Data.(*js_ast.SForOf)
 		s.Stmt = p.visitSingleStmt(s.Stmt, stmtsNormal)
 		p.popScope()
 
+		// Lowering a "for of" loop wraps the loop in a "try" statement, so the
+		// label must be moved onto the inner loop for "continue" to still work
+		if isForOf {
+			if loop := findLoopInLoweredForOf(s.Stmt); loop != nil {
+				*loop = js_ast.Stmt{Loc: loop.Loc, Data: &js_ast.SLabel{Name: s.Name, Stmt: *loop}}
+				return append(stmts, s.Stmt)
+			}
+		}
+
 		if p.options.minifySyntax {
 			// Optimize "x: break x" which some people apparently write by hand
 			if child, ok := s.Stmt.Data.(*js_ast.SBreak); ok && child.Label != nil && child.Label.Ref == s.Name.Ref {
@@ -10084,6 +10646,11 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 
 	case *js_ast.SLocal:
 		// Silently remove unsupported top-level "await" in dead code branches
+		if s.Kind == js_ast.LocalAwaitUsing {
+			if loop := p.fnOrArrowDataVisit.letOrConstLoop; loop != nil {
+				loop.yieldOrAwaitKeyword = "await using"
+			}
+		}
 		if s.Kind == js_ast.LocalAwaitUsing && p.fnOrArrowDataVisit.isOutsideFnOrArrow {
 			if p.isControlFlowDead && (p.options.unsupportedJSFeatures.Has(compat.TopLevelAwait) || !p.options.outputFormat.KeepESMImportExportSyntax()) {
 				s.Kind = js_ast.LocalUsing
@@ -10173,6 +10740,17 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 					p.currentScope.IsAfterConstLocalPrefix = true
 				}
 			}
+
+			// Other top-level constants may still be inlined into other files
+			if p.options.mode == config.ModeBundle && p.options.minifySyntax && p.currentScope == p.moduleScope &&
+				s.Kind == js_ast.LocalConst && d.ValueOrNil.Data != nil {
+				if id, ok := d.Binding.Data.(*js_ast.BIdentifier); ok {
+					p.recordCrossModuleConstCandidate(id.Ref, d.ValueOrNil)
+					if p.options.collapseProperties {
+						p.recordCollapsibleObject(id.Ref, d.ValueOrNil)
+					}
+				}
+			}
 		}
 
 		// Handle being exported inside a namespace
@@ -10214,10 +10792,19 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			}
 		}
 
+		// Handle "let" and "const" declarations that will become "var". These
+		// aren't relocated when inside a loop because the loop body may need to
+		// be moved into a function, which relies on the declaration staying put.
+		canRelocate := true
+		if s.Kind == js_ast.LocalLet || s.Kind == js_ast.LocalConst {
+			p.lowerLetOrConstDecls(s, false)
+			canRelocate = p.fnOrArrowDataVisit.letOrConstLoop == nil
+		}
+
 		s.Kind = p.selectLocalKind(s.Kind)
 
 		// Potentially relocate "var" declarations to the top level
-		if s.Kind == js_ast.LocalVar {
+		if s.Kind == js_ast.LocalVar && canRelocate {
 			if assign, ok := p.maybeRelocateVarsToTopLevel(s.Decls, relocateVarsNormal); ok {
 				if assign.Data != nil {
 					stmts = append(stmts, assign)
@@ -10273,6 +10860,11 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 					s.ValueOrNil = js_ast.Expr{}
 				}
 			}
+		} else if ref := p.fnOnlyDataVisit.derivedClassThisRef; ref != nil && !p.fnOrArrowDataVisit.isArrow {
+			// A lowered derived class constructor must return the object that the
+			// lowered "super()" call created: "return" => "return _this"
+			p.recordUsage(*ref)
+			s.ValueOrNil = js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EIdentifier{Ref: *ref}}
 		}
 
 	case *js_ast.SBlock:
@@ -10307,8 +10899,10 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		p.popScope()
 
 	case *js_ast.SWhile:
+		loop := p.pushLetOrConstLoop()
 		s.Test = p.visitExpr(s.Test)
 		s.Body = p.visitLoopBody(s.Body)
+		stmts = p.popLetOrConstLoop(loop, &s.Body, false, stmts)
 
 		if p.options.minifySyntax {
 			s.Test = js_ast.SimplifyBooleanExpr(s.Test)
@@ -10326,7 +10920,9 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		}
 
 	case *js_ast.SDoWhile:
+		loop := p.pushLetOrConstLoop()
 		s.Body = p.visitLoopBody(s.Body)
+		stmts = p.popLetOrConstLoop(loop, &s.Body, false, stmts)
 		s.Test = p.visitExpr(s.Test)
 
 		if p.options.minifySyntax {
@@ -10378,6 +10974,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		}
 
 	case *js_ast.SFor:
+		loop := p.pushLetOrConstLoop()
 		p.pushScopeForVisitPass(js_ast.ScopeBlock, stmt.Loc)
 		if s.InitOrNil.Data != nil {
 			p.visitForLoopInit(s.InitOrNil, false)
@@ -10400,6 +10997,7 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			s.UpdateOrNil = p.visitExpr(s.UpdateOrNil)
 		}
 		s.Body = p.visitLoopBody(s.Body)
+		stmts = p.popLetOrConstLoop(loop, &s.Body, true, stmts)
 
 		// Potentially relocate "var" declarations to the top level. Note that this
 		// must be done inside the scope of the for loop or they won't be relocated.
@@ -10422,10 +11020,12 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 		}
 
 	case *js_ast.SForIn:
+		loop := p.pushLetOrConstLoop()
 		p.pushScopeForVisitPass(js_ast.ScopeBlock, stmt.Loc)
 		p.visitForLoopInit(s.Init, true)
 		s.Value = p.visitExpr(s.Value)
 		s.Body = p.visitLoopBody(s.Body)
+		stmts = p.popLetOrConstLoop(loop, &s.Body, false, stmts)
 
 		// Check for a variable initializer
 		if local, ok := s.Init.Data.(*js_ast.SLocal); ok && local.Kind == js_ast.LocalVar && len(local.Decls) == 1 {
@@ -10465,10 +11065,17 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			}
 		}
 
+		// The implicit "await" in this loop can't be moved into another function
+		if loop := p.fnOrArrowDataVisit.letOrConstLoop; loop != nil && s.Await.Len > 0 {
+			loop.yieldOrAwaitKeyword = "for await"
+		}
+
+		loop := p.pushLetOrConstLoop()
 		p.pushScopeForVisitPass(js_ast.ScopeBlock, stmt.Loc)
 		p.visitForLoopInit(s.Init, true)
 		s.Value = p.visitExpr(s.Value)
 		s.Body = p.visitLoopBody(s.Body)
+		stmts = p.popLetOrConstLoop(loop, &s.Body, false, stmts)
 
 		// Potentially relocate "var" declarations to the top level. Note that this
 		// must be done inside the scope of the for loop or they won't be relocated.
@@ -10511,6 +11118,11 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 			return p.lowerForAwaitLoop(stmt.Loc, s, stmts)
 		}
 
+		// Lower "for of" if it's unsupported
+		if s.Await.Len == 0 && p.options.unsupportedJSFeatures.Has(compat.ForOf) {
+			return p.lowerForOfLoop(stmt.Loc, s, stmts)
+		}
+
 	case *js_ast.STry:
 		p.pushScopeForVisitPass(js_ast.ScopeBlock, stmt.Loc)
 		if p.fnOrArrowDataVisit.tryBodyCount == 0 {
@@ -10610,6 +11222,11 @@ func (p *parser) visitAndAppendStmt(stmts []js_ast.Stmt, stmt js_ast.Stmt) []js_
 					}
 				}
 			}
+
+			// Remember top-level functions that the linker may decide to inline
+			if p.options.inlineFunctions && p.options.mode == config.ModeBundle && p.currentScope == p.moduleScope {
+				p.recordInlinableFunction(&s.Fn)
+			}
 		}
 
 		// Handle exporting this function from a namespace
@@ -10915,6 +11532,244 @@ func isSafeForConstLocalPrefix(expr js_ast.Expr) bool {
 	return false
 }
 
+// Top-level constants outside of the const local prefix can't be inlined
+// within the file that declares them, but the linker may still be able to
+// inline them into other files. It only does this if the declaring file isn't
+// part of an import cycle, since the constant is then always initialized
+// before any importing file is evaluated.
+func (p *parser) recordCrossModuleConstCandidate(ref ast.Ref, value js_ast.Expr) {
+	if constValue := js_ast.ExprToConstValue(value); constValue.Kind != js_ast.ConstValueNone {
+		if p.constValueCandidates == nil {
+			p.constValueCandidates = make(map[ast.Ref]js_ast.ConstValue)
+		}
+		p.constValueCandidates[ref] = constValue
+		return
+	}
+
+	// Objects are treated like enums if they can't be mutated. That is the case
+	// with "Object.freeze({ A: 0 })". TypeScript's "{ A: 0 } as const" is only a
+	// type annotation, so the linker also checks that nothing mutates the object.
+	object, ok := value.Data.(*js_ast.EObject)
+	isUnfrozen := ok && object.IsConstAsserted
+	if !isUnfrozen {
+		call, ok := value.Data.(*js_ast.ECall)
+		if !ok || len(call.Args) != 1 {
+			return
+		}
+		dot, ok := call.Target.Data.(*js_ast.EDot)
+		if !ok || dot.Name != "freeze" || dot.OptionalChain != js_ast.OptionalChainNone {
+			return
+		}
+		if id, ok := dot.Target.Data.(*js_ast.EIdentifier); !ok || p.symbols[id.Ref.InnerIndex].Kind != ast.SymbolUnbound ||
+			p.symbols[id.Ref.InnerIndex].OriginalName != "Object" {
+			return
+		}
+		if object, ok = call.Args[0].Data.(*js_ast.EObject); !ok {
+			return
+		}
+	}
+
+	var values map[string]js_ast.TSEnumValue
+	for _, property := range object.Properties {
+		if property.Kind != js_ast.PropertyNormal || property.Flags.Has(js_ast.PropertyIsComputed) || property.Flags.Has(js_ast.PropertyIsMethod) {
+			return
+		}
+		key, ok := property.Key.Data.(*js_ast.EString)
+		if !ok {
+			return
+		}
+		name := helpers.UTF16ToString(key.Value)
+		if name == "__proto__" {
+			continue
+		}
+
+		// Only numbers and strings are inlined, just like for enums. Other
+		// properties can still be accessed through the object at run-time.
+		var value js_ast.TSEnumValue
+		switch v := property.ValueOrNil.Data.(type) {
+		case *js_ast.ENumber:
+			value.Number = v.Value
+		case *js_ast.EString:
+			value.String = v.Value
+		default:
+			delete(values, name)
+			continue
+		}
+		if values == nil {
+			values = make(map[string]js_ast.TSEnumValue)
+		}
+		values[name] = value
+	}
+
+	if values != nil {
+		if p.constObjectCandidates == nil {
+			p.constObjectCandidates = make(map[ast.Ref]map[string]js_ast.TSEnumValue)
+		}
+		p.constObjectCandidates[ref] = values
+		if isUnfrozen {
+			if p.unfrozenConstObjects == nil {
+				p.unfrozenConstObjects = make(map[ast.Ref]bool)
+			}
+			p.unfrozenConstObjects[ref] = true
+		}
+	}
+}
+
+// Objects are only collapsible if all of their properties are known and they
+// have no side effects other than evaluating the property values in order
+func (p *parser) recordCollapsibleObject(ref ast.Ref, value js_ast.Expr) {
+	if object, ok := value.Data.(*js_ast.EObject); ok {
+		if leaves, ok := p.appendCollapsibleLeaves(nil, object, ""); ok {
+			if p.collapsibleObjects == nil {
+				p.collapsibleObjects = make(map[ast.Ref][]js_ast.CollapsibleLeaf)
+			}
+			p.collapsibleObjects[ref] = leaves
+		}
+	}
+}
+
+func (p *parser) appendCollapsibleLeaves(leaves []js_ast.CollapsibleLeaf, object *js_ast.EObject, prefix string) ([]js_ast.CollapsibleLeaf, bool) {
+	if len(object.Properties) == 0 {
+		return leaves, false
+	}
+	seen := make(map[string]bool)
+	for _, property := range object.Properties {
+		if property.Kind != js_ast.PropertyNormal || property.Flags.Has(js_ast.PropertyIsComputed) ||
+			property.Flags.Has(js_ast.PropertyIsMethod) || property.ValueOrNil.Data == nil {
+			return leaves, false
+		}
+		key, ok := property.Key.Data.(*js_ast.EString)
+		if !ok {
+			return leaves, false
+		}
+		name := helpers.UTF16ToString(key.Value)
+		if name == "__proto__" || !js_ast.IsIdentifier(name) || seen[name] {
+			return leaves, false
+		}
+		seen[name] = true
+		path := prefix + name
+
+		// Nested object literals are collapsed too if possible
+		if nested, ok := property.ValueOrNil.Data.(*js_ast.EObject); ok {
+			if nestedLeaves, ok := p.appendCollapsibleLeaves(leaves, nested, path+"."); ok {
+				leaves = nestedLeaves
+				continue
+			}
+		}
+
+		// Arrow functions don't have their own "this" value
+		_, isArrow := property.ValueOrNil.Data.(*js_ast.EArrow)
+		nameIsInferred := isArrow
+		switch v := property.ValueOrNil.Data.(type) {
+		case *js_ast.EFunction:
+			nameIsInferred = v.Fn.Name == nil
+		case *js_ast.EClass:
+			nameIsInferred = v.Class.Name == nil
+		}
+
+		// Don't change function names if they are supposed to be kept
+		if nameIsInferred && p.options.keepNames {
+			return leaves, false
+		}
+
+		leaves = append(leaves, js_ast.CollapsibleLeaf{
+			Path:                   path,
+			CanBeCalledWithoutThis: isArrow,
+			CanBeRemovedIfUnused:   js_ast.ExprCanBeRemovedIfUnused(property.ValueOrNil, p.isUnbound),
+			NameIsInferred:         nameIsInferred,
+		})
+	}
+	return leaves, true
+}
+
+// Escaping symbols are used to inline "as const" objects and to collapse the
+// properties of objects, both of which only happen when bundling and minifying
+func (p *parser) shouldTrackEscapingSymbols() bool {
+	return p.options.mode == config.ModeBundle && p.options.minifySyntax
+}
+
+func (p *parser) markEscapingExpr(expr js_ast.Expr) {
+	switch e := expr.Data.(type) {
+	case *js_ast.EIdentifier:
+		p.markEscapingSymbol(e.Ref)
+	case *js_ast.EImportIdentifier:
+		p.markEscapingSymbol(e.Ref)
+	}
+}
+
+// Imports and top-level constants may refer to a collapsible object
+func (p *parser) isPropertyChainRoot(ref ast.Ref) bool {
+	kind := p.symbols[ref.InnerIndex].Kind
+	return kind == ast.SymbolImport || kind == ast.SymbolConst
+}
+
+func (p *parser) markEscapingSymbol(ref ast.Ref) {
+	if p.isPropertyChainRoot(ref) {
+		if p.escapingSymbols == nil {
+			p.escapingSymbols = make(map[ast.Ref]bool)
+		}
+		p.escapingSymbols[ref] = true
+	}
+}
+
+func (p *parser) recordPropertyChain(e *js_ast.EDot, targetChain *propertyChain, isMutated bool, isCalled bool) *propertyChain {
+	var ref ast.Ref
+	var path string
+
+	switch target := e.Target.Data.(type) {
+	case *js_ast.EIdentifier:
+		ref = target.Ref
+
+	case *js_ast.EImportIdentifier:
+		ref = target.Ref
+
+	case *js_ast.EDot:
+		// Stop the chain at an optional chain
+		if targetChain == nil || targetChain.dot != target || e.OptionalChain != js_ast.OptionalChainNone {
+			return nil
+		}
+
+		// Only record the longest chain
+		ref = targetChain.ref
+		path = targetChain.path + "."
+		uses := p.propertyChainUses[ref]
+		if use := uses[targetChain.path]; use.CountEstimate > 1 {
+			use.CountEstimate--
+			uses[targetChain.path] = use
+		} else {
+			delete(uses, targetChain.path)
+		}
+
+	default:
+		return nil
+	}
+
+	if !p.isPropertyChainRoot(ref) {
+		return nil
+	}
+	if isMutated || e.OptionalChain != js_ast.OptionalChainNone {
+		p.markEscapingSymbol(ref)
+		return nil
+	}
+
+	path += e.Name
+	if p.propertyChainUses == nil {
+		p.propertyChainUses = make(map[ast.Ref]map[string]js_ast.PropertyChainUse)
+	}
+	uses := p.propertyChainUses[ref]
+	if uses == nil {
+		uses = make(map[string]js_ast.PropertyChainUse)
+		p.propertyChainUses[ref] = uses
+	}
+	use := uses[path]
+	use.CountEstimate++
+	if isCalled {
+		use.IsCalled = true
+	}
+	uses[path] = use
+	return &propertyChain{dot: e, ref: ref, path: path}
+}
+
 type relocateVarsMode uint8
 
 const (
@@ -11180,6 +12035,10 @@ type visitClassResult struct {
 	innerClassNameRef ast.Ref
 	superCtorRef      ast.Ref
 
+	// This is only valid for derived classes that are lowered to constructor
+	// functions. It holds the object returned by the base class constructor.
+	derivedClassThisRef ast.Ref
+
 	// If true, the class was determined to be safe to remove if the class is
 	// never used (i.e. the class definition is side-effect free). This is
 	// determined after visiting but before lowering since lowering may generate
@@ -11276,6 +12135,16 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class, defaul
 	oldSuperCtorRef := p.superCtorRef
 	p.superCtorRef = result.superCtorRef
 
+	// Derived classes that are lowered to constructor functions replace "this"
+	// in the constructor with the object returned by the base class constructor
+	result.derivedClassThisRef = ast.InvalidRef
+	lowerClassSyntax := p.options.unsupportedJSFeatures.Has(compat.Class)
+	if lowerClassSyntax && class.ExtendsOrNil.Data != nil {
+		result.derivedClassThisRef = p.newSymbol(ast.SymbolOther, "_this")
+		p.currentScope.Generated = append(p.currentScope.Generated, result.derivedClassThisRef)
+		p.recordDeclaredSymbol(result.derivedClassThisRef)
+	}
+
 	// Insert an immutable inner name that spans the whole class to match
 	// JavaScript's semantics specifically the "CreateImmutableBinding" here:
 	// https://262.ecma-international.org/6.0/#sec-runtime-semantics-classdefinitionevaluation
@@ -11305,6 +12174,7 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class, defaul
 	// A scope is needed for private identifiers
 	p.pushScopeForVisitPass(js_ast.ScopeClassBody, class.BodyLoc)
 	result.bodyScope = p.currentScope
+	classTSPrivateProps := p.tsPrivatePropsByClass[class.BodyLoc]
 
 	for i := range class.Properties {
 		property := &class.Properties[i]
@@ -11319,6 +12189,7 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class, defaul
 				isNewTargetAllowed:     true,
 				isInStaticClassContext: true,
 				innerClassNameRef:      &result.innerClassNameRef,
+				classTSPrivateProps:    classTSPrivateProps,
 			}
 
 			if classLoweringInfo.lowerAllStaticFields {
@@ -11352,6 +12223,8 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class, defaul
 				p.symbols[result.innerClassNameRef.InnerIndex].Kind = ast.SymbolClassInComputedPropertyKey
 			}
 
+			property.Key = p.maybeMangleTSPrivateKey(property.Key,
+				property.Flags.Has(js_ast.PropertyIsComputed) || property.Flags.Has(js_ast.PropertyPreferQuotedKey), classTSPrivateProps)
 			key, _ := p.visitExprInOut(property.Key, exprIn{
 				shouldMangleStringsAsProps: true,
 			})
@@ -11415,6 +12288,25 @@ func (p *parser) visitClass(nameScopeLoc logger.Loc, class *js_ast.Class, defaul
 		p.fnOnlyDataVisit.isNewTargetAllowed = true
 		p.fnOnlyDataVisit.isInStaticClassContext = property.Flags.Has(js_ast.PropertyIsStatic)
 		p.fnOnlyDataVisit.innerClassNameRef = &result.innerClassNameRef
+		p.fnOnlyDataVisit.classTSPrivateProps = classTSPrivateProps
+		p.fnOnlyDataVisit.derivedClassThisRef = nil
+
+		// Methods are moved outside of the class body when lowering class syntax,
+		// so "super" must be lowered everywhere. Instance field initializers are
+		// moved into the constructor, so they use the same "this" as it does.
+		if lowerClassSyntax {
+			p.fnOrArrowDataVisit.shouldLowerSuperPropertyAccess = true
+			if result.derivedClassThisRef != ast.InvalidRef && !property.Flags.Has(js_ast.PropertyIsStatic) {
+				if property.Flags.Has(js_ast.PropertyIsMethod) {
+					if str, ok := property.Key.Data.(*js_ast.EString); ok && helpers.UTF16EqualsString(str.Value, "constructor") {
+						p.propDerivedCtorValue = property.ValueOrNil.Data
+						p.propDerivedCtorThisRef = result.derivedClassThisRef
+					}
+				} else {
+					p.fnOnlyDataVisit.derivedClassThisRef = &result.derivedClassThisRef
+				}
+			}
+		}
 
 		// We need to explicitly assign the name to the property initializer if it
 		// will be transformed such that it is no longer an inline initializer.
@@ -11767,6 +12659,9 @@ func (p *parser) instantiateDefineExpr(loc logger.Loc, expr config.DefineExpr, o
 	// Build up a chain of property access expressions for subsequent parts
 	for _, part := range parts {
 		if expr, ok := p.maybeRewritePropertyAccess(loc, js_ast.AssignTargetNone, false, value, part, loc, false, false, false); ok {
+			if p.shouldTrackEscapingSymbols() {
+				p.markEscapingExpr(expr)
+			}
 			value = expr
 		} else if p.isMangledProp(part) {
 			value = js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{
@@ -12129,6 +13024,12 @@ func (p *parser) maybeRewritePropertyAccess(
 
 	// Symbol uses due to a property access off of an imported symbol are tracked
 	// specially. This lets us do tree shaking for cross-file TypeScript enums.
+	if p.options.mode == config.ModeBundle {
+		// Property accesses that are written to must not be inlined
+		if id, ok := target.Data.(*js_ast.EImportIdentifier); ok && (assignTarget != js_ast.AssignTargetNone || isDeleteTarget) {
+			p.symbols[id.Ref.InnerIndex].Flags |= ast.PropertiesCouldPotentiallyBeMutated
+		}
+	}
 	if p.options.mode == config.ModeBundle && !p.isControlFlowDead {
 		if id, ok := target.Data.(*js_ast.EImportIdentifier); ok {
 			// Remove the normal symbol use
@@ -12268,6 +13169,16 @@ type exprOut struct {
 	// If true and this is used as a call target, the whole call expression
 	// must be replaced with undefined.
 	methodCallMustBeReplacedWithUndefined bool
+
+	// If this is a property access chain off of an import or a top-level
+	// constant, this is passed to the parent so it can extend the chain
+	propertyChain *propertyChain
+}
+
+type propertyChain struct {
+	dot  *js_ast.EDot
+	ref  ast.Ref
+	path string
 }
 
 func (p *parser) visitExpr(expr js_ast.Expr) js_ast.Expr {
@@ -12282,6 +13193,13 @@ func (p *parser) valueForThis(
 	isCallTarget bool,
 	isDeleteTarget bool,
 ) (js_ast.Expr, bool) {
+	// Substitute "this" if we're inside a derived class constructor that's being
+	// lowered to a constructor function
+	if ref := p.fnOnlyDataVisit.derivedClassThisRef; ref != nil {
+		p.recordUsage(*ref)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: *ref}}, true
+	}
+
 	// Substitute "this" if we're inside a static class context
 	if p.fnOnlyDataVisit.shouldReplaceThisWithInnerClassNameRef {
 		p.recordUsage(*p.fnOnlyDataVisit.innerClassNameRef)
@@ -12347,6 +13265,22 @@ func (p *parser) valueForImportMeta(loc logger.Loc) (js_ast.Expr, bool) {
 	return js_ast.Expr{}, false
 }
 
+// If "import.meta" is converted to a variable, that variable is normally an
+// empty object. But some combinations of platform and output format have an
+// obvious equivalent for each property, in which case this returns the name
+// of the runtime helper that generates the object.
+func (p *parser) importMetaPolyfill() string {
+	if p.options.mode != config.ModePassThrough {
+		if p.options.platform == config.PlatformNode && p.options.outputFormat == config.FormatCommonJS {
+			return "__importMetaNode"
+		}
+		if p.options.platform == config.PlatformBrowser && p.options.outputFormat == config.FormatIIFE {
+			return "__importMetaBrowser"
+		}
+	}
+	return ""
+}
+
 func locAfterOp(e *js_ast.EBinary) logger.Loc {
 	if e.Left.Loc.Start < e.Right.Loc.Start {
 		return e.Right.Loc
@@ -12542,6 +13476,235 @@ pattern:
 	return
 }
 
+func (p *parser) visitJSXProperties(properties []js_ast.Property) (hasSpread bool) {
+	for i, property := range properties {
+		if property.Kind == js_ast.PropertySpread {
+			hasSpread = true
+		} else {
+			property.Key = p.maybeMangleTSPrivateKey(property.Key,
+				property.Flags.Has(js_ast.PropertyIsComputed) || property.Flags.Has(js_ast.PropertyPreferQuotedKey), nil)
+			if mangled, ok := property.Key.Data.(*js_ast.ENameOfSymbol); ok {
+				mangled.Ref = p.symbolForMangledProp(p.loadNameFromRef(mangled.Ref))
+			} else {
+				property.Key = p.visitExpr(property.Key)
+			}
+		}
+		if property.ValueOrNil.Data != nil {
+			property.ValueOrNil = p.visitExpr(property.ValueOrNil)
+		}
+		if property.InitializerOrNil.Data != nil {
+			property.InitializerOrNil = p.visitExpr(property.InitializerOrNil)
+		}
+		properties[i] = property
+	}
+	return
+}
+
+func (p *parser) isInsideFunctionBody() bool {
+	for scope := p.currentScope; scope != nil; scope = scope.Parent {
+		if scope.Kind == js_ast.ScopeFunctionBody {
+			return true
+		}
+	}
+	return false
+}
+
+// A JSX element is constant if creating it again would always produce an
+// equivalent element. That means its tag must be a string or a top-level
+// binding that can't change, and all of its props and children must be
+// primitive values or other constant JSX elements.
+func (p *parser) isConstantJSXElement(e *js_ast.EJSXElement, children []js_ast.Expr) bool {
+	if !p.isConstantJSXTag(e.TagOrNil) {
+		return false
+	}
+	for _, property := range e.Properties {
+		if property.Kind == js_ast.PropertySpread || isJSXRefProperty(property) {
+			return false
+		}
+		if !p.isConstantJSXValue(property.ValueOrNil) {
+			return false
+		}
+	}
+	for _, child := range children {
+		if !p.isConstantJSXValue(child) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) isConstantJSXTag(tag js_ast.Expr) bool {
+	switch e := tag.Data.(type) {
+	case nil, *js_ast.EString, *js_ast.EImportIdentifier:
+		return true
+
+	case *js_ast.EIdentifier:
+		symbol := &p.symbols[e.Ref.InnerIndex]
+		if member, ok := p.moduleScope.Members[symbol.OriginalName]; ok && member.Ref == e.Ref {
+			switch symbol.Kind {
+			case ast.SymbolConst, ast.SymbolClass, ast.SymbolHoistedFunction, ast.SymbolImport:
+				return true
+			}
+		}
+
+	case *js_ast.EDot:
+		return p.isConstantJSXTag(e.Target)
+	}
+	return false
+}
+
+func (p *parser) isConstantJSXValue(value js_ast.Expr) bool {
+	switch e := value.Data.(type) {
+	case *js_ast.EString, *js_ast.ENumber, *js_ast.EBigInt, *js_ast.EBoolean, *js_ast.ENull, *js_ast.EUndefined:
+		return true
+
+	case *js_ast.EInlinedEnum:
+		return p.isConstantJSXValue(e.Value)
+
+	case *js_ast.EBinary:
+		_, ok := p.jsxHoistedElements[e]
+		return ok
+	}
+	return false
+}
+
+// React attaches an element with a "ref" to the component that created it
+func isJSXRefProperty(property js_ast.Property) bool {
+	str, ok := property.Key.Data.(*js_ast.EString)
+	return ok && helpers.UTF16EqualsString(str.Value, "ref")
+}
+
+// A constant element caches itself instead of caching each of its children
+func (p *parser) unhoistJSXChildren(e *js_ast.EJSXElement, children []js_ast.Expr, tempRefStart int) {
+	unhoist := func(value js_ast.Expr) js_ast.Expr {
+		if binary, ok := value.Data.(*js_ast.EBinary); ok {
+			if element, ok := p.jsxHoistedElements[binary]; ok {
+				ref := binary.Left.Data.(*js_ast.EIdentifier).Ref
+				p.ignoreUsage(ref)
+				p.ignoreUsage(ref)
+				delete(p.jsxHoistedElements, binary)
+				return element
+			}
+		}
+		return value
+	}
+	for i, property := range e.Properties {
+		e.Properties[i].ValueOrNil = unhoist(property.ValueOrNil)
+	}
+	for i, child := range children {
+		children[i] = unhoist(child)
+	}
+
+	// All top-level temporary variables generated inside a constant element
+	// were for its children, so give them back
+	removed := p.topLevelTempRefsToDeclare[tempRefStart:]
+	if len(removed) == 0 {
+		return
+	}
+	isRemoved := make(map[ast.Ref]bool, len(removed))
+	for _, temp := range removed {
+		isRemoved[temp.ref] = true
+	}
+	generated := p.moduleScope.Generated[:0]
+	for _, ref := range p.moduleScope.Generated {
+		if !isRemoved[ref] {
+			generated = append(generated, ref)
+		}
+	}
+	p.moduleScope.Generated = generated
+	p.topLevelTempRefsToDeclare = p.topLevelTempRefsToDeclare[:tempRefStart]
+	p.topLevelTempRefCount -= len(removed)
+}
+
+// "<div />" => "_a || (_a = jsx("div", {}))"
+func (p *parser) hoistConstantJSXElement(loc logger.Loc, value js_ast.Expr) js_ast.Expr {
+	ref := p.generateTopLevelTempRef(js_ast.Expr{})
+	p.recordUsage(ref)
+	p.recordUsage(ref)
+	binary := &js_ast.EBinary{
+		Op:   js_ast.BinOpLogicalOr,
+		Left: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+		Right: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+			Op:    js_ast.BinOpAssign,
+			Left:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+			Right: value,
+		}},
+	}
+	if p.jsxHoistedElements == nil {
+		p.jsxHoistedElements = make(map[*js_ast.EBinary]js_ast.Expr)
+	}
+	p.jsxHoistedElements[binary] = value
+	return js_ast.Expr{Loc: loc, Data: binary}
+}
+
+func canInlineJSXElement(e *js_ast.EJSXElement) bool {
+	switch e.TagOrNil.Data.(type) {
+	case nil, *js_ast.EString:
+	default:
+		return false
+	}
+	for _, property := range e.Properties {
+		if property.Kind == js_ast.PropertySpread || isJSXRefProperty(property) {
+			return false
+		}
+	}
+	return true
+}
+
+// "<div key={k} />" => "{ $$typeof: __reactElement, type: "div", key: "" + k, ref: null, props: {}, _owner: null }"
+func (p *parser) inlineJSXElement(loc logger.Loc, tag js_ast.Expr, props js_ast.Expr, key js_ast.Expr, hasKey bool, isMultiLine bool) js_ast.Expr {
+	keyValue := js_ast.Expr{Loc: loc, Data: js_ast.ENullShared}
+	var tempRef ast.Ref
+	hasTempRef := false
+	if hasKey {
+		keyValue = key
+		if _, ok := key.Data.(*js_ast.EString); !ok {
+			// React always converts keys to strings
+			keyValue = js_ast.Expr{Loc: key.Loc, Data: &js_ast.EBinary{
+				Op:    js_ast.BinOpAdd,
+				Left:  js_ast.Expr{Loc: key.Loc, Data: &js_ast.EString{}},
+				Right: key,
+			}}
+		}
+
+		// The key comes before the props in the element object, but "jsx()"
+		// evaluates the props first. Store the props in a temporary variable
+		// if both of them may have side effects.
+		if !js_ast.ExprCanBeRemovedIfUnused(key, p.isUnbound) && !js_ast.ExprCanBeRemovedIfUnused(props, p.isUnbound) {
+			if p.currentScope.Kind == js_ast.ScopeFunctionArgs {
+				return js_ast.Expr{}
+			}
+			tempRef = p.generateTempRef(tempRefNeedsDeclare, "")
+			hasTempRef = true
+			p.recordUsage(tempRef)
+		}
+	}
+
+	propsValue := props
+	if hasTempRef {
+		p.recordUsage(tempRef)
+		propsValue = js_ast.Expr{Loc: props.Loc, Data: &js_ast.EIdentifier{Ref: tempRef}}
+	}
+
+	typeOf := p.importFromRuntime(loc, "__reactElement")
+	element := js_ast.Expr{Loc: loc, Data: &js_ast.EObject{
+		Properties: []js_ast.Property{
+			{Kind: js_ast.PropertyNormal, Key: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16("$$typeof")}}, ValueOrNil: typeOf},
+			{Kind: js_ast.PropertyNormal, Key: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16("type")}}, ValueOrNil: tag},
+			{Kind: js_ast.PropertyNormal, Key: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16("key")}}, ValueOrNil: keyValue},
+			{Kind: js_ast.PropertyNormal, Key: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16("ref")}}, ValueOrNil: js_ast.Expr{Loc: loc, Data: js_ast.ENullShared}},
+			{Kind: js_ast.PropertyNormal, Key: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16("props")}}, ValueOrNil: propsValue},
+			{Kind: js_ast.PropertyNormal, Key: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16("_owner")}}, ValueOrNil: js_ast.Expr{Loc: loc, Data: js_ast.ENullShared}},
+		},
+		IsSingleLine: !isMultiLine,
+	}}
+
+	if hasTempRef {
+		return js_ast.JoinWithComma(js_ast.Assign(js_ast.Expr{Loc: props.Loc, Data: &js_ast.EIdentifier{Ref: tempRef}}, props), element)
+	}
+	return element
+}
+
 // This function takes "exprIn" as input from the caller and produces "exprOut"
 // for the caller to pass along extra data. This is mostly for optional chaining.
 func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprOut) {
@@ -12555,15 +13718,40 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 	// it doesn't affect these mitigations by ensuring that the mitigations are not
 	// applied in those cases (e.g. by adding an additional conditional check).
 	switch e := expr.Data.(type) {
-	case *js_ast.ENull, *js_ast.ESuper, *js_ast.EBoolean, *js_ast.EBigInt, *js_ast.EUndefined:
+	case *js_ast.ENull, *js_ast.EBoolean, *js_ast.EBigInt, *js_ast.EUndefined:
 
 	case *js_ast.ENameOfSymbol:
 		e.Ref = p.symbolForMangledProp(p.loadNameFromRef(e.Ref))
 
 	case *js_ast.ERegExp:
-		// "/pattern/flags" => "new RegExp('pattern', 'flags')"
-		if pattern, flags, ok := p.isUnsupportedRegularExpression(expr.Loc, e.Value); ok {
-			args := []js_ast.Expr{{
+		// Try to compile unsupported syntax into equivalent supported syntax first
+		if value, groupNames, ok := p.lowerRegExp(e.Value); ok {
+			e.Value = value
+
+			// "/(?<a>b)/" => "__wrapRegExp(/(b)/, { a: 1 })"
+			if groupNames != nil {
+				properties := make([]js_ast.Property, 0, len(groupNames))
+				for _, group := range groupNames {
+					properties = append(properties, js_ast.Property{
+						Key:        js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(group.name)}},
+						ValueOrNil: js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ENumber{Value: float64(group.index)}},
+					})
+				}
+				return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{
+					Target: p.importFromRuntime(expr.Loc, "__wrapRegExp"),
+					Args: []js_ast.Expr{
+						expr,
+						{Loc: expr.Loc, Data: &js_ast.EObject{Properties: properties}},
+					},
+					CanBeUnwrappedIfUnused: true,
+				}}, exprOut{}
+			}
+			return expr, exprOut{}
+		}
+
+		// "/pattern/flags" => "new RegExp('pattern', 'flags')"
+		if pattern, flags, ok := p.isUnsupportedRegularExpression(expr.Loc, e.Value); ok {
+			args := []js_ast.Expr{{
 				Loc:  logger.Loc{Start: expr.Loc.Start + 1},
 				Data: &js_ast.EString{Value: helpers.StringToUTF16(pattern)},
 			}}
@@ -12586,6 +13774,19 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		if !p.fnOnlyDataVisit.isNewTargetAllowed {
 			p.log.AddError(&p.tracker, e.Range, "Cannot use \"new.target\" here:")
 		}
+		if loop := p.fnOnlyDataVisit.letOrConstLoop; loop != nil {
+			loop.superOrNewTargetKeyword = "new.target"
+		}
+
+	case *js_ast.ESuper:
+		// Lowered "super" expressions reference "this" instead
+		if loop := p.fnOnlyDataVisit.letOrConstLoop; loop != nil {
+			if p.fnOrArrowDataVisit.shouldLowerSuperPropertyAccess {
+				loop.usesThis = true
+			} else {
+				loop.superOrNewTargetKeyword = "super"
+			}
+		}
 
 	case *js_ast.EString:
 		if e.LegacyOctalLoc.Start > 0 {
@@ -12626,6 +13827,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EIdentifier{Ref: p.captureThis()}}, exprOut{}
 		}
 
+		// Pass "this" along if this loop body is moved into a function
+		if loop := p.fnOnlyDataVisit.letOrConstLoop; loop != nil {
+			loop.usesThis = true
+		}
+
 	case *js_ast.EImportMeta:
 		isDeleteTarget := e == p.deleteTarget
 		isCallTarget := e == p.callTarget
@@ -12658,7 +13864,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		}
 
 		// Warn about "import.meta" if it's not replaced by a define
-		if p.options.unsupportedJSFeatures.Has(compat.ImportMeta) {
+		if p.importMetaPolyfill() != "" {
+			// There's no need to warn since the object won't be empty
+		} else if p.options.unsupportedJSFeatures.Has(compat.ImportMeta) {
 			r := logger.Range{Loc: expr.Loc, Len: e.RangeLen}
 			p.markSyntaxFeature(compat.ImportMeta, r)
 		} else if p.options.mode != config.ModePassThrough && !p.options.outputFormat.KeepESMImportExportSyntax() {
@@ -12690,6 +13898,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		result := p.findSymbol(expr.Loc, name)
 		e.MustKeepDueToWithStmt = result.isInsideWithScope
 		e.Ref = result.ref
+		if p.symbols[result.ref.InnerIndex].Kind == ast.SymbolUnbound && !result.isInsideWithScope {
+			p.markBuiltInUse(compat.BuiltInsForGlobal(name))
+		}
 
 		// Handle referencing a class name within that class's computed property
 		// key. This is not allowed, and must fail at run-time:
@@ -12705,6 +13916,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			return p.callRuntime(expr.Loc, "__earlyAccess", []js_ast.Expr{{Loc: expr.Loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(name)}}}), exprOut{}
 		}
 
+		// Handle referencing a "let" or "const" binding that will become "var"
+		if value, ok := p.visitLetOrConstReference(expr.Loc, result.ref, in.assignTarget); ok {
+			return value, exprOut{}
+		}
+
 		// Handle assigning to a constant
 		if in.assignTarget != js_ast.AssignTargetNone {
 			switch p.symbols[result.ref.InnerIndex].Kind {
@@ -12779,6 +13995,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			}
 
 	case *js_ast.EJSXElement:
+		// Other frameworks transform a whole tree of JSX elements at once
+		if !p.options.jsx.Preserve && p.options.jsx.Target != config.JSXTargetReact {
+			return p.visitJSXElementForTarget(expr.Loc, e), exprOut{}
+		}
+
 		propsLoc := expr.Loc
 
 		// Resolving the location index to a specific line and column in
@@ -12812,6 +14033,10 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			jsxSourceColumn = p.jsxSourceColumn
 		}
 
+		// Any top-level temporary variables generated after this point belong to
+		// this element, which matters if the element is cached in one itself
+		tempRefStart := len(p.topLevelTempRefsToDeclare)
+
 		if e.TagOrNil.Data != nil {
 			propsLoc = e.TagOrNil.Loc
 			e.TagOrNil = p.visitExpr(e.TagOrNil)
@@ -12819,25 +14044,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		}
 
 		// Visit properties
-		hasSpread := false
-		for i, property := range e.Properties {
-			if property.Kind == js_ast.PropertySpread {
-				hasSpread = true
-			} else {
-				if mangled, ok := property.Key.Data.(*js_ast.ENameOfSymbol); ok {
-					mangled.Ref = p.symbolForMangledProp(p.loadNameFromRef(mangled.Ref))
-				} else {
-					property.Key = p.visitExpr(property.Key)
-				}
-			}
-			if property.ValueOrNil.Data != nil {
-				property.ValueOrNil = p.visitExpr(property.ValueOrNil)
-			}
-			if property.InitializerOrNil.Data != nil {
-				property.InitializerOrNil = p.visitExpr(property.InitializerOrNil)
-			}
-			e.Properties[i] = property
-		}
+		hasSpread := p.visitJSXProperties(e.Properties)
 
 		// "{a, ...{b, c}, d}" => "{a, b, c, d}"
 		if p.options.minifySyntax && hasSpread {
@@ -12876,6 +14083,19 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 				children = children[:end]
 			}
 
+			// Elements that never change can be created once and then reused
+			shouldHoist := p.options.jsx.ConstantElements && !p.options.jsx.Development && !p.options.jsx.SideEffects &&
+				p.isInsideFunctionBody() && p.isConstantJSXElement(e, children)
+			if shouldHoist {
+				p.unhoistJSXChildren(e, children, tempRefStart)
+			}
+
+			// React elements for intrinsic tags and fragments don't depend on the
+			// component type (e.g. "defaultProps"), so they can be created inline
+			shouldInline := p.options.jsx.InlineElements && p.options.jsx.AutomaticRuntime &&
+				!p.options.jsx.Development && !p.options.jsx.SideEffects &&
+				p.options.jsx.ImportSource == defaultJSXImportSource && canInlineJSXElement(e)
+
 			// A missing tag is a fragment
 			if e.TagOrNil.Data == nil {
 				if p.options.jsx.AutomaticRuntime {
@@ -12935,7 +14155,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					}
 					p.warnAboutImportNamespaceCall(target, exprKindCall)
 				}
-				return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{
+				result := p.lowerSpreadInCall(expr.Loc, &js_ast.ECall{
 					Target:        target,
 					Args:          args,
 					CloseParenLoc: e.CloseLoc,
@@ -12944,7 +14164,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 
 					// Enable tree shaking
 					CanBeUnwrappedIfUnused: !p.options.ignoreDCEAnnotations && !p.options.jsx.SideEffects,
-				}}, exprOut{}
+				}, false)
+				if shouldHoist {
+					result = p.hoistConstantJSXElement(expr.Loc, result)
+				}
+				return result, exprOut{}
 			} else {
 				// Arguments to jsx()
 				args := []js_ast.Expr{e.TagOrNil}
@@ -12997,13 +14221,13 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					childrenValue := children[0]
 
 					if len(children) > 1 {
-						childrenValue.Data = &js_ast.EArray{Items: children}
+						childrenValue.Data = p.lowerArraySpread(childrenValue.Loc, children, false).Data
 					} else if _, ok := childrenValue.Data.(*js_ast.ESpread); ok {
 						// TypeScript considers spread children to be static, but Babel considers
 						// it to be an error ("Spread children are not supported in React.").
 						// We'll follow TypeScript's behavior here because spread children may be
 						// valid with non-React source runtimes.
-						childrenValue.Data = &js_ast.EArray{Items: []js_ast.Expr{childrenValue}}
+						childrenValue.Data = p.lowerArraySpread(childrenValue.Loc, []js_ast.Expr{childrenValue}, false).Data
 						isStaticChildren = true
 					}
 
@@ -13061,20 +14285,32 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					}
 				}
 
-				jsx := JSXImportJSX
-				if isStaticChildren {
-					jsx = JSXImportJSXS
+				var result js_ast.Expr
+				if shouldInline {
+					result = p.inlineJSXElement(expr.Loc, args[0], args[1], keyProperty, hasKey, !e.IsTagSingleLine)
 				}
 
-				return js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{
-					Target:        p.importJSXSymbol(expr.Loc, jsx),
-					Args:          args,
-					CloseParenLoc: e.CloseLoc,
-					IsMultiLine:   !e.IsTagSingleLine,
+				if result.Data == nil {
+					jsx := JSXImportJSX
+					if isStaticChildren {
+						jsx = JSXImportJSXS
+					}
 
-					// Enable tree shaking
-					CanBeUnwrappedIfUnused: !p.options.ignoreDCEAnnotations && !p.options.jsx.SideEffects,
-				}}, exprOut{}
+					result = js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ECall{
+						Target:        p.importJSXSymbol(expr.Loc, jsx),
+						Args:          args,
+						CloseParenLoc: e.CloseLoc,
+						IsMultiLine:   !e.IsTagSingleLine,
+
+						// Enable tree shaking
+						CanBeUnwrappedIfUnused: !p.options.ignoreDCEAnnotations && !p.options.jsx.SideEffects,
+					}}
+				}
+
+				if shouldHoist {
+					result = p.hoistConstantJSXElement(expr.Loc, result)
+				}
+				return result, exprOut{}
 			}
 		}
 
@@ -13242,6 +14478,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		isDeleteTarget := e == p.deleteTarget
 		isCallTarget := e == p.callTarget
 		isTemplateTag := e == p.templateTag
+		isDotOrIndexTarget := e == p.dotOrIndexTarget
 
 		// Check both user-specified defines and known globals
 		if defines, ok := p.options.defines.DotDefines[e.Name]; ok {
@@ -13304,17 +14541,30 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			}
 		}
 
+		isTSPrivatePropAccess := p.isTSPrivatePropAccess(e.Target, e.Name)
 		p.dotOrIndexTarget = e.Target.Data
 		target, out := p.visitExprInOut(e.Target, exprIn{
 			hasChainParent: e.OptionalChain == js_ast.OptionalChainContinue,
 		})
 		e.Target = target
+		p.markBuiltInPropertyUse(e.Target, e.Name)
+
+		// Track property access chains in case their properties are collapsed
+		var chain *propertyChain
+		if p.options.collapseProperties {
+			chain = p.recordPropertyChain(e, out.propertyChain, in.assignTarget != js_ast.AssignTargetNone || isDeleteTarget, isCallTarget || isTemplateTag)
+		} else if p.shouldTrackEscapingSymbols() && (in.assignTarget != js_ast.AssignTargetNone || isDeleteTarget) {
+			p.markEscapingExpr(e.Target)
+		}
 
 		// Lower "super.prop" if necessary
 		if e.OptionalChain == js_ast.OptionalChainNone && in.assignTarget == js_ast.AssignTargetNone &&
 			!isCallTarget && p.shouldLowerSuperPropertyAccess(e.Target) {
 			// "super.foo" => "__superGet('foo')"
 			key := js_ast.Expr{Loc: e.NameLoc, Data: &js_ast.EString{Value: helpers.StringToUTF16(e.Name)}}
+			if isTSPrivatePropAccess {
+				key.Data = &js_ast.ENameOfSymbol{Ref: p.symbolForMangledProp(e.Name)}
+			}
 			value := p.lowerSuperPropertyGet(expr.Loc, key)
 			if isTemplateTag {
 				value.Data = &js_ast.ECall{
@@ -13333,6 +14583,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		// Lower optional chaining if we're the top of the chain
 		containsOptionalChain := e.OptionalChain != js_ast.OptionalChainNone
 		if containsOptionalChain && !in.hasChainParent {
+			if isTSPrivatePropAccess {
+				expr.Data = p.mangleTSPrivateDot(e)
+			}
 			return p.lowerOptionalChain(expr, in, out)
 		}
 
@@ -13342,6 +14595,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			methodCallMustBeReplacedWithUndefined: out.methodCallMustBeReplacedWithUndefined,
 			thisArgFunc:                           out.thisArgFunc,
 			thisArgWrapFunc:                       out.thisArgWrapFunc,
+			propertyChain:                         chain,
 		}
 		if !in.hasChainParent {
 			out.thisArgFunc = nil
@@ -13350,15 +14604,22 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		if e.OptionalChain == js_ast.OptionalChainNone {
 			if value, ok := p.maybeRewritePropertyAccess(expr.Loc, in.assignTarget,
 				isDeleteTarget, e.Target, e.Name, e.NameLoc, isCallTarget, isTemplateTag, false); ok {
+				if p.shouldTrackEscapingSymbols() && !isDotOrIndexTarget {
+					p.markEscapingExpr(value)
+				}
 				return value, out
 			}
 		}
+		if isTSPrivatePropAccess {
+			return js_ast.Expr{Loc: expr.Loc, Data: p.mangleTSPrivateDot(e)}, out
+		}
 		return js_ast.Expr{Loc: expr.Loc, Data: e}, out
 
 	case *js_ast.EIndex:
 		isCallTarget := e == p.callTarget
 		isTemplateTag := e == p.templateTag
 		isDeleteTarget := e == p.deleteTarget
+		isDotOrIndexTarget := e == p.dotOrIndexTarget
 
 		// Check both user-specified defines and known globals
 		if str, ok := e.Index.Data.(*js_ast.EString); ok {
@@ -13416,12 +14677,26 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			}
 		}
 
+		// Quoted property accesses such as "this['x']" are never mangled
+		if str, ok := e.Index.Data.(*js_ast.EString); ok && p.tsPrivateProps != nil {
+			p.markTSPrivatePropUsedNormally(helpers.UTF16ToString(str.Value))
+		}
+
 		p.dotOrIndexTarget = e.Target.Data
 		target, out := p.visitExprInOut(e.Target, exprIn{
 			hasChainParent: e.OptionalChain == js_ast.OptionalChainContinue,
 		})
 		e.Target = target
 
+		// Computed property accesses can't be collapsed. Reading a string key is
+		// still fine for inlining though, since that doesn't mutate the object.
+		if p.shouldTrackEscapingSymbols() {
+			if _, ok := e.Index.Data.(*js_ast.EString); !ok || p.options.collapseProperties ||
+				in.assignTarget != js_ast.AssignTargetNone || isDeleteTarget {
+				p.markEscapingExpr(target)
+			}
+		}
+
 		// Special-case private identifiers
 		if private, ok := e.Index.Data.(*js_ast.EPrivateIdentifier); ok {
 			name := p.loadNameFromRef(private.Ref)
@@ -13509,6 +14784,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			preferQuotedKey := !p.options.minifySyntax
 			if value, ok := p.maybeRewritePropertyAccess(expr.Loc, in.assignTarget, isDeleteTarget,
 				e.Target, helpers.UTF16ToString(str.Value), e.Index.Loc, isCallTarget, isTemplateTag, preferQuotedKey); ok {
+				if p.shouldTrackEscapingSymbols() && !isDotOrIndexTarget {
+					p.markEscapingExpr(value)
+				}
 				return value, out
 			}
 		}
@@ -13638,6 +14916,9 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 				if property := p.extractSuperProperty(e.Value); property.Data != nil {
 					e.Value = p.callSuperPropertyWrapper(expr.Loc, property)
 				}
+				if result, ok := p.maybeLowerLetOrConstAssign(e.Op, e.Value, js_ast.Expr{}); ok {
+					return result, exprOut{}
+				}
 			}
 		}
 
@@ -13722,6 +15003,10 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			}
 		}
 
+		if loop := p.fnOrArrowDataVisit.letOrConstLoop; loop != nil {
+			loop.yieldOrAwaitKeyword = "await"
+		}
+
 		p.awaitTarget = e.Value.Data
 		e.Value = p.visitExpr(e.Value)
 
@@ -13729,6 +15014,10 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		return p.maybeLowerAwait(expr.Loc, e), exprOut{}
 
 	case *js_ast.EYield:
+		if loop := p.fnOrArrowDataVisit.letOrConstLoop; loop != nil {
+			loop.yieldOrAwaitKeyword = "yield"
+		}
+
 		if e.ValueOrNil.Data != nil {
 			e.ValueOrNil = p.visitExpr(e.ValueOrNil)
 		}
@@ -13743,7 +15032,6 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			if e.CommaAfterSpread.Start != 0 {
 				p.log.AddError(&p.tracker, logger.Range{Loc: e.CommaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
 			}
-			p.markSyntaxFeature(compat.Destructuring, logger.Range{Loc: expr.Loc, Len: 1})
 		}
 		hasSpread := false
 		for i, item := range e.Items {
@@ -13774,9 +15062,16 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			e.Items[i] = item
 		}
 
-		// "[1, ...[2, 3], 4]" => "[1, 2, 3, 4]"
-		if p.options.minifySyntax && hasSpread && in.assignTarget == js_ast.AssignTargetNone {
-			e.Items = js_ast.InlineSpreadsOfArrayLiterals(e.Items)
+		if hasSpread && in.assignTarget == js_ast.AssignTargetNone {
+			// "[1, ...[2, 3], 4]" => "[1, 2, 3, 4]"
+			if p.options.minifySyntax {
+				e.Items = js_ast.InlineSpreadsOfArrayLiterals(e.Items)
+			}
+
+			// "[1, ...a]" => "[1].concat(__toArray(a))"
+			if p.options.unsupportedJSFeatures.Has(compat.ArraySpread) {
+				return p.lowerArraySpread(expr.Loc, e.Items, e.IsSingleLine), exprOut{}
+			}
 		}
 
 	case *js_ast.EObject:
@@ -13784,7 +15079,6 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			if e.CommaAfterSpread.Start != 0 {
 				p.log.AddError(&p.tracker, logger.Range{Loc: e.CommaAfterSpread, Len: 1}, "Unexpected \",\" after rest pattern")
 			}
-			p.markSyntaxFeature(compat.Destructuring, logger.Range{Loc: expr.Loc, Len: 1})
 		}
 
 		hasSpread := false
@@ -13795,6 +15089,8 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			property := &e.Properties[i]
 
 			if property.Kind != js_ast.PropertySpread {
+				property.Key = p.maybeMangleTSPrivateKey(property.Key,
+					property.Flags.Has(js_ast.PropertyIsComputed) || property.Flags.Has(js_ast.PropertyPreferQuotedKey), nil)
 				key := property.Key
 				if mangled, ok := key.Data.(*js_ast.ENameOfSymbol); ok {
 					mangled.Ref = p.symbolForMangledProp(p.loadNameFromRef(mangled.Ref))
@@ -13853,26 +15149,38 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			if property.ValueOrNil.Data != nil {
 				oldIsInStaticClassContext := p.fnOnlyDataVisit.isInStaticClassContext
 				oldInnerClassNameRef := p.fnOnlyDataVisit.innerClassNameRef
+				oldClassTSPrivateProps := p.fnOnlyDataVisit.classTSPrivateProps
+				oldShouldLowerSuperPropertyAccess := p.fnOrArrowDataVisit.shouldLowerSuperPropertyAccess
 
 				// If this is an async method and async methods are unsupported,
 				// generate a temporary variable in case this async method contains a
 				// "super" property reference. If that happens, the "super" expression
 				// must be lowered which will need a reference to this object literal.
-				if property.Flags.Has(js_ast.PropertyIsMethod) && p.options.unsupportedJSFeatures.Has(compat.AsyncAwait) {
-					if fn, ok := property.ValueOrNil.Data.(*js_ast.EFunction); ok && fn.Fn.IsAsync {
-						if innerClassNameRef == ast.InvalidRef {
-							innerClassNameRef = p.generateTempRef(tempRefNeedsDeclareMayBeCapturedInsideLoop, "")
+				// The same applies to all methods if methods will become functions.
+				if property.Flags.Has(js_ast.PropertyIsMethod) {
+					if fn, ok := property.ValueOrNil.Data.(*js_ast.EFunction); ok {
+						lowerMethod := p.options.unsupportedJSFeatures.Has(compat.ObjectExtensions)
+						if lowerMethod || (fn.Fn.IsAsync && p.options.unsupportedJSFeatures.Has(compat.AsyncAwait)) {
+							if innerClassNameRef == ast.InvalidRef {
+								innerClassNameRef = p.generateTempRef(tempRefNeedsDeclareMayBeCapturedInsideLoop, "")
+							}
+							p.propMethodValue = property.ValueOrNil.Data
+							p.fnOnlyDataVisit.isInStaticClassContext = true
+							p.fnOnlyDataVisit.innerClassNameRef = &innerClassNameRef
+							p.fnOnlyDataVisit.classTSPrivateProps = nil
+							if lowerMethod {
+								p.fnOrArrowDataVisit.shouldLowerSuperPropertyAccess = true
+							}
 						}
-						p.propMethodValue = property.ValueOrNil.Data
-						p.fnOnlyDataVisit.isInStaticClassContext = true
-						p.fnOnlyDataVisit.innerClassNameRef = &innerClassNameRef
 					}
 				}
 
 				property.ValueOrNil, _ = p.visitExprInOut(property.ValueOrNil, exprIn{assignTarget: in.assignTarget})
 
 				p.fnOnlyDataVisit.innerClassNameRef = oldInnerClassNameRef
+				p.fnOnlyDataVisit.classTSPrivateProps = oldClassTSPrivateProps
 				p.fnOnlyDataVisit.isInStaticClassContext = oldIsInStaticClassContext
+				p.fnOrArrowDataVisit.shouldLowerSuperPropertyAccess = oldShouldLowerSuperPropertyAccess
 			}
 
 			if property.InitializerOrNil.Data != nil {
@@ -13928,7 +15236,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		isThenCatchTarget := e == p.thenCatchChain.nextTarget && p.thenCatchChain.hasCatch
 		e.Expr = p.visitExpr(e.Expr)
 
-		var assertions *ast.ImportAssertions
+		var assertOrWith *ast.ImportAssertOrWith
 		var flags ast.ImportRecordFlags
 		if e.OptionsOrNil.Data != nil {
 			e.OptionsOrNil = p.visitExpr(e.OptionsOrNil)
@@ -13942,18 +15250,22 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			whyLoc := e.OptionsOrNil.Loc
 
 			// However, make a special case for an additional argument that contains
-			// only an "assert" clause. In that case we can split this AST node.
+			// only an "assert" or "with" clause. In that case we can split this AST node.
 			if object, ok := e.OptionsOrNil.Data.(*js_ast.EObject); ok {
 				if len(object.Properties) == 1 {
 					if prop := object.Properties[0]; prop.Kind == js_ast.PropertyNormal && !prop.Flags.Has(js_ast.PropertyIsComputed) && !prop.Flags.Has(js_ast.PropertyIsMethod) {
-						if str, ok := prop.Key.Data.(*js_ast.EString); ok && helpers.UTF16EqualsString(str.Value, "assert") {
+						if str, ok := prop.Key.Data.(*js_ast.EString); ok && (helpers.UTF16EqualsString(str.Value, "assert") || helpers.UTF16EqualsString(str.Value, "with")) {
+							keyword := ast.WithKeyword
+							if helpers.UTF16EqualsString(str.Value, "assert") {
+								keyword = ast.AssertKeyword
+							}
 							if value, ok := prop.ValueOrNil.Data.(*js_ast.EObject); ok {
-								entries := []ast.AssertEntry{}
+								entries := []ast.AssertOrWithEntry{}
 								for _, p := range value.Properties {
 									if p.Kind == js_ast.PropertyNormal && !p.Flags.Has(js_ast.PropertyIsComputed) && !p.Flags.Has(js_ast.PropertyIsMethod) {
 										if key, ok := p.Key.Data.(*js_ast.EString); ok {
 											if value, ok := p.ValueOrNil.Data.(*js_ast.EString); ok {
-												entries = append(entries, ast.AssertEntry{
+												entries = append(entries, ast.AssertOrWithEntry{
 													Key:             key.Value,
 													KeyLoc:          p.Key.Loc,
 													Value:           value.Value,
@@ -13981,22 +15293,23 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 									break
 								}
 								if entries != nil {
-									assertions = &ast.ImportAssertions{
+									assertOrWith = &ast.ImportAssertOrWith{
 										Entries:            entries,
-										AssertLoc:          prop.Key.Loc,
+										KeywordLoc:         prop.Key.Loc,
 										InnerOpenBraceLoc:  prop.ValueOrNil.Loc,
 										InnerCloseBraceLoc: value.CloseBraceLoc,
 										OuterOpenBraceLoc:  e.OptionsOrNil.Loc,
 										OuterCloseBraceLoc: object.CloseBraceLoc,
+										Keyword:            keyword,
 									}
 									why = ""
 								}
 							} else {
-								why = "the value for \"assert\" was not an object literal"
+								why = fmt.Sprintf("the value for %q was not an object literal", keyword.String())
 								whyLoc = prop.ValueOrNil.Loc
 							}
 						} else {
-							why = "this property was not called \"assert\""
+							why = "this property was not called \"assert\" or \"with\""
 							whyLoc = prop.Key.Loc
 						}
 					} else {
@@ -14004,12 +15317,12 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 						whyLoc = prop.Key.Loc
 					}
 				} else {
-					why = "the second argument was not an object literal with a single property called \"assert\""
+					why = "the second argument was not an object literal with a single property called \"assert\" or \"with\""
 					whyLoc = e.OptionsOrNil.Loc
 				}
 			}
 
-			// Handle the case that isn't just an import assertion clause
+			// Handle the case that isn't just an import assertion or attribute clause
 			if why != "" {
 				// Only warn when bundling
 				if p.options.mode == config.ModeBundle {
@@ -14021,16 +15334,16 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					p.log.AddID(logger.MsgID_JS_UnsupportedDynamicImport, kind, &p.tracker, logger.Range{Loc: whyLoc}, text)
 				}
 
-				// If import assertions aren't supported in the target platform, keeping
-				// them would be a syntax error so we need to get rid of them. We can't
-				// just not print them because they may have important side effects.
-				// Attempt to discard them without changing side effects and generate an
-				// error if that isn't possible.
-				if p.options.unsupportedJSFeatures.Has(compat.ImportAssertions) {
+				// If neither import assertions nor import attributes are supported in
+				// the target platform, keeping them would be a syntax error so we need
+				// to get rid of them. We can't just not print them because they may
+				// have important side effects. Attempt to discard them without changing
+				// side effects and generate an error if that isn't possible.
+				if p.options.unsupportedJSFeatures.Has(compat.ImportAssertions) && p.options.unsupportedJSFeatures.Has(compat.ImportAttributes) {
 					if js_ast.ExprCanBeRemovedIfUnused(e.OptionsOrNil, p.isUnbound) {
 						e.OptionsOrNil = js_ast.Expr{}
 					} else {
-						p.markSyntaxFeature(compat.ImportAssertions, logger.Range{Loc: e.OptionsOrNil.Loc})
+						p.markSyntaxFeature(compat.ImportAttributes, logger.Range{Loc: e.OptionsOrNil.Loc})
 					}
 				}
 
@@ -14050,7 +15363,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					return js_ast.Expr{Loc: arg.Loc, Data: js_ast.ENullShared}
 				}
 
-				importRecordIndex := p.addImportRecord(ast.ImportDynamic, arg.Loc, helpers.UTF16ToString(str.Value), assertions, flags)
+				importRecordIndex := p.addImportRecord(ast.ImportDynamic, arg.Loc, helpers.UTF16ToString(str.Value), assertOrWith, flags)
 				if isAwaitTarget && p.fnOrArrowDataVisit.tryBodyCount != 0 {
 					record := &p.importRecords[importRecordIndex]
 					record.Flags |= ast.HandlesImportErrors
@@ -14267,7 +15580,8 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 		case *js_ast.EImportIdentifier:
 			// If this function is inlined, allow it to be tree-shaken
 			if p.options.minifySyntax && !p.isControlFlowDead {
-				p.convertSymbolUseToCall(t.Ref, len(e.Args) == 1 && !hasSpread)
+				e.HasInlinableArgs = p.options.inlineFunctions && e.OptionalChain == js_ast.OptionalChainNone && p.isInlinableCallArgs(e.Args)
+				p.convertSymbolUseToCall(t.Ref, len(e.Args) == 1 && !hasSpread, e.HasInlinableArgs)
 			}
 
 		case *js_ast.EIdentifier:
@@ -14387,7 +15701,8 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 
 			// If this function is inlined, allow it to be tree-shaken
 			if p.options.minifySyntax && !p.isControlFlowDead {
-				p.convertSymbolUseToCall(t.Ref, len(e.Args) == 1 && !hasSpread)
+				e.HasInlinableArgs = p.options.inlineFunctions && e.OptionalChain == js_ast.OptionalChainNone && p.isInlinableCallArgs(e.Args)
+				p.convertSymbolUseToCall(t.Ref, len(e.Args) == 1 && !hasSpread, e.HasInlinableArgs)
 			}
 
 		case *js_ast.EDot:
@@ -14484,7 +15799,7 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			if target, loc, private := p.extractPrivateIndex(e.Target); private != nil {
 				// "foo.#bar(123)" => "__privateGet(_a = foo, #bar).call(_a, 123)"
 				targetFunc, targetWrapFunc := p.captureValueWithPossibleSideEffects(target.Loc, 2, target, valueCouldBeMutated)
-				return targetWrapFunc(js_ast.Expr{Loc: target.Loc, Data: &js_ast.ECall{
+				return targetWrapFunc(p.lowerSpreadInCall(target.Loc, &js_ast.ECall{
 					Target: js_ast.Expr{Loc: target.Loc, Data: &js_ast.EDot{
 						Target:  p.lowerPrivateGet(targetFunc(), loc, private),
 						Name:    "call",
@@ -14493,9 +15808,17 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 					Args:                   append([]js_ast.Expr{targetFunc()}, e.Args...),
 					CanBeUnwrappedIfUnused: e.CanBeUnwrappedIfUnused,
 					Kind:                   js_ast.TargetWasOriginallyPropertyAccess,
-				}}), exprOut{}
+				}, true)), exprOut{}
 			}
+			oldTarget := e.Target.Data
 			p.maybeLowerSuperPropertyGetInsideCall(e)
+
+			// "foo(...a)" => "foo.apply(void 0, __toArray(a))"
+			if hasSpread && p.options.unsupportedJSFeatures.Has(compat.ArraySpread) {
+				if _, ok := e.Target.Data.(*js_ast.ESuper); !ok {
+					return p.lowerSpreadInCall(expr.Loc, e, e.Target.Data != oldTarget), exprOut{}
+				}
+			}
 		}
 
 		// Track calls to require() so we can use them while bundling
@@ -14602,6 +15925,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 
 		p.maybeMarkKnownGlobalConstructorAsPure(e)
 
+		// "new foo(...a)" => "__construct(foo, __toArray(a))"
+		if hasSpread && p.options.unsupportedJSFeatures.Has(compat.ArraySpread) {
+			return p.lowerSpreadInNew(expr.Loc, e), exprOut{}
+		}
+
 	case *js_ast.EArrow:
 		// Check for a propagated name to keep from the parent context
 		var nameToKeep string
@@ -14676,7 +16004,11 @@ func (p *parser) visitExprInOut(expr js_ast.Expr, in exprIn) (js_ast.Expr, exprO
 			nameToKeep = p.nameToKeep
 		}
 
-		p.visitFn(&e.Fn, expr.Loc, visitFnOpts{isClassMethod: e == p.propMethodValue})
+		opts := visitFnOpts{isClassMethod: e == p.propMethodValue}
+		if e == p.propDerivedCtorValue {
+			opts.derivedClassThisRef = &p.propDerivedCtorThisRef
+		}
+		p.visitFn(&e.Fn, expr.Loc, opts)
 		name := e.Fn.Name
 
 		// Remove unused function names when minifying
@@ -14900,6 +16232,13 @@ func (v *binaryExprVisitor) visitRightAndFinish(p *parser) js_ast.Expr {
 		}
 	}
 
+	// Assigning to a "let" or "const" binding that will become "var" may throw
+	if v.in.assignTarget == js_ast.AssignTargetNone && e.Op.BinaryAssignTarget() != js_ast.AssignTargetNone {
+		if result, ok := p.maybeLowerLetOrConstAssign(e.Op, e.Left, e.Right); ok {
+			return result
+		}
+	}
+
 	// Post-process the binary expression
 	switch e.Op {
 	case js_ast.BinOpComma:
@@ -15293,7 +16632,341 @@ func remapExprLocsInJSON(expr *js_ast.Expr, table []logger.StringInJSTableEntry)
 	}
 }
 
-func (p *parser) convertSymbolUseToCall(ref ast.Ref, isSingleNonSpreadArgCall bool) {
+func (p *parser) recordInlinableFunction(fn *js_ast.Fn) {
+	// A function declared more than once takes the value of the last one, so
+	// make sure an earlier declaration isn't used if a later one isn't inlinable
+	if value, ok := p.inlinableFunctionValue(fn); ok {
+		if p.inlinableFunctions == nil {
+			p.inlinableFunctions = make(map[ast.Ref]js_ast.InlinableFunction)
+		}
+		p.inlinableFunctions[fn.Name.Ref] = value
+	} else if p.inlinableFunctions != nil {
+		delete(p.inlinableFunctions, fn.Name.Ref)
+	}
+}
+
+// Functions whose body is a single "return" statement of a simple enough
+// expression can be inlined at any call site. Other functions can only be
+// inlined at their only call site (see "inlinableFunctionStmts").
+func (p *parser) inlinableFunctionValue(fn *js_ast.Fn) (js_ast.InlinableFunction, bool) {
+	args := make([]ast.Ref, len(fn.Args))
+	for i, arg := range fn.Args {
+		id, ok := arg.Binding.Data.(*js_ast.BIdentifier)
+		if !ok || arg.DefaultOrNil.Data != nil {
+			return js_ast.InlinableFunction{}, false
+		}
+		for _, other := range args[:i] {
+			if other == id.Ref {
+				return js_ast.InlinableFunction{}, false
+			}
+		}
+		args[i] = id.Ref
+	}
+	if len(fn.Body.Block.Stmts) == 1 {
+		if ret, ok := fn.Body.Block.Stmts[0].Data.(*js_ast.SReturn); ok && ret.ValueOrNil.Data != nil {
+			if size, ok := js_ast.InlinableFunctionBodySize(ret.ValueOrNil, args); ok {
+				return js_ast.InlinableFunction{Args: args, Body: ret.ValueOrNil, Size: size}, true
+			}
+		}
+	}
+	return p.inlinableFunctionStmts(fn, args)
+}
+
+// A function with any other body can still be inlined if the linker finds
+// that it's only called once, and that the call is an entire top-level
+// statement. The body is then printed inside a block in place of the call.
+// This means the body must not declare anything that would escape from that
+// block ("var" and function declarations) and must not return anywhere but
+// at the end. It also must not use "this" or "arguments", call itself, or
+// contain nested functions. Its local variables keep their own symbols, and
+// the linker makes sure the renamer gives them names that don't collide with
+// anything at the call site.
+func (p *parser) inlinableFunctionStmts(fn *js_ast.Fn, args []ast.Ref) (js_ast.InlinableFunction, bool) {
+	stmts := fn.Body.Block.Stmts
+	if len(stmts) == 0 || p.options.unsupportedJSFeatures.Has(compat.ConstAndLet) {
+		return js_ast.InlinableFunction{}, false
+	}
+	c := inlinableStmtsChecker{p: p, fn: fn, declared: make(map[ast.Ref]bool)}
+	for _, arg := range args {
+		c.declared[arg] = true
+	}
+	for i, stmt := range stmts {
+		if ret, ok := stmt.Data.(*js_ast.SReturn); ok && i+1 == len(stmts) {
+			if ret.ValueOrNil.Data != nil && !c.expr(ret.ValueOrNil) {
+				return js_ast.InlinableFunction{}, false
+			}
+		} else if !c.stmt(stmt) {
+			return js_ast.InlinableFunction{}, false
+		}
+	}
+	return js_ast.InlinableFunction{Args: args, Stmts: stmts, FreeRefs: c.freeRefs}, true
+}
+
+type inlinableStmtsChecker struct {
+	p        *parser
+	fn       *js_ast.Fn
+	declared map[ast.Ref]bool
+	freeRefs []ast.Ref
+}
+
+func (c *inlinableStmtsChecker) ref(ref ast.Ref) bool {
+	if c.declared[ref] {
+		return true
+	}
+	p := c.p
+	symbol := &p.symbols[ref.InnerIndex]
+
+	// Globals mean the same thing everywhere
+	if symbol.Kind == ast.SymbolUnbound {
+		return ref != p.requireRef
+	}
+
+	// Other top-level symbols in this file must be kept alive by the call site
+	if symbol.Kind == ast.SymbolImport || ref == c.fn.Name.Ref || ref == p.exportsRef || ref == p.moduleRef {
+		return false
+	}
+	if member, ok := p.moduleScope.Members[symbol.OriginalName]; !ok || member.Ref != ref {
+		return false
+	}
+	for _, other := range c.freeRefs {
+		if other == ref {
+			return true
+		}
+	}
+	c.freeRefs = append(c.freeRefs, ref)
+	return true
+}
+
+func (c *inlinableStmtsChecker) binding(binding js_ast.Binding) bool {
+	switch b := binding.Data.(type) {
+	case *js_ast.BMissing:
+		return true
+
+	case *js_ast.BIdentifier:
+		c.declared[b.Ref] = true
+		return true
+
+	case *js_ast.BArray:
+		for _, item := range b.Items {
+			if (item.DefaultValueOrNil.Data != nil && !c.expr(item.DefaultValueOrNil)) || !c.binding(item.Binding) {
+				return false
+			}
+		}
+		return true
+
+	case *js_ast.BObject:
+		for _, property := range b.Properties {
+			if !c.expr(property.Key) || (property.DefaultValueOrNil.Data != nil && !c.expr(property.DefaultValueOrNil)) || !c.binding(property.Value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func (c *inlinableStmtsChecker) local(s *js_ast.SLocal) bool {
+	if s.IsExport || (s.Kind != js_ast.LocalLet && s.Kind != js_ast.LocalConst) {
+		return false
+	}
+	for _, decl := range s.Decls {
+		// Check the value first since it can't refer to the binding
+		if (decl.ValueOrNil.Data != nil && !c.expr(decl.ValueOrNil)) || !c.binding(decl.Binding) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *inlinableStmtsChecker) loopInit(init js_ast.Stmt) bool {
+	switch s := init.Data.(type) {
+	case *js_ast.SLocal:
+		return c.local(s)
+
+	case *js_ast.SExpr:
+		return c.expr(s.Value)
+	}
+	return false
+}
+
+func (c *inlinableStmtsChecker) stmts(stmts []js_ast.Stmt) bool {
+	for _, stmt := range stmts {
+		if !c.stmt(stmt) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *inlinableStmtsChecker) stmt(stmt js_ast.Stmt) bool {
+	switch s := stmt.Data.(type) {
+	case *js_ast.SEmpty, *js_ast.SDebugger, *js_ast.SBreak, *js_ast.SContinue:
+		return true
+
+	case *js_ast.SExpr:
+		return c.expr(s.Value)
+
+	case *js_ast.SThrow:
+		return c.expr(s.Value)
+
+	case *js_ast.SLocal:
+		return c.local(s)
+
+	case *js_ast.SBlock:
+		return c.stmts(s.Stmts)
+
+	case *js_ast.SLabel:
+		return c.stmt(s.Stmt)
+
+	case *js_ast.SIf:
+		return c.expr(s.Test) && c.stmt(s.Yes) && (s.NoOrNil.Data == nil || c.stmt(s.NoOrNil))
+
+	case *js_ast.SFor:
+		return (s.InitOrNil.Data == nil || c.loopInit(s.InitOrNil)) &&
+			(s.TestOrNil.Data == nil || c.expr(s.TestOrNil)) &&
+			(s.UpdateOrNil.Data == nil || c.expr(s.UpdateOrNil)) &&
+			c.stmt(s.Body)
+
+	case *js_ast.SForIn:
+		return c.expr(s.Value) && c.loopInit(s.Init) && c.stmt(s.Body)
+
+	case *js_ast.SForOf:
+		return s.Await.Len == 0 && c.expr(s.Value) && c.loopInit(s.Init) && c.stmt(s.Body)
+
+	case *js_ast.SWhile:
+		return c.expr(s.Test) && c.stmt(s.Body)
+
+	case *js_ast.SDoWhile:
+		return c.stmt(s.Body) && c.expr(s.Test)
+
+	case *js_ast.SSwitch:
+		if !c.expr(s.Test) {
+			return false
+		}
+		for _, item := range s.Cases {
+			if (item.ValueOrNil.Data != nil && !c.expr(item.ValueOrNil)) || !c.stmts(item.Body) {
+				return false
+			}
+		}
+		return true
+
+	case *js_ast.STry:
+		if !c.stmts(s.Block.Stmts) {
+			return false
+		}
+		if s.Catch != nil && ((s.Catch.BindingOrNil.Data != nil && !c.binding(s.Catch.BindingOrNil)) || !c.stmts(s.Catch.Block.Stmts)) {
+			return false
+		}
+		return s.Finally == nil || c.stmts(s.Finally.Block.Stmts)
+	}
+
+	return false
+}
+
+func (c *inlinableStmtsChecker) exprs(exprs []js_ast.Expr) bool {
+	for _, expr := range exprs {
+		if !c.expr(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *inlinableStmtsChecker) expr(expr js_ast.Expr) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.ENull, *js_ast.EUndefined, *js_ast.EBoolean, *js_ast.ENumber, *js_ast.EBigInt,
+		*js_ast.EString, *js_ast.ERegExp, *js_ast.EMissing:
+		return true
+
+	case *js_ast.EIdentifier:
+		return !e.MustKeepDueToWithStmt && c.ref(e.Ref)
+
+	case *js_ast.EUnary:
+		return c.expr(e.Value)
+
+	case *js_ast.EBinary:
+		return c.expr(e.Left) && c.expr(e.Right)
+
+	case *js_ast.EIf:
+		return c.expr(e.Test) && c.expr(e.Yes) && c.expr(e.No)
+
+	case *js_ast.EDot:
+		return c.expr(e.Target)
+
+	case *js_ast.EIndex:
+		return c.expr(e.Target) && c.expr(e.Index)
+
+	case *js_ast.ECall:
+		// Direct "eval" can see the names of local variables
+		return e.Kind != js_ast.DirectEval && c.expr(e.Target) && c.exprs(e.Args)
+
+	case *js_ast.ENew:
+		return c.expr(e.Target) && c.exprs(e.Args)
+
+	case *js_ast.EArray:
+		return c.exprs(e.Items)
+
+	case *js_ast.ESpread:
+		return c.expr(e.Value)
+
+	case *js_ast.EAnnotation:
+		return c.expr(e.Value)
+
+	case *js_ast.EInlinedEnum:
+		return c.expr(e.Value)
+
+	case *js_ast.EObject:
+		for _, property := range e.Properties {
+			if (property.Kind != js_ast.PropertyNormal && property.Kind != js_ast.PropertySpread) || property.Flags.Has(js_ast.PropertyIsMethod) {
+				return false
+			}
+			if (property.Key.Data != nil && !c.expr(property.Key)) || (property.ValueOrNil.Data != nil && !c.expr(property.ValueOrNil)) ||
+				(property.InitializerOrNil.Data != nil && !c.expr(property.InitializerOrNil)) {
+				return false
+			}
+		}
+		return true
+
+	case *js_ast.ETemplate:
+		if e.TagOrNil.Data != nil && !c.expr(e.TagOrNil) {
+			return false
+		}
+		for _, part := range e.Parts {
+			if !c.expr(part.Value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// Calls with these arguments can be replaced by the body of an inlined function
+// because the arguments can be evaluated later (or more than once, or not at
+// all) without changing what the code does
+func (p *parser) isInlinableCallArgs(args []js_ast.Expr) bool {
+	for _, arg := range args {
+		switch a := arg.Data.(type) {
+		case *js_ast.ENull, *js_ast.EUndefined, *js_ast.EBoolean, *js_ast.ENumber, *js_ast.EBigInt, *js_ast.EString:
+
+		case *js_ast.EIdentifier:
+			// Unbound identifiers may throw a ReferenceError when evaluated
+			if a.MustKeepDueToWithStmt || p.symbols[a.Ref.InnerIndex].Kind == ast.SymbolUnbound {
+				return false
+			}
+
+		case *js_ast.EImportIdentifier:
+
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) convertSymbolUseToCall(ref ast.Ref, isSingleNonSpreadArgCall bool, hasInlinableArgs bool) {
 	// Remove the normal symbol use
 	use := p.symbolUses[ref]
 	use.CountEstimate--
@@ -15312,6 +16985,9 @@ func (p *parser) convertSymbolUseToCall(ref ast.Ref, isSingleNonSpreadArgCall bo
 	if isSingleNonSpreadArgCall {
 		callUse.SingleArgNonSpreadCallCountEstimate++
 	}
+	if hasInlinableArgs {
+		callUse.InlinableArgsCallCountEstimate++
+	}
 	p.symbolCallUses[ref] = callUse
 }
 
@@ -15525,6 +17201,12 @@ func (p *parser) handleIdentifier(loc logger.Loc, e *js_ast.EIdentifier, opts id
 		}
 	}
 
+	// Anything other than a property access lets the object escape. Generated
+	// identifiers for namespace property accesses are handled by the caller.
+	if p.shouldTrackEscapingSymbols() && opts.wasOriginallyIdentifier && e != p.dotOrIndexTarget {
+		p.markEscapingSymbol(ref)
+	}
+
 	// Capture the "arguments" variable if necessary
 	if p.fnOnlyDataVisit.argumentsRef != nil && ref == *p.fnOnlyDataVisit.argumentsRef {
 		isInsideUnsupportedArrow := p.fnOrArrowDataVisit.isArrow && p.options.unsupportedJSFeatures.Has(compat.Arrow)
@@ -15532,6 +17214,12 @@ func (p *parser) handleIdentifier(loc logger.Loc, e *js_ast.EIdentifier, opts id
 		if isInsideUnsupportedArrow || isInsideUnsupportedAsyncArrow {
 			return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.captureArguments()}}
 		}
+
+		// This may need to be captured later if it's inside a loop body that
+		// ends up being moved into a function due to lowering "let" or "const"
+		if loop := p.fnOnlyDataVisit.letOrConstLoop; loop != nil {
+			loop.argumentsUses = append(loop.argumentsUses, e)
+		}
 	}
 
 	// Create an error for assigning to an import namespace
@@ -15656,7 +17344,8 @@ func (p *parser) handleIdentifier(loc logger.Loc, e *js_ast.EIdentifier, opts id
 }
 
 type visitFnOpts struct {
-	isClassMethod bool
+	derivedClassThisRef *ast.Ref
+	isClassMethod       bool
 }
 
 func (p *parser) visitFn(fn *js_ast.Fn, scopeLoc logger.Loc, opts visitFnOpts) {
@@ -15666,7 +17355,8 @@ func (p *parser) visitFn(fn *js_ast.Fn, scopeLoc logger.Loc, opts visitFnOpts) {
 	p.fnOrArrowDataVisit = fnOrArrowDataVisit{
 		isAsync:                        fn.IsAsync,
 		isGenerator:                    fn.IsGenerator,
-		shouldLowerSuperPropertyAccess: fn.IsAsync && p.options.unsupportedJSFeatures.Has(compat.AsyncAwait),
+		shouldLowerSuperPropertyAccess: (fn.IsAsync && p.options.unsupportedJSFeatures.Has(compat.AsyncAwait)) ||
+			(fn.IsGenerator && p.options.unsupportedJSFeatures.Has(compat.Generator)),
 	}
 	p.fnOnlyDataVisit = fnOnlyDataVisit{
 		isThisNested:       true,
@@ -15677,11 +17367,16 @@ func (p *parser) visitFn(fn *js_ast.Fn, scopeLoc logger.Loc, opts visitFnOpts) {
 	if opts.isClassMethod {
 		decoratorScope = p.propMethodDecoratorScope
 		p.fnOnlyDataVisit.innerClassNameRef = oldFnOnlyData.innerClassNameRef
+		p.fnOnlyDataVisit.classTSPrivateProps = oldFnOnlyData.classTSPrivateProps
 		p.fnOnlyDataVisit.isInStaticClassContext = oldFnOnlyData.isInStaticClassContext
 		if oldFnOrArrowData.shouldLowerSuperPropertyAccess {
 			p.fnOrArrowDataVisit.shouldLowerSuperPropertyAccess = true
 		}
 	}
+	if opts.derivedClassThisRef != nil {
+		ref := *opts.derivedClassThisRef
+		p.fnOnlyDataVisit.derivedClassThisRef = &ref
+	}
 
 	if fn.Name != nil {
 		p.recordDeclaredSymbol(fn.Name.Ref)
@@ -15776,10 +17471,15 @@ func (p *parser) scanForUnusedTSImportEquals(stmts []js_ast.Stmt) (result import
 		if s, ok := stmt.Data.(*js_ast.SLocal); ok && p.checkForUnusedTSImportEquals(s, &result) {
 			// Remove unused import-equals statements, since those likely
 			// correspond to types instead of values
+			p.removedStmtComments = p.takeStmtComments(stmt.Loc, p.removedStmtComments)
 			continue
 		}
 
 		// Filter out statements we skipped over
+		if p.removedStmtComments != nil {
+			p.attachStmtComments(stmt.Loc, p.removedStmtComments)
+			p.removedStmtComments = nil
+		}
 		stmts[stmtsEnd] = stmt
 		stmtsEnd++
 	}
@@ -15797,6 +17497,10 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 		case *js_ast.SImport:
 			record := &p.importRecords[s.ImportRecordIndex]
 
+			if p.options.ts.Parse && p.options.ts.IsErasableSyntaxOnly() {
+				p.checkForMissingTypeModifier(s)
+			}
+
 			// We implement TypeScript's "preserveValueImports" tsconfig.json setting
 			// to support the use case of compiling partial modules for compile-to-
 			// JavaScript languages such as Svelte. These languages try to reference
@@ -15940,6 +17644,7 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 					// for injected files and we definitely do not want to trim these.
 					if !record.SourceIndex.IsValid() && !record.CopySourceIndex.IsValid() {
 						record.Flags |= ast.IsUnused
+						p.removedStmtComments = p.takeStmtComments(stmt.Loc, p.removedStmtComments)
 						continue
 					}
 				}
@@ -16054,6 +17759,7 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 			// Remove unused import-equals statements, since those likely
 			// correspond to types instead of values
 			if p.checkForUnusedTSImportEquals(s, &result) {
+				p.removedStmtComments = p.takeStmtComments(stmt.Loc, p.removedStmtComments)
 				continue
 			}
 
@@ -16125,11 +17831,16 @@ func (p *parser) scanForImportsAndExports(stmts []js_ast.Stmt) (result importsEx
 			// correctness since some re-exports might be fake (only in the type
 			// system and used for type-only stuff).
 			if p.options.ts.Parse && len(s.Items) == 0 && (unusedImportFlags&config.TSUnusedImport_KeepStmt) == 0 {
+				p.removedStmtComments = p.takeStmtComments(stmt.Loc, p.removedStmtComments)
 				continue
 			}
 		}
 
 		// Filter out statements we skipped over
+		if p.removedStmtComments != nil {
+			p.attachStmtComments(stmt.Loc, p.removedStmtComments)
+			p.removedStmtComments = nil
+		}
 		stmts[stmtsEnd] = stmt
 		stmtsEnd++
 	}
@@ -16238,6 +17949,7 @@ func newParser(log logger.Log, source logger.Source, lexer js_lexer.Lexer, optio
 		// For JSX runtime imports
 		jsxRuntimeImports: make(map[string]ast.LocRef),
 		jsxLegacyImports:  make(map[string]ast.LocRef),
+		jsxTargetImports:  make(map[string]ast.LocRef),
 
 		suppressWarningsAboutWeirdCode: helpers.IsInsideNodeModules(source.KeyPath.Text),
 	}
@@ -16251,6 +17963,24 @@ func newParser(log logger.Log, source logger.Source, lexer js_lexer.Lexer, optio
 
 	if !options.minifyWhitespace {
 		p.exprComments = make(map[logger.Loc][]string)
+		if options.preserveComments {
+			p.trailingComments = make(map[logger.Loc][]string)
+		}
+	}
+
+	// Don't generate polyfill imports for the runtime or for the polyfill
+	// package itself, since the polyfills would end up importing themselves
+	if options.polyfill == "" || source.Index == runtime.SourceIndex || isInsidePackage(source.KeyPath.Text, options.polyfill) {
+		p.options.unsupportedBuiltIns = 0
+	}
+
+	// Types are only recorded if we'll need them to generate declarations
+	if options.declarations && options.ts.Parse {
+		p.dts = newDTSRecorder()
+	}
+	if options.ts.Parse && options.ts.Config.EmitDecoratorMetadata == config.True && options.ts.Config.ExperimentalDecorators == config.True {
+		p.metadataTypes = make(map[logger.Loc]logger.Range)
+		p.metadataImportUses = make(map[ast.Ref]uint32)
 	}
 
 	p.isUnbound = func(ref ast.Ref) bool {
@@ -16265,7 +17995,11 @@ func newParser(log logger.Log, source logger.Source, lexer js_lexer.Lexer, optio
 var defaultJSXFactory = []string{"React", "createElement"}
 var defaultJSXFragment = []string{"React", "Fragment"}
 
+var defaultJSXTaggedTemplate = []string{"html"}
+
 const defaultJSXImportSource = "react"
+const defaultJSXImportSourceSolid = "solid-js"
+const defaultJSXImportSourceVue = "vue"
 
 func Parse(log logger.Log, source logger.Source, options Options) (result js_ast.AST, ok bool) {
 	ok = true
@@ -16280,13 +18014,24 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 
 	// Default options for JSX elements
 	if len(options.jsx.Factory.Parts) == 0 {
-		options.jsx.Factory = config.DefineExpr{Parts: defaultJSXFactory}
+		if options.jsx.Target == config.JSXTargetHTM {
+			options.jsx.Factory = config.DefineExpr{Parts: defaultJSXTaggedTemplate}
+		} else {
+			options.jsx.Factory = config.DefineExpr{Parts: defaultJSXFactory}
+		}
 	}
 	if len(options.jsx.Fragment.Parts) == 0 && options.jsx.Fragment.Constant == nil {
 		options.jsx.Fragment = config.DefineExpr{Parts: defaultJSXFragment}
 	}
 	if len(options.jsx.ImportSource) == 0 {
-		options.jsx.ImportSource = defaultJSXImportSource
+		switch options.jsx.Target {
+		case config.JSXTargetSolid:
+			options.jsx.ImportSource = defaultJSXImportSourceSolid
+		case config.JSXTargetVue:
+			options.jsx.ImportSource = defaultJSXImportSourceVue
+		default:
+			options.jsx.ImportSource = defaultJSXImportSource
+		}
 	}
 
 	p := newParser(log, source, js_lexer.NewLexer(log, source, options.ts), &options)
@@ -16307,6 +18052,21 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 		isModuleScope:          true,
 		allowDirectivePrologue: true,
 	})
+
+	// Declarations must be generated before the visit pass mutates the AST
+	var declarations *dts_ast.AST
+	if p.dts != nil {
+		declarations = p.generateDeclarations()
+
+		// Declaration files don't contain any code, so their imports and exports
+		// are all for types. Those are handled by the declarations instead.
+		if IsDeclarationFile(p.source.KeyPath.Text) {
+			stmts = nil
+			for i := range p.importRecords {
+				p.importRecords[i].Flags |= ast.IsUnused
+			}
+		}
+	}
 	p.prepareForVisitPass()
 
 	// Insert a "use strict" directive if "alwaysStrict" is active
@@ -16561,16 +18321,32 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 	// happens when bundling, in which case we are flatting the module scopes of
 	// all modules together anyway so such directives are meaningless.
 	if p.importMetaRef != ast.InvalidRef {
+		p.symbolUses = make(map[ast.Ref]js_ast.SymbolUse)
+		value := js_ast.Expr{Data: &js_ast.EObject{}}
+		if helper := p.importMetaPolyfill(); helper != "" {
+			var args []js_ast.Expr
+			if p.options.platform == config.PlatformNode {
+				// Use new unbound symbols instead of looking these names up because
+				// they may be shadowed by top-level variables in this module
+				for _, name := range []string{"__filename", "__dirname"} {
+					ref := p.newSymbol(ast.SymbolUnbound, name)
+					p.moduleScope.Generated = append(p.moduleScope.Generated, ref)
+					p.recordUsage(ref)
+					args = append(args, js_ast.Expr{Data: &js_ast.EIdentifier{Ref: ref}})
+				}
+			}
+			value = p.callRuntime(logger.Loc{}, helper, args)
+		}
 		importMetaStmt := js_ast.Stmt{Data: &js_ast.SLocal{
 			Kind: p.selectLocalKind(js_ast.LocalConst),
 			Decls: []js_ast.Decl{{
 				Binding:    js_ast.Binding{Data: &js_ast.BIdentifier{Ref: p.importMetaRef}},
-				ValueOrNil: js_ast.Expr{Data: &js_ast.EObject{}},
+				ValueOrNil: value,
 			}},
 		}}
 		before = append(before, js_ast.Part{
 			Stmts:                []js_ast.Stmt{importMetaStmt},
-			SymbolUses:           make(map[ast.Ref]js_ast.SymbolUse),
+			SymbolUses:           p.symbolUses,
 			DeclaredSymbols:      []js_ast.DeclaredSymbol{{Ref: p.importMetaRef, IsTopLevel: true}},
 			CanBeRemovedIfUnused: true,
 		})
@@ -16581,6 +18357,7 @@ func Parse(log logger.Log, source logger.Source, options Options) (result js_ast
 
 	result = p.toAST(before, parts, after, hashbang, directives)
 	result.SourceMapComment = p.lexer.SourceMappingURL
+	result.Declarations = declarations
 	return
 }
 
@@ -16993,7 +18770,43 @@ func sortedKeysOfMapStringLocRef(in map[string]ast.LocRef) []string {
 	return keys
 }
 
+// Polyfills are imported for their side effects only, so these are bare
+// import statements. They come first so that they run before any other code.
+func (p *parser) generatePolyfillImportStmts(parts []js_ast.Part) []js_ast.Part {
+	for _, module := range p.usedBuiltIns.PolyfillModules() {
+		path := p.options.polyfill + "/modules/" + module + ".js"
+		importRecordIndex := p.addImportRecord(ast.ImportStmt, logger.Loc{}, path, nil, 0)
+		namespaceRef := p.newSymbol(ast.SymbolOther, "import_"+js_ast.GenerateNonUniqueNameFromPath(path))
+		p.moduleScope.Generated = append(p.moduleScope.Generated, namespaceRef)
+		parts = append(parts, js_ast.Part{
+			Stmts: []js_ast.Stmt{{Data: &js_ast.SImport{
+				NamespaceRef:      namespaceRef,
+				ImportRecordIndex: importRecordIndex,
+			}}},
+		})
+	}
+	return parts
+}
+
 func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, directives []string) js_ast.AST {
+	// These names are only reserved if they were used as a normal property.
+	// Otherwise every use of these names was mangled.
+	for name := range p.tsPrivateProps {
+		if p.tsPrivatePropsUsedNormally[name] {
+			if p.reservedProps == nil {
+				p.reservedProps = make(map[string]bool)
+			}
+			p.reservedProps[name] = true
+		} else {
+			delete(p.reservedProps, name)
+		}
+	}
+
+	// Insert import statements for any polyfills that this file needs
+	if p.usedBuiltIns != 0 {
+		before = p.generatePolyfillImportStmts(before)
+	}
+
 	// Insert an import statement for any runtime imports we generated
 	if len(p.runtimeImports) > 0 && !p.options.omitRuntimeForTests {
 		keys := sortedKeysOfMapStringLocRef(p.runtimeImports)
@@ -17023,6 +18836,16 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 		before = p.generateImportStmt(path, keys, before, p.jsxLegacyImports, nil, nil)
 	}
 
+	// Insert an import statement for any imports from other JSX frameworks
+	if len(p.jsxTargetImports) > 0 && !p.options.omitJSXRuntimeForTests {
+		keys := sortedKeysOfMapStringLocRef(p.jsxTargetImports)
+		path := p.options.jsx.ImportSource
+		if p.options.jsx.Target == config.JSXTargetSolid {
+			path += "/web"
+		}
+		before = p.generateImportStmt(path, keys, before, p.jsxTargetImports, nil, nil)
+	}
+
 	// Generated imports are inserted before other code instead of appending them
 	// to the end of the file. Appending them should work fine because JavaScript
 	// import statements are "hoisted" to run before the importing file. However,
@@ -17105,6 +18928,13 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 		parts = parts[:partsEnd]
 	}
 
+	// Comments from removed statements at the end of the file are still kept
+	if p.removedStmtComments != nil && len(parts) > 0 {
+		last := &parts[len(parts)-1]
+		last.Stmts = appendCommentStmts(last.Stmts, logger.Loc{Start: int32(len(p.source.Contents))}, p.removedStmtComments)
+		p.removedStmtComments = nil
+	}
+
 	// Do a second pass for exported items now that imported items are filled out
 	for _, part := range parts {
 		for _, stmt := range part.Stmts {
@@ -17120,6 +18950,14 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 		}
 	}
 
+	// Imports that are only referenced by decorator metadata may be types
+	for ref, uses := range p.metadataImportUses {
+		if namedImport, ok := p.namedImports[ref]; ok && p.symbols[ref.InnerIndex].UseCountEstimate <= uses {
+			namedImport.IsOnlyUsedByDecoratorMetadata = true
+			p.namedImports[ref] = namedImport
+		}
+	}
+
 	// Analyze cross-part dependencies for tree shaking and code splitting
 	{
 		// Map locals to parts
@@ -17144,6 +18982,13 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 		p.topLevelSymbolToParts[p.exportsRef] = append(p.topLevelSymbolToParts[p.exportsRef], js_ast.NSExportPartIndex)
 	}
 
+	// A direct "eval" can reassign any top-level function or reference any
+	// top-level object without us knowing
+	if p.moduleScope.ContainsDirectEval {
+		p.inlinableFunctions = nil
+		p.collapsibleObjects = nil
+	}
+
 	// Make a wrapper symbol in case we need to be wrapped in a closure
 	wrapperRef := p.newSymbol(ast.SymbolOther, "require_"+p.source.IdentifierName)
 
@@ -17202,7 +19047,15 @@ func (p *parser) toAST(before, parts, after []js_ast.Part, hashbang string, dire
 		NamedExports:                    p.namedExports,
 		TSEnums:                         p.tsEnums,
 		ConstValues:                     p.constValues,
+		ConstValueCandidates:            p.constValueCandidates,
+		InlinableFunctions:              p.inlinableFunctions,
+		CollapsibleObjects:              p.collapsibleObjects,
+		PropertyChainUses:               p.propertyChainUses,
+		EscapingSymbols:                 p.escapingSymbols,
+		ConstObjectCandidates:           p.constObjectCandidates,
+		UnfrozenConstObjectCandidates:   p.unfrozenConstObjects,
 		ExprComments:                    p.exprComments,
+		TrailingComments:                p.trailingComments,
 		NestedScopeSlotCounts:           nestedScopeSlotCounts,
 		TopLevelSymbolToPartsFromParser: p.topLevelSymbolToParts,
 		ExportStarImportRecords:         p.exportStarImportRecords,
@@ -0,0 +1,976 @@
+package js_parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/compat"
+	"github.com/evanw/esbuild/internal/config"
+	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+// This file contains the JSX transforms for frameworks other than React.
+// React's transform turns each JSX element into a separate function call, so
+// it runs after the children of that element have been visited. Some of the
+// transforms here combine a whole tree of JSX elements into a single template
+// instead, so they visit the children of each element themselves.
+func (p *parser) visitJSXElementForTarget(loc logger.Loc, e *js_ast.EJSXElement) js_ast.Expr {
+	switch p.options.jsx.Target {
+	case config.JSXTargetSolid:
+		return p.lowerJSXToDOMExpressions(loc, e)
+
+	case config.JSXTargetVue:
+		return p.lowerJSXToVNode(loc, e)
+
+	case config.JSXTargetHTM:
+		return p.lowerJSXToTaggedTemplate(loc, e)
+	}
+	panic("Internal error")
+}
+
+func (p *parser) importJSXTargetSymbol(loc logger.Loc, name string) js_ast.Expr {
+	it, ok := p.jsxTargetImports[name]
+	if !ok {
+		it.Loc = loc
+		it.Ref = p.newSymbol(ast.SymbolOther, name)
+		p.moduleScope.Generated = append(p.moduleScope.Generated, it.Ref)
+		p.isImportItem[it.Ref] = true
+		p.jsxTargetImports[name] = it
+	}
+
+	p.recordUsage(it.Ref)
+	return p.handleIdentifier(loc, &js_ast.EIdentifier{Ref: it.Ref}, identifierOpts{
+		wasOriginallyIdentifier: true,
+	})
+}
+
+func (p *parser) callJSXTargetSymbol(loc logger.Loc, name string, args []js_ast.Expr) js_ast.Expr {
+	return js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+		Target: p.importJSXTargetSymbol(loc, name),
+		Args:   args,
+	}}
+}
+
+func jsxChildrenWithoutNils(children []js_ast.Expr) []js_ast.Expr {
+	result := make([]js_ast.Expr, 0, len(children))
+	for _, child := range children {
+		if child.Data != nil {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+func jsxPropertyName(property js_ast.Property) (string, bool) {
+	if str, ok := property.Key.Data.(*js_ast.EString); ok {
+		return helpers.UTF16ToString(str.Value), true
+	}
+	return "", false
+}
+
+func isConstantJSXLiteral(value js_ast.Expr) bool {
+	switch value.Data.(type) {
+	case *js_ast.EString, *js_ast.ENumber, *js_ast.EBigInt, *js_ast.EBoolean, *js_ast.ENull, *js_ast.EUndefined:
+		return true
+	}
+	return false
+}
+
+func jsxString(loc logger.Loc, text string) js_ast.Expr {
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(text)}}
+}
+
+// "value" => "() => value"
+func jsxArrow(loc logger.Loc, value js_ast.Expr) js_ast.Expr {
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EArrow{
+		Body:       js_ast.FnBody{Loc: loc, Block: js_ast.SBlock{Stmts: []js_ast.Stmt{{Loc: value.Loc, Data: &js_ast.SReturn{ValueOrNil: value}}}}},
+		PreferExpr: true,
+	}}
+}
+
+// "value" => "get name() { return value }"
+func jsxGetter(loc logger.Loc, key js_ast.Expr, value js_ast.Expr) js_ast.Property {
+	return js_ast.Property{
+		Kind:  js_ast.PropertyGet,
+		Flags: js_ast.PropertyIsMethod,
+		Loc:   loc,
+		Key:   key,
+		ValueOrNil: js_ast.Expr{Loc: value.Loc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+			Body: js_ast.FnBody{Loc: value.Loc, Block: js_ast.SBlock{Stmts: []js_ast.Stmt{{Loc: value.Loc, Data: &js_ast.SReturn{ValueOrNil: value}}}}},
+		}}},
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Solid
+
+// Solid only tracks reactive values that are read through property accesses
+// and function calls, so other values don't need to be wrapped in a function
+func isPossiblyReactiveJSXValue(value js_ast.Expr) bool {
+	switch value.Data.(type) {
+	case *js_ast.EString, *js_ast.ENumber, *js_ast.EBigInt, *js_ast.EBoolean, *js_ast.ENull, *js_ast.EUndefined,
+		*js_ast.EIdentifier, *js_ast.EImportIdentifier, *js_ast.EArrow, *js_ast.EFunction:
+		return false
+	}
+	return true
+}
+
+// These elements can't have children or closing tags in HTML
+var htmlVoidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"link":   true,
+	"meta":   true,
+	"param":  true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+}
+
+// These are set as DOM properties instead of as HTML attributes
+var domPropertyNames = map[string]bool{
+	"checked":       true,
+	"indeterminate": true,
+	"innerHTML":     true,
+	"innerText":     true,
+	"muted":         true,
+	"selected":      true,
+	"textContent":   true,
+	"value":         true,
+}
+
+var htmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;")
+var htmlAttributeEscaper = strings.NewReplacer("&", "&amp;", "\"", "&quot;")
+
+type domNodeKind uint8
+
+const (
+	domNodeElement domNodeKind = iota
+	domNodeText
+	domNodeMarker
+)
+
+// A node in the HTML template for a tree of JSX elements. Nodes that dynamic
+// content refers to are found by walking the template after it's cloned.
+type domNode struct {
+	kind       domNodeKind
+	tag        string
+	attributes string
+	text       string
+	children   []*domNode
+
+	// Changes to make to this element after it's created. These may refer to
+	// the references of other nodes, so they are run after all references
+	// have been generated.
+	updates []func() js_ast.Expr
+
+	loc      logger.Loc
+	ref      ast.Ref
+	needsRef bool
+}
+
+func (node *domNode) subtreeNeedsRef() bool {
+	if node.needsRef {
+		return true
+	}
+	for _, child := range node.children {
+		if child.subtreeNeedsRef() {
+			return true
+		}
+	}
+	return false
+}
+
+func (node *domNode) writeHTML(sb *strings.Builder) {
+	switch node.kind {
+	case domNodeText:
+		sb.WriteString(htmlTextEscaper.Replace(node.text))
+
+	case domNodeMarker:
+		sb.WriteString("<!>")
+
+	case domNodeElement:
+		sb.WriteString("<" + node.tag + node.attributes + ">")
+		if !htmlVoidElements[node.tag] {
+			for _, child := range node.children {
+				child.writeHTML(sb)
+			}
+			sb.WriteString("</" + node.tag + ">")
+		}
+	}
+}
+
+// Fragments become arrays, elements with a string tag become cloned HTML
+// templates, and other elements become calls to "createComponent":
+//
+//	"<div class="a">{b}</div>" => "(() => { var _el = _a(); insert(_el, b); return _el; })()"
+func (p *parser) lowerJSXToDOMExpressions(loc logger.Loc, e *js_ast.EJSXElement) js_ast.Expr {
+	if e.TagOrNil.Data == nil {
+		children := p.visitSolidChildren(e.NullableChildren)
+		if len(children) == 1 {
+			return children[0]
+		}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: children, IsSingleLine: e.IsTagSingleLine}}
+	}
+
+	if _, ok := e.TagOrNil.Data.(*js_ast.EString); ok {
+		return p.lowerJSXToDOMTemplate(loc, e)
+	}
+
+	return p.lowerJSXToSolidComponent(loc, e)
+}
+
+func (p *parser) visitSolidChildren(nullableChildren []js_ast.Expr) []js_ast.Expr {
+	children := jsxChildrenWithoutNils(nullableChildren)
+	for i, child := range children {
+		_, isJSX := child.Data.(*js_ast.EJSXElement)
+		child = p.visitExpr(child)
+		if !isJSX && isPossiblyReactiveJSXValue(child) {
+			child = jsxArrow(child.Loc, child)
+		}
+		children[i] = child
+	}
+	return children
+}
+
+func (p *parser) lowerJSXToSolidComponent(loc logger.Loc, e *js_ast.EJSXElement) js_ast.Expr {
+	tag := p.visitExpr(e.TagOrNil)
+	p.visitJSXProperties(e.Properties)
+
+	// Props that might be reactive are passed as getters so the component can
+	// track them. Spread props are merged with "mergeProps" for the same reason.
+	var sources []js_ast.Expr
+	var properties []js_ast.Property
+	flush := func() {
+		if len(properties) > 0 || len(sources) == 0 {
+			sources = append(sources, js_ast.Expr{Loc: loc, Data: &js_ast.EObject{Properties: properties, IsSingleLine: e.IsTagSingleLine}})
+			properties = nil
+		}
+	}
+	for _, property := range e.Properties {
+		if property.Kind == js_ast.PropertySpread {
+			if len(properties) > 0 {
+				flush()
+			}
+			sources = append(sources, property.ValueOrNil)
+		} else if isPossiblyReactiveJSXValue(property.ValueOrNil) {
+			properties = append(properties, jsxGetter(property.Loc, property.Key, property.ValueOrNil))
+		} else {
+			properties = append(properties, property)
+		}
+	}
+
+	// Children are also passed as a getter so they are created by the component
+	if children := jsxChildrenWithoutNils(e.NullableChildren); len(children) > 0 {
+		allStrings := true
+		for _, child := range children {
+			if _, ok := child.Data.(*js_ast.EString); !ok {
+				allStrings = false
+				break
+			}
+		}
+		children = p.visitSolidChildren(children)
+		value := children[0]
+		if len(children) > 1 {
+			value = js_ast.Expr{Loc: value.Loc, Data: &js_ast.EArray{Items: children, IsSingleLine: e.IsTagSingleLine}}
+		}
+		key := jsxString(value.Loc, "children")
+		if allStrings {
+			properties = append(properties, js_ast.Property{Kind: js_ast.PropertyNormal, Loc: value.Loc, Key: key, ValueOrNil: value})
+		} else {
+			properties = append(properties, jsxGetter(value.Loc, key, value))
+		}
+	}
+
+	flush()
+	props := sources[0]
+	if len(sources) > 1 {
+		props = p.callJSXTargetSymbol(loc, "mergeProps", sources)
+	}
+	return p.callJSXTargetSymbol(loc, "createComponent", []js_ast.Expr{tag, props})
+}
+
+func (p *parser) lowerJSXToDOMTemplate(loc logger.Loc, e *js_ast.EJSXElement) js_ast.Expr {
+	root := p.buildDOMNode(loc, e)
+	root.needsRef = true
+
+	// "var _a = /* @__PURE__ */ template("<div></div>")"
+	var sb strings.Builder
+	root.writeHTML(&sb)
+	templateRef := p.generateTopLevelTempRef(js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+		Target:                 p.importJSXTargetSymbol(loc, "template"),
+		Args:                   []js_ast.Expr{jsxString(loc, sb.String())},
+		CanBeUnwrappedIfUnused: !p.options.ignoreDCEAnnotations,
+	}})
+	p.recordUsage(templateRef)
+	clone := js_ast.Expr{Loc: loc, Data: &js_ast.ECall{Target: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: templateRef}}}}
+
+	// Nothing else is needed if the template never changes
+	if len(root.updates) == 0 && !hasDOMNodeThatNeedsRef(root.children) {
+		return clone
+	}
+
+	// Generate references to each node that's needed by walking the template.
+	// These are declared inside the function body of the IIFE below, so they
+	// need a scope of their own to be renamed correctly.
+	scope := &js_ast.Scope{Kind: js_ast.ScopeFunctionBody, Parent: p.currentScope}
+	p.currentScope.Children = append(p.currentScope.Children, scope)
+	p.scopesForCurrentPart = append(p.scopesForCurrentPart, scope)
+	newRef := func() ast.Ref {
+		name := "_el"
+		if n := len(scope.Generated); n > 0 {
+			name += strconv.Itoa(n + 1)
+		}
+		ref := p.newSymbol(ast.SymbolOther, name)
+		scope.Generated = append(scope.Generated, ref)
+		return ref
+	}
+	root.ref = newRef()
+	decls := []js_ast.Decl{{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: root.ref}}, ValueOrNil: clone}}
+	var declareChildren func(node *domNode)
+	declareChildren = func(node *domNode) {
+		last := -1
+		for i, child := range node.children {
+			if child.subtreeNeedsRef() {
+				last = i
+			}
+		}
+		for i := 0; i <= last; i++ {
+			child := node.children[i]
+			var value js_ast.Expr
+			if i == 0 {
+				p.recordUsage(node.ref)
+				value = js_ast.Expr{Loc: child.loc, Data: &js_ast.EDot{
+					Target:  js_ast.Expr{Loc: child.loc, Data: &js_ast.EIdentifier{Ref: node.ref}},
+					Name:    "firstChild",
+					NameLoc: child.loc,
+				}}
+			} else {
+				previous := node.children[i-1].ref
+				p.recordUsage(previous)
+				value = js_ast.Expr{Loc: child.loc, Data: &js_ast.EDot{
+					Target:  js_ast.Expr{Loc: child.loc, Data: &js_ast.EIdentifier{Ref: previous}},
+					Name:    "nextSibling",
+					NameLoc: child.loc,
+				}}
+			}
+			child.ref = newRef()
+			decls = append(decls, js_ast.Decl{Binding: js_ast.Binding{Loc: child.loc, Data: &js_ast.BIdentifier{Ref: child.ref}}, ValueOrNil: value})
+		}
+		for i := 0; i <= last; i++ {
+			declareChildren(node.children[i])
+		}
+	}
+	declareChildren(root)
+
+	// Apply the updates after all references have been generated
+	stmts := []js_ast.Stmt{{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: decls}}}
+	var applyUpdates func(node *domNode)
+	applyUpdates = func(node *domNode) {
+		for _, update := range node.updates {
+			value := update()
+			stmts = append(stmts, js_ast.Stmt{Loc: value.Loc, Data: &js_ast.SExpr{Value: value}})
+		}
+		for _, child := range node.children {
+			applyUpdates(child)
+		}
+	}
+	applyUpdates(root)
+	p.recordUsage(root.ref)
+	stmts = append(stmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: root.ref}}}})
+
+	// "(() => { ... })()"
+	return js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+		Target: js_ast.Expr{Loc: loc, Data: &js_ast.EArrow{
+			Body: js_ast.FnBody{Loc: loc, Block: js_ast.SBlock{Stmts: stmts}},
+		}},
+	}}
+}
+
+func hasDOMNodeThatNeedsRef(nodes []*domNode) bool {
+	for _, node := range nodes {
+		if node.subtreeNeedsRef() {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) domNodeRef(node *domNode) js_ast.Expr {
+	p.recordUsage(node.ref)
+	return js_ast.Expr{Loc: node.loc, Data: &js_ast.EIdentifier{Ref: node.ref}}
+}
+
+func (p *parser) buildDOMNode(loc logger.Loc, e *js_ast.EJSXElement) *domNode {
+	node := &domNode{kind: domNodeElement, loc: loc, tag: helpers.UTF16ToString(e.TagOrNil.Data.(*js_ast.EString).Value)}
+	hasChildren := len(jsxChildrenWithoutNils(e.NullableChildren)) > 0
+	p.visitJSXProperties(e.Properties)
+
+	// Static attributes are written into the template
+	var attributes strings.Builder
+	for _, property := range e.Properties {
+		property := property
+		if property.Kind == js_ast.PropertySpread {
+			node.needsRef = true
+			node.updates = append(node.updates, func() js_ast.Expr {
+				return p.callJSXTargetSymbol(property.Loc, "spread", []js_ast.Expr{
+					p.domNodeRef(node),
+					property.ValueOrNil,
+					{Loc: property.Loc, Data: &js_ast.EBoolean{Value: false}},
+					{Loc: property.Loc, Data: &js_ast.EBoolean{Value: hasChildren}},
+				})
+			})
+			continue
+		}
+
+		name, ok := jsxPropertyName(property)
+		if !ok {
+			// This key was renamed by property mangling, so assign it as a property
+			node.needsRef = true
+			node.updates = append(node.updates, func() js_ast.Expr {
+				return js_ast.Assign(js_ast.Expr{Loc: property.Loc, Data: &js_ast.EIndex{
+					Target: p.domNodeRef(node),
+					Index:  property.Key,
+				}}, property.ValueOrNil)
+			})
+			continue
+		}
+		switch name {
+		case "className":
+			name = "class"
+		case "htmlFor":
+			name = "for"
+		}
+
+		switch value := property.ValueOrNil.Data.(type) {
+		case *js_ast.EString:
+			if name != "ref" && !strings.HasPrefix(name, "on") && !strings.HasPrefix(name, "prop:") && !domPropertyNames[name] {
+				attributes.WriteString(" " + strings.TrimPrefix(name, "attr:") + "=\"" + htmlAttributeEscaper.Replace(helpers.UTF16ToString(value.Value)) + "\"")
+				continue
+			}
+
+		case *js_ast.EBoolean:
+			if !strings.HasPrefix(name, "prop:") && !domPropertyNames[name] {
+				if value.Value {
+					attributes.WriteString(" " + strings.TrimPrefix(name, "attr:"))
+				}
+				continue
+			}
+
+		case *js_ast.ENull, *js_ast.EUndefined:
+			continue
+		}
+
+		node.needsRef = true
+		node.updates = append(node.updates, p.solidAttributeUpdate(node, name, property))
+	}
+	node.attributes = attributes.String()
+
+	// Children that are JSX elements with a string tag become part of the
+	// template, and everything else is inserted after the template is cloned
+	type dynamicChild struct {
+		value js_ast.Expr
+		index int
+	}
+	var dynamicChildren []dynamicChild
+	for _, child := range jsxChildrenWithoutNils(e.NullableChildren) {
+		switch c := child.Data.(type) {
+		case *js_ast.EString:
+			text := helpers.UTF16ToString(c.Value)
+			if n := len(node.children); n > 0 && node.children[n-1] != nil && node.children[n-1].kind == domNodeText {
+				node.children[n-1].text += text
+			} else {
+				node.children = append(node.children, &domNode{kind: domNodeText, loc: child.Loc, text: text})
+			}
+			continue
+
+		case *js_ast.EBoolean, *js_ast.ENull, *js_ast.EUndefined:
+			continue
+
+		case *js_ast.EJSXElement:
+			if _, ok := c.TagOrNil.Data.(*js_ast.EString); ok {
+				node.children = append(node.children, p.buildDOMNode(child.Loc, c))
+				continue
+			}
+			dynamicChildren = append(dynamicChildren, dynamicChild{value: p.visitExpr(child), index: len(node.children)})
+
+		default:
+			value := p.visitExpr(child)
+			if isPossiblyReactiveJSXValue(value) {
+				value = jsxArrow(value.Loc, value)
+			}
+			dynamicChildren = append(dynamicChildren, dynamicChild{value: value, index: len(node.children)})
+		}
+
+		// Reserve a spot for the dynamic child
+		node.children = append(node.children, nil)
+	}
+
+	// Each dynamic child is inserted before a marker node. The marker is the
+	// next element if there is one. Otherwise it's an empty comment, which
+	// also keeps adjacent text nodes apart. The last child doesn't need a
+	// marker because a marker of "null" appends to the end.
+	if len(dynamicChildren) == 1 && len(node.children) == 1 {
+		node.children = nil
+		node.needsRef = true
+		value := dynamicChildren[0].value
+		node.updates = append(node.updates, func() js_ast.Expr {
+			return p.callJSXTargetSymbol(value.Loc, "insert", []js_ast.Expr{p.domNodeRef(node), value})
+		})
+	} else if len(dynamicChildren) > 0 {
+		children := make([]*domNode, 0, len(node.children)+len(dynamicChildren))
+		markers := make(map[int]*domNode)
+		for i, child := range node.children {
+			if child == nil {
+				var marker *domNode
+				if next := i + 1; next == len(node.children) {
+					continue
+				} else if node.children[next] != nil && node.children[next].kind == domNodeElement {
+					marker = node.children[next]
+				} else {
+					marker = &domNode{kind: domNodeMarker, loc: node.loc}
+					children = append(children, marker)
+				}
+				marker.needsRef = true
+				markers[i] = marker
+				continue
+			}
+			children = append(children, child)
+		}
+		node.children = children
+		node.needsRef = true
+		for _, dynamic := range dynamicChildren {
+			value := dynamic.value
+			marker := markers[dynamic.index]
+			node.updates = append(node.updates, func() js_ast.Expr {
+				markerOrNull := js_ast.Expr{Loc: value.Loc, Data: js_ast.ENullShared}
+				if marker != nil {
+					markerOrNull = p.domNodeRef(marker)
+				}
+				return p.callJSXTargetSymbol(value.Loc, "insert", []js_ast.Expr{p.domNodeRef(node), value, markerOrNull})
+			})
+		}
+	}
+
+	return node
+}
+
+func (p *parser) solidAttributeUpdate(node *domNode, name string, property js_ast.Property) func() js_ast.Expr {
+	loc := property.Loc
+	value := property.ValueOrNil
+
+	// Event handlers are only attached once
+	if event := strings.TrimPrefix(name, "on:"); event != name || (len(name) > 2 && strings.HasPrefix(name, "on") && name[2] >= 'A' && name[2] <= 'Z') {
+		if event == name {
+			event = strings.ToLower(name[2:])
+		}
+		return func() js_ast.Expr {
+			return js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+				Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: p.domNodeRef(node), Name: "addEventListener", NameLoc: loc}},
+				Args:   []js_ast.Expr{jsxString(loc, event), value},
+				Kind:   js_ast.TargetWasOriginallyPropertyAccess,
+			}}
+		}
+	}
+
+	// "ref={a}" either calls "a" with the element or assigns the element to "a"
+	if name == "ref" {
+		return func() js_ast.Expr {
+			if id, ok := value.Data.(*js_ast.EIdentifier); ok {
+				p.recordUsage(id.Ref)
+				p.recordUsage(id.Ref)
+				return js_ast.Expr{Loc: loc, Data: &js_ast.EIf{
+					Test: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+						Op:    js_ast.BinOpStrictEq,
+						Left:  js_ast.Expr{Loc: loc, Data: &js_ast.EUnary{Op: js_ast.UnOpTypeof, Value: value, WasOriginallyTypeofIdentifier: true}},
+						Right: jsxString(loc, "function"),
+					}},
+					Yes: js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+						Target: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: id.Ref}},
+						Args:   []js_ast.Expr{p.domNodeRef(node)},
+					}},
+					No: js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: id.Ref}}, p.domNodeRef(node)),
+				}}
+			}
+			return js_ast.Expr{Loc: loc, Data: &js_ast.ECall{Target: value, Args: []js_ast.Expr{p.domNodeRef(node)}}}
+		}
+	}
+
+	// Everything else is updated whenever it changes
+	var update func() js_ast.Expr
+	if prop := strings.TrimPrefix(name, "prop:"); prop != name || domPropertyNames[name] {
+		update = func() js_ast.Expr {
+			return js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: p.domNodeRef(node), Name: prop, NameLoc: loc}}, value)
+		}
+	} else if name == "style" {
+		update = func() js_ast.Expr {
+			return p.callJSXTargetSymbol(loc, "style", []js_ast.Expr{p.domNodeRef(node), value})
+		}
+	} else {
+		attribute := strings.TrimPrefix(name, "attr:")
+		update = func() js_ast.Expr {
+			return p.callJSXTargetSymbol(loc, "setAttribute", []js_ast.Expr{p.domNodeRef(node), jsxString(loc, attribute), value})
+		}
+	}
+	if !isPossiblyReactiveJSXValue(value) {
+		return update
+	}
+	return func() js_ast.Expr {
+		return p.callJSXTargetSymbol(loc, "effect", []js_ast.Expr{jsxArrow(loc, update())})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// Vue
+
+// See "PatchFlags" in Vue's source code
+const (
+	vuePatchFlagText      = 1 << 0
+	vuePatchFlagClass     = 1 << 1
+	vuePatchFlagStyle     = 1 << 2
+	vuePatchFlagProps     = 1 << 3
+	vuePatchFlagFullProps = 1 << 4
+	vuePatchFlagNeedPatch = 1 << 9
+)
+
+// Strings, numbers, and bigints are rendered as text. Arithmetic is included
+// even if the exact type isn't known since it always evaluates to one of them.
+func isVueTextValue(value js_ast.Expr) bool {
+	switch js_ast.KnownPrimitiveType(value.Data) {
+	case js_ast.PrimitiveString, js_ast.PrimitiveNumber, js_ast.PrimitiveBigInt:
+		return true
+
+	case js_ast.PrimitiveMixed:
+		switch e := value.Data.(type) {
+		case *js_ast.EUnary:
+			return true
+		case *js_ast.EBinary:
+			switch e.Op {
+			case js_ast.BinOpLogicalOr, js_ast.BinOpLogicalAnd, js_ast.BinOpNullishCoalescing, js_ast.BinOpComma, js_ast.BinOpAssign,
+				js_ast.BinOpLogicalOrAssign, js_ast.BinOpLogicalAndAssign, js_ast.BinOpNullishCoalescingAssign:
+				return false
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Children that are all text with at least one value that might change are
+// passed as a single string so Vue only has to compare the text. Values that
+// aren't known to be text might be VNodes and are left alone.
+//
+//	"<div>a {`${b}`}</div>" => "createVNode("div", null, `a ${b}`, 1)"
+func vueDynamicText(children []js_ast.Expr) (js_ast.Expr, bool) {
+	isDynamic := false
+	for _, child := range children {
+		if _, ok := child.Data.(*js_ast.EString); ok {
+			continue
+		}
+		if !isVueTextValue(child) {
+			return js_ast.Expr{}, false
+		}
+		if !isConstantJSXLiteral(child) {
+			isDynamic = true
+		}
+	}
+	if !isDynamic {
+		return js_ast.Expr{}, false
+	}
+	if len(children) == 1 {
+		return children[0], true
+	}
+
+	// Join the children into a template literal
+	template := &js_ast.ETemplate{HeadLoc: children[0].Loc}
+	cooked := &template.HeadCooked
+	for _, child := range children {
+		if str, ok := child.Data.(*js_ast.EString); ok {
+			*cooked = append(*cooked, str.Value...)
+			continue
+		}
+		template.Parts = append(template.Parts, js_ast.TemplatePart{Value: child, TailLoc: child.Loc})
+		cooked = &template.Parts[len(template.Parts)-1].TailCooked
+	}
+	return js_ast.Expr{Loc: children[0].Loc, Data: template}, true
+}
+
+// "<div class={a}>b</div>" => "createVNode("div", { class: a }, "b", 2)"
+func (p *parser) lowerJSXToVNode(loc logger.Loc, e *js_ast.EJSXElement) js_ast.Expr {
+	var tag js_ast.Expr
+	isComponent := false
+	switch e.TagOrNil.Data.(type) {
+	case nil:
+		tag = p.importJSXTargetSymbol(loc, "Fragment")
+	case *js_ast.EString:
+		tag = e.TagOrNil
+	default:
+		tag = p.visitExpr(e.TagOrNil)
+		isComponent = true
+	}
+	hasSpread := p.visitJSXProperties(e.Properties)
+	children := jsxChildrenWithoutNils(e.NullableChildren)
+	for i, child := range children {
+		children[i] = p.visitExpr(child)
+	}
+
+	// Vue only needs to compare the props that might change
+	patchFlag := 0
+	needsPatch := false
+	var dynamicProps []js_ast.Expr
+	for _, property := range e.Properties {
+		if property.Kind == js_ast.PropertySpread || isConstantJSXLiteral(property.ValueOrNil) {
+			continue
+		}
+		name, ok := jsxPropertyName(property)
+		if !ok {
+			hasSpread = true
+			continue
+		}
+		switch {
+		case name == "key":
+		case name == "ref":
+			needsPatch = true
+		case name == "class" && !isComponent:
+			patchFlag |= vuePatchFlagClass
+		case name == "style" && !isComponent:
+			patchFlag |= vuePatchFlagStyle
+		default:
+			patchFlag |= vuePatchFlagProps
+			dynamicProps = append(dynamicProps, jsxString(property.Key.Loc, name))
+		}
+	}
+	if hasSpread {
+		patchFlag = vuePatchFlagFullProps
+		dynamicProps = nil
+	} else if patchFlag == 0 && needsPatch {
+		patchFlag = vuePatchFlagNeedPatch
+	}
+
+	// Props with spreads are merged with "mergeProps" so "class", "style", and
+	// event handlers are combined instead of overwritten
+	props := js_ast.Expr{Loc: loc, Data: js_ast.ENullShared}
+	if len(e.Properties) > 0 {
+		if hasSpread {
+			var sources []js_ast.Expr
+			var properties []js_ast.Property
+			for _, property := range e.Properties {
+				if property.Kind == js_ast.PropertySpread {
+					if len(properties) > 0 {
+						sources = append(sources, js_ast.Expr{Loc: loc, Data: &js_ast.EObject{Properties: properties, IsSingleLine: e.IsTagSingleLine}})
+						properties = nil
+					}
+					sources = append(sources, property.ValueOrNil)
+				} else {
+					properties = append(properties, property)
+				}
+			}
+			if len(properties) > 0 {
+				sources = append(sources, js_ast.Expr{Loc: loc, Data: &js_ast.EObject{Properties: properties, IsSingleLine: e.IsTagSingleLine}})
+			}
+			props = p.callJSXTargetSymbol(loc, "mergeProps", sources)
+		} else {
+			props = js_ast.Expr{Loc: loc, Data: &js_ast.EObject{Properties: e.Properties, IsSingleLine: e.IsTagSingleLine}}
+		}
+	}
+
+	// Components receive their children as a "default" slot
+	childrenArg := js_ast.Expr{Loc: loc, Data: js_ast.ENullShared}
+	if len(children) > 0 {
+		array := js_ast.Expr{Loc: children[0].Loc, Data: &js_ast.EArray{Items: children, IsSingleLine: e.IsTagSingleLine}}
+		if isComponent {
+			if _, ok := children[0].Data.(*js_ast.EObject); ok && len(children) == 1 {
+				childrenArg = children[0]
+			} else {
+				childrenArg = js_ast.Expr{Loc: array.Loc, Data: &js_ast.EObject{
+					Properties: []js_ast.Property{{
+						Kind:       js_ast.PropertyNormal,
+						Loc:        array.Loc,
+						Key:        jsxString(array.Loc, "default"),
+						ValueOrNil: jsxArrow(array.Loc, array),
+					}},
+					IsSingleLine: e.IsTagSingleLine,
+				}}
+			}
+		} else if _, ok := children[0].Data.(*js_ast.EString); ok && len(children) == 1 && e.TagOrNil.Data != nil {
+			childrenArg = children[0]
+		} else if text, ok := vueDynamicText(children); ok && e.TagOrNil.Data != nil {
+			childrenArg = text
+			patchFlag |= vuePatchFlagText
+		} else {
+			childrenArg = array
+		}
+	}
+
+	// Omit trailing arguments that have the default value
+	args := []js_ast.Expr{tag, props, childrenArg}
+	if patchFlag != 0 {
+		args = append(args, js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: float64(patchFlag)}})
+		if len(dynamicProps) > 0 {
+			args = append(args, js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: dynamicProps, IsSingleLine: true}})
+		}
+	} else if len(children) == 0 {
+		args = args[:2]
+		if len(e.Properties) == 0 {
+			args = args[:1]
+		}
+	}
+
+	return js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+		Target:        p.importJSXTargetSymbol(loc, "createVNode"),
+		Args:          args,
+		CloseParenLoc: e.CloseLoc,
+		IsMultiLine:   !e.IsTagSingleLine,
+
+		// Enable tree shaking
+		CanBeUnwrappedIfUnused: !p.options.ignoreDCEAnnotations && !p.options.jsx.SideEffects,
+	}}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// htm
+
+type taggedTemplateBuilder struct {
+	parts  []js_ast.TemplatePart
+	head   string
+	cooked []string
+}
+
+// Escape anything that would end the template or start a substitution
+var templateRawEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${")
+
+func (b *taggedTemplateBuilder) appendText(text string) {
+	if n := len(b.parts); n > 0 {
+		b.parts[n-1].TailRaw += templateRawEscaper.Replace(text)
+	} else {
+		b.head += templateRawEscaper.Replace(text)
+	}
+	b.cooked[len(b.cooked)-1] += text
+}
+
+func (b *taggedTemplateBuilder) appendValue(value js_ast.Expr) {
+	b.parts = append(b.parts, js_ast.TemplatePart{Value: value, TailLoc: value.Loc})
+	b.cooked = append(b.cooked, "")
+}
+
+// Text is only written into the template when "htm" will read it back as the
+// same string. Everything else is passed as a substitution instead.
+func isSafeHTMText(text string) bool {
+	return !strings.ContainsAny(text, "<>\"'\r\n")
+}
+
+// "<div class={a}>b</div>" => "html`<div class=${a}>b</div>`"
+func (p *parser) lowerJSXToTaggedTemplate(loc logger.Loc, e *js_ast.EJSXElement) js_ast.Expr {
+	b := taggedTemplateBuilder{cooked: []string{""}}
+	p.appendJSXToTaggedTemplate(&b, e)
+	for i := range b.parts {
+		b.parts[i].TailCooked = helpers.StringToUTF16(b.cooked[i+1])
+	}
+
+	tag := p.instantiateDefineExpr(loc, p.options.jsx.Factory, identifierOpts{
+		wasOriginallyIdentifier: true,
+		matchAgainstDefines:     true, // Allow defines to rewrite the JSX factory
+	})
+	template := &js_ast.ETemplate{
+		TagOrNil:                       tag,
+		HeadLoc:                        loc,
+		HeadRaw:                        b.head,
+		HeadCooked:                     helpers.StringToUTF16(b.cooked[0]),
+		Parts:                          b.parts,
+		TagWasOriginallyPropertyAccess: js_ast.IsPropertyAccess(tag),
+	}
+	if p.options.unsupportedJSFeatures.Has(compat.TemplateLiteral) {
+		return p.lowerTemplateLiteral(loc, template, nil, nil)
+	}
+	return js_ast.Expr{Loc: loc, Data: template}
+}
+
+func (p *parser) appendJSXToTaggedTemplate(b *taggedTemplateBuilder, e *js_ast.EJSXElement) {
+	children := jsxChildrenWithoutNils(e.NullableChildren)
+	appendChildren := func() {
+		for _, child := range children {
+			switch c := child.Data.(type) {
+			case *js_ast.EString:
+				if text := helpers.UTF16ToString(c.Value); isSafeHTMText(text) {
+					b.appendText(text)
+					continue
+				}
+
+			case *js_ast.EJSXElement:
+				p.appendJSXToTaggedTemplate(b, c)
+				continue
+			}
+			b.appendValue(p.visitExpr(child))
+		}
+	}
+
+	// Fragments are just their children
+	if e.TagOrNil.Data == nil {
+		appendChildren()
+		return
+	}
+
+	// "<div>" or "<${Foo}>"
+	var tagName string
+	b.appendText("<")
+	if str, ok := e.TagOrNil.Data.(*js_ast.EString); ok {
+		tagName = helpers.UTF16ToString(str.Value)
+		b.appendText(tagName)
+	} else {
+		b.appendValue(p.visitExpr(e.TagOrNil))
+	}
+
+	p.visitJSXProperties(e.Properties)
+	for _, property := range e.Properties {
+		if property.Kind == js_ast.PropertySpread {
+			b.appendText(" ...")
+			b.appendValue(property.ValueOrNil)
+			continue
+		}
+		name, ok := jsxPropertyName(property)
+		if !ok {
+			// Property mangling renamed this key, so it must be spread in instead
+			b.appendText(" ...")
+			b.appendValue(js_ast.Expr{Loc: property.Loc, Data: &js_ast.EObject{Properties: []js_ast.Property{property}, IsSingleLine: true}})
+			continue
+		}
+		switch value := property.ValueOrNil.Data.(type) {
+		case *js_ast.EString:
+			if text := helpers.UTF16ToString(value.Value); isSafeHTMText(text) {
+				b.appendText(" " + name + "=\"" + text + "\"")
+				continue
+			}
+
+		case *js_ast.EBoolean:
+			if value.Value && property.Flags.Has(js_ast.PropertyWasShorthand) {
+				b.appendText(" " + name)
+				continue
+			}
+		}
+		b.appendText(" " + name + "=")
+		b.appendValue(property.ValueOrNil)
+	}
+
+	if len(children) == 0 {
+		b.appendText(" />")
+		return
+	}
+	b.appendText(">")
+	appendChildren()
+
+	// "</div>" or "<//>"
+	if tagName != "" {
+		b.appendText("</" + tagName + ">")
+	} else {
+		b.appendText("<//>")
+	}
+}
@@ -6,12 +6,14 @@ package js_parser
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/evanw/esbuild/internal/ast"
 	"github.com/evanw/esbuild/internal/compat"
 	"github.com/evanw/esbuild/internal/config"
 	"github.com/evanw/esbuild/internal/helpers"
 	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
 	"github.com/evanw/esbuild/internal/logger"
 )
 
@@ -42,13 +44,29 @@ func (p *parser) prettyPrintTargetEnvironment(feature compat.JSFeature) (where s
 func (p *parser) markSyntaxFeature(feature compat.JSFeature, r logger.Range) (didGenerateError bool) {
 	didGenerateError = true
 
-	if !p.options.unsupportedJSFeatures.Has(feature) {
-		if feature == compat.TopLevelAwait && !p.options.outputFormat.KeepESMImportExportSyntax() {
+	if feature == compat.TopLevelAwait && !p.options.outputFormat.KeepESMImportExportSyntax() {
+		if p.options.mode != config.ModeBundle {
 			p.log.AddError(&p.tracker, r, fmt.Sprintf(
 				"Top-level await is currently not supported with the %q output format", p.options.outputFormat.String()))
 			return
 		}
 
+		// When bundling, the linker moves the top-level await into an async module
+		// initializer. That only requires support for async functions, not for
+		// top-level await itself. Async functions are lowered to generators, but
+		// esbuild can't lower generators, so at least those must be supported.
+		if !p.options.unsupportedJSFeatures.Has(compat.AsyncAwait) || !p.options.unsupportedJSFeatures.Has(compat.Generator) {
+			didGenerateError = false
+			return
+		}
+		where, notes := p.prettyPrintTargetEnvironment(compat.Generator)
+		p.log.AddErrorWithNotes(&p.tracker, r, fmt.Sprintf(
+			"Top-level await with the %q output format requires generator functions, which are not available in %s",
+			p.options.outputFormat.String(), where), notes)
+		return
+	}
+
+	if !p.options.unsupportedJSFeatures.Has(feature) {
 		didGenerateError = false
 		return
 	}
@@ -57,55 +75,16 @@ func (p *parser) markSyntaxFeature(feature compat.JSFeature, r logger.Range) (di
 	where, notes := p.prettyPrintTargetEnvironment(feature)
 
 	switch feature {
-	case compat.DefaultArgument:
-		name = "default arguments"
-
 	case compat.RestArgument:
 		name = "rest arguments"
 
-	case compat.ArraySpread:
-		name = "array spread"
-
-	case compat.ForOf:
-		name = "for-of loops"
-
 	case compat.ObjectAccessors:
 		name = "object accessors"
 
-	case compat.ObjectExtensions:
-		name = "object literal extensions"
-
-	case compat.Destructuring:
-		name = "destructuring"
-
 	case compat.NewTarget:
 		name = "new.target"
 
-	case compat.ConstAndLet:
-		name = p.source.TextForRange(r)
-
-	case compat.Class:
-		name = "class syntax"
-
-	case compat.Generator:
-		name = "generator functions"
-
-	case compat.AsyncAwait:
-		name = "async functions"
-
-	case compat.AsyncGenerator:
-		name = "async generator functions"
-
-	case compat.ForAwait:
-		name = "for-await loops"
-
-	case compat.NestedRestBinding:
-		name = "non-identifier array rest patterns"
-
-	case compat.Decorators:
-		name = "JavaScript decorators"
-
-	case compat.ImportAssertions:
+	case compat.ImportAttributes:
 		p.log.AddErrorWithNotes(&p.tracker, r, fmt.Sprintf(
 			"Using an arbitrary value as the second argument to \"import()\" is not possible in %s", where), notes)
 		return
@@ -147,6 +126,39 @@ func (p *parser) markSyntaxFeature(feature compat.JSFeature, r logger.Range) (di
 	return
 }
 
+// Lowering can only handle syntax. Built-in APIs that are missing from the
+// target environment are handled by importing a polyfill for them instead.
+// Uses inside dead code don't need a polyfill.
+func (p *parser) markBuiltInUse(builtIns compat.BuiltIn) {
+	if builtIns &= p.options.unsupportedBuiltIns; builtIns != 0 && !p.isControlFlowDead {
+		p.usedBuiltIns |= builtIns
+	}
+}
+
+func (p *parser) markBuiltInPropertyUse(target js_ast.Expr, name string) {
+	if p.options.unsupportedBuiltIns == 0 {
+		return
+	}
+
+	// We don't know the type of the target, so assume that any property with
+	// a matching name could be a use of that built-in (e.g. "x.at(-1)" could
+	// be either "Array.prototype.at" or "String.prototype.at")
+	builtIns := compat.BuiltInsForInstanceMember(name)
+
+	// Static methods are only detected when they are on the global object
+	if id, ok := target.Data.(*js_ast.EIdentifier); ok {
+		if symbol := &p.symbols[id.Ref.InnerIndex]; symbol.Kind == ast.SymbolUnbound {
+			builtIns |= compat.BuiltInsForStaticMember(symbol.OriginalName, name)
+		}
+	}
+
+	p.markBuiltInUse(builtIns)
+}
+
+func isInsidePackage(path string, name string) bool {
+	return strings.Contains(strings.ReplaceAll(path, "\\", "/"), "/node_modules/"+name+"/")
+}
+
 func (p *parser) isStrictMode() bool {
 	return p.currentScope.StrictMode != js_ast.SloppyMode
 }
@@ -253,22 +265,6 @@ func (p *parser) whyStrictMode(scope *js_ast.Scope) (where string, notes []logge
 	return
 }
 
-func (p *parser) markAsyncFn(asyncRange logger.Range, isGenerator bool) (didGenerateError bool) {
-	// Lowered async functions are implemented in terms of generators. So if
-	// generators aren't supported, async functions aren't supported either.
-	// But if generators are supported, then async functions are unconditionally
-	// supported because we can use generators to implement them.
-	if !p.options.unsupportedJSFeatures.Has(compat.Generator) {
-		return false
-	}
-
-	feature := compat.AsyncAwait
-	if isGenerator {
-		feature = compat.AsyncGenerator
-	}
-	return p.markSyntaxFeature(feature, asyncRange)
-}
-
 func (p *parser) captureThis() ast.Ref {
 	if p.fnOnlyDataVisit.thisCaptureRef == nil {
 		ref := p.newSymbol(ast.SymbolHoisted, "_this")
@@ -301,8 +297,10 @@ func (p *parser) lowerFunction(
 	hasRestArg *bool,
 	isArrow bool,
 ) {
-	// Lower object rest binding patterns in function arguments
-	if p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
+	// Lower object rest binding patterns in function arguments. Default values
+	// and rest arguments are lowered later on since they may need to stay with
+	// the arguments if they are moved into a nested generator function below.
+	if !p.options.unsupportedJSFeatures.Has(compat.Destructuring) && p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
 		var prefixStmts []js_ast.Stmt
 
 		// Lower each argument individually instead of lowering all arguments
@@ -336,7 +334,7 @@ func (p *parser) lowerFunction(
 		// thinking that perhaps scope matters more in real-world code than side
 		// effect order.
 		for i, arg := range *args {
-			if bindingHasObjectRest(arg.Binding) {
+			if p.bindingNeedsLowering(arg.Binding) {
 				ref := p.generateTempRef(tempRefNoDeclare, "")
 				target := js_ast.ConvertBindingToExpr(arg.Binding, nil)
 				init := js_ast.Expr{Loc: arg.Binding.Loc, Data: &js_ast.EIdentifier{Ref: ref}}
@@ -451,7 +449,14 @@ func (p *parser) lowerFunction(
 			// Forward all arguments from the outer function to the inner function
 			if !isArrow {
 				// Normal functions can just use "arguments" to forward everything
-				forwardedArgs = js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: *p.fnOnlyDataVisit.argumentsRef}}
+				argumentsRef := *p.fnOnlyDataVisit.argumentsRef
+				if p.options.unsupportedJSFeatures.Has(compat.Generator) {
+					// The "arguments" symbol will be turned into a captured variable when
+					// the inner generator function is lowered, so use a separate symbol
+					argumentsRef = p.newSymbol(ast.SymbolUnbound, "arguments")
+					p.moduleScope.Generated = append(p.moduleScope.Generated, argumentsRef)
+				}
+				forwardedArgs = js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: argumentsRef}}
 			} else {
 				// Arrow functions can't use "arguments", so we need to forward
 				// the arguments manually.
@@ -484,7 +489,7 @@ func (p *parser) lowerFunction(
 					}
 					items = append(items, item)
 				}
-				forwardedArgs = js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EArray{Items: items, IsSingleLine: true}}
+				forwardedArgs = p.lowerArraySpread(bodyLoc, items, true)
 			}
 		}
 
@@ -498,6 +503,18 @@ func (p *parser) lowerFunction(
 			name = "__async"
 		}
 		*isAsync = false
+
+		// Lower the inner generator function too if generators are unsupported
+		if p.options.unsupportedJSFeatures.Has(compat.Generator) {
+			fn.Body.Block.Stmts = p.lowerGeneratorBody(bodyLoc, fn.Body.Block.Stmts, p.generatorArgumentsRef(isArrow))
+			fn.IsGenerator = false
+		}
+
+		// Any arguments that were moved to the inner function may also need to be lowered
+		if prefixStmts := p.lowerFunctionArgs(&fn.Args, &fn.HasRestArg, false /* isArrow */); len(prefixStmts) > 0 {
+			fn.Body.Block.Stmts = append(prefixStmts, fn.Body.Block.Stmts...)
+		}
+
 		callAsync := p.callRuntime(bodyLoc, name, []js_ast.Expr{
 			thisValue,
 			forwardedArgs,
@@ -505,6 +522,167 @@ func (p *parser) lowerFunction(
 		})
 		bodyBlock.Stmts = []js_ast.Stmt{{Loc: bodyLoc, Data: &js_ast.SReturn{ValueOrNil: callAsync}}}
 	}
+
+	// Lower generator functions
+	if isGenerator != nil && *isGenerator && p.options.unsupportedJSFeatures.Has(compat.Generator) {
+		bodyBlock.Stmts = p.lowerGeneratorBody(bodyLoc, bodyBlock.Stmts, p.generatorArgumentsRef(isArrow))
+		*isGenerator = false
+	}
+
+	// Lower default values, binding patterns, and rest arguments. This is done
+	// last so that the code evaluating them is not moved into a generator's
+	// state machine, which would delay it until the first call to "next()".
+	if prefixStmts := p.lowerFunctionArgs(args, hasRestArg, isArrow); len(prefixStmts) > 0 {
+		bodyBlock.Stmts = append(prefixStmts, bodyBlock.Stmts...)
+		if preferExpr != nil {
+			*preferExpr = false
+		}
+	}
+}
+
+// This moves default values, binding patterns, and rest arguments that can't
+// be used in the target environment into the function body. Arguments from
+// the first one with a default value onward are read from "arguments" so that
+// the "length" property of the function doesn't change. This is the same
+// approach that Babel takes:
+//
+//	// Original code
+//	function foo([a, b], c = 1, {d}, ...e) {}
+//
+//	// Lowered code
+//	function foo(_a) {
+//	  var a = _a[0], b = _a[1];
+//	  var c = arguments[1] === void 0 ? 1 : arguments[1];
+//	  var _b = arguments[2], d = _b.d;
+//	  var e = __slice(arguments, 3);
+//	}
+//
+// Arrow functions don't have their own "arguments", so default values are
+// assigned to the arguments in the body instead if the arrow function is kept.
+func (p *parser) lowerFunctionArgs(args *[]js_ast.Arg, hasRestArg *bool, isArrow bool) (prefixStmts []js_ast.Stmt) {
+	lowerDefaults := p.options.unsupportedJSFeatures.Has(compat.DefaultArgument)
+	canUseArguments := !isArrow || p.options.unsupportedJSFeatures.Has(compat.Arrow)
+
+	// Find the first argument that doesn't count towards the "length" property
+	firstDefault := -1
+	if lowerDefaults && canUseArguments {
+		for i, arg := range *args {
+			if arg.DefaultOrNil.Data != nil {
+				firstDefault = i
+				break
+			}
+		}
+	}
+
+	argumentsRef := ast.InvalidRef
+	argumentsAt := func(loc logger.Loc, index int) js_ast.Expr {
+		if argumentsRef == ast.InvalidRef {
+			argumentsRef = p.newSymbol(ast.SymbolUnbound, "arguments")
+			p.moduleScope.Generated = append(p.moduleScope.Generated, argumentsRef)
+		}
+		value := js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: argumentsRef}}
+		if index < 0 {
+			return value
+		}
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{Target: value, Index: js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: float64(index)}}}}
+	}
+
+	for i := range *args {
+		arg := &(*args)[i]
+		loc := arg.Binding.Loc
+
+		// "function foo(a, ...b) {}" => "function foo(a) { var b = __slice(arguments, 1) }"
+		if *hasRestArg && i+1 == len(*args) && (p.options.unsupportedJSFeatures.Has(compat.RestArgument) || firstDefault != -1) {
+			sliceArgs := []js_ast.Expr{argumentsAt(loc, -1)}
+			if i > 0 {
+				sliceArgs = append(sliceArgs, js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: float64(i)}})
+			}
+			decls := p.lowerObjectRestInDecls([]js_ast.Decl{{Binding: arg.Binding, ValueOrNil: p.callRuntime(loc, "__slice", sliceArgs)}})
+			prefixStmts = append(prefixStmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: decls}})
+			*args = (*args)[:i]
+			*hasRestArg = false
+			break
+		}
+
+		// "function foo(a, b = 1, c) {}" => "function foo(a) { var b = arguments[1] === void 0 ? 1 : arguments[1], c = arguments[2] }"
+		if firstDefault != -1 && i >= firstDefault {
+			value := argumentsAt(loc, i)
+			if arg.DefaultOrNil.Data != nil {
+				value.Data = &js_ast.EIf{
+					Test: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+						Op:    js_ast.BinOpStrictEq,
+						Left:  argumentsAt(loc, i),
+						Right: js_ast.Expr{Loc: loc, Data: js_ast.EUndefinedShared},
+					}},
+					Yes: arg.DefaultOrNil,
+					No:  value,
+				}
+			}
+			if _, ok := arg.Binding.Data.(*js_ast.BIdentifier); !ok {
+				ref := p.generateTempRef(tempRefNoDeclare, "")
+				p.recordUsage(ref)
+				prefixStmts = append(prefixStmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: append(
+					[]js_ast.Decl{{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: ref}}, ValueOrNil: value}},
+					p.lowerObjectRestInDecls([]js_ast.Decl{{Binding: arg.Binding, ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}}})...)}})
+				continue
+			}
+			prefixStmts = append(prefixStmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: []js_ast.Decl{{Binding: arg.Binding, ValueOrNil: value}}}})
+			continue
+		}
+
+		lowerDefault := lowerDefaults && arg.DefaultOrNil.Data != nil
+
+		// "function foo(a = 1) {}" => "function foo(a) { if (a === void 0) a = 1 }"
+		if id, ok := arg.Binding.Data.(*js_ast.BIdentifier); ok {
+			if lowerDefault {
+				p.recordUsage(id.Ref)
+				p.recordUsage(id.Ref)
+				prefixStmts = append(prefixStmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SIf{
+					Test: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+						Op:    js_ast.BinOpStrictEq,
+						Left:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: id.Ref}},
+						Right: js_ast.Expr{Loc: loc, Data: js_ast.EUndefinedShared},
+					}},
+					Yes: js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: js_ast.Assign(
+						js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: id.Ref}},
+						arg.DefaultOrNil,
+					)}},
+				}})
+				arg.DefaultOrNil = js_ast.Expr{}
+			}
+			continue
+		}
+
+		if !lowerDefault && !p.bindingNeedsLowering(arg.Binding) {
+			continue
+		}
+
+		// "function foo({a} = b) {}" => "function foo(_a) { var a = (_a === void 0 ? b : _a).a }"
+		ref := p.generateTempRef(tempRefNoDeclare, "")
+		init := js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+		p.recordUsage(ref)
+		if lowerDefault {
+			p.recordUsage(ref)
+			init.Data = &js_ast.EIf{
+				Test: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+					Op:    js_ast.BinOpStrictEq,
+					Left:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+					Right: js_ast.Expr{Loc: loc, Data: js_ast.EUndefinedShared},
+				}},
+				Yes: arg.DefaultOrNil,
+				No:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+			}
+			arg.DefaultOrNil = js_ast.Expr{}
+		}
+		decls := p.lowerObjectRestInDecls([]js_ast.Decl{{Binding: arg.Binding, ValueOrNil: init}})
+		arg.Binding.Data = &js_ast.BIdentifier{Ref: ref}
+		prefixStmts = append(prefixStmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: decls}})
+	}
+
+	if firstDefault != -1 && firstDefault < len(*args) {
+		*args = (*args)[:firstDefault]
+	}
+	return
 }
 
 func (p *parser) lowerOptionalChain(expr js_ast.Expr, in exprIn, childOut exprOut) (js_ast.Expr, exprOut) {
@@ -729,7 +907,7 @@ flatten:
 			// a property access, invoke the function using ".call(this, ...args)" to
 			// explicitly provide the value for "this".
 			if i == len(chain)-1 && thisArg.Data != nil {
-				result = js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+				result = p.lowerSpreadInCall(loc, &js_ast.ECall{
 					Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
 						Target:  result,
 						Name:    "call",
@@ -739,7 +917,7 @@ flatten:
 					CanBeUnwrappedIfUnused: e.CanBeUnwrappedIfUnused,
 					IsMultiLine:            e.IsMultiLine,
 					Kind:                   js_ast.TargetWasOriginallyPropertyAccess,
-				}}
+				}, true)
 				break
 			}
 
@@ -748,7 +926,7 @@ flatten:
 			// the property access target that was stashed away earlier as the value
 			// for "this" for the call. Example for this case: "foo.#bar?.()"
 			if privateThisFunc != nil {
-				result = privateThisWrapFunc(js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+				result = privateThisWrapFunc(p.lowerSpreadInCall(loc, &js_ast.ECall{
 					Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
 						Target:  result,
 						Name:    "call",
@@ -758,18 +936,18 @@ flatten:
 					CanBeUnwrappedIfUnused: e.CanBeUnwrappedIfUnused,
 					IsMultiLine:            e.IsMultiLine,
 					Kind:                   js_ast.TargetWasOriginallyPropertyAccess,
-				}})
+				}, true))
 				privateThisFunc = nil
 				break
 			}
 
-			result = js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+			result = p.lowerSpreadInCall(loc, &js_ast.ECall{
 				Target:                 result,
 				Args:                   e.Args,
 				CanBeUnwrappedIfUnused: e.CanBeUnwrappedIfUnused,
 				IsMultiLine:            e.IsMultiLine,
 				Kind:                   e.Kind,
-			}}
+			}, false)
 
 		case *js_ast.EUnary:
 			result = js_ast.Expr{Loc: loc, Data: &js_ast.EUnary{
@@ -816,7 +994,7 @@ flatten:
 }
 
 func (p *parser) lowerParenthesizedOptionalChain(loc logger.Loc, e *js_ast.ECall, childOut exprOut) js_ast.Expr {
-	return childOut.thisArgWrapFunc(js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+	return childOut.thisArgWrapFunc(p.lowerSpreadInCall(loc, &js_ast.ECall{
 		Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
 			Target:  e.Target,
 			Name:    "call",
@@ -825,7 +1003,7 @@ func (p *parser) lowerParenthesizedOptionalChain(loc logger.Loc, e *js_ast.ECall
 		Args:        append(append(make([]js_ast.Expr, 0, len(e.Args)+1), childOut.thisArgFunc()), e.Args...),
 		IsMultiLine: e.IsMultiLine,
 		Kind:        js_ast.TargetWasOriginallyPropertyAccess,
-	}})
+	}, true))
 }
 
 func (p *parser) lowerAssignmentOperator(value js_ast.Expr, callback func(js_ast.Expr, js_ast.Expr) js_ast.Expr) js_ast.Expr {
@@ -1015,7 +1193,7 @@ func (p *parser) lowerObjectSpread(loc logger.Loc, e *js_ast.EObject) js_ast.Exp
 	}
 
 	if !needsLowering {
-		return js_ast.Expr{Loc: loc, Data: e}
+		return p.lowerObjectExtensions(loc, e)
 	}
 
 	var result js_ast.Expr
@@ -1030,17 +1208,17 @@ func (p *parser) lowerObjectSpread(loc logger.Loc, e *js_ast.EObject) js_ast.Exp
 		if len(properties) > 0 || result.Data == nil {
 			if result.Data == nil {
 				// "{a, ...b}" => "__spreadValues({a}, b)"
-				result = js_ast.Expr{Loc: loc, Data: &js_ast.EObject{
+				result = p.lowerObjectExtensions(loc, &js_ast.EObject{
 					Properties:   properties,
 					IsSingleLine: e.IsSingleLine,
-				}}
+				})
 			} else {
 				// "{...a, b, ...c}" => "__spreadValues(__spreadProps(__spreadValues({}, a), {b}), c)"
 				result = p.callRuntime(loc, "__spreadProps",
-					[]js_ast.Expr{result, {Loc: loc, Data: &js_ast.EObject{
+					[]js_ast.Expr{result, p.lowerObjectExtensions(loc, &js_ast.EObject{
 						Properties:   properties,
 						IsSingleLine: e.IsSingleLine,
-					}}})
+					})})
 			}
 			properties = []js_ast.Property{}
 		}
@@ -1051,16 +1229,246 @@ func (p *parser) lowerObjectSpread(loc logger.Loc, e *js_ast.EObject) js_ast.Exp
 
 	if len(properties) > 0 {
 		// "{...a, b}" => "__spreadProps(__spreadValues({}, a), {b})"
-		result = p.callRuntime(loc, "__spreadProps", []js_ast.Expr{result, {Loc: loc, Data: &js_ast.EObject{
+		result = p.callRuntime(loc, "__spreadProps", []js_ast.Expr{result, p.lowerObjectExtensions(loc, &js_ast.EObject{
 			Properties:    properties,
 			IsSingleLine:  e.IsSingleLine,
 			CloseBraceLoc: e.CloseBraceLoc,
-		}}})
+		})})
 	}
 
 	return result
 }
 
+// This converts array spread into calls to "concat" for environments that
+// don't support it. Spread values are converted to arrays using the iterator
+// protocol unless loose iteration is enabled:
+//
+//	// Original code
+//	[a, ...b, c]
+//
+//	// Lowered code
+//	[a].concat(__toArray(b), [c])
+//
+//	// Lowered code (with loose iteration)
+//	[a].concat(__slice(b), [c])
+func (p *parser) lowerArraySpread(loc logger.Loc, items []js_ast.Expr, isSingleLine bool) js_ast.Expr {
+	needsLowering := false
+
+	if p.options.unsupportedJSFeatures.Has(compat.ArraySpread) {
+		for _, item := range items {
+			if _, ok := item.Data.(*js_ast.ESpread); ok {
+				needsLowering = true
+				break
+			}
+		}
+	}
+
+	if !needsLowering {
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: items, IsSingleLine: isSingleLine}}
+	}
+
+	var chunks []js_ast.Expr
+	var before []js_ast.Expr
+
+	for _, item := range items {
+		spread, ok := item.Data.(*js_ast.ESpread)
+		if !ok {
+			before = append(before, item)
+			continue
+		}
+
+		if len(before) > 0 {
+			chunks = append(chunks, js_ast.Expr{Loc: before[0].Loc, Data: &js_ast.EArray{Items: before, IsSingleLine: isSingleLine}})
+			before = nil
+		}
+
+		// Both of these always return a new array that's safe to mutate
+		if p.options.looseIteration {
+			chunks = append(chunks, p.callRuntime(item.Loc, "__slice", []js_ast.Expr{spread.Value}))
+		} else {
+			chunks = append(chunks, p.callRuntime(item.Loc, "__toArray", []js_ast.Expr{spread.Value}))
+		}
+	}
+
+	if len(before) > 0 {
+		chunks = append(chunks, js_ast.Expr{Loc: before[0].Loc, Data: &js_ast.EArray{Items: before, IsSingleLine: isSingleLine}})
+	}
+
+	// "[...a]" => "__toArray(a)"
+	if len(chunks) == 1 {
+		return chunks[0]
+	}
+
+	// "[...a, ...b]" => "__toArray(a).concat(__toArray(b))"
+	return js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+		Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: chunks[0], Name: "concat", NameLoc: loc}},
+		Args:   chunks[1:],
+		Kind:   js_ast.TargetWasOriginallyPropertyAccess,
+	}}
+}
+
+// This converts a call with spread arguments into a call to "apply" for
+// environments that don't support it. If "isCallWithThisArg" is true, the
+// call is a generated "fn.call(thisArg, ...args)" call.
+//
+//	// Original code
+//	a.b(c, ...d)
+//
+//	// Lowered code
+//	a.b.apply(a, [c].concat(__toArray(d)))
+func (p *parser) lowerSpreadInCall(loc logger.Loc, e *js_ast.ECall, isCallWithThisArg bool) js_ast.Expr {
+	needsLowering := false
+
+	if p.options.unsupportedJSFeatures.Has(compat.ArraySpread) {
+		for _, arg := range e.Args {
+			if _, ok := arg.Data.(*js_ast.ESpread); ok {
+				needsLowering = true
+				break
+			}
+		}
+	}
+
+	if !needsLowering {
+		return js_ast.Expr{Loc: loc, Data: e}
+	}
+
+	target := e.Target
+	args := e.Args
+	var thisArg js_ast.Expr
+	wrapFunc := func(expr js_ast.Expr) js_ast.Expr { return expr }
+
+	if isCallWithThisArg {
+		// "a.call(b, ...c)" => "a.apply(b, __toArray(c))"
+		target = target.Data.(*js_ast.EDot).Target
+		thisArg = args[0]
+		args = args[1:]
+	} else {
+		switch t := target.Data.(type) {
+		case *js_ast.EDot:
+			if _, ok := t.Target.Data.(*js_ast.ESuper); ok {
+				thisArg = js_ast.Expr{Loc: t.Target.Loc, Data: js_ast.EThisShared}
+			} else {
+				targetFunc, targetWrapFunc := p.captureValueWithPossibleSideEffects(t.Target.Loc, 2, t.Target, valueDefinitelyNotMutated)
+				target = js_ast.Expr{Loc: target.Loc, Data: &js_ast.EDot{Target: targetFunc(), Name: t.Name, NameLoc: t.NameLoc}}
+				thisArg = targetFunc()
+				wrapFunc = targetWrapFunc
+			}
+
+		case *js_ast.EIndex:
+			if _, ok := t.Target.Data.(*js_ast.ESuper); ok {
+				thisArg = js_ast.Expr{Loc: t.Target.Loc, Data: js_ast.EThisShared}
+			} else {
+				targetFunc, targetWrapFunc := p.captureValueWithPossibleSideEffects(t.Target.Loc, 2, t.Target, valueDefinitelyNotMutated)
+				target = js_ast.Expr{Loc: target.Loc, Data: &js_ast.EIndex{Target: targetFunc(), Index: t.Index}}
+				thisArg = targetFunc()
+				wrapFunc = targetWrapFunc
+			}
+
+		default:
+			thisArg = js_ast.Expr{Loc: loc, Data: js_ast.EUndefinedShared}
+		}
+	}
+
+	// "a(...b)" => "a.apply(void 0, b)" when loose iteration is enabled
+	var argsArray js_ast.Expr
+	if spread, ok := args[0].Data.(*js_ast.ESpread); ok && len(args) == 1 && p.options.looseIteration {
+		argsArray = spread.Value
+	} else {
+		argsArray = p.lowerArraySpread(loc, args, !e.IsMultiLine)
+	}
+
+	return wrapFunc(js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+		Target:                 js_ast.Expr{Loc: target.Loc, Data: &js_ast.EDot{Target: target, Name: "apply", NameLoc: target.Loc}},
+		Args:                   []js_ast.Expr{thisArg, argsArray},
+		CanBeUnwrappedIfUnused: e.CanBeUnwrappedIfUnused,
+		Kind:                   js_ast.TargetWasOriginallyPropertyAccess,
+	}})
+}
+
+// "new a(b, ...c)" => "__construct(a, [b].concat(__toArray(c)))"
+func (p *parser) lowerSpreadInNew(loc logger.Loc, e *js_ast.ENew) js_ast.Expr {
+	for _, arg := range e.Args {
+		if _, ok := arg.Data.(*js_ast.ESpread); ok && p.options.unsupportedJSFeatures.Has(compat.ArraySpread) {
+			return p.callRuntime(loc, "__construct", []js_ast.Expr{e.Target, p.lowerArraySpread(loc, e.Args, !e.IsMultiLine)})
+		}
+	}
+	return js_ast.Expr{Loc: loc, Data: e}
+}
+
+// This lowers object literal syntax that was introduced in ES6 for
+// environments that don't support it. Methods are converted into function
+// expressions and everything starting with the first computed property is
+// assigned to the object after it's created:
+//
+//	// Original code
+//	x = { a() {}, [b]: c, get d() {} }
+//
+//	// Lowered code
+//	x = (_a = { a: function() {} }, _a[b] = c, __defAccessor(_a, "d", function() {}), _a)
+func (p *parser) lowerObjectExtensions(loc logger.Loc, e *js_ast.EObject) js_ast.Expr {
+	if !p.options.unsupportedJSFeatures.Has(compat.ObjectExtensions) {
+		return js_ast.Expr{Loc: loc, Data: e}
+	}
+
+	split := -1
+	for i := range e.Properties {
+		property := &e.Properties[i]
+		if property.Kind == js_ast.PropertyNormal {
+			property.Flags &= ^js_ast.PropertyIsMethod
+		}
+		if split == -1 && property.Flags.Has(js_ast.PropertyIsComputed) {
+			split = i
+		}
+	}
+
+	if split == -1 {
+		return js_ast.Expr{Loc: loc, Data: e}
+	}
+
+	ref := p.generateTempRef(tempRefNeedsDeclare, "")
+	p.recordUsage(ref)
+	result := js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}, js_ast.Expr{Loc: loc, Data: &js_ast.EObject{
+		Properties:   e.Properties[:split],
+		IsSingleLine: e.IsSingleLine,
+	}})
+
+	for _, property := range e.Properties[split:] {
+		keyLoc := property.Key.Loc
+		key := property.Key
+		p.recordUsage(ref)
+
+		switch property.Kind {
+		case js_ast.PropertyGet, js_ast.PropertySet:
+			// "{get [a]() {}}" => "__defAccessor(_a, a, function() {})"
+			args := []js_ast.Expr{{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}, key, property.ValueOrNil}
+			if property.Kind == js_ast.PropertySet {
+				args = append(args, js_ast.Expr{Loc: keyLoc, Data: &js_ast.EBoolean{Value: true}})
+			}
+			result = js_ast.JoinWithComma(result, p.callRuntime(property.Loc, "__defAccessor", args))
+
+		default:
+			// "{[a]: b}" => "_a[a] = b"
+			var target js_ast.Expr
+			if str, ok := key.Data.(*js_ast.EString); ok && !property.Flags.Has(js_ast.PropertyIsComputed) && js_ast.IsIdentifierUTF16(str.Value) {
+				target = js_ast.Expr{Loc: keyLoc, Data: &js_ast.EDot{
+					Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+					Name:    helpers.UTF16ToString(str.Value),
+					NameLoc: keyLoc,
+				}}
+			} else {
+				target = js_ast.Expr{Loc: keyLoc, Data: &js_ast.EIndex{
+					Target: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+					Index:  key,
+				}}
+			}
+			result = js_ast.JoinWithComma(result, js_ast.Assign(target, property.ValueOrNil))
+		}
+	}
+
+	p.recordUsage(ref)
+	return js_ast.JoinWithComma(result, js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}})
+}
+
 func (p *parser) maybeLowerAwait(loc logger.Loc, e *js_ast.EAwait) js_ast.Expr {
 	// "await x" turns into "yield __await(x)" when lowering async generator functions
 	if p.fnOrArrowDataVisit.isGenerator && (p.options.unsupportedJSFeatures.Has(compat.AsyncAwait) || p.options.unsupportedJSFeatures.Has(compat.AsyncGenerator)) {
@@ -1083,53 +1491,69 @@ func (p *parser) maybeLowerAwait(loc logger.Loc, e *js_ast.EAwait) js_ast.Expr {
 }
 
 func (p *parser) lowerForAwaitLoop(loc logger.Loc, loop *js_ast.SForOf, stmts []js_ast.Stmt) []js_ast.Stmt {
-	// This code:
+	return p.lowerForOfLoopWithIterator(loc, loop, stmts, true /* isAwait */)
+}
+
+func (p *parser) lowerForOfLoop(loc logger.Loc, loop *js_ast.SForOf, stmts []js_ast.Stmt) []js_ast.Stmt {
+	if !p.options.looseIteration {
+		return p.lowerForOfLoopWithIterator(loc, loop, stmts, false /* isAwait */)
+	}
+
+	// When loose iteration is enabled, this code:
 	//
-	//   for await (let x of y) z()
+	//   for (let x of y) z()
 	//
 	// is transformed into the following code:
 	//
-	//   try {
-	//     for (var iter = __forAwait(y), more, temp, error; more = !(temp = await iter.next()).done; more = false) {
-	//       let x = temp.value;
-	//       z();
-	//     }
-	//   } catch (temp) {
-	//     error = [temp]
-	//   } finally {
-	//     try {
-	//       more && (temp = iter.return) && (await temp.call(iter))
-	//     } finally {
-	//       if (error) throw error[0]
-	//     }
+	//   for (var _a = 0, _b = y; _a < _b.length; _a++) {
+	//     let x = _b[_a];
+	//     z();
 	//   }
 	//
-	// except that "yield" is used instead of "await" if await is unsupported.
-	// This mostly follows TypeScript's implementation of the syntax transform.
+	indexRef := p.generateTempRef(tempRefNoDeclare, "")
+	arrayRef := p.generateTempRef(tempRefNoDeclare, "")
+	value := js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{
+		Target: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: arrayRef}},
+		Index:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: indexRef}},
+	}}
 
-	iterRef := p.generateTempRef(tempRefNoDeclare, "iter")
-	moreRef := p.generateTempRef(tempRefNoDeclare, "more")
-	tempRef := p.generateTempRef(tempRefNoDeclare, "temp")
-	errorRef := p.generateTempRef(tempRefNoDeclare, "error")
+	return append(stmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SFor{
+		InitOrNil: js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: []js_ast.Decl{
+			{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: indexRef}},
+				ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: 0}}},
+			{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: arrayRef}},
+				ValueOrNil: loop.Value},
+		}}},
+		TestOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+			Op:   js_ast.BinOpLt,
+			Left: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: indexRef}},
+			Right: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+				Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: arrayRef}},
+				NameLoc: loc,
+				Name:    "length",
+			}},
+		}},
+		UpdateOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EUnary{
+			Op:    js_ast.UnOpPostInc,
+			Value: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: indexRef}},
+		}},
+		Body: forOfLoopBodyWithValue(loc, loop, value),
+	}})
+}
 
+// This turns the body of a for-of loop into a block statement that starts by
+// assigning the provided value to the loop variable
+func forOfLoopBodyWithValue(loc logger.Loc, loop *js_ast.SForOf, value js_ast.Expr) js_ast.Stmt {
 	switch init := loop.Init.Data.(type) {
 	case *js_ast.SLocal:
 		if len(init.Decls) == 1 {
-			init.Decls[0].ValueOrNil = js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
-				Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: tempRef}},
-				NameLoc: loc,
-				Name:    "value",
-			}}
+			init.Decls[0].ValueOrNil = value
 		}
 	case *js_ast.SExpr:
 		init.Value.Data = &js_ast.EBinary{
-			Op:   js_ast.BinOpAssign,
-			Left: init.Value,
-			Right: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
-				Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: tempRef}},
-				NameLoc: loc,
-				Name:    "value",
-			}},
+			Op:    js_ast.BinOpAssign,
+			Left:  init.Value,
+			Right: value,
 		}
 	}
 
@@ -1144,12 +1568,77 @@ func (p *parser) lowerForAwaitLoop(loc logger.Loc, loop *js_ast.SForOf, stmts []
 		body = append(body, loop.Body)
 	}
 
-	awaitIterNext := js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
-		Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
-			Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: iterRef}},
-			NameLoc: loc,
-			Name:    "next",
-		}},
+	return js_ast.Stmt{Loc: loop.Body.Loc, Data: &js_ast.SBlock{
+		Stmts:         body,
+		CloseBraceLoc: closeBraceLoc,
+	}}
+}
+
+// Returns the loop inside the "try" statement generated when lowering a
+// "for of" loop, or nil if the statement isn't one. The "try" statement may
+// be preceded by other statements if the loop body was moved into a closure.
+func findLoopInLoweredForOf(stmt js_ast.Stmt) *js_ast.Stmt {
+	if block, ok := stmt.Data.(*js_ast.SBlock); ok && len(block.Stmts) > 0 {
+		stmt = block.Stmts[len(block.Stmts)-1]
+	}
+	if try, ok := stmt.Data.(*js_ast.STry); ok && len(try.Block.Stmts) == 1 {
+		if _, ok := try.Block.Stmts[0].Data.(*js_ast.SFor); ok {
+			return &try.Block.Stmts[0]
+		}
+	}
+	return nil
+}
+
+func (p *parser) lowerForOfLoopWithIterator(loc logger.Loc, loop *js_ast.SForOf, stmts []js_ast.Stmt, isAwait bool) []js_ast.Stmt {
+	// This code:
+	//
+	//   for await (let x of y) z()
+	//
+	// is transformed into the following code:
+	//
+	//   try {
+	//     for (var iter = __forAwait(y), more, temp, error; more = !(temp = await iter.next()).done; more = false) {
+	//       let x = temp.value;
+	//       z();
+	//     }
+	//   } catch (temp) {
+	//     error = [temp]
+	//   } finally {
+	//     try {
+	//       more && (temp = iter.return) && (await temp.call(iter))
+	//     } finally {
+	//       if (error) throw error[0]
+	//     }
+	//   }
+	//
+	// except that "yield" is used instead of "await" if await is unsupported.
+	// This mostly follows TypeScript's implementation of the syntax transform.
+	// Non-async loops use "__iterator" instead of "__forAwait" and omit the
+	// "await" keywords. They also use numbered temporary names since they are
+	// much more likely to be nested inside each other at the top level, where
+	// names aren't renamed to avoid collisions.
+
+	iterName, moreName, tempName, errorName := "iter", "more", "temp", "error"
+	if !isAwait {
+		iterName, moreName, tempName, errorName = "", "", "", ""
+	}
+	iterRef := p.generateTempRef(tempRefNoDeclare, iterName)
+	moreRef := p.generateTempRef(tempRefNoDeclare, moreName)
+	tempRef := p.generateTempRef(tempRefNoDeclare, tempName)
+	errorRef := p.generateTempRef(tempRefNoDeclare, errorName)
+
+	body := forOfLoopBodyWithValue(loc, loop, js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+		Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: tempRef}},
+		NameLoc: loc,
+		Name:    "value",
+	}})
+
+	awaitIterNext := js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+		Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+			Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: iterRef}},
+			NameLoc: loc,
+			Name:    "next",
+		}},
 		Kind: js_ast.TargetWasOriginallyPropertyAccess,
 	}}
 	awaitTempCallIter := js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
@@ -1163,8 +1652,12 @@ func (p *parser) lowerForAwaitLoop(loc logger.Loc, loop *js_ast.SForOf, stmts []
 	}}
 
 	// "await" expressions turn into "yield" expressions when lowering
-	awaitIterNext = p.maybeLowerAwait(awaitIterNext.Loc, &js_ast.EAwait{Value: awaitIterNext})
-	awaitTempCallIter = p.maybeLowerAwait(awaitTempCallIter.Loc, &js_ast.EAwait{Value: awaitTempCallIter})
+	iterHelper := "__iterator"
+	if isAwait {
+		iterHelper = "__forAwait"
+		awaitIterNext = p.maybeLowerAwait(awaitIterNext.Loc, &js_ast.EAwait{Value: awaitIterNext})
+		awaitTempCallIter = p.maybeLowerAwait(awaitTempCallIter.Loc, &js_ast.EAwait{Value: awaitTempCallIter})
+	}
 
 	return append(stmts, js_ast.Stmt{Loc: loc, Data: &js_ast.STry{
 		BlockLoc: loc,
@@ -1172,7 +1665,7 @@ func (p *parser) lowerForAwaitLoop(loc logger.Loc, loop *js_ast.SForOf, stmts []
 			Stmts: []js_ast.Stmt{{Loc: loc, Data: &js_ast.SFor{
 				InitOrNil: js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: []js_ast.Decl{
 					{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: iterRef}},
-						ValueOrNil: p.callRuntime(loc, "__forAwait", []js_ast.Expr{loop.Value})},
+						ValueOrNil: p.callRuntime(loc, iterHelper, []js_ast.Expr{loop.Value})},
 					{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: moreRef}}},
 					{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: tempRef}}},
 					{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: errorRef}}},
@@ -1198,10 +1691,7 @@ func (p *parser) lowerForAwaitLoop(loc logger.Loc, loop *js_ast.SForOf, stmts []
 					Left:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: moreRef}},
 					Right: js_ast.Expr{Loc: loc, Data: &js_ast.EBoolean{Value: false}},
 				}},
-				Body: js_ast.Stmt{Loc: loop.Body.Loc, Data: &js_ast.SBlock{
-					Stmts:         body,
-					CloseBraceLoc: closeBraceLoc,
-				}},
+				Body: body,
 			}}},
 		},
 
@@ -1263,17 +1753,32 @@ func (p *parser) lowerForAwaitLoop(loc logger.Loc, loop *js_ast.SForOf, stmts []
 	}})
 }
 
-func bindingHasObjectRest(binding js_ast.Binding) bool {
+// Returns true if this binding pattern contains syntax that isn't supported
+// in the target environment. This includes binding patterns in general when
+// destructuring is unsupported, object rest patterns, and array rest patterns
+// with a nested binding pattern.
+func (p *parser) bindingNeedsLowering(binding js_ast.Binding) bool {
 	switch b := binding.Data.(type) {
 	case *js_ast.BArray:
-		for _, item := range b.Items {
-			if bindingHasObjectRest(item.Binding) {
+		if p.options.unsupportedJSFeatures.Has(compat.Destructuring) {
+			return true
+		}
+		for i, item := range b.Items {
+			if b.HasSpread && i+1 == len(b.Items) && p.options.unsupportedJSFeatures.Has(compat.NestedRestBinding) {
+				if _, ok := item.Binding.Data.(*js_ast.BIdentifier); !ok {
+					return true
+				}
+			}
+			if p.bindingNeedsLowering(item.Binding) {
 				return true
 			}
 		}
 	case *js_ast.BObject:
+		if p.options.unsupportedJSFeatures.Has(compat.Destructuring) {
+			return true
+		}
 		for _, property := range b.Properties {
-			if property.IsSpread || bindingHasObjectRest(property.Value) {
+			if (property.IsSpread && p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread)) || p.bindingNeedsLowering(property.Value) {
 				return true
 			}
 		}
@@ -1281,21 +1786,34 @@ func bindingHasObjectRest(binding js_ast.Binding) bool {
 	return false
 }
 
-func exprHasObjectRest(expr js_ast.Expr) bool {
+// This is the same as "bindingNeedsLowering" but for assignment patterns
+func (p *parser) exprNeedsLowering(expr js_ast.Expr) bool {
 	switch e := expr.Data.(type) {
 	case *js_ast.EBinary:
-		if e.Op == js_ast.BinOpAssign && exprHasObjectRest(e.Left) {
+		if e.Op == js_ast.BinOpAssign && p.exprNeedsLowering(e.Left) {
 			return true
 		}
 	case *js_ast.EArray:
+		if p.options.unsupportedJSFeatures.Has(compat.Destructuring) {
+			return true
+		}
 		for _, item := range e.Items {
-			if exprHasObjectRest(item) {
+			if spread, ok := item.Data.(*js_ast.ESpread); ok {
+				if p.options.unsupportedJSFeatures.Has(compat.NestedRestBinding) && isBindingPatternExpr(spread.Value) {
+					return true
+				}
+				item = spread.Value
+			}
+			if p.exprNeedsLowering(item) {
 				return true
 			}
 		}
 	case *js_ast.EObject:
+		if p.options.unsupportedJSFeatures.Has(compat.Destructuring) {
+			return true
+		}
 		for _, property := range e.Properties {
-			if property.Kind == js_ast.PropertySpread || exprHasObjectRest(property.ValueOrNil) {
+			if (property.Kind == js_ast.PropertySpread && p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread)) || p.exprNeedsLowering(property.ValueOrNil) {
 				return true
 			}
 		}
@@ -1303,15 +1821,19 @@ func exprHasObjectRest(expr js_ast.Expr) bool {
 	return false
 }
 
-func (p *parser) lowerObjectRestInDecls(decls []js_ast.Decl) []js_ast.Decl {
-	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
-		return decls
+func isBindingPatternExpr(expr js_ast.Expr) bool {
+	switch expr.Data.(type) {
+	case *js_ast.EArray, *js_ast.EObject:
+		return true
 	}
+	return false
+}
 
-	// Don't do any allocations if there are no object rest patterns. We want as
+func (p *parser) lowerObjectRestInDecls(decls []js_ast.Decl) []js_ast.Decl {
+	// Don't do any allocations if there are no patterns to lower. We want as
 	// little overhead as possible in the common case.
 	for i, decl := range decls {
-		if decl.ValueOrNil.Data != nil && bindingHasObjectRest(decl.Binding) {
+		if decl.ValueOrNil.Data != nil && p.bindingNeedsLowering(decl.Binding) {
 			clone := append([]js_ast.Decl{}, decls[:i]...)
 			for _, decl := range decls[i:] {
 				if decl.ValueOrNil.Data != nil {
@@ -1332,17 +1854,13 @@ func (p *parser) lowerObjectRestInDecls(decls []js_ast.Decl) []js_ast.Decl {
 }
 
 func (p *parser) lowerObjectRestInForLoopInit(init js_ast.Stmt, body *js_ast.Stmt) {
-	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
-		return
-	}
-
 	var bodyPrefixStmt js_ast.Stmt
 
 	switch s := init.Data.(type) {
 	case *js_ast.SExpr:
 		// "for ({...x} in y) {}"
 		// "for ({...x} of y) {}"
-		if exprHasObjectRest(s.Value) {
+		if p.exprNeedsLowering(s.Value) {
 			ref := p.generateTempRef(tempRefNeedsDeclare, "")
 			if expr, ok := p.lowerAssign(s.Value, js_ast.Expr{Loc: init.Loc, Data: &js_ast.EIdentifier{Ref: ref}}, objRestReturnValueIsUnused); ok {
 				p.recordUsage(ref)
@@ -1354,7 +1872,7 @@ func (p *parser) lowerObjectRestInForLoopInit(init js_ast.Stmt, body *js_ast.Stm
 	case *js_ast.SLocal:
 		// "for (let {...x} in y) {}"
 		// "for (let {...x} of y) {}"
-		if len(s.Decls) == 1 && bindingHasObjectRest(s.Decls[0].Binding) {
+		if len(s.Decls) == 1 && p.bindingNeedsLowering(s.Decls[0].Binding) {
 			ref := p.generateTempRef(tempRefNoDeclare, "")
 			decl := js_ast.Decl{Binding: s.Decls[0].Binding, ValueOrNil: js_ast.Expr{Loc: init.Loc, Data: &js_ast.EIdentifier{Ref: ref}}}
 			p.recordUsage(ref)
@@ -1377,18 +1895,14 @@ func (p *parser) lowerObjectRestInForLoopInit(init js_ast.Stmt, body *js_ast.Stm
 }
 
 func (p *parser) lowerObjectRestInCatchBinding(catch *js_ast.Catch) {
-	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
-		return
-	}
-
-	if catch.BindingOrNil.Data != nil && bindingHasObjectRest(catch.BindingOrNil) {
+	if catch.BindingOrNil.Data != nil && p.bindingNeedsLowering(catch.BindingOrNil) {
 		ref := p.generateTempRef(tempRefNoDeclare, "")
 		decl := js_ast.Decl{Binding: catch.BindingOrNil, ValueOrNil: js_ast.Expr{Loc: catch.BindingOrNil.Loc, Data: &js_ast.EIdentifier{Ref: ref}}}
 		p.recordUsage(ref)
 		decls := p.lowerObjectRestInDecls([]js_ast.Decl{decl})
 		catch.BindingOrNil.Data = &js_ast.BIdentifier{Ref: ref}
 		stmts := make([]js_ast.Stmt, 0, 1+len(catch.Block.Stmts))
-		stmts = append(stmts, js_ast.Stmt{Loc: catch.BindingOrNil.Loc, Data: &js_ast.SLocal{Kind: js_ast.LocalLet, Decls: decls}})
+		stmts = append(stmts, js_ast.Stmt{Loc: catch.BindingOrNil.Loc, Data: &js_ast.SLocal{Kind: p.selectLocalKind(js_ast.LocalLet), Decls: decls}})
 		catch.Block.Stmts = append(stmts, catch.Block.Stmts...)
 	}
 }
@@ -1445,18 +1959,19 @@ func (p *parser) lowerObjectRestHelper(
 	declare generateTempRefArg,
 	mode objRestMode,
 ) (wrapFunc func(js_ast.Expr) js_ast.Expr, ok bool) {
-	if !p.options.unsupportedJSFeatures.Has(compat.ObjectRestSpread) {
+	unsupported := p.options.unsupportedJSFeatures
+	if !unsupported.Has(compat.ObjectRestSpread) && !unsupported.Has(compat.NestedRestBinding) && !unsupported.Has(compat.Destructuring) {
 		return nil, false
 	}
 
 	// Check if this could possibly contain an object rest binding
-	switch rootExpr.Data.(type) {
-	case *js_ast.EArray, *js_ast.EObject:
-	default:
+	if !isBindingPatternExpr(rootExpr) {
 		return nil, false
 	}
 
-	// Scan for object rest bindings and initialize rest binding containment
+	// Scan for object rest bindings and initialize rest binding containment.
+	// This isn't necessary if all binding patterns need to be lowered anyway.
+	lowerAll := unsupported.Has(compat.Destructuring)
 	containsRestBinding := make(map[js_ast.E]bool)
 	var findRestBindings func(js_ast.Expr) bool
 	findRestBindings = func(expr js_ast.Expr) bool {
@@ -1466,6 +1981,14 @@ func (p *parser) lowerObjectRestHelper(
 			if e.Op == js_ast.BinOpAssign && findRestBindings(e.Left) {
 				found = true
 			}
+		case *js_ast.ESpread:
+			// "let [...[a]] = b"
+			if unsupported.Has(compat.NestedRestBinding) && isBindingPatternExpr(e.Value) {
+				found = true
+			}
+			if findRestBindings(e.Value) {
+				found = true
+			}
 		case *js_ast.EArray:
 			for _, item := range e.Items {
 				if findRestBindings(item) {
@@ -1474,7 +1997,7 @@ func (p *parser) lowerObjectRestHelper(
 			}
 		case *js_ast.EObject:
 			for _, property := range e.Properties {
-				if property.Kind == js_ast.PropertySpread || findRestBindings(property.ValueOrNil) {
+				if (property.Kind == js_ast.PropertySpread && unsupported.Has(compat.ObjectRestSpread)) || findRestBindings(property.ValueOrNil) {
 					found = true
 				}
 			}
@@ -1484,9 +2007,11 @@ func (p *parser) lowerObjectRestHelper(
 		}
 		return found
 	}
-	findRestBindings(rootExpr)
-	if len(containsRestBinding) == 0 {
-		return nil, false
+	if !lowerAll {
+		findRestBindings(rootExpr)
+		if len(containsRestBinding) == 0 {
+			return nil, false
+		}
 	}
 
 	// If there is at least one rest binding, lower the whole expression
@@ -1540,6 +2065,8 @@ func (p *parser) lowerObjectRestHelper(
 		binding := &split
 		if binary, ok := binding.Data.(*js_ast.EBinary); ok && binary.Op == js_ast.BinOpAssign {
 			binding = &binary.Left
+		} else if spread, ok := binding.Data.(*js_ast.ESpread); ok {
+			binding = &spread.Value
 		}
 
 		// Swap the binding with a temporary
@@ -1703,10 +2230,249 @@ func (p *parser) lowerObjectRestHelper(
 		}
 	}
 
-	visit(rootExpr, rootInit, nil)
+	if lowerAll {
+		p.lowerDestructuring(rootExpr, rootInit, assign, declare)
+	} else {
+		visit(rootExpr, rootInit, nil)
+	}
 	return wrapFunc, true
 }
 
+// This lowers all binding patterns for environments that don't support
+// destructuring at all. Array patterns use the iterator protocol by default
+// and are assumed to be array-like if loose iteration is enabled:
+//
+//	// Original code
+//	var [a, b = 1, ...c] = d, {e, f: [g]} = h
+//
+//	// Lowered code
+//	var _a = __toArray(d), a = _a[0], _b = _a[1], b = _b === void 0 ? 1 : _b, c = _a.slice(2),
+//	  e = h.e, g = __toArray(h.f, 1)[0]
+//
+//	// Lowered code (with loose iteration)
+//	var a = d[0], _b = d[1], b = _b === void 0 ? 1 : _b, c = __slice(d, 2),
+//	  e = h.e, g = h.f[0]
+func (p *parser) lowerDestructuring(
+	rootExpr js_ast.Expr,
+	rootInit js_ast.Expr,
+	assign func(js_ast.Expr, js_ast.Expr),
+	declare generateTempRefArg,
+) {
+	var visit func(js_ast.Expr, js_ast.Expr)
+
+	captureIntoRef := func(expr js_ast.Expr) ast.Ref {
+		ref := p.generateTempRef(declare, "")
+		assign(js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EIdentifier{Ref: ref}}, expr)
+		p.recordUsage(ref)
+		return ref
+	}
+
+	// This returns a function that generates a reference to the value being
+	// destructured each time it's called. The value is only stored in a
+	// temporary if it's used more than once and isn't already an identifier.
+	// Identifiers that are assigned to by the pattern itself must still be
+	// stored in a temporary: "({a, b: a} = a)".
+	valueFunc := func(pattern js_ast.Expr, init js_ast.Expr, uses int) func() js_ast.Expr {
+		if uses > 1 {
+			if id, ok := init.Data.(*js_ast.EIdentifier); ok && !exprAssignsToRef(pattern, id.Ref) {
+				isFirst := true
+				return func() js_ast.Expr {
+					if isFirst {
+						isFirst = false
+						return init
+					}
+					p.recordUsage(id.Ref)
+					return js_ast.Expr{Loc: init.Loc, Data: &js_ast.EIdentifier{Ref: id.Ref}}
+				}
+			}
+			ref := captureIntoRef(init)
+			return func() js_ast.Expr {
+				p.recordUsage(ref)
+				return js_ast.Expr{Loc: init.Loc, Data: &js_ast.EIdentifier{Ref: ref}}
+			}
+		}
+		return func() js_ast.Expr { return init }
+	}
+
+	// "[a = b] = c" => "_a = c[0], a = _a === void 0 ? b : _a"
+	visitWithDefault := func(target js_ast.Expr, defaultValue js_ast.Expr, value js_ast.Expr) {
+		if defaultValue.Data != nil {
+			ref := captureIntoRef(value)
+			loc := value.Loc
+			value = js_ast.Expr{Loc: loc, Data: &js_ast.EIf{
+				Test: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+					Op:    js_ast.BinOpStrictEq,
+					Left:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+					Right: js_ast.Expr{Loc: loc, Data: js_ast.EUndefinedShared},
+				}},
+				Yes: defaultValue,
+				No:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+			}}
+			p.recordUsage(ref)
+			p.recordUsage(ref)
+		}
+		visit(target, value)
+	}
+
+	visit = func(expr js_ast.Expr, init js_ast.Expr) {
+		switch e := expr.Data.(type) {
+		case *js_ast.EArray:
+			uses := 0
+			hasRest := false
+			for _, item := range e.Items {
+				switch item.Data.(type) {
+				case *js_ast.EMissing:
+				case *js_ast.ESpread:
+					hasRest = true
+					uses++
+				default:
+					uses++
+				}
+			}
+
+			// Convert the iterable to an array first, taking only as many items as
+			// are needed so that infinite iterators still work
+			if !p.options.looseIteration {
+				args := []js_ast.Expr{init}
+				if !hasRest {
+					args = append(args, js_ast.Expr{Loc: expr.Loc, Data: &js_ast.ENumber{Value: float64(len(e.Items))}})
+				}
+				init = p.callRuntime(init.Loc, "__toArray", args)
+			}
+
+			// Make sure the initializer is still evaluated if nothing is bound
+			if uses == 0 {
+				captureIntoRef(init)
+				return
+			}
+
+			value := valueFunc(expr, init, uses)
+			for i, item := range e.Items {
+				loc := item.Loc
+				switch item := item.Data.(type) {
+				case *js_ast.EMissing:
+					continue
+
+				case *js_ast.ESpread:
+					// "[a, ...b] = c" => "_a = __toArray(c), a = _a[0], b = _a.slice(1)"
+					var rest js_ast.Expr
+					if p.options.looseIteration {
+						args := []js_ast.Expr{value()}
+						if i > 0 {
+							args = append(args, js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: float64(i)}})
+						}
+						rest = p.callRuntime(loc, "__slice", args)
+					} else if i == 0 {
+						rest = value()
+					} else {
+						rest = js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+							Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: value(), Name: "slice", NameLoc: loc}},
+							Args:   []js_ast.Expr{{Loc: loc, Data: &js_ast.ENumber{Value: float64(i)}}},
+							Kind:   js_ast.TargetWasOriginallyPropertyAccess,
+						}}
+					}
+					visit(item.Value, rest)
+
+				default:
+					target := e.Items[i]
+					var defaultValue js_ast.Expr
+					if binary, ok := item.(*js_ast.EBinary); ok && binary.Op == js_ast.BinOpAssign {
+						target = binary.Left
+						defaultValue = binary.Right
+					}
+					visitWithDefault(target, defaultValue, js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{
+						Target: value(),
+						Index:  js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: float64(i)}},
+					}})
+				}
+			}
+			return
+
+		case *js_ast.EObject:
+			// "({} = a)" must still throw if "a" is null or undefined
+			if len(e.Properties) == 0 {
+				captureIntoRef(p.callRuntime(init.Loc, "__requireObject", []js_ast.Expr{init}))
+				return
+			}
+
+			last := len(e.Properties) - 1
+			endsWithRestBinding := e.Properties[last].Kind == js_ast.PropertySpread
+			value := valueFunc(expr, init, len(e.Properties))
+			var capturedKeys []func() js_ast.Expr
+
+			for _, property := range e.Properties {
+				loc := property.Key.Loc
+
+				// "({a, ...b} = c)" => "(a = c.a, b = __objRest(c, ["a"]))"
+				if property.Kind == js_ast.PropertySpread {
+					keysToExclude := make([]js_ast.Expr, len(capturedKeys))
+					for i, capturedKey := range capturedKeys {
+						keysToExclude[i] = capturedKey()
+					}
+					rest := property.ValueOrNil
+					visit(rest, p.callRuntime(rest.Loc, "__objRest", []js_ast.Expr{value(),
+						{Loc: rest.Loc, Data: &js_ast.EArray{Items: keysToExclude, IsSingleLine: e.IsSingleLine}}}))
+					break
+				}
+
+				// Save a copy of this key so the rest binding can exclude it
+				key := property.Key
+				if endsWithRestBinding {
+					var capturedKey func() js_ast.Expr
+					key, capturedKey = p.captureKeyForObjectRest(key)
+					capturedKeys = append(capturedKeys, capturedKey)
+				}
+
+				// "({a: b} = c)" => "(b = c.a)"
+				var access js_ast.Expr
+				if str, ok := key.Data.(*js_ast.EString); ok && js_ast.IsIdentifierUTF16(str.Value) {
+					access = js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: value(), Name: helpers.UTF16ToString(str.Value), NameLoc: loc}}
+				} else {
+					access = js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{Target: value(), Index: key}}
+				}
+
+				target := property.ValueOrNil
+				defaultValue := property.InitializerOrNil
+				if binary, ok := target.Data.(*js_ast.EBinary); ok && binary.Op == js_ast.BinOpAssign && defaultValue.Data == nil {
+					target = binary.Left
+					defaultValue = binary.Right
+				}
+				visitWithDefault(target, defaultValue, access)
+			}
+			return
+		}
+
+		assign(expr, init)
+	}
+
+	visit(rootExpr, rootInit)
+}
+
+// Returns true if this assignment pattern assigns to the provided symbol
+func exprAssignsToRef(expr js_ast.Expr, ref ast.Ref) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EIdentifier:
+		return e.Ref == ref
+	case *js_ast.EBinary:
+		return e.Op == js_ast.BinOpAssign && exprAssignsToRef(e.Left, ref)
+	case *js_ast.ESpread:
+		return exprAssignsToRef(e.Value, ref)
+	case *js_ast.EArray:
+		for _, item := range e.Items {
+			if exprAssignsToRef(item, ref) {
+				return true
+			}
+		}
+	case *js_ast.EObject:
+		for _, property := range e.Properties {
+			if exprAssignsToRef(property.ValueOrNil, ref) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Save a copy of the key for the call to "__objRest" later on. Certain
 // expressions can be converted to keys more efficiently than others.
 func (p *parser) captureKeyForObjectRest(originalKey js_ast.Expr) (finalKey js_ast.Expr, capturedKey func() js_ast.Expr) {
@@ -1835,7 +2601,7 @@ func (p *parser) lowerTemplateLiteral(loc logger.Loc, e *js_ast.ETemplate, tagTh
 	templateObj := p.callRuntime(e.HeadLoc, "__template", arrays)
 
 	// Cache it in a temporary object (required by the specification)
-	tempRef := p.generateTopLevelTempRef()
+	tempRef := p.generateTopLevelTempRef(js_ast.Expr{})
 	p.recordUsage(tempRef)
 	p.recordUsage(tempRef)
 	args[0] = js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
@@ -2161,3 +2927,2054 @@ func (p *parser) maybeLowerUsingDeclarationsInSwitch(loc logger.Loc, s *js_ast.S
 	}
 	return ctx.finalize(p, []js_ast.Stmt{{Loc: loc, Data: s}}, false)
 }
+
+// Generator functions are lowered to a state machine that is driven by the
+// "__makeGenerator" runtime helper. This is the same approach used by the
+// TypeScript compiler and by Regenerator. The function body is split into
+// blocks at each "yield" and each block becomes a case in a switch statement:
+//
+//	function* foo(x) {
+//	  let y = yield x
+//	  return y
+//	}
+//
+// This turns into:
+//
+//	function foo(x) {
+//	  var y;
+//	  return __makeGenerator(this, function (_a) {
+//	    switch (_a.label) {
+//	      case 0:
+//	        return [4, x];
+//	      case 1:
+//	        y = _a.sent();
+//	        return [2, y];
+//	    }
+//	  });
+//	}
+//
+// Each block returns an operation for the runtime helper to perform. Local
+// variables are hoisted out of the state machine so that their values are
+// preserved across calls. Statements that don't contain "yield" are emitted
+// mostly as-is, except that "return", "break", and "continue" may need to be
+// rewritten into operations.
+type generatorOp uint8
+
+const (
+	generatorOpReturn     generatorOp = 2
+	generatorOpJump       generatorOp = 3
+	generatorOpYield      generatorOp = 4
+	generatorOpYieldStar  generatorOp = 5
+	generatorOpEndFinally generatorOp = 7
+)
+
+type generatorJumpTarget struct {
+	labels        []ast.Ref
+	breakLabel    int
+	continueLabel int
+	isLoop        bool
+	isSwitch      bool
+
+	// Native targets are statements that are emitted as-is, so jumps to them
+	// don't need to be rewritten
+	isNative bool
+}
+
+type generatorLabelUse struct {
+	number *js_ast.ENumber
+	label  int
+}
+
+type generatorLowering struct {
+	p            *parser
+	hoistedSet   map[ast.Ref]bool
+	hoistedRefs  []ast.Ref
+	hoistedFns   []js_ast.Stmt
+	blocks       [][]js_ast.Stmt
+	labelBlocks  []int
+	labelUses    []generatorLabelUse
+	targets      []generatorJumpTarget
+	stateRef     ast.Ref
+	isTerminated bool
+}
+
+// This returns the symbol for "arguments" if the generator function being
+// lowered uses it, since it must then be captured outside of the state machine
+func (p *parser) generatorArgumentsRef(isArrow bool) *ast.Ref {
+	if !isArrow && p.fnOnlyDataVisit.argumentsRef != nil {
+		ref := *p.fnOnlyDataVisit.argumentsRef
+		if p.symbolUses[ref].CountEstimate > 0 || p.fnOnlyDataVisit.argumentsCaptureRef != nil {
+			return &ref
+		}
+	}
+	return nil
+}
+
+func (p *parser) lowerGeneratorBody(bodyLoc logger.Loc, stmts []js_ast.Stmt, argumentsRef *ast.Ref) []js_ast.Stmt {
+	g := &generatorLowering{
+		p:          p,
+		hoistedSet: make(map[ast.Ref]bool),
+		blocks:     [][]js_ast.Stmt{nil},
+		stateRef:   p.generateTempRef(tempRefNoDeclare, ""),
+	}
+
+	// Directives must stay at the top of the outer function
+	var result []js_ast.Stmt
+	for len(stmts) > 0 {
+		if _, ok := stmts[0].Data.(*js_ast.SDirective); !ok {
+			break
+		}
+		result = append(result, stmts[0])
+		stmts = stmts[1:]
+	}
+
+	for _, stmt := range stmts {
+		g.visitStmt(stmt, nil)
+	}
+	g.emit(g.returnOp(bodyLoc, generatorOpReturn, js_ast.Expr{}))
+
+	// Now that every label has a block, fill in the jump targets
+	for _, use := range g.labelUses {
+		use.number.Value = float64(g.labelBlocks[use.label])
+	}
+
+	// Use a switch statement if there is more than one block
+	body := g.blocks[0]
+	if len(g.blocks) > 1 {
+		cases := make([]js_ast.Case, len(g.blocks))
+		for i, block := range g.blocks {
+			cases[i] = js_ast.Case{Loc: bodyLoc, ValueOrNil: g.number(bodyLoc, i), Body: block}
+		}
+		body = []js_ast.Stmt{{Loc: bodyLoc, Data: &js_ast.SSwitch{
+			Test:    g.stateDot(bodyLoc, "label"),
+			Cases:   cases,
+			BodyLoc: bodyLoc,
+		}}}
+	}
+
+	// The state machine is a separate function, so "arguments" must be captured
+	var decls []js_ast.Decl
+	if argumentsRef != nil {
+		// Turn the existing symbol into a normal variable so that all references
+		// to it now refer to the captured value
+		symbol := &p.symbols[argumentsRef.InnerIndex]
+		symbol.Kind = ast.SymbolHoisted
+		symbol.OriginalName = "_arguments"
+		symbol.Flags &^= ast.MustNotBeRenamed
+		valueRef := p.newSymbol(ast.SymbolUnbound, "arguments")
+		p.moduleScope.Generated = append(p.moduleScope.Generated, valueRef)
+		decls = append(decls, js_ast.Decl{
+			Binding:    js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: *argumentsRef}},
+			ValueOrNil: js_ast.Expr{Loc: bodyLoc, Data: &js_ast.EIdentifier{Ref: valueRef}},
+		})
+	}
+	for _, ref := range g.hoistedRefs {
+		decls = append(decls, js_ast.Decl{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: ref}}})
+	}
+	if len(decls) > 0 {
+		result = append(result, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: decls}})
+	}
+	result = append(result, g.hoistedFns...)
+
+	// "return __makeGenerator(this, function (_a) { ... })"
+	return append(result, js_ast.Stmt{Loc: bodyLoc, Data: &js_ast.SReturn{ValueOrNil: p.callRuntime(bodyLoc, "__makeGenerator", []js_ast.Expr{
+		{Loc: bodyLoc, Data: js_ast.EThisShared},
+		{Loc: bodyLoc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+			Args: []js_ast.Arg{{Binding: js_ast.Binding{Loc: bodyLoc, Data: &js_ast.BIdentifier{Ref: g.stateRef}}}},
+			Body: js_ast.FnBody{Loc: bodyLoc, Block: js_ast.SBlock{Stmts: body}},
+		}}},
+	})}})
+}
+
+func (g *generatorLowering) ident(loc logger.Loc, ref ast.Ref) js_ast.Expr {
+	g.p.recordUsage(ref)
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
+}
+
+func (g *generatorLowering) number(loc logger.Loc, value int) js_ast.Expr {
+	return js_ast.Expr{Loc: loc, Data: &js_ast.ENumber{Value: float64(value)}}
+}
+
+func (g *generatorLowering) stateDot(loc logger.Loc, name string) js_ast.Expr {
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: g.ident(loc, g.stateRef), Name: name, NameLoc: loc}}
+}
+
+// This is the value sent back in by "next()" or "throw()" after a "yield"
+func (g *generatorLowering) sent(loc logger.Loc) js_ast.Expr {
+	return js_ast.Expr{Loc: loc, Data: &js_ast.ECall{Target: g.stateDot(loc, "sent"), Kind: js_ast.TargetWasOriginallyPropertyAccess}}
+}
+
+func (g *generatorLowering) hoistRef(ref ast.Ref) {
+	if !g.hoistedSet[ref] {
+		g.hoistedSet[ref] = true
+		g.hoistedRefs = append(g.hoistedRefs, ref)
+		g.p.currentScope.Generated = append(g.p.currentScope.Generated, ref)
+	}
+}
+
+func (g *generatorLowering) hoistBinding(binding js_ast.Binding) {
+	switch b := binding.Data.(type) {
+	case *js_ast.BIdentifier:
+		g.hoistRef(b.Ref)
+
+	case *js_ast.BArray:
+		for _, item := range b.Items {
+			g.hoistBinding(item.Binding)
+		}
+
+	case *js_ast.BObject:
+		for _, property := range b.Properties {
+			g.hoistBinding(property.Value)
+		}
+	}
+}
+
+// Temporary variables are always hoisted since they may live across a "yield"
+func (g *generatorLowering) tempRef() ast.Ref {
+	ref := g.p.generateTempRef(tempRefNoDeclare, "")
+	g.hoistedSet[ref] = true
+	g.hoistedRefs = append(g.hoistedRefs, ref)
+	return ref
+}
+
+func (g *generatorLowering) emit(stmt js_ast.Stmt) {
+	// Anything after a "return" or "throw" in the same block is unreachable
+	if g.isTerminated {
+		return
+	}
+
+	index := len(g.blocks) - 1
+	g.blocks[index] = append(g.blocks[index], stmt)
+
+	switch stmt.Data.(type) {
+	case *js_ast.SReturn, *js_ast.SThrow:
+		g.isTerminated = true
+	}
+}
+
+func (g *generatorLowering) emitAssign(loc logger.Loc, ref ast.Ref, value js_ast.Expr) {
+	g.emit(js_ast.AssignStmt(g.ident(loc, ref), value))
+}
+
+func (g *generatorLowering) newLabel() int {
+	g.labelBlocks = append(g.labelBlocks, -1)
+	return len(g.labelBlocks) - 1
+}
+
+// Labels that are marked in a row without any code between them end up
+// referring to the same block
+func (g *generatorLowering) markLabel(loc logger.Loc, label int) {
+	index := len(g.blocks) - 1
+	if len(g.blocks[index]) > 0 {
+		if !g.isTerminated {
+			// Make sure "_a.label" is up to date when falling through to the next
+			// block, since the runtime uses it to find the active "try" block
+			g.emit(js_ast.AssignStmt(g.stateDot(loc, "label"), g.number(loc, index+1)))
+		}
+		g.blocks = append(g.blocks, nil)
+		index++
+	}
+	g.labelBlocks[label] = index
+	g.isTerminated = false
+}
+
+// The actual block number for a label may not be known yet, so it's filled
+// in later once all code has been generated
+func (g *generatorLowering) labelExpr(loc logger.Loc, label int) js_ast.Expr {
+	number := &js_ast.ENumber{}
+	g.labelUses = append(g.labelUses, generatorLabelUse{number: number, label: label})
+	return js_ast.Expr{Loc: loc, Data: number}
+}
+
+func (g *generatorLowering) returnOp(loc logger.Loc, op generatorOp, valueOrNil js_ast.Expr) js_ast.Stmt {
+	items := []js_ast.Expr{{Loc: loc, Data: &js_ast.ENumber{Value: float64(op)}}}
+	if valueOrNil.Data != nil {
+		items = append(items, valueOrNil)
+	}
+	return js_ast.Stmt{Loc: loc, Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: items, IsSingleLine: true}}}}
+}
+
+func (g *generatorLowering) emitJump(loc logger.Loc, label int) {
+	g.emit(g.returnOp(loc, generatorOpJump, g.labelExpr(loc, label)))
+}
+
+func (g *generatorLowering) emitJumpIf(loc logger.Loc, test js_ast.Expr, label int) {
+	g.emit(js_ast.Stmt{Loc: loc, Data: &js_ast.SIf{Test: test, Yes: g.returnOp(loc, generatorOpJump, g.labelExpr(loc, label))}})
+}
+
+func (g *generatorLowering) pushTarget(target generatorJumpTarget) {
+	g.targets = append(g.targets, target)
+}
+
+func (g *generatorLowering) popTarget() {
+	g.targets = g.targets[:len(g.targets)-1]
+}
+
+func (g *generatorLowering) findTarget(label *ast.LocRef, isContinue bool) *generatorJumpTarget {
+	for i := len(g.targets) - 1; i >= 0; i-- {
+		target := &g.targets[i]
+		if label != nil {
+			for _, ref := range target.labels {
+				if ref == label.Ref {
+					return target
+				}
+			}
+		} else if target.isLoop || (target.isSwitch && !isContinue) {
+			return target
+		}
+	}
+	return nil
+}
+
+func (g *generatorLowering) unsupported(loc logger.Loc, what string) {
+	p := g.p
+	p.log.AddError(&p.tracker, logger.Range{Loc: loc}, fmt.Sprintf(
+		"Transforming generator functions with \"yield\" inside %s to the configured target environment is not supported yet", what))
+}
+
+func (g *generatorLowering) visitStmt(stmt js_ast.Stmt, labels []ast.Ref) {
+	switch s := stmt.Data.(type) {
+	case *js_ast.SFunction:
+		// Function declarations are hoisted out of the state machine
+		g.hoistedFns = append(g.hoistedFns, stmt)
+		return
+
+	case *js_ast.SLocal:
+		g.visitLocal(stmt.Loc, s)
+		return
+
+	case *js_ast.SClass:
+		// Class declarations are turned into assignments to hoisted variables
+		if s.Class.Name != nil {
+			g.hoistRef(s.Class.Name.Ref)
+			value := g.visitExpr(js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EClass{Class: s.Class}})
+			g.emit(js_ast.AssignStmt(g.ident(s.Class.Name.Loc, s.Class.Name.Ref), value))
+			return
+		}
+	}
+
+	if !generatorStmtContainsYield(stmt) {
+		g.emit(g.rewriteStmt(stmt))
+		return
+	}
+
+	switch s := stmt.Data.(type) {
+	case *js_ast.SBlock:
+		for _, child := range s.Stmts {
+			g.visitStmt(child, nil)
+		}
+
+	case *js_ast.SExpr:
+		value := g.visitExpr(s.Value)
+		if _, ok := value.Data.(*js_ast.EIdentifier); !ok {
+			g.emit(js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExpr{Value: value}})
+		}
+
+	case *js_ast.SReturn:
+		var value js_ast.Expr
+		if s.ValueOrNil.Data != nil {
+			value = g.visitExpr(s.ValueOrNil)
+		}
+		g.emit(g.returnOp(stmt.Loc, generatorOpReturn, value))
+
+	case *js_ast.SThrow:
+		g.emit(js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SThrow{Value: g.visitExpr(s.Value)}})
+
+	case *js_ast.SIf:
+		test := g.visitExpr(s.Test)
+		if !generatorStmtContainsYield(s.Yes) && (s.NoOrNil.Data == nil || !generatorStmtContainsYield(s.NoOrNil)) {
+			s.Test = test
+			g.emit(g.rewriteStmt(stmt))
+			break
+		}
+		end := g.newLabel()
+		if s.NoOrNil.Data == nil {
+			g.emitJumpIf(stmt.Loc, js_ast.Not(test), end)
+			g.visitStmt(s.Yes, nil)
+		} else {
+			otherwise := g.newLabel()
+			g.emitJumpIf(stmt.Loc, js_ast.Not(test), otherwise)
+			g.visitStmt(s.Yes, nil)
+			g.emitJump(stmt.Loc, end)
+			g.markLabel(s.NoOrNil.Loc, otherwise)
+			g.visitStmt(s.NoOrNil, nil)
+		}
+		g.markLabel(stmt.Loc, end)
+
+	case *js_ast.SWhile:
+		test, end := g.newLabel(), g.newLabel()
+		g.markLabel(stmt.Loc, test)
+		g.emitLoopTest(s.Test, end)
+		g.visitLoopBody(s.Body, labels, end, test)
+		g.emitJump(stmt.Loc, test)
+		g.markLabel(stmt.Loc, end)
+
+	case *js_ast.SDoWhile:
+		body, test, end := g.newLabel(), g.newLabel(), g.newLabel()
+		g.markLabel(stmt.Loc, body)
+		g.visitLoopBody(s.Body, labels, end, test)
+		g.markLabel(s.Test.Loc, test)
+		g.emitJumpIf(s.Test.Loc, g.visitExpr(s.Test), body)
+		g.markLabel(stmt.Loc, end)
+
+	case *js_ast.SFor:
+		if s.InitOrNil.Data != nil {
+			g.visitStmt(s.InitOrNil, nil)
+		}
+		test, update, end := g.newLabel(), g.newLabel(), g.newLabel()
+		g.markLabel(stmt.Loc, test)
+		if s.TestOrNil.Data != nil {
+			g.emitLoopTest(s.TestOrNil, end)
+		}
+		g.visitLoopBody(s.Body, labels, end, update)
+		g.markLabel(stmt.Loc, update)
+		if s.UpdateOrNil.Data != nil {
+			g.visitStmt(js_ast.Stmt{Loc: s.UpdateOrNil.Loc, Data: &js_ast.SExpr{Value: s.UpdateOrNil}}, nil)
+		}
+		g.emitJump(stmt.Loc, test)
+		g.markLabel(stmt.Loc, end)
+
+	case *js_ast.SForIn:
+		g.visitForIn(stmt.Loc, s, labels)
+
+	case *js_ast.SForOf:
+		g.visitForOf(stmt.Loc, s, labels)
+
+	case *js_ast.SLabel:
+		labels = append(append([]ast.Ref{}, labels...), s.Name.Ref)
+		switch s.Stmt.Data.(type) {
+		case *js_ast.SLabel, *js_ast.SFor, *js_ast.SForIn, *js_ast.SForOf, *js_ast.SWhile, *js_ast.SDoWhile:
+			g.visitStmt(s.Stmt, labels)
+
+		default:
+			end := g.newLabel()
+			g.pushTarget(generatorJumpTarget{labels: labels, breakLabel: end})
+			g.visitStmt(s.Stmt, nil)
+			g.popTarget()
+			g.markLabel(stmt.Loc, end)
+		}
+
+	case *js_ast.SSwitch:
+		g.visitSwitch(stmt.Loc, s)
+
+	case *js_ast.STry:
+		g.visitTry(stmt.Loc, s)
+
+	case *js_ast.SWith:
+		g.unsupported(stmt.Loc, "a \"with\" statement")
+
+	default:
+		g.emit(stmt)
+	}
+}
+
+func (g *generatorLowering) emitLoopTest(test js_ast.Expr, end int) {
+	// Avoid generating a test for "while (true)" and "for (;;)"
+	if boolean, sideEffects, ok := js_ast.ToBooleanWithSideEffects(test.Data); ok && boolean && sideEffects == js_ast.NoSideEffects {
+		return
+	}
+	g.emitJumpIf(test.Loc, js_ast.Not(g.visitExpr(test)), end)
+}
+
+func (g *generatorLowering) visitLoopBody(body js_ast.Stmt, labels []ast.Ref, breakLabel int, continueLabel int) {
+	g.pushTarget(generatorJumpTarget{labels: labels, breakLabel: breakLabel, continueLabel: continueLabel, isLoop: true})
+	g.visitStmt(body, nil)
+	g.popTarget()
+}
+
+func (g *generatorLowering) visitLocal(loc logger.Loc, s *js_ast.SLocal) {
+	for _, decl := range s.Decls {
+		g.hoistBinding(decl.Binding)
+		if decl.ValueOrNil.Data != nil {
+			value := g.visitExpr(decl.ValueOrNil)
+			g.emit(js_ast.AssignStmt(js_ast.ConvertBindingToExpr(decl.Binding, nil), value))
+		} else if s.Kind != js_ast.LocalVar {
+			// "let x" inside a loop must reset the value each iteration
+			if id, ok := decl.Binding.Data.(*js_ast.BIdentifier); ok {
+				g.emitAssign(decl.Binding.Loc, id.Ref, js_ast.Expr{Loc: decl.Binding.Loc, Data: js_ast.EUndefinedShared})
+			}
+		}
+	}
+}
+
+// "for (x in y) z" becomes a loop over a snapshot of the keys:
+//
+//	_b = [];
+//	for (_c in y) _b.push(_c);
+//	_d = 0;
+//	while (_d < _b.length) { x = _b[_d]; z; _d++ }
+//
+// Note that unlike a native "for-in" loop, keys that are deleted while the
+// loop is running are still visited.
+func (g *generatorLowering) visitForIn(loc logger.Loc, s *js_ast.SForIn, labels []ast.Ref) {
+	object := g.visitExpr(s.Value)
+	keysRef, keyRef, indexRef := g.tempRef(), g.tempRef(), g.tempRef()
+
+	g.emitAssign(loc, keysRef, js_ast.Expr{Loc: loc, Data: &js_ast.EArray{}})
+	g.emit(js_ast.Stmt{Loc: loc, Data: &js_ast.SForIn{
+		Init:  js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: g.ident(loc, keyRef)}},
+		Value: object,
+		Body: js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+			Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: g.ident(loc, keysRef), Name: "push", NameLoc: loc}},
+			Args:   []js_ast.Expr{g.ident(loc, keyRef)},
+			Kind:   js_ast.TargetWasOriginallyPropertyAccess,
+		}}}},
+	}})
+	g.emitAssign(loc, indexRef, g.number(loc, 0))
+
+	test, update, end := g.newLabel(), g.newLabel(), g.newLabel()
+	g.markLabel(loc, test)
+	g.emitJumpIf(loc, js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+		Op:    js_ast.BinOpGe,
+		Left:  g.ident(loc, indexRef),
+		Right: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: g.ident(loc, keysRef), Name: "length", NameLoc: loc}},
+	}}, end)
+
+	// Assign the current key to the loop variable
+	var target js_ast.Expr
+	switch init := s.Init.Data.(type) {
+	case *js_ast.SLocal:
+		g.hoistBinding(init.Decls[0].Binding)
+		target = js_ast.ConvertBindingToExpr(init.Decls[0].Binding, nil)
+	case *js_ast.SExpr:
+		target = g.captureAssignTarget(init.Value)
+	}
+	g.emit(js_ast.AssignStmt(target, js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{
+		Target: g.ident(loc, keysRef),
+		Index:  g.ident(loc, indexRef),
+	}}))
+
+	g.visitLoopBody(s.Body, labels, end, update)
+	g.markLabel(loc, update)
+	g.emit(js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: js_ast.Expr{Loc: loc, Data: &js_ast.EUnary{
+		Op:    js_ast.UnOpPostInc,
+		Value: g.ident(loc, indexRef),
+	}}}})
+	g.emitJump(loc, test)
+	g.markLabel(loc, end)
+}
+
+// "for (x of y) z" is first turned into a "for" loop that uses the iterator
+// protocol directly, similar to what is done for "for await" loops. This is
+// then transformed like any other statement:
+//
+//	try {
+//	  for (iter = __iterator(y); more = !(temp = iter.next()).done; more = false) {
+//	    x = temp.value;
+//	    z;
+//	  }
+//	} catch (temp) {
+//	  error = [temp];
+//	} finally {
+//	  try {
+//	    more && (temp = iter.return) && temp.call(iter);
+//	  } finally {
+//	    if (error) throw error[0];
+//	  }
+//	}
+func (g *generatorLowering) visitForOf(loc logger.Loc, s *js_ast.SForOf, labels []ast.Ref) {
+	p := g.p
+	iterRef, moreRef, tempRef, errorRef := g.tempRef(), g.tempRef(), g.tempRef(), g.tempRef()
+	tempValue := js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: g.ident(loc, tempRef), Name: "value", NameLoc: loc}}
+
+	// Assign the current value to the loop variable at the start of the body
+	var bodyStmts []js_ast.Stmt
+	switch init := s.Init.Data.(type) {
+	case *js_ast.SLocal:
+		bodyStmts = append(bodyStmts, js_ast.Stmt{Loc: s.Init.Loc, Data: &js_ast.SLocal{
+			Kind:  init.Kind,
+			Decls: []js_ast.Decl{{Binding: init.Decls[0].Binding, ValueOrNil: tempValue}},
+		}})
+	case *js_ast.SExpr:
+		bodyStmts = append(bodyStmts, js_ast.AssignStmt(init.Value, tempValue))
+	}
+	if block, ok := s.Body.Data.(*js_ast.SBlock); ok {
+		bodyStmts = append(bodyStmts, block.Stmts...)
+	} else {
+		bodyStmts = append(bodyStmts, s.Body)
+	}
+
+	loop := js_ast.Stmt{Loc: loc, Data: &js_ast.SFor{
+		InitOrNil: js_ast.AssignStmt(g.ident(loc, iterRef), p.callRuntime(loc, "__iterator", []js_ast.Expr{s.Value})),
+		TestOrNil: js_ast.Assign(g.ident(loc, moreRef), js_ast.Not(js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+			Target: js_ast.Assign(g.ident(loc, tempRef), js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+				Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: g.ident(loc, iterRef), Name: "next", NameLoc: loc}},
+				Kind:   js_ast.TargetWasOriginallyPropertyAccess,
+			}}),
+			Name:    "done",
+			NameLoc: loc,
+		}})),
+		UpdateOrNil: js_ast.Assign(g.ident(loc, moreRef), js_ast.Expr{Loc: loc, Data: &js_ast.EBoolean{Value: false}}),
+		Body:        js_ast.Stmt{Loc: s.Body.Loc, Data: &js_ast.SBlock{Stmts: bodyStmts}},
+	}}
+
+	// Keep any labels on the inner loop so "break" and "continue" still work
+	for i := len(labels) - 1; i >= 0; i-- {
+		loop = js_ast.Stmt{Loc: loc, Data: &js_ast.SLabel{Name: ast.LocRef{Loc: loc, Ref: labels[i]}, Stmt: loop}}
+	}
+
+	closeIter := js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+		Op: js_ast.BinOpLogicalAnd,
+		Left: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+			Op:   js_ast.BinOpLogicalAnd,
+			Left: g.ident(loc, moreRef),
+			Right: js_ast.Assign(g.ident(loc, tempRef), js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+				Target:  g.ident(loc, iterRef),
+				Name:    "return",
+				NameLoc: loc,
+			}}),
+		}},
+		Right: js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+			Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: g.ident(loc, tempRef), Name: "call", NameLoc: loc}},
+			Args:   []js_ast.Expr{g.ident(loc, iterRef)},
+			Kind:   js_ast.TargetWasOriginallyPropertyAccess,
+		}},
+	}}
+
+	g.visitStmt(js_ast.Stmt{Loc: loc, Data: &js_ast.STry{
+		Block: js_ast.SBlock{Stmts: []js_ast.Stmt{loop}},
+		Catch: &js_ast.Catch{
+			Loc:          loc,
+			BindingOrNil: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: tempRef}},
+			Block: js_ast.SBlock{Stmts: []js_ast.Stmt{js_ast.AssignStmt(g.ident(loc, errorRef),
+				js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: []js_ast.Expr{g.ident(loc, tempRef)}, IsSingleLine: true}})}},
+		},
+		Finally: &js_ast.Finally{Loc: loc, Block: js_ast.SBlock{Stmts: []js_ast.Stmt{{Loc: loc, Data: &js_ast.STry{
+			Block: js_ast.SBlock{Stmts: []js_ast.Stmt{{Loc: loc, Data: &js_ast.SExpr{Value: closeIter}}}},
+			Finally: &js_ast.Finally{Loc: loc, Block: js_ast.SBlock{Stmts: []js_ast.Stmt{{Loc: loc, Data: &js_ast.SIf{
+				Test: g.ident(loc, errorRef),
+				Yes: js_ast.Stmt{Loc: loc, Data: &js_ast.SThrow{Value: js_ast.Expr{Loc: loc, Data: &js_ast.EIndex{
+					Target: g.ident(loc, errorRef),
+					Index:  g.number(loc, 0),
+				}}}},
+			}}}}},
+		}}}}},
+	}}, nil)
+}
+
+func (g *generatorLowering) visitSwitch(loc logger.Loc, s *js_ast.SSwitch) {
+	// Evaluate the cases in order and jump to the first one that matches
+	testRef := g.tempRef()
+	g.emitAssign(loc, testRef, g.visitExpr(s.Test))
+	end := g.newLabel()
+	defaultLabel := end
+	caseLabels := make([]int, len(s.Cases))
+	for i, c := range s.Cases {
+		caseLabels[i] = g.newLabel()
+		if c.ValueOrNil.Data == nil {
+			defaultLabel = caseLabels[i]
+			continue
+		}
+		g.emitJumpIf(c.Loc, js_ast.Expr{Loc: c.Loc, Data: &js_ast.EBinary{
+			Op:    js_ast.BinOpStrictEq,
+			Left:  g.ident(c.Loc, testRef),
+			Right: g.visitExpr(c.ValueOrNil),
+		}}, caseLabels[i])
+	}
+	g.emitJump(loc, defaultLabel)
+
+	// Then emit the case bodies, which may fall through to the next one
+	g.pushTarget(generatorJumpTarget{breakLabel: end, isSwitch: true})
+	for i, c := range s.Cases {
+		g.markLabel(c.Loc, caseLabels[i])
+		for _, stmt := range c.Body {
+			g.visitStmt(stmt, nil)
+		}
+	}
+	g.popTarget()
+	g.markLabel(loc, end)
+}
+
+// Each "try" statement registers a region with the runtime using the labels
+// for the start of the "try" block, the "catch" block, the "finally" block,
+// and the end of the whole statement. The runtime then takes care of routing
+// errors to the "catch" block and running the "finally" block on the way out.
+func (g *generatorLowering) visitTry(loc logger.Loc, s *js_ast.STry) {
+	start, catchLabel, finallyLabel, end := g.newLabel(), -1, -1, g.newLabel()
+	items := []js_ast.Expr{g.labelExpr(loc, start), {Loc: loc, Data: js_ast.EMissingShared}, {Loc: loc, Data: js_ast.EMissingShared}, g.labelExpr(loc, end)}
+	if s.Catch != nil {
+		catchLabel = g.newLabel()
+		items[1] = g.labelExpr(s.Catch.Loc, catchLabel)
+	}
+	if s.Finally != nil {
+		finallyLabel = g.newLabel()
+		items[2] = g.labelExpr(s.Finally.Loc, finallyLabel)
+	}
+
+	// "_a.trys.push([1, 3, 4, 5])"
+	g.markLabel(loc, start)
+	g.emit(js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: js_ast.Expr{Loc: loc, Data: &js_ast.ECall{
+		Target: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: g.stateDot(loc, "trys"), Name: "push", NameLoc: loc}},
+		Args:   []js_ast.Expr{{Loc: loc, Data: &js_ast.EArray{Items: items, IsSingleLine: true}}},
+		Kind:   js_ast.TargetWasOriginallyPropertyAccess,
+	}}}})
+	for _, stmt := range s.Block.Stmts {
+		g.visitStmt(stmt, nil)
+	}
+	g.emitJump(loc, end)
+
+	if s.Catch != nil {
+		g.markLabel(s.Catch.Loc, catchLabel)
+		if s.Catch.BindingOrNil.Data != nil {
+			g.hoistBinding(s.Catch.BindingOrNil)
+			g.emit(js_ast.AssignStmt(js_ast.ConvertBindingToExpr(s.Catch.BindingOrNil, nil), g.sent(s.Catch.Loc)))
+		}
+		for _, stmt := range s.Catch.Block.Stmts {
+			g.visitStmt(stmt, nil)
+		}
+		g.emitJump(s.Catch.Loc, end)
+	}
+
+	if s.Finally != nil {
+		g.markLabel(s.Finally.Loc, finallyLabel)
+		for _, stmt := range s.Finally.Block.Stmts {
+			g.visitStmt(stmt, nil)
+		}
+		g.emit(g.returnOp(s.Finally.Loc, generatorOpEndFinally, js_ast.Expr{}))
+	}
+
+	g.markLabel(loc, end)
+}
+
+// Statements without "yield" are emitted as-is except for a few changes:
+// "var" declarations are hoisted, "return" becomes an operation, and jumps
+// to statements that were transformed become operations too.
+func (g *generatorLowering) rewriteStmt(stmt js_ast.Stmt) js_ast.Stmt {
+	switch s := stmt.Data.(type) {
+	case *js_ast.SBlock:
+		s.Stmts = g.rewriteStmts(s.Stmts)
+
+	case *js_ast.SLocal:
+		if s.Kind == js_ast.LocalVar {
+			var value js_ast.Expr
+			for _, decl := range s.Decls {
+				g.hoistBinding(decl.Binding)
+				if decl.ValueOrNil.Data != nil {
+					value = js_ast.JoinWithComma(value, js_ast.Assign(js_ast.ConvertBindingToExpr(decl.Binding, nil), decl.ValueOrNil))
+				}
+			}
+			if value.Data == nil {
+				return js_ast.Stmt{Loc: stmt.Loc, Data: js_ast.SEmptyShared}
+			}
+			return js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExpr{Value: value}}
+		}
+
+	case *js_ast.SReturn:
+		return g.returnOp(stmt.Loc, generatorOpReturn, s.ValueOrNil)
+
+	case *js_ast.SBreak:
+		if target := g.findTarget(s.Label, false); target != nil && !target.isNative {
+			return g.returnOp(stmt.Loc, generatorOpJump, g.labelExpr(stmt.Loc, target.breakLabel))
+		}
+
+	case *js_ast.SContinue:
+		if target := g.findTarget(s.Label, true); target != nil && !target.isNative {
+			return g.returnOp(stmt.Loc, generatorOpJump, g.labelExpr(stmt.Loc, target.continueLabel))
+		}
+
+	case *js_ast.SIf:
+		s.Yes = g.rewriteStmt(s.Yes)
+		if s.NoOrNil.Data != nil {
+			s.NoOrNil = g.rewriteStmt(s.NoOrNil)
+		}
+
+	case *js_ast.SFor:
+		if s.InitOrNil.Data != nil {
+			s.InitOrNil = g.rewriteStmt(s.InitOrNil)
+			if _, ok := s.InitOrNil.Data.(*js_ast.SEmpty); ok {
+				s.InitOrNil = js_ast.Stmt{}
+			}
+		}
+		s.Body = g.rewriteLoopBody(s.Body)
+
+	case *js_ast.SForIn:
+		s.Init = g.rewriteForInit(s.Init)
+		s.Body = g.rewriteLoopBody(s.Body)
+
+	case *js_ast.SForOf:
+		s.Init = g.rewriteForInit(s.Init)
+		s.Body = g.rewriteLoopBody(s.Body)
+
+	case *js_ast.SWhile:
+		s.Body = g.rewriteLoopBody(s.Body)
+
+	case *js_ast.SDoWhile:
+		s.Body = g.rewriteLoopBody(s.Body)
+
+	case *js_ast.SLabel:
+		g.pushTarget(generatorJumpTarget{labels: []ast.Ref{s.Name.Ref}, isNative: true})
+		s.Stmt = g.rewriteStmt(s.Stmt)
+		g.popTarget()
+
+	case *js_ast.SSwitch:
+		g.pushTarget(generatorJumpTarget{isSwitch: true, isNative: true})
+		for i := range s.Cases {
+			s.Cases[i].Body = g.rewriteStmts(s.Cases[i].Body)
+		}
+		g.popTarget()
+
+	case *js_ast.STry:
+		s.Block.Stmts = g.rewriteStmts(s.Block.Stmts)
+		if s.Catch != nil {
+			s.Catch.Block.Stmts = g.rewriteStmts(s.Catch.Block.Stmts)
+		}
+		if s.Finally != nil {
+			s.Finally.Block.Stmts = g.rewriteStmts(s.Finally.Block.Stmts)
+		}
+
+	case *js_ast.SWith:
+		s.Body = g.rewriteStmt(s.Body)
+	}
+
+	return stmt
+}
+
+func (g *generatorLowering) rewriteStmts(stmts []js_ast.Stmt) []js_ast.Stmt {
+	end := 0
+	for _, stmt := range stmts {
+		stmt = g.rewriteStmt(stmt)
+		if _, ok := stmt.Data.(*js_ast.SEmpty); ok {
+			continue
+		}
+		stmts[end] = stmt
+		end++
+	}
+	return stmts[:end]
+}
+
+func (g *generatorLowering) rewriteLoopBody(body js_ast.Stmt) js_ast.Stmt {
+	g.pushTarget(generatorJumpTarget{isLoop: true, isNative: true})
+	body = g.rewriteStmt(body)
+	g.popTarget()
+	return body
+}
+
+// "for (var x in y)" becomes "for (x in y)" since "x" is hoisted
+func (g *generatorLowering) rewriteForInit(init js_ast.Stmt) js_ast.Stmt {
+	if s, ok := init.Data.(*js_ast.SLocal); ok && s.Kind == js_ast.LocalVar {
+		g.hoistBinding(s.Decls[0].Binding)
+		return js_ast.Stmt{Loc: init.Loc, Data: &js_ast.SExpr{Value: js_ast.ConvertBindingToExpr(s.Decls[0].Binding, nil)}}
+	}
+	return init
+}
+
+// Store a value in a temporary variable so that it's not affected by any code
+// that runs during a later "yield" in the same expression
+func (g *generatorLowering) spill(expr js_ast.Expr) js_ast.Expr {
+	switch expr.Data.(type) {
+	case *js_ast.ENull, *js_ast.EUndefined, *js_ast.EBoolean, *js_ast.ENumber, *js_ast.EBigInt,
+		*js_ast.EString, *js_ast.EThis, *js_ast.EFunction, *js_ast.EArrow, *js_ast.EPrivateIdentifier:
+		return expr
+	}
+	ref := g.tempRef()
+	g.emitAssign(expr.Loc, ref, expr)
+	return g.ident(expr.Loc, ref)
+}
+
+// Every expression before the last one containing a "yield" is spilled to
+// preserve the evaluation order
+func (g *generatorLowering) visitSlots(slots []*js_ast.Expr) {
+	last := -1
+	for i, slot := range slots {
+		if generatorExprContainsYield(*slot) {
+			last = i
+		}
+	}
+	for i := 0; i <= last; i++ {
+		value := g.visitExpr(*slots[i])
+		if i < last {
+			if spread, ok := value.Data.(*js_ast.ESpread); ok {
+				spread.Value = g.spill(spread.Value)
+			} else {
+				value = g.spill(value)
+			}
+		}
+		*slots[i] = value
+	}
+}
+
+func (g *generatorLowering) visitExprs(exprs []js_ast.Expr) {
+	slots := make([]*js_ast.Expr, len(exprs))
+	for i := range exprs {
+		slots[i] = &exprs[i]
+	}
+	g.visitSlots(slots)
+}
+
+// The object and key of a property access are evaluated before the value
+// that's assigned to it, so they must be spilled
+func (g *generatorLowering) captureAssignTarget(target js_ast.Expr) js_ast.Expr {
+	switch t := target.Data.(type) {
+	case *js_ast.EDot:
+		return js_ast.Expr{Loc: target.Loc, Data: &js_ast.EDot{
+			Target:  g.spill(g.visitExpr(t.Target)),
+			Name:    t.Name,
+			NameLoc: t.NameLoc,
+		}}
+
+	case *js_ast.EIndex:
+		object := g.spill(g.visitExpr(t.Target))
+		return js_ast.Expr{Loc: target.Loc, Data: &js_ast.EIndex{
+			Target: object,
+			Index:  g.spill(g.visitExpr(t.Index)),
+		}}
+
+	case *js_ast.EIdentifier:
+		return target
+	}
+
+	if generatorExprContainsYield(target) {
+		g.unsupported(target.Loc, "a destructuring assignment")
+	}
+	return target
+}
+
+func cloneAssignTarget(target js_ast.Expr) js_ast.Expr {
+	switch t := target.Data.(type) {
+	case *js_ast.EDot:
+		clone := *t
+		return js_ast.Expr{Loc: target.Loc, Data: &clone}
+
+	case *js_ast.EIndex:
+		clone := *t
+		return js_ast.Expr{Loc: target.Loc, Data: &clone}
+
+	case *js_ast.EIdentifier:
+		clone := *t
+		return js_ast.Expr{Loc: target.Loc, Data: &clone}
+	}
+	return target
+}
+
+func (g *generatorLowering) visitExpr(expr js_ast.Expr) js_ast.Expr {
+	if !generatorExprContainsYield(expr) {
+		return expr
+	}
+
+	switch e := expr.Data.(type) {
+	case *js_ast.EYield:
+		var value js_ast.Expr
+		if e.ValueOrNil.Data != nil {
+			value = g.visitExpr(e.ValueOrNil)
+		}
+		op := generatorOpYield
+		if e.IsStar {
+			op = generatorOpYieldStar
+		}
+		g.emit(g.returnOp(expr.Loc, op, value))
+		g.markLabel(expr.Loc, g.newLabel())
+		return g.sent(expr.Loc)
+
+	case *js_ast.EBinary:
+		return g.visitBinary(expr, e)
+
+	case *js_ast.EUnary:
+		e.Value = g.visitExpr(e.Value)
+
+	case *js_ast.EIf:
+		if !generatorExprContainsYield(e.Yes) && !generatorExprContainsYield(e.No) {
+			e.Test = g.visitExpr(e.Test)
+			break
+		}
+		resultRef := g.tempRef()
+		otherwise, end := g.newLabel(), g.newLabel()
+		g.emitJumpIf(e.Test.Loc, js_ast.Not(g.visitExpr(e.Test)), otherwise)
+		g.emitAssign(e.Yes.Loc, resultRef, g.visitExpr(e.Yes))
+		g.emitJump(e.Yes.Loc, end)
+		g.markLabel(e.No.Loc, otherwise)
+		g.emitAssign(e.No.Loc, resultRef, g.visitExpr(e.No))
+		g.markLabel(expr.Loc, end)
+		return g.ident(expr.Loc, resultRef)
+
+	case *js_ast.EDot:
+		e.Target = g.visitExpr(e.Target)
+
+	case *js_ast.EIndex:
+		g.visitSlots([]*js_ast.Expr{&e.Target, &e.Index})
+
+	case *js_ast.ECall:
+		if !generatorExprsContainYield(e.Args) {
+			e.Target = g.visitExpr(e.Target)
+			break
+		}
+
+		// Calling a spilled method must preserve the value of "this":
+		//
+		//   a.b(yield)  =>  _b = a; _c = _b.b; ... _c.call(_b, _a.sent())
+		//
+		var thisArg js_ast.Expr
+		switch t := e.Target.Data.(type) {
+		case *js_ast.EDot:
+			thisArg = g.spill(g.visitExpr(t.Target))
+			e.Target = g.spill(js_ast.Expr{Loc: e.Target.Loc, Data: &js_ast.EDot{Target: thisArg, Name: t.Name, NameLoc: t.NameLoc}})
+
+		case *js_ast.EIndex:
+			thisArg = g.spill(g.visitExpr(t.Target))
+			index := g.spill(g.visitExpr(t.Index))
+			e.Target = g.spill(js_ast.Expr{Loc: e.Target.Loc, Data: &js_ast.EIndex{Target: thisArg, Index: index}})
+
+		default:
+			e.Target = g.spill(g.visitExpr(e.Target))
+		}
+		g.visitExprs(e.Args)
+		if thisArg.Data != nil {
+			e.Target = js_ast.Expr{Loc: e.Target.Loc, Data: &js_ast.EDot{Target: e.Target, Name: "call", NameLoc: e.Target.Loc}}
+			e.Args = append([]js_ast.Expr{cloneAssignTarget(thisArg)}, e.Args...)
+			e.Kind = js_ast.TargetWasOriginallyPropertyAccess
+		}
+
+	case *js_ast.ENew:
+		slots := []*js_ast.Expr{&e.Target}
+		for i := range e.Args {
+			slots = append(slots, &e.Args[i])
+		}
+		g.visitSlots(slots)
+
+	case *js_ast.EArray:
+		g.visitExprs(e.Items)
+
+	case *js_ast.EObject:
+		var slots []*js_ast.Expr
+		for i := range e.Properties {
+			property := &e.Properties[i]
+			if property.Flags.Has(js_ast.PropertyIsComputed) {
+				slots = append(slots, &property.Key)
+			}
+			if property.ValueOrNil.Data != nil {
+				slots = append(slots, &property.ValueOrNil)
+			}
+		}
+		g.visitSlots(slots)
+
+	case *js_ast.ESpread:
+		e.Value = g.visitExpr(e.Value)
+
+	case *js_ast.ETemplate:
+		slots := []*js_ast.Expr{}
+		if e.TagOrNil.Data != nil {
+			slots = append(slots, &e.TagOrNil)
+		}
+		for i := range e.Parts {
+			slots = append(slots, &e.Parts[i].Value)
+		}
+		g.visitSlots(slots)
+
+	case *js_ast.EImportCall:
+		slots := []*js_ast.Expr{&e.Expr}
+		if e.OptionsOrNil.Data != nil {
+			slots = append(slots, &e.OptionsOrNil)
+		}
+		g.visitSlots(slots)
+
+	case *js_ast.EAnnotation:
+		e.Value = g.visitExpr(e.Value)
+
+	case *js_ast.EClass:
+		g.unsupported(expr.Loc, "a class")
+
+	default:
+		g.unsupported(expr.Loc, "this expression")
+	}
+
+	return expr
+}
+
+func (g *generatorLowering) visitBinary(expr js_ast.Expr, e *js_ast.EBinary) js_ast.Expr {
+	switch e.Op {
+	case js_ast.BinOpComma:
+		left := g.visitExpr(e.Left)
+		if _, ok := left.Data.(*js_ast.EIdentifier); !ok {
+			g.emit(js_ast.Stmt{Loc: left.Loc, Data: &js_ast.SExpr{Value: left}})
+		}
+		return g.visitExpr(e.Right)
+
+	case js_ast.BinOpLogicalAnd, js_ast.BinOpLogicalOr, js_ast.BinOpNullishCoalescing:
+		if !generatorExprContainsYield(e.Right) {
+			e.Left = g.visitExpr(e.Left)
+			return expr
+		}
+
+		// "a && (yield)" => "_b = a; if (!_b) jump end; _b = yield; end:"
+		resultRef := g.tempRef()
+		end := g.newLabel()
+		g.emitAssign(e.Left.Loc, resultRef, g.visitExpr(e.Left))
+		g.emitJumpIf(expr.Loc, g.shortCircuitTest(expr.Loc, e.Op, g.ident(expr.Loc, resultRef)), end)
+		g.emitAssign(e.Right.Loc, resultRef, g.visitExpr(e.Right))
+		g.markLabel(expr.Loc, end)
+		return g.ident(expr.Loc, resultRef)
+
+	case js_ast.BinOpLogicalAndAssign, js_ast.BinOpLogicalOrAssign, js_ast.BinOpNullishCoalescingAssign:
+		if !generatorExprContainsYield(e.Right) {
+			e.Left = g.visitExpr(e.Left)
+			return expr
+		}
+
+		// "a.b ||= yield" => "_b = a; _c = _b.b; if (_c) jump end; _c = _b.b = yield; end:"
+		target := g.captureAssignTarget(e.Left)
+		resultRef := g.tempRef()
+		end := g.newLabel()
+		op := js_ast.BinOpLogicalAnd
+		if e.Op == js_ast.BinOpLogicalOrAssign {
+			op = js_ast.BinOpLogicalOr
+		} else if e.Op == js_ast.BinOpNullishCoalescingAssign {
+			op = js_ast.BinOpNullishCoalescing
+		}
+		g.emitAssign(e.Left.Loc, resultRef, cloneAssignTarget(target))
+		g.emitJumpIf(expr.Loc, g.shortCircuitTest(expr.Loc, op, g.ident(expr.Loc, resultRef)), end)
+		g.emitAssign(e.Right.Loc, resultRef, js_ast.Assign(target, g.visitExpr(e.Right)))
+		g.markLabel(expr.Loc, end)
+		return g.ident(expr.Loc, resultRef)
+
+	case js_ast.BinOpAssign:
+		if !generatorExprContainsYield(e.Right) {
+			e.Left = g.captureAssignTarget(e.Left)
+			return expr
+		}
+		e.Left = g.captureAssignTarget(e.Left)
+		e.Right = g.visitExpr(e.Right)
+		return expr
+	}
+
+	if binOp, ok := generatorCompoundAssignOps[e.Op]; ok {
+		if !generatorExprContainsYield(e.Right) {
+			e.Left = g.visitExpr(e.Left)
+			return expr
+		}
+
+		// "a.b += yield" => "_b = a; _c = _b.b; ... _b.b = _c + _a.sent()"
+		target := g.captureAssignTarget(e.Left)
+		current := g.spill(cloneAssignTarget(target))
+		return js_ast.Assign(target, js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EBinary{
+			Op:    binOp,
+			Left:  current,
+			Right: g.visitExpr(e.Right),
+		}})
+	}
+
+	g.visitSlots([]*js_ast.Expr{&e.Left, &e.Right})
+	return expr
+}
+
+// This returns a test that is true when the right operand is skipped
+func (g *generatorLowering) shortCircuitTest(loc logger.Loc, op js_ast.OpCode, value js_ast.Expr) js_ast.Expr {
+	switch op {
+	case js_ast.BinOpLogicalAnd:
+		return js_ast.Not(value)
+	case js_ast.BinOpLogicalOr:
+		return value
+	default:
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{Op: js_ast.BinOpLooseNe, Left: value, Right: js_ast.Expr{Loc: loc, Data: js_ast.ENullShared}}}
+	}
+}
+
+var generatorCompoundAssignOps = map[js_ast.OpCode]js_ast.OpCode{
+	js_ast.BinOpAddAssign:        js_ast.BinOpAdd,
+	js_ast.BinOpSubAssign:        js_ast.BinOpSub,
+	js_ast.BinOpMulAssign:        js_ast.BinOpMul,
+	js_ast.BinOpDivAssign:        js_ast.BinOpDiv,
+	js_ast.BinOpRemAssign:        js_ast.BinOpRem,
+	js_ast.BinOpPowAssign:        js_ast.BinOpPow,
+	js_ast.BinOpShlAssign:        js_ast.BinOpShl,
+	js_ast.BinOpShrAssign:        js_ast.BinOpShr,
+	js_ast.BinOpUShrAssign:       js_ast.BinOpUShr,
+	js_ast.BinOpBitwiseOrAssign:  js_ast.BinOpBitwiseOr,
+	js_ast.BinOpBitwiseAndAssign: js_ast.BinOpBitwiseAnd,
+	js_ast.BinOpBitwiseXorAssign: js_ast.BinOpBitwiseXor,
+}
+
+func generatorExprsContainYield(exprs []js_ast.Expr) bool {
+	for _, expr := range exprs {
+		if generatorExprContainsYield(expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// This doesn't look inside nested functions since they have their own "yield"
+func generatorExprContainsYield(expr js_ast.Expr) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EYield:
+		return true
+
+	case *js_ast.EArray:
+		return generatorExprsContainYield(e.Items)
+
+	case *js_ast.EUnary:
+		return generatorExprContainsYield(e.Value)
+
+	case *js_ast.EBinary:
+		return generatorExprContainsYield(e.Left) || generatorExprContainsYield(e.Right)
+
+	case *js_ast.EIf:
+		return generatorExprContainsYield(e.Test) || generatorExprContainsYield(e.Yes) || generatorExprContainsYield(e.No)
+
+	case *js_ast.ENew:
+		return generatorExprContainsYield(e.Target) || generatorExprsContainYield(e.Args)
+
+	case *js_ast.ECall:
+		return generatorExprContainsYield(e.Target) || generatorExprsContainYield(e.Args)
+
+	case *js_ast.EDot:
+		return generatorExprContainsYield(e.Target)
+
+	case *js_ast.EIndex:
+		return generatorExprContainsYield(e.Target) || generatorExprContainsYield(e.Index)
+
+	case *js_ast.ESpread:
+		return generatorExprContainsYield(e.Value)
+
+	case *js_ast.EAwait:
+		return generatorExprContainsYield(e.Value)
+
+	case *js_ast.EAnnotation:
+		return generatorExprContainsYield(e.Value)
+
+	case *js_ast.EInlinedEnum:
+		return generatorExprContainsYield(e.Value)
+
+	case *js_ast.EImportCall:
+		return generatorExprContainsYield(e.Expr) || generatorExprContainsYield(e.OptionsOrNil)
+
+	case *js_ast.ETemplate:
+		if generatorExprContainsYield(e.TagOrNil) {
+			return true
+		}
+		for _, part := range e.Parts {
+			if generatorExprContainsYield(part.Value) {
+				return true
+			}
+		}
+
+	case *js_ast.EObject:
+		return generatorPropertiesContainYield(e.Properties)
+
+	case *js_ast.EClass:
+		return generatorClassContainsYield(&e.Class)
+
+	case *js_ast.EJSXElement:
+		if generatorExprContainsYield(e.TagOrNil) || generatorPropertiesContainYield(e.Properties) {
+			return true
+		}
+		for _, child := range e.NullableChildren {
+			if generatorExprContainsYield(child) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func generatorPropertiesContainYield(properties []js_ast.Property) bool {
+	for _, property := range properties {
+		if generatorExprContainsYield(property.Key) || generatorExprContainsYield(property.ValueOrNil) ||
+			generatorExprContainsYield(property.InitializerOrNil) {
+			return true
+		}
+	}
+	return false
+}
+
+func generatorClassContainsYield(class *js_ast.Class) bool {
+	if generatorExprContainsYield(class.ExtendsOrNil) {
+		return true
+	}
+	for _, property := range class.Properties {
+		if property.Flags.Has(js_ast.PropertyIsComputed) && generatorExprContainsYield(property.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+func generatorBindingContainsYield(binding js_ast.Binding) bool {
+	switch b := binding.Data.(type) {
+	case *js_ast.BArray:
+		for _, item := range b.Items {
+			if generatorBindingContainsYield(item.Binding) || generatorExprContainsYield(item.DefaultValueOrNil) {
+				return true
+			}
+		}
+
+	case *js_ast.BObject:
+		for _, property := range b.Properties {
+			if generatorExprContainsYield(property.Key) || generatorBindingContainsYield(property.Value) ||
+				generatorExprContainsYield(property.DefaultValueOrNil) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func generatorStmtsContainYield(stmts []js_ast.Stmt) bool {
+	for _, stmt := range stmts {
+		if generatorStmtContainsYield(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+func generatorStmtContainsYield(stmt js_ast.Stmt) bool {
+	switch s := stmt.Data.(type) {
+	case *js_ast.SBlock:
+		return generatorStmtsContainYield(s.Stmts)
+
+	case *js_ast.SExpr:
+		return generatorExprContainsYield(s.Value)
+
+	case *js_ast.SReturn:
+		return generatorExprContainsYield(s.ValueOrNil)
+
+	case *js_ast.SThrow:
+		return generatorExprContainsYield(s.Value)
+
+	case *js_ast.SLocal:
+		for _, decl := range s.Decls {
+			if generatorBindingContainsYield(decl.Binding) || generatorExprContainsYield(decl.ValueOrNil) {
+				return true
+			}
+		}
+
+	case *js_ast.SIf:
+		return generatorExprContainsYield(s.Test) || generatorStmtContainsYield(s.Yes) ||
+			(s.NoOrNil.Data != nil && generatorStmtContainsYield(s.NoOrNil))
+
+	case *js_ast.SFor:
+		return (s.InitOrNil.Data != nil && generatorStmtContainsYield(s.InitOrNil)) || generatorExprContainsYield(s.TestOrNil) ||
+			generatorExprContainsYield(s.UpdateOrNil) || generatorStmtContainsYield(s.Body)
+
+	case *js_ast.SForIn:
+		return generatorStmtContainsYield(s.Init) || generatorExprContainsYield(s.Value) || generatorStmtContainsYield(s.Body)
+
+	case *js_ast.SForOf:
+		return generatorStmtContainsYield(s.Init) || generatorExprContainsYield(s.Value) || generatorStmtContainsYield(s.Body)
+
+	case *js_ast.SWhile:
+		return generatorExprContainsYield(s.Test) || generatorStmtContainsYield(s.Body)
+
+	case *js_ast.SDoWhile:
+		return generatorStmtContainsYield(s.Body) || generatorExprContainsYield(s.Test)
+
+	case *js_ast.SLabel:
+		return generatorStmtContainsYield(s.Stmt)
+
+	case *js_ast.SWith:
+		return generatorExprContainsYield(s.Value) || generatorStmtContainsYield(s.Body)
+
+	case *js_ast.SSwitch:
+		if generatorExprContainsYield(s.Test) {
+			return true
+		}
+		for _, c := range s.Cases {
+			if generatorExprContainsYield(c.ValueOrNil) || generatorStmtsContainYield(c.Body) {
+				return true
+			}
+		}
+
+	case *js_ast.STry:
+		return generatorStmtsContainYield(s.Block.Stmts) ||
+			(s.Catch != nil && (generatorBindingContainsYield(s.Catch.BindingOrNil) || generatorStmtsContainYield(s.Catch.Block.Stmts))) ||
+			(s.Finally != nil && generatorStmtsContainYield(s.Finally.Block.Stmts))
+
+	case *js_ast.SClass:
+		return generatorClassContainsYield(&s.Class)
+	}
+
+	return false
+}
+
+// This is information about a "let" or "const" binding that will be turned
+// into a "var" binding because the target environment doesn't support them.
+type loweredLetOrConst struct {
+	// This is the loop that declares this binding in its head, if any
+	loop *letOrConstLoop
+
+	// This is the location of the declaration, which is used for warnings
+	loc logger.Loc
+
+	// If true, a function inside this binding's scope references it. Each loop
+	// iteration creates a new binding, so a loop that declares a captured
+	// binding must move its body into a function to preserve this behavior.
+	isCaptured bool
+
+	// If true, the body of the loop that declares this binding in its head
+	// assigns to it. The new value must be copied into the binding for the
+	// next iteration if the loop body is moved into a function.
+	isAssignedInLoopBody bool
+}
+
+// This is information about a loop that may need to be moved into a function
+// so that each iteration has its own copy of the "let" and "const" bindings.
+type letOrConstLoop struct {
+	parentFnOnly    *letOrConstLoop
+	parentFnOrArrow *letOrConstLoop
+
+	labels        []ast.Ref
+	headRefs      []ast.Ref
+	bodyRefs      []ast.Ref
+	argumentsUses []*js_ast.EIdentifier
+
+	// These are set to the keyword that prevents this loop body from being
+	// moved into a function, if any
+	yieldOrAwaitKeyword     string
+	superOrNewTargetKeyword string
+
+	usesThis       bool
+	isVisitingBody bool
+}
+
+func (p *parser) markLetOrConstForLowering(decls []js_ast.Decl) {
+	if !p.options.unsupportedJSFeatures.Has(compat.ConstAndLet) {
+		return
+	}
+	if p.loweredLetOrConstRefs == nil {
+		p.loweredLetOrConstRefs = make(map[ast.Ref]*loweredLetOrConst)
+	}
+	js_ast.ForEachIdentifierBindingInDecls(decls, func(loc logger.Loc, b *js_ast.BIdentifier) {
+		p.loweredLetOrConstRefs[b.Ref] = &loweredLetOrConst{loc: loc}
+	})
+}
+
+// A "var" binding is visible in the whole function, not just in the block that
+// declares it. So converting sibling "let" bindings with the same name to "var"
+// could cause them to collide. Avoid this by giving them names in the scope of
+// the function instead of in the scope of the block.
+func (p *parser) hoistLoweredLetOrConst(ref ast.Ref) {
+	scope := p.currentScope
+	for !scope.Kind.StopsHoisting() {
+		scope = scope.Parent
+	}
+	if scope != p.currentScope {
+		scope.Generated = append(scope.Generated, ref)
+		if scope == p.moduleScope {
+			p.declaredSymbols = append(p.declaredSymbols, js_ast.DeclaredSymbol{Ref: ref, IsTopLevel: true})
+		}
+	}
+}
+
+// This is called on each "let" or "const" declaration before it's turned into
+// a "var" declaration
+func (p *parser) lowerLetOrConstDecls(s *js_ast.SLocal, isLoopHead bool) {
+	if p.loweredLetOrConstRefs == nil {
+		return
+	}
+	loop := p.fnOrArrowDataVisit.letOrConstLoop
+
+	for i := range s.Decls {
+		decl := &s.Decls[i]
+
+		// A "let" without an initializer inside a loop must be reset on every
+		// iteration: "for (;;) { let x; x ||= 1 }" => "for (;;) { var x = void 0; x ||= 1 }"
+		if !isLoopHead && s.Kind == js_ast.LocalLet && decl.ValueOrNil.Data == nil && p.fnOrArrowDataVisit.isInsideLoop {
+			if id, ok := decl.Binding.Data.(*js_ast.BIdentifier); ok {
+				if _, ok := p.loweredLetOrConstRefs[id.Ref]; ok {
+					decl.ValueOrNil = js_ast.Expr{Loc: decl.Binding.Loc, Data: js_ast.EUndefinedShared}
+				}
+			}
+		}
+
+		js_ast.ForEachIdentifierBinding(decl.Binding, func(loc logger.Loc, b *js_ast.BIdentifier) {
+			info, ok := p.loweredLetOrConstRefs[b.Ref]
+			if !ok {
+				return
+			}
+			p.hoistLoweredLetOrConst(b.Ref)
+			if loop != nil {
+				if isLoopHead {
+					info.loop = loop
+					loop.headRefs = append(loop.headRefs, b.Ref)
+				} else {
+					loop.bodyRefs = append(loop.bodyRefs, b.Ref)
+				}
+			}
+		})
+	}
+}
+
+// Returns whether this reference to a lowered "let" or "const" binding comes
+// before the declaration in the same function (and must therefore throw), and
+// whether this reference is inside a nested function.
+func (p *parser) classifyLetOrConstReference(ref ast.Ref, loc logger.Loc) (isBeforeDeclaration bool, isInsideClosure bool) {
+	name := p.symbols[ref.InnerIndex].OriginalName
+	for scope := p.currentScope; scope != nil; scope = scope.Parent {
+		if member, ok := scope.Members[name]; ok && member.Ref == ref {
+			return !isInsideClosure && loc.Start < member.Loc.Start, isInsideClosure
+		}
+		if scope.Kind == js_ast.ScopeClassBody || scope.Kind.StopsHoisting() {
+			isInsideClosure = true
+		}
+	}
+	return false, isInsideClosure
+}
+
+func (p *parser) visitLetOrConstReference(loc logger.Loc, ref ast.Ref, assignTarget js_ast.AssignTarget) (js_ast.Expr, bool) {
+	info, ok := p.loweredLetOrConstRefs[ref]
+	if !ok {
+		return js_ast.Expr{}, false
+	}
+	isBeforeDeclaration, isInsideClosure := p.classifyLetOrConstReference(ref, loc)
+	if isInsideClosure {
+		info.isCaptured = true
+	}
+
+	// Assignments are handled by the parent expression
+	if assignTarget != js_ast.AssignTargetNone {
+		if info.loop != nil && info.loop.isVisitingBody {
+			info.isAssignedInLoopBody = true
+		}
+		return js_ast.Expr{}, false
+	}
+
+	// Reading from the binding before it's declared must throw
+	if isBeforeDeclaration {
+		name := p.symbols[ref.InnerIndex].OriginalName
+		return p.callRuntime(loc, "__earlyAccess", []js_ast.Expr{{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(name)}}}), true
+	}
+	return js_ast.Expr{}, false
+}
+
+// Assigning to a "let" or "const" binding throws in some cases where assigning
+// to a "var" binding doesn't. Generate code that throws in those cases instead:
+//
+//	"x = y; let x" => "y, __earlyAccess('x'); var x"
+//	"const x = 1; x = y" => "var x = 1; y, __constAssign('x')"
+//	"const x = 1; x++" => "var x = 1; __constAssign('x')"
+func (p *parser) maybeLowerLetOrConstAssign(op js_ast.OpCode, target js_ast.Expr, valueOrNil js_ast.Expr) (js_ast.Expr, bool) {
+	id, ok := target.Data.(*js_ast.EIdentifier)
+	if !ok {
+		return js_ast.Expr{}, false
+	}
+	if _, ok := p.loweredLetOrConstRefs[id.Ref]; !ok {
+		return js_ast.Expr{}, false
+	}
+	name := []js_ast.Expr{{Loc: target.Loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(p.symbols[id.Ref.InnerIndex].OriginalName)}}}
+
+	// Everything except a plain assignment reads from the binding first
+	if isBeforeDeclaration, _ := p.classifyLetOrConstReference(id.Ref, target.Loc); isBeforeDeclaration {
+		p.ignoreUsage(id.Ref)
+		throw := p.callRuntime(target.Loc, "__earlyAccess", name)
+		if op == js_ast.BinOpAssign {
+			return js_ast.JoinWithComma(valueOrNil, throw), true
+		}
+		return throw, true
+	}
+
+	if p.symbols[id.Ref.InnerIndex].Kind != ast.SymbolConst {
+		return js_ast.Expr{}, false
+	}
+	throw := p.callRuntime(target.Loc, "__constAssign", name)
+	if valueOrNil.Data != nil {
+		throw = js_ast.JoinWithComma(valueOrNil, throw)
+	}
+
+	// Logical assignment operators only assign if they short-circuit
+	switch op {
+	case js_ast.BinOpLogicalOrAssign:
+		return js_ast.Expr{Loc: target.Loc, Data: &js_ast.EBinary{Op: js_ast.BinOpLogicalOr, Left: target, Right: throw}}, true
+
+	case js_ast.BinOpLogicalAndAssign:
+		return js_ast.Expr{Loc: target.Loc, Data: &js_ast.EBinary{Op: js_ast.BinOpLogicalAnd, Left: target, Right: throw}}, true
+
+	case js_ast.BinOpNullishCoalescingAssign:
+		p.recordUsage(id.Ref)
+		return js_ast.Expr{Loc: target.Loc, Data: &js_ast.EIf{
+			Test: js_ast.Expr{Loc: target.Loc, Data: &js_ast.EBinary{Op: js_ast.BinOpLooseNe, Left: target, Right: js_ast.Expr{Loc: target.Loc, Data: js_ast.ENullShared}}},
+			Yes:  js_ast.Expr{Loc: target.Loc, Data: &js_ast.EIdentifier{Ref: id.Ref}},
+			No:   throw,
+		}}, true
+	}
+
+	p.ignoreUsage(id.Ref)
+	return throw, true
+}
+
+func (p *parser) pushLetOrConstLoop() *letOrConstLoop {
+	if p.loweredLetOrConstRefs == nil {
+		return nil
+	}
+	loop := &letOrConstLoop{
+		parentFnOnly:    p.fnOnlyDataVisit.letOrConstLoop,
+		parentFnOrArrow: p.fnOrArrowDataVisit.letOrConstLoop,
+	}
+
+	// Remember the labels for this loop, since jumps to them need to be handled
+	// specially if the loop body is moved into a function
+	for scope := p.currentScope; scope.Kind == js_ast.ScopeLabel; scope = scope.Parent {
+		loop.labels = append(loop.labels, scope.Label.Ref)
+	}
+
+	p.fnOnlyDataVisit.letOrConstLoop = loop
+	p.fnOrArrowDataVisit.letOrConstLoop = loop
+	return loop
+}
+
+// Closures inside a loop body that capture a "let" or "const" binding from
+// that loop expect each loop iteration to have its own copy of that binding.
+// This isn't the case with "var", so we move the loop body into a function
+// and call it once per iteration:
+//
+//	for (let i = 0; i < 3; i++) fns.push(() => i)
+//
+// becomes:
+//
+//	var _loop = function(i) {
+//	  fns.push(() => i);
+//	};
+//	for (var i = 0; i < 3; i++) _loop(i);
+//
+// This is the same approach that Babel and TypeScript take.
+func (p *parser) popLetOrConstLoop(loop *letOrConstLoop, body *js_ast.Stmt, isForLoop bool, stmts []js_ast.Stmt) []js_ast.Stmt {
+	if loop == nil {
+		return stmts
+	}
+	p.fnOnlyDataVisit.letOrConstLoop = loop.parentFnOnly
+	p.fnOrArrowDataVisit.letOrConstLoop = loop.parentFnOrArrow
+
+	// Only wrap the loop body if something captures a binding from this loop
+	capturedRef := ast.InvalidRef
+	for _, refs := range [2][]ast.Ref{loop.headRefs, loop.bodyRefs} {
+		for _, ref := range refs {
+			if capturedRef == ast.InvalidRef && p.loweredLetOrConstRefs[ref].isCaptured {
+				capturedRef = ref
+			}
+		}
+	}
+	shouldWrap := capturedRef != ast.InvalidRef
+
+	// We can't move "super" or "new.target" into another function. The same
+	// goes for top-level "await" since there is no function to make async.
+	keyword := loop.superOrNewTargetKeyword
+	if keyword == "" && p.fnOrArrowDataVisit.isOutsideFnOrArrow {
+		keyword = loop.yieldOrAwaitKeyword
+	}
+	if shouldWrap && keyword != "" {
+		name := p.symbols[capturedRef.InnerIndex].OriginalName
+		where, notes := p.prettyPrintTargetEnvironment(compat.ConstAndLet)
+		p.log.AddIDWithNotes(logger.MsgID_JS_UnsupportedLoopClosure, logger.Error, &p.tracker,
+			js_lexer.RangeOfIdentifier(p.source, p.loweredLetOrConstRefs[capturedRef].loc),
+			fmt.Sprintf("Transforming a loop with closures that capture %q to %s is not supported yet", name, where),
+			append(notes, logger.MsgData{Text: fmt.Sprintf("Each loop iteration normally has a separate copy of %q. "+
+				"Emulating this requires moving the loop body into a separate function, which isn't possible here because it contains %q.", name, keyword)}))
+		shouldWrap = false
+	}
+
+	// Propagate information to the enclosing loop
+	if parent := loop.parentFnOnly; parent != nil {
+		parent.usesThis = parent.usesThis || loop.usesThis
+		if parent.superOrNewTargetKeyword == "" {
+			parent.superOrNewTargetKeyword = loop.superOrNewTargetKeyword
+		}
+		if !shouldWrap {
+			parent.argumentsUses = append(parent.argumentsUses, loop.argumentsUses...)
+		}
+	}
+	if parent := loop.parentFnOrArrow; parent != nil && parent.yieldOrAwaitKeyword == "" {
+		parent.yieldOrAwaitKeyword = loop.yieldOrAwaitKeyword
+	}
+	if !shouldWrap {
+		return stmts
+	}
+
+	// References to "arguments" must now go through a captured variable
+	for _, id := range loop.argumentsUses {
+		p.ignoreUsage(id.Ref)
+		id.Ref = p.captureArguments()
+	}
+
+	loc := body.Loc
+	generateRef := func(name string) ast.Ref {
+		ref := p.newSymbol(ast.SymbolOther, name)
+		p.hoistLoweredLetOrConst(ref)
+		p.loweredLetOrConstRefs[ref] = &loweredLetOrConst{}
+		return ref
+	}
+	loopRef := generateRef("_loop")
+
+	// Each binding in the loop head becomes an argument to the function
+	var args []js_ast.Arg
+	var callArgs []js_ast.Expr
+	var copyBack []ast.Ref
+	var copyBackTemps []ast.Ref
+	for _, ref := range loop.headRefs {
+		args = append(args, js_ast.Arg{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: ref}}})
+		callArgs = append(callArgs, js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}})
+		p.recordUsage(ref)
+
+		// Changes to the bindings in a "for" loop head must be carried over to
+		// the next iteration
+		if isForLoop && p.loweredLetOrConstRefs[ref].isAssignedInLoopBody {
+			copyBack = append(copyBack, ref)
+			copyBackTemps = append(copyBackTemps, generateRef("_"+p.symbols[ref.InnerIndex].OriginalName))
+		}
+	}
+	copyBackStmts := func(loc logger.Loc, reverse bool) (stmts []js_ast.Stmt) {
+		for i, ref := range copyBack {
+			target, value := copyBackTemps[i], ref
+			if reverse {
+				target, value = value, target
+			}
+			p.recordUsage(target)
+			p.recordUsage(value)
+			stmts = append(stmts, js_ast.AssignStmt(
+				js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: target}},
+				js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: value}},
+			))
+		}
+		return
+	}
+
+	// Rewrite the loop body so that it works inside a function
+	var bodyStmts []js_ast.Stmt
+	if block, ok := body.Data.(*js_ast.SBlock); ok {
+		bodyStmts = block.Stmts
+	} else {
+		bodyStmts = []js_ast.Stmt{*body}
+	}
+	r := letOrConstLoopRewriter{p: p, labels: loop.labels, copyBack: copyBackStmts}
+	bodyStmts = r.rewriteStmts(bodyStmts)
+	bodyStmts = append(bodyStmts, copyBackStmts(loc, false)...)
+
+	// Call the function from the loop body
+	p.recordUsage(loopRef)
+	call := &js_ast.ECall{
+		Target: js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: loopRef}},
+		Args:   callArgs,
+	}
+	if loop.usesThis {
+		call.Target = js_ast.Expr{Loc: loc, Data: &js_ast.EDot{Target: call.Target, Name: "call", NameLoc: loc}}
+		call.Args = append([]js_ast.Expr{{Loc: loc, Data: js_ast.EThisShared}}, callArgs...)
+		call.Kind = js_ast.TargetWasOriginallyPropertyAccess
+	}
+	callExpr := js_ast.Expr{Loc: loc, Data: call}
+
+	// A loop body containing "yield" or "await" is moved into a generator or
+	// async function instead, and the loop delegates to it:
+	//
+	//   var _loop = function* (i) { yield () => i; };
+	//   for (var i = 0; i < 3; i++) yield* _loop(i);
+	//
+	var isGenerator, isAsync bool
+	if loop.yieldOrAwaitKeyword != "" {
+		fn := p.fnOrArrowDataVisit
+		awaitIsYield := fn.isAsync && (p.options.unsupportedJSFeatures.Has(compat.AsyncAwait) ||
+			(fn.isGenerator && p.options.unsupportedJSFeatures.Has(compat.AsyncGenerator)))
+		isGenerator = fn.isGenerator || awaitIsYield
+		isAsync = fn.isAsync && !awaitIsYield
+		if isGenerator {
+			callExpr = js_ast.Expr{Loc: loc, Data: &js_ast.EYield{ValueOrNil: callExpr, IsStar: true}}
+		} else if isAsync {
+			callExpr = js_ast.Expr{Loc: loc, Data: &js_ast.EAwait{Value: callExpr}}
+		}
+		if isGenerator && p.options.unsupportedJSFeatures.Has(compat.Generator) {
+			bodyStmts = p.lowerGeneratorBody(loc, bodyStmts, nil)
+			isGenerator = false
+		}
+	}
+
+	var newBody []js_ast.Stmt
+	if !r.hasBreak && !r.hasReturn && len(r.outerJumps) == 0 {
+		newBody = append(newBody, js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: callExpr}})
+		newBody = append(newBody, copyBackStmts(loc, true)...)
+	} else {
+		// Handle jumps out of the function:
+		//
+		//   var _ret = _loop(i);
+		//   if (_ret === "break") break;
+		//   if (_ret === "continue|outer") continue outer;
+		//   if (typeof _ret === "object") return _ret.v;
+		//
+		retRef := generateRef("_ret")
+		ret := func() js_ast.Expr {
+			p.recordUsage(retRef)
+			return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: retRef}}
+		}
+		isRet := func(code string) js_ast.Expr {
+			return js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{Op: js_ast.BinOpStrictEq, Left: ret(),
+				Right: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(code)}}}}
+		}
+		newBody = append(newBody, js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: []js_ast.Decl{{
+			Binding:    js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: retRef}},
+			ValueOrNil: callExpr,
+		}}}})
+		newBody = append(newBody, copyBackStmts(loc, true)...)
+		if r.hasBreak {
+			newBody = append(newBody, js_ast.Stmt{Loc: loc, Data: &js_ast.SIf{Test: isRet("break"), Yes: js_ast.Stmt{Loc: loc, Data: &js_ast.SBreak{}}}})
+		}
+		for _, jump := range r.outerJumps {
+			p.recordUsage(jump.label)
+			label := &ast.LocRef{Loc: loc, Ref: jump.label}
+			var yes js_ast.Stmt
+			if jump.isContinue {
+				yes = js_ast.Stmt{Loc: loc, Data: &js_ast.SContinue{Label: label}}
+			} else {
+				yes = js_ast.Stmt{Loc: loc, Data: &js_ast.SBreak{Label: label}}
+			}
+			newBody = append(newBody, js_ast.Stmt{Loc: loc, Data: &js_ast.SIf{Test: isRet(jump.code), Yes: yes}})
+		}
+		if r.hasReturn {
+			newBody = append(newBody, js_ast.Stmt{Loc: loc, Data: &js_ast.SIf{
+				Test: js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+					Op:    js_ast.BinOpStrictEq,
+					Left:  js_ast.Expr{Loc: loc, Data: &js_ast.EUnary{Op: js_ast.UnOpTypeof, Value: ret()}},
+					Right: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16("object")}},
+				}},
+				Yes: js_ast.Stmt{Loc: loc, Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+					Target:  ret(),
+					Name:    "v",
+					NameLoc: loc,
+				}}}},
+			}})
+		}
+	}
+	*body = js_ast.Stmt{Loc: loc, Data: &js_ast.SBlock{Stmts: newBody}}
+
+	// Declare the function before the loop. Any "var" declarations that were
+	// in the loop body are declared here too so they stay in the outer function.
+	decls := r.hoistedDecls
+	for _, ref := range copyBackTemps {
+		decls = append(decls, js_ast.Decl{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: ref}}})
+	}
+	decls = append(decls, js_ast.Decl{
+		Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: loopRef}},
+		ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+			Args:        args,
+			Body:        js_ast.FnBody{Loc: loc, Block: js_ast.SBlock{Stmts: bodyStmts}},
+			IsAsync:     isAsync,
+			IsGenerator: isGenerator,
+		}}},
+	})
+	return append(stmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: decls}})
+}
+
+type letOrConstLoopRewriter struct {
+	p            *parser
+	copyBack     func(loc logger.Loc, reverse bool) []js_ast.Stmt
+	labels       []ast.Ref
+	targets      []letOrConstLoopTarget
+	hoistedDecls []js_ast.Decl
+	outerJumps   []letOrConstLoopJump
+	hasBreak     bool
+	hasReturn    bool
+}
+
+type letOrConstLoopTarget struct {
+	label    ast.Ref
+	isLoop   bool
+	isSwitch bool
+}
+
+type letOrConstLoopJump struct {
+	code       string
+	label      ast.Ref
+	isContinue bool
+}
+
+func (r *letOrConstLoopRewriter) rewriteStmts(stmts []js_ast.Stmt) []js_ast.Stmt {
+	end := 0
+	for _, stmt := range stmts {
+		stmt = r.rewriteStmt(stmt)
+		if _, ok := stmt.Data.(*js_ast.SEmpty); ok {
+			continue
+		}
+		stmts[end] = stmt
+		end++
+	}
+	return stmts[:end]
+}
+
+func (r *letOrConstLoopRewriter) rewriteBody(body js_ast.Stmt, target letOrConstLoopTarget) js_ast.Stmt {
+	target.label = ast.InvalidRef
+	r.targets = append(r.targets, target)
+	body = r.rewriteStmt(body)
+	r.targets = r.targets[:len(r.targets)-1]
+	return body
+}
+
+// Statements are left as-is except for a few changes: "var" declarations are
+// moved out of the function, and "return", "break", and "continue" are turned
+// into a return value that's checked by the caller.
+func (r *letOrConstLoopRewriter) rewriteStmt(stmt js_ast.Stmt) js_ast.Stmt {
+	switch s := stmt.Data.(type) {
+	case *js_ast.SBlock:
+		s.Stmts = r.rewriteStmts(s.Stmts)
+
+	case *js_ast.SLocal:
+		if r.isHoistedVar(s) {
+			var value js_ast.Expr
+			for _, decl := range s.Decls {
+				r.hoistBinding(decl.Binding)
+				if decl.ValueOrNil.Data != nil {
+					value = js_ast.JoinWithComma(value, js_ast.Assign(js_ast.ConvertBindingToExpr(decl.Binding, nil), decl.ValueOrNil))
+				}
+			}
+			if value.Data == nil {
+				return js_ast.Stmt{Loc: stmt.Loc, Data: js_ast.SEmptyShared}
+			}
+			return js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExpr{Value: value}}
+		}
+
+	case *js_ast.SReturn:
+		r.hasReturn = true
+		value := s.ValueOrNil
+		if value.Data == nil {
+			value = js_ast.Expr{Loc: stmt.Loc, Data: js_ast.EUndefinedShared}
+		}
+		return js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EObject{
+			Properties: []js_ast.Property{{
+				Key:        js_ast.Expr{Loc: stmt.Loc, Data: &js_ast.EString{Value: helpers.StringToUTF16("v")}},
+				ValueOrNil: value,
+			}},
+		}}}}
+
+	case *js_ast.SBreak:
+		if !r.isNativeJump(s.Label, false) {
+			if s.Label == nil || r.isOwnLabel(s.Label.Ref) {
+				r.hasBreak = true
+				return r.returnCode(stmt.Loc, "break")
+			}
+			return r.returnCode(stmt.Loc, r.outerJump(s.Label.Ref, false))
+		}
+
+	case *js_ast.SContinue:
+		if !r.isNativeJump(s.Label, true) {
+			if s.Label == nil || r.isOwnLabel(s.Label.Ref) {
+				stmts := append(r.copyBack(stmt.Loc, false), js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SReturn{}})
+				if len(stmts) == 1 {
+					return stmts[0]
+				}
+				return js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SBlock{Stmts: stmts}}
+			}
+			return r.returnCode(stmt.Loc, r.outerJump(s.Label.Ref, true))
+		}
+
+	case *js_ast.SIf:
+		s.Yes = r.rewriteStmt(s.Yes)
+		if s.NoOrNil.Data != nil {
+			s.NoOrNil = r.rewriteStmt(s.NoOrNil)
+		}
+
+	case *js_ast.SFor:
+		if s.InitOrNil.Data != nil {
+			s.InitOrNil = r.rewriteStmt(s.InitOrNil)
+			if _, ok := s.InitOrNil.Data.(*js_ast.SEmpty); ok {
+				s.InitOrNil = js_ast.Stmt{}
+			}
+		}
+		s.Body = r.rewriteBody(s.Body, letOrConstLoopTarget{isLoop: true})
+
+	case *js_ast.SForIn:
+		s.Init = r.rewriteForInit(s.Init)
+		s.Body = r.rewriteBody(s.Body, letOrConstLoopTarget{isLoop: true})
+
+	case *js_ast.SForOf:
+		s.Init = r.rewriteForInit(s.Init)
+		s.Body = r.rewriteBody(s.Body, letOrConstLoopTarget{isLoop: true})
+
+	case *js_ast.SWhile:
+		s.Body = r.rewriteBody(s.Body, letOrConstLoopTarget{isLoop: true})
+
+	case *js_ast.SDoWhile:
+		s.Body = r.rewriteBody(s.Body, letOrConstLoopTarget{isLoop: true})
+
+	case *js_ast.SLabel:
+		r.targets = append(r.targets, letOrConstLoopTarget{label: s.Name.Ref})
+		s.Stmt = r.rewriteStmt(s.Stmt)
+		r.targets = r.targets[:len(r.targets)-1]
+
+	case *js_ast.SSwitch:
+		r.targets = append(r.targets, letOrConstLoopTarget{label: ast.InvalidRef, isSwitch: true})
+		for i := range s.Cases {
+			s.Cases[i].Body = r.rewriteStmts(s.Cases[i].Body)
+		}
+		r.targets = r.targets[:len(r.targets)-1]
+
+	case *js_ast.STry:
+		s.Block.Stmts = r.rewriteStmts(s.Block.Stmts)
+		if s.Catch != nil {
+			s.Catch.Block.Stmts = r.rewriteStmts(s.Catch.Block.Stmts)
+		}
+		if s.Finally != nil {
+			s.Finally.Block.Stmts = r.rewriteStmts(s.Finally.Block.Stmts)
+		}
+
+	case *js_ast.SWith:
+		s.Body = r.rewriteStmt(s.Body)
+	}
+
+	return stmt
+}
+
+// "for (var x in y)" becomes "for (x in y)" since "x" is moved out
+func (r *letOrConstLoopRewriter) rewriteForInit(init js_ast.Stmt) js_ast.Stmt {
+	if s, ok := init.Data.(*js_ast.SLocal); ok && r.isHoistedVar(s) {
+		r.hoistBinding(s.Decls[0].Binding)
+		return js_ast.Stmt{Loc: init.Loc, Data: &js_ast.SExpr{Value: js_ast.ConvertBindingToExpr(s.Decls[0].Binding, nil)}}
+	}
+	return init
+}
+
+// Only declarations that were originally "var" need to be moved out of the
+// function. Lowered "let" and "const" declarations stay where they are.
+func (r *letOrConstLoopRewriter) isHoistedVar(s *js_ast.SLocal) bool {
+	if s.Kind != js_ast.LocalVar {
+		return false
+	}
+	isHoisted := false
+	js_ast.ForEachIdentifierBindingInDecls(s.Decls, func(loc logger.Loc, b *js_ast.BIdentifier) {
+		if _, ok := r.p.loweredLetOrConstRefs[b.Ref]; !ok {
+			isHoisted = true
+		}
+	})
+	return isHoisted
+}
+
+func (r *letOrConstLoopRewriter) hoistBinding(binding js_ast.Binding) {
+	js_ast.ForEachIdentifierBinding(binding, func(loc logger.Loc, b *js_ast.BIdentifier) {
+		r.hoistedDecls = append(r.hoistedDecls, js_ast.Decl{Binding: js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: b.Ref}}})
+	})
+}
+
+// Jumps to targets inside the function body don't need to be changed
+func (r *letOrConstLoopRewriter) isNativeJump(label *ast.LocRef, isContinue bool) bool {
+	for _, target := range r.targets {
+		if label != nil {
+			if target.label == label.Ref {
+				return true
+			}
+		} else if target.isLoop || (target.isSwitch && !isContinue) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *letOrConstLoopRewriter) isOwnLabel(ref ast.Ref) bool {
+	for _, label := range r.labels {
+		if label == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *letOrConstLoopRewriter) outerJump(label ast.Ref, isContinue bool) string {
+	code := "break|"
+	if isContinue {
+		code = "continue|"
+	}
+	code += r.p.symbols[label.InnerIndex].OriginalName
+	for _, jump := range r.outerJumps {
+		if jump.code == code {
+			return code
+		}
+	}
+	r.outerJumps = append(r.outerJumps, letOrConstLoopJump{code: code, label: label, isContinue: isContinue})
+	return code
+}
+
+func (r *letOrConstLoopRewriter) returnCode(loc logger.Loc, code string) js_ast.Stmt {
+	return js_ast.Stmt{Loc: loc, Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(code)}}}}
+}
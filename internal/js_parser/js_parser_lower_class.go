@@ -254,17 +254,24 @@ func (p *parser) shouldLowerSuperPropertyAccess(expr js_ast.Expr) bool {
 	return false
 }
 
+// Lowered "super" property accesses pass "this" explicitly. This handles "this"
+// in lowered static class field initializers, in lowered derived class
+// constructors, and in arrow functions that will be lowered to functions.
+func (p *parser) valueForSuperPropertyThis(loc logger.Loc) js_ast.Expr {
+	if value, ok := p.valueForThis(loc, false /* shouldLog */, js_ast.AssignTargetNone, false, false); ok {
+		return value
+	}
+	if p.fnOrArrowDataVisit.isArrow && p.options.unsupportedJSFeatures.Has(compat.Arrow) && p.fnOnlyDataVisit.isThisNested {
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: p.captureThis()}}
+	}
+	return js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+}
+
 func (p *parser) callSuperPropertyWrapper(loc logger.Loc, key js_ast.Expr) js_ast.Expr {
 	ref := *p.fnOnlyDataVisit.innerClassNameRef
 	p.recordUsage(ref)
 	class := js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
-	this := js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
-
-	// Handle "this" in lowered static class field initializers
-	if p.fnOnlyDataVisit.shouldReplaceThisWithInnerClassNameRef {
-		p.recordUsage(ref)
-		this.Data = &js_ast.EIdentifier{Ref: ref}
-	}
+	this := p.valueForSuperPropertyThis(loc)
 
 	if !p.fnOnlyDataVisit.isInStaticClassContext {
 		// "super.foo" => "__superWrapper(Class.prototype, this, 'foo')._"
@@ -283,13 +290,7 @@ func (p *parser) lowerSuperPropertyGet(loc logger.Loc, key js_ast.Expr) js_ast.E
 	ref := *p.fnOnlyDataVisit.innerClassNameRef
 	p.recordUsage(ref)
 	class := js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
-	this := js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
-
-	// Handle "this" in lowered static class field initializers
-	if p.fnOnlyDataVisit.shouldReplaceThisWithInnerClassNameRef {
-		p.recordUsage(ref)
-		this.Data = &js_ast.EIdentifier{Ref: ref}
-	}
+	this := p.valueForSuperPropertyThis(loc)
 
 	if !p.fnOnlyDataVisit.isInStaticClassContext {
 		// "super.foo" => "__superGet(Class.prototype, this, 'foo')"
@@ -310,13 +311,7 @@ func (p *parser) lowerSuperPropertySet(loc logger.Loc, key js_ast.Expr, value js
 	ref := *p.fnOnlyDataVisit.innerClassNameRef
 	p.recordUsage(ref)
 	class := js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}}
-	this := js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
-
-	// Handle "this" in lowered static class field initializers
-	if p.fnOnlyDataVisit.shouldReplaceThisWithInnerClassNameRef {
-		p.recordUsage(ref)
-		this.Data = &js_ast.EIdentifier{Ref: ref}
-	}
+	this := p.valueForSuperPropertyThis(loc)
 
 	if !p.fnOnlyDataVisit.isInStaticClassContext {
 		// "super.foo = bar" => "__superSet(Class.prototype, this, 'foo', bar)"
@@ -373,9 +368,44 @@ func (p *parser) maybeLowerSuperPropertyGetInsideCall(call *js_ast.ECall) {
 		Name:    "call",
 	}
 	thisExpr := js_ast.Expr{Loc: call.Target.Loc, Data: js_ast.EThisShared}
+	if p.options.unsupportedJSFeatures.Has(compat.Class) {
+		thisExpr = p.valueForSuperPropertyThis(call.Target.Loc)
+	}
 	call.Args = append([]js_ast.Expr{thisExpr}, call.Args...)
 }
 
+// Returns true if this class has JavaScript decorators (as opposed to
+// TypeScript experimental decorators) that must be lowered
+func (p *parser) shouldLowerStandardDecorators(class *js_ast.Class) bool {
+	if (!p.options.unsupportedJSFeatures.Has(compat.Decorators) && !p.options.unsupportedJSFeatures.Has(compat.Class)) ||
+		(p.options.ts.Parse && p.options.ts.Config.ExperimentalDecorators == config.True) {
+		return false
+	}
+	if len(class.Decorators) > 0 {
+		return true
+	}
+	for _, prop := range class.Properties {
+		if len(prop.Decorators) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// These are the element kinds and flags passed to the "__decorateElement"
+// runtime helper. They must be kept in sync with the runtime.
+const (
+	decoratorKindClass    = 0
+	decoratorKindMethod   = 1
+	decoratorKindGetter   = 2
+	decoratorKindSetter   = 3
+	decoratorKindAccessor = 4
+	decoratorKindField    = 5
+
+	decoratorFlagStatic  = 8
+	decoratorFlagPrivate = 16
+)
+
 type classLoweringInfo struct {
 	lowerAllInstanceFields bool
 	lowerAllStaticFields   bool
@@ -383,6 +413,13 @@ type classLoweringInfo struct {
 }
 
 func (p *parser) computeClassLoweringInfo(class *js_ast.Class) (result classLoweringInfo) {
+	// Lowering class syntax to a constructor function leaves no class body to
+	// put fields in, so all fields must be moved out of the class body
+	if p.options.unsupportedJSFeatures.Has(compat.Class) {
+		result.lowerAllInstanceFields = true
+		result.lowerAllStaticFields = true
+	}
+
 	// Name keeping for classes is implemented with a static block. So we need to
 	// lower all static fields if static blocks are unsupported so that the name
 	// keeping comes first before other static initializers.
@@ -409,6 +446,16 @@ func (p *parser) computeClassLoweringInfo(class *js_ast.Class) (result classLowe
 		result.lowerAllStaticFields = true
 	}
 
+	// Lowered JavaScript decorators need to run decorator-provided initializers
+	// for fields, and static fields must be initialized after class decorators
+	// have run. So all fields are moved out of the class body in this case.
+	// This also causes all private members to be lowered, which is necessary
+	// because decorators can replace private methods and accessors.
+	if p.shouldLowerStandardDecorators(class) {
+		result.lowerAllInstanceFields = true
+		result.lowerAllStaticFields = true
+	}
+
 	// Conservatively lower fields of a given type (instance or static) when any
 	// member of that type needs to be lowered. This must be done to preserve
 	// evaluation order. For example:
@@ -611,11 +658,13 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 	// Unpack the class from the statement or expression
 	var kind classKind
 	var class *js_ast.Class
+	var classExprData *js_ast.EClass
 	var classLoc logger.Loc
 	var defaultName ast.LocRef
 	if stmt.Data == nil {
 		e, _ := expr.Data.(*js_ast.EClass)
 		class = &e.Class
+		classExprData = e
 		kind = classKindExpr
 		if class.Name != nil {
 			symbol := &p.symbols[class.Name.Ref.InnerIndex]
@@ -684,6 +733,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 			// outside the class body.
 			classExpr := &js_ast.EClass{Class: *class}
 			class = &classExpr.Class
+			classExprData = classExpr
 			nameFunc, wrapFunc = p.captureValueWithPossibleSideEffects(classLoc, 2, js_ast.Expr{Loc: classLoc, Data: classExpr}, valueDefinitelyNotMutated)
 			expr = nameFunc()
 			didCaptureClassExpr = true
@@ -734,6 +784,17 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 		}
 	}
 
+	// Code that is moved into the constructor must use the same value for "this"
+	// as the constructor. This is different for derived classes that are lowered
+	// to constructor functions since the base class constructor creates "this".
+	instanceThis := func(loc logger.Loc) js_ast.Expr {
+		if result.derivedClassThisRef != ast.InvalidRef {
+			p.recordUsage(result.derivedClassThisRef)
+			return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: result.derivedClassThisRef}}
+		}
+		return js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+	}
+
 	// Handle lowering of instance and static fields. Move their initializers
 	// from the class body to either the constructor (instance fields) or after
 	// the class (static fields).
@@ -754,7 +815,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 			if prop.Flags.Has(js_ast.PropertyIsStatic) && !staticFieldToBlockAssign {
 				target = nameFunc()
 			} else {
-				target = js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+				target = instanceThis(loc)
 			}
 
 			// Generate the assignment initializer
@@ -880,7 +941,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 				if prop.Flags.Has(js_ast.PropertyIsStatic) {
 					target = nameFunc()
 				} else {
-					target = js_ast.Expr{Loc: loc, Data: js_ast.EThisShared}
+					target = instanceThis(loc)
 				}
 
 				// Add every newly-constructed instance into this map
@@ -907,18 +968,22 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 				}
 			}
 
-			// Move the method definition outside the class body
-			methodRef := p.generateTempRef(tempRefNeedsDeclare, "_")
-			if prop.Kind == js_ast.PropertySet {
-				p.symbols[methodRef.InnerIndex].Link = p.privateSetters[private.Ref]
-			} else {
-				p.symbols[methodRef.InnerIndex].Link = p.privateGetters[private.Ref]
+			// Move the method definition outside the class body. There is no
+			// definition for decorated private auto-accessors since their getter
+			// and setter are returned by the decorator call instead.
+			if prop.ValueOrNil.Data != nil {
+				methodRef := p.generateTempRef(tempRefNeedsDeclare, "_")
+				if prop.Kind == js_ast.PropertySet {
+					p.symbols[methodRef.InnerIndex].Link = p.privateSetters[private.Ref]
+				} else {
+					p.symbols[methodRef.InnerIndex].Link = p.privateGetters[private.Ref]
+				}
+				p.recordUsage(methodRef)
+				privateMembers = append(privateMembers, js_ast.Assign(
+					js_ast.Expr{Loc: prop.Key.Loc, Data: &js_ast.EIdentifier{Ref: methodRef}},
+					prop.ValueOrNil,
+				))
 			}
-			p.recordUsage(methodRef)
-			privateMembers = append(privateMembers, js_ast.Assign(
-				js_ast.Expr{Loc: prop.Key.Loc, Data: &js_ast.EIdentifier{Ref: methodRef}},
-				prop.ValueOrNil,
-			))
 			return true
 		}
 
@@ -933,10 +998,11 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 						if arg.IsTypeScriptCtorField {
 							if id, ok := arg.Binding.Data.(*js_ast.BIdentifier); ok {
 								parameterFields = append(parameterFields, js_ast.AssignStmt(
-									js_ast.Expr{Loc: arg.Binding.Loc, Data: p.dotOrMangledPropVisit(
-										js_ast.Expr{Loc: arg.Binding.Loc, Data: js_ast.EThisShared},
+									js_ast.Expr{Loc: arg.Binding.Loc, Data: p.dotOrMangledTSPrivatePropVisit(
+										instanceThis(arg.Binding.Loc),
 										p.symbols[id.Ref.InnerIndex].OriginalName,
 										arg.Binding.Loc,
+										p.tsPrivatePropsByClass[class.BodyLoc],
 									)},
 									js_ast.Expr{Loc: arg.Binding.Loc, Data: &js_ast.EIdentifier{Ref: id.Ref}},
 								))
@@ -951,10 +1017,138 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 	}
 
 	classLoweringInfo := p.computeClassLoweringInfo(class)
+	lowerClassSyntax := p.options.unsupportedJSFeatures.Has(compat.Class)
 	properties := make([]js_ast.Property, 0, len(class.Properties))
 	autoAccessorCount := 0
 
-	for _, prop := range class.Properties {
+	// JavaScript decorators are lowered by evaluating all decorator expressions
+	// before the class body (along with the base class and any computed keys,
+	// which preserves evaluation order) and then applying them using runtime
+	// helpers after the class body:
+	//
+	//   // Original code
+	//   @dec class Foo {
+	//     @dec2 foo() {}
+	//   }
+	//
+	//   // Lowered code
+	//   var _Foo_decorators, _foo_dec, _init;
+	//   _Foo_decorators = [dec], _foo_dec = [dec2];
+	//   let Foo = class {
+	//     foo() {}
+	//   };
+	//   _init = __decoratorStart(null);
+	//   __decorateElement(_init, 1, "foo", _foo_dec, Foo);
+	//   Foo = __decorateElement(_init, 0, "Foo", _Foo_decorators, Foo);
+	//   __runInitializers(_init, 1, Foo);
+	//
+	// Decorators are applied to static methods and accessors first, then to
+	// instance methods and accessors, then to static fields, and finally to
+	// instance fields. The order of each group is tracked separately here.
+	lowerStdDecorators := p.shouldLowerStandardDecorators(class)
+	var decoratorPrefix js_ast.Expr
+	var decoratorCalls [4][]js_ast.Expr
+	var decoratorInitRef ast.Ref
+	var decoratorBase js_ast.Expr
+	var classDecoratorsRef ast.Ref
+	var classDecoratorsName string
+	var initializerIndices map[int]int
+	hasStaticMethodDecorators := false
+	hasInstanceMethodDecorators := false
+	hasStdClassDecorators := false
+	captureDecorators := func(decorators []js_ast.Decorator, name string) ast.Ref {
+		loc := decorators[0].AtLoc
+		ref := p.generateTempRef(tempRefNeedsDeclare, name)
+		values := make([]js_ast.Expr, len(decorators))
+		for i, decorator := range decorators {
+			values[i] = decorator.Value
+		}
+		p.recordUsage(ref)
+		decoratorPrefix = js_ast.JoinWithComma(decoratorPrefix, js_ast.Assign(
+			js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: ref}},
+			js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: values, IsSingleLine: true}},
+		))
+		return ref
+	}
+	decorateElement := func(loc logger.Loc, flags int, name js_ast.Expr, decoratorsRef ast.Ref, target js_ast.Expr, extra js_ast.Expr) js_ast.Expr {
+		p.recordUsage(decoratorInitRef)
+		p.recordUsage(decoratorsRef)
+		args := []js_ast.Expr{
+			{Loc: loc, Data: &js_ast.EIdentifier{Ref: decoratorInitRef}},
+			{Loc: loc, Data: &js_ast.ENumber{Value: float64(flags)}},
+			name,
+			{Loc: loc, Data: &js_ast.EIdentifier{Ref: decoratorsRef}},
+			target,
+		}
+		if extra.Data != nil {
+			args = append(args, extra)
+		}
+		return p.callRuntime(loc, "__decorateElement", args)
+	}
+	runInitializers := func(loc logger.Loc, flags int, self js_ast.Expr, value js_ast.Expr) js_ast.Expr {
+		p.recordUsage(decoratorInitRef)
+		args := []js_ast.Expr{
+			{Loc: loc, Data: &js_ast.EIdentifier{Ref: decoratorInitRef}},
+			{Loc: loc, Data: &js_ast.ENumber{Value: float64(flags)}},
+			self,
+		}
+		if value.Data != nil {
+			args = append(args, value)
+		}
+		return p.callRuntime(loc, "__runInitializers", args)
+	}
+	if lowerStdDecorators {
+		decoratorInitRef = p.generateTempRef(tempRefNeedsDeclare, "_init")
+
+		// Class decorators are evaluated first
+		if len(class.Decorators) > 0 {
+			if class.Name != nil {
+				classDecoratorsName = p.symbols[class.Name.Ref.InnerIndex].OriginalName
+			} else if kind == classKindExportDefaultStmt {
+				classDecoratorsName = "default"
+			}
+			tempName := "_decorators"
+			if classDecoratorsName != "" {
+				tempName = "_" + classDecoratorsName + tempName
+			}
+			classDecoratorsRef = captureDecorators(class.Decorators, tempName)
+			class.Decorators = nil
+			hasStdClassDecorators = true
+		}
+
+		// Then the base class is evaluated, which is also needed to inherit the
+		// base class metadata
+		if class.ExtendsOrNil.Data != nil {
+			ref := p.generateTempRef(tempRefNeedsDeclare, "")
+			p.recordUsage(ref)
+			decoratorPrefix = js_ast.JoinWithComma(decoratorPrefix, js_ast.Assign(
+				js_ast.Expr{Loc: class.ExtendsOrNil.Loc, Data: &js_ast.EIdentifier{Ref: ref}}, class.ExtendsOrNil))
+			p.recordUsage(ref)
+			class.ExtendsOrNil = js_ast.Expr{Loc: class.ExtendsOrNil.Loc, Data: &js_ast.EIdentifier{Ref: ref}}
+			p.recordUsage(ref)
+			decoratorBase = js_ast.Expr{Loc: class.ExtendsOrNil.Loc, Data: &js_ast.EIdentifier{Ref: ref}}
+		} else {
+			decoratorBase = js_ast.Expr{Loc: classLoc, Data: js_ast.ENullShared}
+		}
+
+		// Each decorated field and auto-accessor has its own initializer lists,
+		// which are allocated in the order that decorators are applied
+		initializerIndices = make(map[int]int)
+		nextIndex := 4
+		for group := 0; group < 4; group++ {
+			for i, prop := range class.Properties {
+				if len(prop.Decorators) > 0 && (prop.Kind == js_ast.PropertyNormal || prop.Kind == js_ast.PropertyAutoAccessor) &&
+					!prop.Flags.Has(js_ast.PropertyIsMethod) && (group < 2) == (prop.Kind == js_ast.PropertyAutoAccessor) &&
+					(group%2 == 0) == prop.Flags.Has(js_ast.PropertyIsStatic) {
+					initializerIndices[i] = nextIndex
+					nextIndex += 2
+				}
+			}
+		}
+	}
+
+	var ctorForMetadata *js_ast.EFunction
+	for propIndex, prop := range class.Properties {
 		if prop.Kind == js_ast.PropertyClassStaticBlock {
 			// Drop empty class blocks when minifying
 			if p.options.minifySyntax && len(prop.ClassStaticBlock.Block.Stmts) == 0 {
@@ -1027,9 +1221,21 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 						args[i].Decorators = nil
 					}
 				}
+
+				// TypeScript's "emitDecoratorMetadata" setting describes the types of
+				// the constructor parameters if the class has decorators
+				if isConstructor && p.metadataTypes != nil {
+					ctorForMetadata = fn
+				}
 			}
 		}
 
+		// TypeScript's "emitDecoratorMetadata" setting describes the types of
+		// decorated members
+		if p.metadataTypes != nil && len(prop.Decorators) > 0 {
+			prop.Decorators = append(prop.Decorators, p.decoratorMetadata(&prop)...)
+		}
+
 		// The TypeScript class field transform requires removing fields without
 		// initializers. If the field is removed, then we only need the key for
 		// its side effects and we don't need a temporary reference for the key.
@@ -1071,10 +1277,128 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 		staticFieldToBlockAssign := prop.Kind == js_ast.PropertyNormal && !mustLowerField && !class.UseDefineForClassFields &&
 			!prop.Flags.Has(js_ast.PropertyIsMethod) && prop.Flags.Has(js_ast.PropertyIsStatic) && private == nil
 
+		// Evaluate JavaScript decorators and computed keys before the class body
+		// when lowering decorators. The decorators of a class element must be
+		// evaluated before its key.
+		decoratorsRef := ast.InvalidRef
+		keyIsHoisted := false
+		var runExtraInitializers js_ast.Expr
+		if lowerStdDecorators {
+			if len(prop.Decorators) > 0 {
+				name := "_dec"
+				switch k := prop.Key.Data.(type) {
+				case *js_ast.EString:
+					name = "_" + helpers.UTF16ToString(k.Value) + "_dec"
+				case *js_ast.EPrivateIdentifier:
+					name = "_" + p.symbols[k.Ref.InnerIndex].OriginalName[1:] + "_dec"
+				}
+				decoratorsRef = captureDecorators(prop.Decorators, name)
+				prop.Decorators = nil
+
+				// Decorated fields are never omitted since decorators may add initializers
+				shouldOmitFieldInitializer = false
+			}
+
+			if prop.Flags.Has(js_ast.PropertyIsComputed) {
+				switch prop.Key.Data.(type) {
+				case *js_ast.EString, *js_ast.ENameOfSymbol, *js_ast.ENumber:
+					// These have no side effects
+				default:
+					ref := p.generateTempRef(tempRefNeedsDeclare, "")
+					p.recordUsage(ref)
+					decoratorPrefix = js_ast.JoinWithComma(decoratorPrefix,
+						js_ast.Assign(js_ast.Expr{Loc: prop.Key.Loc, Data: &js_ast.EIdentifier{Ref: ref}}, prop.Key))
+					p.recordUsage(ref)
+					prop.Key = js_ast.Expr{Loc: prop.Key.Loc, Data: &js_ast.EIdentifier{Ref: ref}}
+					keyIsHoisted = true
+				}
+			}
+
+			// Decorated fields and auto-accessors pass their initial value through
+			// the initializers returned by the decorators. Then extra initializers
+			// added by the decorators run after the field has been defined.
+			if index, ok := initializerIndices[propIndex]; ok {
+				loc := prop.Loc
+				self := func() js_ast.Expr {
+					if prop.Flags.Has(js_ast.PropertyIsStatic) {
+						return nameFunc()
+					}
+					return instanceThis(loc)
+				}
+				prop.InitializerOrNil = runInitializers(loc, index<<1, self(), prop.InitializerOrNil)
+				runExtraInitializers = runInitializers(loc, ((index+1)<<1)|1, self(), js_ast.Expr{})
+			}
+
+			// Decorated auto-accessors are handled below once their storage exists
+			if decoratorsRef != ast.InvalidRef && prop.Kind != js_ast.PropertyAutoAccessor {
+				loc := prop.Loc
+				flags := 0
+				if prop.Flags.Has(js_ast.PropertyIsStatic) {
+					flags |= decoratorFlagStatic
+				}
+				var name, target, extra js_ast.Expr
+				var fnRef ast.Ref
+				if private != nil {
+					flags |= decoratorFlagPrivate
+					name = js_ast.Expr{Loc: prop.Key.Loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(p.symbols[private.Ref.InnerIndex].OriginalName)}}
+					target = js_ast.Expr{Loc: prop.Key.Loc, Data: &js_ast.EIdentifier{Ref: private.Ref}}
+				} else {
+					name = cloneKeyForLowerClass(prop.Key)
+					if keyIsHoisted {
+						p.recordUsage(prop.Key.Data.(*js_ast.EIdentifier).Ref)
+					}
+					target = nameFunc()
+				}
+
+				if prop.Flags.Has(js_ast.PropertyIsMethod) {
+					switch prop.Kind {
+					case js_ast.PropertyGet:
+						flags |= decoratorKindGetter
+					case js_ast.PropertySet:
+						flags |= decoratorKindSetter
+					default:
+						flags |= decoratorKindMethod
+					}
+
+					// Private methods are lowered to functions stored in variables, so
+					// the decorated function must be stored back into that variable
+					if private != nil {
+						if prop.Kind == js_ast.PropertySet {
+							fnRef = p.privateSetters[private.Ref]
+						} else {
+							fnRef = p.privateGetters[private.Ref]
+						}
+						p.recordUsage(fnRef)
+						extra = js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: fnRef}}
+					}
+
+					call := decorateElement(loc, flags, name, decoratorsRef, target, extra)
+					if private != nil {
+						p.recordUsage(fnRef)
+						call = js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: fnRef}}, call)
+					}
+					if prop.Flags.Has(js_ast.PropertyIsStatic) {
+						decoratorCalls[0] = append(decoratorCalls[0], call)
+						hasStaticMethodDecorators = true
+					} else {
+						decoratorCalls[1] = append(decoratorCalls[1], call)
+						hasInstanceMethodDecorators = true
+					}
+				} else {
+					call := decorateElement(loc, flags|decoratorKindField, name, decoratorsRef, target, extra)
+					if prop.Flags.Has(js_ast.PropertyIsStatic) {
+						decoratorCalls[2] = append(decoratorCalls[2], call)
+					} else {
+						decoratorCalls[3] = append(decoratorCalls[3], call)
+					}
+				}
+			}
+		}
+
 		// Make sure the order of computed property keys doesn't change. These
 		// expressions have side effects and must be evaluated in order.
 		keyExprNoSideEffects := prop.Key
-		if prop.Flags.Has(js_ast.PropertyIsComputed) && (len(propExperimentalDecorators) > 0 || mustLowerField || staticFieldToBlockAssign || computedPropertyCache.Data != nil || rewriteAutoAccessorToGetSet) {
+		if prop.Flags.Has(js_ast.PropertyIsComputed) && !keyIsHoisted && (len(propExperimentalDecorators) > 0 || mustLowerField || staticFieldToBlockAssign || computedPropertyCache.Data != nil || rewriteAutoAccessorToGetSet) {
 			needsKey := true
 			if len(propExperimentalDecorators) == 0 && !rewriteAutoAccessorToGetSet && (prop.Flags.Has(js_ast.PropertyIsMethod) || shouldOmitFieldInitializer || (!mustLowerField && !staticFieldToBlockAssign)) {
 				needsKey = false
@@ -1179,6 +1503,10 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 			// Replace this accessor with other properties
 			loc := keyExprNoSideEffects.Loc
 			storagePrivate := &js_ast.EPrivateIdentifier{Ref: storageRef}
+			if lowerStdDecorators {
+				// All other private names in this class are lowered too
+				p.symbols[storageRef.InnerIndex].Flags |= ast.PrivateSymbolMustBeLowered
+			}
 			storageNeedsToBeLowered := p.privateSymbolNeedsToBeLowered(storagePrivate)
 			storageProp := js_ast.Property{
 				Loc:              prop.Loc,
@@ -1192,6 +1520,72 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 			} else if prop, ok := lowerField(storageProp, storagePrivate, false, false); ok {
 				properties = append(properties, prop)
 			}
+			if runExtraInitializers.Data != nil {
+				if prop.Flags.Has(js_ast.PropertyIsStatic) {
+					staticMembers = append(staticMembers, runExtraInitializers)
+				} else {
+					instanceMembers = append(instanceMembers, js_ast.Stmt{Loc: prop.Loc, Data: &js_ast.SExpr{Value: runExtraInitializers}})
+				}
+			}
+
+			// Decorate the accessor. Public accessors are read from and written
+			// back to the class by the runtime. Private accessors return the new
+			// getter and setter, which replace the lowered private methods.
+			decoratedPrivateAccessor := false
+			if decoratorsRef != ast.InvalidRef {
+				flags := decoratorKindAccessor
+				if prop.Flags.Has(js_ast.PropertyIsStatic) {
+					flags |= decoratorFlagStatic
+				}
+				if private != nil {
+					decoratedPrivateAccessor = true
+					flags |= decoratorFlagPrivate
+					getRef := p.generateTempRef(tempRefNeedsDeclare, "_")
+					setRef := p.generateTempRef(tempRefNeedsDeclare, "_")
+					p.symbols[getRef.InnerIndex].Link = p.privateGetters[private.Ref]
+					p.symbols[setRef.InnerIndex].Link = p.privateSetters[private.Ref]
+					descRef := p.generateTempRef(tempRefNeedsDeclare, "")
+					p.recordUsage(private.Ref)
+					p.recordUsage(storageRef)
+					call := decorateElement(loc, flags,
+						js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(p.symbols[private.Ref.InnerIndex].OriginalName)}},
+						decoratorsRef,
+						js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: private.Ref}},
+						js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: storageRef}})
+					p.recordUsage(getRef)
+					p.recordUsage(setRef)
+					p.recordUsage(descRef)
+					p.recordUsage(descRef)
+					call = js_ast.JoinWithComma(
+						js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: getRef}}, js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+							Target:  js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: descRef}}, call),
+							Name:    "get",
+							NameLoc: loc,
+						}}),
+						js_ast.Assign(js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: setRef}}, js_ast.Expr{Loc: loc, Data: &js_ast.EDot{
+							Target:  js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: descRef}},
+							Name:    "set",
+							NameLoc: loc,
+						}}),
+					)
+					if prop.Flags.Has(js_ast.PropertyIsStatic) {
+						decoratorCalls[0] = append(decoratorCalls[0], call)
+					} else {
+						decoratorCalls[1] = append(decoratorCalls[1], call)
+					}
+				} else {
+					name := cloneKeyForLowerClass(keyExprNoSideEffects)
+					if id, ok := name.Data.(*js_ast.EIdentifier); ok {
+						p.recordUsage(id.Ref)
+					}
+					call := decorateElement(loc, flags, name, decoratorsRef, nameFunc(), js_ast.Expr{})
+					if prop.Flags.Has(js_ast.PropertyIsStatic) {
+						decoratorCalls[0] = append(decoratorCalls[0], call)
+					} else {
+						decoratorCalls[1] = append(decoratorCalls[1], call)
+					}
+				}
+			}
 
 			// Getter
 			var getExpr js_ast.Expr
@@ -1222,6 +1616,10 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 					},
 				}},
 			}
+			if decoratedPrivateAccessor {
+				// The getter and setter come from the decorator call instead
+				getterProp.ValueOrNil = js_ast.Expr{}
+			}
 			if !lowerMethod(getterProp, private) {
 				properties = append(properties, getterProp)
 			}
@@ -1263,6 +1661,9 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 					},
 				}},
 			}
+			if decoratedPrivateAccessor {
+				setterProp.ValueOrNil = js_ast.Expr{}
+			}
 			if !lowerMethod(setterProp, private) {
 				properties = append(properties, setterProp)
 			}
@@ -1272,7 +1673,15 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 		// Lower fields
 		if (!prop.Flags.Has(js_ast.PropertyIsMethod) && mustLowerField) || staticFieldToBlockAssign {
 			var keep bool
+			isStatic := prop.Flags.Has(js_ast.PropertyIsStatic)
 			prop, keep = lowerField(prop, private, shouldOmitFieldInitializer, staticFieldToBlockAssign)
+			if runExtraInitializers.Data != nil {
+				if isStatic {
+					staticMembers = append(staticMembers, runExtraInitializers)
+				} else {
+					instanceMembers = append(instanceMembers, js_ast.Stmt{Loc: prop.Loc, Data: &js_ast.SExpr{Value: runExtraInitializers}})
+				}
+			}
 			if !keep {
 				continue
 			}
@@ -1290,6 +1699,14 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 	// Finish the filtering operation
 	class.Properties = properties
 
+	// Extra initializers for decorated instance methods run before any
+	// instance fields are initialized
+	if hasInstanceMethodDecorators {
+		instanceMembers = append([]js_ast.Stmt{{Loc: classLoc, Data: &js_ast.SExpr{
+			Value: runInitializers(classLoc, 5, instanceThis(classLoc), js_ast.Expr{}),
+		}}}, instanceMembers...)
+	}
+
 	// If there are expressions with side effects left over and static blocks are
 	// supported, insert a static block at the start of the class body. This is
 	// necessary because computed static fields need to reference variables that
@@ -1307,7 +1724,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 	//     static { this[_a] = 1; }
 	//   }
 	//
-	if computedPropertyCache.Data != nil && !p.options.unsupportedJSFeatures.Has(compat.ClassStaticBlocks) {
+	if computedPropertyCache.Data != nil && !lowerClassSyntax && !p.options.unsupportedJSFeatures.Has(compat.ClassStaticBlocks) {
 		loc := computedPropertyCache.Loc
 		class.Properties = append(append(
 			make([]js_ast.Property, 0, 1+len(class.Properties)),
@@ -1379,6 +1796,40 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 		}
 	}
 
+	// Apply JavaScript decorators after the class body has been evaluated. This
+	// happens after private members have been initialized (since decorators may
+	// replace private methods) but before static fields are initialized. Class
+	// decorators are applied last, once the class has been fully defined.
+	var decoratorExprs []js_ast.Expr
+	var staticMethodExtraInitializers js_ast.Expr
+	var classDecoratorExprs []js_ast.Expr
+	if lowerStdDecorators {
+		p.recordUsage(decoratorInitRef)
+		decoratorExprs = append(decoratorExprs, js_ast.Assign(
+			js_ast.Expr{Loc: classLoc, Data: &js_ast.EIdentifier{Ref: decoratorInitRef}},
+			p.callRuntime(classLoc, "__decoratorStart", []js_ast.Expr{decoratorBase}),
+		))
+		for _, calls := range decoratorCalls {
+			decoratorExprs = append(decoratorExprs, calls...)
+		}
+		if hasStaticMethodDecorators {
+			staticMethodExtraInitializers = runInitializers(classLoc, 3, nameFunc(), js_ast.Expr{})
+		}
+		if hasStdClassDecorators {
+			classDecoratorExprs = append(classDecoratorExprs,
+				js_ast.Assign(nameFunc(), decorateElement(classLoc, decoratorKindClass,
+					js_ast.Expr{Loc: classLoc, Data: &js_ast.EString{Value: helpers.StringToUTF16(classDecoratorsName)}},
+					classDecoratorsRef, nameFunc(), js_ast.Expr{})),
+				runInitializers(classLoc, 1, nameFunc(), js_ast.Expr{}))
+		} else {
+			p.recordUsage(decoratorInitRef)
+			decoratorExprs = append(decoratorExprs, p.callRuntime(classLoc, "__decoratorMetadata", []js_ast.Expr{
+				{Loc: classLoc, Data: &js_ast.EIdentifier{Ref: decoratorInitRef}},
+				nameFunc(),
+			}))
+		}
+	}
+
 	// Pack the class back into an expression. We don't need to handle TypeScript
 	// decorators for class expressions because TypeScript doesn't support them.
 	if kind == classKindExpr {
@@ -1397,17 +1848,43 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 		for _, value := range privateMembers {
 			expr = js_ast.JoinWithComma(expr, value)
 		}
+		for _, value := range decoratorExprs {
+			expr = js_ast.JoinWithComma(expr, value)
+		}
 		for _, value := range staticPrivateMethods {
 			expr = js_ast.JoinWithComma(expr, value)
 		}
+		if staticMethodExtraInitializers.Data != nil {
+			expr = js_ast.JoinWithComma(expr, staticMethodExtraInitializers)
+		}
 		for _, value := range staticMembers {
 			expr = js_ast.JoinWithComma(expr, value)
 		}
+		for _, value := range classDecoratorExprs {
+			expr = js_ast.JoinWithComma(expr, value)
+		}
 
 		// Finally join "expr" with the variable that holds the class object
 		if nameToJoin.Data != nil {
 			expr = js_ast.JoinWithComma(expr, nameToJoin)
 		}
+
+		// Replace the class expression with a constructor function if necessary
+		if lowerClassSyntax {
+			var nameRef ast.Ref
+			if class.Name != nil {
+				nameRef = class.Name.Ref
+			} else {
+				nameRef = p.newSymbol(ast.SymbolOther, "_class")
+				p.currentScope.Generated = append(p.currentScope.Generated, nameRef)
+			}
+			expr, _ = replaceClassExpr(expr, classExprData, p.lowerClassToConstructorFunction(class, classLoc, nameRef, result))
+		}
+
+		// Decorators, the base class, and computed keys are evaluated first
+		if decoratorPrefix.Data != nil {
+			expr = js_ast.JoinWithComma(decoratorPrefix, expr)
+		}
 		if wrapFunc != nil {
 			expr = wrapFunc(expr)
 		}
@@ -1458,10 +1935,17 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 	// statements to variables during parsing and b) don't yet know whether this
 	// module will need to be lazily-evaluated or not in the parser. So we always
 	// do this just in case it's needed.
-	mustConvertStmtToExpr := p.currentScope.Parent == nil && (p.options.mode == config.ModeBundle || p.willWrapModuleInTryCatchForUsing)
+	//
+	// Class syntax that's lowered to a constructor function must always be
+	// converted since the constructor function is an expression.
+	mustConvertStmtToExpr := lowerClassSyntax ||
+		(p.currentScope.Parent == nil && (p.options.mode == config.ModeBundle || p.willWrapModuleInTryCatchForUsing))
 
 	var classExperimentalDecorators []js_ast.Decorator
 	if p.options.ts.Parse && p.options.ts.Config.ExperimentalDecorators == config.True {
+		if ctorForMetadata != nil && len(class.Decorators) > 0 {
+			class.Decorators = append(class.Decorators, p.constructorMetadata(classLoc, ctorForMetadata))
+		}
 		classExperimentalDecorators = class.Decorators
 	}
 
@@ -1477,7 +1961,8 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 			len(staticMembers) > 0 ||
 			len(instanceDecorators) > 0 ||
 			len(staticDecorators) > 0 ||
-			len(classExperimentalDecorators) > 0)
+			len(classExperimentalDecorators) > 0 ||
+			lowerStdDecorators)
 
 	// Pack the class back into a statement, with potentially some extra
 	// statements afterwards
@@ -1485,7 +1970,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 	var outerClassNameDecl js_ast.Stmt
 	var nameForClassDecorators ast.LocRef
 	didGenerateLocalStmt := false
-	if len(classExperimentalDecorators) > 0 || hasPotentialInnerClassNameEscape || mustConvertStmtToExpr {
+	if len(classExperimentalDecorators) > 0 || hasStdClassDecorators || hasPotentialInnerClassNameEscape || mustConvertStmtToExpr {
 		didGenerateLocalStmt = true
 
 		// Determine the name to use for decorators
@@ -1521,7 +2006,7 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 		}
 
 		// Generate the class initialization statement
-		if len(classExperimentalDecorators) > 0 {
+		if len(classExperimentalDecorators) > 0 || hasStdClassDecorators {
 			// If there are class decorators, then we actually need to mutate the
 			// immutable "const" binding that shadows everything in the class body.
 			// The official TypeScript compiler does this by rewriting all class name
@@ -1578,6 +2063,30 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 				}},
 			}})
 		}
+
+		// Replace the class expression with a constructor function if necessary
+		if lowerClassSyntax {
+			var nameRef ast.Ref
+			if len(classExperimentalDecorators) > 0 || hasStdClassDecorators {
+				// References inside the class body were merged into the outer name,
+				// which is mutable, so the constructor needs a separate name
+				nameRef = p.newSymbol(ast.SymbolOther, p.symbols[nameForClassDecorators.Ref.InnerIndex].OriginalName)
+				p.currentScope.Generated = append(p.currentScope.Generated, nameRef)
+			} else if hasPotentialInnerClassNameEscape {
+				nameRef = class.Name.Ref
+			} else {
+				// The constructor function declaration shadows the outer name, so the
+				// outer name can be used for references inside the class body too:
+				// "class Foo { foo() { Foo } }" => "var Foo = function() { function Foo() {} ... }()"
+				if class.Name != nil {
+					p.mergeSymbols(class.Name.Ref, nameForClassDecorators.Ref)
+					class.Name = nil
+				}
+				nameRef = nameForClassDecorators.Ref
+			}
+			local := stmts[0].Data.(*js_ast.SLocal)
+			local.Decls[0].ValueOrNil = p.lowerClassToConstructorFunction(class, classLoc, nameRef, result)
+		}
 	} else {
 		switch kind {
 		case classKindStmt:
@@ -1616,12 +2125,21 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 	for _, expr := range privateMembers {
 		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
 	}
+	for _, expr := range decoratorExprs {
+		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
+	}
 	for _, expr := range staticPrivateMethods {
 		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
 	}
+	if staticMethodExtraInitializers.Data != nil {
+		stmts = append(stmts, js_ast.Stmt{Loc: staticMethodExtraInitializers.Loc, Data: &js_ast.SExpr{Value: staticMethodExtraInitializers}})
+	}
 	for _, expr := range staticMembers {
 		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
 	}
+	for _, expr := range classDecoratorExprs {
+		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
+	}
 	for _, expr := range instanceDecorators {
 		stmts = append(stmts, js_ast.Stmt{Loc: expr.Loc, Data: &js_ast.SExpr{Value: expr}})
 	}
@@ -1654,6 +2172,10 @@ func (p *parser) lowerClass(stmt js_ast.Stmt, expr js_ast.Expr, result visitClas
 			Items: []js_ast.ClauseItem{{Alias: "default", Name: defaultName}},
 		}})
 	}
+	if decoratorPrefix.Data != nil {
+		// Decorators, the base class, and computed keys are evaluated first
+		stmts = append([]js_ast.Stmt{{Loc: decoratorPrefix.Loc, Data: &js_ast.SExpr{Value: decoratorPrefix}}}, stmts...)
+	}
 	return stmts, js_ast.Expr{}
 }
 
@@ -1822,3 +2344,284 @@ func findFirstTopLevelSuperCall(expr js_ast.Expr, superCtorRef ast.Ref) (js_ast.
 
 	return js_ast.Expr{}, logger.Loc{}, nil, js_ast.Expr{}
 }
+
+// These are the accessor kinds passed to the "__defMethod" runtime helper.
+// They must be kept in sync with the runtime.
+const (
+	classMethodKindGetter = 1
+	classMethodKindSetter = 2
+)
+
+// Lower class syntax to a constructor function for environments without
+// classes. By this point all fields, static blocks, and private members have
+// already been moved out of the class body, so only the constructor, methods,
+// and accessors remain. For example:
+//
+//	// Original code
+//	class Foo extends Bar {
+//	  constructor() { super(); this.x = 1 }
+//	  foo() { return super.foo() }
+//	  static bar() {}
+//	}
+//
+//	// Lowered code
+//	var Foo = /* @__PURE__ */ (function(_super) {
+//	  __inherits(Foo, _super);
+//	  function Foo() {
+//	    __classCallCheck(this, Foo);
+//	    var _this = this;
+//	    _this = __callSuper(_this, Foo, []);
+//	    _this.x = 1;
+//	    return _this;
+//	  }
+//	  __defMethod(Foo.prototype, "foo", function() {
+//	    return __superGet(Foo.prototype, this, "foo").call(this);
+//	  });
+//	  __defMethod(Foo, "bar", function() {
+//	  });
+//	  return Foo;
+//	})(Bar);
+//
+// The base class and any computed keys are passed as arguments so that they
+// are still evaluated in order outside of the function, which preserves the
+// meaning of "this", "arguments", "await", and "yield" inside them.
+func (p *parser) lowerClassToConstructorFunction(class *js_ast.Class, classLoc logger.Loc, nameRef ast.Ref, result visitClassResult) js_ast.Expr {
+	var args []js_ast.Arg
+	var argValues []js_ast.Expr
+	var stmts []js_ast.Stmt
+	var ctor *js_ast.EFunction
+
+	nameExpr := func(loc logger.Loc) js_ast.Expr {
+		p.recordUsage(nameRef)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: nameRef}}
+	}
+	captureArg := func(value js_ast.Expr, name string) js_ast.Expr {
+		ref := p.generateTempRef(tempRefNoDeclare, name)
+		args = append(args, js_ast.Arg{Binding: js_ast.Binding{Loc: value.Loc, Data: &js_ast.BIdentifier{Ref: ref}}})
+		argValues = append(argValues, value)
+		p.recordUsage(ref)
+		return js_ast.Expr{Loc: value.Loc, Data: &js_ast.EIdentifier{Ref: ref}}
+	}
+
+	// "class Foo extends Bar {}" => "__inherits(Foo, _super)"
+	if class.ExtendsOrNil.Data != nil {
+		base := captureArg(class.ExtendsOrNil, "_super")
+		stmts = append(stmts, js_ast.Stmt{Loc: class.ExtendsOrNil.Loc, Data: &js_ast.SExpr{
+			Value: p.callRuntime(class.ExtendsOrNil.Loc, "__inherits", []js_ast.Expr{nameExpr(classLoc), base}),
+		}})
+	}
+
+	// Methods and accessors are defined in order after the constructor
+	var members []js_ast.Stmt
+	for _, prop := range class.Properties {
+		if !prop.Flags.Has(js_ast.PropertyIsStatic) && !prop.Flags.Has(js_ast.PropertyIsComputed) {
+			if key, ok := prop.Key.Data.(*js_ast.EString); ok && helpers.UTF16EqualsString(key.Value, "constructor") {
+				if fn, ok := prop.ValueOrNil.Data.(*js_ast.EFunction); ok {
+					ctor = fn
+					continue
+				}
+			}
+		}
+
+		key := prop.Key
+		if prop.Flags.Has(js_ast.PropertyIsComputed) {
+			switch key.Data.(type) {
+			case *js_ast.EString, *js_ast.ENameOfSymbol, *js_ast.ENumber:
+				// These have no side effects
+			default:
+				key = captureArg(key, "")
+			}
+		}
+
+		target := nameExpr(prop.Loc)
+		if !prop.Flags.Has(js_ast.PropertyIsStatic) {
+			target = js_ast.Expr{Loc: prop.Loc, Data: &js_ast.EDot{Target: target, Name: "prototype", NameLoc: prop.Loc}}
+		}
+		methodArgs := []js_ast.Expr{target, key, prop.ValueOrNil}
+		switch prop.Kind {
+		case js_ast.PropertyGet:
+			methodArgs = append(methodArgs, js_ast.Expr{Loc: prop.Loc, Data: &js_ast.ENumber{Value: classMethodKindGetter}})
+		case js_ast.PropertySet:
+			methodArgs = append(methodArgs, js_ast.Expr{Loc: prop.Loc, Data: &js_ast.ENumber{Value: classMethodKindSetter}})
+		}
+		members = append(members, js_ast.Stmt{Loc: prop.Loc, Data: &js_ast.SExpr{Value: p.callRuntime(prop.Loc, "__defMethod", methodArgs)}})
+	}
+
+	// Generate the constructor function
+	if ctor == nil {
+		ctor = &js_ast.EFunction{Fn: js_ast.Fn{Body: js_ast.FnBody{Loc: classLoc}}}
+
+		// "class Foo extends Bar {}" => "function Foo() { return __callSuper(this, Foo, arguments) }"
+		if class.ExtendsOrNil.Data != nil {
+			argumentsRef := p.newSymbol(ast.SymbolUnbound, "arguments")
+			p.currentScope.Generated = append(p.currentScope.Generated, argumentsRef)
+			ctor.Fn.Body.Block.Stmts = []js_ast.Stmt{{Loc: classLoc, Data: &js_ast.SReturn{ValueOrNil: p.callRuntime(classLoc, "__callSuper", []js_ast.Expr{
+				{Loc: classLoc, Data: js_ast.EThisShared},
+				nameExpr(classLoc),
+				{Loc: classLoc, Data: &js_ast.EIdentifier{Ref: argumentsRef}},
+			})}}}
+		}
+	} else if result.derivedClassThisRef != ast.InvalidRef {
+		p.lowerDerivedClassConstructorBody(&ctor.Fn.Body, nameRef, result)
+	}
+
+	// Calling a class without "new" throws, so do that for the constructor too
+	ctor.Fn.Body.Block.Stmts = append([]js_ast.Stmt{{Loc: classLoc, Data: &js_ast.SExpr{Value: p.callRuntime(classLoc, "__classCallCheck", []js_ast.Expr{
+		{Loc: classLoc, Data: js_ast.EThisShared},
+		nameExpr(classLoc),
+	})}}}, ctor.Fn.Body.Block.Stmts...)
+	ctor.Fn.Name = &ast.LocRef{Loc: classLoc, Ref: nameRef}
+	p.recordDeclaredSymbol(nameRef)
+	stmts = append(stmts, js_ast.Stmt{Loc: classLoc, Data: &js_ast.SFunction{Fn: ctor.Fn}})
+	stmts = append(stmts, members...)
+	stmts = append(stmts, js_ast.Stmt{Loc: classLoc, Data: &js_ast.SReturn{ValueOrNil: nameExpr(classLoc)}})
+
+	return js_ast.Expr{Loc: classLoc, Data: &js_ast.ECall{
+		Target: js_ast.Expr{Loc: classLoc, Data: &js_ast.EFunction{Fn: js_ast.Fn{
+			Args: args,
+			Body: js_ast.FnBody{Loc: class.BodyLoc, Block: js_ast.SBlock{Stmts: stmts}},
+		}}},
+		Args:                   argValues,
+		CanBeUnwrappedIfUnused: result.canBeRemovedIfUnused,
+	}}
+}
+
+// The object returned by the base class constructor replaces "this" in a
+// derived class constructor. All uses of "this" in the constructor have
+// already been replaced with a variable while visiting, so this initializes
+// that variable and assigns the result of "super()" to it:
+//
+//	function Foo() {
+//	  var _this = this;
+//	  _this = __callSuper(_this, Foo, [1, 2]);
+//	  return _this;
+//	}
+//
+// The "super()" calls are where "insertStmtsAfterSuperCall" left them: either
+// a single top-level call or a call at the start of the "__super" helper.
+func (p *parser) lowerDerivedClassConstructorBody(body *js_ast.FnBody, nameRef ast.Ref, result visitClassResult) {
+	thisRef := result.derivedClassThisRef
+	thisExpr := func(loc logger.Loc) js_ast.Expr {
+		p.recordUsage(thisRef)
+		return js_ast.Expr{Loc: loc, Data: &js_ast.EIdentifier{Ref: thisRef}}
+	}
+
+	// "super(a, b)" => "_this = __callSuper(_this, Foo, [a, b])"
+	lowerSuperCall := func(stmt js_ast.Stmt) bool {
+		expr, ok := stmt.Data.(*js_ast.SExpr)
+		if !ok {
+			return false
+		}
+		call, ok := expr.Value.Data.(*js_ast.ECall)
+		if !ok {
+			return false
+		}
+		if _, ok := call.Target.Data.(*js_ast.ESuper); !ok {
+			return false
+		}
+		loc := expr.Value.Loc
+		var args js_ast.Expr
+		if len(call.Args) == 1 {
+			if spread, ok := call.Args[0].Data.(*js_ast.ESpread); ok {
+				// "super(...arguments)" => "__callSuper(_this, Foo, arguments)"
+				if id, ok := spread.Value.Data.(*js_ast.EIdentifier); ok && p.symbols[id.Ref.InnerIndex].OriginalName == "arguments" {
+					args = spread.Value
+				}
+			}
+		}
+		if args.Data == nil {
+			args = p.lowerArraySpread(loc, call.Args, true)
+		}
+		p.recordUsage(nameRef)
+		expr.Value = js_ast.Assign(thisExpr(loc), p.callRuntime(loc, "__callSuper", []js_ast.Expr{
+			thisExpr(loc),
+			{Loc: loc, Data: &js_ast.EIdentifier{Ref: nameRef}},
+			args,
+		}))
+		return true
+	}
+
+	stmts := body.Block.Stmts
+	for _, stmt := range stmts {
+		if lowerSuperCall(stmt) {
+			break
+		}
+
+		// "var __super = (...args) => { super(...args); }" =>
+		// "var __super = function() { _this = __callSuper(_this, Foo, arguments); return _this; }"
+		if local, ok := stmt.Data.(*js_ast.SLocal); ok && len(local.Decls) == 1 && result.superCtorRef != ast.InvalidRef {
+			if id, ok := local.Decls[0].Binding.Data.(*js_ast.BIdentifier); ok && id.Ref == result.superCtorRef {
+				if arrow, ok := local.Decls[0].ValueOrNil.Data.(*js_ast.EArrow); ok && len(arrow.Body.Block.Stmts) > 0 {
+					argumentsRef := p.newSymbol(ast.SymbolUnbound, "arguments")
+					p.currentScope.Generated = append(p.currentScope.Generated, argumentsRef)
+					superStmts := arrow.Body.Block.Stmts
+					if expr, ok := superStmts[0].Data.(*js_ast.SExpr); ok {
+						if call, ok := expr.Value.Data.(*js_ast.ECall); ok {
+							call.Args = []js_ast.Expr{{Loc: arrow.Body.Loc, Data: &js_ast.ESpread{Value: js_ast.Expr{Loc: arrow.Body.Loc, Data: &js_ast.EIdentifier{Ref: argumentsRef}}}}}
+						}
+					}
+					lowerSuperCall(superStmts[0])
+					superStmts = append(superStmts, js_ast.Stmt{Loc: arrow.Body.Loc, Data: &js_ast.SReturn{ValueOrNil: thisExpr(arrow.Body.Loc)}})
+					local.Decls[0].ValueOrNil.Data = &js_ast.EFunction{Fn: js_ast.Fn{
+						Body: js_ast.FnBody{Loc: arrow.Body.Loc, Block: js_ast.SBlock{Stmts: superStmts}},
+					}}
+					break
+				}
+			}
+		}
+	}
+
+	// "var _this = this;" must come first, but after any directives
+	i := 0
+	for i < len(stmts) {
+		if _, ok := stmts[i].Data.(*js_ast.SDirective); !ok {
+			break
+		}
+		i++
+	}
+	p.recordDeclaredSymbol(thisRef)
+	decl := js_ast.Stmt{Loc: body.Loc, Data: &js_ast.SLocal{Kind: js_ast.LocalVar, Decls: []js_ast.Decl{{
+		Binding:    js_ast.Binding{Loc: body.Loc, Data: &js_ast.BIdentifier{Ref: thisRef}},
+		ValueOrNil: js_ast.Expr{Loc: body.Loc, Data: js_ast.EThisShared},
+	}}}}
+	stmts = append(append(append(make([]js_ast.Stmt, 0, len(stmts)+2), stmts[:i]...), decl), stmts[i:]...)
+
+	// Return the object created by the base class constructor
+	if _, ok := stmts[len(stmts)-1].Data.(*js_ast.SReturn); !ok {
+		stmts = append(stmts, js_ast.Stmt{Loc: body.Loc, Data: &js_ast.SReturn{ValueOrNil: thisExpr(body.Loc)}})
+	}
+	body.Block.Stmts = stmts
+}
+
+// The class expression may have been captured in a temporary variable by the
+// time it's lowered to a constructor function, so search for it
+func replaceClassExpr(expr js_ast.Expr, class *js_ast.EClass, value js_ast.Expr) (js_ast.Expr, bool) {
+	switch e := expr.Data.(type) {
+	case *js_ast.EClass:
+		if e == class {
+			return value, true
+		}
+
+	case *js_ast.EBinary:
+		var ok bool
+		if e.Left, ok = replaceClassExpr(e.Left, class, value); ok {
+			return expr, true
+		}
+		if e.Right, ok = replaceClassExpr(e.Right, class, value); ok {
+			return expr, true
+		}
+
+	case *js_ast.ECall:
+		// This handles the arrow function generated by "captureValueWithPossibleSideEffects"
+		if arrow, ok := e.Target.Data.(*js_ast.EArrow); ok && len(arrow.Body.Block.Stmts) == 1 {
+			if ret, ok := arrow.Body.Block.Stmts[0].Data.(*js_ast.SReturn); ok {
+				var ok bool
+				if ret.ValueOrNil, ok = replaceClassExpr(ret.ValueOrNil, class, value); ok {
+					return expr, true
+				}
+			}
+		}
+	}
+
+	return expr, false
+}
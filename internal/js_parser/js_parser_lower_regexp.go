@@ -0,0 +1,1562 @@
+// This file contains code for lowering regular expression syntax. Regular
+// expressions that use syntax the target doesn't support are compiled into
+// an equivalent regular expression that only uses older syntax, similar to
+// what the "regexpu" library does. For example:
+//
+//	/(?<year>\d{4})/         => __wrapRegExp(/(\d{4})/, { year: 1 })
+//	/a.b/s                   => /a[\s\S]b/
+//	/\p{Script=Ogham}/u      => /[\u1680-\u169C]/u
+//	/[[a-z]--[aeiou]]/v      => /[b-df-hj-np-tv-z]/u
+//	/😀+/u                   => /(?:\uD83D\uDE00)+/
+//
+// If a regular expression uses something that can't be lowered (e.g. the "y"
+// flag or lookbehind assertions), it's left alone and the caller falls back
+// to converting it into a "new RegExp()" constructor call instead.
+
+package js_parser
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/evanw/esbuild/internal/compat"
+)
+
+const regExpMaxCodePoint = unicode.MaxRune
+
+// An inclusive range of code points
+type regExpRange struct {
+	lo rune
+	hi rune
+}
+
+// A set of code points stored as sorted, non-overlapping, non-adjacent ranges
+type regExpCharSet []regExpRange
+
+func (set regExpCharSet) normalize() regExpCharSet {
+	if len(set) < 2 {
+		return set
+	}
+	sort.Slice(set, func(i int, j int) bool {
+		return set[i].lo < set[j].lo
+	})
+	end := 0
+	for _, r := range set[1:] {
+		if prev := &set[end]; r.lo <= prev.hi+1 {
+			if r.hi > prev.hi {
+				prev.hi = r.hi
+			}
+		} else {
+			end++
+			set[end] = r
+		}
+	}
+	return set[:end+1]
+}
+
+func (set regExpCharSet) contains(c rune) bool {
+	i := sort.Search(len(set), func(i int) bool {
+		return set[i].hi >= c
+	})
+	return i < len(set) && set[i].lo <= c
+}
+
+func (set regExpCharSet) union(other regExpCharSet) regExpCharSet {
+	result := make(regExpCharSet, 0, len(set)+len(other))
+	result = append(result, set...)
+	result = append(result, other...)
+	return result.normalize()
+}
+
+func (set regExpCharSet) complement() regExpCharSet {
+	var result regExpCharSet
+	next := rune(0)
+	for _, r := range set {
+		if r.lo > next {
+			result = append(result, regExpRange{lo: next, hi: r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= regExpMaxCodePoint {
+		result = append(result, regExpRange{lo: next, hi: regExpMaxCodePoint})
+	}
+	return result
+}
+
+func (set regExpCharSet) intersect(other regExpCharSet) regExpCharSet {
+	var result regExpCharSet
+	i, j := 0, 0
+	for i < len(set) && j < len(other) {
+		a, b := set[i], other[j]
+		lo, hi := a.lo, a.hi
+		if b.lo > lo {
+			lo = b.lo
+		}
+		if b.hi < hi {
+			hi = b.hi
+		}
+		if lo <= hi {
+			result = append(result, regExpRange{lo: lo, hi: hi})
+		}
+		if a.hi < b.hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+func (set regExpCharSet) subtract(other regExpCharSet) regExpCharSet {
+	return set.intersect(other.complement())
+}
+
+func regExpCharSetFromTable(table *unicode.RangeTable) regExpCharSet {
+	var set regExpCharSet
+	for _, r := range table.R16 {
+		if r.Stride == 1 {
+			set = append(set, regExpRange{lo: rune(r.Lo), hi: rune(r.Hi)})
+		} else {
+			for c := rune(r.Lo); c <= rune(r.Hi); c += rune(r.Stride) {
+				set = append(set, regExpRange{lo: c, hi: c})
+			}
+		}
+	}
+	for _, r := range table.R32 {
+		if r.Stride == 1 {
+			set = append(set, regExpRange{lo: rune(r.Lo), hi: rune(r.Hi)})
+		} else {
+			for c := rune(r.Lo); c <= rune(r.Hi); c += rune(r.Stride) {
+				set = append(set, regExpRange{lo: c, hi: c})
+			}
+		}
+	}
+	return set.normalize()
+}
+
+func regExpCharSetFromTables(tables ...*unicode.RangeTable) regExpCharSet {
+	var set regExpCharSet
+	for _, table := range tables {
+		set = set.union(regExpCharSetFromTable(table))
+	}
+	return set
+}
+
+var regExpDigitChars = regExpCharSet{{lo: '0', hi: '9'}}
+
+var regExpWordChars = regExpCharSet{{lo: '0', hi: '9'}, {lo: 'A', hi: 'Z'}, {lo: '_', hi: '_'}, {lo: 'a', hi: 'z'}}
+
+var regExpLineTerminatorChars = regExpCharSet{{lo: '\n', hi: '\n'}, {lo: '\r', hi: '\r'}, {lo: 0x2028, hi: 0x2029}}
+
+var regExpWhitespaceChars = regExpCharSet{
+	{lo: '\t', hi: '\r'},
+	{lo: ' ', hi: ' '},
+	{lo: 0xA0, hi: 0xA0},
+	{lo: 0x1680, hi: 0x1680},
+	{lo: 0x2000, hi: 0x200A},
+	{lo: 0x2028, hi: 0x2029},
+	{lo: 0x202F, hi: 0x202F},
+	{lo: 0x205F, hi: 0x205F},
+	{lo: 0x3000, hi: 0x3000},
+	{lo: 0xFEFF, hi: 0xFEFF},
+}
+
+// These are all code points that are part of a case folding orbit of more
+// than one code point. This is computed once and then shared by all parsers.
+var regExpCaseFoldable struct {
+	once  sync.Once
+	runes []rune
+}
+
+func regExpCaseFoldableRunes() []rune {
+	regExpCaseFoldable.once.Do(func() {
+		for _, r := range unicode.CaseRanges {
+			for c := rune(r.Lo); c <= rune(r.Hi); c++ {
+				if unicode.SimpleFold(c) != c {
+					regExpCaseFoldable.runes = append(regExpCaseFoldable.runes, c)
+				}
+			}
+		}
+	})
+	return regExpCaseFoldable.runes
+}
+
+// Adds every code point that is equal to a code point in the set when case
+// is ignored. This mirrors how the "u" and "v" flags compare characters.
+func (set regExpCharSet) caseClosure() regExpCharSet {
+	var extra regExpCharSet
+	for _, c := range regExpCaseFoldableRunes() {
+		if set.contains(c) {
+			for f := unicode.SimpleFold(c); f != c; f = unicode.SimpleFold(f) {
+				extra = append(extra, regExpRange{lo: f, hi: f})
+			}
+		}
+	}
+	if extra == nil {
+		return set
+	}
+	return set.union(extra)
+}
+
+// This is how regular expressions without the "u" flag compare characters
+// when case is ignored: https://tc39.es/ecma262/#sec-runtime-semantics-canonicalize-ch
+func regExpNonUnicodeCanonicalize(c rune) rune {
+	if upper := unicode.ToUpper(c); c < 128 || upper >= 128 {
+		return upper
+	}
+	return c
+}
+
+type regExpGroupName struct {
+	name  string
+	index int
+}
+
+type regExpLowering struct {
+	groups map[string]int
+	sb     strings.Builder
+
+	pattern     string
+	groupNames  []regExpGroupName
+	i           int
+	isUnicode   bool // The "u" or "v" flag
+	isSets      bool // The "v" flag
+	ignoreCase  bool // The "i" flag
+	dotAll      bool // The "s" flag
+	lowerGroups bool
+	lowerProps  bool
+	lowerDotAll bool
+	lowerSets   bool
+
+	// Lookbehind assertions are needed to lower "\b" and "\B"
+	canUseLookbehind bool
+
+	// If this is true, the "u" flag is being removed. Code points outside of
+	// the BMP must then be matched as a pair of UTF-16 surrogates instead.
+	lowerUnicode bool
+
+	failed bool
+}
+
+// This returns a regular expression literal that only uses syntax supported
+// by the target. If the regular expression originally contained named capture
+// groups, their names are returned too and the caller is responsible for
+// passing them to the "__wrapRegExp" runtime helper.
+func (p *parser) lowerRegExp(value string) (result string, groupNames []regExpGroupName, ok bool) {
+	end := strings.LastIndexByte(value, '/')
+	l := regExpLowering{pattern: value[1:end]}
+	flags := value[end+1:]
+	newFlags := make([]byte, 0, len(flags))
+
+	for i := 0; i < len(flags); i++ {
+		switch c := flags[i]; c {
+		case 'g', 'm':
+			// These are part of ES5 and are always supported
+
+		case 'i':
+			l.ignoreCase = true
+
+		case 's':
+			l.dotAll = true
+			if p.options.unsupportedJSFeatures.Has(compat.RegexpDotAllFlag) {
+				l.lowerDotAll = true
+				continue
+			}
+
+		case 'u':
+			l.isUnicode = true
+			if p.options.unsupportedJSFeatures.Has(compat.RegexpStickyAndUnicodeFlags) {
+				l.lowerUnicode = true
+				continue
+			}
+
+		case 'v':
+			l.isUnicode = true
+			l.isSets = true
+			if p.options.unsupportedJSFeatures.Has(compat.RegexpSetNotation) {
+				l.lowerSets = true
+				if p.options.unsupportedJSFeatures.Has(compat.RegexpStickyAndUnicodeFlags) {
+					l.lowerUnicode = true
+				} else {
+					newFlags = append(newFlags, 'u')
+				}
+				continue
+			}
+
+		case 'y':
+			if p.options.unsupportedJSFeatures.Has(compat.RegexpStickyAndUnicodeFlags) {
+				return // The "sticky" flag can't be lowered
+			}
+
+		case 'd':
+			if p.options.unsupportedJSFeatures.Has(compat.RegexpMatchIndices) {
+				return // Match indices can't be lowered
+			}
+
+		default:
+			return // Unknown flags can't be lowered
+		}
+		newFlags = append(newFlags, flags[i])
+	}
+
+	// Lookbehind assertions can't be lowered
+	if p.options.unsupportedJSFeatures.Has(compat.RegexpLookbehindAssertions) &&
+		(strings.Contains(l.pattern, "(?<=") || strings.Contains(l.pattern, "(?<!")) {
+		return
+	}
+
+	l.canUseLookbehind = !p.options.unsupportedJSFeatures.Has(compat.RegexpLookbehindAssertions)
+	l.lowerGroups = p.options.unsupportedJSFeatures.Has(compat.RegexpNamedCaptureGroups) && strings.Contains(l.pattern, "(?<")
+	l.lowerProps = l.isUnicode && p.options.unsupportedJSFeatures.Has(compat.RegexpUnicodePropertyEscapes) &&
+		(strings.Contains(l.pattern, "\\p{") || strings.Contains(l.pattern, "\\P{"))
+	if !l.lowerGroups && !l.lowerProps && !l.lowerDotAll && !l.lowerUnicode && !l.lowerSets {
+		return
+	}
+
+	if l.lowerGroups && !l.scanGroupNames() {
+		return
+	}
+	l.rewritePattern()
+	if l.failed {
+		return
+	}
+	if l.lowerGroups {
+		groupNames = l.groupNames
+	}
+	return "/" + l.sb.String() + "/" + string(newFlags), groupNames, true
+}
+
+func (l *regExpLowering) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(l.pattern[l.i:], prefix)
+}
+
+// Returns the index after the end of the character class starting at "i"
+func (l *regExpLowering) skipClass(i int) int {
+	depth := 0
+	for i < len(l.pattern) {
+		switch l.pattern[i] {
+		case '\\':
+			i++
+		case '[':
+			if l.isSets || depth == 0 {
+				depth++
+			}
+		case ']':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return i
+}
+
+// Named groups can be referenced before they are declared, so their indices
+// need to be known before the pattern is rewritten
+func (l *regExpLowering) scanGroupNames() bool {
+	l.groups = make(map[string]int)
+	index := 0
+	for i := 0; i < len(l.pattern); i++ {
+		switch l.pattern[i] {
+		case '\\':
+			i++
+
+		case '[':
+			i = l.skipClass(i) - 1
+
+		case '(':
+			tail := l.pattern[i+1:]
+			if !strings.HasPrefix(tail, "?") {
+				index++
+			} else if strings.HasPrefix(tail, "?<") && !strings.HasPrefix(tail, "?<=") && !strings.HasPrefix(tail, "?<!") {
+				end := strings.IndexByte(tail, '>')
+				if end < 0 {
+					return false
+				}
+				name := tail[2:end]
+				if _, ok := l.groups[name]; ok {
+					return false // Duplicate named groups aren't supported
+				}
+				index++
+				l.groups[name] = index
+				l.groupNames = append(l.groupNames, regExpGroupName{name: name, index: index})
+			}
+		}
+	}
+	return true
+}
+
+func (l *regExpLowering) rewritePattern() {
+	for l.i < len(l.pattern) && !l.failed {
+		switch c := l.pattern[l.i]; c {
+		case '\\':
+			l.rewriteEscape()
+
+		case '[':
+			l.rewriteClass()
+
+		case '(':
+			l.i++
+			l.sb.WriteByte('(')
+
+			// "(?<name>a)" => "(a)"
+			if l.lowerGroups && l.hasPrefix("?<") && !l.hasPrefix("?<=") && !l.hasPrefix("?<!") {
+				l.i += strings.IndexByte(l.pattern[l.i:], '>') + 1
+			}
+
+		case '.':
+			l.i++
+			if !l.isUnicode {
+				if l.lowerDotAll && l.dotAll {
+					l.sb.WriteString("[\\s\\S]")
+				} else {
+					l.sb.WriteByte('.')
+				}
+			} else if (l.lowerDotAll && l.dotAll) || l.lowerUnicode {
+				set := regExpCharSet{{lo: 0, hi: regExpMaxCodePoint}}
+				if !l.dotAll {
+					set = set.subtract(regExpLineTerminatorChars)
+				}
+				l.writeClass(set, nil)
+			} else {
+				l.sb.WriteByte('.')
+			}
+
+		default:
+			start := l.i
+			c, size := utf8.DecodeRuneInString(l.pattern[l.i:])
+			l.i += size
+			l.writeLiteral(c, l.pattern[start:l.i])
+		}
+	}
+}
+
+func (l *regExpLowering) rewriteEscape() {
+	start := l.i
+	l.i++
+	if l.i >= len(l.pattern) {
+		l.failed = true
+		return
+	}
+	c := l.pattern[l.i]
+
+	switch {
+	case l.isUnicode && (c == 'p' || c == 'P'):
+		if l.lowerProps || l.lowerUnicode {
+			if set, ok := l.parseClassEscape(); ok {
+				if l.ignoreCase && l.lowerUnicode {
+					set = set.caseClosure()
+				}
+				l.writeClass(set, nil)
+			}
+			return
+		}
+
+	case c == 'k' && l.lowerGroups && l.hasPrefix("k<"):
+		// "\k<name>" => "\1"
+		end := strings.IndexByte(l.pattern[l.i:], '>')
+		if end < 0 {
+			l.failed = true
+			return
+		}
+		index, ok := l.groups[l.pattern[l.i+2:l.i+end]]
+		if !ok {
+			l.failed = true
+			return
+		}
+		l.i += end + 1
+		text := "\\" + strconv.Itoa(index)
+		if l.i < len(l.pattern) && l.pattern[l.i] >= '0' && l.pattern[l.i] <= '9' {
+			text = "(?:" + text + ")"
+		}
+		l.sb.WriteString(text)
+		return
+
+	case l.isUnicode && l.lowerUnicode:
+		switch c {
+		case 'D', 'S', 'W':
+			if set, ok := l.parseClassEscape(); ok {
+				if l.ignoreCase && l.lowerUnicode {
+					set = set.caseClosure()
+				}
+				l.writeClass(set, nil)
+			}
+			return
+
+		case 'w':
+			// "\w" also matches "ſ" and "K" when case is ignored
+			if l.ignoreCase {
+				if set, ok := l.parseClassEscape(); ok {
+					l.writeClass(set, nil)
+				}
+				return
+			}
+
+		case 'b', 'B':
+			if l.ignoreCase {
+				l.rewriteWordBoundary(c == 'B')
+				return
+			}
+
+		case 'd', 's':
+
+		default:
+			if c < '1' || c > '9' {
+				if value, ok := l.parseCharacterEscape(false); ok {
+					l.writeLiteral(value, l.pattern[start:l.i])
+				}
+				return
+			}
+		}
+	}
+
+	// Otherwise, copy the escape sequence over unmodified
+	_, size := utf8.DecodeRuneInString(l.pattern[l.i:])
+	l.i += size
+	l.sb.WriteString(l.pattern[start:l.i])
+}
+
+// Word boundaries also treat "ſ" and "K" as word characters when case is
+// ignored. Checking the previous character requires lookbehind assertions:
+//
+//	"\b" => "(?:(?<=\w)(?!\w)|(?<!\w)(?=\w))"
+//	"\B" => "(?:(?<=\w)(?=\w)|(?<!\w)(?!\w))"
+func (l *regExpLowering) rewriteWordBoundary(isNot bool) {
+	if !l.canUseLookbehind {
+		l.failed = true
+		return
+	}
+	l.i++
+	word := regExpWordChars.caseClosure()
+	ahead := [2]string{"(?!", "(?="}
+	if isNot {
+		ahead[0], ahead[1] = ahead[1], ahead[0]
+	}
+	l.sb.WriteString("(?:(?<=")
+	l.writeClass(word, nil)
+	l.sb.WriteString(")" + ahead[0])
+	l.writeClass(word, nil)
+	l.sb.WriteString(")|(?<!")
+	l.writeClass(word, nil)
+	l.sb.WriteString(")" + ahead[1])
+	l.writeClass(word, nil)
+	l.sb.WriteString("))")
+}
+
+// This is called after the backslash and parses an escape sequence that
+// stands for a set of characters (e.g. "\d" or "\p{Letter}")
+func (l *regExpLowering) parseClassEscape() (regExpCharSet, bool) {
+	var set regExpCharSet
+	c := l.pattern[l.i]
+	l.i++
+
+	switch c {
+	case 'd', 'D':
+		set = regExpDigitChars
+	case 's', 'S':
+		set = regExpWhitespaceChars
+	case 'w', 'W':
+		set = regExpWordChars
+		if l.ignoreCase {
+			set = set.caseClosure()
+		}
+
+	case 'p', 'P':
+		if !l.hasPrefix("{") {
+			l.failed = true
+			return nil, false
+		}
+		end := strings.IndexByte(l.pattern[l.i:], '}')
+		if end < 0 {
+			l.failed = true
+			return nil, false
+		}
+		name := l.pattern[l.i+1 : l.i+end]
+		value := ""
+		if equals := strings.IndexByte(name, '='); equals >= 0 {
+			name, value = name[:equals], name[equals+1:]
+		}
+		l.i += end + 1
+		var ok bool
+		if set, ok = regExpUnicodeProperty(name, value); !ok {
+			l.failed = true
+			return nil, false
+		}
+		if c == 'P' && l.isSets && l.ignoreCase {
+			set = set.caseClosure()
+		}
+
+	default:
+		l.failed = true
+		return nil, false
+	}
+
+	if c >= 'A' && c <= 'Z' {
+		set = set.complement()
+	}
+	return set, true
+}
+
+// This is called after the backslash and parses an escape sequence that
+// stands for a single code point. It's only used with the "u" or "v" flags.
+func (l *regExpLowering) parseCharacterEscape(inClass bool) (rune, bool) {
+	c := l.pattern[l.i]
+	l.i++
+
+	switch c {
+	case 't':
+		return '\t', true
+	case 'n':
+		return '\n', true
+	case 'v':
+		return '\v', true
+	case 'f':
+		return '\f', true
+	case 'r':
+		return '\r', true
+
+	case 'b':
+		if inClass {
+			return '\b', true
+		}
+
+	case '-':
+		if inClass {
+			return '-', true
+		}
+
+	case 'c':
+		if l.i < len(l.pattern) {
+			if letter := l.pattern[l.i]; (letter >= 'a' && letter <= 'z') || (letter >= 'A' && letter <= 'Z') {
+				l.i++
+				return rune(letter % 32), true
+			}
+		}
+
+	case '0':
+		if l.i >= len(l.pattern) || l.pattern[l.i] < '0' || l.pattern[l.i] > '9' {
+			return 0, true
+		}
+
+	case 'x':
+		if value, ok := l.parseHex(2); ok {
+			return value, true
+		}
+
+	case 'u':
+		if l.hasPrefix("{") {
+			end := strings.IndexByte(l.pattern[l.i:], '}')
+			if end > 1 {
+				if value, err := strconv.ParseUint(l.pattern[l.i+1:l.i+end], 16, 32); err == nil && value <= regExpMaxCodePoint {
+					l.i += end + 1
+					return rune(value), true
+				}
+			}
+		} else if value, ok := l.parseHex(4); ok {
+			// Surrogate pairs written as two escapes form a single code point
+			if value >= 0xD800 && value <= 0xDBFF && l.hasPrefix("\\u") {
+				i := l.i
+				l.i += 2
+				if low, ok := l.parseHex(4); ok && low >= 0xDC00 && low <= 0xDFFF {
+					return (value-0xD800)<<10 + (low - 0xDC00) + 0x10000, true
+				}
+				l.i = i
+			}
+			return value, true
+		}
+
+	default:
+		if strings.IndexByte("^$\\.*+?()[]{}|/", c) >= 0 {
+			return rune(c), true
+		}
+		if inClass && l.isSets && strings.IndexByte("&!#%,:;<=>@`~", c) >= 0 {
+			return rune(c), true
+		}
+	}
+
+	l.failed = true
+	return 0, false
+}
+
+func (l *regExpLowering) parseHex(count int) (rune, bool) {
+	if l.i+count > len(l.pattern) {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(l.pattern[l.i:l.i+count], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	l.i += count
+	return rune(value), true
+}
+
+func (l *regExpLowering) rewriteClass() {
+	end := l.skipClass(l.i)
+	text := l.pattern[l.i:end]
+
+	// Only rewrite the character class if it's necessary
+	if !l.lowerUnicode && !l.lowerSets && (!l.lowerProps || (!strings.Contains(text, "\\p{") && !strings.Contains(text, "\\P{"))) {
+		l.sb.WriteString(text)
+		l.i = end
+		return
+	}
+
+	l.i++
+	isNegated := l.hasPrefix("^")
+	if isNegated {
+		l.i++
+	}
+
+	var set regExpCharSet
+	var strs map[string]bool
+	if l.isSets {
+		set, strs = l.parseClassSetExpression()
+	} else {
+		set = l.parseClassRanges()
+	}
+	if l.failed {
+		return
+	}
+
+	if isNegated {
+		if len(strs) > 0 {
+			l.failed = true
+			return
+		}
+
+		// Keep the class negated if the output can represent it directly
+		if !l.lowerUnicode {
+			l.sb.WriteString("[^")
+			writeRegExpClassRanges(&l.sb, set, true)
+			l.sb.WriteByte(']')
+			return
+		}
+
+		if l.ignoreCase {
+			set = set.caseClosure()
+		}
+		set = set.complement()
+	} else if l.ignoreCase && l.lowerUnicode {
+		set = set.caseClosure()
+	}
+	l.writeClass(set, strs)
+}
+
+// Parses the contents of a character class without the "v" flag
+func (l *regExpLowering) parseClassRanges() regExpCharSet {
+	var set regExpCharSet
+	for !l.failed {
+		if l.i >= len(l.pattern) {
+			l.failed = true
+			break
+		}
+		if l.pattern[l.i] == ']' {
+			l.i++
+			break
+		}
+		lo, atom, ok := l.parseClassAtom()
+		if !ok {
+			set = append(set, atom...)
+			continue
+		}
+		if l.hasPrefix("-") && !l.hasPrefix("-]") {
+			l.i++
+			hi, _, ok := l.parseClassAtom()
+			if !ok || hi < lo {
+				l.failed = true
+				break
+			}
+			set = append(set, regExpRange{lo: lo, hi: hi})
+			continue
+		}
+		set = append(set, regExpRange{lo: lo, hi: lo})
+	}
+	return set.normalize()
+}
+
+// Returns either a single code point or a set of code points
+func (l *regExpLowering) parseClassAtom() (rune, regExpCharSet, bool) {
+	if l.pattern[l.i] != '\\' {
+		c, size := utf8.DecodeRuneInString(l.pattern[l.i:])
+		l.i += size
+		return c, nil, true
+	}
+	l.i++
+	if l.i >= len(l.pattern) {
+		l.failed = true
+		return 0, nil, false
+	}
+	if strings.IndexByte("dDsSwWpP", l.pattern[l.i]) >= 0 {
+		set, _ := l.parseClassEscape()
+		return 0, set, false
+	}
+	c, ok := l.parseCharacterEscape(true)
+	return c, nil, ok
+}
+
+// Parses the contents of a character class with the "v" flag. These can
+// contain nested classes, set operations, and strings.
+func (l *regExpLowering) parseClassSetExpression() (regExpCharSet, map[string]bool) {
+	var set regExpCharSet
+	var strs map[string]bool
+
+	if !l.hasPrefix("]") {
+		set, strs = l.parseClassSetItem()
+
+		switch {
+		case l.hasPrefix("&&"):
+			for !l.failed && l.hasPrefix("&&") {
+				l.i += 2
+				other, otherStrs := l.parseClassSetItem()
+				set = set.intersect(other)
+				for str := range strs {
+					if !otherStrs[str] {
+						delete(strs, str)
+					}
+				}
+			}
+
+		case l.hasPrefix("--"):
+			for !l.failed && l.hasPrefix("--") {
+				l.i += 2
+				other, otherStrs := l.parseClassSetItem()
+				set = set.subtract(other)
+				for str := range otherStrs {
+					delete(strs, str)
+				}
+			}
+
+		default:
+			for !l.failed && l.i < len(l.pattern) && l.pattern[l.i] != ']' {
+				other, otherStrs := l.parseClassSetItem()
+				set = set.union(other)
+				for str := range otherStrs {
+					if strs == nil {
+						strs = make(map[string]bool)
+					}
+					strs[str] = true
+				}
+			}
+		}
+	}
+
+	if l.i >= len(l.pattern) || l.pattern[l.i] != ']' {
+		l.failed = true
+	} else {
+		l.i++
+	}
+	return set, strs
+}
+
+// Parses a single operand or a range of code points with the "v" flag
+func (l *regExpLowering) parseClassSetItem() (regExpCharSet, map[string]bool) {
+	set, strs, isSingle := l.parseClassSetOperand()
+	if isSingle && l.hasPrefix("-") && !l.hasPrefix("--") {
+		l.i++
+		hi, _, isSingle := l.parseClassSetOperand()
+		if !isSingle || hi[0].lo < set[0].lo {
+			l.failed = true
+			return nil, nil
+		}
+		set = regExpCharSet{{lo: set[0].lo, hi: hi[0].lo}}
+	}
+	if l.ignoreCase {
+		set = set.caseClosure()
+	}
+	return set, strs
+}
+
+func (l *regExpLowering) parseClassSetOperand() (set regExpCharSet, strs map[string]bool, isSingle bool) {
+	if l.failed || l.i >= len(l.pattern) {
+		l.failed = true
+		return
+	}
+
+	switch {
+	case l.hasPrefix("["):
+		l.i++
+		isNegated := l.hasPrefix("^")
+		if isNegated {
+			l.i++
+		}
+		set, strs = l.parseClassSetExpression()
+		if isNegated {
+			if len(strs) > 0 {
+				l.failed = true
+				return
+			}
+			if l.ignoreCase {
+				set = set.caseClosure()
+			}
+			set = set.complement()
+		}
+		return
+
+	case l.hasPrefix("\\q{"):
+		// "\q{abc|d}" is a set of strings
+		l.i += 3
+		for !l.failed {
+			var sb strings.Builder
+			for l.i < len(l.pattern) && l.pattern[l.i] != '|' && l.pattern[l.i] != '}' {
+				var c rune
+				if l.pattern[l.i] == '\\' {
+					l.i++
+					if l.i >= len(l.pattern) {
+						l.failed = true
+						return
+					}
+					c, _ = l.parseCharacterEscape(true)
+				} else {
+					var size int
+					c, size = utf8.DecodeRuneInString(l.pattern[l.i:])
+					l.i += size
+				}
+				sb.WriteRune(c)
+			}
+			if l.i >= len(l.pattern) {
+				l.failed = true
+				return
+			}
+			if str := sb.String(); utf8.RuneCountInString(str) == 1 {
+				c, _ := utf8.DecodeRuneInString(str)
+				set = append(set, regExpRange{lo: c, hi: c})
+			} else {
+				if strs == nil {
+					strs = make(map[string]bool)
+				}
+				strs[str] = true
+			}
+			l.i++
+			if l.pattern[l.i-1] == '}' {
+				break
+			}
+		}
+		set = set.normalize()
+		return
+
+	default:
+		c, atom, ok := l.parseClassAtom()
+		if !ok {
+			return atom, nil, false
+		}
+		return regExpCharSet{{lo: c, hi: c}}, nil, true
+	}
+}
+
+func (l *regExpLowering) writeLiteral(c rune, raw string) {
+	if l.lowerUnicode {
+		if l.ignoreCase {
+			// The "u" flag changes which characters are considered equal when case
+			// is ignored, so use a character class if the result would be different
+			canonical := regExpNonUnicodeCanonicalize(c)
+			for f := unicode.SimpleFold(c); f != c; f = unicode.SimpleFold(f) {
+				if f > 0xFFFF || regExpNonUnicodeCanonicalize(f) != canonical {
+					l.writeClass(regExpCharSet{{lo: c, hi: c}}.caseClosure(), nil)
+					return
+				}
+			}
+		}
+
+		// Code points outside of the BMP become two code units without the "u"
+		// flag, so wrap them in a group in case they are followed by a quantifier
+		if c > 0xFFFF {
+			l.sb.WriteString("(?:")
+			writeRegExpChar(&l.sb, c, false, false)
+			l.sb.WriteByte(')')
+			return
+		}
+
+		// Escapes such as "\u{41}" are only valid with the "u" flag
+		if strings.HasPrefix(raw, "\\u{") {
+			writeRegExpChar(&l.sb, c, false, false)
+			return
+		}
+	}
+
+	l.sb.WriteString(raw)
+}
+
+// Writes an expression that matches any of the code points in the set or any
+// of the strings. Strings are matched first, from longest to shortest.
+func (l *regExpLowering) writeClass(set regExpCharSet, strs map[string]bool) {
+	var alts []string
+	var sorted []string
+	hasEmptyString := false
+	for str := range strs {
+		if str == "" {
+			hasEmptyString = true
+		} else {
+			sorted = append(sorted, str)
+		}
+	}
+	sort.Slice(sorted, func(i int, j int) bool {
+		a, b := utf8.RuneCountInString(sorted[i]), utf8.RuneCountInString(sorted[j])
+		return a > b || (a == b && sorted[i] < sorted[j])
+	})
+	for _, str := range sorted {
+		sb := strings.Builder{}
+		for _, c := range str {
+			writeRegExpChar(&sb, c, false, !l.lowerUnicode)
+		}
+		alts = append(alts, sb.String())
+	}
+
+	isSingleAtom := false
+	if len(set) > 0 || len(alts) == 0 {
+		var classAlts []string
+		classAlts, isSingleAtom = l.classAlternatives(set)
+		alts = append(alts, classAlts...)
+	}
+	if hasEmptyString {
+		alts = append(alts, "")
+	}
+
+	if len(alts) == 1 && isSingleAtom {
+		l.sb.WriteString(alts[0])
+	} else {
+		l.sb.WriteString("(?:")
+		l.sb.WriteString(strings.Join(alts, "|"))
+		l.sb.WriteByte(')')
+	}
+}
+
+// Returns the alternatives needed to match the set of code points. This is a
+// single character class unless the "u" flag is being removed, in which case
+// code points outside of the BMP are matched as surrogate pairs instead.
+func (l *regExpLowering) classAlternatives(set regExpCharSet) (alts []string, isSingleAtom bool) {
+	if !l.lowerUnicode {
+		return []string{regExpClass(set, regExpCharSet{{lo: 0, hi: regExpMaxCodePoint}}, true)}, true
+	}
+
+	bmp := set.intersect(regExpCharSet{{lo: 0, hi: 0xD7FF}, {lo: 0xDC00, hi: 0xFFFF}})
+	highs := set.intersect(regExpCharSet{{lo: 0xD800, hi: 0xDBFF}})
+	astral := set.intersect(regExpCharSet{{lo: 0x10000, hi: regExpMaxCodePoint}})
+
+	// Group the code points outside of the BMP by their high surrogate
+	type surrogateGroup struct {
+		highs regExpRange
+		lows  regExpCharSet
+	}
+	var groups []surrogateGroup
+	for _, r := range astral {
+		for c := r.lo; c <= r.hi; {
+			high := 0xD800 + (c-0x10000)>>10
+			end := 0x10000 + (high-0xD800+1)<<10 - 1
+			if end > r.hi {
+				end = r.hi
+			}
+			low := regExpRange{lo: 0xDC00 + (c-0x10000)&0x3FF, hi: 0xDC00 + (end-0x10000)&0x3FF}
+			if n := len(groups); n > 0 && groups[n-1].highs.lo == high {
+				groups[n-1].lows = append(groups[n-1].lows, low)
+			} else {
+				groups = append(groups, surrogateGroup{highs: regExpRange{lo: high, hi: high}, lows: regExpCharSet{low}})
+			}
+			c = end + 1
+		}
+	}
+
+	// Merge adjacent high surrogates that are followed by the same low surrogates
+	var merged []surrogateGroup
+	for _, group := range groups {
+		if n := len(merged); n > 0 && merged[n-1].highs.hi+1 == group.highs.lo && regExpCharSetsEqual(merged[n-1].lows, group.lows) {
+			merged[n-1].highs.hi = group.highs.hi
+		} else {
+			merged = append(merged, group)
+		}
+	}
+	for _, group := range merged {
+		alts = append(alts, regExpClass(regExpCharSet{group.highs}, nil, false)+regExpClass(group.lows, nil, false))
+	}
+
+	// A lone high surrogate must not match the first half of a surrogate pair.
+	// Lone low surrogates aren't handled because that would need a lookbehind
+	// assertion, so they can still match the second half of a surrogate pair.
+	if len(highs) > 0 {
+		alts = append(alts, regExpClass(highs, nil, false)+"(?![\\uDC00-\\uDFFF])")
+	}
+
+	if len(bmp) > 0 || len(alts) == 0 {
+		alts = append(alts, regExpClass(bmp, regExpCharSet{{lo: 0, hi: 0xFFFF}}, false))
+		isSingleAtom = len(alts) == 1
+	}
+	return
+}
+
+func regExpCharSetsEqual(a regExpCharSet, b regExpCharSet) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a character class for the set. If a universe is provided, the class
+// will be negated when that's shorter. Single code points aren't put in a class.
+func regExpClass(set regExpCharSet, universe regExpCharSet, isUnicode bool) string {
+	sb := strings.Builder{}
+	if len(set) == 1 && set[0].lo == set[0].hi {
+		writeRegExpChar(&sb, set[0].lo, false, isUnicode)
+		return sb.String()
+	}
+	if universe != nil {
+		if negated := universe.subtract(set); len(negated) == 0 {
+			return "[\\s\\S]"
+		} else if len(negated) < len(set) {
+			sb.WriteString("[^")
+			writeRegExpClassRanges(&sb, negated, isUnicode)
+			sb.WriteByte(']')
+			return sb.String()
+		}
+	}
+	sb.WriteByte('[')
+	writeRegExpClassRanges(&sb, set, isUnicode)
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+func writeRegExpClassRanges(sb *strings.Builder, set regExpCharSet, isUnicode bool) {
+	for _, r := range set {
+		writeRegExpChar(sb, r.lo, true, isUnicode)
+		if r.hi > r.lo {
+			if r.hi > r.lo+1 {
+				sb.WriteByte('-')
+			}
+			writeRegExpChar(sb, r.hi, true, isUnicode)
+		}
+	}
+}
+
+func writeRegExpChar(sb *strings.Builder, c rune, inClass bool, isUnicode bool) {
+	switch {
+	case c == '\t':
+		sb.WriteString("\\t")
+	case c == '\n':
+		sb.WriteString("\\n")
+	case c == '\v':
+		sb.WriteString("\\v")
+	case c == '\f':
+		sb.WriteString("\\f")
+	case c == '\r':
+		sb.WriteString("\\r")
+
+	case c < 0x20 || (c >= 0x7F && c <= 0xFF):
+		sb.WriteString("\\x")
+		writeRegExpHex(sb, int(c), 2)
+
+	case c > 0xFFFF && isUnicode:
+		sb.WriteString("\\u{")
+		sb.WriteString(strings.ToUpper(strconv.FormatInt(int64(c), 16)))
+		sb.WriteByte('}')
+
+	case c > 0xFFFF:
+		c -= 0x10000
+		sb.WriteString("\\u")
+		writeRegExpHex(sb, int(0xD800+(c>>10)), 4)
+		sb.WriteString("\\u")
+		writeRegExpHex(sb, int(0xDC00+(c&0x3FF)), 4)
+
+	case c > 0x7F:
+		sb.WriteString("\\u")
+		writeRegExpHex(sb, int(c), 4)
+
+	default:
+		special := "^$\\.*+?()[]{}|/"
+		if inClass {
+			special = "\\][^-/"
+		}
+		if strings.IndexByte(special, byte(c)) >= 0 {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(byte(c))
+	}
+}
+
+func writeRegExpHex(sb *strings.Builder, value int, digits int) {
+	const hex = "0123456789ABCDEF"
+	for i := digits - 1; i >= 0; i-- {
+		sb.WriteByte(hex[(value>>(i*4))&0xF])
+	}
+}
+
+// Returns the code points for a Unicode property escape such as "\p{Letter}",
+// "\p{Script=Greek}", or "\p{White_Space}". The data comes from the Unicode
+// tables in Go's standard library. Properties without data there (e.g. emoji
+// properties and "Script_Extensions") aren't supported.
+func regExpUnicodeProperty(name string, value string) (regExpCharSet, bool) {
+	if value == "" {
+		if short, ok := regExpGeneralCategoryAliases[name]; ok {
+			name = short
+		}
+		if table, ok := unicode.Categories[name]; ok {
+			return regExpCharSetFromTable(table), true
+		}
+		return regExpBinaryProperty(name)
+	}
+
+	switch name {
+	case "General_Category", "gc":
+		if short, ok := regExpGeneralCategoryAliases[value]; ok {
+			value = short
+		}
+		if table, ok := unicode.Categories[value]; ok {
+			return regExpCharSetFromTable(table), true
+		}
+
+	case "Script", "sc":
+		if long, ok := regExpScriptAliases[value]; ok {
+			value = long
+		}
+		if table, ok := unicode.Scripts[value]; ok {
+			return regExpCharSetFromTable(table), true
+		}
+	}
+
+	return nil, false
+}
+
+func regExpBinaryProperty(name string) (regExpCharSet, bool) {
+	name, ok := regExpBinaryPropertyNames[name]
+	if !ok {
+		return nil, false
+	}
+
+	// Some properties are derived from other properties
+	switch name {
+	case "Any":
+		return regExpCharSet{{lo: 0, hi: regExpMaxCodePoint}}, true
+
+	case "ASCII":
+		return regExpCharSet{{lo: 0, hi: 0x7F}}, true
+
+	case "Assigned":
+		if table, ok := unicode.Categories["Cn"]; ok {
+			return regExpCharSetFromTable(table).complement(), true
+		}
+		return nil, false
+
+	case "Alphabetic":
+		return regExpCharSetFromTables(unicode.Lu, unicode.Ll, unicode.Lt, unicode.Lm, unicode.Lo, unicode.Nl, unicode.Other_Alphabetic), true
+
+	case "Cased":
+		return regExpCharSetFromTables(unicode.Lu, unicode.Ll, unicode.Lt, unicode.Other_Lowercase, unicode.Other_Uppercase), true
+
+	case "Lowercase":
+		return regExpCharSetFromTables(unicode.Ll, unicode.Other_Lowercase), true
+
+	case "Uppercase":
+		return regExpCharSetFromTables(unicode.Lu, unicode.Other_Uppercase), true
+
+	case "Math":
+		return regExpCharSetFromTables(unicode.Sm, unicode.Other_Math), true
+
+	case "Grapheme_Extend":
+		return regExpCharSetFromTables(unicode.Me, unicode.Mn, unicode.Other_Grapheme_Extend), true
+
+	case "ID_Start":
+		return regExpCharSetFromTables(unicode.L, unicode.Nl, unicode.Other_ID_Start).
+			subtract(regExpCharSetFromTables(unicode.Pattern_Syntax, unicode.Pattern_White_Space)), true
+
+	case "ID_Continue":
+		return regExpCharSetFromTables(unicode.L, unicode.Nl, unicode.Other_ID_Start, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue).
+			subtract(regExpCharSetFromTables(unicode.Pattern_Syntax, unicode.Pattern_White_Space)), true
+	}
+
+	if table, ok := unicode.Properties[name]; ok {
+		return regExpCharSetFromTable(table), true
+	}
+	return nil, false
+}
+
+var regExpGeneralCategoryAliases = map[string]string{
+	"Cased_Letter":          "LC",
+	"Close_Punctuation":     "Pe",
+	"Connector_Punctuation": "Pc",
+	"Control":               "Cc",
+	"Currency_Symbol":       "Sc",
+	"Dash_Punctuation":      "Pd",
+	"Decimal_Number":        "Nd",
+	"Enclosing_Mark":        "Me",
+	"Final_Punctuation":     "Pf",
+	"Format":                "Cf",
+	"Initial_Punctuation":   "Pi",
+	"Letter":                "L",
+	"Letter_Number":         "Nl",
+	"Line_Separator":        "Zl",
+	"Lowercase_Letter":      "Ll",
+	"Mark":                  "M",
+	"Math_Symbol":           "Sm",
+	"Modifier_Letter":       "Lm",
+	"Modifier_Symbol":       "Sk",
+	"Nonspacing_Mark":       "Mn",
+	"Number":                "N",
+	"Open_Punctuation":      "Ps",
+	"Other":                 "C",
+	"Other_Letter":          "Lo",
+	"Other_Number":          "No",
+	"Other_Punctuation":     "Po",
+	"Other_Symbol":          "So",
+	"Paragraph_Separator":   "Zp",
+	"Private_Use":           "Co",
+	"Punctuation":           "P",
+	"Separator":             "Z",
+	"Space_Separator":       "Zs",
+	"Spacing_Mark":          "Mc",
+	"Surrogate":             "Cs",
+	"Symbol":                "S",
+	"Titlecase_Letter":      "Lt",
+	"Unassigned":            "Cn",
+	"Uppercase_Letter":      "Lu",
+	"cntrl":                 "Cc",
+	"Combining_Mark":        "M",
+	"digit":                 "Nd",
+	"punct":                 "P",
+}
+
+// These are the binary properties that JavaScript allows in property escapes
+// for which there is data. Both long and short names map to the long name.
+var regExpBinaryPropertyNames = map[string]string{
+	"ASCII":                   "ASCII",
+	"ASCII_Hex_Digit":         "ASCII_Hex_Digit",
+	"Alphabetic":              "Alphabetic",
+	"Any":                     "Any",
+	"Assigned":                "Assigned",
+	"Bidi_Control":            "Bidi_Control",
+	"Cased":                   "Cased",
+	"Dash":                    "Dash",
+	"Deprecated":              "Deprecated",
+	"Diacritic":               "Diacritic",
+	"Extender":                "Extender",
+	"Grapheme_Extend":         "Grapheme_Extend",
+	"Hex_Digit":               "Hex_Digit",
+	"IDS_Binary_Operator":     "IDS_Binary_Operator",
+	"IDS_Trinary_Operator":    "IDS_Trinary_Operator",
+	"ID_Continue":             "ID_Continue",
+	"ID_Start":                "ID_Start",
+	"Ideographic":             "Ideographic",
+	"Join_Control":            "Join_Control",
+	"Logical_Order_Exception": "Logical_Order_Exception",
+	"Lowercase":               "Lowercase",
+	"Math":                    "Math",
+	"Noncharacter_Code_Point": "Noncharacter_Code_Point",
+	"Pattern_Syntax":          "Pattern_Syntax",
+	"Pattern_White_Space":     "Pattern_White_Space",
+	"Quotation_Mark":          "Quotation_Mark",
+	"Radical":                 "Radical",
+	"Regional_Indicator":      "Regional_Indicator",
+	"Sentence_Terminal":       "Sentence_Terminal",
+	"Soft_Dotted":             "Soft_Dotted",
+	"Terminal_Punctuation":    "Terminal_Punctuation",
+	"Unified_Ideograph":       "Unified_Ideograph",
+	"Uppercase":               "Uppercase",
+	"Variation_Selector":      "Variation_Selector",
+	"White_Space":             "White_Space",
+	"AHex":                    "ASCII_Hex_Digit",
+	"Alpha":                   "Alphabetic",
+	"Bidi_C":                  "Bidi_Control",
+	"Dep":                     "Deprecated",
+	"Dia":                     "Diacritic",
+	"Ext":                     "Extender",
+	"Gr_Ext":                  "Grapheme_Extend",
+	"Hex":                     "Hex_Digit",
+	"IDC":                     "ID_Continue",
+	"IDS":                     "ID_Start",
+	"IDSB":                    "IDS_Binary_Operator",
+	"IDST":                    "IDS_Trinary_Operator",
+	"Ideo":                    "Ideographic",
+	"Join_C":                  "Join_Control",
+	"LOE":                     "Logical_Order_Exception",
+	"Lower":                   "Lowercase",
+	"NChar":                   "Noncharacter_Code_Point",
+	"Pat_Syn":                 "Pattern_Syntax",
+	"Pat_WS":                  "Pattern_White_Space",
+	"QMark":                   "Quotation_Mark",
+	"RI":                      "Regional_Indicator",
+	"SD":                      "Soft_Dotted",
+	"STerm":                   "Sentence_Terminal",
+	"Term":                    "Terminal_Punctuation",
+	"UIdeo":                   "Unified_Ideograph",
+	"Upper":                   "Uppercase",
+	"VS":                      "Variation_Selector",
+	"space":                   "White_Space",
+}
+
+// This maps the four-letter ISO 15924 codes to the long script names used by
+// Go's "unicode.Scripts" table
+var regExpScriptAliases = map[string]string{
+	"Adlm": "Adlam",
+	"Aghb": "Caucasian_Albanian",
+	"Arab": "Arabic",
+	"Armi": "Imperial_Aramaic",
+	"Armn": "Armenian",
+	"Avst": "Avestan",
+	"Bali": "Balinese",
+	"Bamu": "Bamum",
+	"Bass": "Bassa_Vah",
+	"Batk": "Batak",
+	"Beng": "Bengali",
+	"Berf": "Beria_Erfe",
+	"Bhks": "Bhaiksuki",
+	"Bopo": "Bopomofo",
+	"Brah": "Brahmi",
+	"Brai": "Braille",
+	"Bugi": "Buginese",
+	"Buhd": "Buhid",
+	"Cakm": "Chakma",
+	"Cans": "Canadian_Aboriginal",
+	"Cari": "Carian",
+	"Cher": "Cherokee",
+	"Chrs": "Chorasmian",
+	"Copt": "Coptic",
+	"Cpmn": "Cypro_Minoan",
+	"Cprt": "Cypriot",
+	"Cyrl": "Cyrillic",
+	"Deva": "Devanagari",
+	"Diak": "Dives_Akuru",
+	"Dogr": "Dogra",
+	"Dsrt": "Deseret",
+	"Dupl": "Duployan",
+	"Egyp": "Egyptian_Hieroglyphs",
+	"Elba": "Elbasan",
+	"Elym": "Elymaic",
+	"Ethi": "Ethiopic",
+	"Gara": "Garay",
+	"Geor": "Georgian",
+	"Glag": "Glagolitic",
+	"Gong": "Gunjala_Gondi",
+	"Gonm": "Masaram_Gondi",
+	"Goth": "Gothic",
+	"Gran": "Grantha",
+	"Grek": "Greek",
+	"Gujr": "Gujarati",
+	"Gukh": "Gurung_Khema",
+	"Guru": "Gurmukhi",
+	"Hang": "Hangul",
+	"Hani": "Han",
+	"Hano": "Hanunoo",
+	"Hatr": "Hatran",
+	"Hebr": "Hebrew",
+	"Hira": "Hiragana",
+	"Hluw": "Anatolian_Hieroglyphs",
+	"Hmng": "Pahawh_Hmong",
+	"Hmnp": "Nyiakeng_Puachue_Hmong",
+	"Hung": "Old_Hungarian",
+	"Ital": "Old_Italic",
+	"Java": "Javanese",
+	"Kali": "Kayah_Li",
+	"Kana": "Katakana",
+	"Khar": "Kharoshthi",
+	"Khmr": "Khmer",
+	"Khoj": "Khojki",
+	"Kits": "Khitan_Small_Script",
+	"Knda": "Kannada",
+	"Krai": "Kirat_Rai",
+	"Kthi": "Kaithi",
+	"Lana": "Tai_Tham",
+	"Laoo": "Lao",
+	"Latn": "Latin",
+	"Lepc": "Lepcha",
+	"Limb": "Limbu",
+	"Lina": "Linear_A",
+	"Linb": "Linear_B",
+	"Lyci": "Lycian",
+	"Lydi": "Lydian",
+	"Mahj": "Mahajani",
+	"Maka": "Makasar",
+	"Mand": "Mandaic",
+	"Mani": "Manichaean",
+	"Marc": "Marchen",
+	"Medf": "Medefaidrin",
+	"Mend": "Mende_Kikakui",
+	"Merc": "Meroitic_Cursive",
+	"Mero": "Meroitic_Hieroglyphs",
+	"Mlym": "Malayalam",
+	"Mong": "Mongolian",
+	"Mroo": "Mro",
+	"Mtei": "Meetei_Mayek",
+	"Mult": "Multani",
+	"Mymr": "Myanmar",
+	"Nagm": "Nag_Mundari",
+	"Nand": "Nandinagari",
+	"Narb": "Old_North_Arabian",
+	"Nbat": "Nabataean",
+	"Nkoo": "Nko",
+	"Nshu": "Nushu",
+	"Ogam": "Ogham",
+	"Olck": "Ol_Chiki",
+	"Onao": "Ol_Onal",
+	"Orkh": "Old_Turkic",
+	"Orya": "Oriya",
+	"Osge": "Osage",
+	"Osma": "Osmanya",
+	"Ougr": "Old_Uyghur",
+	"Palm": "Palmyrene",
+	"Pauc": "Pau_Cin_Hau",
+	"Perm": "Old_Permic",
+	"Phag": "Phags_Pa",
+	"Phli": "Inscriptional_Pahlavi",
+	"Phlp": "Psalter_Pahlavi",
+	"Phnx": "Phoenician",
+	"Plrd": "Miao",
+	"Prti": "Inscriptional_Parthian",
+	"Qaac": "Coptic",
+	"Qaai": "Inherited",
+	"Rjng": "Rejang",
+	"Rohg": "Hanifi_Rohingya",
+	"Runr": "Runic",
+	"Samr": "Samaritan",
+	"Sarb": "Old_South_Arabian",
+	"Saur": "Saurashtra",
+	"Sgnw": "SignWriting",
+	"Shaw": "Shavian",
+	"Shrd": "Sharada",
+	"Sidd": "Siddham",
+	"Sidt": "Sidetic",
+	"Sind": "Khudawadi",
+	"Sinh": "Sinhala",
+	"Sogd": "Sogdian",
+	"Sogo": "Old_Sogdian",
+	"Sora": "Sora_Sompeng",
+	"Soyo": "Soyombo",
+	"Sund": "Sundanese",
+	"Sunu": "Sunuwar",
+	"Sylo": "Syloti_Nagri",
+	"Syrc": "Syriac",
+	"Tagb": "Tagbanwa",
+	"Takr": "Takri",
+	"Tale": "Tai_Le",
+	"Talu": "New_Tai_Lue",
+	"Taml": "Tamil",
+	"Tang": "Tangut",
+	"Tavt": "Tai_Viet",
+	"Tayo": "Tai_Yo",
+	"Telu": "Telugu",
+	"Tfng": "Tifinagh",
+	"Tglg": "Tagalog",
+	"Thaa": "Thaana",
+	"Tibt": "Tibetan",
+	"Tirh": "Tirhuta",
+	"Tnsa": "Tangsa",
+	"Todr": "Todhri",
+	"Tols": "Tolong_Siki",
+	"Tutg": "Tulu_Tigalari",
+	"Ugar": "Ugaritic",
+	"Vaii": "Vai",
+	"Vith": "Vithkuqi",
+	"Wara": "Warang_Citi",
+	"Wcho": "Wancho",
+	"Xpeo": "Old_Persian",
+	"Xsux": "Cuneiform",
+	"Yezi": "Yezidi",
+	"Yiii": "Yi",
+	"Zanb": "Zanabazar_Square",
+	"Zinh": "Inherited",
+	"Zyyy": "Common",
+}
@@ -239,6 +239,245 @@ func TestLowerAsyncFunctions(t *testing.T) {
 `)
 }
 
+func TestLowerGenerators(t *testing.T) {
+	expectPrintedWithUnsupportedFeatures(t, compat.Generator, "function* foo() { let x = yield 1; return x }", `function foo() {
+  var x;
+  return __makeGenerator(this, function(_a) {
+    switch (_a.label) {
+      case 0:
+        return [4, 1];
+      case 1:
+        x = _a.sent();
+        return [2, x];
+    }
+  });
+}
+`)
+	expectPrintedWithUnsupportedFeatures(t, compat.Generator, "function* foo() { for (let i = 0; i < 10; i++) { if (i & 1) continue; if (i > 5) break; yield i } }", `function foo() {
+  var i;
+  return __makeGenerator(this, function(_a) {
+    switch (_a.label) {
+      case 0:
+        i = 0;
+        _a.label = 1;
+      case 1:
+        if (!(i < 10))
+          return [3, 4];
+        if (i & 1)
+          return [3, 3];
+        if (i > 5)
+          return [3, 4];
+        return [4, i];
+      case 2:
+        _a.sent();
+        _a.label = 3;
+      case 3:
+        i++;
+        return [3, 1];
+      case 4:
+        return [2];
+    }
+  });
+}
+`)
+	expectPrintedWithUnsupportedFeatures(t, compat.Generator, "function* foo() { try { yield a() } catch (e) { yield e } finally { b() } }", `function foo() {
+  var e;
+  return __makeGenerator(this, function(_a) {
+    switch (_a.label) {
+      case 0:
+        _a.trys.push([0, 2, 4, 5]);
+        return [4, a()];
+      case 1:
+        _a.sent();
+        return [3, 5];
+      case 2:
+        e = _a.sent();
+        return [4, e];
+      case 3:
+        _a.sent();
+        return [3, 5];
+      case 4:
+        b();
+        return [7];
+      case 5:
+        return [2];
+    }
+  });
+}
+`)
+
+	// Values that are evaluated before a "yield" must be stored in temporaries
+	expectPrintedWithUnsupportedFeatures(t, compat.Generator, "function* foo() { return a.b(yield c, yield d) }", `function foo() {
+  var _b, _c, _d;
+  return __makeGenerator(this, function(_a) {
+    switch (_a.label) {
+      case 0:
+        _b = a;
+        _c = _b.b;
+        return [4, c];
+      case 1:
+        _d = _a.sent();
+        return [4, d];
+      case 2:
+        return [2, _c.call(_b, _d, _a.sent())];
+    }
+  });
+}
+`)
+
+	// The state machine is a separate function so "arguments" must be captured
+	expectPrintedWithUnsupportedFeatures(t, compat.Generator, "function* foo() { yield arguments[0] }", `function foo() {
+  var _arguments = arguments;
+  return __makeGenerator(this, function(_a) {
+    switch (_a.label) {
+      case 0:
+        return [4, _arguments[0]];
+      case 1:
+        _a.sent();
+        return [2];
+    }
+  });
+}
+`)
+
+	// Statements without "yield" are left alone
+	expectPrintedWithUnsupportedFeatures(t, compat.Generator, "function* foo() { for (const x of y) { if (x) return x } }", `function foo() {
+  return __makeGenerator(this, function(_a) {
+    for (const x of y) {
+      if (x)
+        return [2, x];
+    }
+    return [2];
+  });
+}
+`)
+}
+
+func TestLowerLetAndConst(t *testing.T) {
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "let x = 1; { let x = 2; x++ } x++", "var x = 1;\n{\n  var x = 2;\n  x++;\n}\nx++;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "for (let i = 0; i < 3; i++) x(i)", "for (var i = 0; i < 3; i++)\n  x(i);\n")
+
+	// Closures that capture a per-iteration binding need the body in a function
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "for (let i = 0; i < 3; i++) fns.push(() => i)", `var _loop = function(i) {
+  fns.push(() => i);
+};
+for (var i = 0; i < 3; i++) {
+  _loop(i);
+}
+`)
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "for (let i = 0; i < 3; i++) { fns.push(() => i); i++ }", `var _i, _loop = function(i) {
+  fns.push(() => i);
+  i++;
+  _i = i;
+};
+for (var i = 0; i < 3; i++) {
+  _loop(i);
+  i = _i;
+}
+`)
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "while (a) { let x; fns.push(() => x); x ||= 1 }", `var _loop = function() {
+  var x = void 0;
+  fns.push(() => x);
+  x ||= 1;
+};
+while (a) {
+  _loop();
+}
+`)
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "function f() { for (const x of y) fns.push(() => this[x] + arguments[0]) }", `function f() {
+  var _arguments = arguments;
+  var _loop = function(x) {
+    fns.push(() => this[x] + _arguments[0]);
+  };
+  for (var x of y) {
+    _loop.call(this, x);
+  }
+}
+`)
+
+	// Jumps out of the loop body are forwarded through the return value
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "for (const x of y) { fns.push(() => x); if (x) break; if (z) continue; if (w) return x }", `var _loop = function(x) {
+  fns.push(() => x);
+  if (x)
+    return "break";
+  if (z)
+    return;
+  if (w)
+    return {
+      v: x
+    };
+};
+for (var x of y) {
+  var _ret = _loop(x);
+  if (_ret === "break")
+    break;
+  if (typeof _ret === "object")
+    return _ret.v;
+}
+`)
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "outer: for (const x of y) { for (const z of x) { fns.push(() => z); if (z) continue outer } }", `outer:
+  for (var x of y) {
+    var _loop = function(z) {
+      fns.push(() => z);
+      if (z)
+        return "continue|outer";
+    };
+    for (var z of x) {
+      var _ret = _loop(z);
+      if (_ret === "continue|outer")
+        continue outer;
+    }
+  }
+`)
+
+	// Temporal dead zone and constant assignment checks
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "x; let x = 1", "__earlyAccess(\"x\");\nvar x = 1;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "x = 2; let x = 1", "2, __earlyAccess(\"x\");\nvar x = 1;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "const x = 1; x = 2; x += 3; x++",
+		"var x = 1;\n2, __constAssign(\"x\");\n3, __constAssign(\"x\");\n__constAssign(\"x\");\n")
+
+	// Loop bodies containing "yield" or "await" are moved into a generator or async function
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "function* f() { for (const x of y) { fns.push(() => x); yield x } }",
+		"function* f() {\n  var _loop = function* (x) {\n    fns.push(() => x);\n    yield x;\n  };\n  for (var x of y) {\n    yield* _loop(x);\n  }\n}\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet, "async function f() { for (const x of y) { fns.push(() => x); if (await x) return x } }",
+		`async function f() {
+  var _loop = async function(x) {
+    fns.push(() => x);
+    if (await x)
+      return {
+        v: x
+      };
+  };
+  for (var x of y) {
+    var _ret = await _loop(x);
+    if (typeof _ret === "object")
+      return _ret.v;
+  }
+}
+`)
+	expectPrintedWithUnsupportedFeatures(t, compat.ConstAndLet|compat.AsyncAwait, "async function f() { for (const x of y) { fns.push(() => x); await x } }",
+		`function f() {
+  return __async(this, null, function* () {
+    var _loop = function* (x) {
+      fns.push(() => x);
+      yield x;
+    };
+    for (var x of y) {
+      yield* _loop(x);
+    }
+  });
+}
+`)
+
+	// Loop bodies containing "super", "new.target", or top-level "await" can't be moved into a separate function
+	expectParseErrorWithUnsupportedFeatures(t, compat.ConstAndLet, "class A extends B { f() { for (const x of y) { fns.push(() => x); super.f() } } }",
+		"<stdin>: ERROR: Transforming a loop with closures that capture \"x\" to the configured target environment is not supported yet\n"+
+			"NOTE: Each loop iteration normally has a separate copy of \"x\". Emulating this requires moving the loop body into a separate function, which isn't possible here because it contains \"super\".\n")
+	expectParseErrorWithUnsupportedFeatures(t, compat.ConstAndLet, "for (const x of y) { fns.push(() => x); await x }",
+		"<stdin>: ERROR: Transforming a loop with closures that capture \"x\" to the configured target environment is not supported yet\n"+
+			"NOTE: Each loop iteration normally has a separate copy of \"x\". Emulating this requires moving the loop body into a separate function, which isn't possible here because it contains \"await\".\n")
+}
+
 func TestLowerClassSideEffectOrder(t *testing.T) {
 	// The order of computed property side effects must not change
 	expectPrintedTarget(t, 2015, `class Foo {
@@ -541,6 +780,112 @@ func TestLowerClassStaticBlocks(t *testing.T) {
 	expectPrintedMangleTarget(t, 2015, "class Foo { static { x } static {} static { y } }", "class Foo {\n}\nx, y;\n")
 }
 
+func TestLowerClassES5(t *testing.T) {
+	expectPrintedWithUnsupportedFeatures(t, compat.Class, "class Foo { foo() { return 1 } static bar() {} }",
+		"let Foo = /* @__PURE__ */ function() {\n  function Foo() {\n    __classCallCheck(this, Foo);\n  }\n  __defMethod(Foo.prototype, \"foo\", function() {\n    return 1;\n  });\n  __defMethod(Foo, \"bar\", function() {\n  });\n  return Foo;\n}();\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Class, "class Foo { get x() { return 1 } set x(v) {} }",
+		"let Foo = /* @__PURE__ */ function() {\n  function Foo() {\n    __classCallCheck(this, Foo);\n  }\n  __defMethod(Foo.prototype, \"x\", function() {\n    return 1;\n  }, 1);\n  __defMethod(Foo.prototype, \"x\", function(v) {\n  }, 2);\n  return Foo;\n}();\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Class, "class Foo { [a]() {} [b] = 1 }",
+		"var _a;\nlet Foo = function(_b) {\n  function Foo() {\n    __classCallCheck(this, Foo);\n    __publicField(this, _a, 1);\n  }\n  __defMethod(Foo.prototype, _b, function() {\n  });\n  return Foo;\n}(a);\n_a = b;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Class, "class Foo { constructor(x) { this.x = x } }",
+		"let Foo = /* @__PURE__ */ function() {\n  function Foo(x) {\n    __classCallCheck(this, Foo);\n    this.x = x;\n  }\n  return Foo;\n}();\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Class, "class Foo extends Bar {}",
+		"let Foo = function(_super) {\n  __inherits(Foo, _super);\n  function Foo() {\n    __classCallCheck(this, Foo);\n    return __callSuper(this, Foo, arguments);\n  }\n  return Foo;\n}(Bar);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Class, "class Foo extends Bar { constructor() { super(1, 2); this.x = 3 } }",
+		"let Foo = function(_super) {\n  __inherits(Foo, _super);\n  function Foo() {\n    __classCallCheck(this, Foo);\n    var _this = this;\n    _this = __callSuper(_this, Foo, [1, 2]);\n    _this.x = 3;\n    return _this;\n  }\n  return Foo;\n}(Bar);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Class, "class Foo extends Bar { constructor() { super(...args) } }",
+		"let Foo = function(_super) {\n  __inherits(Foo, _super);\n  function Foo() {\n    __classCallCheck(this, Foo);\n    var _this = this;\n    _this = __callSuper(_this, Foo, [...args]);\n    return _this;\n  }\n  return Foo;\n}(Bar);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Class, "class Foo extends Bar { x = 1; y = () => this }",
+		"let Foo = function(_super) {\n  __inherits(Foo, _super);\n  function Foo() {\n    __classCallCheck(this, Foo);\n    var _this = this;\n    _this = __callSuper(_this, Foo, arguments);\n    __publicField(_this, \"x\", 1);\n    __publicField(_this, \"y\", () => _this);\n    return _this;\n  }\n  return Foo;\n}(Bar);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Class, "class Foo extends Bar { foo() { return super.foo() } static bar() { return super.bar } }",
+		"let Foo = function(_super) {\n  __inherits(Foo, _super);\n  function Foo() {\n    __classCallCheck(this, Foo);\n    return __callSuper(this, Foo, arguments);\n  }\n  __defMethod(Foo.prototype, \"foo\", function() {\n    return __superGet(Foo.prototype, this, \"foo\").call(this);\n  });\n  __defMethod(Foo, \"bar\", function() {\n    return __superGet(Foo, this, \"bar\");\n  });\n  return Foo;\n}(Bar);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Class, "x = class extends Bar {}",
+		"x = function(_super) {\n  __inherits(_class, _super);\n  function _class() {\n    __classCallCheck(this, _class);\n    return __callSuper(this, _class, arguments);\n  }\n  return _class;\n}(Bar);\n")
+}
+
+func TestLowerDestructuring(t *testing.T) {
+	expectPrintedTarget(t, 5, "var [a, b] = c;", "var _a = __toArray(c, 2), a = _a[0], b = _a[1];\n")
+	expectPrintedTarget(t, 5, "var [a, , b] = c;", "var _a = __toArray(c, 3), a = _a[0], b = _a[2];\n")
+	expectPrintedTarget(t, 5, "var [a = 1, ...b] = c;", "var _a = __toArray(c), _b = _a[0], a = _b === void 0 ? 1 : _b, b = _a.slice(1);\n")
+	expectPrintedTarget(t, 5, "var [[a], {b}] = c;", "var _a = __toArray(c, 2), a = __toArray(_a[0], 1)[0], b = _a[1].b;\n")
+	expectPrintedTarget(t, 5, "var {a, b: c = 1, ...d} = e;", "var a = e.a, _a = e.b, c = _a === void 0 ? 1 : _a, d = __objRest(e, [\"a\", \"b\"]);\n")
+	expectPrintedTarget(t, 5, "var {[a]: b, ...c} = d;", "var b = d[a], c = __objRest(d, [__restKey(a)]);\n")
+	expectPrintedTarget(t, 5, "var {a: {b}} = c;", "var b = c.a.b;\n")
+	expectPrintedTarget(t, 5, "[a, b] = [b, a];", "var _a;\n_a = __toArray([b, a], 2), a = _a[0], b = _a[1];\n")
+	expectPrintedTarget(t, 5, "x = [a, b] = c;", "var _a, _b;\nx = (_b = __toArray(_a = c, 2), a = _b[0], b = _b[1], _a);\n")
+	expectPrintedTarget(t, 5, "({a, b} = a);", "var _a;\n_a = a, a = _a.a, b = _a.b;\n")
+	expectPrintedTarget(t, 5, "({a: this.x, b: y[z]} = c);", "this.x = c.a, y[z] = c.b;\n")
+	expectPrintedTarget(t, 5, "try {} catch ([a, b]) {}", "try {\n} catch (_a) {\n  var _b = __toArray(_a, 2), a = _b[0], b = _b[1];\n}\n")
+	expectPrintedTarget(t, 5, "for (var [a, b] of c) ;", "try {\n  for (var _c = __iterator(c), _d, _e, _f; _d = !(_e = _c.next()).done; _d = false) {\n    var _a = _e.value;\n    var _b = __toArray(_a, 2), a = _b[0], b = _b[1];\n    ;\n  }\n} catch (_e) {\n  _f = [_e];\n} finally {\n  try {\n    _d && (_e = _c.return) && _e.call(_c);\n  } finally {\n    if (_f)\n      throw _f[0];\n  }\n}\n")
+	expectPrintedTarget(t, 5, "for ({a, b} in c) ;", "var _a;\nfor (_a in c) {\n  a = _a.a, b = _a.b;\n  ;\n}\n")
+	expectPrintedLooseIterationTarget(t, 5, "var [a, b] = c;", "var a = c[0], b = c[1];\n")
+	expectPrintedLooseIterationTarget(t, 5, "var [a, ...b] = c;", "var a = c[0], b = __slice(c, 1);\n")
+}
+
+func TestLowerDefaultAndRestArguments(t *testing.T) {
+	expectPrintedTarget(t, 5, "function f([a, b] = [], {c} = {}) {}", "function f() {\n  var _a = arguments[0] === void 0 ? [] : arguments[0], _b = __toArray(_a, 2), a = _b[0], b = _b[1];\n  var _c = arguments[1] === void 0 ? {} : arguments[1], c = _c.c;\n}\n")
+	expectPrintedTarget(t, 5, "function f(a, b = a, ...c) {}", "function f(a) {\n  var b = arguments[1] === void 0 ? a : arguments[1];\n  var c = __slice(arguments, 2);\n}\n")
+	expectPrintedTarget(t, 5, "function f(...[a, b]) {}", "function f() {\n  var _a = __toArray(__slice(arguments), 2), a = _a[0], b = _a[1];\n}\n")
+	expectPrintedTarget(t, 5, "async function f(a = 1, ...b) { await a }", "function f() {\n  var a = arguments[0] === void 0 ? 1 : arguments[0];\n  var b = __slice(arguments, 1);\n  return __async(this, null, function() {\n    return __makeGenerator(this, function(_a) {\n      switch (_a.label) {\n        case 0:\n          return [4, a];\n        case 1:\n          _a.sent();\n          return [2];\n      }\n    });\n  });\n}\n")
+}
+
+func TestLowerSpread(t *testing.T) {
+	expectPrintedTarget(t, 5, "[a, ...b, c, ...d];", "[a].concat(__toArray(b), [c], __toArray(d));\n")
+	expectPrintedTarget(t, 5, "a.b(...c);", "a.b.apply(a, __toArray(c));\n")
+	expectPrintedTarget(t, 5, "a().b(...c);", "var _a;\n(_a = a()).b.apply(_a, __toArray(c));\n")
+	expectPrintedTarget(t, 5, "a[b](...c);", "a[b].apply(a, __toArray(c));\n")
+	expectPrintedTarget(t, 5, "new A(...b);", "__construct(A, __toArray(b));\n")
+	expectPrintedTarget(t, 5, "a?.b(...c);", "a == null ? void 0 : a.b.apply(a, __toArray(c));\n")
+	expectPrintedTarget(t, 5, "f(a, ...b);", "f.apply(void 0, [a].concat(__toArray(b)));\n")
+	expectPrintedLooseIterationTarget(t, 5, "[a, ...b];", "[a].concat(__slice(b));\n")
+	expectPrintedLooseIterationTarget(t, 5, "f(a, ...b);", "f.apply(void 0, [a].concat(__slice(b)));\n")
+	expectPrintedLooseIterationTarget(t, 5, "f(...a);", "f.apply(void 0, a);\n")
+}
+
+func TestLowerObjectExtensions(t *testing.T) {
+	expectPrintedTarget(t, 5, "x = { [a]: b, c };", "var _a;\nx = (_a = {}, _a[a] = b, _a.c = c, _a);\n")
+	expectPrintedTarget(t, 5, "x = { a() { return super.a } };", "var _a;\nx = _a = { a: function() {\n  return __superGet(_a, this, \"a\");\n} };\n")
+	expectPrintedTarget(t, 5, "x = { a, [b]() {}, get [c]() {}, set [c](v) {}, d: 1 };", "var _b;\nx = (_b = { a: a }, _b[b] = function() {\n}, __defAccessor(_b, c, function() {\n}), __defAccessor(_b, c, function(v) {\n}, true), _b.d = 1, _b);\n")
+	expectPrintedTarget(t, 5, "x = { ...a, [b]: c };", "var _a;\nx = __spreadProps(__spreadValues({}, a), (_a = {}, _a[b] = c, _a));\n")
+}
+
+func TestLowerForOf(t *testing.T) {
+	expectPrintedTarget(t, 5, "for (const a of b) c(a);", "try {\n  for (var _a = __iterator(b), _b, _c, _d; _b = !(_c = _a.next()).done; _b = false) {\n    var a = _c.value;\n    c(a);\n  }\n} catch (_c) {\n  _d = [_c];\n} finally {\n  try {\n    _b && (_c = _a.return) && _c.call(_a);\n  } finally {\n    if (_d)\n      throw _d[0];\n  }\n}\n")
+	expectPrintedTarget(t, 5, "label: for (const a of b) continue label;", "try {\n  label:\n    for (var _a = __iterator(b), _b, _c, _d; _b = !(_c = _a.next()).done; _b = false) {\n      var a = _c.value;\n      continue label;\n    }\n} catch (_c) {\n  _d = [_c];\n} finally {\n  try {\n    _b && (_c = _a.return) && _c.call(_a);\n  } finally {\n    if (_d)\n      throw _d[0];\n  }\n}\n")
+	expectPrintedLooseIterationTarget(t, 5, "for (const a of b) c(a);", "for (var _a = 0, _b = b; _a < _b.length; _a++) {\n  var a = _b[_a];\n  c(a);\n}\n")
+	expectPrintedLooseIterationTarget(t, 5, "label: for (const a of b) continue label;", "label:\n  for (var _a = 0, _b = b; _a < _b.length; _a++) {\n    var a = _b[_a];\n    continue label;\n  }\n")
+}
+
+func TestLowerRegExp(t *testing.T) {
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpNamedCaptureGroups, `x = /(?<a>\d+)-(?<b>\w)\k<a>/`, "x = /* @__PURE__ */ __wrapRegExp(/(\\d+)-(\\w)\\1/, {\n  a: 1,\n  b: 2\n});\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpNamedCaptureGroups, `x = /(?<a>.)\k<a>0/u`, "x = /* @__PURE__ */ __wrapRegExp(/(.)(?:\\1)0/u, {\n  a: 1\n});\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpNamedCaptureGroups, `x = /(?<a>x)|(?<a>y)/`, "x = new RegExp(\"(?<a>x)|(?<a>y)\");\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpNamedCaptureGroups|compat.RegexpLookbehindAssertions, `x = /(?<a>.)(?<=b)/`, "x = new RegExp(\"(?<a>.)(?<=b)\");\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpDotAllFlag, `x = /a.b[.]/s`, "x = /a[\\s\\S]b[.]/;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpDotAllFlag, `x = /a.b/gsm`, "x = /a[\\s\\S]b/gm;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpUnicodePropertyEscapes, `x = /\p{Script=Ogham}\P{ASCII}/u`, "x = /[\\u1680-\\u169C][\\x80-\\u{10FFFF}]/u;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpUnicodePropertyEscapes, `x = /[^\p{sc=Ogam}a-z]/u`, "x = /[^a-z\\u1680-\\u169C]/u;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpUnicodePropertyEscapes, `x = /\p{Zs}/iu`, "x = /[ \\xA0\\u1680\\u2000-\\u200A\\u202F\\u205F\\u3000]/iu;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpUnicodePropertyEscapes, `x = /\p{Emoji}/u`, "x = new RegExp(\"\\\\p{Emoji}\", \"u\");\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpStickyAndUnicodeFlags, `x = /😀+\u{1F601}/u`, "x = /(?:\\uD83D\\uDE00)+(?:\\uD83D\\uDE01)/;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpStickyAndUnicodeFlags, `x = /[😀-😂a]/u`, "x = /(?:\\uD83D[\\uDE00-\\uDE02]|a)/;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpStickyAndUnicodeFlags, `x = /^.\S$/u`, "x = /^(?:[\\uD800-\\uDBFF][\\uDC00-\\uDFFF]|[\\uD800-\\uDBFF](?![\\uDC00-\\uDFFF])|[^\\n\\r\\u2028\\u2029\\uD800-\\uDBFF])(?:[\\uD800-\\uDBFF][\\uDC00-\\uDFFF]|[\\uD800-\\uDBFF](?![\\uDC00-\\uDFFF])|[^\\t-\\r \\xA0\\u1680\\u2000-\\u200A\\u2028\\u2029\\u202F\\u205F\\u3000\\uD800-\\uDBFF\\uFEFF])$/;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpStickyAndUnicodeFlags, `x = /k[s-t]/iu`, "x = /[Kk\\u212A][STst\\u017F]/i;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpStickyAndUnicodeFlags, `x = /\w\W/iu`,
+		"x = /[0-9A-Z_a-z\\u017F\\u212A](?:[\\uD800-\\uDBFF][\\uDC00-\\uDFFF]|[\\uD800-\\uDBFF](?![\\uDC00-\\uDFFF])|[^0-9A-Z_a-z\\u017F\\u212A\\uD800-\\uDBFF])/i;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpStickyAndUnicodeFlags, `x = /\ba\B/iu`,
+		"x = /(?:(?<=[0-9A-Z_a-z\\u017F\\u212A])(?![0-9A-Z_a-z\\u017F\\u212A])|(?<![0-9A-Z_a-z\\u017F\\u212A])(?=[0-9A-Z_a-z\\u017F\\u212A]))a"+
+			"(?:(?<=[0-9A-Z_a-z\\u017F\\u212A])(?=[0-9A-Z_a-z\\u017F\\u212A])|(?<![0-9A-Z_a-z\\u017F\\u212A])(?![0-9A-Z_a-z\\u017F\\u212A]))/i;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpStickyAndUnicodeFlags|compat.RegexpLookbehindAssertions, `x = /\b/iu`, "x = new RegExp(\"\\\\b\", \"iu\");\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpStickyAndUnicodeFlags, `x = /\w\b/u`, "x = /\\w\\b/;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpStickyAndUnicodeFlags, `x = /a/uy`, "x = new RegExp(\"a\", \"uy\");\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpSetNotation, `x = /[\p{ASCII}&&\p{L}]/v`, "x = /[A-Za-z]/u;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpSetNotation, `x = /[[a-z]--[aeiou]]/v`, "x = /[b-df-hj-np-tv-z]/u;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpSetNotation, `x = /[\q{abc|de|}x-z]/v`, "x = /(?:abc|de|[x-z]|)/u;\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpSetNotation, `x = /[^\q{abc}]/v`, "x = new RegExp(\"[^\\\\q{abc}]\", \"v\");\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.RegexpSetNotation|compat.RegexpStickyAndUnicodeFlags, `x = /[\q{😀😀}😁]/v`, "x = /(?:\\uD83D\\uDE00\\uD83D\\uDE00|\\uD83D\\uDE01)/;\n")
+}
+
 func TestLowerOptionalChain(t *testing.T) {
 	expectPrintedTarget(t, 2019, "a?.b.c", "a == null ? void 0 : a.b.c;\n")
 	expectPrintedTarget(t, 2019, "(a?.b).c", "(a == null ? void 0 : a.b).c;\n")
@@ -722,12 +1067,10 @@ func TestAsyncGeneratorFns(t *testing.T) {
 	expectParseErrorWithUnsupportedFeatures(t, compat.AsyncAwait, "(async function () {});", err)
 	expectParseErrorWithUnsupportedFeatures(t, compat.AsyncAwait, "({ async foo() {} });", err)
 
-	err = "<stdin>: ERROR: Transforming generator functions to the configured target environment is not supported yet\n"
 	expectParseErrorWithUnsupportedFeatures(t, compat.Generator, "function* gen() {}", err)
 	expectParseErrorWithUnsupportedFeatures(t, compat.Generator, "(function* () {});", err)
 	expectParseErrorWithUnsupportedFeatures(t, compat.Generator, "({ *foo() {} });", err)
 
-	err = "<stdin>: ERROR: Transforming async functions to the configured target environment is not supported yet\n"
 	expectParseErrorWithUnsupportedFeatures(t, compat.AsyncAwait|compat.Generator, "async function gen() {}", err)
 	expectParseErrorWithUnsupportedFeatures(t, compat.AsyncAwait|compat.Generator, "(async function () {});", err)
 	expectParseErrorWithUnsupportedFeatures(t, compat.AsyncAwait|compat.Generator, "({ async foo() {} });", err)
@@ -749,10 +1092,7 @@ func TestForAwait(t *testing.T) {
 	// This is ok because for-await can be lowered to yield
 	expectParseErrorWithUnsupportedFeatures(t, compat.ForAwait|compat.AsyncAwait, "async function gen() { for await (x of y) ; }", err)
 
-	// This is not ok because for-await can't be lowered
-	err =
-		"<stdin>: ERROR: Transforming async functions to the configured target environment is not supported yet\n" +
-			"<stdin>: ERROR: Transforming for-await loops to the configured target environment is not supported yet\n"
+	// This is ok because for-await can be lowered to yield and then to a state machine
 	expectParseErrorWithUnsupportedFeatures(t, compat.ForAwait|compat.AsyncAwait|compat.Generator, "async function gen() { for await (x of y) ; }", err)
 
 	// Can't use for-await at the top-level without top-level await
@@ -131,6 +131,23 @@ func expectPrintedASCII(t *testing.T, contents string, expected string) {
 	})
 }
 
+func expectPrintedLooseIterationTarget(t *testing.T, esVersion int, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		UnsupportedJSFeatures: compat.UnsupportedJSFeatures(map[compat.Engine][]int{
+			compat.ES: {esVersion},
+		}),
+		LooseIteration: true,
+	})
+}
+
+func expectPrintedPreserveComments(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		PreserveComments: true,
+	})
+}
+
 func expectPrintedTargetASCII(t *testing.T, esVersion int, contents string, expected string) {
 	t.Helper()
 	expectPrintedCommon(t, contents, expected, config.Options{
@@ -179,6 +196,26 @@ func expectPrintedJSXSideEffects(t *testing.T, contents string, expected string)
 	})
 }
 
+func expectPrintedJSXConstantElements(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		JSX: config.JSXOptions{
+			Parse:            true,
+			ConstantElements: true,
+		},
+	})
+}
+
+func expectPrintedJSXTarget(t *testing.T, target config.JSXTarget, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		JSX: config.JSXOptions{
+			Parse:  true,
+			Target: target,
+		},
+	})
+}
+
 func expectPrintedMangleJSX(t *testing.T, contents string, expected string) {
 	t.Helper()
 	expectPrintedCommon(t, contents, expected, config.Options{
@@ -194,6 +231,8 @@ type JSXAutomaticTestOptions struct {
 	ImportSource           string
 	OmitJSXRuntimeForTests bool
 	SideEffects            bool
+	ConstantElements       bool
+	InlineElements         bool
 }
 
 func expectParseErrorJSXAutomatic(t *testing.T, options JSXAutomaticTestOptions, contents string, expected string) {
@@ -220,6 +259,8 @@ func expectPrintedJSXAutomatic(t *testing.T, options JSXAutomaticTestOptions, co
 			Development:      options.Development,
 			ImportSource:     options.ImportSource,
 			SideEffects:      options.SideEffects,
+			ConstantElements: options.ConstantElements,
+			InlineElements:   options.InlineElements,
 		},
 	})
 }
@@ -2036,14 +2077,25 @@ func TestDecorators(t *testing.T) {
 	expectParseError(t, "@new Function() class Foo {}", "<stdin>: ERROR: Expected identifier but found \"new\"\n")
 	expectParseError(t, "@() => {} class Foo {}", "<stdin>: ERROR: Unexpected \")\"\n")
 
-	errorText := "<stdin>: ERROR: Transforming JavaScript decorators to the configured target environment is not supported yet\n"
-	expectParseErrorWithUnsupportedFeatures(t, compat.Decorators, "@dec class Foo {}", errorText)
-	expectParseErrorWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec x }", errorText)
-	expectParseErrorWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec x() {} }", errorText)
-	expectParseErrorWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec accessor x }", errorText)
-	expectParseErrorWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec static x }", errorText)
-	expectParseErrorWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec static x() {} }", errorText)
-	expectParseErrorWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec static accessor x }", errorText)
+	// JavaScript decorators can be lowered
+	expectPrintedWithUnsupportedFeatures(t, compat.Decorators, "@dec class Foo {}",
+		"var _init, _Foo_decorators;\n_Foo_decorators = [dec];\nlet Foo = class {\n};\n_init = __decoratorStart(null);\nFoo = __decorateElement(_init, 0, \"Foo\", _Foo_decorators, Foo);\n__runInitializers(_init, 1, Foo);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec x }",
+		"var _init, _x_dec;\n_x_dec = [dec];\nclass Foo {\n  constructor() {\n    __publicField(this, \"x\", __runInitializers(_init, 8, this));\n    __runInitializers(_init, 11, this);\n  }\n}\n_init = __decoratorStart(null);\n__decorateElement(_init, 5, \"x\", _x_dec, Foo);\n__decoratorMetadata(_init, Foo);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec x() {} }",
+		"var _init, _x_dec;\n_x_dec = [dec];\nclass Foo {\n  constructor() {\n    __runInitializers(_init, 5, this);\n  }\n  x() {\n  }\n}\n_init = __decoratorStart(null);\n__decorateElement(_init, 1, \"x\", _x_dec, Foo);\n__decoratorMetadata(_init, Foo);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec accessor x }",
+		"var _init, _x_dec, _x;\n_x_dec = [dec];\nclass Foo {\n  constructor() {\n    __privateAdd(this, _x, __runInitializers(_init, 8, this));\n    __runInitializers(_init, 11, this);\n  }\n  get x() {\n    return __privateGet(this, _x);\n  }\n  set x(_) {\n    __privateSet(this, _x, _);\n  }\n}\n_x = new WeakMap();\n_init = __decoratorStart(null);\n__decorateElement(_init, 4, \"x\", _x_dec, Foo);\n__decoratorMetadata(_init, Foo);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec static x }",
+		"var _init, _x_dec;\n_x_dec = [dec];\nclass Foo {\n}\n_init = __decoratorStart(null);\n__decorateElement(_init, 13, \"x\", _x_dec, Foo);\n__decoratorMetadata(_init, Foo);\n__publicField(Foo, \"x\", __runInitializers(_init, 8, Foo));\n__runInitializers(_init, 11, Foo);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec static x() {} }",
+		"var _init, _x_dec;\n_x_dec = [dec];\nclass Foo {\n  static x() {\n  }\n}\n_init = __decoratorStart(null);\n__decorateElement(_init, 9, \"x\", _x_dec, Foo);\n__decoratorMetadata(_init, Foo);\n__runInitializers(_init, 3, Foo);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec static accessor x }",
+		"var _init, _x_dec, _x;\n_x_dec = [dec];\nclass Foo {\n  static get x() {\n    return __privateGet(this, _x);\n  }\n  static set x(_) {\n    __privateSet(this, _x, _);\n  }\n}\n_x = new WeakMap();\n_init = __decoratorStart(null);\n__decorateElement(_init, 12, \"x\", _x_dec, Foo);\n__decoratorMetadata(_init, Foo);\n__privateAdd(Foo, _x, __runInitializers(_init, 8, Foo));\n__runInitializers(_init, 11, Foo);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Decorators, "class Foo { @dec #x() {} }",
+		"var _init, _x_dec, _x, x_fn;\n_x_dec = [dec];\nclass Foo {\n  constructor() {\n    __privateAdd(this, _x);\n    __runInitializers(_init, 5, this);\n  }\n}\n_x = new WeakSet();\nx_fn = function() {\n};\n_init = __decoratorStart(null);\nx_fn = __decorateElement(_init, 17, \"#x\", _x_dec, _x, x_fn);\n__decoratorMetadata(_init, Foo);\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.Decorators, "(@a class { @b [c()] = 1 })",
+		"var _init, _decorators, _dec, _a, _b;\n_decorators = [a], _dec = [b], _a = c(), _b = class {\n  constructor() {\n    __publicField(this, _a, __runInitializers(_init, 8, this, 1));\n    __runInitializers(_init, 11, this);\n  }\n}, _init = __decoratorStart(null), __decorateElement(_init, 5, _a, _dec, _b), _b = __decorateElement(_init, 0, \"\", _decorators, _b), __runInitializers(_init, 1, _b), _b;\n")
 }
 
 func TestGenerator(t *testing.T) {
@@ -4992,6 +5044,38 @@ func TestPreservedComments(t *testing.T) {
 	expectPrinted(t, "x\u2029    /*!\u2029     * Re-indent test\u2029     */", "x;\n/*!\n * Re-indent test\n */\n")
 }
 
+func TestPreserveAllComments(t *testing.T) {
+	expectPrintedPreserveComments(t, "//", "//\n")
+	expectPrintedPreserveComments(t, "/* a */", "/* a */\n")
+	expectPrintedPreserveComments(t, "// a\nfoo()", "// a\nfoo();\n")
+	expectPrintedPreserveComments(t, "foo() // a", "foo(); // a\n")
+	expectPrintedPreserveComments(t, "foo() /* a */ /* b */", "foo(); /* a */ /* b */\n")
+	expectPrintedPreserveComments(t, "foo() // a\n// b\nbar()", "foo(); // a\n// b\nbar();\n")
+	expectPrintedPreserveComments(t, "foo()\n// a", "foo();\n// a\n")
+	expectPrintedPreserveComments(t, "//! a\nfoo() //! b", "//! a\nfoo();\n//! b\n")
+
+	expectPrintedPreserveComments(t, "/** a */\nfunction foo() {\n// b\nreturn // c\n}",
+		"/** a */\nfunction foo() {\n  // b\n  return; // c\n}\n")
+	expectPrintedPreserveComments(t, "/** a */\nexport function foo() {}", "/** a */\nexport function foo() {\n}\n")
+	expectPrintedPreserveComments(t, "/** a */\nexport default class {}", "/** a */\nexport default class {\n}\n")
+	expectPrintedPreserveComments(t, "function foo() {\nbar()\n// a\n}", "function foo() {\n  bar();\n  // a\n}\n")
+
+	expectPrintedPreserveComments(t, "x = {\n// a\nb: 1, // b\nc: 2 // c\n}",
+		"x = {\n  // a\n  b: 1, // b\n  c: 2 // c\n};\n")
+	expectPrintedPreserveComments(t, "x = {b: 1 /* b */}", "x = {\n  b: 1 /* b */\n};\n")
+	expectPrintedPreserveComments(t, "class Foo {\n/** a */\nfoo() {} // b\nbar = 1 // c\nstatic {} // d\n}",
+		"class Foo {\n  /** a */\n  foo() {\n  } // b\n  bar = 1; // c\n  static {\n  } // d\n}\n")
+
+	expectPrintedPreserveComments(t, "switch (x) {\n// a\ncase 1:\n// b\nfoo() // c\n/** d */\ndefault:\nbar() // e\n}",
+		"switch (x) {\n  // a\n  case 1:\n    // b\n    foo(); // c\n  /** d */\n  default:\n    bar(); // e\n}\n")
+
+	// Comments are still removed when whitespace is minified
+	expectPrintedCommon(t, "// a\nfoo() // b", "foo();\n", config.Options{
+		PreserveComments: true,
+		MinifyWhitespace: true,
+	})
+}
+
 func TestUnicodeWhitespace(t *testing.T) {
 	whitespace := []string{
 		"\u0009", // character tabulation
@@ -5496,6 +5580,196 @@ func TestJSXSideEffects(t *testing.T) {
 	expectPrintedJSXSideEffects(t, "<></>", "React.createElement(React.Fragment, null);\n")
 }
 
+func TestJSXConstantElements(t *testing.T) {
+	// Only elements inside functions are cached
+	expectPrintedJSXConstantElements(t, "<a/>", "/* @__PURE__ */ React.createElement(\"a\", null);\n")
+	expectPrintedJSXConstantElements(t, "function f() { return <a/> }",
+		"var _a;\nfunction f() {\n  return _a || (_a = /* @__PURE__ */ React.createElement(\"a\", null));\n}\n")
+	expectPrintedJSXConstantElements(t, "() => <a b='c' d={1} e={null} f>x</a>",
+		"var _a;\n() => _a || (_a = /* @__PURE__ */ React.createElement(\"a\", { b: \"c\", d: 1, e: null, f: true }, \"x\"));\n")
+
+	// Only the outermost constant element is cached
+	expectPrintedJSXConstantElements(t, "() => <a><b/><c>{'d'}</c></a>",
+		"var _a;\n() => _a || (_a = /* @__PURE__ */ React.createElement(\"a\", null, /* @__PURE__ */ React.createElement(\"b\", null), /* @__PURE__ */ React.createElement(\"c\", null, \"d\")));\n")
+	expectPrintedJSXConstantElements(t, "() => <a>{x}<b/><c/></a>",
+		"var _a, _b;\n() => /* @__PURE__ */ React.createElement(\"a\", null, x, _a || (_a = /* @__PURE__ */ React.createElement(\"b\", null)), _b || (_b = /* @__PURE__ */ React.createElement(\"c\", null)));\n")
+	expectPrintedJSXConstantElements(t, "() => <a b={<c/>} />",
+		"var _a;\n() => _a || (_a = /* @__PURE__ */ React.createElement(\"a\", { b: /* @__PURE__ */ React.createElement(\"c\", null) }));\n")
+
+	// The tag must be a string or a top-level binding that can't change
+	expectPrintedJSXConstantElements(t, "import A from 'a'; const B = 0; class C {} () => [<A/>, <B/>, <C/>, <A.b/>]",
+		"var _a, _b, _c, _d;\nimport A from \"a\";\nconst B = 0;\nclass C {\n}\n() => [_a || (_a = /* @__PURE__ */ React.createElement(A, null)), _b || (_b = /* @__PURE__ */ React.createElement(B, null)), _c || (_c = /* @__PURE__ */ React.createElement(C, null)), _d || (_d = /* @__PURE__ */ React.createElement(A.b, null))];\n")
+	expectPrintedJSXConstantElements(t, "let A; () => <A/>", "let A;\n() => /* @__PURE__ */ React.createElement(A, null);\n")
+	expectPrintedJSXConstantElements(t, "(A) => <A/>", "(A) => /* @__PURE__ */ React.createElement(A, null);\n")
+	expectPrintedJSXConstantElements(t, "() => <Unbound/>", "() => /* @__PURE__ */ React.createElement(Unbound, null);\n")
+
+	// Elements with dynamic values, spreads, or refs are not cached
+	expectPrintedJSXConstantElements(t, "() => <a b={c} />", "() => /* @__PURE__ */ React.createElement(\"a\", { b: c });\n")
+	expectPrintedJSXConstantElements(t, "() => <a {...b} />", "() => /* @__PURE__ */ React.createElement(\"a\", { ...b });\n")
+	expectPrintedJSXConstantElements(t, "() => <a ref='b' />", "() => /* @__PURE__ */ React.createElement(\"a\", { ref: \"b\" });\n")
+	expectPrintedJSXConstantElements(t, "() => <a>{b}</a>", "() => /* @__PURE__ */ React.createElement(\"a\", null, b);\n")
+
+	// This is disabled in development mode and when JSX has side effects
+	dev := JSXAutomaticTestOptions{Development: true, ConstantElements: true, OmitJSXRuntimeForTests: true}
+	expectPrintedJSXAutomatic(t, dev, "() => <a/>", "() => /* @__PURE__ */ jsxDEV(\"a\", {}, void 0, false, {\n  fileName: \"<stdin>\",\n  lineNumber: 1,\n  columnNumber: 7\n}, this);\n")
+	sideEffects := JSXAutomaticTestOptions{SideEffects: true, ConstantElements: true, OmitJSXRuntimeForTests: true}
+	expectPrintedJSXAutomatic(t, sideEffects, "() => <a/>", "() => jsx(\"a\", {});\n")
+
+	p := JSXAutomaticTestOptions{ConstantElements: true, OmitJSXRuntimeForTests: true}
+	expectPrintedJSXAutomatic(t, p, "() => <a key='b'><c/></a>", "var _a;\n() => _a || (_a = /* @__PURE__ */ jsx(\"a\", { children: /* @__PURE__ */ jsx(\"c\", {}) }, \"b\"));\n")
+	expectPrintedJSXAutomatic(t, p, "() => <><a/></>", "var _a;\n() => _a || (_a = /* @__PURE__ */ jsx(Fragment, { children: /* @__PURE__ */ jsx(\"a\", {}) }));\n")
+}
+
+func TestJSXInlineElements(t *testing.T) {
+	p := JSXAutomaticTestOptions{InlineElements: true, OmitJSXRuntimeForTests: true}
+	expectPrintedJSXAutomatic(t, p, "<a/>",
+		"({ $$typeof: __reactElement, type: \"a\", key: null, ref: null, props: {}, _owner: null });\n")
+	expectPrintedJSXAutomatic(t, p, "<a b={c}>d</a>",
+		"({ $$typeof: __reactElement, type: \"a\", key: null, ref: null, props: { b: c, children: \"d\" }, _owner: null });\n")
+	expectPrintedJSXAutomatic(t, p, "<>a</>",
+		"({ $$typeof: __reactElement, type: Fragment, key: null, ref: null, props: { children: \"a\" }, _owner: null });\n")
+
+	// Keys are converted to strings
+	expectPrintedJSXAutomatic(t, p, "<a key='b' />",
+		"({ $$typeof: __reactElement, type: \"a\", key: \"b\", ref: null, props: {}, _owner: null });\n")
+	expectPrintedJSXAutomatic(t, p, "<a key={1} />",
+		"({ $$typeof: __reactElement, type: \"a\", key: \"\" + 1, ref: null, props: {}, _owner: null });\n")
+
+	// The props must be evaluated before the key
+	expectPrintedJSXAutomatic(t, p, "let c; <a key={b()}>{c}</a>",
+		"let c;\n({ $$typeof: __reactElement, type: \"a\", key: \"\" + b(), ref: null, props: { children: c }, _owner: null });\n")
+	expectPrintedJSXAutomatic(t, p, "<a key={b()}>{c()}</a>",
+		"var _a;\n_a = { children: c() }, { $$typeof: __reactElement, type: \"a\", key: \"\" + b(), ref: null, props: _a, _owner: null };\n")
+
+	// Components, spreads, and refs still use "jsx()"
+	expectPrintedJSXAutomatic(t, p, "<A/>", "/* @__PURE__ */ jsx(A, {});\n")
+	expectPrintedJSXAutomatic(t, p, "<a {...b} />", "/* @__PURE__ */ jsx(\"a\", { ...b });\n")
+	expectPrintedJSXAutomatic(t, p, "<a ref={b} />", "/* @__PURE__ */ jsx(\"a\", { ref: b });\n")
+	expectPrintedJSXAutomatic(t, p, "<a><A/></a>",
+		"({ $$typeof: __reactElement, type: \"a\", key: null, ref: null, props: { children: /* @__PURE__ */ jsx(A, {}) }, _owner: null });\n")
+
+	// This only works with React's production runtime
+	other := JSXAutomaticTestOptions{InlineElements: true, ImportSource: "preact", OmitJSXRuntimeForTests: true}
+	expectPrintedJSXAutomatic(t, other, "<a/>", "/* @__PURE__ */ jsx(\"a\", {});\n")
+	sideEffects := JSXAutomaticTestOptions{InlineElements: true, SideEffects: true, OmitJSXRuntimeForTests: true}
+	expectPrintedJSXAutomatic(t, sideEffects, "<a/>", "jsx(\"a\", {});\n")
+	expectPrintedJSX(t, "<a/>", "/* @__PURE__ */ React.createElement(\"a\", null);\n")
+
+	// Inlined elements can also be cached
+	both := JSXAutomaticTestOptions{InlineElements: true, ConstantElements: true, OmitJSXRuntimeForTests: true}
+	expectPrintedJSXAutomatic(t, both, "() => <a><b/></a>",
+		"var _a;\n() => _a || (_a = { $$typeof: __reactElement, type: \"a\", key: null, ref: null, props: { children: { $$typeof: __reactElement, type: \"b\", key: null, ref: null, props: {}, _owner: null } }, _owner: null });\n")
+}
+
+func TestJSXSolid(t *testing.T) {
+	solid := config.JSXTargetSolid
+	expectPrintedJSXTarget(t, solid, "<a/>",
+		"import { template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<a></a>\");\n_a();\n")
+	expectPrintedJSXTarget(t, solid, "<a/>;<a/>",
+		"import { template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<a></a>\"), _b = /* @__PURE__ */ template(\"<a></a>\");\n_a();\n_b();\n")
+	expectPrintedJSXTarget(t, solid, "<br/>",
+		"import { template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<br>\");\n_a();\n")
+
+	// Static attributes and text are written into the template
+	expectPrintedJSXTarget(t, solid, "<a className='b' htmlFor='c' d e={false} f={null} g='&\"'>x &amp; &lt; <b>y</b></a>",
+		"import { template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template('<a class=\"b\" for=\"c\" d g=\"&amp;&quot;\">x &amp; &lt; <b>y</b></a>');\n_a();\n")
+
+	// Dynamic attributes are set after the template is cloned
+	expectPrintedJSXTarget(t, solid, "<a b={c} d={e()} />",
+		"import { effect, setAttribute, template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<a></a>\");\n(() => {\n  var _el = _a();\n  setAttribute(_el, \"b\", c);\n  effect(() => setAttribute(_el, \"d\", e()));\n  return _el;\n})();\n")
+	expectPrintedJSXTarget(t, solid, "<a value={b()} prop:c={d} attr:e={f} style={g} />",
+		"import { effect, setAttribute, style, template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<a></a>\");\n(() => {\n  var _el = _a();\n  effect(() => _el.value = b());\n  _el.c = d;\n  setAttribute(_el, \"e\", f);\n  style(_el, g);\n  return _el;\n})();\n")
+	expectPrintedJSXTarget(t, solid, "<a onClick={b} on:custom={c} ref={d} />",
+		"import { template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<a></a>\");\n(() => {\n  var _el = _a();\n  _el.addEventListener(\"click\", b);\n  _el.addEventListener(\"custom\", c);\n  typeof d === \"function\" ? d(_el) : d = _el;\n  return _el;\n})();\n")
+	expectPrintedJSXTarget(t, solid, "<a {...b}><c/></a>",
+		"import { spread, template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<a><c></c></a>\");\n(() => {\n  var _el = _a();\n  spread(_el, b, false, true);\n  return _el;\n})();\n")
+
+	// Dynamic children are inserted before a marker node
+	expectPrintedJSXTarget(t, solid, "<a>{b}</a>",
+		"import { insert, template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<a></a>\");\n(() => {\n  var _el = _a();\n  insert(_el, b);\n  return _el;\n})();\n")
+	expectPrintedJSXTarget(t, solid, "<a>x{b()}<c/>{d}</a>",
+		"import { insert, template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<a>x<c></c></a>\");\n(() => {\n  var _el = _a(), _el2 = _el.firstChild, _el3 = _el2.nextSibling;\n  insert(_el, () => b(), _el3);\n  insert(_el, d, null);\n  return _el;\n})();\n")
+	expectPrintedJSXTarget(t, solid, "<a>{b}x{c}</a>",
+		"import { insert, template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<a><!>x</a>\");\n(() => {\n  var _el = _a(), _el2 = _el.firstChild;\n  insert(_el, b, _el2);\n  insert(_el, c, null);\n  return _el;\n})();\n")
+	expectPrintedJSXTarget(t, solid, "<a><b>{c}</b></a>",
+		"import { insert, template } from \"solid-js/web\";\nvar _a = /* @__PURE__ */ template(\"<a><b></b></a>\");\n(() => {\n  var _el = _a(), _el2 = _el.firstChild;\n  insert(_el2, c);\n  return _el;\n})();\n")
+
+	// Components and fragments
+	expectPrintedJSXTarget(t, solid, "<A b='c' d={e} f={g()} />",
+		"import { createComponent } from \"solid-js/web\";\ncreateComponent(A, { b: \"c\", d: e, get f() {\n  return g();\n} });\n")
+	expectPrintedJSXTarget(t, solid, "<A {...b} c={d}>e</A>",
+		"import { createComponent, mergeProps } from \"solid-js/web\";\ncreateComponent(A, mergeProps(b, { c: d, children: \"e\" }));\n")
+	expectPrintedJSXTarget(t, solid, "<A>{b()}{c}</A>",
+		"import { createComponent } from \"solid-js/web\";\ncreateComponent(A, { get children() {\n  return [() => b(), c];\n} });\n")
+	expectPrintedJSXTarget(t, solid, "<>a{b()}</>",
+		"[\"a\", () => b()];\n")
+}
+
+func TestJSXVue(t *testing.T) {
+	vue := config.JSXTargetVue
+	expectPrintedJSXTarget(t, vue, "<a/>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\");\n")
+	expectPrintedJSXTarget(t, vue, "<a b='c'>d</a>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", { b: \"c\" }, \"d\");\n")
+	expectPrintedJSXTarget(t, vue, "<a>b<c/></a>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", null, [\"b\", /* @__PURE__ */ createVNode(\"c\")]);\n")
+	expectPrintedJSXTarget(t, vue, "<>a</>",
+		"import { Fragment, createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(Fragment, null, [\"a\"]);\n")
+
+	// Patch flags tell Vue which props to compare
+	expectPrintedJSXTarget(t, vue, "<a class={b} style={c} />",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", { class: b, style: c }, null, 6);\n")
+	expectPrintedJSXTarget(t, vue, "<a key={b} c={d} e={f} />",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", { key: b, c: d, e: f }, null, 8, [\"c\", \"e\"]);\n")
+	expectPrintedJSXTarget(t, vue, "<a ref={b} />",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", { ref: b }, null, 512);\n")
+	expectPrintedJSXTarget(t, vue, "<a b={c} {...d} />",
+		"import { createVNode, mergeProps } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", mergeProps({ b: c }, d), null, 16);\n")
+
+	// Dynamic text children are passed as a single string
+	expectPrintedJSXTarget(t, vue, "<a>{`${b}`}</a>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", null, `${b}`, 1);\n")
+	expectPrintedJSXTarget(t, vue, "<a class={b}>c {d + 1}!</a>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", { class: b }, `c ${d + 1}!`, 3);\n")
+	expectPrintedJSXTarget(t, vue, "<a ref={b}>{c.length}</a>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", { ref: b }, [c.length], 512);\n")
+	expectPrintedJSXTarget(t, vue, "<a ref={b}>{-c}</a>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", { ref: b }, -c, 513);\n")
+	expectPrintedJSXTarget(t, vue, "<a>{b}</a>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", null, [b]);\n")
+	expectPrintedJSXTarget(t, vue, "<a>b{'c'}</a>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(\"a\", null, [\"b\", \"c\"]);\n")
+	expectPrintedJSXTarget(t, vue, "<>{`${a}`}</>",
+		"import { Fragment, createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(Fragment, null, [`${a}`]);\n")
+
+	// Components receive their children as slots
+	expectPrintedJSXTarget(t, vue, "<A class={b}>c</A>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(A, { class: b }, { default: () => [\"c\"] }, 8, [\"class\"]);\n")
+	expectPrintedJSXTarget(t, vue, "<A>{{ b: () => c }}</A>",
+		"import { createVNode } from \"vue\";\n/* @__PURE__ */ createVNode(A, null, { b: () => c });\n")
+}
+
+func TestJSXHTM(t *testing.T) {
+	htm := config.JSXTargetHTM
+	expectPrintedJSXTarget(t, htm, "<a/>", "html`<a />`;\n")
+	expectPrintedJSXTarget(t, htm, "<a b='c' d e={f} {...g}>h<i/>{j}</a>", "html`<a b=\"c\" d e=${f} ...${g}>h<i />${j}</a>`;\n")
+	expectPrintedJSXTarget(t, htm, "<A b={c}><D/></A>", "html`<${A} b=${c}><${D} /><//>`;\n")
+	expectPrintedJSXTarget(t, htm, "<>a<b/></>", "html`a<b />`;\n")
+
+	// Text that htm would parse differently is passed as a value
+	expectPrintedJSXTarget(t, htm, "<a b='&quot;'>{'<'}&gt;</a>", "html`<a b=${'\"'}>${\"<\"}${\">\"}</a>`;\n")
+	expectPrintedJSXTarget(t, htm, "<a>{'`${b}\\\\'}</a>", "html`<a>\\`\\${b}\\\\</a>`;\n")
+
+	// The tag can be changed with the JSX factory
+	expectPrintedCommon(t, "<a/>", "h.html`<a />`;\n", config.Options{
+		JSX: config.JSXOptions{
+			Parse:   true,
+			Target:  config.JSXTargetHTM,
+			Factory: config.DefineExpr{Parts: []string{"h", "html"}},
+		},
+	})
+}
+
 func TestPreserveOptionalChainParentheses(t *testing.T) {
 	expectPrinted(t, "a?.b.c", "a?.b.c;\n")
 	expectPrinted(t, "(a?.b).c", "(a?.b).c;\n")
@@ -5719,87 +5993,100 @@ func TestImportAssertions(t *testing.T) {
 	expectParseError(t, "export { foo } from 'x' assert {type: 'json'}", "")
 }
 
+func TestImportAttributes(t *testing.T) {
+	expectPrinted(t, "import 'x' with {}", "import \"x\" with {};\n")
+	expectPrinted(t, "import 'x' with {\n}", "import \"x\" with {};\n")
+	expectPrinted(t, "import 'x' with\n{}", "import \"x\" with {};\n")
+	expectPrinted(t, "import 'x'\nwith\n{}", "import \"x\" with {};\n")
+	expectPrinted(t, "import 'x' with {type: 'json'}", "import \"x\" with { type: \"json\" };\n")
+	expectPrinted(t, "import 'x' with {type: 'json',}", "import \"x\" with { type: \"json\" };\n")
+	expectPrinted(t, "import 'x' with {'type': 'json'}", "import \"x\" with { \"type\": \"json\" };\n")
+	expectPrinted(t, "import 'x' with {a: 'b', c: 'd'}", "import \"x\" with { a: \"b\", c: \"d\" };\n")
+	expectPrinted(t, "import 'x' with {if: 'keyword'}", "import \"x\" with { if: \"keyword\" };\n")
+	expectPrintedMangle(t, "import 'x' with {'type': 'json'}", "import \"x\" with { type: \"json\" };\n")
+	expectPrintedMangle(t, "import 'x' with {'ty pe': 'json'}", "import \"x\" with { \"ty pe\": \"json\" };\n")
+
+	expectParseError(t, "import 'x' with {,}", "<stdin>: ERROR: Expected identifier but found \",\"\n")
+	expectParseError(t, "import 'x' with {x}", "<stdin>: ERROR: Expected \":\" but found \"}\"\n")
+	expectParseError(t, "import 'x' with {x: y}", "<stdin>: ERROR: Expected string but found \"y\"\n")
+	expectParseError(t, "import 'x' with: {x: 'y'}", "<stdin>: ERROR: Expected \"{\" but found \":\"\n")
+	expectParseError(t, "import 'x' with {x: 'y', x: 'y'}",
+		"<stdin>: ERROR: Duplicate import attribute \"x\"\n<stdin>: NOTE: The first \"x\" was here:\n")
+
+	expectPrinted(t, "import x from 'x' with {x: 'y'}", "import x from \"x\" with { x: \"y\" };\n")
+	expectPrinted(t, "import * as x from 'x' with {x: 'y'}", "import * as x from \"x\" with { x: \"y\" };\n")
+	expectPrinted(t, "import {} from 'x' with {x: 'y'}", "import {} from \"x\" with { x: \"y\" };\n")
+	expectPrinted(t, "export {} from 'x' with {x: 'y'}", "export {} from \"x\" with { x: \"y\" };\n")
+	expectPrinted(t, "export * from 'x' with {x: 'y'}", "export * from \"x\" with { x: \"y\" };\n")
+
+	expectPrinted(t, "import(x ? 'y' : 'z', {with: {}})",
+		"x ? import(\"y\", { with: {} }) : import(\"z\", { with: {} });\n")
+	expectPrinted(t, "import(x ? 'y' : 'z', {with: {a: 'b'}})",
+		"x ? import(\"y\", { with: { a: \"b\" } }) : import(\"z\", { with: { a: \"b\" } });\n")
+	expectPrintedMangle(t, "import(x ? 'y' : 'z', {with: {'a': 'b'}})",
+		"x ? import(\"y\", { with: { a: \"b\" } }) : import(\"z\", { with: { a: \"b\" } });\n")
+	expectPrinted(t, "import(x ? 'y' : 'z', {with: []})", "import(x ? \"y\" : \"z\", { with: [] });\n")
+	expectPrinted(t, "import(x ? 'y' : 'z', {with: {x: 1}})", "import(x ? \"y\" : \"z\", { with: { x: 1 } });\n")
+
+	// Import attributes and import assertions are supported separately
+	expectPrintedTarget(t, 2015, "import 'x' with {x: 'y'}", "import \"x\";\n")
+	expectPrintedTarget(t, 2015, "import(x, {with: {x: 'y'}})", "import(x);\n")
+	expectPrintedTarget(t, 2015, "import(x ? 'y' : 'z', {with: {x: 'y'}})", "x ? import(\"y\") : import(\"z\");\n")
+	expectParseErrorTarget(t, 2015, "import(x ? 'y' : 'z', {with: {x: foo()}})",
+		"<stdin>: ERROR: Using an arbitrary value as the second argument to \"import()\" is not possible in the configured target environment\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ImportAssertions, "import 'x' with {x: 'y'}", "import \"x\" with { x: \"y\" };\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ImportAssertions, "import 'x' assert {x: 'y'}", "import \"x\";\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ImportAttributes, "import 'x' with {x: 'y'}", "import \"x\";\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ImportAttributes, "import 'x' assert {x: 'y'}", "import \"x\" assert { x: \"y\" };\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ImportAttributes, "import(x ? 'y' : 'z', {with: {x: 'y'}})", "x ? import(\"y\") : import(\"z\");\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ImportAttributes, "import(x ? 'y' : 'z', {foo: bar})", "import(x ? \"y\" : \"z\", { foo: bar });\n")
+	expectPrintedWithUnsupportedFeatures(t, compat.ImportAssertions|compat.ImportAttributes, "import(x ? 'y' : 'z', {foo: 1})", "import(x ? \"y\" : \"z\");\n")
+
+	// Make sure there are no errors when bundling is disabled
+	expectParseError(t, "import { foo } from 'x' with {type: 'json'}", "")
+	expectParseError(t, "export { foo } from 'x' with {type: 'json'}", "")
+}
+
 func TestES5(t *testing.T) {
 	// Do not generate "let" when emulating block-level function declarations and targeting ES5
 	expectPrintedTarget(t, 2015, "if (1) function f() {}", "if (1) {\n  let f = function() {\n  };\n  var f = f;\n}\n")
 	expectPrintedTarget(t, 5, "if (1) function f() {}", "if (1) {\n  var f = function() {\n  };\n  var f = f;\n}\n")
 
-	expectParseErrorTarget(t, 5, "function foo(x = 0) {}",
-		"<stdin>: ERROR: Transforming default arguments to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(function(x = 0) {})",
-		"<stdin>: ERROR: Transforming default arguments to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(x = 0) => {}",
-		"<stdin>: ERROR: Transforming default arguments to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function foo(...x) {}",
-		"<stdin>: ERROR: Transforming rest arguments to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(function(...x) {})",
-		"<stdin>: ERROR: Transforming rest arguments to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(...x) => {}",
-		"<stdin>: ERROR: Transforming rest arguments to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "foo(...x)",
-		"<stdin>: ERROR: Transforming rest arguments to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "[...x]",
-		"<stdin>: ERROR: Transforming array spread to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "for (var x of y) ;",
-		"<stdin>: ERROR: Transforming for-of loops to the configured target environment is not supported yet\n")
+	expectPrintedTarget(t, 5, "function foo(x = 0) {}", "function foo() {\n  var x = arguments[0] === void 0 ? 0 : arguments[0];\n}\n")
+	expectPrintedTarget(t, 5, "(function(x = 0) {})", "(function() {\n  var x = arguments[0] === void 0 ? 0 : arguments[0];\n});\n")
+	expectPrintedTarget(t, 5, "(x = 0) => {}", "(function() {\n  var x = arguments[0] === void 0 ? 0 : arguments[0];\n});\n")
+	expectPrintedTarget(t, 5, "function foo(...x) {}", "function foo() {\n  var x = __slice(arguments);\n}\n")
+	expectPrintedTarget(t, 5, "(function(...x) {})", "(function() {\n  var x = __slice(arguments);\n});\n")
+	expectPrintedTarget(t, 5, "(...x) => {}", "(function() {\n  var x = __slice(arguments);\n});\n")
+	expectPrintedTarget(t, 5, "foo(...x)", "foo.apply(void 0, __toArray(x));\n")
+	expectPrintedTarget(t, 5, "[...x]", "__toArray(x);\n")
+	expectPrintedTarget(t, 5, "for (var x of y) ;", "try {\n  for (var _a = __iterator(y), _b, _c, _d; _b = !(_c = _a.next()).done; _b = false) {\n    var x = _c.value;\n    ;\n  }\n} catch (_c) {\n  _d = [_c];\n} finally {\n  try {\n    _b && (_c = _a.return) && _c.call(_a);\n  } finally {\n    if (_d)\n      throw _d[0];\n  }\n}\n")
 	expectPrintedTarget(t, 5, "({ x })", "({ x: x });\n")
-	expectParseErrorTarget(t, 5, "({ [x]: y })",
-		"<stdin>: ERROR: Transforming object literal extensions to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "({ x() {} });",
-		"<stdin>: ERROR: Transforming object literal extensions to the configured target environment is not supported yet\n")
+	expectPrintedTarget(t, 5, "({ [x]: y })", "var _a;\n_a = {}, _a[x] = y, _a;\n")
+	expectPrintedTarget(t, 5, "({ x() {} });", "({ x: function() {\n} });\n")
 	expectParseErrorTarget(t, 5, "({ get x() {} });", "")
 	expectParseErrorTarget(t, 5, "({ set x(x) {} });", "")
-	expectParseErrorTarget(t, 5, "({ get [x]() {} });",
-		"<stdin>: ERROR: Transforming object literal extensions to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "({ set [x](x) {} });",
-		"<stdin>: ERROR: Transforming object literal extensions to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function foo([]) {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function foo({}) {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(function([]) {})",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(function({}) {})",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "([]) => {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "({}) => {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "var [] = [];",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "var {} = {};",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "([] = []);",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "({} = {});",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "for ([] in []);",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "for ({} in []);",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function foo([...x]) {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(function([...x]) {})",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "([...x]) => {}",
-		"<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function foo([...[x]]) {}",
-		`<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming non-identifier array rest patterns to the configured target environment is not supported yet
-`)
-	expectParseErrorTarget(t, 5, "(function([...[x]]) {})",
-		`<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming non-identifier array rest patterns to the configured target environment is not supported yet
-`)
-	expectParseErrorTarget(t, 5, "([...[x]]) => {}",
-		`<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet
-<stdin>: ERROR: Transforming non-identifier array rest patterns to the configured target environment is not supported yet
-`)
-	expectParseErrorTarget(t, 5, "([...[x]])",
-		"<stdin>: ERROR: Transforming array spread to the configured target environment is not supported yet\n")
+	expectPrintedTarget(t, 5, "({ get [x]() {} });", "var _b;\n_b = {}, __defAccessor(_b, x, function() {\n}), _b;\n")
+	expectPrintedTarget(t, 5, "({ set [x](x) {} });", "var _b;\n_b = {}, __defAccessor(_b, x, function(x) {\n}, true), _b;\n")
+	expectPrintedTarget(t, 5, "function foo([]) {}", "function foo(_a) {\n  var _b = __toArray(_a, 0);\n}\n")
+	expectPrintedTarget(t, 5, "function foo({}) {}", "function foo(_a) {\n  var _b = __requireObject(_a);\n}\n")
+	expectPrintedTarget(t, 5, "(function([]) {})", "(function(_a) {\n  var _b = __toArray(_a, 0);\n});\n")
+	expectPrintedTarget(t, 5, "(function({}) {})", "(function(_a) {\n  var _b = __requireObject(_a);\n});\n")
+	expectPrintedTarget(t, 5, "([]) => {}", "(function(_a) {\n  var _b = __toArray(_a, 0);\n});\n")
+	expectPrintedTarget(t, 5, "({}) => {}", "(function(_a) {\n  var _b = __requireObject(_a);\n});\n")
+	expectPrintedTarget(t, 5, "var [] = [];", "var _a = __toArray([], 0);\n")
+	expectPrintedTarget(t, 5, "var {} = {};", "var _a = __requireObject({});\n")
+	expectPrintedTarget(t, 5, "([] = []);", "var _a;\n_a = __toArray([], 0);\n")
+	expectPrintedTarget(t, 5, "({} = {});", "var _a;\n_a = __requireObject({});\n")
+	expectPrintedTarget(t, 5, "for ([] in []);", "var _a, _b;\nfor (_a in []) {\n  _b = __toArray(_a, 0);\n  ;\n}\n")
+	expectPrintedTarget(t, 5, "for ({} in []);", "var _a, _b;\nfor (_a in []) {\n  _b = __requireObject(_a);\n  ;\n}\n")
+	expectPrintedTarget(t, 5, "function foo([...x]) {}", "function foo(_a) {\n  var x = __toArray(_a);\n}\n")
+	expectPrintedTarget(t, 5, "(function([...x]) {})", "(function(_a) {\n  var x = __toArray(_a);\n});\n")
+	expectPrintedTarget(t, 5, "([...x]) => {}", "(function(_a) {\n  var x = __toArray(_a);\n});\n")
+	expectPrintedTarget(t, 5, "function foo([...[x]]) {}", "function foo(_a) {\n  var x = __toArray(__toArray(_a), 1)[0];\n}\n")
+	expectPrintedTarget(t, 5, "(function([...[x]]) {})", "(function(_a) {\n  var x = __toArray(__toArray(_a), 1)[0];\n});\n")
+	expectPrintedTarget(t, 5, "([...[x]]) => {}", "(function(_a) {\n  var x = __toArray(__toArray(_a), 1)[0];\n});\n")
+	expectPrintedTarget(t, 5, "([...[x]])", "__toArray([x]);\n")
 	expectPrintedTarget(t, 5, "`abc`;", "\"abc\";\n")
 	expectPrintedTarget(t, 5, "`a${b}`;", "\"a\".concat(b);\n")
 	expectPrintedTarget(t, 5, "`${a}b`;", "\"\".concat(a, \"b\");\n")
@@ -5818,27 +6105,17 @@ func TestES5(t *testing.T) {
 	expectPrintedTarget(t, 5, "tag`a${b}\\u`;", "var _a;\ntag(_a || (_a = __template([\"a\", void 0], [\"a\", \"\\\\u\"])), b);\n")
 	expectPrintedTarget(t, 5, "tag`\\u${b}c`;", "var _a;\ntag(_a || (_a = __template([void 0, \"c\"], [\"\\\\u\", \"c\"])), b);\n")
 	expectParseErrorTarget(t, 5, "class Foo { constructor() { new.target } }",
-		"<stdin>: ERROR: Transforming class syntax to the configured target environment is not supported yet\n"+
-			"<stdin>: ERROR: Transforming object literal extensions to the configured target environment is not supported yet\n"+
-			"<stdin>: ERROR: Transforming new.target to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "const x = 1;",
-		"<stdin>: ERROR: Transforming const to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "let x = 2;",
-		"<stdin>: ERROR: Transforming let to the configured target environment is not supported yet\n")
+		"<stdin>: ERROR: Transforming new.target to the configured target environment is not supported yet\n")
+	expectPrintedTarget(t, 5, "const x = 1;", "var x = 1;\n")
+	expectPrintedTarget(t, 5, "let x = 2;", "var x = 2;\n")
 	expectPrintedTarget(t, 5, "async => foo;", "(function(async) {\n  return foo;\n});\n")
 	expectPrintedTarget(t, 5, "x => x;", "(function(x) {\n  return x;\n});\n")
-	expectParseErrorTarget(t, 5, "async () => foo;",
-		"<stdin>: ERROR: Transforming async functions to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "class Foo {}",
-		"<stdin>: ERROR: Transforming class syntax to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(class {});",
-		"<stdin>: ERROR: Transforming class syntax to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "function* gen() {}",
-		"<stdin>: ERROR: Transforming generator functions to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "(function* () {});",
-		"<stdin>: ERROR: Transforming generator functions to the configured target environment is not supported yet\n")
-	expectParseErrorTarget(t, 5, "({ *foo() {} });",
-		"<stdin>: ERROR: Transforming generator functions to the configured target environment is not supported yet\n")
+	expectPrintedTarget(t, 5, "async () => foo;", "(function() {\n  return __async(this, null, function() {\n    return __makeGenerator(this, function(_a) {\n      return [2, foo];\n    });\n  });\n});\n")
+	expectPrintedTarget(t, 5, "class Foo {}", "var Foo = /* @__PURE__ */ function() {\n  function Foo() {\n    __classCallCheck(this, Foo);\n  }\n  return Foo;\n}();\n")
+	expectPrintedTarget(t, 5, "(class {});", "/* @__PURE__ */ (function() {\n  function _class() {\n    __classCallCheck(this, _class);\n  }\n  return _class;\n})();\n")
+	expectPrintedTarget(t, 5, "function* gen() {}", "function gen() {\n  return __makeGenerator(this, function(_a) {\n    return [2];\n  });\n}\n")
+	expectPrintedTarget(t, 5, "(function* () {});", "(function() {\n  return __makeGenerator(this, function(_a) {\n    return [2];\n  });\n});\n")
+	expectPrintedTarget(t, 5, "({ *foo() {} });", "({ foo: function() {\n  return __makeGenerator(this, function(_b) {\n    return [2];\n  });\n} });\n")
 }
 
 func TestASCIIOnly(t *testing.T) {
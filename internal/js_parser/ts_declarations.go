@@ -0,0 +1,1553 @@
+package js_parser
+
+// This file generates TypeScript declaration files (i.e. ".d.ts" files). The
+// parser normally throws away all type annotations, so when declarations are
+// enabled it also records the source ranges of those annotations in a few side
+// tables. After parsing (but before visiting, which mutates the AST), these
+// tables are combined with the AST to form declarations.
+//
+// This deliberately doesn't do any type inference since esbuild doesn't have a
+// type checker. It only supports the subset of TypeScript that's allowed by
+// TypeScript's "isolatedDeclarations" setting, which requires exported values
+// to have explicit type annotations. Anything that would need inference is
+// reported as an error instead.
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/dts_ast"
+	"github.com/evanw/esbuild/internal/dts_lexer"
+	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+type dtsRecorder struct {
+	// These are the statements in the most recently parsed module or namespace
+	// scope. Type-only statements are included so their text can be copied.
+	stmts      []dtsStmt
+	namespaces map[logger.Loc][]dtsStmt
+
+	// Type annotations for arguments and variables, keyed by binding location
+	types     map[logger.Loc]logger.Range
+	optionals map[logger.Loc]bool
+
+	// Function signatures are keyed by the location of the "(" token
+	returnTypes map[logger.Loc]logger.Range
+	thisArgs    map[logger.Loc]logger.Range
+	arrowParens map[logger.Loc]logger.Loc
+
+	// Type parameters are keyed by the location of the token after the ">"
+	typeParams map[logger.Loc]logger.Range
+
+	// Parameter properties are mapped to the start of their modifiers
+	ctorFields map[logger.Loc]logger.Loc
+
+	// Type assertions such as "x as T" are keyed by expression location
+	typeAssertions map[logger.Loc]dtsTypeAssertion
+
+	// Classes are keyed by body location and members by start location
+	classes map[logger.Loc]*dtsClass
+	members map[logger.Loc]*dtsMemberInfo
+}
+
+type dtsTypeAssertion struct {
+	data      js_ast.E // Used to tell apart expressions at the same location
+	typeRange logger.Range
+	isConst   bool
+}
+
+type dtsStmt struct {
+	data       js_ast.S
+	r          logger.Range
+	docComment logger.Range
+}
+
+type dtsClass struct {
+	heritage logger.Range
+	members  []dtsMember
+}
+
+type dtsMember struct {
+	r          logger.Range
+	docComment logger.Range
+	isKept     bool
+}
+
+type dtsMemberInfo struct {
+	key               logger.Range
+	fieldType         logger.Range
+	isOptional        bool
+	isMethodSignature bool
+}
+
+func newDTSRecorder() *dtsRecorder {
+	return &dtsRecorder{
+		namespaces:     make(map[logger.Loc][]dtsStmt),
+		types:          make(map[logger.Loc]logger.Range),
+		optionals:      make(map[logger.Loc]bool),
+		returnTypes:    make(map[logger.Loc]logger.Range),
+		thisArgs:       make(map[logger.Loc]logger.Range),
+		arrowParens:    make(map[logger.Loc]logger.Loc),
+		typeParams:     make(map[logger.Loc]logger.Range),
+		ctorFields:     make(map[logger.Loc]logger.Loc),
+		typeAssertions: make(map[logger.Loc]dtsTypeAssertion),
+		classes:        make(map[logger.Loc]*dtsClass),
+		members:        make(map[logger.Loc]*dtsMemberInfo),
+	}
+}
+
+// Returns the range from the start location to the end of the previous token
+func (p *parser) dtsRangeFrom(start logger.Loc) logger.Range {
+	end := p.lexer.PrevTokenEnd().Start
+	if end < start.Start {
+		return logger.Range{Loc: start}
+	}
+	return logger.Range{Loc: start, Len: end - start.Start}
+}
+
+// Documentation comments are copied over to the declarations
+func (p *parser) dtsDocComment() logger.Range {
+	if p.dts != nil {
+		if n := len(p.lexer.CommentsBeforeToken); n > 0 {
+			if comment := p.lexer.CommentsBeforeToken[n-1]; strings.HasPrefix(p.source.TextForRange(comment), "/**") {
+				return comment
+			}
+		}
+	}
+	return logger.Range{}
+}
+
+// This is called after the arguments of an arrow function have been parsed
+// and after its return type (if any) has been skipped
+func (p *parser) dtsRecordArrow(loc logger.Loc, openParenLoc logger.Loc, returnTypeLoc logger.Loc) {
+	p.dts.arrowParens[loc] = openParenLoc
+	if p.source.Contents[returnTypeLoc.Start] == ':' {
+		p.dts.returnTypes[openParenLoc] = p.dtsRangeFrom(logger.Loc{Start: returnTypeLoc.Start + 1})
+	}
+}
+
+type dtsError struct {
+	text string
+	r    logger.Range
+}
+
+// Each top-level declaration becomes a candidate. Unexported candidates are
+// only kept if something that's kept references them. Errors are only
+// reported for candidates that are kept.
+type dtsCandidate struct {
+	stmt       dts_ast.Stmt
+	names      []string
+	errors     []dtsError
+	path       dts_lexer.Token
+	isExported bool
+	isKept     bool
+}
+
+type dtsGenerator struct {
+	p          *parser
+	errors     []dtsError
+	overloads  map[string]bool
+	isAmbient  bool
+	isModule   bool
+	candidates []dtsCandidate
+}
+
+func (p *parser) generateDeclarations() *dts_ast.AST {
+	g := dtsGenerator{p: p}
+	g.visitStmts(p.dts.stmts)
+
+	// Scripts declare globals, so everything in them is kept
+	referenced := make(map[string]bool)
+	for i := range g.candidates {
+		c := &g.candidates[i]
+		if _, ok := c.stmt.Data.(*dts_ast.SImport); !ok && (c.isExported || len(c.names) == 0 || !g.isModule) {
+			c.isKept = true
+			dtsMarkReferences(c.stmt, referenced)
+		}
+	}
+
+	// Keep unexported declarations that are referenced by kept declarations
+	for {
+		changed := false
+		for i := range g.candidates {
+			c := &g.candidates[i]
+			if c.isKept {
+				continue
+			}
+			if _, ok := c.stmt.Data.(*dts_ast.SImport); ok {
+				continue
+			}
+			for _, name := range c.names {
+				if referenced[name] {
+					c.isKept = true
+					changed = true
+					dtsMarkReferences(c.stmt, referenced)
+					break
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	tree := &dts_ast.AST{}
+	hasModuleSyntax := false
+	addImportRecord := func(path dts_lexer.Token) uint32 {
+		index := uint32(len(tree.ImportRecords))
+		tree.ImportRecords = append(tree.ImportRecords, ast.ImportRecord{
+			Kind:  ast.ImportTypes,
+			Path:  logger.Path{Text: path.Value},
+			Range: path.Range,
+		})
+		return index
+	}
+	for _, c := range g.candidates {
+		if s, ok := c.stmt.Data.(*dts_ast.SImport); ok {
+			// Remove unused imports
+			if !referenced[s.DefaultName] {
+				s.DefaultName = ""
+			}
+			if !referenced[s.NamespaceName] {
+				s.NamespaceName = ""
+			}
+			if s.Items != nil {
+				items := []dts_ast.ClauseItem{}
+				for _, item := range s.Items {
+					if referenced[item.Name] {
+						items = append(items, item)
+					}
+				}
+				s.Items = items
+				if len(items) == 0 {
+					s.Items = nil
+				}
+			}
+			if s.DefaultName == "" && s.NamespaceName == "" && s.Items == nil {
+				continue
+			}
+			s.ImportRecordIndex = addImportRecord(c.path)
+			hasModuleSyntax = true
+		} else if !c.isKept {
+			continue
+		} else {
+			switch s := c.stmt.Data.(type) {
+			case *dts_ast.SExportFrom:
+				s.ImportRecordIndex = addImportRecord(c.path)
+			case *dts_ast.SExportStar:
+				s.ImportRecordIndex = addImportRecord(c.path)
+			}
+			if _, ok := c.stmt.Data.(*dts_ast.SDecl); !ok || c.isExported {
+				hasModuleSyntax = true
+			}
+		}
+		for _, err := range c.errors {
+			p.log.AddError(&p.tracker, err.r, err.text)
+		}
+		tree.Stmts = append(tree.Stmts, c.stmt)
+	}
+
+	// Make sure the declaration file is still a module if the source was. The
+	// top-level declarations would be global otherwise.
+	if g.isModule && !hasModuleSyntax {
+		tree.Stmts = append(tree.Stmts, dts_ast.Stmt{Data: &dts_ast.SExportClause{}})
+	}
+	return tree
+}
+
+// This returns true for ".d.ts" files, which only contain types
+func IsDeclarationFile(path string) bool {
+	return strings.HasSuffix(path, ".d.ts") || strings.HasSuffix(path, ".d.mts") || strings.HasSuffix(path, ".d.cts")
+}
+
+func dtsMarkReferences(stmt dts_ast.Stmt, referenced map[string]bool) {
+	switch s := stmt.Data.(type) {
+	case *dts_ast.SDecl:
+		for _, t := range dts_lexer.Tokenize(s.Text) {
+			if t.IsReference {
+				referenced[t.Value] = true
+			}
+		}
+
+	case *dts_ast.SExportClause:
+		for _, item := range s.Items {
+			referenced[item.Name] = true
+		}
+
+	case *dts_ast.SExportDefault:
+		referenced[s.Name] = true
+	}
+}
+
+func (g *dtsGenerator) addCandidate(loc logger.Loc, s dts_ast.S, names []string, isExported bool) {
+	switch s.(type) {
+	case *dts_ast.SImport, *dts_ast.SExportClause, *dts_ast.SExportFrom, *dts_ast.SExportStar, *dts_ast.SExportDefault:
+		g.isModule = true
+	default:
+		if isExported {
+			g.isModule = true
+		}
+	}
+	g.candidates = append(g.candidates, dtsCandidate{
+		stmt:       dts_ast.Stmt{Loc: loc, Data: s},
+		names:      names,
+		errors:     g.errors,
+		isExported: isExported,
+	})
+	g.errors = nil
+}
+
+func (g *dtsGenerator) addError(r logger.Range, text string) {
+	g.errors = append(g.errors, dtsError{r: r, text: text + " when generating declarations"})
+}
+
+func (g *dtsGenerator) visitStmts(stmts []dtsStmt) {
+	g.overloads = make(map[string]bool)
+	for _, stmt := range stmts {
+		g.visitStmt(stmt)
+	}
+}
+
+func (g *dtsGenerator) visitStmt(stmt dtsStmt) {
+	p := g.p
+	var text string
+	var names []string
+	isExported := false
+
+	switch s := stmt.data.(type) {
+	case *js_ast.STypeScript, *js_ast.SImport, *js_ast.SExportClause, *js_ast.SExportFrom, *js_ast.SExportStar, *js_ast.SExportEquals:
+		g.visitVerbatimStmt(stmt)
+		return
+
+	case *js_ast.SLocal:
+		// "import x = require('y')" is parsed as a variable declaration
+		if tokens := dts_lexer.Tokenize(g.text(stmt.r)); len(tokens) > 0 && (tokens[0].Value == "import" || (len(tokens) > 1 && tokens[1].Value == "import")) {
+			g.visitVerbatimStmt(stmt)
+			return
+		}
+		if !s.IsExport && g.isAmbient {
+			return
+		}
+		text, names = g.localDecl(s)
+		isExported = s.IsExport
+
+	case *js_ast.SFunction:
+		name := g.nameAt(s.Fn.Name.Loc)
+		if g.overloads[name] {
+			// The implementation of an overloaded function is omitted
+			return
+		}
+		if !s.IsExport && g.isAmbient {
+			return
+		}
+		text = "function " + name + g.fnSignature(s.Fn.OpenParenLoc, s.Fn.Args, s.Fn.HasRestArg,
+			js_lexer.RangeOfIdentifier(p.source, s.Fn.Name.Loc), dtsFnNormal) + ";"
+		names = []string{name}
+		isExported = s.IsExport
+
+	case *js_ast.SClass:
+		if !s.IsExport && g.isAmbient {
+			return
+		}
+		name := g.nameAt(s.Class.Name.Loc)
+		text = g.classDecl(&s.Class, name, g.isAbstractClass(stmt.r, s.Class.ClassKeyword.Loc))
+		names = []string{name}
+		isExported = s.IsExport
+
+	case *js_ast.SEnum:
+		if !s.IsExport && g.isAmbient {
+			return
+		}
+
+		// Enums are copied verbatim, but without the "export" keyword for now
+		text = g.reindent(stmt.r, g.enumText(stmt.r, s))
+		if tokens := dts_lexer.Tokenize(text); s.IsExport && len(tokens) > 1 {
+			text = text[tokens[1].Range.Loc.Start:]
+		}
+		names = []string{g.nameAt(s.Name.Loc)}
+		isExported = s.IsExport
+
+	case *js_ast.SNamespace:
+		if !s.IsExport && g.isAmbient {
+			return
+		}
+		name := g.nameAt(s.Name.Loc)
+		text = g.namespaceDecl(name, p.dts.namespaces[s.Name.Loc])
+		names = []string{name}
+		isExported = s.IsExport
+
+	case *js_ast.SExportDefault:
+		g.visitExportDefault(stmt, s)
+		return
+
+	default:
+		return
+	}
+
+	if !g.isAmbient {
+		text = g.insertDeclare(text)
+	}
+	g.addCandidate(stmt.r.Loc, &dts_ast.SDecl{
+		DocComment: g.docComment(stmt.docComment),
+		Text:       text,
+		Names:      names,
+		IsExport:   isExported,
+	}, names, isExported)
+}
+
+func (g *dtsGenerator) visitExportDefault(stmt dtsStmt, s *js_ast.SExportDefault) {
+	p := g.p
+	var text string
+	var names []string
+
+	switch v := s.Value.Data.(type) {
+	case *js_ast.SFunction:
+		name := " "
+		r := logger.Range{Loc: s.DefaultName.Loc}
+		if v.Fn.Name != nil {
+			name = g.nameAt(v.Fn.Name.Loc)
+			if g.overloads[name] {
+				return
+			}
+			r = js_lexer.RangeOfIdentifier(p.source, v.Fn.Name.Loc)
+			names = []string{name}
+			name = " " + name
+		}
+		text = "function" + name + g.fnSignature(v.Fn.OpenParenLoc, v.Fn.Args, v.Fn.HasRestArg, r, dtsFnNormal) + ";"
+
+	case *js_ast.SClass:
+		name := ""
+		if v.Class.Name != nil {
+			name = g.nameAt(v.Class.Name.Loc)
+			names = []string{name}
+		}
+		text = g.classDecl(&v.Class, name, g.isAbstractClass(stmt.r, v.Class.ClassKeyword.Loc))
+
+	case *js_ast.SExpr:
+		// "export default foo"
+		if id, ok := v.Value.Data.(*js_ast.EIdentifier); ok {
+			g.addCandidate(stmt.r.Loc, &dts_ast.SExportDefault{Name: p.loadNameFromRef(id.Ref)}, nil, true)
+			return
+		}
+
+		// "export default 123"
+		typeText, ok := g.valueType(v.Value, true)
+		if !ok {
+			g.addError(g.exprRange(v.Value), "Default export must be an identifier or a value with an explicit type")
+			typeText = "any"
+		}
+		g.addCandidate(stmt.r.Loc, &dts_ast.SDecl{
+			DocComment: g.docComment(stmt.docComment),
+			Text:       "declare const _default: " + typeText + ";",
+			Names:      []string{"_default"},
+		}, []string{"_default"}, false)
+		g.addCandidate(stmt.r.Loc, &dts_ast.SExportDefault{Name: "_default"}, nil, true)
+		return
+
+	default:
+		return
+	}
+
+	g.addCandidate(stmt.r.Loc, &dts_ast.SDecl{
+		DocComment: g.docComment(stmt.docComment),
+		Text:       text,
+		Names:      names,
+		IsExport:   true,
+		IsDefault:  true,
+	}, names, true)
+}
+
+// TypeScript-only statements (and import and export statements, which may
+// contain TypeScript-only syntax) are copied over mostly verbatim
+func (g *dtsGenerator) visitVerbatimStmt(stmt dtsStmt) {
+	text := g.text(stmt.r)
+	tokens := dts_lexer.Tokenize(text)
+	if len(tokens) == 0 {
+		return
+	}
+
+	// Try to parse structured imports and exports first
+	if s, path, ok := dtsParseImportOrExport(tokens); ok {
+		g.addCandidate(stmt.r.Loc, s, nil, false)
+
+		// Make the range of the import path relative to the source
+		if path.Kind != js_lexer.TEndOfFile {
+			offset := int32(strings.Index(g.p.source.TextForRange(stmt.r), text))
+			path.Range.Loc.Start += stmt.r.Loc.Start + offset
+			g.candidates[len(g.candidates)-1].path = path
+		}
+		return
+	}
+
+	// Imports that don't declare anything (i.e. side-effect imports) are removed
+	if tokens[0].Value == "import" {
+		hasEquals := false
+		for _, t := range tokens {
+			if t.Kind == js_lexer.TEquals {
+				hasEquals = true
+				break
+			}
+		}
+		if !hasEquals {
+			g.isModule = true
+			return
+		}
+	}
+
+	// Remove the "export" and "default" keywords, except for things like
+	// "export = foo" and "export as namespace foo" which are kept as-is
+	i := 0
+	isExported := false
+	isDefault := false
+	if len(tokens) > 1 && tokens[0].Value == "export" && tokens[1].Kind != js_lexer.TEquals && tokens[1].Value != "as" {
+		isExported = true
+		i++
+		if tokens[i].Value == "default" && i+1 < len(tokens) {
+			isDefault = true
+			i++
+		}
+		text = text[tokens[i].Range.Loc.Start:]
+	}
+	for i < len(tokens) && (tokens[i].Value == "declare" || tokens[i].Value == "abstract" || tokens[i].Value == "async") {
+		i++
+	}
+
+	// Find the declared names
+	var names []string
+	if i < len(tokens) {
+		keyword := tokens[i].Value
+		i++
+		switch keyword {
+		case "const":
+			if i < len(tokens) && tokens[i].Value == "enum" {
+				i++
+			}
+		case "function":
+			if i < len(tokens) && tokens[i].Kind == js_lexer.TAsterisk {
+				i++
+			}
+		case "import":
+			// "import x = require('y')"
+			if i < len(tokens) && tokens[i].Value == "type" && i+1 < len(tokens) && tokens[i+1].Kind == js_lexer.TIdentifier {
+				i++
+			}
+		case "global", "namespace", "module", "interface", "type", "class", "enum", "let", "var":
+		default:
+			i = len(tokens)
+		}
+		if i < len(tokens) && tokens[i].Kind == js_lexer.TIdentifier {
+			names = []string{tokens[i].Value}
+			if keyword == "function" {
+				g.overloads[tokens[i].Value] = true
+			}
+		}
+	}
+
+	if !g.isAmbient && !isDefault {
+		text = g.insertDeclare(text)
+	}
+	if !strings.HasSuffix(text, ";") && !strings.HasSuffix(text, "}") {
+		text += ";"
+	}
+	g.addCandidate(stmt.r.Loc, &dts_ast.SDecl{
+		DocComment: g.docComment(stmt.docComment),
+		Text:       g.reindent(stmt.r, text),
+		Names:      names,
+		IsExport:   isExported,
+		IsDefault:  isDefault,
+	}, names, isExported)
+}
+
+// Top-level value declarations in a declaration file need a "declare" keyword
+func (g *dtsGenerator) insertDeclare(text string) string {
+	if tokens := dts_lexer.Tokenize(text); len(tokens) > 0 {
+		switch tokens[0].Value {
+		case "function", "class", "abstract", "enum", "const", "let", "var", "namespace", "module", "async":
+			// Don't insert "declare" for "module 'foo' {}"-style module names
+			return "declare " + text
+		}
+	}
+	return text
+}
+
+func (g *dtsGenerator) localDecl(s *js_ast.SLocal) (string, []string) {
+	p := g.p
+	var keyword string
+	switch s.Kind {
+	case js_ast.LocalConst:
+		keyword = "const "
+	case js_ast.LocalLet:
+		keyword = "let "
+	case js_ast.LocalVar:
+		keyword = "var "
+	default:
+		keyword = "const "
+	}
+
+	var names []string
+	var parts []string
+	for _, decl := range s.Decls {
+		id, ok := decl.Binding.Data.(*js_ast.BIdentifier)
+		if !ok {
+			g.addError(logger.Range{Loc: decl.Binding.Loc, Len: 1}, "Binding patterns can't be exported")
+			continue
+		}
+		name := p.symbols[id.Ref.InnerIndex].OriginalName
+		names = append(names, name)
+
+		if r, ok := p.dts.types[decl.Binding.Loc]; ok {
+			parts = append(parts, name+": "+g.text(r))
+		} else if decl.ValueOrNil.Data == nil {
+			parts = append(parts, name+": any")
+		} else if literal, ok := g.literal(decl.ValueOrNil); ok && s.Kind == js_ast.LocalConst && !g.hasTypeAssertion(decl.ValueOrNil) {
+			parts = append(parts, name+" = "+literal)
+		} else if typeText, ok := g.valueType(decl.ValueOrNil, false); ok {
+			parts = append(parts, name+": "+typeText)
+		} else {
+			g.addError(js_lexer.RangeOfIdentifier(p.source, decl.Binding.Loc), "Variable must have an explicit type annotation")
+			parts = append(parts, name+": any")
+		}
+	}
+	return keyword + strings.Join(parts, ", ") + ";", names
+}
+
+type dtsFnKind uint8
+
+const (
+	dtsFnNormal dtsFnKind = iota
+	dtsFnType
+	dtsFnConstructor
+	dtsFnSetter
+)
+
+// This returns "<T>(a: T): R" or "<T>(a: T) => R" for function types
+func (g *dtsGenerator) fnSignature(openParenLoc logger.Loc, args []js_ast.Arg, hasRestArg bool, r logger.Range, kind dtsFnKind) string {
+	p := g.p
+	var sb strings.Builder
+	if typeParams, ok := p.dts.typeParams[openParenLoc]; ok {
+		sb.WriteString(g.text(typeParams))
+	}
+	sb.WriteString("(")
+	needsComma := false
+	if thisArg, ok := p.dts.thisArgs[openParenLoc]; ok {
+		sb.WriteString(g.text(thisArg))
+		needsComma = true
+	}
+
+	// Arguments with default values are only optional if they are at the end
+	requiredCount := 0
+	for i, arg := range args {
+		if arg.DefaultOrNil.Data == nil && !p.dts.optionals[arg.Binding.Loc] && (!hasRestArg || i+1 < len(args)) {
+			requiredCount = i + 1
+		}
+	}
+
+	for i, arg := range args {
+		if needsComma {
+			sb.WriteString(", ")
+		}
+		needsComma = true
+		if hasRestArg && i+1 == len(args) {
+			sb.WriteString("...")
+		}
+		sb.WriteString(g.bindingText(arg.Binding))
+		if p.dts.optionals[arg.Binding.Loc] || (arg.DefaultOrNil.Data != nil && i >= requiredCount) {
+			sb.WriteString("?")
+		}
+		if typeRange, ok := p.dts.types[arg.Binding.Loc]; ok {
+			sb.WriteString(": ")
+			sb.WriteString(g.text(typeRange))
+		} else if typeText, ok := g.valueType(arg.DefaultOrNil, false); ok && arg.DefaultOrNil.Data != nil {
+			sb.WriteString(": ")
+			sb.WriteString(typeText)
+		} else {
+			g.addError(g.bindingRange(arg.Binding), "Parameter must have an explicit type annotation")
+		}
+	}
+	sb.WriteString(")")
+
+	switch kind {
+	case dtsFnConstructor, dtsFnSetter:
+		return sb.String()
+	}
+
+	returnType, ok := p.dts.returnTypes[openParenLoc]
+	if !ok {
+		g.addError(r, "Function must have an explicit return type annotation")
+		returnType = logger.Range{}
+	}
+	if kind == dtsFnType {
+		sb.WriteString(" => ")
+	} else {
+		sb.WriteString(": ")
+	}
+	if ok {
+		sb.WriteString(g.text(returnType))
+	} else {
+		sb.WriteString("any")
+	}
+	return sb.String()
+}
+
+// Bindings in declarations don't have default values
+func (g *dtsGenerator) bindingText(binding js_ast.Binding) string {
+	switch b := binding.Data.(type) {
+	case *js_ast.BIdentifier:
+		return g.p.symbols[b.Ref.InnerIndex].OriginalName
+
+	case *js_ast.BArray:
+		parts := make([]string, len(b.Items))
+		for i, item := range b.Items {
+			if item.Binding.Data != nil {
+				if _, ok := item.Binding.Data.(*js_ast.BMissing); !ok {
+					parts[i] = g.bindingText(item.Binding)
+				}
+			}
+		}
+		if b.HasSpread && len(parts) > 0 {
+			parts[len(parts)-1] = "..." + parts[len(parts)-1]
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+
+	case *js_ast.BObject:
+		if len(b.Properties) == 0 {
+			return "{}"
+		}
+		parts := make([]string, len(b.Properties))
+		for i, property := range b.Properties {
+			value := g.bindingText(property.Value)
+			if property.IsSpread {
+				parts[i] = "..." + value
+			} else if key, ok := property.Key.Data.(*js_ast.EString); ok && !property.IsComputed {
+				keyText := helpers.UTF16ToString(key.Value)
+				if keyText == value {
+					parts[i] = value
+				} else if js_ast.IsIdentifier(keyText) {
+					parts[i] = keyText + ": " + value
+				} else {
+					parts[i] = string(helpers.QuoteForJSON(keyText, false)) + ": " + value
+				}
+			} else {
+				parts[i] = g.text(g.exprRange(property.Key)) + ": " + value
+			}
+		}
+		return "{ " + strings.Join(parts, ", ") + " }"
+	}
+	return ""
+}
+
+func (g *dtsGenerator) bindingRange(binding js_ast.Binding) logger.Range {
+	if _, ok := binding.Data.(*js_ast.BIdentifier); ok {
+		return js_lexer.RangeOfIdentifier(g.p.source, binding.Loc)
+	}
+	return logger.Range{Loc: binding.Loc, Len: 1}
+}
+
+func (g *dtsGenerator) exprRange(expr js_ast.Expr) logger.Range {
+	switch expr.Data.(type) {
+	case *js_ast.EIdentifier:
+		return js_lexer.RangeOfIdentifier(g.p.source, expr.Loc)
+	case *js_ast.EString:
+		if r := g.p.source.RangeOfString(expr.Loc); r.Len > 0 {
+			return r
+		}
+		return js_lexer.RangeOfIdentifier(g.p.source, expr.Loc)
+	}
+	return g.p.source.RangeOfNumber(expr.Loc)
+}
+
+// Returns true if the expression is followed by a non-const "as" assertion,
+// in which case the asserted type is used instead of the literal type
+func (g *dtsGenerator) hasTypeAssertion(expr js_ast.Expr) bool {
+	assertion, ok := g.p.dts.typeAssertions[expr.Loc]
+	return ok && assertion.data == expr.Data && !assertion.isConst
+}
+
+// The type of a literal value is the literal itself if the binding can't be
+// reassigned, and the widened primitive type otherwise. Functions with fully
+// annotated signatures can also be used without a type annotation, as can
+// object literals whose property values can be typed this way. Array literals
+// need an "as const" assertion because their element type can't be inferred
+// without a type checker.
+func (g *dtsGenerator) valueType(expr js_ast.Expr, isConst bool) (string, bool) {
+	return g.valueTypeAt(expr, isConst, 0)
+}
+
+func (g *dtsGenerator) valueTypeAt(expr js_ast.Expr, isConst bool, depth int) (string, bool) {
+	if assertion, ok := g.p.dts.typeAssertions[expr.Loc]; ok && assertion.data == expr.Data {
+		if assertion.isConst {
+			return g.constType(expr, depth)
+		}
+		return g.text(assertion.typeRange), true
+	}
+
+	if isConst {
+		if literal, ok := g.literal(expr); ok {
+			return literal, true
+		}
+	}
+
+	switch e := expr.Data.(type) {
+	case *js_ast.EString:
+		return "string", true
+
+	case *js_ast.ETemplate:
+		if e.TagOrNil.Data == nil && len(e.Parts) == 0 {
+			return "string", true
+		}
+
+	case *js_ast.ENumber:
+		return "number", true
+
+	case *js_ast.EUnary:
+		if _, ok := e.Value.Data.(*js_ast.ENumber); ok && (e.Op == js_ast.UnOpNeg || e.Op == js_ast.UnOpPos) {
+			return "number", true
+		}
+
+	case *js_ast.EBigInt:
+		return "bigint", true
+
+	case *js_ast.EBoolean:
+		return "boolean", true
+
+	case *js_ast.EObject:
+		return g.objectType(e, false, depth), true
+
+	case *js_ast.EArrow:
+		if openParenLoc, ok := g.p.dts.arrowParens[expr.Loc]; ok {
+			return g.fnSignature(openParenLoc, e.Args, e.HasRestArg, logger.Range{Loc: openParenLoc, Len: 1}, dtsFnType), true
+		}
+
+	case *js_ast.EFunction:
+		r := logger.Range{Loc: e.Fn.OpenParenLoc, Len: 1}
+		if e.Fn.Name != nil {
+			r = js_lexer.RangeOfIdentifier(g.p.source, e.Fn.Name.Loc)
+		}
+		return g.fnSignature(e.Fn.OpenParenLoc, e.Fn.Args, e.Fn.HasRestArg, r, dtsFnType), true
+	}
+
+	return "", false
+}
+
+// Values in an "as const" context have readonly literal types
+func (g *dtsGenerator) constType(expr js_ast.Expr, depth int) (string, bool) {
+	if g.hasTypeAssertion(expr) {
+		return g.valueTypeAt(expr, true, depth)
+	}
+	if literal, ok := g.literal(expr); ok {
+		return literal, true
+	}
+
+	switch e := expr.Data.(type) {
+	case *js_ast.EArray:
+		parts := make([]string, 0, len(e.Items))
+		for _, item := range e.Items {
+			if _, ok := item.Data.(*js_ast.ESpread); ok {
+				g.addError(logger.Range{Loc: item.Loc, Len: 3}, "Array spread can't be inferred")
+				parts = append(parts, "...any[]")
+				continue
+			}
+			if _, ok := item.Data.(*js_ast.EMissing); ok {
+				parts = append(parts, "undefined")
+				continue
+			}
+			typeText, ok := g.constType(item, depth)
+			if !ok {
+				g.addError(g.exprRange(item), "Array element must have an explicit type")
+				typeText = "any"
+			}
+			parts = append(parts, typeText)
+		}
+		return "readonly [" + strings.Join(parts, ", ") + "]", true
+
+	case *js_ast.EObject:
+		return g.objectType(e, true, depth), true
+
+	case *js_ast.EIdentifier:
+		// "undefined" isn't a literal in the AST because it can be shadowed
+		if g.p.loadNameFromRef(e.Ref) == "undefined" {
+			return "undefined", true
+		}
+	}
+
+	return g.valueTypeAt(expr, true, depth)
+}
+
+// Object literals become multi-line type literals. Properties are readonly
+// in an "as const" context but methods and accessors never are.
+func (g *dtsGenerator) objectType(object *js_ast.EObject, isConst bool, depth int) string {
+	if len(object.Properties) == 0 {
+		return "{}"
+	}
+	indent := strings.Repeat("    ", depth+1)
+	var sb strings.Builder
+	sb.WriteString("{\n")
+	for _, property := range object.Properties {
+		if property.Kind == js_ast.PropertySpread {
+			g.addError(logger.Range{Loc: property.Loc, Len: 3}, "Object spread can't be inferred")
+			continue
+		}
+		keyRange := g.exprRange(property.Key)
+		if property.Flags.Has(js_ast.PropertyIsComputed) {
+			g.addError(logger.Range{Loc: property.Key.Loc, Len: 1}, "Computed property name can't be inferred")
+			continue
+		}
+		if property.Flags.Has(js_ast.PropertyWasShorthand) {
+			g.addError(keyRange, "Shorthand property can't be inferred")
+			continue
+		}
+
+		var keyText string
+		switch key := property.Key.Data.(type) {
+		case *js_ast.EString:
+			keyText = helpers.UTF16ToString(key.Value)
+			if !js_ast.IsIdentifier(keyText) {
+				keyText = string(helpers.QuoteForJSON(keyText, false))
+			}
+		case *js_ast.ENumber:
+			keyText, _ = dtsNumber(key.Value)
+		}
+
+		var text string
+		if property.Flags.Has(js_ast.PropertyIsMethod) {
+			fn := property.ValueOrNil.Data.(*js_ast.EFunction).Fn
+			switch property.Kind {
+			case js_ast.PropertyGet:
+				text = "get " + keyText + g.fnSignature(fn.OpenParenLoc, fn.Args, fn.HasRestArg, keyRange, dtsFnNormal)
+			case js_ast.PropertySet:
+				text = "set " + keyText + g.fnSignature(fn.OpenParenLoc, fn.Args, fn.HasRestArg, keyRange, dtsFnSetter)
+			default:
+				text = keyText + g.fnSignature(fn.OpenParenLoc, fn.Args, fn.HasRestArg, keyRange, dtsFnNormal)
+			}
+		} else {
+			var typeText string
+			var ok bool
+			if isConst {
+				typeText, ok = g.constType(property.ValueOrNil, depth+1)
+			} else {
+				typeText, ok = g.valueTypeAt(property.ValueOrNil, false, depth+1)
+			}
+			if !ok {
+				g.addError(keyRange, "Property must have an explicit type annotation")
+				typeText = "any"
+			}
+			text = keyText + ": " + typeText
+			if isConst {
+				text = "readonly " + text
+			}
+		}
+		sb.WriteString(indent)
+		sb.WriteString(text)
+		sb.WriteString(";\n")
+	}
+	sb.WriteString(indent[4:])
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// Returns the literal type for primitive literals
+func (g *dtsGenerator) literal(expr js_ast.Expr) (string, bool) {
+	switch e := expr.Data.(type) {
+	case *js_ast.EString:
+		return string(helpers.QuoteForJSON(helpers.UTF16ToString(e.Value), false)), true
+
+	case *js_ast.ETemplate:
+		if e.TagOrNil.Data == nil && len(e.Parts) == 0 {
+			return string(helpers.QuoteForJSON(helpers.UTF16ToString(e.HeadCooked), false)), true
+		}
+
+	case *js_ast.ENumber:
+		return dtsNumber(e.Value)
+
+	case *js_ast.EUnary:
+		if e.Op == js_ast.UnOpNeg {
+			switch v := e.Value.Data.(type) {
+			case *js_ast.ENumber:
+				if text, ok := dtsNumber(v.Value); ok {
+					return "-" + text, true
+				}
+			case *js_ast.EBigInt:
+				return "-" + v.Value + "n", true
+			}
+		}
+
+	case *js_ast.EBigInt:
+		return e.Value + "n", true
+
+	case *js_ast.EBoolean:
+		if e.Value {
+			return "true", true
+		}
+		return "false", true
+
+	case *js_ast.ENull:
+		return "null", true
+	}
+
+	return "", false
+}
+
+func dtsNumber(value float64) (string, bool) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "", false
+	}
+	if value == math.Trunc(value) && math.Abs(value) < 1e21 {
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64), true
+}
+
+func (g *dtsGenerator) isAbstractClass(r logger.Range, classKeywordLoc logger.Loc) bool {
+	if classKeywordLoc.Start < r.Loc.Start {
+		return false
+	}
+	for _, t := range dts_lexer.Tokenize(g.p.source.Contents[r.Loc.Start:classKeywordLoc.Start]) {
+		if t.Value == "abstract" {
+			return true
+		}
+	}
+	return false
+}
+
+var dtsMemberModifiers = map[string]bool{
+	"public":    true,
+	"private":   true,
+	"protected": true,
+	"static":    true,
+	"readonly":  true,
+	"override":  true,
+	"abstract":  true,
+	"accessor":  true,
+}
+
+func (g *dtsGenerator) classDecl(class *js_ast.Class, name string, isAbstract bool) string {
+	p := g.p
+	var sb strings.Builder
+	if isAbstract {
+		sb.WriteString("abstract ")
+	}
+	sb.WriteString("class")
+	if name != "" {
+		sb.WriteString(" ")
+		sb.WriteString(name)
+	}
+
+	// Type parameters are keyed by the first token after them
+	info := p.dts.classes[class.BodyLoc]
+	typeParamsLoc := class.BodyLoc
+	if info.heritage.Len > 0 {
+		typeParamsLoc = info.heritage.Loc
+	}
+	if typeParams, ok := p.dts.typeParams[typeParamsLoc]; ok {
+		sb.WriteString(g.text(typeParams))
+	}
+
+	// Declarations can't contain expressions, so the base class must be a name
+	if class.ExtendsOrNil.Data != nil && !dtsIsEntityName(class.ExtendsOrNil) {
+		g.addError(logger.Range{Loc: class.ExtendsOrNil.Loc, Len: 1}, "Extends clause must be an identifier or property access")
+	}
+	if info.heritage.Len > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(g.text(info.heritage))
+	}
+	sb.WriteString(" {\n")
+
+	// TypeScript represents all "#private" members with a single member
+	for _, member := range info.members {
+		if key := p.dts.members[member.r.Loc]; key != nil && strings.HasPrefix(g.text(key.key), "#") {
+			sb.WriteString("    #private;\n")
+			break
+		}
+	}
+
+	overloads := make(map[string]bool)
+	propertyIndex := 0
+	for _, member := range info.members {
+		key := p.dts.members[member.r.Loc]
+		var property *js_ast.Property
+		if member.isKept {
+			property = &class.Properties[propertyIndex]
+			propertyIndex++
+		}
+		if key != nil && strings.HasPrefix(g.text(key.key), "#") {
+			continue
+		}
+
+		// Members that are only types are copied over verbatim
+		if property == nil {
+			if key != nil && key.isMethodSignature {
+				overloads[g.text(key.key)] = true
+			}
+			text := g.text(member.r)
+			if strings.HasPrefix(text, "declare ") {
+				text = strings.TrimLeft(text[len("declare "):], " \t")
+			}
+			if !strings.HasSuffix(text, ";") {
+				text += ";"
+			}
+			g.writeMember(&sb, member.docComment, g.reindent(member.r, text))
+			continue
+		}
+
+		if property.Kind == js_ast.PropertyClassStaticBlock || key == nil {
+			continue
+		}
+		keyText := g.text(key.key)
+		modifiers := g.modifiersBefore(member.r.Loc, key.key.Loc)
+		isPrivate := strings.Contains(" "+modifiers, " private ")
+		optional := ""
+		if key.isOptional {
+			optional = "?"
+		}
+		keyRange := js_lexer.RangeOfIdentifier(p.source, key.key.Loc)
+
+		if property.Flags.Has(js_ast.PropertyIsMethod) {
+			fn := property.ValueOrNil.Data.(*js_ast.EFunction).Fn
+			switch {
+			case property.Kind == js_ast.PropertyNormal && overloads[keyText]:
+				// The implementation of an overloaded method is omitted
+
+			case isPrivate:
+				g.writeMember(&sb, member.docComment, modifiers+g.accessorPrefix(property.Kind)+keyText+g.privateAccessorArgs(property.Kind)+";")
+
+			case keyText == "constructor" && !property.Flags.Has(js_ast.PropertyIsStatic) && !property.Flags.Has(js_ast.PropertyIsComputed):
+				// Parameter properties become class fields
+				for _, arg := range fn.Args {
+					if !arg.IsTypeScriptCtorField {
+						continue
+					}
+					argName := g.bindingText(arg.Binding)
+					argModifiers := strings.TrimPrefix(g.modifiersBefore(p.dts.ctorFields[arg.Binding.Loc], arg.Binding.Loc), "public ")
+					if strings.Contains(" "+argModifiers, " private ") {
+						g.writeMember(&sb, logger.Range{}, argModifiers+argName+";")
+					} else if typeRange, ok := p.dts.types[arg.Binding.Loc]; ok {
+						argOptional := ""
+						if p.dts.optionals[arg.Binding.Loc] || arg.DefaultOrNil.Data != nil {
+							argOptional = "?"
+						}
+						g.writeMember(&sb, logger.Range{}, argModifiers+argName+argOptional+": "+g.text(typeRange)+";")
+					}
+				}
+				g.writeMember(&sb, member.docComment, modifiers+"constructor"+g.fnSignature(fn.OpenParenLoc, fn.Args, fn.HasRestArg, keyRange, dtsFnConstructor)+";")
+
+			case property.Kind == js_ast.PropertySet:
+				g.writeMember(&sb, member.docComment, modifiers+"set "+keyText+g.fnSignature(fn.OpenParenLoc, fn.Args, fn.HasRestArg, keyRange, dtsFnSetter)+";")
+
+			default:
+				g.writeMember(&sb, member.docComment, modifiers+g.accessorPrefix(property.Kind)+keyText+optional+g.fnSignature(fn.OpenParenLoc, fn.Args, fn.HasRestArg, keyRange, dtsFnNormal)+";")
+			}
+			continue
+		}
+
+		// This is a field
+		var text string
+		if isPrivate {
+			text = modifiers + keyText + optional + ";"
+		} else if key.fieldType.Len > 0 {
+			text = modifiers + keyText + optional + ": " + g.text(key.fieldType) + ";"
+		} else if property.InitializerOrNil.Data == nil {
+			text = modifiers + keyText + optional + ": any;"
+		} else if literal, ok := g.literal(property.InitializerOrNil); ok && strings.Contains(" "+modifiers, " readonly ") && !g.hasTypeAssertion(property.InitializerOrNil) {
+			text = modifiers + keyText + optional + " = " + literal + ";"
+		} else if typeText, ok := g.valueType(property.InitializerOrNil, false); ok {
+			text = modifiers + keyText + optional + ": " + typeText + ";"
+		} else {
+			g.addError(keyRange, "Property must have an explicit type annotation")
+			continue
+		}
+		g.writeMember(&sb, member.docComment, text)
+	}
+
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func (g *dtsGenerator) accessorPrefix(kind js_ast.PropertyKind) string {
+	switch kind {
+	case js_ast.PropertyGet:
+		return "get "
+	case js_ast.PropertySet:
+		return "set "
+	}
+	return ""
+}
+
+// TypeScript omits the types of private members, but accessors still need
+// to have the right number of arguments
+func (g *dtsGenerator) privateAccessorArgs(kind js_ast.PropertyKind) string {
+	switch kind {
+	case js_ast.PropertyGet:
+		return "()"
+	case js_ast.PropertySet:
+		return "(value)"
+	}
+	return ""
+}
+
+// Returns the modifier keywords (e.g. "private static ") between two locations
+func (g *dtsGenerator) modifiersBefore(start logger.Loc, end logger.Loc) string {
+	if end.Start <= start.Start {
+		return ""
+	}
+	var sb strings.Builder
+	for _, t := range dts_lexer.Tokenize(g.p.source.Contents[start.Start:end.Start]) {
+		if dtsMemberModifiers[t.Value] {
+			sb.WriteString(t.Value)
+			sb.WriteString(" ")
+		}
+	}
+	return sb.String()
+}
+
+func (g *dtsGenerator) writeMember(sb *strings.Builder, docComment logger.Range, text string) {
+	if comment := g.docComment(docComment); comment != "" {
+		text = comment + "\n" + text
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			sb.WriteString("    ")
+			sb.WriteString(line)
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// Members of an ambient enum without an initializer aren't numbered like the
+// members of a regular enum, so the value of each one is written out. The
+// values are computed the same way the TypeScript compiler does for constant
+// enum expressions.
+func (g *dtsGenerator) enumText(r logger.Range, s *js_ast.SEnum) string {
+	p := g.p
+	contents := p.source.Contents
+	enumName := g.nameAt(s.Name.Loc)
+	values := make(map[string]float64)
+	var sb strings.Builder
+	end := r.Loc.Start
+	next, hasNext := 0.0, true
+
+	for _, value := range s.Values {
+		name := helpers.UTF16ToString(value.Name)
+		if value.ValueOrNil.Data != nil {
+			next, hasNext = g.enumNumber(value.ValueOrNil, enumName, values)
+		} else {
+			// Find the end of the member name, which may be a string literal
+			nameRange := p.source.RangeOfString(value.Loc)
+			if nameRange.Len == 0 {
+				nameRange = js_lexer.RangeOfIdentifier(p.source, value.Loc)
+			}
+			if contents[value.Loc.Start] == '[' {
+				if i := strings.IndexByte(contents[value.Loc.Start:], ']'); i != -1 {
+					nameRange.Len = int32(i + 1)
+				}
+			}
+			if !hasNext {
+				g.addError(nameRange, "Enum member must have an initializer")
+				continue
+			}
+			text, _ := dtsNumber(next)
+			sb.WriteString(contents[end:nameRange.End()])
+			sb.WriteString(" = ")
+			sb.WriteString(text)
+			end = nameRange.End()
+		}
+		if hasNext {
+			values[name] = next
+			next++
+		}
+	}
+
+	sb.WriteString(contents[end:r.End()])
+	return strings.TrimSpace(sb.String())
+}
+
+func (g *dtsGenerator) enumNumber(expr js_ast.Expr, enumName string, values map[string]float64) (float64, bool) {
+	switch e := expr.Data.(type) {
+	case *js_ast.ENumber:
+		return e.Value, true
+
+	case *js_ast.EUnary:
+		if value, ok := g.enumNumber(e.Value, enumName, values); ok {
+			switch e.Op {
+			case js_ast.UnOpPos:
+				return value, true
+			case js_ast.UnOpNeg:
+				return -value, true
+			case js_ast.UnOpCpl:
+				return float64(^js_ast.ToInt32(value)), true
+			}
+		}
+
+	case *js_ast.EBinary:
+		if left, ok := g.enumNumber(e.Left, enumName, values); ok {
+			if right, ok := g.enumNumber(e.Right, enumName, values); ok {
+				folded := js_ast.FoldBinaryArithmetic(expr.Loc, &js_ast.EBinary{
+					Op:    e.Op,
+					Left:  js_ast.Expr{Data: &js_ast.ENumber{Value: left}},
+					Right: js_ast.Expr{Data: &js_ast.ENumber{Value: right}},
+				})
+				if number, ok := folded.Data.(*js_ast.ENumber); ok {
+					return number.Value, true
+				}
+			}
+		}
+
+	case *js_ast.EIdentifier:
+		value, ok := values[g.p.loadNameFromRef(e.Ref)]
+		return value, ok
+
+	case *js_ast.EDot:
+		if target, ok := e.Target.Data.(*js_ast.EIdentifier); ok && g.p.loadNameFromRef(target.Ref) == enumName {
+			value, ok := values[e.Name]
+			return value, ok
+		}
+	}
+
+	return 0, false
+}
+
+func (g *dtsGenerator) namespaceDecl(name string, stmts []dtsStmt) string {
+	// Generate the namespace body as a separate list of declarations
+	inner := dtsGenerator{p: g.p, isAmbient: true}
+	inner.visitStmts(stmts)
+
+	var sb strings.Builder
+	sb.WriteString("namespace ")
+	sb.WriteString(name)
+	sb.WriteString(" {\n")
+	for _, c := range inner.candidates {
+		s, ok := c.stmt.Data.(*dts_ast.SDecl)
+		if !ok || !c.isExported {
+			continue
+		}
+		g.errors = append(g.errors, c.errors...)
+		text := "export " + s.Text
+		if s.DocComment != "" {
+			text = s.DocComment + "\n" + text
+		}
+		for _, line := range strings.Split(text, "\n") {
+			if line != "" {
+				sb.WriteString("    ")
+				sb.WriteString(line)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func dtsIsEntityName(expr js_ast.Expr) bool {
+	switch e := expr.Data.(type) {
+	case *js_ast.EIdentifier:
+		return true
+	case *js_ast.EDot:
+		return dtsIsEntityName(e.Target)
+	}
+	return false
+}
+
+func (g *dtsGenerator) nameAt(loc logger.Loc) string {
+	return g.p.source.TextForRange(js_lexer.RangeOfIdentifier(g.p.source, loc))
+}
+
+func (g *dtsGenerator) text(r logger.Range) string {
+	return strings.TrimSpace(g.p.source.TextForRange(r))
+}
+
+func (g *dtsGenerator) docComment(comment logger.Range) string {
+	if comment.Len == 0 {
+		return ""
+	}
+	return g.p.source.CommentTextWithoutIndent(comment)
+}
+
+// Multi-line text is copied from the source with its original indentation,
+// so remove the indentation of the first line from the following lines
+func (g *dtsGenerator) reindent(r logger.Range, text string) string {
+	if !strings.Contains(text, "\n") {
+		return text
+	}
+	contents := g.p.source.Contents
+	lineStart := int(r.Loc.Start)
+	for lineStart > 0 && contents[lineStart-1] != '\n' && contents[lineStart-1] != '\r' {
+		lineStart--
+	}
+	indent := contents[lineStart:r.Loc.Start]
+	if indent == "" || strings.TrimLeft(indent, " \t") != "" {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// This parses imports and exports from their source text instead of using the
+// AST because the parser removes type-only clause items from the AST. The
+// returned token is the import path, if there is one.
+func dtsParseImportOrExport(tokens []dts_lexer.Token) (dts_ast.S, dts_lexer.Token, bool) {
+	i := 0
+	next := func() dts_lexer.Token {
+		if i < len(tokens) {
+			return tokens[i]
+		}
+		return dts_lexer.Token{Kind: js_lexer.TEndOfFile}
+	}
+
+	// Parses "{ a, type b as c }"
+	parseClause := func(isImport bool) ([]dts_ast.ClauseItem, bool) {
+		items := []dts_ast.ClauseItem{}
+		if next().Kind != js_lexer.TOpenBrace {
+			return nil, false
+		}
+		i++
+		for next().Kind != js_lexer.TCloseBrace {
+			isTypeOnly := false
+			if next().Value == "type" && i+1 < len(tokens) && tokens[i+1].Kind != js_lexer.TComma &&
+				tokens[i+1].Kind != js_lexer.TCloseBrace && (tokens[i+1].Value != "as" || (i+2 < len(tokens) &&
+				tokens[i+2].Kind != js_lexer.TComma && tokens[i+2].Kind != js_lexer.TCloseBrace)) {
+				isTypeOnly = true
+				i++
+			}
+			first := next()
+			if first.Value == "" && first.Kind != js_lexer.TStringLiteral {
+				return nil, false
+			}
+			i++
+			second := first
+			if next().Value == "as" {
+				i++
+				second = next()
+				if second.Value == "" && second.Kind != js_lexer.TStringLiteral {
+					return nil, false
+				}
+				i++
+			}
+			if isImport {
+				items = append(items, dts_ast.ClauseItem{Alias: first.Value, Name: second.Value, IsTypeOnly: isTypeOnly})
+			} else {
+				items = append(items, dts_ast.ClauseItem{Name: first.Value, Alias: second.Value, IsTypeOnly: isTypeOnly})
+			}
+			if next().Kind != js_lexer.TComma {
+				break
+			}
+			i++
+		}
+		if next().Kind != js_lexer.TCloseBrace {
+			return nil, false
+		}
+		i++
+		return items, true
+	}
+
+	parsePath := func() (dts_lexer.Token, bool) {
+		if next().Value != "from" {
+			return dts_lexer.Token{}, false
+		}
+		i++
+		if t := next(); t.Kind == js_lexer.TStringLiteral || t.Kind == js_lexer.TNoSubstitutionTemplateLiteral {
+			i++
+			return t, true
+		}
+		return dts_lexer.Token{}, false
+	}
+
+	isTypeOnlyKeyword := func() bool {
+		if next().Value == "type" && i+1 < len(tokens) {
+			if t := tokens[i+1]; t.Kind == js_lexer.TOpenBrace || t.Kind == js_lexer.TAsterisk || (t.Kind == js_lexer.TIdentifier && t.Value != "from") ||
+				(t.Value == "from" && i+2 < len(tokens) && tokens[i+2].Value == "from") {
+				i++
+				return true
+			}
+		}
+		return false
+	}
+
+	switch next().Value {
+	case "import":
+		i++
+		s := &dts_ast.SImport{}
+		s.IsTypeOnly = isTypeOnlyKeyword()
+
+		// "import 'path'" doesn't import anything
+		if next().Kind == js_lexer.TStringLiteral {
+			return nil, dts_lexer.Token{}, false
+		}
+
+		hasClause := true
+		if next().Kind == js_lexer.TIdentifier && next().Value != "from" || (next().Value == "from" && i+1 < len(tokens) && tokens[i+1].Value == "from") {
+			s.DefaultName = next().Value
+			i++
+			if next().Kind == js_lexer.TComma {
+				i++
+			} else {
+				hasClause = false
+			}
+		}
+		if !hasClause {
+			// "import a from 'path'"
+		} else if next().Kind == js_lexer.TAsterisk {
+			i++
+			if next().Value != "as" {
+				return nil, dts_lexer.Token{}, false
+			}
+			i++
+			s.NamespaceName = next().Value
+			i++
+		} else {
+			items, ok := parseClause(true)
+			if !ok {
+				return nil, dts_lexer.Token{}, false
+			}
+			s.Items = items
+		}
+
+		path, ok := parsePath()
+		if !ok {
+			return nil, dts_lexer.Token{}, false
+		}
+		return s, path, true
+
+	case "export":
+		i++
+		isTypeOnly := isTypeOnlyKeyword()
+
+		// "export * from 'path'"
+		if next().Kind == js_lexer.TAsterisk {
+			i++
+			s := &dts_ast.SExportStar{IsTypeOnly: isTypeOnly}
+			if next().Value == "as" {
+				i++
+				s.Alias = next().Value
+				i++
+			}
+			path, ok := parsePath()
+			if !ok {
+				return nil, dts_lexer.Token{}, false
+			}
+			return s, path, true
+		}
+
+		// "export { a } from 'path'"
+		items, ok := parseClause(false)
+		if !ok {
+			return nil, dts_lexer.Token{}, false
+		}
+		if next().Value == "from" {
+			path, ok := parsePath()
+			if !ok {
+				return nil, dts_lexer.Token{}, false
+			}
+			return &dts_ast.SExportFrom{Items: items, IsTypeOnly: isTypeOnly}, path, true
+		}
+		return &dts_ast.SExportClause{Items: items, IsTypeOnly: isTypeOnly}, dts_lexer.Token{}, true
+	}
+
+	return nil, dts_lexer.Token{}, false
+}
@@ -0,0 +1,235 @@
+package js_parser
+
+import (
+	"testing"
+
+	"github.com/evanw/esbuild/internal/config"
+	"github.com/evanw/esbuild/internal/dts_printer"
+	"github.com/evanw/esbuild/internal/logger"
+	"github.com/evanw/esbuild/internal/test"
+)
+
+func expectDeclarationsCommon(t *testing.T, contents string, expected string, expectedErrors string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+		options := config.Options{
+			TS:           config.TSOptions{Parse: true},
+			Declarations: true,
+		}
+		tree, ok := Parse(log, test.SourceForTest(contents), OptionsFromConfig(&options))
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			if msg.Kind != logger.Warning {
+				text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+			}
+		}
+		test.AssertEqualWithDiff(t, text, expectedErrors)
+		if !ok || expectedErrors != "" {
+			return
+		}
+		dts := dts_printer.Print(*tree.Declarations).DTS
+		test.AssertEqualWithDiff(t, string(dts), expected)
+	})
+}
+
+func expectDeclarations(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectDeclarationsCommon(t, contents, expected, "")
+}
+
+func expectDeclarationsError(t *testing.T, contents string, expectedErrors string) {
+	t.Helper()
+	expectDeclarationsCommon(t, contents, "", expectedErrors)
+}
+
+func TestDeclarationsFunction(t *testing.T) {
+	expectDeclarations(t, "export function f(a: number, b?: string): void {}",
+		"export declare function f(a: number, b?: string): void;\n")
+	expectDeclarations(t, "export function f<T extends object = {}>(this: Window, a: T, ...rest: T[]): T { return a }",
+		"export declare function f<T extends object = {}>(this: Window, a: T, ...rest: T[]): T;\n")
+	expectDeclarations(t, "export function f(a = 1, b = 'x', c: number = 2): void {}",
+		"export declare function f(a?: number, b?: string, c?: number): void;\n")
+	expectDeclarations(t, "export function f(a = 1, b: number): void {}",
+		"export declare function f(a: number, b: number): void;\n")
+	expectDeclarations(t, "export function f({ a, b: [c, d] }: X): void {}",
+		"export declare function f({ a, b: [c, d] }: X): void;\n")
+	expectDeclarations(t, "export async function* f(): AsyncGenerator<number> {}",
+		"export declare function f(): AsyncGenerator<number>;\n")
+	expectDeclarations(t, "export function f(a: string): string\nexport function f(a: number): number\nexport function f(a: any): any { return a }",
+		"export declare function f(a: string): string;\nexport declare function f(a: number): number;\n")
+	expectDeclarations(t, "/** Docs */\nexport function f(): void {}",
+		"/** Docs */\nexport declare function f(): void;\n")
+
+	expectDeclarationsError(t, "export function f(a): void {}",
+		"<stdin>: ERROR: Parameter must have an explicit type annotation when generating declarations\n")
+	expectDeclarationsError(t, "export function f(a: number) {}",
+		"<stdin>: ERROR: Function must have an explicit return type annotation when generating declarations\n")
+	expectDeclarationsError(t, "export function f({ a }): void {}",
+		"<stdin>: ERROR: Parameter must have an explicit type annotation when generating declarations\n")
+}
+
+func TestDeclarationsVariable(t *testing.T) {
+	expectDeclarations(t, "export const a: number = 1, b = 'b', c = -2, d = 10n, e = true, f = null",
+		"export declare const a: number, b = \"b\", c = -2, d = 10n, e = true, f = null;\n")
+	expectDeclarations(t, "export let a = 1, b = 'b', c = `c`, d = 10n, e = false, f",
+		"export declare let a: number, b: string, c: string, d: bigint, e: boolean, f: any;\n")
+	expectDeclarations(t, "export const f = (a: number, b?: string): void => {}",
+		"export declare const f: (a: number, b?: string) => void;\n")
+	expectDeclarations(t, "export const f = <T,>(a: T): T => a",
+		"export declare const f: <T,>(a: T) => T;\n")
+	expectDeclarations(t, "export const f = async (a: number): Promise<void> => {}",
+		"export declare const f: (a: number) => Promise<void>;\n")
+	expectDeclarations(t, "export const f = function <T>(a: T): T { return a }",
+		"export declare const f: <T>(a: T) => T;\n")
+	expectDeclarations(t, "export const a = 1 as number, b = <string>'b', c = 'c' as const, d = 'd' satisfies string",
+		"export declare const a: number, b: string, c = \"c\", d = \"d\";\n")
+	expectDeclarations(t, "export let a = [1, 'b', [true, -1n], undefined, null] as const",
+		"export declare let a: readonly [1, \"b\", readonly [true, -1n], undefined, null];\n")
+	expectDeclarations(t, "export const a = { b: 1, 'c-d': 'x', 2: { e: true }, f: 1 as number } as const",
+		"export declare const a: {\n    readonly b: 1;\n    readonly \"c-d\": \"x\";\n    readonly 2: {\n        readonly e: true;\n    };\n    readonly f: number;\n};\n")
+	expectDeclarations(t, "export const a = { b: 1, c: 'c', d: { e: [1] as const }, f: (x: number): void => {}, g(): string { return '' } }",
+		"export declare const a: {\n    b: number;\n    c: string;\n    d: {\n        e: readonly [1];\n    };\n    f: (x: number) => void;\n    g(): string;\n};\n")
+	expectDeclarations(t, "export const a = { get b(): number { return 1 }, set b(x: number) {} }, c = {}",
+		"export declare const a: {\n    get b(): number;\n    set b(x: number);\n}, c: {};\n")
+
+	expectDeclarationsError(t, "export const a = foo()",
+		"<stdin>: ERROR: Variable must have an explicit type annotation when generating declarations\n")
+	expectDeclarationsError(t, "export const f = (a: number) => a",
+		"<stdin>: ERROR: Function must have an explicit return type annotation when generating declarations\n")
+	expectDeclarationsError(t, "export const f = x => x",
+		"<stdin>: ERROR: Variable must have an explicit type annotation when generating declarations\n")
+	expectDeclarationsError(t, "export const a = [1, 2]",
+		"<stdin>: ERROR: Variable must have an explicit type annotation when generating declarations\n")
+	expectDeclarationsError(t, "export const a = { b: [1, 2] }",
+		"<stdin>: ERROR: Property must have an explicit type annotation when generating declarations\n")
+	expectDeclarationsError(t, "export const a = { b, ...c, [d]: 1 }",
+		"<stdin>: ERROR: Shorthand property can't be inferred when generating declarations\n"+
+			"<stdin>: ERROR: Object spread can't be inferred when generating declarations\n"+
+			"<stdin>: ERROR: Computed property name can't be inferred when generating declarations\n")
+	expectDeclarationsError(t, "export const a = [...b] as const",
+		"<stdin>: ERROR: Array spread can't be inferred when generating declarations\n")
+	expectDeclarationsError(t, "export const { a } = b",
+		"<stdin>: ERROR: Binding patterns can't be exported when generating declarations\n")
+}
+
+func TestDeclarationsClass(t *testing.T) {
+	expectDeclarations(t, `
+export class Foo<T> extends Bar<T> implements Baz {
+	/** Docs */
+	a: number = 1
+	b?: string
+	readonly c = 'c'
+	static d = 2
+	private e: number = 3
+	protected f!: T
+	#g = 1;
+	[key: string]: any;
+	declare h: number
+	accessor i: string = ''
+	constructor(public x: number, private y: string, z?: T) { super() }
+	m<U>(u: U): T { return null! }
+	get n(): number { return 1 }
+	set n(v: number) {}
+	private p(a: number): void {}
+	static { foo() }
+	o(a: string): void
+	o(a: number): void
+	o(a: any): void {}
+}
+`, `export declare class Foo<T> extends Bar<T> implements Baz {
+    #private;
+    /** Docs */
+    a: number;
+    b?: string;
+    readonly c = "c";
+    static d: number;
+    private e;
+    protected f: T;
+    [key: string]: any;
+    h: number;
+    accessor i: string;
+    x: number;
+    private y;
+    constructor(x: number, y: string, z?: T);
+    m<U>(u: U): T;
+    get n(): number;
+    set n(v: number);
+    private p;
+    o(a: string): void;
+    o(a: number): void;
+}
+`)
+	expectDeclarations(t, "export abstract class Foo { abstract foo(): void; bar(): void {} }",
+		"export declare abstract class Foo {\n    abstract foo(): void;\n    bar(): void;\n}\n")
+	expectDeclarations(t, "export default class { x: number = 1 }",
+		"export default class {\n    x: number;\n}\n")
+
+	expectDeclarationsError(t, "export class Foo { x = foo() }",
+		"<stdin>: ERROR: Property must have an explicit type annotation when generating declarations\n")
+	expectDeclarationsError(t, "export class Foo { foo() {} }",
+		"<stdin>: ERROR: Function must have an explicit return type annotation when generating declarations\n")
+	expectDeclarationsError(t, "export class Foo extends mixin(Bar) {}",
+		"<stdin>: ERROR: Extends clause must be an identifier or property access when generating declarations\n")
+}
+
+func TestDeclarationsTypes(t *testing.T) {
+	expectDeclarations(t, "export interface Foo { a: number }\nexport type Bar = string | Foo",
+		"export interface Foo { a: number }\nexport type Bar = string | Foo;\n")
+	expectDeclarations(t, "export enum Foo { A, B = 2 }\nexport const enum Bar { C = 'c' }",
+		"export declare enum Foo { A = 0, B = 2 }\nexport declare const enum Bar { C = 'c' }\n")
+	expectDeclarations(t, "export enum Foo { A = 1 << 2, B, C = A | B, D, E = 'e', F = ~Foo.D, 'g-h' }",
+		"export declare enum Foo { A = 1 << 2, B = 5, C = A | B, D = 6, E = 'e', F = ~Foo.D, 'g-h' = -6 }\n")
+	expectDeclarations(t, "export enum Foo {\n    A,\n    /** B */\n    B,\n}",
+		"export declare enum Foo {\n    A = 0,\n    /** B */\n    B = 1,\n}\n")
+
+	expectDeclarationsError(t, "export enum Foo { A = 'a', B }",
+		"<stdin>: ERROR: Enum member must have an initializer when generating declarations\n")
+	expectDeclarationsError(t, "export enum Foo { A = foo(), B }",
+		"<stdin>: ERROR: Enum member must have an initializer when generating declarations\n")
+	expectDeclarations(t, "export declare const x: number\nexport declare function f(): void",
+		"export declare const x: number;\nexport declare function f(): void;\n")
+	expectDeclarations(t, "declare global { interface Window { foo: number } }\nexport {}",
+		"declare global { interface Window { foo: number } }\nexport {};\n")
+	expectDeclarations(t, "export namespace ns { export const x: number = 1; const y = 2; export type T = number }",
+		"export declare namespace ns {\n    export const x: number;\n    export type T = number;\n}\n")
+	expectDeclarations(t, "export namespace a.b { export function f(): void {} }",
+		"export declare namespace a {\n    export namespace b {\n        export function f(): void;\n    }\n}\n")
+}
+
+func TestDeclarationsImportsAndExports(t *testing.T) {
+	expectDeclarations(t, `
+import a, { b, type c, d as e } from './foo'
+import type { f } from './bar'
+import * as ns from './ns'
+import './side-effect'
+export function foo(x: a, y: c, z: ns.T): f { return null! }
+`, `import a, { type c } from "./foo";
+import type { f } from "./bar";
+import * as ns from "./ns";
+export declare function foo(x: a, y: c, z: ns.T): f;
+`)
+	expectDeclarations(t, "export { a, b as c } from './foo'\nexport type { T } from './types'\nexport * from './all'\nexport * as ns from './ns'",
+		"export { a, b as c } from \"./foo\";\nexport type { T } from \"./types\";\nexport * from \"./all\";\nexport * as ns from \"./ns\";\n")
+
+	// Unexported declarations are only kept if they are referenced
+	expectDeclarations(t, "interface A {}\ninterface B {}\nconst x: A = {}\nfunction unused() {}\nexport { x as y }",
+		"interface A {}\ndeclare const x: A;\nexport { x as y };\n")
+	expectDeclarations(t, "const x: number = 1\nexport default x",
+		"declare const x: number;\nexport default x;\n")
+	expectDeclarations(t, "export default 123",
+		"declare const _default: 123;\nexport default _default;\n")
+	expectDeclarations(t, "export default function (a: number): void {}",
+		"export default function (a: number): void;\n")
+	expectDeclarations(t, "import './foo'",
+		"export {};\n")
+
+	// Scripts declare globals, so everything is kept
+	expectDeclarations(t, "function f(): void {}\nlet x = 1",
+		"declare function f(): void;\ndeclare let x: number;\n")
+
+	expectDeclarationsError(t, "export default foo()",
+		"<stdin>: ERROR: Default export must be an identifier or a value with an explicit type when generating declarations\n")
+}
@@ -0,0 +1,561 @@
+package js_parser
+
+// This file implements TypeScript's "emitDecoratorMetadata" setting. When
+// it's enabled, decorated class members also get decorators that pass the
+// types of the member to "Reflect.metadata()" (which is usually provided by
+// the "reflect-metadata" package). Dependency injection frameworks use this
+// to find the types of constructor parameters at run-time.
+//
+// Types don't exist at run-time, so each type annotation is serialized as the
+// constructor function for values of that type using the same rules as the
+// TypeScript compiler. We don't do type checking, so a reference to something
+// that may only be a type is guarded with a "typeof" check that falls back to
+// "Object" like the TypeScript compiler does when it doesn't know either:
+//
+//   typeof Foo === "undefined" ? Object : Foo
+//
+
+import (
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/config"
+	"github.com/evanw/esbuild/internal/dts_lexer"
+	"github.com/evanw/esbuild/internal/helpers"
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+type metadataTypeKind uint8
+
+const (
+	// "Object"
+	metadataObject metadataTypeKind = iota
+
+	// "void 0" (this is used for "void", "undefined", "null", and "never")
+	metadataVoid
+
+	// A constructor that always exists such as "String" or "Array"
+	metadataGlobal
+
+	// A name that may refer to a value, a type, or both
+	metadataReference
+)
+
+type metadataType struct {
+	parts []string
+	kind  metadataTypeKind
+}
+
+func metadataGlobalType(name string) metadataType {
+	return metadataType{kind: metadataGlobal, parts: []string{name}}
+}
+
+// These are assumed to be global constructors if they aren't shadowed
+var knownGlobalConstructors = map[string]bool{
+	"Array":             true,
+	"ArrayBuffer":       true,
+	"BigInt":            true,
+	"Boolean":           true,
+	"DataView":          true,
+	"Date":              true,
+	"Error":             true,
+	"Float32Array":      true,
+	"Float64Array":      true,
+	"Function":          true,
+	"Int16Array":        true,
+	"Int32Array":        true,
+	"Int8Array":         true,
+	"Map":               true,
+	"Number":            true,
+	"Object":            true,
+	"Promise":           true,
+	"RegExp":            true,
+	"Set":               true,
+	"String":            true,
+	"Symbol":            true,
+	"Uint16Array":       true,
+	"Uint32Array":       true,
+	"Uint8Array":        true,
+	"Uint8ClampedArray": true,
+	"WeakMap":           true,
+	"WeakSet":           true,
+}
+
+// This returns the decorators that "emitDecoratorMetadata" adds to a class
+// member. They go after the member's own decorators. Note that the class
+// itself is handled by "constructorMetadata" instead.
+func (p *parser) decoratorMetadata(prop *js_ast.Property) (decorators []js_ast.Decorator) {
+	loc := prop.Key.Loc
+	fn, _ := prop.ValueOrNil.Data.(*js_ast.EFunction)
+
+	switch {
+	case prop.Kind == js_ast.PropertyGet && fn != nil:
+		decorators = append(decorators,
+			p.metadataDecorator(loc, "design:type", p.metadataTypeToExpr(loc, p.metadataTypeForReturn(&fn.Fn))),
+			p.metadataDecorator(loc, "design:paramtypes", p.metadataParamTypes(loc, &fn.Fn)))
+
+	case prop.Kind == js_ast.PropertySet && fn != nil:
+		paramType := metadataType{kind: metadataObject}
+		if len(fn.Fn.Args) > 0 {
+			paramType = p.metadataTypeForLoc(fn.Fn.Args[0].Binding.Loc)
+		}
+		decorators = append(decorators,
+			p.metadataDecorator(loc, "design:type", p.metadataTypeToExpr(loc, paramType)),
+			p.metadataDecorator(loc, "design:paramtypes", p.metadataParamTypes(loc, &fn.Fn)))
+
+	case prop.Flags.Has(js_ast.PropertyIsMethod) && fn != nil:
+		decorators = append(decorators,
+			p.metadataDecorator(loc, "design:type", p.metadataTypeToExpr(loc, metadataGlobalType("Function"))),
+			p.metadataDecorator(loc, "design:paramtypes", p.metadataParamTypes(loc, &fn.Fn)),
+			p.metadataDecorator(loc, "design:returntype", p.metadataTypeToExpr(loc, p.metadataTypeForReturn(&fn.Fn))))
+
+	case prop.Kind == js_ast.PropertyNormal || prop.Kind == js_ast.PropertyAutoAccessor:
+		decorators = append(decorators,
+			p.metadataDecorator(loc, "design:type", p.metadataTypeToExpr(loc, p.metadataTypeForLoc(prop.Loc))))
+	}
+	return
+}
+
+// Classes with decorators describe the parameters of their constructor
+func (p *parser) constructorMetadata(loc logger.Loc, ctor *js_ast.EFunction) js_ast.Decorator {
+	return p.metadataDecorator(loc, "design:paramtypes", p.metadataParamTypes(loc, &ctor.Fn))
+}
+
+func (p *parser) metadataDecorator(loc logger.Loc, key string, value js_ast.Expr) js_ast.Decorator {
+	return js_ast.Decorator{
+		Value: p.callRuntime(loc, "__metadata", []js_ast.Expr{
+			{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16(key)}},
+			value,
+		}),
+		AtLoc: loc,
+	}
+}
+
+func (p *parser) metadataParamTypes(loc logger.Loc, fn *js_ast.Fn) js_ast.Expr {
+	items := make([]js_ast.Expr, 0, len(fn.Args))
+	for _, arg := range fn.Args {
+		items = append(items, p.metadataTypeToExpr(loc, p.metadataTypeForLoc(arg.Binding.Loc)))
+	}
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EArray{Items: items, IsSingleLine: true}}
+}
+
+func (p *parser) metadataTypeForReturn(fn *js_ast.Fn) metadataType {
+	if _, ok := p.metadataTypes[fn.OpenParenLoc]; ok {
+		return p.metadataTypeForLoc(fn.OpenParenLoc)
+	}
+
+	// Functions without a return type only have a known type if they're async
+	if fn.IsAsync {
+		return metadataGlobalType("Promise")
+	}
+	return metadataType{kind: metadataVoid}
+}
+
+func (p *parser) metadataTypeForLoc(loc logger.Loc) metadataType {
+	r, ok := p.metadataTypes[loc]
+	if !ok {
+		return metadataType{kind: metadataObject}
+	}
+	mp := metadataTypeParser{tokens: dts_lexer.Tokenize(p.source.TextForRange(r))}
+	return mp.parseType()
+}
+
+func (p *parser) metadataTypeToExpr(loc logger.Loc, t metadataType) js_ast.Expr {
+	switch t.kind {
+	case metadataVoid:
+		return js_ast.Expr{Loc: loc, Data: js_ast.EUndefinedShared}
+
+	case metadataObject:
+		return p.instantiateDefineExpr(loc, config.DefineExpr{Parts: []string{"Object"}}, identifierOpts{})
+
+	case metadataGlobal:
+		return p.instantiateDefineExpr(loc, config.DefineExpr{Parts: t.parts}, identifierOpts{})
+	}
+
+	// References to local classes, functions, and variables are known to be
+	// values. Imports may only be types, so they need to be checked.
+	if member, ok := p.findMemberWithoutRecordingUsage(t.parts[0]); ok {
+		switch p.symbols[member.Ref.InnerIndex].Kind {
+		case ast.SymbolImport:
+			// The check below references the import once for each part
+			p.metadataImportUses[member.Ref] += uint32(len(t.parts) + 1)
+		}
+	}
+	if len(t.parts) == 1 {
+		if member, ok := p.findMemberWithoutRecordingUsage(t.parts[0]); ok {
+			switch p.symbols[member.Ref.InnerIndex].Kind {
+			case ast.SymbolImport, ast.SymbolTSNamespace:
+			case ast.SymbolTSEnum:
+				return p.metadataTypeToExpr(loc, p.metadataTypeForEnum(member.Ref))
+			default:
+				return p.instantiateDefineExpr(loc, config.DefineExpr{Parts: t.parts}, identifierOpts{})
+			}
+		} else if p.localTypeNames[t.parts[0]] {
+			return p.metadataTypeToExpr(loc, metadataType{kind: metadataObject})
+		} else if knownGlobalConstructors[t.parts[0]] {
+			return p.instantiateDefineExpr(loc, config.DefineExpr{Parts: t.parts}, identifierOpts{})
+		}
+	}
+
+	// "typeof Foo === 'undefined' ? Object : Foo"
+	var test js_ast.Expr
+	for i := range t.parts {
+		check := js_ast.Expr{Loc: loc, Data: &js_ast.EBinary{
+			Op: js_ast.BinOpStrictEq,
+			Left: js_ast.Expr{Loc: loc, Data: &js_ast.EUnary{
+				Op:    js_ast.UnOpTypeof,
+				Value: p.instantiateDefineExpr(loc, config.DefineExpr{Parts: t.parts[:i+1]}, identifierOpts{}),
+
+				// This must not throw if the name doesn't exist
+				WasOriginallyTypeofIdentifier: i == 0,
+			}},
+			Right: js_ast.Expr{Loc: loc, Data: &js_ast.EString{Value: helpers.StringToUTF16("undefined")}},
+		}}
+		if test.Data == nil {
+			test = check
+		} else {
+			test = js_ast.JoinWithLeftAssociativeOp(js_ast.BinOpLogicalOr, test, check)
+		}
+	}
+	return js_ast.Expr{Loc: loc, Data: &js_ast.EIf{
+		Test: test,
+		Yes:  p.instantiateDefineExpr(loc, config.DefineExpr{Parts: []string{"Object"}}, identifierOpts{}),
+		No:   p.instantiateDefineExpr(loc, config.DefineExpr{Parts: t.parts}, identifierOpts{}),
+	}}
+}
+
+func (p *parser) findMemberWithoutRecordingUsage(name string) (js_ast.ScopeMember, bool) {
+	for s := p.currentScope; s != nil; s = s.Parent {
+		if member, ok := s.Members[name]; ok {
+			return member, true
+		}
+	}
+	return js_ast.ScopeMember{}, false
+}
+
+// Enums are serialized as the type of their values
+func (p *parser) metadataTypeForEnum(ref ast.Ref) metadataType {
+	result := metadataType{kind: metadataObject}
+	if data, ok := p.refToTSNamespaceMemberData[ref].(*js_ast.TSNamespaceMemberNamespace); ok {
+		for _, member := range data.ExportedMembers {
+			var t metadataType
+			switch member.Data.(type) {
+			case *js_ast.TSNamespaceMemberEnumNumber:
+				t = metadataGlobalType("Number")
+			case *js_ast.TSNamespaceMemberEnumString:
+				t = metadataGlobalType("String")
+			default:
+				return metadataType{kind: metadataObject}
+			}
+			if result.kind == metadataObject {
+				result = t
+			} else if !helpers.StringSlicesAreEqual(result.parts, t.parts) {
+				return metadataType{kind: metadataObject}
+			}
+		}
+	}
+	return result
+}
+
+// This is a best-effort parser for type annotations that only determines what
+// the TypeScript compiler would serialize each type as. The type annotation
+// was already checked for syntax errors when it was skipped by the parser.
+type metadataTypeParser struct {
+	tokens []dts_lexer.Token
+	index  int
+}
+
+func (mp *metadataTypeParser) peek() dts_lexer.Token {
+	if mp.index < len(mp.tokens) {
+		return mp.tokens[mp.index]
+	}
+	return dts_lexer.Token{Kind: js_lexer.TEndOfFile}
+}
+
+func (mp *metadataTypeParser) next() {
+	if mp.index < len(mp.tokens) {
+		mp.index++
+	}
+}
+
+// This skips over a token and everything up to its matching closing token
+func (mp *metadataTypeParser) skipBalanced() {
+	depth := 0
+	for mp.index < len(mp.tokens) {
+		switch mp.tokens[mp.index].Kind {
+		case js_lexer.TOpenParen, js_lexer.TOpenBracket, js_lexer.TOpenBrace, js_lexer.TLessThan, js_lexer.TTemplateHead:
+			depth++
+		case js_lexer.TCloseParen, js_lexer.TCloseBracket, js_lexer.TCloseBrace, js_lexer.TGreaterThan, js_lexer.TTemplateTail:
+			depth--
+		case js_lexer.TGreaterThanGreaterThan:
+			depth -= 2
+		case js_lexer.TGreaterThanGreaterThanGreaterThan:
+			depth -= 3
+		}
+		mp.index++
+		if depth <= 0 {
+			return
+		}
+	}
+}
+
+func (mp *metadataTypeParser) isTypeStart() bool {
+	switch mp.peek().Kind {
+	case js_lexer.TIdentifier, js_lexer.TNew, js_lexer.TThis, js_lexer.TTypeof,
+		js_lexer.TOpenParen, js_lexer.TOpenBracket, js_lexer.TOpenBrace:
+		return true
+	}
+	return false
+}
+
+func (mp *metadataTypeParser) parseType() metadataType {
+	t := mp.parseUnionOrIntersection()
+
+	// "A extends B ? C : D" is serialized like "C | D"
+	if mp.peek().Kind == js_lexer.TExtends {
+		mp.next()
+		mp.parseUnionOrIntersection()
+		if mp.peek().Kind != js_lexer.TQuestion {
+			return metadataType{kind: metadataObject}
+		}
+		mp.next()
+		yes := mp.parseType()
+		if mp.peek().Kind != js_lexer.TColon {
+			return metadataType{kind: metadataObject}
+		}
+		mp.next()
+		no := mp.parseType()
+		return combineMetadataTypes([]metadataType{yes, no})
+	}
+	return t
+}
+
+func (mp *metadataTypeParser) parseUnionOrIntersection() metadataType {
+	var types []metadataType
+	if kind := mp.peek().Kind; kind == js_lexer.TBar || kind == js_lexer.TAmpersand {
+		mp.next()
+	}
+	for {
+		types = append(types, mp.parsePostfix())
+		if kind := mp.peek().Kind; kind != js_lexer.TBar && kind != js_lexer.TAmpersand {
+			break
+		}
+		mp.next()
+	}
+	if len(types) == 1 {
+		return types[0]
+	}
+	return combineMetadataTypes(types)
+}
+
+// Like the TypeScript compiler, this ignores "null", "undefined", and "never"
+// and only uses a specific type if all other types serialize the same way
+func combineMetadataTypes(types []metadataType) metadataType {
+	var result *metadataType
+	for i := range types {
+		t := &types[i]
+		if t.kind == metadataVoid {
+			continue
+		}
+		if t.kind == metadataObject {
+			return *t
+		}
+		if result == nil {
+			result = t
+		} else if result.kind != t.kind || !helpers.StringSlicesAreEqual(result.parts, t.parts) {
+			return metadataType{kind: metadataObject}
+		}
+	}
+	if result == nil {
+		return metadataType{kind: metadataVoid}
+	}
+	return *result
+}
+
+func (mp *metadataTypeParser) parsePostfix() metadataType {
+	t := mp.parsePrimary()
+	for mp.peek().Kind == js_lexer.TOpenBracket {
+		if mp.index+1 < len(mp.tokens) && mp.tokens[mp.index+1].Kind == js_lexer.TCloseBracket {
+			// "T[]"
+			mp.index += 2
+			t = metadataGlobalType("Array")
+		} else {
+			// "T[K]"
+			mp.skipBalanced()
+			t = metadataType{kind: metadataObject}
+		}
+	}
+	return t
+}
+
+func (mp *metadataTypeParser) parsePrimary() metadataType {
+	token := mp.peek()
+
+	switch token.Kind {
+	case js_lexer.TOpenParen:
+		// "(a: A) => B"
+		start := mp.index
+		mp.skipBalanced()
+		if mp.peek().Kind == js_lexer.TEqualsGreaterThan {
+			mp.next()
+			mp.parseType()
+			return metadataGlobalType("Function")
+		}
+
+		// "(A)"
+		mp.index = start + 1
+		t := mp.parseType()
+		if mp.peek().Kind == js_lexer.TCloseParen {
+			mp.next()
+		}
+		return t
+
+	case js_lexer.TLessThan, js_lexer.TNew:
+		// "<T>(a: T) => T"
+		// "new (a: A) => B"
+		if token.Kind == js_lexer.TNew {
+			mp.next()
+		}
+		if mp.peek().Kind == js_lexer.TLessThan {
+			mp.skipBalanced()
+		}
+		if mp.peek().Kind == js_lexer.TOpenParen {
+			mp.skipBalanced()
+		}
+		if mp.peek().Kind == js_lexer.TEqualsGreaterThan {
+			mp.next()
+			mp.parseType()
+		}
+		return metadataGlobalType("Function")
+
+	case js_lexer.TOpenBracket:
+		mp.skipBalanced()
+		return metadataGlobalType("Array")
+
+	case js_lexer.TOpenBrace:
+		mp.skipBalanced()
+		return metadataType{kind: metadataObject}
+
+	case js_lexer.TStringLiteral, js_lexer.TNoSubstitutionTemplateLiteral:
+		mp.next()
+		return metadataGlobalType("String")
+
+	case js_lexer.TTemplateHead:
+		mp.skipBalanced()
+		return metadataGlobalType("String")
+
+	case js_lexer.TMinus:
+		mp.next()
+		return mp.parsePrimary()
+
+	case js_lexer.TNumericLiteral:
+		mp.next()
+		return metadataGlobalType("Number")
+
+	case js_lexer.TBigIntegerLiteral:
+		mp.next()
+		return metadataGlobalType("BigInt")
+
+	case js_lexer.TTrue, js_lexer.TFalse:
+		mp.next()
+		return metadataGlobalType("Boolean")
+
+	case js_lexer.TNull, js_lexer.TVoid:
+		mp.next()
+		return metadataType{kind: metadataVoid}
+
+	case js_lexer.TTypeof:
+		// "typeof x.y<T>"
+		mp.next()
+		mp.next()
+		for mp.peek().Kind == js_lexer.TDot {
+			mp.next()
+			mp.next()
+		}
+		if mp.peek().Kind == js_lexer.TLessThan {
+			mp.skipBalanced()
+		}
+		return metadataType{kind: metadataObject}
+
+	case js_lexer.TThis:
+		// "this is T"
+		mp.next()
+		if t := mp.peek(); t.Kind == js_lexer.TIdentifier && t.Value == "is" {
+			mp.next()
+			mp.parseType()
+			return metadataGlobalType("Boolean")
+		}
+		return metadataType{kind: metadataObject}
+
+	case js_lexer.TIdentifier:
+		mp.next()
+		switch token.Value {
+		case "any", "unknown", "object":
+			return metadataType{kind: metadataObject}
+		case "string":
+			return metadataGlobalType("String")
+		case "number":
+			return metadataGlobalType("Number")
+		case "boolean":
+			return metadataGlobalType("Boolean")
+		case "bigint":
+			return metadataGlobalType("BigInt")
+		case "symbol":
+			return metadataGlobalType("Symbol")
+		case "undefined", "never":
+			return metadataType{kind: metadataVoid}
+		}
+
+		// "keyof T"
+		// "readonly T[]"
+		// "unique symbol"
+		// "abstract new () => T"
+		if mp.isTypeStart() {
+			switch token.Value {
+			case "keyof":
+				mp.parsePostfix()
+				return metadataType{kind: metadataObject}
+			case "readonly":
+				return mp.parsePostfix()
+			case "unique":
+				mp.parsePostfix()
+				return metadataGlobalType("Symbol")
+			case "abstract":
+				return mp.parsePrimary()
+			case "asserts":
+				mp.next()
+				if t := mp.peek(); t.Kind == js_lexer.TIdentifier && t.Value == "is" {
+					mp.next()
+					mp.parseType()
+				}
+				return metadataType{kind: metadataVoid}
+			case "infer":
+				mp.next()
+				return metadataType{kind: metadataObject}
+			}
+		}
+
+		// "x is T"
+		if t := mp.peek(); t.Kind == js_lexer.TIdentifier && t.Value == "is" {
+			mp.next()
+			mp.parseType()
+			return metadataGlobalType("Boolean")
+		}
+
+		// "A.B<T>"
+		parts := []string{token.Value}
+		for mp.peek().Kind == js_lexer.TDot {
+			mp.next()
+			parts = append(parts, mp.peek().Value)
+			mp.next()
+		}
+		if mp.peek().Kind == js_lexer.TLessThan {
+			mp.skipBalanced()
+		}
+		return metadataType{kind: metadataReference, parts: parts}
+	}
+
+	// Anything else (e.g. "import('x').Y") can't be serialized
+	mp.skipBalanced()
+	return metadataType{kind: metadataObject}
+}
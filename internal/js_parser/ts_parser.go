@@ -159,6 +159,11 @@ func (p *parser) skipTypeScriptParenOrFnType() {
 
 func (p *parser) skipTypeScriptReturnType() {
 	p.skipTypeScriptTypeWithFlags(js_ast.LLowest, isReturnTypeFlag)
+
+	// "function isString(x: mixed): boolean %checks { ... }"
+	if p.options.flow.Parse && p.lexer.Token == js_lexer.TPercent {
+		p.skipFlowPredicate()
+	}
 }
 
 func (p *parser) skipTypeScriptType(level js_ast.L) {
@@ -172,6 +177,7 @@ const (
 	isIndexSignatureFlag
 	allowTupleLabelsFlag
 	disallowConditionalTypesFlag
+	isFlowArrowReturnTypeFlag
 )
 
 func (flags skipTypeFlags) has(flag skipTypeFlags) bool {
@@ -257,6 +263,21 @@ loop:
 			p.lexer.Next()
 			continue
 
+		case js_lexer.TQuestion:
+			// "let foo: ?string" (a Flow maybe type)
+			if !p.options.flow.Parse {
+				p.lexer.Unexpected()
+			}
+			p.lexer.Next()
+			continue
+
+		case js_lexer.TAsterisk:
+			// "let foo: Array<*>" (a Flow existential type)
+			if !p.options.flow.Parse {
+				p.lexer.Unexpected()
+			}
+			p.lexer.Next()
+
 		case js_lexer.TImport:
 			// "import('fs')"
 			p.lexer.Next()
@@ -298,11 +319,19 @@ loop:
 		case js_lexer.TLessThan:
 			// "<T>() => Foo<T>"
 			p.skipTypeScriptTypeParameters(allowConstModifier)
-			p.skipTypeScriptParenOrFnType()
+			if p.options.flow.Parse {
+				p.skipFlowParenOrFnType(flags)
+			} else {
+				p.skipTypeScriptParenOrFnType()
+			}
 
 		case js_lexer.TOpenParen:
 			// "(number | string)"
-			p.skipTypeScriptParenOrFnType()
+			if p.options.flow.Parse {
+				p.skipFlowParenOrFnType(flags)
+			} else {
+				p.skipTypeScriptParenOrFnType()
+			}
 
 		case js_lexer.TIdentifier:
 			kind := tsTypeIdentifierMap[p.lexer.Identifier.String]
@@ -371,6 +400,7 @@ loop:
 				checkTypeParameters = false
 
 			default:
+				p.recordNameUsedInType(p.lexer.Identifier.String)
 				p.lexer.Next()
 			}
 
@@ -402,6 +432,7 @@ loop:
 				if !p.lexer.IsIdentifierOrKeyword() {
 					p.lexer.Expected(js_lexer.TIdentifier)
 				}
+				p.recordNameUsedInType(p.lexer.Identifier.String)
 				p.lexer.Next()
 
 				// "typeof x.y"
@@ -481,15 +512,42 @@ loop:
 			if level >= js_ast.LBitwiseOr {
 				return
 			}
+
+			// "{| foo: string |}" (the end of a Flow exact object type)
+			if p.options.flow.Parse && p.isFlowExactObjectTypeEnd() {
+				return
+			}
 			p.lexer.Next()
-			p.skipTypeScriptType(js_ast.LBitwiseOr)
+			p.skipTypeScriptTypeWithFlags(js_ast.LBitwiseOr, p.flowArrowReturnTypeFlags(flags))
 
 		case js_lexer.TAmpersand:
 			if level >= js_ast.LBitwiseAnd {
 				return
 			}
 			p.lexer.Next()
-			p.skipTypeScriptType(js_ast.LBitwiseAnd)
+			p.skipTypeScriptTypeWithFlags(js_ast.LBitwiseAnd, p.flowArrowReturnTypeFlags(flags))
+
+		case js_lexer.TEqualsGreaterThan:
+			// "let foo: string => void" (a Flow function type without parentheses)
+			//
+			// This isn't allowed in an arrow function's return type because it's
+			// ambiguous with the body of the arrow function: "(x): string => x".
+			if !p.options.flow.Parse || flags.has(isFlowArrowReturnTypeFlag) {
+				return
+			}
+			p.lexer.Next()
+			p.skipTypeScriptType(js_ast.LLowest)
+			return
+
+		case js_lexer.TQuestionDot:
+			// "let foo: Obj?.['prop']" (a Flow optional indexed access type)
+			if !p.options.flow.Parse || p.lexer.HasNewlineBefore {
+				return
+			}
+			p.lexer.Next()
+			p.lexer.Expect(js_lexer.TOpenBracket)
+			p.skipTypeScriptType(js_ast.LLowest)
+			p.lexer.Expect(js_lexer.TCloseBracket)
 
 		case js_lexer.TExclamation:
 			// A postfix "!" is allowed in JSDoc types in TypeScript, which are only
@@ -548,7 +606,36 @@ loop:
 func (p *parser) skipTypeScriptObjectType() {
 	p.lexer.Expect(js_lexer.TOpenBrace)
 
-	for p.lexer.Token != js_lexer.TCloseBrace {
+	// "{| foo: string |}" (a Flow exact object type)
+	isExact := false
+	if p.options.flow.Parse {
+		if p.lexer.Token == js_lexer.TBarBar {
+			p.lexer.Next()
+			p.lexer.Expect(js_lexer.TCloseBrace)
+			return
+		}
+		if p.lexer.Token == js_lexer.TBar {
+			p.lexer.Next()
+			isExact = true
+		}
+	}
+
+	for p.lexer.Token != js_lexer.TCloseBrace && (!isExact || p.lexer.Token != js_lexer.TBar) {
+		// "{ ...Foo, bar: string }"
+		// "{ foo: string, ... }"
+		if p.options.flow.Parse && p.lexer.Token == js_lexer.TDotDotDot {
+			p.lexer.Next()
+			switch p.lexer.Token {
+			case js_lexer.TCloseBrace, js_lexer.TComma, js_lexer.TSemicolon, js_lexer.TBar:
+			default:
+				p.skipTypeScriptType(js_ast.LLowest)
+			}
+			if p.lexer.Token == js_lexer.TComma || p.lexer.Token == js_lexer.TSemicolon {
+				p.lexer.Next()
+			}
+			continue
+		}
+
 		// "{ -readonly [K in keyof T]: T[K] }"
 		// "{ +readonly [K in keyof T]: T[K] }"
 		if p.lexer.Token == js_lexer.TPlus || p.lexer.Token == js_lexer.TMinus {
@@ -615,7 +702,11 @@ func (p *parser) skipTypeScriptObjectType() {
 
 		case js_lexer.TOpenParen:
 			// Method signature
-			p.skipTypeScriptFnArgs()
+			if p.options.flow.Parse {
+				p.skipFlowFnTypeParams()
+			} else {
+				p.skipTypeScriptFnArgs()
+			}
 			if p.lexer.Token == js_lexer.TColon {
 				p.lexer.Next()
 				p.skipTypeScriptReturnType()
@@ -634,12 +725,15 @@ func (p *parser) skipTypeScriptObjectType() {
 			p.lexer.Next()
 
 		default:
-			if !p.lexer.HasNewlineBefore {
+			if !p.lexer.HasNewlineBefore && (!isExact || p.lexer.Token != js_lexer.TBar) {
 				p.lexer.Unexpected()
 			}
 		}
 	}
 
+	if isExact {
+		p.lexer.Expect(js_lexer.TBar)
+	}
 	p.lexer.Expect(js_lexer.TCloseBrace)
 }
 
@@ -668,6 +762,7 @@ func (p *parser) skipTypeScriptTypeParameters(flags typeParameterFlags) skipType
 		return didNotSkipAnything
 	}
 
+	lessThanLoc := p.lexer.Loc()
 	p.lexer.Next()
 	result := couldBeTypeCast
 
@@ -735,6 +830,12 @@ func (p *parser) skipTypeScriptTypeParameters(flags typeParameterFlags) skipType
 			break
 		}
 
+		// "class Foo<+T, -U> {}" (Flow variance annotations)
+		if p.options.flow.Parse && (p.lexer.Token == js_lexer.TPlus || p.lexer.Token == js_lexer.TMinus) {
+			result = definitelyTypeParameters
+			p.lexer.Next()
+		}
+
 		// Only report an error for the first invalid modifier
 		if invalidModifierRange.Len > 0 {
 			p.log.AddError(&p.tracker, invalidModifierRange, fmt.Sprintf(
@@ -747,6 +848,13 @@ func (p *parser) skipTypeScriptTypeParameters(flags typeParameterFlags) skipType
 			p.lexer.Expect(js_lexer.TIdentifier)
 		}
 
+		// "class Foo<T: number> {}" (a Flow bound)
+		if p.options.flow.Parse && p.lexer.Token == js_lexer.TColon {
+			result = definitelyTypeParameters
+			p.lexer.Next()
+			p.skipTypeScriptType(js_ast.LLowest)
+		}
+
 		// "class Foo<T extends number> {}"
 		if p.lexer.Token == js_lexer.TExtends {
 			result = definitelyTypeParameters
@@ -772,6 +880,10 @@ func (p *parser) skipTypeScriptTypeParameters(flags typeParameterFlags) skipType
 	}
 
 	p.lexer.ExpectGreaterThan(false /* isInsideJSXElement */)
+	p.stripTypesFrom(lessThanLoc)
+	if p.dts != nil {
+		p.dts.typeParams[p.lexer.Loc()] = p.dtsRangeFrom(lessThanLoc)
+	}
 	return result
 }
 
@@ -788,6 +900,7 @@ func (p *parser) skipTypeScriptTypeArguments(opts skipTypeScriptTypeArgumentsOpt
 		return false
 	}
 
+	lessThanLoc := p.lexer.Loc()
 	p.lexer.ExpectLessThan(false /* isInsideJSXElement */)
 
 	for {
@@ -820,6 +933,7 @@ func (p *parser) skipTypeScriptTypeArguments(opts skipTypeScriptTypeArgumentsOpt
 			p.lexer.Expect(js_lexer.TGreaterThan)
 		}
 	}
+	p.stripTypesFrom(lessThanLoc)
 	return true
 }
 
@@ -890,7 +1004,11 @@ func (p *parser) trySkipTypeScriptArrowReturnTypeWithBacktracking() bool {
 	}()
 
 	p.lexer.Expect(js_lexer.TColon)
-	p.skipTypeScriptReturnType()
+	if p.options.flow.Parse {
+		p.skipFlowArrowReturnType()
+	} else {
+		p.skipTypeScriptReturnType()
+	}
 
 	// Check the token after this and backtrack if it's the wrong one
 	if p.lexer.Token != js_lexer.TEqualsGreaterThan {
@@ -964,7 +1082,7 @@ func (p *parser) isTSArrowFnJSX() (isTSArrowFn bool) {
 	}
 	if p.lexer.Token == js_lexer.TIdentifier {
 		p.lexer.Next()
-		if p.lexer.Token == js_lexer.TComma || p.lexer.Token == js_lexer.TEquals {
+		if p.lexer.Token == js_lexer.TComma || p.lexer.Token == js_lexer.TEquals || (p.options.flow.Parse && p.lexer.Token == js_lexer.TColon) {
 			isTSArrowFn = true
 		} else if p.lexer.Token == js_lexer.TExtends {
 			p.lexer.Next()
@@ -1299,7 +1417,16 @@ func (p *parser) parseTypeScriptEnumStmt(loc logger.Loc, opts parseStmtOpts) js_
 	}
 
 	// Parse the body
-	for p.lexer.Token != js_lexer.TCloseBrace {
+	for {
+		if p.trailingComments != nil {
+			if len(values) > 0 {
+				p.saveTrailingComments(values[len(values)-1].Loc)
+			}
+			p.saveLeadingComments()
+		}
+		if p.lexer.Token == js_lexer.TCloseBrace {
+			break
+		}
 		nameRange := p.lexer.Range()
 		value := js_ast.EnumValue{
 			Loc: nameRange.Loc,
@@ -1363,6 +1490,9 @@ func (p *parser) parseTypeScriptEnumStmt(loc logger.Loc, opts parseStmtOpts) js_
 				p.log.AddMsg(logger.Msg{Kind: logger.Error, Data: data})
 				panic(js_lexer.LexerPanic{})
 			}
+			if p.trailingComments != nil {
+				p.saveTrailingComments(value.Loc)
+			}
 			break
 		}
 		p.lexer.Next()
@@ -1565,6 +1695,9 @@ func (p *parser) parseTypeScriptNamespaceStmt(loc logger.Loc, opts parseStmtOpts
 			isNamespaceScope:    true,
 			isTypeScriptDeclare: opts.isTypeScriptDeclare,
 		})}
+		if p.dts != nil {
+			p.dts.namespaces[nameLoc] = []dtsStmt{{data: stmts[0].Data}}
+		}
 	} else if opts.isTypeScriptDeclare && p.lexer.Token != js_lexer.TOpenBrace {
 		p.lexer.ExpectOrInsertSemicolon()
 	} else {
@@ -1573,6 +1706,9 @@ func (p *parser) parseTypeScriptNamespaceStmt(loc logger.Loc, opts parseStmtOpts
 			isNamespaceScope:    true,
 			isTypeScriptDeclare: opts.isTypeScriptDeclare,
 		})
+		if p.dts != nil {
+			p.dts.namespaces[nameLoc] = p.dts.stmts
+		}
 		p.lexer.Next()
 	}
 
@@ -1991,3 +2127,76 @@ func (p *parser) wrapInlinedEnum(value js_ast.Expr, comment string) js_ast.Expr
 		Comment: comment,
 	}}
 }
+
+// Some TypeScript syntax generates code instead of only being erased. This
+// can't be supported when only stripping types. It's also reported if the
+// "erasableSyntaxOnly" setting is enabled, which is useful for making sure
+// that code can be run by tools that only strip types such as node.
+func (p *parser) markNonErasableTypeScriptSyntax(r logger.Range, what string) {
+	if p.strippedTypeFixups != nil {
+		p.log.AddError(&p.tracker, r, fmt.Sprintf("%s are not supported when only stripping types because they generate code", what))
+	} else if p.options.ts.IsErasableSyntaxOnly() {
+		p.log.AddID(logger.MsgID_TS_ErasableSyntaxOnly, logger.Error, &p.tracker, r,
+			fmt.Sprintf("%s are not allowed when \"erasableSyntaxOnly\" is enabled", what))
+	}
+}
+
+func (p *parser) checkForNonErasableTypeScriptStmt(stmtLoc logger.Loc, stmt js_ast.Stmt) {
+	r := js_lexer.RangeOfIdentifier(p.source, stmt.Loc)
+
+	switch s := stmt.Data.(type) {
+	case *js_ast.STypeScript:
+		p.stripTypeOnlyStmtFrom(stmtLoc)
+
+	case *js_ast.SEnum:
+		p.markNonErasableTypeScriptSyntax(r, "Enums")
+
+	case *js_ast.SNamespace:
+		p.markNonErasableTypeScriptSyntax(r, "Namespaces containing values")
+
+	case *js_ast.SExportEquals:
+		p.markNonErasableTypeScriptSyntax(r, "Export assignments")
+
+	case *js_ast.SLocal:
+		if s.WasTSImportEquals {
+			p.markNonErasableTypeScriptSyntax(r, "Import assignments")
+		}
+	}
+}
+
+// Names are recorded without scope information since types aren't bound to
+// symbols. This is only used to tell if an import that is never used as a
+// value is used as a type, so a name collision only causes a false positive.
+func (p *parser) recordNameUsedInType(name string) {
+	if p.options.ts.IsErasableSyntaxOnly() {
+		if p.namesUsedInTypes == nil {
+			p.namesUsedInTypes = make(map[string]bool)
+		}
+		p.namesUsedInTypes[name] = true
+	}
+}
+
+// With "erasableSyntaxOnly", an import that is only used as a type must be
+// marked with "type". Otherwise a tool that only strips types would keep the
+// import, which then fails at run-time if the imported name is only a type.
+func (p *parser) checkForMissingTypeModifier(s *js_ast.SImport) {
+	check := func(ref ast.Ref, loc logger.Loc) {
+		name := p.symbols[ref.InnerIndex].OriginalName
+		if p.tsUseCounts[ref.InnerIndex] == 0 && p.namesUsedInTypes[name] {
+			p.log.AddID(logger.MsgID_TS_MissingTypeModifier, logger.Error, &p.tracker, js_lexer.RangeOfIdentifier(p.source, loc),
+				fmt.Sprintf("%q is only used as a type and must be imported using a type-only import when \"erasableSyntaxOnly\" is enabled", name))
+		}
+	}
+
+	if s.DefaultName != nil {
+		check(s.DefaultName.Ref, s.DefaultName.Loc)
+	}
+	if s.StarNameLoc != nil {
+		check(s.NamespaceRef, *s.StarNameLoc)
+	}
+	if s.Items != nil {
+		for _, item := range *s.Items {
+			check(item.Name.Ref, item.Name.Loc)
+		}
+	}
+}
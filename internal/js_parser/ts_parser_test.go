@@ -28,6 +28,18 @@ func expectParseErrorExperimentalDecoratorTS(t *testing.T, contents string, expe
 	})
 }
 
+func expectParseErrorErasableSyntaxOnlyTS(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectParseErrorCommon(t, contents, expected, config.Options{
+		TS: config.TSOptions{
+			Parse: true,
+			Config: config.TSConfig{
+				ErasableSyntaxOnly: config.True,
+			},
+		},
+	})
+}
+
 func expectParseErrorWithUnsupportedFeaturesTS(t *testing.T, unsupportedJSFeatures compat.JSFeature, contents string, expected string) {
 	t.Helper()
 	expectParseErrorCommon(t, contents, expected, config.Options{
@@ -59,6 +71,16 @@ func expectPrintedTS(t *testing.T, contents string, expected string) {
 	})
 }
 
+func expectPrintedPreserveCommentsTS(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		TS: config.TSOptions{
+			Parse: true,
+		},
+		PreserveComments: true,
+	})
+}
+
 func expectPrintedAssignSemanticsTS(t *testing.T, contents string, expected string) {
 	t.Helper()
 	expectPrintedCommon(t, contents, expected, config.Options{
@@ -98,6 +120,19 @@ func expectPrintedExperimentalDecoratorTS(t *testing.T, contents string, expecte
 	})
 }
 
+func expectPrintedDecoratorMetadataTS(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents, expected, config.Options{
+		TS: config.TSOptions{
+			Parse: true,
+			Config: config.TSConfig{
+				EmitDecoratorMetadata:  config.True,
+				ExperimentalDecorators: config.True,
+			},
+		},
+	})
+}
+
 func expectPrintedMangleTS(t *testing.T, contents string, expected string) {
 	t.Helper()
 	expectPrintedCommon(t, contents, expected, config.Options{
@@ -1999,6 +2034,126 @@ func TestTSExperimentalDecorator(t *testing.T) {
 	expectParseErrorExperimentalDecoratorTS(t, "@() => {} class Foo {}", "<stdin>: ERROR: Unexpected \")\"\n")
 }
 
+func TestTSDecoratorMetadata(t *testing.T) {
+	expectPrintedDecoratorMetadataTS(t, "class Foo { x: string }", "class Foo {\n  x;\n}\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { @dec foo(a: number, b: Bar, c): void {} }",
+		"class Foo {\n  foo(a, b, c) {\n  }\n}\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", Function),\n"+
+			"  __metadata(\"design:paramtypes\", [Number, typeof Bar === \"undefined\" ? Object : Bar, Object]),\n"+
+			"  __metadata(\"design:returntype\", void 0)\n], Foo.prototype, \"foo\", 1);\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { @dec foo(): x is string {} @dec async bar() {} }",
+		"class Foo {\n  foo() {\n  }\n  async bar() {\n  }\n}\n"+
+			"__decorateClass([\n  dec,\n  __metadata(\"design:type\", Function),\n  __metadata(\"design:paramtypes\", []),\n"+
+			"  __metadata(\"design:returntype\", Boolean)\n], Foo.prototype, \"foo\", 1);\n"+
+			"__decorateClass([\n  dec,\n  __metadata(\"design:type\", Function),\n  __metadata(\"design:paramtypes\", []),\n"+
+			"  __metadata(\"design:returntype\", Promise)\n], Foo.prototype, \"bar\", 1);\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { @dec get x(): boolean { return true } @dec set y(v: number[]) {} }",
+		"class Foo {\n  get x() {\n    return true;\n  }\n  set y(v) {\n  }\n}\n"+
+			"__decorateClass([\n  dec,\n  __metadata(\"design:type\", Boolean),\n  __metadata(\"design:paramtypes\", [])\n], Foo.prototype, \"x\", 1);\n"+
+			"__decorateClass([\n  dec,\n  __metadata(\"design:type\", Array),\n  __metadata(\"design:paramtypes\", [Array])\n], Foo.prototype, \"y\", 1);\n")
+
+	// Constructor parameters are described by the class decorators
+	expectPrintedDecoratorMetadataTS(t, "@dec class Foo { constructor(a: string | null, private b: Bar.Baz, c?: Date) {} }",
+		"let Foo = class {\n  constructor(a, b, c) {\n    this.b = b;\n  }\n};\nFoo = __decorateClass([\n  dec,\n"+
+			"  __metadata(\"design:paramtypes\", [String, typeof Bar === \"undefined\" || typeof Bar.Baz === \"undefined\" ? Object : Bar.Baz, Date])\n"+
+			"], Foo);\n")
+	expectPrintedDecoratorMetadataTS(t, "class Foo { constructor(@inject a: A) {} }",
+		"let Foo = class {\n  constructor(a) {\n  }\n};\nFoo = __decorateClass([\n  __decorateParam(0, inject),\n"+
+			"  __metadata(\"design:paramtypes\", [typeof A === \"undefined\" ? Object : A])\n], Foo);\n")
+	expectPrintedDecoratorMetadataTS(t, "@dec class Foo {}", "let Foo = class {\n};\nFoo = __decorateClass([\n  dec\n], Foo);\n")
+
+	// Check how each kind of type is serialized
+	expectType := func(prefix string, typeText string, expected string) {
+		t.Helper()
+		expectPrintedDecoratorMetadataTS(t, prefix+"class Foo { @dec x: "+typeText+" }",
+			"class Foo {\n  x;\n}\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", "+expected+")\n], Foo.prototype, \"x\", 2);\n")
+	}
+	expectType("", "any", "Object")
+	expectType("", "unknown", "Object")
+	expectType("", "object", "Object")
+	expectType("", "{ x: A }", "Object")
+	expectType("", "string", "String")
+	expectType("", "'x' | `y${z}`", "String")
+	expectType("", "number", "Number")
+	expectType("", "1 | -2", "Number")
+	expectType("", "bigint", "BigInt")
+	expectType("", "boolean", "Boolean")
+	expectType("", "true", "Boolean")
+	expectType("", "symbol", "Symbol")
+	expectType("", "unique symbol", "Symbol")
+	expectType("", "void", "void 0")
+	expectType("", "null | undefined", "void 0")
+	expectType("", "string | undefined | never", "String")
+	expectType("", "string | number", "Object")
+	expectType("", "(x: A) => B", "Function")
+	expectType("", "new () => A", "Function")
+	expectType("", "<T>(x: T) => T", "Function")
+	expectType("", "[A, B]", "Array")
+	expectType("", "readonly A[]", "Array")
+	expectType("", "Map<K, Array<V>>", "Map")
+	expectType("", "Promise<void>", "Promise")
+	expectType("", "T[K]", "Object")
+	expectType("", "A extends B ? string : 'x'", "String")
+	expectType("", "typeof x", "Object")
+	expectType("", "keyof T", "Object")
+	expectType("", "(string)", "String")
+	expectType("", "A", "typeof A === \"undefined\" ? Object : A")
+	expectType("", "A<B>", "typeof A === \"undefined\" ? Object : A")
+	expectType("interface A {} ", "A", "Object")
+	expectType("type A = string; ", "A", "Object")
+
+	// Local values don't need to be checked, and imports are no longer unused
+	expectPrintedDecoratorMetadataTS(t, "class A {} class Foo { @dec x: A }",
+		"class A {\n}\nclass Foo {\n  x;\n}\n__decorateClass([\n  dec,\n  __metadata(\"design:type\", A)\n], Foo.prototype, \"x\", 2);\n")
+	expectPrintedDecoratorMetadataTS(t, "import { A } from 'a'; class Foo { @dec x: A }",
+		"import { A } from \"a\";\nclass Foo {\n  x;\n}\n__decorateClass([\n  dec,\n"+
+			"  __metadata(\"design:type\", typeof A === \"undefined\" ? Object : A)\n], Foo.prototype, \"x\", 2);\n")
+	expectPrintedDecoratorMetadataTS(t, "import type { A } from 'a'; class Foo { @dec x: A }",
+		"class Foo {\n  x;\n}\n__decorateClass([\n  dec,\n"+
+			"  __metadata(\"design:type\", typeof A === \"undefined\" ? Object : A)\n], Foo.prototype, \"x\", 2);\n")
+
+	// Enums are serialized as the type of their values
+	expectPrintedDecoratorMetadataTS(t, "enum A { X } enum B { X = 'x' } enum C { X = 1, Y = 'y' } class Foo { @dec a: A; @dec b: B; @dec c: C }",
+		"var A = /* @__PURE__ */ ((A) => {\n  A[A[\"X\"] = 0] = \"X\";\n  return A;\n})(A || {});\n"+
+			"var B = /* @__PURE__ */ ((B) => {\n  B[\"X\"] = \"x\";\n  return B;\n})(B || {});\n"+
+			"var C = /* @__PURE__ */ ((C) => {\n  C[C[\"X\"] = 1] = \"X\";\n  C[\"Y\"] = \"y\";\n  return C;\n})(C || {});\n"+
+			"class Foo {\n  a;\n  b;\n  c;\n}\n"+
+			"__decorateClass([\n  dec,\n  __metadata(\"design:type\", Number)\n], Foo.prototype, \"a\", 2);\n"+
+			"__decorateClass([\n  dec,\n  __metadata(\"design:type\", String)\n], Foo.prototype, \"b\", 2);\n"+
+			"__decorateClass([\n  dec,\n  __metadata(\"design:type\", Object)\n], Foo.prototype, \"c\", 2);\n")
+}
+
+func TestTSPreserveAllComments(t *testing.T) {
+	expectPrintedPreserveCommentsTS(t, "/** a */\nenum Foo {\n/** b */\nX, // c\nY = 2 // d\n}",
+		"/** a */\nvar Foo = /* @__PURE__ */ ((Foo) => {\n  /** b */\n  Foo[Foo[\"X\"] = 0] = \"X\"; // c\n  Foo[Foo[\"Y\"] = 2] = \"Y\"; // d\n  return Foo;\n})(Foo || {});\n")
+	expectPrintedPreserveCommentsTS(t, "/** a */\nfunction foo(x: number): void {} // b",
+		"/** a */\nfunction foo(x) {\n} // b\n")
+	expectPrintedPreserveCommentsTS(t, "class Foo {\n/** a */\nprivate x: number = 1 // b\n}",
+		"class Foo {\n  /** a */\n  x = 1; // b\n}\n")
+	expectPrintedPreserveCommentsTS(t, "/** a */\nnamespace ns {\n// b\nexport let x = 1 // c\n}",
+		"/** a */\nvar ns;\n((ns) => {\n  // b\n  ns.x = 1; // c\n})(ns || (ns = {}));\n")
+
+	// Comments before statements that are removed are moved to the next statement
+	expectPrintedPreserveCommentsTS(t, "// a\n\n// b\nimport type { T } from './t'\nexport const x = 1",
+		"// a\n// b\nexport const x = 1;\n")
+	expectPrintedPreserveCommentsTS(t, "/** a */\ninterface I {} // b\n/** c */\ntype T = I\nlet x = 1",
+		"/** a */\n/** c */\nlet x = 1;\n")
+	expectPrintedPreserveCommentsTS(t, "// a\nimport { T } from './t' // b\nlet x: T = 1",
+		"// a\nlet x = 1;\n")
+	expectPrintedPreserveCommentsTS(t, "// a\nimport c = foo.c\n// b\nlet x = 1",
+		"// a\n// b\nlet x = 1;\n")
+	expectPrintedPreserveCommentsTS(t, "let x = 1\n// a\ndeclare let y: number // b",
+		"let x = 1;\n// a\n")
+	expectPrintedPreserveCommentsTS(t, "let x = 1\n// a\nimport { T } from './t'",
+		"let x = 1;\n// a\n")
+
+	// Trailing comments of statements that are removed are removed too
+	expectPrintedPreserveCommentsTS(t, "interface I {} // c", "")
+	expectPrintedPreserveCommentsTS(t, "type T = number // c\nlet x: T = 1 // d",
+		"let x = 1; // d\n")
+	expectPrintedPreserveCommentsTS(t, "// a\nimport { T } from './t' // b\n// c\nlet x: T = 1",
+		"// a\n// c\nlet x = 1;\n")
+}
+
 func TestTSDecorators(t *testing.T) {
 	expectPrintedTS(t, "@x @y class Foo {}", "@x\n@y\nclass Foo {\n}\n")
 	expectPrintedTS(t, "@x @y export class Foo {}", "@x\n@y\nexport class Foo {\n}\n")
@@ -2037,14 +2192,14 @@ func TestTSDecorators(t *testing.T) {
 	expectParseErrorTS(t, "@new Function() class Foo {}", "<stdin>: ERROR: Expected identifier but found \"new\"\n")
 	expectParseErrorTS(t, "@() => {} class Foo {}", "<stdin>: ERROR: Unexpected \")\"\n")
 
-	errorText := "<stdin>: ERROR: Transforming JavaScript decorators to the configured target environment is not supported yet\n"
-	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "@dec class Foo {}", errorText)
-	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec x }", errorText)
-	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec x() {} }", errorText)
-	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec accessor x }", errorText)
-	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec static x }", errorText)
-	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec static x() {} }", errorText)
-	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec static accessor x }", errorText)
+	// JavaScript decorators can be lowered
+	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "@dec class Foo {}", "")
+	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec x }", "")
+	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec x() {} }", "")
+	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec accessor x }", "")
+	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec static x }", "")
+	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec static x() {} }", "")
+	expectParseErrorWithUnsupportedFeaturesTS(t, compat.Decorators, "class Foo { @dec static accessor x }", "")
 }
 
 func TestTSTry(t *testing.T) {
@@ -2138,8 +2293,8 @@ func TestTSArrow(t *testing.T) {
 	expectPrintedTS(t, "function x(): ({y: z}) {}", "function x() {\n}\n")
 
 	expectParseErrorTargetTS(t, 5, "return check ? (hover = 2, bar) : baz()", "")
-	expectParseErrorTargetTS(t, 5, "return check ? (hover = 2, bar) => 0 : baz()",
-		"<stdin>: ERROR: Transforming default arguments to the configured target environment is not supported yet\n")
+	expectPrintedTargetTS(t, 5, "return check ? (hover = 2, bar) => 0 : baz()",
+		"return check ? function() {\n  var hover = arguments[0] === void 0 ? 2 : arguments[0];\n  var bar = arguments[1];\n  return 0;\n} : baz();\n")
 }
 
 func TestTSSuperCall(t *testing.T) {
@@ -2661,6 +2816,51 @@ func TestTSTypeOnlyExport(t *testing.T) {
 	expectPrintedTS(t, "export type * as 'f o' from 'bar'; foo", "foo;\n")
 }
 
+func TestTSErasableSyntaxOnly(t *testing.T) {
+	expectParseErrorErasableSyntaxOnlyTS(t, "enum Foo { A }",
+		"<stdin>: ERROR: Enums are not allowed when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "export const enum Foo { A }",
+		"<stdin>: ERROR: Enums are not allowed when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "namespace Foo { let x }",
+		"<stdin>: ERROR: Namespaces containing values are not allowed when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "namespace Foo.Bar { let x }",
+		"<stdin>: ERROR: Namespaces containing values are not allowed when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "class Foo { constructor(private x, protected y) {} }",
+		"<stdin>: ERROR: Parameter properties are not allowed when \"erasableSyntaxOnly\" is enabled\n"+
+			"<stdin>: ERROR: Parameter properties are not allowed when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import x = require('x')",
+		"<stdin>: ERROR: Import assignments are not allowed when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "export import x = y.z",
+		"<stdin>: ERROR: Import assignments are not allowed when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "export = x",
+		"<stdin>: ERROR: Export assignments are not allowed when \"erasableSyntaxOnly\" is enabled\n")
+
+	// Syntax that is only types is fine
+	expectParseErrorErasableSyntaxOnlyTS(t, "declare enum Foo { A }", "")
+	expectParseErrorErasableSyntaxOnlyTS(t, "declare namespace Foo { let x }", "")
+	expectParseErrorErasableSyntaxOnlyTS(t, "namespace Foo { export type T = number }", "")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import type x = require('x')", "")
+	expectParseErrorErasableSyntaxOnlyTS(t, "class Foo { constructor(x: number) {} }", "")
+	expectParseErrorErasableSyntaxOnlyTS(t, "let x: number = y as any", "")
+
+	// Imports that are only used as types must be marked as type-only imports
+	expectParseErrorErasableSyntaxOnlyTS(t, "import { A } from 'x'; let y: A",
+		"<stdin>: ERROR: \"A\" is only used as a type and must be imported using a type-only import when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import { A as B } from 'x'; let y: B<number>",
+		"<stdin>: ERROR: \"B\" is only used as a type and must be imported using a type-only import when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import A from 'x'; class Foo implements A {}",
+		"<stdin>: ERROR: \"A\" is only used as a type and must be imported using a type-only import when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import * as ns from 'x'; let y: ns.A",
+		"<stdin>: ERROR: \"ns\" is only used as a type and must be imported using a type-only import when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import { A } from 'x'; let y: typeof A",
+		"<stdin>: ERROR: \"A\" is only used as a type and must be imported using a type-only import when \"erasableSyntaxOnly\" is enabled\n")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import { type A } from 'x'; let y: A", "")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import type { A } from 'x'; let y: A", "")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import { A } from 'x'; let y: A = new A", "")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import { A } from 'x'; export { A }; let y: A", "")
+	expectParseErrorErasableSyntaxOnlyTS(t, "import { A } from 'x'", "")
+}
+
 func TestTSOptionalChain(t *testing.T) {
 	expectParseError(t, "a?.<T>()", "<stdin>: ERROR: Expected identifier but found \"<\"\n")
 	expectParseError(t, "a?.<<T>() => T>()", "<stdin>: ERROR: Expected identifier but found \"<<\"\n")
@@ -2911,8 +3111,8 @@ func TestTSES5(t *testing.T) {
 	expectPrintedTargetTS(t, 5, "0 ? ({}) : 0", "0 ? {} : 0;\n")
 	expectPrintedTargetTS(t, 2015, "0 ? ([]): 0 => 0 : 0", "0 ? ([]) => 0 : 0;\n")
 	expectPrintedTargetTS(t, 2015, "0 ? ({}): 0 => 0 : 0", "0 ? ({}) => 0 : 0;\n")
-	expectParseErrorTargetTS(t, 5, "0 ? ([]): 0 => 0 : 0", "<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
-	expectParseErrorTargetTS(t, 5, "0 ? ({}): 0 => 0 : 0", "<stdin>: ERROR: Transforming destructuring to the configured target environment is not supported yet\n")
+	expectPrintedTargetTS(t, 5, "0 ? ([]): 0 => 0 : 0", "0 ? function(_a) {\n  var _b = __toArray(_a, 0);\n  return 0;\n} : 0;\n")
+	expectPrintedTargetTS(t, 5, "0 ? ({}): 0 => 0 : 0", "0 ? function(_a) {\n  var _b = __requireObject(_a);\n  return 0;\n} : 0;\n")
 }
 
 func TestTSUsing(t *testing.T) {
@@ -0,0 +1,201 @@
+package js_parser
+
+// This file implements a mode that only strips TypeScript types. Instead of
+// printing the AST, every type annotation is replaced with whitespace in the
+// original source text. All remaining tokens keep their original line and
+// column, so the output doesn't need a source map. This is only possible for
+// TypeScript syntax that has no runtime semantics, so syntax such as enums
+// and parameter properties is reported as an error instead.
+//
+// The parser records the ranges of type syntax while parsing. The recorded
+// ranges are stored on the lexer so that they are rewound when the parser
+// backtracks. Nothing is visited or printed, so this is much faster than a
+// normal parse.
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/evanw/esbuild/internal/js_ast"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+)
+
+func StripTypes(log logger.Log, source logger.Source, options Options) (result string, ok bool) {
+	ok = true
+	defer func() {
+		r := recover()
+		if _, isLexerPanic := r.(js_lexer.LexerPanic); isLexerPanic {
+			ok = false
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	p := newParser(log, source, js_lexer.NewLexer(log, source, options.ts), &options)
+	p.strippedTypeFixups = make(map[int32]byte)
+
+	// Consume a leading hashbang comment
+	if p.lexer.Token == js_lexer.THashbang {
+		p.lexer.Next()
+	}
+
+	// Allow top-level await
+	p.fnOrArrowDataParse.await = allowExpr
+	p.fnOrArrowDataParse.isTopLevel = true
+
+	p.parseStmtsUpTo(js_lexer.TEndOfFile, parseStmtOpts{
+		isModuleScope:          true,
+		allowDirectivePrologue: true,
+	})
+
+	result = p.replaceStrippedTypesWithWhitespace()
+	return
+}
+
+// This records the range from the start location to the end of the previous
+// token as type syntax
+func (p *parser) stripTypesFrom(start logger.Loc) {
+	if p.strippedTypeFixups != nil {
+		if end := p.lexer.PrevTokenEnd().Start; end > start.Start {
+			p.lexer.StrippedTypeRanges = append(p.lexer.StrippedTypeRanges, logger.Range{Loc: start, Len: end - start.Start})
+		}
+	}
+}
+
+func (p *parser) stripTypesInRange(r logger.Range) {
+	if p.strippedTypeFixups != nil && r.Len > 0 {
+		p.lexer.StrippedTypeRanges = append(p.lexer.StrippedTypeRanges, r)
+	}
+}
+
+// This is used for type-only statements and class members. Removing them may
+// join the previous and next statements together, so a semicolon is inserted
+// if the next token could continue the previous statement:
+//
+//	let x = y
+//	type T = any
+//	(z || w).foo()
+//
+//	class Foo {
+//	  x = y
+//	  abstract foo(): void
+//	  *bar() {}
+//	}
+func (p *parser) stripTypeOnlyStmtFrom(start logger.Loc) {
+	if p.strippedTypeFixups != nil && p.lexer.PrevTokenEnd().Start > start.Start {
+		p.stripTypesFrom(start)
+		switch p.lexer.Token {
+		case js_lexer.TOpenParen, js_lexer.TOpenBracket, js_lexer.TNoSubstitutionTemplateLiteral, js_lexer.TTemplateHead,
+			js_lexer.TPlus, js_lexer.TMinus, js_lexer.TSlash, js_lexer.TSlashEquals, js_lexer.TLessThan, js_lexer.TAsterisk:
+			p.strippedTypeFixups[start.Start] = ';'
+		}
+	}
+}
+
+// This parses a statement that is the body of another statement, such as an
+// "if" statement or a loop. A type-only statement in this position must still
+// leave an empty statement behind. Otherwise the next statement would become
+// the body instead:
+//
+//	if (a) type T = U
+//	else b()
+//
+//	while (a()) type T = U
+//	b()
+func (p *parser) parseNestedStmt(opts parseStmtOpts) js_ast.Stmt {
+	start := p.lexer.Loc()
+	stmt := p.parseStmt(opts)
+	if p.strippedTypeFixups != nil && p.lexer.PrevTokenEnd().Start > start.Start {
+		if _, ok := stmt.Data.(*js_ast.STypeScript); ok {
+			p.stripTypesFrom(start)
+			p.strippedTypeFixups[start.Start] = ';'
+		}
+	}
+	return stmt
+}
+
+// Replacing an arrow function's return type with whitespace is a problem if
+// the return type contains a newline, since there can't be a newline between
+// the arguments and the "=>" token. The ")" token is moved to the end of the
+// return type to fix this:
+//
+//	// Original code
+//	let fn = (x): Promise<
+//	  string
+//	> => x
+//
+//	// Stripped code
+//	let fn = (x
+//
+//	) => x
+func (p *parser) stripArrowReturnType(closeParenLoc logger.Loc) {
+	if p.strippedTypeFixups != nil {
+		typeEnd := p.lexer.PrevTokenEnd().Start
+		if strings.ContainsAny(p.source.Contents[closeParenLoc.Start:typeEnd], "\r\n\u2028\u2029") {
+			_, width := utf8.DecodeLastRuneInString(p.source.Contents[:typeEnd])
+			p.stripTypesFrom(closeParenLoc)
+			p.strippedTypeFixups[typeEnd-int32(width)] = ')'
+		} else {
+			p.stripTypesFrom(logger.Loc{Start: closeParenLoc.Start + 1})
+		}
+	}
+}
+
+func (p *parser) replaceStrippedTypesWithWhitespace() string {
+	contents := p.source.Contents
+	ranges := p.lexer.StrippedTypeRanges
+	if len(ranges) == 0 {
+		return contents
+	}
+
+	sort.SliceStable(ranges, func(i int, j int) bool {
+		return ranges[i].Loc.Start < ranges[j].Loc.Start
+	})
+
+	sb := strings.Builder{}
+	sb.Grow(len(contents))
+	end := int32(0)
+
+	for _, r := range ranges {
+		start := r.Loc.Start
+		if start < end {
+			// Ranges nest, so skip over anything that was already stripped
+			start = end
+		}
+		if r.End() <= start {
+			continue
+		}
+		sb.WriteString(contents[end:start])
+
+		// Keep line and column numbers the same by keeping newlines and by
+		// writing one space per UTF-16 code unit. Column numbers in source maps
+		// and in JavaScript stack traces are measured in UTF-16 code units.
+		for i := start; i < r.End(); {
+			c, width := utf8.DecodeRuneInString(contents[i:])
+			if fixup, ok := p.strippedTypeFixups[i]; ok {
+				sb.WriteByte(fixup)
+				if c >= 0x10000 {
+					sb.WriteByte(' ')
+				}
+			} else {
+				switch c {
+				case '\r', '\n', '\u2028', '\u2029':
+					sb.WriteString(contents[i : i+int32(width)])
+				default:
+					if c >= 0x10000 {
+						sb.WriteString("  ")
+					} else {
+						sb.WriteByte(' ')
+					}
+				}
+			}
+			i += int32(width)
+		}
+		end = r.End()
+	}
+
+	sb.WriteString(contents[end:])
+	return sb.String()
+}
@@ -0,0 +1,208 @@
+package js_parser
+
+import (
+	"testing"
+
+	"github.com/evanw/esbuild/internal/config"
+	"github.com/evanw/esbuild/internal/logger"
+	"github.com/evanw/esbuild/internal/test"
+)
+
+func expectStrippedCommon(t *testing.T, contents string, expected string, options config.Options) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+		result, ok := StripTypes(log, test.SourceForTest(contents), OptionsFromConfig(&options))
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			if msg.Kind != logger.Warning {
+				text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+			}
+		}
+		test.AssertEqualWithDiff(t, text, "")
+		if !ok {
+			t.Fatal("Parse error")
+		}
+		test.AssertEqualWithDiff(t, result, expected)
+	})
+}
+
+func expectStripped(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectStrippedCommon(t, contents, expected, config.Options{
+		TS: config.TSOptions{
+			Parse: true,
+		},
+	})
+}
+
+func expectStrippedTSX(t *testing.T, contents string, expected string) {
+	t.Helper()
+	expectStrippedCommon(t, contents, expected, config.Options{
+		TS: config.TSOptions{
+			Parse: true,
+		},
+		JSX: config.JSXOptions{
+			Parse: true,
+		},
+	})
+}
+
+func expectStripTypesError(t *testing.T, contents string, expected string) {
+	t.Helper()
+	t.Run(contents, func(t *testing.T) {
+		t.Helper()
+		log := logger.NewDeferLog(logger.DeferLogNoVerboseOrDebug, nil)
+		options := config.Options{
+			TS: config.TSOptions{
+				Parse: true,
+			},
+		}
+		StripTypes(log, test.SourceForTest(contents), OptionsFromConfig(&options))
+		msgs := log.Done()
+		text := ""
+		for _, msg := range msgs {
+			text += msg.String(logger.OutputOptions{}, logger.TerminalInfo{})
+		}
+		test.AssertEqualWithDiff(t, text, expected)
+	})
+}
+
+func TestStripTypesAnnotations(t *testing.T) {
+	expectStripped(t, "let x: number = 1", "let x         = 1")
+	expectStripped(t, "let x!: number", "let x         ")
+	expectStripped(t, "let x: Array<T>= y", "let x          = y")
+	expectStripped(t, "let x: Map<A, Set<B>>", "let x                ")
+	expectStripped(t, "function f(x: T, y?: U): V {}", "function f(x   , y    )    {}")
+	expectStripped(t, "function f<T>(x: T) {}", "function f   (x   ) {}")
+	expectStripped(t, "function f(this: Foo, x) {}", "function f(           x) {}")
+	expectStripped(t, "function f(this: Foo) {}", "function f(         ) {}")
+	expectStripped(t, "function f(): asserts x is T {}", "function f()                 {}")
+	expectStripped(t, "let x = y as T", "let x = y     ")
+	expectStripped(t, "let x = y satisfies T", "let x = y            ")
+	expectStripped(t, "let x = y!", "let x = y ")
+	expectStripped(t, "let x = y!.z", "let x = y .z")
+	expectStripped(t, "let x = <T>y", "let x =    y")
+	expectStripped(t, "let x = f<T>(y)", "let x = f   (y)")
+	expectStripped(t, "let x = new Foo<T>()", "let x = new Foo   ()")
+	expectStripped(t, "let x = f<T>", "let x = f   ")
+	expectStripped(t, "let x = a < b", "let x = a < b")
+	expectStripped(t, "let x = \"\U0001F600\" as T", "let x = \"\U0001F600\"     ")
+	expectStripped(t, "let x: \"\U0001F600\" = 1", "let x       = 1")
+	expectStripped(t, "let x: {\n  a: A\n  b: B\n} = y", "let x   \n      \n      \n  = y")
+}
+
+func TestStripTypesArrow(t *testing.T) {
+	expectStripped(t, "let f = (x: T): U => x", "let f = (x   )    => x")
+	expectStripped(t, "let f = <T,>(x: T) => x", "let f =     (x   ) => x")
+	expectStripped(t, "let f = async (x?: T): Promise<U> => x", "let f = async (x    )             => x")
+	expectStripped(t, "let f = (x): Promise<\n  U\n> => x", "let f = (x           \n   \n) => x")
+	expectStripped(t, "let f = (x): Promise<\r\n  U\r\n> => x", "let f = (x           \r\n   \r\n) => x")
+	expectStripped(t, "let x = a ? (b): T => c : d", "let x = a ? (b)    => c : d")
+}
+
+func TestStripTypesStatements(t *testing.T) {
+	expectStripped(t, "type T = number\nlet x", "               \nlet x")
+	expectStripped(t, "interface I { x: number }\nlet x", "                         \nlet x")
+	expectStripped(t, "declare let x: number\nlet y", "                     \nlet y")
+	expectStripped(t, "declare module 'foo' {}\nlet y", "                       \nlet y")
+	expectStripped(t, "declare enum E { A }\nlet y", "                    \nlet y")
+	expectStripped(t, "declare namespace N { let x }\nlet y", "                             \nlet y")
+	expectStripped(t, "namespace N { type T = number }\nlet y", "                               \nlet y")
+	expectStripped(t, "export type T = number\nlet x", "                      \nlet x")
+	expectStripped(t, "export interface I {}\nlet x", "                     \nlet x")
+	expectStripped(t, "export declare let x\nlet y", "                    \nlet y")
+	expectStripped(t, "function f(): void\nfunction f() {}", "                  \nfunction f() {}")
+	expectStripped(t, "export function f(): void\nexport function f() {}", "                         \nexport function f() {}")
+	expectStripped(t, "abstract class Foo {}", "         class Foo {}")
+	expectStripped(t, "export abstract class Foo {}", "export          class Foo {}")
+	expectStripped(t, "export default abstract class {}", "export default          class {}")
+	expectStripped(t, "function f() {\n  type T = U\n  return 1\n}", "function f() {\n            \n  return 1\n}")
+
+	// A semicolon must be inserted if the next statement could continue the previous one
+	expectStripped(t, "let x = y\ntype T = U\n(z || w).foo()", "let x = y\n;         \n(z || w).foo()")
+	expectStripped(t, "let x = y\ntype T = U\n[z].forEach(f)", "let x = y\n;         \n[z].forEach(f)")
+	expectStripped(t, "let x = y\ntype T = U\n`z`", "let x = y\n;         \n`z`")
+	expectStripped(t, "let x = y\ntype T = U\n-z", "let x = y\n;         \n-z")
+	expectStripped(t, "let x = y\ntype T = U\nz", "let x = y\n          \nz")
+	expectStripped(t, "let x = y\ninterface I {}\n(z)", "let x = y\n;             \n(z)")
+
+	// An empty statement must be left behind if only a single statement is allowed
+	expectStripped(t, "if (a) type Q = 1;\nb()", "if (a) ;          \nb()")
+	expectStripped(t, "if (a) type Q = 1\nelse b()", "if (a) ;         \nelse b()")
+	expectStripped(t, "if (a) b()\nelse type Q = 1\nc()", "if (a) b()\nelse ;         \nc()")
+	expectStripped(t, "while (a()) interface I {}\nb()", "while (a()) ;             \nb()")
+	expectStripped(t, "do type Q = 1; while (a())", "do ;           while (a())")
+	expectStripped(t, "for (;;) declare let x: number\nb()", "for (;;) ;                    \nb()")
+	expectStripped(t, "for (x of y) type Q = 1\nb()", "for (x of y) ;         \nb()")
+	expectStripped(t, "label: type Q = 1\nb()", "label: ;         \nb()")
+	expectStripped(t, "if (a) { type Q = 1 }", "if (a) {            }")
+}
+
+func TestStripTypesImportsAndExports(t *testing.T) {
+	expectStripped(t, "import type { A } from 'x'\nlet y", "                          \nlet y")
+	expectStripped(t, "import type A from 'x'\nlet y", "                      \nlet y")
+	expectStripped(t, "export type { A } from 'x'\nlet y", "                          \nlet y")
+	expectStripped(t, "export type { A }\nlet y", "                 \nlet y")
+	expectStripped(t, "import { type A, B } from 'x'", "import {         B } from 'x'")
+	expectStripped(t, "import { B, type A } from 'x'", "import { B,        } from 'x'")
+	expectStripped(t, "import { type A } from 'x'", "import {        } from 'x'")
+	expectStripped(t, "import { type A as C, B } from 'x'", "import {              B } from 'x'")
+	expectStripped(t, "import { type, B } from 'x'", "import { type, B } from 'x'")
+	expectStripped(t, "import { type as } from 'x'", "import {         } from 'x'")
+	expectStripped(t, "import { type as as } from 'x'", "import { type as as } from 'x'")
+	expectStripped(t, "export { type A, B }", "export {         B }")
+	expectStripped(t, "export { type A, B } from 'x'", "export {         B } from 'x'")
+
+	// Imports that are only used as types are not removed
+	expectStripped(t, "import { A } from 'x'\nlet y: A", "import { A } from 'x'\nlet y   ")
+}
+
+func TestStripTypesClasses(t *testing.T) {
+	expectStripped(t, "class Foo<T> extends Bar<T> implements I, J<T> {}", "class Foo    extends Bar                       {}")
+	expectStripped(t, "class Foo { x: number = 1 }", "class Foo { x         = 1 }")
+	expectStripped(t, "class Foo { x?: number; y!: string }", "class Foo { x         ; y          }")
+	expectStripped(t, "class Foo { private x; protected y; public z }", "class Foo {         x;           y;        z }")
+	expectStripped(t, "class Foo { readonly x = 1; static override y }", "class Foo {          x = 1; static          y }")
+	expectStripped(t, "class Foo { foo<T>(x: T): T {} }", "class Foo { foo   (x   )    {} }")
+	expectStripped(t, "class Foo { foo?() {} }", "class Foo { foo () {} }")
+	expectStripped(t, "class Foo { [key: string]: any }", "class Foo {                    }")
+	expectStripped(t, "class Foo { declare x: number }", "class Foo {                   }")
+	expectStripped(t, "abstract class Foo { abstract foo(): void }", "         class Foo {                      }")
+	expectStripped(t, "class Foo { foo(): void\nfoo() {} }", "class Foo {            \nfoo() {} }")
+
+	// A semicolon must be inserted if the next member could continue the previous one
+	expectStripped(t, "class Foo {\n  x = y\n  foo(): void\n  [z]() {}\n}", "class Foo {\n  x = y\n  ;          \n  [z]() {}\n}")
+	expectStripped(t, "class Foo {\n  x = y\n  foo(): void\n  *bar() {}\n}", "class Foo {\n  x = y\n  ;          \n  *bar() {}\n}")
+}
+
+func TestStripTypesTSX(t *testing.T) {
+	expectStrippedTSX(t, "let x = <div a={b as T}>{c!}</div>", "let x = <div a={b     }>{c }</div>")
+	expectStrippedTSX(t, "let f = <T,>(x: T) => <T>x</T>", "let f =     (x   ) => <T>x</T>")
+}
+
+func TestStripTypesErrors(t *testing.T) {
+	expectStripTypesError(t, "enum E { A }",
+		"<stdin>: ERROR: Enums are not supported when only stripping types because they generate code\n")
+	expectStripTypesError(t, "export const enum E { A }",
+		"<stdin>: ERROR: Enums are not supported when only stripping types because they generate code\n")
+	expectStripTypesError(t, "namespace N { let x }",
+		"<stdin>: ERROR: Namespaces containing values are not supported when only stripping types because they generate code\n")
+	expectStripTypesError(t, "module N { let x }",
+		"<stdin>: ERROR: Namespaces containing values are not supported when only stripping types because they generate code\n")
+	expectStripTypesError(t, "import x = require('x')",
+		"<stdin>: ERROR: Import assignments are not supported when only stripping types because they generate code\n")
+	expectStripTypesError(t, "import x = y.z",
+		"<stdin>: ERROR: Import assignments are not supported when only stripping types because they generate code\n")
+	expectStripTypesError(t, "export = x",
+		"<stdin>: ERROR: Export assignments are not supported when only stripping types because they generate code\n")
+	expectStripTypesError(t, "class Foo { constructor(private x) {} }",
+		"<stdin>: ERROR: Parameter properties are not supported when only stripping types because they generate code\n")
+	expectStripTypesError(t, "class Foo { constructor(public readonly x) {} }",
+		"<stdin>: ERROR: Parameter properties are not supported when only stripping types because they generate code\n")
+
+	// Type-only imports that look like import assignments are fine
+	expectStripTypesError(t, "import type x = require('x')", "")
+}
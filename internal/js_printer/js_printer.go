@@ -354,6 +354,8 @@ type printer struct {
 	callTarget             js_ast.E
 	exprComments           map[logger.Loc][]string
 	printedExprComments    map[logger.Loc]bool
+	trailingComments       map[logger.Loc][]string
+	printedTrailing        map[logger.Loc]bool
 	hasLegalComment        map[string]struct{}
 	extractedLegalComments []string
 	js                     []byte
@@ -361,6 +363,7 @@ type printer struct {
 	binaryExprStack        []binaryExprVisitor
 	options                Options
 	builder                sourcemap.ChunkBuilder
+	indentText             string
 
 	stmtStart          int
 	exportDefaultStart int
@@ -373,17 +376,51 @@ type printer struct {
 	noLeadingNewlineHere int
 	oldLineStart         int
 	oldLineEnd           int
+	asiStart             int
 	intToBytesBuffer     [64]byte
 	needsSemicolon       bool
+	needsASISemicolon    bool
+	omitSemicolons       bool
+	isMeasuring          bool
 	wasLazyExport        bool
 	prevOp               js_ast.OpCode
 	moduleType           js_ast.ModuleType
 }
 
 func (p *printer) print(text string) {
+	if p.needsASISemicolon {
+		p.printASISemicolonIfNeeded(text)
+	}
 	p.js = append(p.js, text...)
 }
 
+// When semicolons are omitted, a statement that starts with one of these
+// characters would be joined onto the end of the previous statement by
+// automatic semicolon insertion. In that case we print a semicolon before it.
+func (p *printer) printASISemicolonIfNeeded(text string) {
+	if len(p.js) > p.asiStart {
+		// Something that doesn't need a semicolon (e.g. an identifier) has
+		// already been printed without going through "print"
+		p.needsASISemicolon = false
+		return
+	}
+
+	// A "/* @__PURE__ */" comment is treated as part of the statement after it
+	// so that the semicolon doesn't end up between the comment and the call
+	trimmed := strings.TrimLeft(text, " \t\n")
+	if trimmed == "" || strings.HasPrefix(trimmed, "//") || (strings.HasPrefix(trimmed, "/*") && !strings.HasPrefix(trimmed, "/* @__PURE__ */")) {
+		// Whitespace and comments don't start the next statement
+		p.asiStart = len(p.js) + len(text)
+		return
+	}
+
+	p.needsASISemicolon = false
+	switch trimmed[0] {
+	case '(', '[', '`', '+', '-', '/', '<':
+		p.js = append(p.js, ';')
+	}
+}
+
 // This is the same as "print(string(bytes))" without any unnecessary temporary
 // allocations
 func (p *printer) printBytes(bytes []byte) {
@@ -420,11 +457,11 @@ func (p *printer) addSourceMappingForName(loc logger.Loc, name string, ref ast.R
 func (p *printer) printIndent() {
 	if !p.options.MinifyWhitespace {
 		indent := p.options.Indent
-		if p.options.LineLimit > 0 && indent*2 >= p.options.LineLimit {
-			indent = p.options.LineLimit / 2
+		if width := len(p.indentText); p.options.LineLimit > 0 && indent*width >= p.options.LineLimit {
+			indent = p.options.LineLimit / width
 		}
 		for i := 0; i < indent; i++ {
-			p.print("  ")
+			p.print(p.indentText)
 		}
 	}
 }
@@ -437,29 +474,36 @@ func (p *printer) mangledPropName(ref ast.Ref) string {
 	return p.renamer.NameForSymbol(ref)
 }
 
-func (p *printer) tryToGetImportedEnumValue(target js_ast.Expr, name string) (js_ast.TSEnumValue, bool) {
+func (p *printer) tryToGetImportedEnum(target js_ast.Expr) (map[string]js_ast.TSEnumValue, bool) {
 	if id, ok := target.Data.(*js_ast.EImportIdentifier); ok {
 		ref := ast.FollowSymbols(p.symbols, id.Ref)
 		if symbol := p.symbols.Get(ref); symbol.Kind == ast.SymbolTSEnum {
-			if enum, ok := p.options.TSEnums[ref]; ok {
-				value, ok := enum[name]
-				return value, ok
-			}
+			enum, ok := p.options.TSEnums[ref]
+			return enum, ok
+		}
+
+		// Frozen objects are inlined like enums unless they are mutated here
+		if !p.symbols.Get(id.Ref).Flags.Has(ast.PropertiesCouldPotentiallyBeMutated) {
+			enum, ok := p.options.ConstObjects[ref]
+			return enum, ok
 		}
 	}
+	return nil, false
+}
+
+func (p *printer) tryToGetImportedEnumValue(target js_ast.Expr, name string) (js_ast.TSEnumValue, bool) {
+	if enum, ok := p.tryToGetImportedEnum(target); ok {
+		value, ok := enum[name]
+		return value, ok
+	}
 	return js_ast.TSEnumValue{}, false
 }
 
 func (p *printer) tryToGetImportedEnumValueUTF16(target js_ast.Expr, name []uint16) (js_ast.TSEnumValue, string, bool) {
-	if id, ok := target.Data.(*js_ast.EImportIdentifier); ok {
-		ref := ast.FollowSymbols(p.symbols, id.Ref)
-		if symbol := p.symbols.Get(ref); symbol.Kind == ast.SymbolTSEnum {
-			if enum, ok := p.options.TSEnums[ref]; ok {
-				name := helpers.UTF16ToString(name)
-				value, ok := enum[name]
-				return value, name, ok
-			}
-		}
+	if enum, ok := p.tryToGetImportedEnum(target); ok {
+		name := helpers.UTF16ToString(name)
+		value, ok := enum[name]
+		return value, name, ok
 	}
 	return js_ast.TSEnumValue{}, "", false
 }
@@ -667,6 +711,12 @@ func (p *printer) printBinding(binding js_ast.Binding) {
 			}
 
 			if isMultiLine {
+				// "[a, ...b,] = c" is a syntax error
+				if n := len(b.Items); n > 0 && !b.HasSpread {
+					if _, ok := b.Items[n-1].Binding.Data.(*js_ast.BMissing); !ok {
+						p.printTrailingComma(config.TrailingCommasES5)
+					}
+				}
 				p.printNewline()
 				p.printExprCommentsAfterCloseTokenAtLoc(b.CloseBracketLoc)
 				p.options.Indent--
@@ -804,6 +854,10 @@ func (p *printer) printBinding(binding js_ast.Binding) {
 			}
 
 			if isMultiLine {
+				// "{a, ...b,} = c" is a syntax error
+				if n := len(b.Properties); n > 0 && !b.Properties[n-1].IsSpread {
+					p.printTrailingComma(config.TrailingCommasES5)
+				}
 				p.printNewline()
 				p.printExprCommentsAfterCloseTokenAtLoc(b.CloseBraceLoc)
 				p.options.Indent--
@@ -829,10 +883,93 @@ func (p *printer) printSpace() {
 
 func (p *printer) printNewline() {
 	if !p.options.MinifyWhitespace {
+		if p.isMeasuring {
+			panic(measuredLine{})
+		}
 		p.print("\n")
 	}
 }
 
+// This is thrown by "printNewline" to stop measuring once the first line has
+// been printed. We only care about whether the first line fits.
+type measuredLine struct{}
+
+// This prints something using a throwaway printer to check whether it would
+// fit on the current line without exceeding the print width. Nested lists are
+// measured as if they were printed on one line too.
+func (p *printer) fitsInPrintWidth(suffixLen int, print func(q *printer)) bool {
+	q := &printer{
+		symbols:       p.symbols,
+		isUnbound:     p.isUnbound,
+		renamer:       p.renamer,
+		importRecords: p.importRecords,
+		exprComments:  p.exprComments,
+		options:       p.options,
+		indentText:    p.indentText,
+		moduleType:    p.moduleType,
+		wasLazyExport: p.wasLazyExport,
+		isMeasuring:   true,
+
+		stmtStart:          -1,
+		exportDefaultStart: -1,
+		arrowExprStart:     -1,
+		forOfInitStart:     -1,
+
+		prevOpEnd:            -1,
+		needSpaceBeforeDot:   -1,
+		prevRegExpEnd:        -1,
+		noLeadingNewlineHere: -1,
+	}
+	q.options.AddSourceMappings = false
+	q.options.NeedsMetafile = false
+	q.options.LineLimit = 0
+	q.options.Formatting.PrintWidth = 0
+	if q.exprComments != nil {
+		q.printedExprComments = make(map[logger.Loc]bool)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				if _, ok := r.(measuredLine); !ok {
+					panic(r)
+				}
+			}
+		}()
+		print(q)
+	}()
+
+	width := len(q.js)
+	if i := bytes.IndexByte(q.js, '\n'); i != -1 {
+		width = i
+	}
+	return p.currentLineLength()+width+suffixLen <= p.options.Formatting.PrintWidth
+}
+
+func (p *printer) argsFitInPrintWidth(args []js_ast.Expr) bool {
+	return p.fitsInPrintWidth(0, func(q *printer) {
+		q.print("(")
+		for i, arg := range args {
+			if i != 0 {
+				q.print(", ")
+			}
+			q.printExpr(arg, js_ast.LComma, 0)
+		}
+		q.print(")")
+	})
+}
+
+func (p *printer) canWrapForPrintWidth() bool {
+	return p.options.Formatting.PrintWidth > 0 && !p.options.MinifyWhitespace
+}
+
+// Trailing commas are only printed after the last item of a multi-line list
+func (p *printer) printTrailingComma(minimum config.TrailingCommas) {
+	if p.options.Formatting.TrailingCommas >= minimum && !p.options.MinifyWhitespace {
+		p.print(",")
+	}
+}
+
 func (p *printer) currentLineLength() int {
 	js := p.js
 	n := len(js)
@@ -880,7 +1017,11 @@ func (p *printer) printSpaceBeforeOperator(next js_ast.OpCode) {
 }
 
 func (p *printer) printSemicolonAfterStatement() {
-	if !p.options.MinifyWhitespace {
+	if p.omitSemicolons {
+		p.print("\n")
+		p.needsASISemicolon = true
+		p.asiStart = len(p.js)
+	} else if !p.options.MinifyWhitespace {
 		p.print(";\n")
 	} else {
 		p.needsSemicolon = true
@@ -917,17 +1058,31 @@ func (p *printer) printFnArgs(args []js_ast.Arg, opts fnArgsOpts) {
 		}
 	}
 
+	isMultiLine := false
+	if wrap && len(args) > 0 && p.canWrapForPrintWidth() {
+		isMultiLine = !p.fitsInPrintWidth(0, func(q *printer) { q.printFnArgs(args, opts) })
+	}
+
 	if wrap {
 		if opts.addMappingForOpenParenLoc {
 			p.addSourceMapping(opts.openParenLoc)
 		}
 		p.print("(")
 	}
+	if isMultiLine {
+		p.options.Indent++
+	}
 
 	for i, arg := range args {
 		if i != 0 {
 			p.print(",")
-			p.printSpace()
+			if !isMultiLine {
+				p.printSpace()
+			}
+		}
+		if isMultiLine {
+			p.printNewline()
+			p.printIndent()
 		}
 		p.printDecorators(arg.Decorators, printDecoratorsAllOnOneLine)
 		if opts.hasRestArg && i+1 == len(args) {
@@ -943,6 +1098,15 @@ func (p *printer) printFnArgs(args []js_ast.Arg, opts fnArgsOpts) {
 		}
 	}
 
+	if isMultiLine {
+		// "function(a, ...b,) {}" is a syntax error
+		if !opts.hasRestArg {
+			p.printTrailingComma(config.TrailingCommasAll)
+		}
+		p.printNewline()
+		p.options.Indent--
+		p.printIndent()
+	}
 	if wrap {
 		p.print(")")
 	}
@@ -1051,17 +1215,25 @@ func (p *printer) printClass(class js_ast.Class) {
 			p.printSpace()
 			p.printBlock(item.ClassStaticBlock.Loc, item.ClassStaticBlock.Block)
 			p.printNewline()
+			p.printTrailingCommentsBeforeNewline(item.Loc)
 			continue
 		}
 
 		p.printProperty(item)
 
-		// Need semicolons after class fields
+		// Need semicolons after class fields. These are kept even when other
+		// semicolons are omitted because there are too many ways for the next
+		// class member to continue the field's initializer.
 		if item.ValueOrNil.Data == nil {
-			p.printSemicolonAfterStatement()
+			if p.omitSemicolons {
+				p.print(";\n")
+			} else {
+				p.printSemicolonAfterStatement()
+			}
 		} else {
 			p.printNewline()
 		}
+		p.printTrailingCommentsBeforeNewline(item.Loc)
 	}
 
 	p.needsSemicolon = false
@@ -1201,7 +1373,7 @@ func (p *printer) printProperty(property js_ast.Property) {
 
 	switch key := property.Key.Data.(type) {
 	case *js_ast.EPrivateIdentifier:
-		name := p.renamer.NameForSymbol(key.Ref)
+		name := p.mangledPropName(key.Ref)
 		p.addSourceMappingForName(property.Key.Loc, name, key.Ref)
 		p.printIdentifier(name)
 
@@ -1356,13 +1528,20 @@ func (p *printer) printQuotedUTF16(data []uint16, flags printQuotedFlags) {
 		}
 	}
 
-	c := "\""
-	if doubleCost > singleCost {
-		c = "'"
-		if singleCost > backtickCost && (flags&printQuotedAllowBacktick) != 0 {
+	// Prefer the configured quote character if there is no cost difference
+	preferred, preferredCost := "\"", doubleCost
+	other, otherCost := "'", singleCost
+	if p.options.Formatting.Quotes == config.QuotesSingle {
+		preferred, preferredCost, other, otherCost = other, otherCost, preferred, preferredCost
+	}
+
+	c := preferred
+	if preferredCost > otherCost {
+		c = other
+		if otherCost > backtickCost && (flags&printQuotedAllowBacktick) != 0 {
 			c = "`"
 		}
-	} else if doubleCost > backtickCost && (flags&printQuotedAllowBacktick) != 0 {
+	} else if preferredCost > backtickCost && (flags&printQuotedAllowBacktick) != 0 {
 		c = "`"
 	}
 
@@ -1470,10 +1649,9 @@ func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_as
 		}
 		isMultiLine := p.willPrintExprCommentsAtLoc(record.Range.Loc) ||
 			p.willPrintExprCommentsAtLoc(closeParenLoc) ||
-			(record.Assertions != nil &&
+			(p.canPrintAssertOrWith(record.AssertOrWith) &&
 				!p.options.UnsupportedFeatures.Has(compat.DynamicImport) &&
-				!p.options.UnsupportedFeatures.Has(compat.ImportAssertions) &&
-				p.willPrintExprCommentsAtLoc(record.Assertions.OuterOpenBraceLoc))
+				p.willPrintExprCommentsAtLoc(record.AssertOrWith.OuterOpenBraceLoc))
 		if isMultiLine {
 			p.printNewline()
 			p.options.Indent++
@@ -1482,7 +1660,7 @@ func (p *printer) printRequireOrImportExpr(importRecordIndex uint32, level js_as
 		p.printExprCommentsAtLoc(record.Range.Loc)
 		p.printPath(importRecordIndex, kind)
 		if !p.options.UnsupportedFeatures.Has(compat.DynamicImport) {
-			p.printImportCallAssertions(record.Assertions, isMultiLine)
+			p.printImportCallAssertOrWith(record.AssertOrWith, isMultiLine)
 		}
 		if isMultiLine {
 			p.printNewline()
@@ -1678,11 +1856,88 @@ func (p *printer) simplifyUnusedExpr(expr js_ast.Expr) js_ast.Expr {
 				return js_ast.SimplifyUnusedExpr(p.simplifyUnusedExpr(arg), p.options.UnsupportedFeatures, p.isUnbound)
 			}
 		}
+
+		// Inline other small functions at print time
+		if inlined, ok := p.tryToInlineFunctionCall(e, expr.Loc); ok {
+			return js_ast.SimplifyUnusedExpr(inlined, p.options.UnsupportedFeatures, p.isUnbound)
+		}
 	}
 
 	return expr
 }
 
+func (p *printer) inlinedFunctionForCall(e *js_ast.ECall) (js_ast.InlinableFunction, bool) {
+	if e.HasInlinableArgs {
+		var ref ast.Ref
+		switch target := e.Target.Data.(type) {
+		case *js_ast.EIdentifier:
+			ref = target.Ref
+		case *js_ast.EImportIdentifier:
+			ref = ast.FollowSymbols(p.symbols, target.Ref)
+		default:
+			return js_ast.InlinableFunction{}, false
+		}
+		if fn, ok := p.options.InlinedFunctions[ref]; ok && !p.symbols.Get(ref).Flags.Has(ast.CouldPotentiallyBeMutated) {
+			return fn, true
+		}
+	}
+	return js_ast.InlinableFunction{}, false
+}
+
+func (p *printer) tryToInlineFunctionCall(e *js_ast.ECall, loc logger.Loc) (js_ast.Expr, bool) {
+	// Functions with a statement body are handled by "printInlinedFunctionStmts"
+	if fn, ok := p.inlinedFunctionForCall(e); ok && fn.Stmts == nil {
+		return js_ast.InlineFunctionCall(fn, e.Args, loc), true
+	}
+	return js_ast.Expr{}, false
+}
+
+// A function with a statement body is only inlined at its single call site,
+// which is an entire top-level statement. The function body is moved into a
+// block there, with the arguments declared as local variables. The linker has
+// already made sure that the local variables have names that don't conflict
+// with anything at the call site. The statements may come from another file,
+// so source mappings and comments (which are keyed by location) are omitted.
+func (p *printer) printInlinedFunctionStmts(loc logger.Loc, fn js_ast.InlinableFunction, values []js_ast.Expr) {
+	stmts := make([]js_ast.Stmt, 0, len(fn.Stmts)+1)
+	if len(fn.Args) > 0 {
+		decls := make([]js_ast.Decl, len(fn.Args))
+		for i, arg := range fn.Args {
+			decls[i].Binding = js_ast.Binding{Loc: loc, Data: &js_ast.BIdentifier{Ref: arg}}
+			if i < len(values) {
+				decls[i].ValueOrNil = values[i]
+			}
+		}
+		stmts = append(stmts, js_ast.Stmt{Loc: loc, Data: &js_ast.SLocal{Kind: js_ast.LocalLet, Decls: decls}})
+	}
+	for i, stmt := range fn.Stmts {
+		if ret, ok := stmt.Data.(*js_ast.SReturn); ok && i+1 == len(fn.Stmts) {
+			// The return value is unused since the call was a statement
+			if ret.ValueOrNil.Data != nil {
+				if value := js_ast.SimplifyUnusedExpr(ret.ValueOrNil, p.options.UnsupportedFeatures, p.isUnbound); value.Data != nil {
+					stmts = append(stmts, js_ast.Stmt{Loc: stmt.Loc, Data: &js_ast.SExpr{Value: value}})
+				}
+			}
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	p.addSourceMapping(loc)
+	p.printIndent()
+	oldAddSourceMappings := p.options.AddSourceMappings
+	oldExprComments := p.exprComments
+	oldTrailingComments := p.trailingComments
+	p.options.AddSourceMappings = false
+	p.exprComments = nil
+	p.trailingComments = nil
+	p.printBlock(loc, js_ast.SBlock{Stmts: stmts})
+	p.options.AddSourceMappings = oldAddSourceMappings
+	p.exprComments = oldExprComments
+	p.trailingComments = oldTrailingComments
+	p.printNewline()
+}
+
 // This assumes the original expression was some form of indirect value, such
 // as a value returned from a function call or the result of a comma operator.
 // In this case, there is no special behavior with the "delete" operator or
@@ -1913,6 +2168,43 @@ func (p *printer) printExprCommentsAfterCloseTokenAtLoc(loc logger.Loc) {
 	}
 }
 
+func (p *printer) willPrintTrailingCommentsAtLoc(loc logger.Loc) bool {
+	return !p.options.MinifyWhitespace && p.trailingComments[loc] != nil && !p.printedTrailing[loc]
+}
+
+// Trailing comments are printed on the same line as the code they come after
+func (p *printer) printTrailingCommentsAtLoc(loc logger.Loc) {
+	if p.willPrintTrailingCommentsAtLoc(loc) {
+		for _, comment := range p.trailingComments[loc] {
+			// Avoid generating a comment containing the character sequence "</script"
+			if !p.options.UnsupportedFeatures.Has(compat.InlineScript) {
+				comment = helpers.EscapeClosingTag(comment, "/script")
+			}
+			p.print(" ")
+			p.print(comment)
+		}
+		p.printedTrailing[loc] = true
+	}
+}
+
+// Statements and class members end with a newline, so the comments need to be
+// printed before it. A single-line comment must still be followed by one.
+func (p *printer) printTrailingCommentsBeforeNewline(loc logger.Loc) {
+	if p.willPrintTrailingCommentsAtLoc(loc) {
+		if n := len(p.js); n > 0 && p.js[n-1] == '\n' {
+			p.js = p.js[:n-1]
+			p.printTrailingCommentsAtLoc(loc)
+			p.print("\n")
+		} else {
+			for _, comment := range p.trailingComments[loc] {
+				p.printIndent()
+				p.printIndentedComment(comment)
+			}
+			p.printedTrailing[loc] = true
+		}
+	}
+}
+
 func (p *printer) printExprWithoutLeadingNewline(expr js_ast.Expr, level js_ast.L, flags printExprFlags) {
 	if !p.options.MinifyWhitespace && p.willPrintExprCommentsAtLoc(expr.Loc) {
 		p.print("(")
@@ -2224,6 +2516,9 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		isMultiLine := !p.options.MinifyWhitespace && ((e.IsMultiLine && len(e.Args) > 0) ||
 			p.willPrintExprCommentsForAnyOf(e.Args) ||
 			p.willPrintExprCommentsAtLoc(e.CloseParenLoc))
+		if !isMultiLine && len(e.Args) > 0 && p.canWrapForPrintWidth() {
+			isMultiLine = !p.argsFitInPrintWidth(e.Args)
+		}
 		if !p.options.MinifyWhitespace || len(e.Args) > 0 || level >= js_ast.LPostfix || isMultiLine {
 			needsNewline := true
 			p.print("(")
@@ -2248,6 +2543,9 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 				needsNewline = true
 			}
 			if isMultiLine {
+				if len(e.Args) > 0 {
+					p.printTrailingComma(config.TrailingCommasAll)
+				}
 				if needsNewline || p.willPrintExprCommentsAtLoc(e.CloseParenLoc) {
 					p.printNewline()
 				}
@@ -2303,6 +2601,12 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 					break
 				}
 			}
+
+			// Inline other small functions at print time
+			if inlined, ok := p.tryToInlineFunctionCall(e, expr.Loc); ok {
+				p.printExpr(p.guardAgainstBehaviorChangeDueToSubstitution(inlined, flags), level, flags)
+				break
+			}
 		}
 
 		wrap := level >= js_ast.LNew || (flags&forbidCall) != 0
@@ -2348,6 +2652,9 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		isMultiLine := !p.options.MinifyWhitespace && ((e.IsMultiLine && len(e.Args) > 0) ||
 			p.willPrintExprCommentsForAnyOf(e.Args) ||
 			p.willPrintExprCommentsAtLoc(e.CloseParenLoc))
+		if !isMultiLine && len(e.Args) > 0 && p.canWrapForPrintWidth() {
+			isMultiLine = !p.argsFitInPrintWidth(e.Args)
+		}
 		p.print("(")
 		if isMultiLine {
 			p.options.Indent++
@@ -2367,6 +2674,9 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 			p.printExpr(arg, js_ast.LComma, 0)
 		}
 		if isMultiLine {
+			if len(e.Args) > 0 {
+				p.printTrailingComma(config.TrailingCommasAll)
+			}
 			p.printNewline()
 			p.printExprCommentsAfterCloseTokenAtLoc(e.CloseParenLoc)
 			p.options.Indent--
@@ -2421,11 +2731,12 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		p.printRequireOrImportExpr(e.ImportRecordIndex, level, flags, e.CloseParenLoc)
 
 	case *js_ast.EImportCall:
-		// Just omit import assertions if they aren't supported
-		printImportAssertions := e.OptionsOrNil.Data != nil && !p.options.UnsupportedFeatures.Has(compat.ImportAssertions)
+		// Just omit import assertions and attributes if neither is supported
+		printImportAssertOrWith := e.OptionsOrNil.Data != nil && (!p.options.UnsupportedFeatures.Has(compat.ImportAssertions) ||
+			!p.options.UnsupportedFeatures.Has(compat.ImportAttributes))
 		isMultiLine := !p.options.MinifyWhitespace &&
 			(p.willPrintExprCommentsAtLoc(e.Expr.Loc) ||
-				(printImportAssertions && p.willPrintExprCommentsAtLoc(e.OptionsOrNil.Loc)) ||
+				(printImportAssertOrWith && p.willPrintExprCommentsAtLoc(e.OptionsOrNil.Loc)) ||
 				p.willPrintExprCommentsAtLoc(e.CloseParenLoc))
 		wrap := level >= js_ast.LNew || (flags&forbidCall) != 0
 		if wrap {
@@ -2441,7 +2752,7 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		}
 		p.printExpr(e.Expr, js_ast.LComma, 0)
 
-		if printImportAssertions {
+		if printImportAssertOrWith {
 			p.print(",")
 			if isMultiLine {
 				p.printNewline()
@@ -2468,6 +2779,12 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		if e.OptionalChain == js_ast.OptionalChainNone {
 			flags |= hasNonOptionalChainParent
 
+			// Properties of collapsed objects are separate variables
+			if ref, ok := p.tryToGetCollapsedProperty(e); ok {
+				p.printExpr(js_ast.Expr{Loc: expr.Loc, Data: &js_ast.EIdentifier{Ref: ref}}, level, flags)
+				break
+			}
+
 			// Inline cross-module TypeScript enum references here
 			if value, ok := p.tryToGetImportedEnumValue(e.Target, e.Name); ok {
 				if value.String != nil {
@@ -2555,7 +2872,7 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 			if e.OptionalChain != js_ast.OptionalChainStart {
 				p.print(".")
 			}
-			name := p.renamer.NameForSymbol(index.Ref)
+			name := p.mangledPropName(index.Ref)
 			p.addSourceMappingForName(e.Index.Loc, name, index.Ref)
 			p.printIdentifier(name)
 			return
@@ -2733,6 +3050,9 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 
 	case *js_ast.EArray:
 		isMultiLine := (len(e.Items) > 0 && !e.IsSingleLine) || p.willPrintExprCommentsForAnyOf(e.Items) || p.willPrintExprCommentsAtLoc(e.CloseBracketLoc)
+		if !isMultiLine && len(e.Items) > 0 && p.canWrapForPrintWidth() {
+			isMultiLine = !p.fitsInPrintWidth(0, func(q *printer) { q.printExpr(expr, level, flags) })
+		}
 		p.addSourceMapping(expr.Loc)
 		p.print("[")
 		if len(e.Items) > 0 || isMultiLine {
@@ -2762,6 +3082,14 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 			}
 
 			if isMultiLine {
+				if n := len(e.Items); n > 0 {
+					switch e.Items[n-1].Data.(type) {
+					case *js_ast.EMissing, *js_ast.ESpread:
+						// "[a, ...b,] = c" is a syntax error
+					default:
+						p.printTrailingComma(config.TrailingCommasES5)
+					}
+				}
 				p.printNewline()
 				p.printExprCommentsAfterCloseTokenAtLoc(e.CloseBracketLoc)
 				p.options.Indent--
@@ -2777,7 +3105,7 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		isMultiLine := (len(e.Properties) > 0 && !e.IsSingleLine) || p.willPrintExprCommentsAtLoc(e.CloseBraceLoc)
 		if !p.options.MinifyWhitespace && !isMultiLine {
 			for _, property := range e.Properties {
-				if p.willPrintExprCommentsAtLoc(property.Loc) {
+				if p.willPrintExprCommentsAtLoc(property.Loc) || p.willPrintTrailingCommentsAtLoc(property.Loc) {
 					isMultiLine = true
 					break
 				}
@@ -2785,6 +3113,9 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 		}
 		n := len(p.js)
 		wrap := p.stmtStart == n || p.arrowExprStart == n
+		if !isMultiLine && len(e.Properties) > 0 && p.canWrapForPrintWidth() {
+			isMultiLine = !p.fitsInPrintWidth(0, func(q *printer) { q.printExpr(expr, level, flags) })
+		}
 		if wrap {
 			p.print("(")
 		}
@@ -2798,6 +3129,7 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 			for i, item := range e.Properties {
 				if i != 0 {
 					p.print(",")
+					p.printTrailingCommentsAtLoc(e.Properties[i-1].Loc)
 				}
 				if p.options.LineLimit <= 0 || !p.printNewlinePastLineLimit() {
 					if isMultiLine {
@@ -2811,6 +3143,13 @@ func (p *printer) printExpr(expr js_ast.Expr, level js_ast.L, flags printExprFla
 			}
 
 			if isMultiLine {
+				if n := len(e.Properties); n > 0 {
+					// "({a, ...b,} = c)" is a syntax error
+					if e.Properties[n-1].Kind != js_ast.PropertySpread {
+						p.printTrailingComma(config.TrailingCommasES5)
+					}
+					p.printTrailingCommentsAtLoc(e.Properties[n-1].Loc)
+				}
 				p.printNewline()
 				p.printExprCommentsAfterCloseTokenAtLoc(e.CloseBraceLoc)
 				p.options.Indent--
@@ -3311,7 +3650,7 @@ func (v *binaryExprVisitor) checkAndPrepare(p *printer) bool {
 
 	// Special-case "#foo in bar"
 	if private, ok := e.Left.Data.(*js_ast.EPrivateIdentifier); ok && e.Op == js_ast.BinOpIn {
-		name := p.renamer.NameForSymbol(private.Ref)
+		name := p.mangledPropName(private.Ref)
 		p.addSourceMappingForName(e.Left.Loc, name, private.Ref)
 		p.printIdentifier(name)
 		v.visitRightAndFinish(p)
@@ -3591,6 +3930,10 @@ func (p *printer) printForLoopInit(init js_ast.Stmt, flags printExprFlags) {
 }
 
 func (p *printer) printDecls(keyword string, decls []js_ast.Decl, flags printExprFlags) {
+	if p.options.CollapsedObjects != nil {
+		decls = p.collapseObjectDecls(decls)
+	}
+
 	p.print(keyword)
 	p.printSpace()
 
@@ -3612,6 +3955,84 @@ func (p *printer) printDecls(keyword string, decls []js_ast.Decl, flags printExp
 	}
 }
 
+// "const a = { b: { c: 1 } }" => "const a$b$c = 1"
+func (p *printer) collapseObjectDecls(decls []js_ast.Decl) []js_ast.Decl {
+	var result []js_ast.Decl
+	for i, decl := range decls {
+		if id, ok := decl.Binding.Data.(*js_ast.BIdentifier); ok {
+			if leafRefs, ok := p.options.CollapsedObjects[id.Ref]; ok {
+				if result == nil {
+					result = append([]js_ast.Decl{}, decls[:i]...)
+				}
+				result = appendCollapsedObjectDecls(result, decl.ValueOrNil.Data.(*js_ast.EObject), "", leafRefs)
+				continue
+			}
+		}
+		if result != nil {
+			result = append(result, decl)
+		}
+	}
+	if result == nil {
+		return decls
+	}
+	return result
+}
+
+func appendCollapsedObjectDecls(decls []js_ast.Decl, object *js_ast.EObject, prefix string, leafRefs map[string]ast.Ref) []js_ast.Decl {
+	for _, property := range object.Properties {
+		path := prefix + helpers.UTF16ToString(property.Key.Data.(*js_ast.EString).Value)
+		if ref, ok := leafRefs[path]; ok {
+			if ref == ast.InvalidRef {
+				continue // This property is never used
+			}
+			decls = append(decls, js_ast.Decl{
+				Binding:    js_ast.Binding{Loc: property.Key.Loc, Data: &js_ast.BIdentifier{Ref: ref}},
+				ValueOrNil: property.ValueOrNil,
+			})
+		} else {
+			decls = appendCollapsedObjectDecls(decls, property.ValueOrNil.Data.(*js_ast.EObject), path+".", leafRefs)
+		}
+	}
+	return decls
+}
+
+func (p *printer) tryToGetCollapsedProperty(e *js_ast.EDot) (ast.Ref, bool) {
+	if p.options.CollapsedObjects == nil {
+		return ast.Ref{}, false
+	}
+
+	// Find the object at the root of this property access chain
+	var root ast.Ref
+	names := []string{e.Name}
+	target := e.Target
+	for {
+		if dot, ok := target.Data.(*js_ast.EDot); ok && dot.OptionalChain == js_ast.OptionalChainNone {
+			names = append(names, dot.Name)
+			target = dot.Target
+			continue
+		}
+		switch t := target.Data.(type) {
+		case *js_ast.EIdentifier:
+			root = t.Ref
+		case *js_ast.EImportIdentifier:
+			root = ast.FollowSymbols(p.symbols, t.Ref)
+		default:
+			return ast.Ref{}, false
+		}
+		break
+	}
+
+	leafRefs, ok := p.options.CollapsedObjects[root]
+	if !ok {
+		return ast.Ref{}, false
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	ref, ok := leafRefs[strings.Join(names, ".")]
+	return ref, ok && ref != ast.InvalidRef
+}
+
 func (p *printer) printBody(body js_ast.Stmt) {
 	if block, ok := body.Data.(*js_ast.SBlock); ok {
 		p.printSpace()
@@ -3770,6 +4191,15 @@ func (p *printer) printIndentedComment(text string) {
 		text = helpers.EscapeClosingTag(text, "/script")
 	}
 
+	// The lines of a multi-line comment don't start the next statement
+	if p.needsASISemicolon {
+		p.needsASISemicolon = false
+		defer func() {
+			p.needsASISemicolon = true
+			p.asiStart = len(p.js)
+		}()
+	}
+
 	if strings.HasPrefix(text, "/*") {
 		// Re-indent multi-line comments
 		for {
@@ -3806,31 +4236,33 @@ func (p *printer) printPath(importRecordIndex uint32, importKind ast.ImportKind)
 			external))
 	}
 
-	// Just omit import assertions if they aren't supported
-	if p.options.UnsupportedFeatures.Has(compat.ImportAssertions) {
-		return
-	}
-
-	if record.Assertions != nil && importKind == ast.ImportStmt {
+	if importKind == ast.ImportStmt && p.canPrintAssertOrWith(record.AssertOrWith) {
 		p.printSpace()
-		p.addSourceMapping(record.Assertions.AssertLoc)
-		p.print("assert")
+		p.addSourceMapping(record.AssertOrWith.KeywordLoc)
+		p.print(record.AssertOrWith.Keyword.String())
 		p.printSpace()
-		p.printImportAssertionsClause(*record.Assertions)
+		p.printImportAssertOrWithClause(*record.AssertOrWith)
 	}
 }
 
-func (p *printer) printImportCallAssertions(assertions *ast.ImportAssertions, outerIsMultiLine bool) {
-	// Just omit import assertions if they aren't supported
-	if p.options.UnsupportedFeatures.Has(compat.ImportAssertions) {
-		return
+// Import assertions and import attributes are separate features, so just omit
+// the clause if the keyword that it uses isn't supported
+func (p *printer) canPrintAssertOrWith(assertOrWith *ast.ImportAssertOrWith) bool {
+	if assertOrWith == nil {
+		return false
 	}
+	if assertOrWith.Keyword == ast.AssertKeyword {
+		return !p.options.UnsupportedFeatures.Has(compat.ImportAssertions)
+	}
+	return !p.options.UnsupportedFeatures.Has(compat.ImportAttributes)
+}
 
-	if assertions == nil {
+func (p *printer) printImportCallAssertOrWith(assertions *ast.ImportAssertOrWith, outerIsMultiLine bool) {
+	if !p.canPrintAssertOrWith(assertions) {
 		return
 	}
 
-	isMultiLine := p.willPrintExprCommentsAtLoc(assertions.AssertLoc) ||
+	isMultiLine := p.willPrintExprCommentsAtLoc(assertions.KeywordLoc) ||
 		p.willPrintExprCommentsAtLoc(assertions.InnerOpenBraceLoc) ||
 		p.willPrintExprCommentsAtLoc(assertions.OuterCloseBraceLoc)
 
@@ -3853,20 +4285,21 @@ func (p *printer) printImportCallAssertions(assertions *ast.ImportAssertions, ou
 		p.printSpace()
 	}
 
-	p.printExprCommentsAtLoc(assertions.AssertLoc)
-	p.addSourceMapping(assertions.AssertLoc)
-	p.print("assert:")
+	p.printExprCommentsAtLoc(assertions.KeywordLoc)
+	p.addSourceMapping(assertions.KeywordLoc)
+	p.print(assertions.Keyword.String())
+	p.print(":")
 
 	if p.willPrintExprCommentsAtLoc(assertions.InnerOpenBraceLoc) {
 		p.printNewline()
 		p.options.Indent++
 		p.printIndent()
 		p.printExprCommentsAtLoc(assertions.InnerOpenBraceLoc)
-		p.printImportAssertionsClause(*assertions)
+		p.printImportAssertOrWithClause(*assertions)
 		p.options.Indent--
 	} else {
 		p.printSpace()
-		p.printImportAssertionsClause(*assertions)
+		p.printImportAssertOrWithClause(*assertions)
 	}
 
 	if isMultiLine {
@@ -3882,7 +4315,7 @@ func (p *printer) printImportCallAssertions(assertions *ast.ImportAssertions, ou
 	p.print("}")
 }
 
-func (p *printer) printImportAssertionsClause(assertions ast.ImportAssertions) {
+func (p *printer) printImportAssertOrWithClause(assertions ast.ImportAssertOrWith) {
 	isMultiLine := p.willPrintExprCommentsAtLoc(assertions.InnerCloseBraceLoc)
 	if !isMultiLine {
 		for _, entry := range assertions.Entries {
@@ -3960,6 +4393,12 @@ func (p *printer) printStmt(stmt js_ast.Stmt, flags printStmtFlags) {
 		p.printNewlinePastLineLimit()
 	}
 
+	// Comments attached to this statement go before and after it
+	if p.trailingComments != nil {
+		p.printExprCommentsAfterCloseTokenAtLoc(stmt.Loc)
+		defer p.printTrailingCommentsBeforeNewline(stmt.Loc)
+	}
+
 	switch s := stmt.Data.(type) {
 	case *js_ast.SComment:
 		text := s.Text
@@ -4153,6 +4592,9 @@ func (p *printer) printStmt(stmt js_ast.Stmt, flags printStmtFlags) {
 		}
 
 		if !s.IsSingleLine {
+			if len(s.Items) > 0 {
+				p.printTrailingComma(config.TrailingCommasES5)
+			}
 			p.options.Indent--
 			p.printNewline()
 			p.printIndent()
@@ -4200,6 +4642,9 @@ func (p *printer) printStmt(stmt js_ast.Stmt, flags printStmtFlags) {
 		}
 
 		if !s.IsSingleLine {
+			if len(s.Items) > 0 {
+				p.printTrailingComma(config.TrailingCommasES5)
+			}
 			p.options.Indent--
 			p.printNewline()
 			p.printIndent()
@@ -4519,6 +4964,9 @@ func (p *printer) printStmt(stmt js_ast.Stmt, flags printStmtFlags) {
 
 		for _, c := range s.Cases {
 			p.printSemicolonIfNeeded()
+			if p.trailingComments != nil {
+				p.printExprCommentsAfterCloseTokenAtLoc(c.Loc)
+			}
 			p.printIndent()
 			p.addSourceMapping(c.Loc)
 
@@ -4611,6 +5059,9 @@ func (p *printer) printStmt(stmt js_ast.Stmt, flags printStmtFlags) {
 			}
 
 			if !s.IsSingleLine {
+				if len(*s.Items) > 0 {
+					p.printTrailingComma(config.TrailingCommasES5)
+				}
 				p.options.Indent--
 				p.printNewline()
 				p.printIndent()
@@ -4716,6 +5167,14 @@ func (p *printer) printStmt(stmt js_ast.Stmt, flags printStmtFlags) {
 	case *js_ast.SExpr:
 		value := s.Value
 
+		// Replace the only call to a function with a statement body with its body
+		if call, ok := value.Data.(*js_ast.ECall); ok {
+			if fn, ok := p.inlinedFunctionForCall(call); ok && fn.Stmts != nil {
+				p.printInlinedFunctionStmts(stmt.Loc, fn, call.Args)
+				break
+			}
+		}
+
 		// Omit calls to empty functions from the output completely
 		if p.options.MinifySyntax {
 			value = p.simplifyUnusedExpr(value)
@@ -4759,6 +5218,16 @@ type Options struct {
 	// Cross-module inlining of detected inlinable constants is also done during printing
 	ConstValues map[ast.Ref]js_ast.ConstValue
 
+	// Property accesses off of frozen objects are inlined like TypeScript enums
+	ConstObjects map[ast.Ref]map[string]js_ast.TSEnumValue
+
+	// Calls to these functions are replaced with the function body if the call
+	// has "HasInlinableArgs" set
+	InlinedFunctions map[ast.Ref]js_ast.InlinableFunction
+
+	// The properties of these objects are printed as separate variables
+	CollapsedObjects map[ast.Ref]map[string]ast.Ref
+
 	// Property mangling results go here
 	MangledProps map[ast.Ref]string
 
@@ -4776,6 +5245,7 @@ type Options struct {
 	UnsupportedFeatures compat.JSFeature
 	Indent              int
 	LineLimit           int
+	Formatting          config.Formatting
 	OutputFormat        config.Format
 	MinifyWhitespace    bool
 	MinifyIdentifiers   bool
@@ -4809,13 +5279,15 @@ type PrintResult struct {
 
 func Print(tree js_ast.AST, symbols ast.SymbolMap, r renamer.Renamer, options Options) PrintResult {
 	p := &printer{
-		symbols:       symbols,
-		renamer:       r,
-		importRecords: tree.ImportRecords,
-		options:       options,
-		moduleType:    tree.ModuleTypeData.Type,
-		exprComments:  tree.ExprComments,
-		wasLazyExport: tree.HasLazyExport,
+		symbols:          symbols,
+		renamer:          r,
+		importRecords:    tree.ImportRecords,
+		options:          options,
+		indentText:       options.Formatting.IndentText(),
+		moduleType:       tree.ModuleTypeData.Type,
+		exprComments:     tree.ExprComments,
+		trailingComments: tree.TrailingComments,
+		wasLazyExport:    tree.HasLazyExport,
 
 		stmtStart:          -1,
 		exportDefaultStart: -1,
@@ -4832,6 +5304,17 @@ func Print(tree js_ast.AST, symbols ast.SymbolMap, r renamer.Renamer, options Op
 	if p.exprComments != nil {
 		p.printedExprComments = make(map[logger.Loc]bool)
 	}
+	if p.trailingComments != nil {
+		p.printedTrailing = make(map[logger.Loc]bool)
+	}
+
+	// Without semicolons, this output could be joined onto the end of whatever
+	// comes before it (e.g. another file in the same bundle). So it's treated
+	// as if it follows another statement.
+	if !options.MinifyWhitespace && options.Formatting.Semicolons == config.SemicolonsASI {
+		p.omitSemicolons = true
+		p.needsASISemicolon = true
+	}
 
 	p.isUnbound = func(ref ast.Ref) bool {
 		ref = ast.FollowSymbols(symbols, ref)
@@ -4842,8 +5325,12 @@ func Print(tree js_ast.AST, symbols ast.SymbolMap, r renamer.Renamer, options Op
 	for _, directive := range tree.Directives {
 		p.printIndent()
 		p.printQuotedUTF8(directive, 0)
-		p.print(";")
-		p.printNewline()
+		if p.omitSemicolons {
+			p.printSemicolonAfterStatement()
+		} else {
+			p.print(";")
+			p.printNewline()
+		}
 	}
 
 	for _, part := range tree.Parts {
@@ -43,6 +43,7 @@ func expectPrintedCommon(t *testing.T, name string, contents string, expected st
 			MinifySyntax:        options.MinifySyntax,
 			MinifyWhitespace:    options.MinifyWhitespace,
 			UnsupportedFeatures: options.UnsupportedJSFeatures,
+			Formatting:          options.Formatting,
 		}).JS
 		test.AssertEqualWithDiff(t, string(js), expected)
 	})
@@ -90,6 +91,13 @@ func expectPrintedMinifyASCII(t *testing.T, contents string, expected string) {
 	})
 }
 
+func expectPrintedFormatting(t *testing.T, formatting config.Formatting, contents string, expected string) {
+	t.Helper()
+	expectPrintedCommon(t, contents+" [formatting]", contents, expected, config.Options{
+		Formatting: formatting,
+	})
+}
+
 func expectPrintedTarget(t *testing.T, esVersion int, contents string, expected string) {
 	t.Helper()
 	expectPrintedCommon(t, contents, contents, expected, config.Options{
@@ -1116,3 +1124,137 @@ func TestUsing(t *testing.T) {
 	expectPrintedMinify(t, "await using x = y", "await using x=y;")
 	expectPrintedMinify(t, "await using x = y, z = _", "await using x=y,z=_;")
 }
+
+func TestIndentWidth(t *testing.T) {
+	four := config.Formatting{IndentWidth: 4}
+	tabs := config.Formatting{UseTabs: true}
+
+	expectPrintedFormatting(t, four, "if (a) { b() }", "if (a) {\n    b();\n}\n")
+	expectPrintedFormatting(t, four, "class Foo { x = {\ny: [\n1] } }", "class Foo {\n    x = {\n        y: [\n            1\n        ]\n    };\n}\n")
+	expectPrintedFormatting(t, tabs, "if (a) { b() }", "if (a) {\n\tb();\n}\n")
+	expectPrintedCommon(t, "tabs with comments", "function foo() {\n  /**\n   * a\n   */\n  bar()\n}", "function foo() {\n\t/**\n\t * a\n\t */\n\tbar();\n}\n", config.Options{
+		Formatting:       tabs,
+		PreserveComments: true,
+	})
+}
+
+func TestQuotes(t *testing.T) {
+	single := config.Formatting{Quotes: config.QuotesSingle}
+
+	expectPrinted(t, "x = 'a'", "x = \"a\";\n")
+	expectPrinted(t, "x = \"'\"", "x = \"'\";\n")
+	expectPrinted(t, "x = '\"'", "x = '\"';\n")
+
+	expectPrintedFormatting(t, single, "x = \"a\"", "x = 'a';\n")
+	expectPrintedFormatting(t, single, "x = \"'\"", "x = \"'\";\n")
+	expectPrintedFormatting(t, single, "x = '\"'", "x = '\"';\n")
+	expectPrintedFormatting(t, single, "x = `'\"`", "x = `'\"`;\n")
+	expectPrintedFormatting(t, single, "x = \"'\\\"\"", "x = `'\"`;\n")
+	expectPrintedFormatting(t, single, "import x from \"y\"", "import x from 'y';\n")
+	expectPrintedFormatting(t, single, "x = {\"a-b\": 1}", "x = { 'a-b': 1 };\n")
+	expectPrintedCommon(t, "jsx", "x = <a b=\"c\" />", "x = <a b=\"c\" />;\n", config.Options{
+		JSX:        config.JSXOptions{Parse: true, Preserve: true},
+		Formatting: single,
+	})
+}
+
+func TestTrailingCommas(t *testing.T) {
+	es5 := config.Formatting{TrailingCommas: config.TrailingCommasES5}
+	all := config.Formatting{TrailingCommas: config.TrailingCommasAll}
+
+	// Only multi-line lists get trailing commas
+	expectPrintedFormatting(t, es5, "x = [1, 2]", "x = [1, 2];\n")
+	expectPrintedFormatting(t, es5, "x = {a, b}", "x = { a, b };\n")
+	expectPrintedFormatting(t, es5, "x = [\n1, 2]", "x = [\n  1,\n  2,\n];\n")
+	expectPrintedFormatting(t, es5, "x = {\na, b}", "x = {\n  a,\n  b,\n};\n")
+	expectPrintedFormatting(t, es5, "let [\na, b] = c", "let [\n  a,\n  b,\n] = c;\n")
+	expectPrintedFormatting(t, es5, "let {\na, b} = c", "let {\n  a,\n  b,\n} = c;\n")
+	expectPrintedFormatting(t, es5, "import {\na, b} from 'c'", "import {\n  a,\n  b,\n} from \"c\";\n")
+	expectPrintedFormatting(t, es5, "export {\na, b} from 'c'", "export {\n  a,\n  b,\n} from \"c\";\n")
+	expectPrintedFormatting(t, es5, "let a, b; export {\na, b}", "let a, b;\nexport {\n  a,\n  b,\n};\n")
+	expectPrintedFormatting(t, es5, "foo(\na, b)", "foo(\n  a,\n  b\n);\n")
+	expectPrintedFormatting(t, es5, "new Foo(\na, b)", "new Foo(\n  a,\n  b\n);\n")
+
+	// Arguments only get trailing commas with "all"
+	expectPrintedFormatting(t, all, "foo(\na, b)", "foo(\n  a,\n  b,\n);\n")
+	expectPrintedFormatting(t, all, "new Foo(\na, b)", "new Foo(\n  a,\n  b,\n);\n")
+	expectPrintedFormatting(t, all, "foo(\n...a)", "foo(\n  ...a,\n);\n")
+
+	// A trailing comma after a rest element is a syntax error
+	expectPrintedFormatting(t, es5, "[\na, ...b] = c", "[\n  a,\n  ...b\n] = c;\n")
+	expectPrintedFormatting(t, es5, "({\na, ...b} = c)", "({\n  a,\n  ...b\n} = c);\n")
+	expectPrintedFormatting(t, es5, "let [\na, ...b] = c", "let [\n  a,\n  ...b\n] = c;\n")
+	expectPrintedFormatting(t, es5, "let {\na, ...b} = c", "let {\n  a,\n  ...b\n} = c;\n")
+
+	// A trailing comma after a hole would add another hole
+	expectPrintedFormatting(t, es5, "x = [\na, ,]", "x = [\n  a,\n  ,\n];\n")
+	expectPrintedFormatting(t, es5, "let [\na, ,] = b", "let [\n  a,\n  ,\n] = b;\n")
+
+	// Trailing commas are never printed when minifying
+	expectPrintedCommon(t, "minified", "x = [\n1, 2]; import {\na} from 'b'", "x=[1,2];import{a}from\"b\";", config.Options{
+		MinifyWhitespace: true,
+		Formatting:       all,
+	})
+}
+
+func TestSemicolonsASI(t *testing.T) {
+	asi := config.Formatting{Semicolons: config.SemicolonsASI}
+
+	expectPrintedFormatting(t, asi, "a(); b()", "a()\nb()\n")
+	expectPrintedFormatting(t, asi, "let a = 1; return", "let a = 1\nreturn\n")
+	expectPrintedFormatting(t, asi, "for (;;) ;", "for (; ; )\n  ;\n")
+	expectPrintedFormatting(t, asi, "if (a) b(); else c()", "if (a)\n  b()\nelse\n  c()\n")
+	expectPrintedFormatting(t, asi, "'use strict'; a()", "\"use strict\"\na()\n")
+
+	// Statements that would continue the previous statement need a semicolon
+	expectPrintedFormatting(t, asi, "a(); (function() { b() })()", "a()\n;(function() {\n  b()\n})()\n")
+	expectPrintedFormatting(t, asi, "a(); (() => b)()", "a()\n;(() => b)()\n")
+	expectPrintedFormatting(t, asi, "a(); [b] = c", "a()\n;[b] = c\n")
+	expectPrintedFormatting(t, asi, "a(); `b`.c()", "a()\n;`b`.c()\n")
+	expectPrintedFormatting(t, asi, "a(); +b", "a()\n;+b\n")
+	expectPrintedFormatting(t, asi, "a(); -b", "a()\n;-b\n")
+	expectPrintedFormatting(t, asi, "a(); /b/.test(c)", "a()\n;/b/.test(c)\n")
+	expectPrintedCommon(t, "jsx", "a(); <b />", "a()\n;<b />\n", config.Options{
+		JSX:        config.JSXOptions{Parse: true, Preserve: true},
+		Formatting: asi,
+	})
+	expectPrintedFormatting(t, asi, "a(); ({ b } = c)", "a()\n;({ b } = c)\n")
+	expectPrintedFormatting(t, asi, "a(); 'b'[c]()", "a()\n\"b\"[c]()\n")
+	expectPrintedFormatting(t, asi, "a(); b[c]()", "a()\nb[c]()\n")
+	expectPrintedFormatting(t, asi, "(() => a)()", ";(() => a)()\n")
+
+	// Comments between the statements don't change anything
+	expectPrintedCommon(t, "comments", "a(); // b\n/** c\n */\n[d] = e", "a() // b\n/** c\n */\n;[d] = e\n", config.Options{
+		Formatting:       asi,
+		PreserveComments: true,
+	})
+	expectPrintedFormatting(t, asi, "a(); /* @__PURE__ */ (() => {})()", "a()\n;/* @__PURE__ */ (() => {\n})()\n")
+
+	// Class fields keep their semicolons
+	expectPrintedFormatting(t, asi, "class Foo { a; [b] = 1; c() {} }", "class Foo {\n  a;\n  [b] = 1;\n  c() {\n  }\n}\n")
+}
+
+func TestPrintWidth(t *testing.T) {
+	width := config.Formatting{PrintWidth: 20}
+
+	expectPrintedFormatting(t, width, "foo(a, b)", "foo(a, b);\n")
+	expectPrintedFormatting(t, width, "foo(aaaaaa, bbbbbb, cccccc)", "foo(\n  aaaaaa,\n  bbbbbb,\n  cccccc\n);\n")
+	expectPrintedFormatting(t, width, "new Foo(aaaaaa, bbbbbb, cccc)", "new Foo(\n  aaaaaa,\n  bbbbbb,\n  cccc\n);\n")
+	expectPrintedFormatting(t, width, "x = [aaaaaa, bbbbbb, cccccc]", "x = [\n  aaaaaa,\n  bbbbbb,\n  cccccc\n];\n")
+	expectPrintedFormatting(t, width, "x = {aaaaaa, bbbbbb, cccccc}", "x = {\n  aaaaaa,\n  bbbbbb,\n  cccccc\n};\n")
+	expectPrintedFormatting(t, width, "function f(aaaaaa, bbbbbb) {}", "function f(\n  aaaaaa,\n  bbbbbb\n) {\n}\n")
+	expectPrintedFormatting(t, width, "x = (aaaaaa, bbbbbbb) => {}", "x = (\n  aaaaaa,\n  bbbbbbb\n) => {\n};\n")
+
+	// Nested lists are only wrapped if they still don't fit
+	expectPrintedFormatting(t, width, "foo(a, [bbbbbb, cccccc], d)", "foo(\n  a,\n  [bbbbbb, cccccc],\n  d\n);\n")
+	expectPrintedFormatting(t, width, "foo(a, [bbbbbb, cccccc, dddddd])", "foo(\n  a,\n  [\n    bbbbbb,\n    cccccc,\n    dddddd\n  ]\n);\n")
+
+	// Only the first line of a list has to fit
+	expectPrintedFormatting(t, width, "foo(() => { bar() })", "foo(() => {\n  bar();\n});\n")
+
+	// Trailing commas are added to lists that were wrapped
+	expectPrintedFormatting(t, config.Formatting{PrintWidth: 20, TrailingCommas: config.TrailingCommasAll},
+		"foo(aaaaaa, bbbbbb, cccccc)", "foo(\n  aaaaaa,\n  bbbbbb,\n  cccccc,\n);\n")
+	expectPrintedFormatting(t, config.Formatting{PrintWidth: 20, TrailingCommas: config.TrailingCommasAll},
+		"function f(aaaaaa, ...bbbbbb) {}", "function f(\n  aaaaaa,\n  ...bbbbbb\n) {\n}\n")
+}
@@ -0,0 +1,577 @@
+package linker
+
+// This file combines the declarations generated for each TypeScript file into
+// a single declaration file for each entry point (i.e. it "rolls up" ".d.ts"
+// files). Declarations are stored as text, so this works on the tokens of
+// that text instead of on symbols like the JavaScript linker does.
+//
+// Only the declarations that are reachable from the exports of the entry
+// point are kept. Everything is moved into the top-level scope of the output
+// file, so declarations from different files with the same name are renamed.
+// Imports of files that aren't part of the bundle are kept as imports.
+
+import (
+	"strings"
+
+	"github.com/evanw/esbuild/internal/ast"
+	"github.com/evanw/esbuild/internal/dts_ast"
+	"github.com/evanw/esbuild/internal/dts_lexer"
+	"github.com/evanw/esbuild/internal/graph"
+	"github.com/evanw/esbuild/internal/js_lexer"
+	"github.com/evanw/esbuild/internal/logger"
+	"github.com/evanw/esbuild/internal/renamer"
+)
+
+type dtsSymbolKind uint8
+
+const (
+	// A top-level declaration in one of the files in the bundle
+	dtsSymbolDecl dtsSymbolKind = iota
+
+	// A namespace object for one of the files in the bundle ("import * as ns")
+	dtsSymbolNamespace
+
+	// An import of something that isn't in the bundle
+	dtsSymbolExternal
+)
+
+type dtsSymbol struct {
+	// For declarations, this is the local name. Anonymous default exports use
+	// the name "default" since that can't collide with any other name. For
+	// external imports, this is the imported name or "*" for a namespace.
+	name string
+
+	// This is only used for external imports
+	path string
+
+	sourceIndex uint32
+	kind        dtsSymbolKind
+}
+
+type dtsBinding struct {
+	name        string
+	recordIndex ast.Index32
+}
+
+type dtsFile struct {
+	tree *dts_ast.AST
+
+	// Maps top-level names to the indices of the statements that declare them.
+	// There can be more than one statement due to function overloads and
+	// declaration merging.
+	decls map[string][]int
+
+	// For imports, the name is the imported name or "*" for a namespace import
+	imports map[string]dtsBinding
+
+	// For re-exports, the name is the imported name or "*" for a namespace.
+	// Otherwise, the name is a local name.
+	exports     map[string]dtsBinding
+	exportOrder []string
+	exportStars []uint32
+
+	tokens   map[int][]dts_lexer.Token
+	liveStmt map[int]bool
+}
+
+type dtsRollup struct {
+	c              *linkerContext
+	files          map[uint32]*dtsFile
+	live           map[dtsSymbol]bool
+	externals      []dtsSymbol
+	names          map[dtsSymbol]string
+	exportResolver map[dtsSymbol]bool
+	renamer        renamer.ExportRenamer
+}
+
+func (c *linkerContext) rollUpDeclarations(entrySourceIndex uint32) *dts_ast.AST {
+	r := dtsRollup{
+		c:              c,
+		files:          make(map[uint32]*dtsFile),
+		live:           make(map[dtsSymbol]bool),
+		names:          make(map[dtsSymbol]string),
+		exportResolver: make(map[dtsSymbol]bool),
+	}
+
+	// Find all files in the declaration graph, with dependencies first
+	var order []uint32
+	var visit func(sourceIndex uint32)
+	visit = func(sourceIndex uint32) {
+		if _, ok := r.files[sourceIndex]; ok {
+			return
+		}
+		f := r.parseFile(sourceIndex)
+		r.files[sourceIndex] = f
+		for _, record := range f.tree.ImportRecords {
+			if other, ok := r.internalSourceIndex(record); ok {
+				visit(other)
+			}
+		}
+		order = append(order, sourceIndex)
+	}
+	visit(entrySourceIndex)
+	entry := r.files[entrySourceIndex]
+
+	// Mark everything that the entry point exports as live
+	aliases, externalStars := r.collectExports(entrySourceIndex, make(map[uint32]bool))
+	exports := make([]dtsSymbol, len(aliases))
+	for i, alias := range aliases {
+		if sym, ok := r.resolveExport(entrySourceIndex, alias); ok {
+			exports[i] = sym
+			r.markLive(sym)
+		}
+	}
+
+	// Global declarations and module augmentations are always kept
+	for _, sourceIndex := range order {
+		f := r.files[sourceIndex]
+		for i, stmt := range f.tree.Stmts {
+			if s, ok := stmt.Data.(*dts_ast.SDecl); ok && len(s.Names) == 0 && !s.IsDefault {
+				// Things like "export = foo" only make sense in the entry point
+				if tokens := r.tokensForStmt(f, i); len(tokens) > 0 && tokens[0].Value == "export" && f != entry {
+					continue
+				}
+				r.markStmtLive(sourceIndex, i)
+			}
+		}
+	}
+
+	// Names that refer to globals can't be used for anything else
+	globals := make(map[string]bool)
+	for _, sourceIndex := range order {
+		f := r.files[sourceIndex]
+		for i := range f.tree.Stmts {
+			if !f.liveStmt[i] {
+				continue
+			}
+			for _, t := range r.tokensForStmt(f, i) {
+				if t.IsReference {
+					if _, ok := r.resolveLocal(sourceIndex, t.Value); !ok && !globals[t.Value] {
+						globals[t.Value] = true
+						r.renamer.NextRenamedName(t.Value)
+					}
+				}
+			}
+		}
+	}
+
+	// Assign names to everything else, starting with the entry point so that
+	// its names are the ones that are least likely to be renamed
+	r.assignNames(entrySourceIndex)
+	for i, sym := range exports {
+		if r.live[sym] {
+			if alias := aliases[i]; alias == "default" {
+				r.nameFor(sym, "_default")
+			} else {
+				r.nameFor(sym, alias)
+			}
+		}
+	}
+	for _, sourceIndex := range order {
+		if sourceIndex != entrySourceIndex {
+			r.assignNames(sourceIndex)
+		}
+	}
+
+	tree := &dts_ast.AST{}
+
+	// Generate imports for things that aren't in the bundle
+	importsForPath := make(map[string]*dts_ast.SImport)
+	for _, sym := range r.externals {
+		name := r.names[sym]
+		if sym.name == "*" {
+			tree.Stmts = append(tree.Stmts, dts_ast.Stmt{Data: &dts_ast.SImport{
+				NamespaceName:     name,
+				ImportRecordIndex: r.addImportRecord(tree, sym.path),
+			}})
+			continue
+		}
+		s, ok := importsForPath[sym.path]
+		if !ok {
+			s = &dts_ast.SImport{ImportRecordIndex: r.addImportRecord(tree, sym.path)}
+			importsForPath[sym.path] = s
+			tree.Stmts = append(tree.Stmts, dts_ast.Stmt{Data: s})
+		}
+		if sym.name == "default" && s.DefaultName == "" {
+			s.DefaultName = name
+		} else {
+			s.Items = append(s.Items, dts_ast.ClauseItem{Alias: sym.name, Name: name})
+		}
+	}
+
+	// Generate the declarations for each file
+	for _, sourceIndex := range order {
+		f := r.files[sourceIndex]
+		for i, stmt := range f.tree.Stmts {
+			if s, ok := stmt.Data.(*dts_ast.SDecl); ok && f.liveStmt[i] {
+				tree.Stmts = append(tree.Stmts, dts_ast.Stmt{Loc: stmt.Loc, Data: &dts_ast.SDecl{
+					DocComment: s.DocComment,
+					Text:       r.rewriteDecl(sourceIndex, i, s),
+					Names:      s.Names,
+				}})
+			}
+		}
+	}
+
+	// Generate namespace objects for files that are imported with "import * as"
+	for _, sourceIndex := range order {
+		if sym := (dtsSymbol{kind: dtsSymbolNamespace, sourceIndex: sourceIndex}); r.live[sym] {
+			var sb strings.Builder
+			sb.WriteString("declare namespace ")
+			sb.WriteString(r.names[sym])
+			sb.WriteString(" {\n    export { ")
+			aliases, _ := r.collectExports(sourceIndex, make(map[uint32]bool))
+			for i, alias := range aliases {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				if target, ok := r.resolveExport(sourceIndex, alias); ok && r.names[target] != alias {
+					sb.WriteString(r.names[target])
+					sb.WriteString(" as ")
+				}
+				sb.WriteString(alias)
+			}
+			sb.WriteString(" };\n}")
+			tree.Stmts = append(tree.Stmts, dts_ast.Stmt{Data: &dts_ast.SDecl{Text: sb.String(), Names: []string{r.names[sym]}}})
+		}
+	}
+
+	// Export everything that the entry point exports
+	items := []dts_ast.ClauseItem{}
+	for i, alias := range aliases {
+		if name, ok := r.names[exports[i]]; ok {
+			items = append(items, dts_ast.ClauseItem{Name: name, Alias: alias})
+		}
+	}
+	if len(items) > 0 || len(externalStars) == 0 {
+		tree.Stmts = append(tree.Stmts, dts_ast.Stmt{Data: &dts_ast.SExportClause{Items: items}})
+	}
+	for _, path := range externalStars {
+		tree.Stmts = append(tree.Stmts, dts_ast.Stmt{Data: &dts_ast.SExportStar{ImportRecordIndex: r.addImportRecord(tree, path)}})
+	}
+	return tree
+}
+
+func (r *dtsRollup) parseFile(sourceIndex uint32) *dtsFile {
+	tree := r.c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr).AST.Declarations
+	f := &dtsFile{
+		tree:     tree,
+		decls:    make(map[string][]int),
+		imports:  make(map[string]dtsBinding),
+		exports:  make(map[string]dtsBinding),
+		tokens:   make(map[int][]dts_lexer.Token),
+		liveStmt: make(map[int]bool),
+	}
+
+	addExport := func(alias string, binding dtsBinding) {
+		if _, ok := f.exports[alias]; !ok {
+			f.exportOrder = append(f.exportOrder, alias)
+		}
+		f.exports[alias] = binding
+	}
+
+	for i, stmt := range tree.Stmts {
+		switch s := stmt.Data.(type) {
+		case *dts_ast.SImport:
+			record := ast.MakeIndex32(s.ImportRecordIndex)
+			if s.DefaultName != "" {
+				f.imports[s.DefaultName] = dtsBinding{name: "default", recordIndex: record}
+			}
+			if s.NamespaceName != "" {
+				f.imports[s.NamespaceName] = dtsBinding{name: "*", recordIndex: record}
+			}
+			for _, item := range s.Items {
+				f.imports[item.Name] = dtsBinding{name: item.Alias, recordIndex: record}
+			}
+
+		case *dts_ast.SExportClause:
+			for _, item := range s.Items {
+				addExport(item.Alias, dtsBinding{name: item.Name})
+			}
+
+		case *dts_ast.SExportFrom:
+			for _, item := range s.Items {
+				addExport(item.Alias, dtsBinding{name: item.Name, recordIndex: ast.MakeIndex32(s.ImportRecordIndex)})
+			}
+
+		case *dts_ast.SExportStar:
+			if s.Alias != "" {
+				addExport(s.Alias, dtsBinding{name: "*", recordIndex: ast.MakeIndex32(s.ImportRecordIndex)})
+			} else {
+				f.exportStars = append(f.exportStars, s.ImportRecordIndex)
+			}
+
+		case *dts_ast.SExportDefault:
+			addExport("default", dtsBinding{name: s.Name})
+
+		case *dts_ast.SDecl:
+			names := s.Names
+			if s.IsDefault && len(names) == 0 {
+				names = []string{"default"}
+			}
+			for _, name := range names {
+				f.decls[name] = append(f.decls[name], i)
+			}
+			if s.IsDefault {
+				addExport("default", dtsBinding{name: names[0]})
+			} else if s.IsExport {
+				for _, name := range names {
+					addExport(name, dtsBinding{name: name})
+				}
+			}
+		}
+	}
+	return f
+}
+
+// Only imports of files that have declarations are followed
+func (r *dtsRollup) internalSourceIndex(record ast.ImportRecord) (uint32, bool) {
+	if record.SourceIndex.IsValid() {
+		sourceIndex := record.SourceIndex.GetIndex()
+		if repr, ok := r.c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok && repr.AST.Declarations != nil {
+			return sourceIndex, true
+		}
+	}
+	return 0, false
+}
+
+func (r *dtsRollup) tokensForStmt(f *dtsFile, stmtIndex int) []dts_lexer.Token {
+	tokens, ok := f.tokens[stmtIndex]
+	if !ok {
+		tokens = dts_lexer.Tokenize(f.tree.Stmts[stmtIndex].Data.(*dts_ast.SDecl).Text)
+		f.tokens[stmtIndex] = tokens
+	}
+	return tokens
+}
+
+// Returns the names exported by a file, including the ones from "export *"
+// statements. Also returns the paths of "export *" statements for files that
+// aren't in the bundle, since their export names aren't known.
+func (r *dtsRollup) collectExports(sourceIndex uint32, visited map[uint32]bool) (aliases []string, externalStars []string) {
+	if visited[sourceIndex] {
+		return
+	}
+	visited[sourceIndex] = true
+	f := r.files[sourceIndex]
+	aliases = append(aliases, f.exportOrder...)
+	seen := make(map[string]bool)
+	for _, alias := range aliases {
+		seen[alias] = true
+	}
+	for _, recordIndex := range f.exportStars {
+		record := f.tree.ImportRecords[recordIndex]
+		other, ok := r.internalSourceIndex(record)
+		if !ok {
+			externalStars = append(externalStars, record.Path.Text)
+			continue
+		}
+		otherAliases, otherStars := r.collectExports(other, visited)
+		for _, alias := range otherAliases {
+			// "export *" never re-exports the default export
+			if alias != "default" && !seen[alias] {
+				seen[alias] = true
+				aliases = append(aliases, alias)
+			}
+		}
+		externalStars = append(externalStars, otherStars...)
+	}
+	return
+}
+
+func (r *dtsRollup) resolveExport(sourceIndex uint32, alias string) (dtsSymbol, bool) {
+	// Avoid infinite loops due to import cycles
+	key := dtsSymbol{sourceIndex: sourceIndex, name: alias}
+	if r.exportResolver[key] {
+		return dtsSymbol{}, false
+	}
+	r.exportResolver[key] = true
+	defer delete(r.exportResolver, key)
+
+	f := r.files[sourceIndex]
+	if binding, ok := f.exports[alias]; ok {
+		if binding.recordIndex.IsValid() {
+			return r.resolveImport(sourceIndex, binding)
+		}
+		return r.resolveLocal(sourceIndex, binding.name)
+	}
+
+	if alias != "default" {
+		for _, recordIndex := range f.exportStars {
+			if other, ok := r.internalSourceIndex(f.tree.ImportRecords[recordIndex]); ok {
+				if sym, ok := r.resolveExport(other, alias); ok {
+					return sym, true
+				}
+			}
+		}
+	}
+	return dtsSymbol{}, false
+}
+
+func (r *dtsRollup) resolveImport(sourceIndex uint32, binding dtsBinding) (dtsSymbol, bool) {
+	record := r.files[sourceIndex].tree.ImportRecords[binding.recordIndex.GetIndex()]
+	other, ok := r.internalSourceIndex(record)
+	if !ok {
+		return dtsSymbol{kind: dtsSymbolExternal, path: record.Path.Text, name: binding.name}, true
+	}
+	if binding.name == "*" {
+		return dtsSymbol{kind: dtsSymbolNamespace, sourceIndex: other}, true
+	}
+	return r.resolveExport(other, binding.name)
+}
+
+// Returns false if the name is a global
+func (r *dtsRollup) resolveLocal(sourceIndex uint32, name string) (dtsSymbol, bool) {
+	f := r.files[sourceIndex]
+	if _, ok := f.decls[name]; ok {
+		return dtsSymbol{kind: dtsSymbolDecl, sourceIndex: sourceIndex, name: name}, true
+	}
+	if binding, ok := f.imports[name]; ok {
+		return r.resolveImport(sourceIndex, binding)
+	}
+	return dtsSymbol{}, false
+}
+
+func (r *dtsRollup) markLive(sym dtsSymbol) {
+	if r.live[sym] {
+		return
+	}
+	r.live[sym] = true
+
+	switch sym.kind {
+	case dtsSymbolDecl:
+		for _, stmtIndex := range r.files[sym.sourceIndex].decls[sym.name] {
+			r.markStmtLive(sym.sourceIndex, stmtIndex)
+		}
+
+	case dtsSymbolNamespace:
+		aliases, _ := r.collectExports(sym.sourceIndex, make(map[uint32]bool))
+		for _, alias := range aliases {
+			if target, ok := r.resolveExport(sym.sourceIndex, alias); ok {
+				r.markLive(target)
+			}
+		}
+
+	case dtsSymbolExternal:
+		r.externals = append(r.externals, sym)
+	}
+}
+
+func (r *dtsRollup) markStmtLive(sourceIndex uint32, stmtIndex int) {
+	f := r.files[sourceIndex]
+	if f.liveStmt[stmtIndex] {
+		return
+	}
+	f.liveStmt[stmtIndex] = true
+	for _, t := range r.tokensForStmt(f, stmtIndex) {
+		if t.IsReference {
+			if sym, ok := r.resolveLocal(sourceIndex, t.Value); ok {
+				r.markLive(sym)
+			}
+		}
+	}
+}
+
+func (r *dtsRollup) nameFor(sym dtsSymbol, preferred string) string {
+	name, ok := r.names[sym]
+	if !ok {
+		name = r.renamer.NextRenamedName(preferred)
+		r.names[sym] = name
+	}
+	return name
+}
+
+func (r *dtsRollup) assignNames(sourceIndex uint32) {
+	f := r.files[sourceIndex]
+	for i, stmt := range f.tree.Stmts {
+		if !f.liveStmt[i] {
+			continue
+		}
+		s := stmt.Data.(*dts_ast.SDecl)
+		if s.IsDefault && len(s.Names) == 0 {
+			r.nameFor(dtsSymbol{kind: dtsSymbolDecl, sourceIndex: sourceIndex, name: "default"}, "_default")
+		}
+		for _, name := range s.Names {
+			r.nameFor(dtsSymbol{kind: dtsSymbolDecl, sourceIndex: sourceIndex, name: name}, name)
+		}
+		for _, t := range r.tokensForStmt(f, i) {
+			if t.IsReference {
+				if sym, ok := r.resolveLocal(sourceIndex, t.Value); ok {
+					r.nameFor(sym, t.Value)
+				}
+			}
+		}
+	}
+}
+
+func (r *dtsRollup) rewriteDecl(sourceIndex uint32, stmtIndex int, s *dts_ast.SDecl) string {
+	f := r.files[sourceIndex]
+	tokens := r.tokensForStmt(f, stmtIndex)
+	var sb strings.Builder
+	end := 0
+	depth := 0
+
+	for i, t := range tokens {
+		switch t.Kind {
+		case js_lexer.TOpenBrace, js_lexer.TOpenParen, js_lexer.TOpenBracket:
+			depth++
+		case js_lexer.TCloseBrace, js_lexer.TCloseParen, js_lexer.TCloseBracket:
+			depth--
+		}
+
+		// Give anonymous default exports a name
+		if s.IsDefault && len(s.Names) == 0 && (t.Value == "function" || t.Value == "class") && (i == 0 || tokens[i-1].Value == "abstract") {
+			name := r.names[dtsSymbol{kind: dtsSymbolDecl, sourceIndex: sourceIndex, name: "default"}]
+			tokenEnd := int(t.Range.End())
+			sb.WriteString(s.Text[end:tokenEnd])
+			sb.WriteString(" ")
+			sb.WriteString(name)
+			end = tokenEnd
+			if i+1 < len(tokens) && tokens[i+1].Kind == js_lexer.TOpenParen {
+				end = int(tokens[i+1].Range.Loc.Start)
+			}
+			continue
+		}
+
+		// The names being declared aren't references, but they may need to be
+		// renamed too. They are always outside of any brackets.
+		isDeclaredName := false
+		if !t.IsReference && depth == 0 {
+			for _, name := range s.Names {
+				if t.Value == name {
+					isDeclaredName = true
+					break
+				}
+			}
+		}
+		if !t.IsReference && !isDeclaredName {
+			continue
+		}
+		if sym, ok := r.resolveLocal(sourceIndex, t.Value); ok {
+			if name := r.names[sym]; name != t.Value {
+				sb.WriteString(s.Text[end:t.Range.Loc.Start])
+				sb.WriteString(name)
+				end = int(t.Range.End())
+			}
+		}
+	}
+	sb.WriteString(s.Text[end:])
+	text := sb.String()
+
+	// Default exports don't have a "declare" keyword, but they need one now
+	if s.IsDefault && len(tokens) > 0 {
+		switch tokens[0].Value {
+		case "function", "class", "abstract", "async":
+			text = "declare " + text
+		}
+	}
+	return text
+}
+
+func (r *dtsRollup) addImportRecord(tree *dts_ast.AST, path string) uint32 {
+	index := uint32(len(tree.ImportRecords))
+	tree.ImportRecords = append(tree.ImportRecords, ast.ImportRecord{
+		Kind: ast.ImportTypes,
+		Path: logger.Path{Text: path},
+	})
+	return index
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/evanw/esbuild/internal/css_lexer"
 	"github.com/evanw/esbuild/internal/css_parser"
 	"github.com/evanw/esbuild/internal/css_printer"
+	"github.com/evanw/esbuild/internal/dts_printer"
 	"github.com/evanw/esbuild/internal/fs"
 	"github.com/evanw/esbuild/internal/graph"
 	"github.com/evanw/esbuild/internal/helpers"
@@ -371,6 +372,8 @@ func (c *linkerContext) mangleProps(mangleCache map[string]interface{}) {
 	// Merge all mangled property symbols together
 	freq := ast.CharFreq{}
 	mergedProps := make(map[string]ast.Ref)
+	usedProps := make(map[string]bool)
+	mergedPrivateNames := make(map[string][]ast.Ref)
 	for _, sourceIndex := range c.graph.ReachableFiles {
 		// Don't mangle anything in the runtime code
 		if sourceIndex == runtime.SourceIndex {
@@ -382,6 +385,17 @@ func (c *linkerContext) mangleProps(mangleCache map[string]interface{}) {
 			// Reserve all non-mangled properties
 			for prop := range repr.AST.ReservedProps {
 				reservedProps[prop] = true
+				usedProps[prop] = true
+			}
+
+			// Group all private names by name, since they are mangled by name too
+			if c.options.ManglePrivate {
+				for innerIndex, symbol := range c.graph.Symbols.SymbolsForSource[sourceIndex] {
+					if symbol.Kind.IsPrivate() {
+						ref := ast.Ref{SourceIndex: sourceIndex, InnerIndex: uint32(innerIndex)}
+						mergedPrivateNames[symbol.OriginalName] = append(mergedPrivateNames[symbol.OriginalName], ref)
+					}
+				}
 			}
 
 			// Merge each mangled property with other ones of the same name
@@ -418,6 +432,15 @@ func (c *linkerContext) mangleProps(mangleCache map[string]interface{}) {
 	for _, symbolCount := range sorted {
 		symbol := c.graph.Symbols.Get(symbolCount.Ref)
 
+		// The names of TypeScript "private" and "protected" members are only
+		// mangled in the files where they are declared. If another file uses the
+		// same property name without mangling it (e.g. a subclass in another file
+		// accessing a "protected" member), the property must not be renamed.
+		if usedProps[symbol.OriginalName] {
+			mangledProps[symbolCount.Ref] = symbol.OriginalName
+			continue
+		}
+
 		// Don't change existing mappings
 		if existing, ok := mangleCache[symbol.OriginalName]; ok {
 			if existing != false {
@@ -442,6 +465,78 @@ func (c *linkerContext) mangleProps(mangleCache map[string]interface{}) {
 		}
 		mangledProps[symbolCount.Ref] = name
 	}
+
+	if len(mergedPrivateNames) > 0 {
+		c.manglePrivateNames(mangleCache, mergedPrivateNames, minifier)
+	}
+}
+
+// Private names can't collide with other properties, and names in different
+// classes can't collide with each other either. So each distinct private name
+// just needs a distinct mangled name, which is shared by every class.
+func (c *linkerContext) manglePrivateNames(mangleCache map[string]interface{}, mergedPrivateNames map[string][]ast.Ref, minifier ast.NameMinifier) {
+	mangledProps := c.mangledProps
+
+	// Reserve all target private names in the cache
+	reservedNames := make(map[string]bool)
+	for original, remapped := range mangleCache {
+		if strings.HasPrefix(original, "#") {
+			if remapped == false {
+				reservedNames[original] = true
+			} else {
+				reservedNames[remapped.(string)] = true
+			}
+		}
+	}
+
+	// Sort by use count (note: does not currently account for live vs. dead code)
+	sorted := make(renamer.StableSymbolCountArray, 0, len(mergedPrivateNames))
+	stableSourceIndices := c.graph.StableSourceIndices
+	for _, refs := range mergedPrivateNames {
+		count := uint32(0)
+		for _, ref := range refs {
+			count += c.graph.Symbols.Get(ref).UseCountEstimate
+		}
+		sorted = append(sorted, renamer.StableSymbolCount{
+			StableSourceIndex: stableSourceIndices[refs[0].SourceIndex],
+			Ref:               refs[0],
+			Count:             count,
+		})
+	}
+	sort.Sort(sorted)
+
+	// Assign names in order of use count
+	nextName := 0
+	for _, symbolCount := range sorted {
+		originalName := c.graph.Symbols.Get(symbolCount.Ref).OriginalName
+		refs := mergedPrivateNames[originalName]
+
+		// Don't change existing mappings
+		if existing, ok := mangleCache[originalName]; ok {
+			if existing != false {
+				for _, ref := range refs {
+					mangledProps[ref] = existing.(string)
+				}
+			}
+			continue
+		}
+
+		// Generate a new name, avoiding reserved names
+		name := "#" + minifier.NumberToMinifiedName(nextName)
+		nextName++
+		for reservedNames[name] {
+			name = "#" + minifier.NumberToMinifiedName(nextName)
+			nextName++
+		}
+
+		// Track the new mapping
+		if mangleCache != nil {
+			mangleCache[originalName] = name
+		}
+		for _, ref := range refs {
+			mangledProps[ref] = name
+		}
+	}
 }
 
 func (c *linkerContext) mangleLocalCSS() {
@@ -705,6 +800,28 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 				})
 			}
 
+			// Generate the optional declaration file for this chunk
+			isDeclarationEntryPoint := false
+			if _, ok := chunk.chunkRepr.(*chunkReprJS); ok && chunk.isEntryPoint {
+				if repr, ok := c.graph.Files[chunk.sourceIndex].InputFile.Repr.(*graph.JSRepr); ok && repr.AST.Declarations != nil {
+					// When bundling, the declarations from all imported files are
+					// combined into a single file. Otherwise imports are kept as-is.
+					tree := repr.AST.Declarations
+					if c.options.Mode == config.ModeBundle {
+						tree = c.rollUpDeclarations(chunk.sourceIndex)
+					}
+					inputPath := c.graph.Files[chunk.sourceIndex].InputFile.Source.KeyPath.Text
+					isDeclarationEntryPoint = declarationExtension(inputPath) != ""
+					declarations := dts_printer.Print(*tree).DTS
+					outputFiles = append(outputFiles, graph.OutputFile{
+						AbsPath:  c.fs.Join(c.options.AbsOutputDir, declarationPathForChunk(chunk.finalRelPath, inputPath)),
+						Contents: declarations,
+						JSONMetadataChunk: fmt.Sprintf(
+							"{\n      \"imports\": [],\n      \"exports\": [],\n      \"inputs\": {},\n      \"bytes\": %d\n    }", len(declarations)),
+					})
+				}
+			}
+
 			// Generate the optional source map for this chunk
 			if c.options.SourceMap != config.SourceMapNone && chunk.outputSourceMap.HasContent() {
 				outputSourceMap := chunk.outputSourceMap.Finalize(outputSourceMapShifts)
@@ -756,13 +873,16 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 				jsonMetadataChunk = string(jsonMetadataChunkBytes.Done())
 			}
 
-			// Generate the output file for this chunk
-			outputFiles = append(outputFiles, graph.OutputFile{
-				AbsPath:           c.fs.Join(c.options.AbsOutputDir, chunk.finalRelPath),
-				Contents:          outputContents,
-				JSONMetadataChunk: jsonMetadataChunk,
-				IsExecutable:      chunk.isExecutable,
-			})
+			// Generate the output file for this chunk. Entry points that are
+			// declaration files have no code, so only the declarations are written.
+			if !isDeclarationEntryPoint {
+				outputFiles = append(outputFiles, graph.OutputFile{
+					AbsPath:           c.fs.Join(c.options.AbsOutputDir, chunk.finalRelPath),
+					Contents:          outputContents,
+					JSONMetadataChunk: jsonMetadataChunk,
+					IsExecutable:      chunk.isExecutable,
+				})
+			}
 
 			results[chunkIndex] = outputFiles
 			resultsWaitGroup.Done()
@@ -784,6 +904,33 @@ func (c *linkerContext) generateChunksInParallel(additionalFiles []graph.OutputF
 	return outputFiles
 }
 
+// TypeScript looks for the declaration file for "foo.js" at "foo.d.ts", for
+// "foo.mjs" at "foo.d.mts", and for "foo.cjs" at "foo.d.cts". Entry points
+// that are already declaration files keep their original extension.
+func declarationPathForChunk(finalRelPath string, inputPath string) string {
+	ext := path.Ext(finalRelPath)
+	base := finalRelPath[:len(finalRelPath)-len(ext)]
+	if dtsExt := declarationExtension(inputPath); dtsExt != "" {
+		return strings.TrimSuffix(base, ".d") + dtsExt
+	}
+	switch ext {
+	case ".mjs":
+		return base + ".d.mts"
+	case ".cjs":
+		return base + ".d.cts"
+	}
+	return base + ".d.ts"
+}
+
+func declarationExtension(inputPath string) string {
+	for _, ext := range []string{".d.ts", ".d.mts", ".d.cts"} {
+		if strings.HasSuffix(inputPath, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
 // Given a set of output pieces (i.e. a buffer already divided into the spans
 // between import paths), substitute the final import paths in and then join
 // everything into a single byte buffer.
@@ -1002,6 +1149,12 @@ func (c *linkerContext) computeCrossChunkDependencies() {
 								ref = symbol.NamespaceAlias.NamespaceRef
 							}
 
+							// Objects with collapsed properties aren't declared anymore.
+							// Their properties are imported instead.
+							if _, ok := c.graph.CollapsedObjects[ref]; ok {
+								continue
+							}
+
 							// We must record this relationship even for symbols that are not
 							// imports. Due to code splitting, the definition of a symbol may
 							// be moved to a separate chunk than the use of a symbol even if
@@ -1443,6 +1596,15 @@ func (c *linkerContext) scanImportsAndExports() {
 				c.options.OutputFormat == config.FormatIIFE || c.options.OutputFormat == config.FormatESModule) {
 				repr.Meta.Wrap = graph.WrapCJS
 			}
+
+			// Top-level await is only valid in ECMAScript modules. If the output
+			// format isn't ESM, any file that is affected by top-level await must
+			// be wrapped in an async initializer instead. That includes the entry
+			// point, which then evaluates the rest of the bundle asynchronously.
+			if repr.Meta.IsAsyncOrHasAsyncDependency && repr.Meta.Wrap == graph.WrapNone &&
+				!c.options.OutputFormat.KeepESMImportExportSyntax() {
+				repr.Meta.Wrap = graph.WrapESM
+			}
 		}
 
 		file.InputFile.AdditionalFiles = additionalFiles
@@ -1550,6 +1712,16 @@ func (c *linkerContext) scanImportsAndExports() {
 		// Create the wrapper part for wrapped files. This is needed by a later step.
 		c.createWrapperForFile(uint32(sourceIndex))
 	}
+
+	// Determine which constants can be inlined across files. This must be done
+	// before the next step, which uses this to avoid adding dependencies on them.
+	c.addCrossModuleConstCandidates()
+	if c.options.InlineFunctions {
+		c.decideWhichFunctionsToInline()
+	}
+	if c.options.CollapseProperties {
+		c.collapseObjectProperties()
+	}
 	c.timer.End("Step 4")
 
 	// Step 5: Create namespace exports for every file. This is always necessary
@@ -1642,26 +1814,31 @@ func (c *linkerContext) scanImportsAndExports() {
 				for ref, properties := range part.ImportSymbolPropertyUses {
 					use := part.SymbolUses[ref]
 
-					// Rare path: this import is a TypeScript enum
+					// Rare path: this import is a TypeScript enum or a frozen object
 					if importData, ok := repr.Meta.ImportsToBind[ref]; ok {
+						var enum map[string]js_ast.TSEnumValue
+						isEnum := false
 						if symbol := graph.Symbols.Get(importData.Ref); symbol.Kind == ast.SymbolTSEnum {
-							if enum, ok := graph.TSEnums[importData.Ref]; ok {
-								foundNonInlinedEnum := false
-								for name, propertyUse := range properties {
-									if _, ok := enum[name]; !ok {
-										foundNonInlinedEnum = true
-										use.CountEstimate += propertyUse.CountEstimate
-									}
-								}
-								if foundNonInlinedEnum {
-									part.SymbolUses[ref] = use
+							enum, isEnum = graph.TSEnums[importData.Ref], true
+						} else if !graph.Symbols.Get(ref).Flags.Has(ast.PropertiesCouldPotentiallyBeMutated) {
+							enum, isEnum = graph.ConstObjects[importData.Ref]
+						}
+						if isEnum {
+							foundNonInlinedEnum := false
+							for name, propertyUse := range properties {
+								if _, ok := enum[name]; !ok {
+									foundNonInlinedEnum = true
+									use.CountEstimate += propertyUse.CountEstimate
 								}
 							}
+							if foundNonInlinedEnum {
+								part.SymbolUses[ref] = use
+							}
 							continue
 						}
 					}
 
-					// Common path: this import isn't a TypeScript enum
+					// Common path: this import isn't a TypeScript enum or a frozen object
 					for _, propertyUse := range properties {
 						use.CountEstimate += propertyUse.CountEstimate
 					}
@@ -1675,9 +1852,11 @@ func (c *linkerContext) scanImportsAndExports() {
 					use := part.SymbolUses[ref]
 
 					// Find the symbol that was called
+					targetRef := ref
 					symbol := graph.Symbols.Get(ref)
 					if symbol.Kind == ast.SymbolImport {
 						if importData, ok := repr.Meta.ImportsToBind[ref]; ok {
+							targetRef = importData.Ref
 							symbol = graph.Symbols.Get(importData.Ref)
 						}
 					}
@@ -1693,6 +1872,18 @@ func (c *linkerContext) scanImportsAndExports() {
 						if callUse.CallCountEstimate == 0 {
 							continue
 						}
+					} else if fn, ok := graph.InlinedFunctions[targetRef]; ok && (flags&ast.CouldPotentiallyBeMutated) == 0 {
+						// The inlined statements may reference other top-level symbols in
+						// the function's file, so this part now depends on their parts
+						if fn.Stmts != nil {
+							part.Dependencies = c.appendInlinedFunctionDependencies(part.Dependencies, targetRef, fn)
+						}
+
+						// Every call with simple enough arguments will be inlined
+						callUse.CallCountEstimate -= callUse.InlinableArgsCallCountEstimate
+						if callUse.CallCountEstimate == 0 {
+							continue
+						}
 					}
 
 					// Common path: this isn't a function that will be inlined
@@ -2342,8 +2533,12 @@ func (c *linkerContext) createExportsForFile(sourceIndex uint32) {
 	// instead of by mutating the exports object because other modules in the
 	// bundle (including the entry point module) may do "import * as" to get
 	// access to the exports object and should NOT see the "__esModule" flag.
+	//
+	// This is skipped for entry points that use top-level await because their
+	// exports are only available once the async initializer has finished. In
+	// that case "module.exports" is set to a promise at the end of the file.
 	if repr.Meta.ForceIncludeExportsForEntryPoint &&
-		c.options.OutputFormat == config.FormatCommonJS {
+		c.options.OutputFormat == config.FormatCommonJS && !repr.Meta.IsAsyncOrHasAsyncDependency {
 
 		runtimeRepr := c.graph.Files[runtime.SourceIndex].InputFile.Repr.(*graph.JSRepr)
 		toCommonJSRef := runtimeRepr.AST.NamedExports["__toCommonJS"].Ref
@@ -2463,6 +2658,12 @@ func (c *linkerContext) createWrapperForFile(sourceIndex uint32) {
 		})
 		repr.Meta.WrapperPartIndex = ast.MakeIndex32(partIndex)
 		c.graph.GenerateSymbolImportAndUse(sourceIndex, partIndex, c.esmRuntimeRef, 1, runtime.SourceIndex)
+
+		// Async closures are generators wrapped in "__async" when async functions
+		// aren't supported
+		if repr.Meta.IsAsyncOrHasAsyncDependency && c.options.UnsupportedJSFeatures.Has(compat.AsyncAwait) {
+			c.graph.GenerateRuntimeSymbolImportAndUse(sourceIndex, partIndex, "__async", 1)
+		}
 	}
 }
 
@@ -2863,6 +3064,486 @@ func (c *linkerContext) maybeCorrectObviousTypo(repr *graph.JSRepr, name string,
 	}
 }
 
+// Top-level constants that aren't part of a file's const local prefix are
+// only inlined into other files if the declaring file isn't part of an import
+// cycle. Otherwise an importing file could be evaluated first and reference a
+// constant before it's initialized, and inlining it would hide the TDZ error.
+func (c *linkerContext) addCrossModuleConstCandidates() {
+	var candidates []uint32
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok &&
+			(repr.AST.ConstValueCandidates != nil || repr.AST.ConstObjectCandidates != nil) {
+			candidates = append(candidates, sourceIndex)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	// Objects that use "as const" aren't frozen at run-time, so they can only
+	// be inlined if no code can mutate them
+	var escaping map[ast.Ref]bool
+	for _, sourceIndex := range candidates {
+		if repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); repr.AST.UnfrozenConstObjectCandidates != nil {
+			escaping = c.findEscapingObjects(func(ref ast.Ref) bool {
+				repr, ok := c.graph.Files[ref.SourceIndex].InputFile.Repr.(*graph.JSRepr)
+				return ok && repr.AST.UnfrozenConstObjectCandidates[ref]
+			})
+			break
+		}
+	}
+
+	isInImportCycle := c.findFilesInImportCycles()
+	for _, sourceIndex := range candidates {
+		if isInImportCycle[sourceIndex] {
+			continue
+		}
+		repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		for ref, value := range repr.AST.ConstValueCandidates {
+			if c.graph.ConstValues == nil {
+				c.graph.ConstValues = make(map[ast.Ref]js_ast.ConstValue)
+			}
+			c.graph.ConstValues[ref] = value
+		}
+		for ref, values := range repr.AST.ConstObjectCandidates {
+			if escaping[ref] {
+				continue
+			}
+			if c.graph.ConstObjects == nil {
+				c.graph.ConstObjects = make(map[ast.Ref]map[string]js_ast.TSEnumValue)
+			}
+			c.graph.ConstObjects[ref] = values
+		}
+	}
+}
+
+// Small functions are always inlined at call sites with simple arguments.
+// Larger functions are only inlined if there's exactly one call to them in
+// the whole bundle and nothing else references them, since the function can
+// then be removed entirely. This can only be determined here because call
+// counts aren't known until all files have been parsed. Functions with other
+// statements in their body are only inlined if that one call is an entire
+// top-level statement, in which case the printer replaces the statement with
+// the function body.
+func (c *linkerContext) decideWhichFunctionsToInline() {
+	candidates := make(map[ast.Ref]js_ast.InlinableFunction)
+	exportsRefs := make(map[ast.Ref]bool)
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok && repr.AST.InlinableFunctions != nil {
+			for ref, fn := range repr.AST.InlinableFunctions {
+				// Identity functions are already handled separately
+				if (c.graph.Symbols.Get(ref).Flags & (ast.CouldPotentiallyBeMutated | ast.IsIdentityFunction)) == 0 {
+					candidates[ref] = fn
+				}
+			}
+			exportsRefs[repr.AST.ExportsRef] = true
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	// Count the calls with simple arguments separately from all other uses
+	type useCounts struct {
+		inlinableCalls uint32
+		otherUses      uint32
+		stmtCalls      uint32
+	}
+	counts := make(map[ast.Ref]useCounts)
+	stmtCallSites := make(map[ast.Ref]uint32)
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		if !ok {
+			continue
+		}
+		resolve := func(ref ast.Ref) (ast.Ref, bool) {
+			if importData, ok := repr.Meta.ImportsToBind[ref]; ok {
+				ref = importData.Ref
+			}
+			_, isCandidate := candidates[ref]
+			return ref, isCandidate || exportsRefs[ref]
+		}
+		for _, part := range repr.AST.Parts {
+			for ref, use := range part.SymbolUses {
+				if ref, ok := resolve(ref); ok {
+					count := counts[ref]
+					count.otherUses += use.CountEstimate
+					counts[ref] = count
+				}
+			}
+			for ref, properties := range part.ImportSymbolPropertyUses {
+				if ref, ok := resolve(ref); ok {
+					count := counts[ref]
+					for _, use := range properties {
+						count.otherUses += use.CountEstimate
+					}
+					counts[ref] = count
+				}
+			}
+			for ref, callUse := range part.SymbolCallUses {
+				if ref, ok := resolve(ref); ok {
+					count := counts[ref]
+					count.inlinableCalls += callUse.InlinableArgsCallCountEstimate
+					count.otherUses += callUse.CallCountEstimate - callUse.InlinableArgsCallCountEstimate
+					counts[ref] = count
+				}
+			}
+
+			// The printer looks for calls in exactly this form (see "printInlinedFunctionStmts")
+			for _, stmt := range part.Stmts {
+				if s, ok := stmt.Data.(*js_ast.SExpr); ok {
+					if call, ok := s.Value.Data.(*js_ast.ECall); ok && call.HasInlinableArgs {
+						var ref ast.Ref
+						switch target := call.Target.Data.(type) {
+						case *js_ast.EIdentifier:
+							ref = target.Ref
+						case *js_ast.EImportIdentifier:
+							ref = target.Ref
+						default:
+							continue
+						}
+						if ref, ok := resolve(ref); ok {
+							count := counts[ref]
+							count.stmtCalls++
+							counts[ref] = count
+							stmtCallSites[ref] = sourceIndex
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Functions that can be referenced from outside the bundle (or from an
+	// exports object) can't be removed, so don't duplicate large ones
+	isExported := make(map[ast.Ref]bool)
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		file := &c.graph.Files[sourceIndex]
+		repr, ok := file.InputFile.Repr.(*graph.JSRepr)
+		if !ok || (!file.IsEntryPoint() && repr.Meta.Wrap == graph.WrapNone && counts[repr.AST.ExportsRef] == (useCounts{})) {
+			continue
+		}
+		for _, export := range repr.Meta.ResolvedExports {
+			isExported[export.Ref] = true
+		}
+	}
+
+	budget := c.options.InlineBudget
+	if budget == 0 {
+		budget = config.DefaultInlineBudget
+	}
+	for ref, fn := range candidates {
+		count := counts[ref]
+		isOnlyUse := count.inlinableCalls == 1 && count.otherUses == 0 && !isExported[ref]
+		if fn.Stmts != nil {
+			// The function body may reference other top-level symbols in its file.
+			// Code splitting is avoided since the call site could then end up in a
+			// different chunk than those symbols.
+			if !isOnlyUse || count.stmtCalls != 1 || c.options.CodeSplitting {
+				continue
+			}
+			if c.graph.InlinedFunctionCallSites == nil {
+				c.graph.InlinedFunctionCallSites = make(map[ast.Ref]uint32)
+			}
+			c.graph.InlinedFunctionCallSites[ref] = stmtCallSites[ref]
+		} else if fn.Size > budget && !isOnlyUse {
+			continue
+		}
+		if c.graph.InlinedFunctions == nil {
+			c.graph.InlinedFunctions = make(map[ast.Ref]js_ast.InlinableFunction)
+		}
+		c.graph.InlinedFunctions[ref] = fn
+	}
+}
+
+func (c *linkerContext) appendInlinedFunctionDependencies(dependencies []js_ast.Dependency, ref ast.Ref, fn js_ast.InlinableFunction) []js_ast.Dependency {
+	repr := c.graph.Files[ref.SourceIndex].InputFile.Repr.(*graph.JSRepr)
+	for _, freeRef := range fn.FreeRefs {
+		for _, partIndex := range repr.TopLevelSymbolToParts(freeRef) {
+			dependencies = append(dependencies, js_ast.Dependency{
+				SourceIndex: ref.SourceIndex,
+				PartIndex:   partIndex,
+			})
+		}
+	}
+	return dependencies
+}
+
+// Top-level object literals that never escape have their properties turned
+// into separate top-level variables, so "a.b.c" becomes "a$b$c". An object
+// escapes if any file uses it as anything other than the root of a static
+// property access chain that ends at (or goes through) one of its leaf
+// properties, or if it may be visible outside of the bundle.
+func (c *linkerContext) collapseObjectProperties() {
+	type candidate struct {
+		sourceIndex uint32
+		leaves      []js_ast.CollapsibleLeaf
+		leafMap     map[string]js_ast.CollapsibleLeaf
+		usedLeaves  map[string]bool
+		escapes     bool
+	}
+	candidates := make(map[ast.Ref]*candidate)
+	var sortedRefs []ast.Ref
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok {
+			for ref, leaves := range repr.AST.CollapsibleObjects {
+				// Objects that are inlined like enums are handled separately
+				if _, ok := c.graph.ConstObjects[ref]; ok {
+					continue
+				}
+				leafMap := make(map[string]js_ast.CollapsibleLeaf, len(leaves))
+				for _, leaf := range leaves {
+					leafMap[leaf.Path] = leaf
+				}
+				candidates[ref] = &candidate{sourceIndex: sourceIndex, leaves: leaves, leafMap: leafMap}
+				sortedRefs = append(sortedRefs, ref)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	// Check how every file uses these objects
+	for ref := range c.findEscapingObjects(func(ref ast.Ref) bool { return candidates[ref] != nil }) {
+		candidates[ref].escapes = true
+	}
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		if !ok {
+			continue
+		}
+		for ref, uses := range repr.AST.PropertyChainUses {
+			if importData, ok := repr.Meta.ImportsToBind[ref]; ok {
+				ref = importData.Ref
+			}
+			candidate, ok := candidates[ref]
+			if !ok || candidate.escapes {
+				continue
+			}
+			for path, use := range uses {
+				leafPath, ok := findCollapsedLeafForPropertyChain(candidate.leafMap, path, use.IsCalled)
+				if !ok {
+					candidate.escapes = true
+					break
+				}
+				if candidate.usedLeaves == nil {
+					candidate.usedLeaves = make(map[string]bool)
+				}
+				candidate.usedLeaves[leafPath] = true
+			}
+		}
+	}
+
+	// Generate a top-level symbol for each property of the remaining objects.
+	// Do this in a deterministic order so the generated symbols are stable.
+	sort.Slice(sortedRefs, func(i, j int) bool {
+		a, b := sortedRefs[i], sortedRefs[j]
+		return a.SourceIndex < b.SourceIndex || (a.SourceIndex == b.SourceIndex && a.InnerIndex < b.InnerIndex)
+	})
+	for _, ref := range sortedRefs {
+		candidate := candidates[ref]
+		if candidate.escapes {
+			continue
+		}
+		repr := c.graph.Files[candidate.sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		partIndices := repr.TopLevelSymbolToParts(ref)
+		if len(partIndices) != 1 {
+			continue
+		}
+		part := &repr.AST.Parts[partIndices[0]]
+		declaredSymbols := append([]js_ast.DeclaredSymbol{}, part.DeclaredSymbols...)
+		name := c.graph.Symbols.Get(ref).OriginalName
+		leafRefs := make(map[string]ast.Ref, len(candidate.leaves))
+		for _, leaf := range candidate.leaves {
+			path := leaf.Path
+
+			// Properties that are never read don't need to be declared at all
+			if c.options.TreeShaking && leaf.CanBeRemovedIfUnused && !candidate.usedLeaves[path] {
+				leafRefs[path] = ast.InvalidRef
+				continue
+			}
+
+			leafRef := c.graph.GenerateNewSymbol(candidate.sourceIndex, ast.SymbolOther, name+"$"+strings.ReplaceAll(path, ".", "$"))
+			declaredSymbols = append(declaredSymbols, js_ast.DeclaredSymbol{Ref: leafRef, IsTopLevel: true})
+			if repr.Meta.TopLevelSymbolToPartsOverlay == nil {
+				repr.Meta.TopLevelSymbolToPartsOverlay = make(map[ast.Ref][]uint32)
+			}
+			repr.Meta.TopLevelSymbolToPartsOverlay[leafRef] = partIndices
+			leafRefs[path] = leafRef
+		}
+		part.DeclaredSymbols = declaredSymbols
+		if c.graph.CollapsedObjects == nil {
+			c.graph.CollapsedObjects = make(map[ast.Ref]map[string]ast.Ref)
+		}
+		c.graph.CollapsedObjects[ref] = leafRefs
+	}
+	if c.graph.CollapsedObjects == nil {
+		return
+	}
+
+	// Parts that use a collapsed object now use its properties instead. This
+	// is needed so that these symbols are imported across chunks.
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
+		if !ok || repr.AST.PropertyChainUses == nil {
+			continue
+		}
+		for partIndex := range repr.AST.Parts {
+			part := &repr.AST.Parts[partIndex]
+			var leafRefs []ast.Ref
+			for ref, uses := range repr.AST.PropertyChainUses {
+				_, isUsed := part.SymbolUses[ref]
+				if !isUsed {
+					_, isUsed = part.ImportSymbolPropertyUses[ref]
+				}
+				if !isUsed {
+					continue
+				}
+				if importData, ok := repr.Meta.ImportsToBind[ref]; ok {
+					ref = importData.Ref
+				}
+				if candidate := candidates[ref]; candidate != nil {
+					if collapsed, ok := c.graph.CollapsedObjects[ref]; ok {
+						for path := range uses {
+							leafPath, _ := findCollapsedLeafForPropertyChain(candidate.leafMap, path, false)
+							leafRefs = append(leafRefs, collapsed[leafPath])
+						}
+					}
+				}
+			}
+			if leafRefs != nil && part.SymbolUses == nil {
+				part.SymbolUses = make(map[ast.Ref]js_ast.SymbolUse)
+			}
+			for _, leafRef := range leafRefs {
+				use := part.SymbolUses[leafRef]
+				use.CountEstimate++
+				part.SymbolUses[leafRef] = use
+			}
+		}
+	}
+}
+
+// This returns the top-level objects for which "isCandidate" returns true that
+// any file uses as something other than the target of a property access that
+// isn't written to. Objects that are exported from an entry point or that can
+// be reached through an exports object may also be used by code we can't see.
+func (c *linkerContext) findEscapingObjects(isCandidate func(ast.Ref) bool) map[ast.Ref]bool {
+	escaping := make(map[ast.Ref]bool)
+	usedExportsRefs := make(map[ast.Ref]bool)
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok {
+			for ref := range repr.AST.EscapingSymbols {
+				if importData, ok := repr.Meta.ImportsToBind[ref]; ok {
+					ref = importData.Ref
+				}
+				if isCandidate(ref) {
+					escaping[ref] = true
+				}
+				usedExportsRefs[ref] = true
+			}
+		}
+	}
+
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		file := &c.graph.Files[sourceIndex]
+		repr, ok := file.InputFile.Repr.(*graph.JSRepr)
+		if !ok || (!file.IsEntryPoint() && repr.Meta.Wrap == graph.WrapNone && !usedExportsRefs[repr.AST.ExportsRef]) {
+			continue
+		}
+		for _, export := range repr.Meta.ResolvedExports {
+			if isCandidate(export.Ref) {
+				escaping[export.Ref] = true
+			}
+		}
+	}
+	return escaping
+}
+
+// A chain is valid if it ends at or goes through a leaf property. Calling a
+// leaf property must not be able to observe the value of "this", and reading
+// the "name" property of a leaf must not be able to observe its new name. This
+// returns the path of that leaf property.
+func findCollapsedLeafForPropertyChain(leaves map[string]js_ast.CollapsibleLeaf, path string, isCalled bool) (string, bool) {
+	for i := 0; i <= len(path); i++ {
+		if i == len(path) || path[i] == '.' {
+			if leaf, ok := leaves[path[:i]]; ok {
+				if i == len(path) {
+					return leaf.Path, !isCalled || leaf.CanBeCalledWithoutThis
+				}
+				rest := path[i+1:]
+				isName := rest == "name" || strings.HasPrefix(rest, "name.")
+				return leaf.Path, !isName || !leaf.NameIsInferred
+			}
+		}
+	}
+	return "", false
+}
+
+// This returns all files that can end up importing themselves, either directly
+// or indirectly. It finds the strongly-connected components of the import
+// graph using Tarjan's algorithm.
+func (c *linkerContext) findFilesInImportCycles() map[uint32]bool {
+	order := make([]uint32, len(c.graph.Files)) // Zero means not yet visited
+	lowLink := make([]uint32, len(c.graph.Files))
+	onStack := make([]bool, len(c.graph.Files))
+	stack := []uint32{}
+	nextOrder := uint32(1)
+	isInImportCycle := make(map[uint32]bool)
+
+	var visit func(sourceIndex uint32)
+	visit = func(sourceIndex uint32) {
+		order[sourceIndex] = nextOrder
+		lowLink[sourceIndex] = nextOrder
+		nextOrder++
+		stack = append(stack, sourceIndex)
+		onStack[sourceIndex] = true
+		importsItself := false
+
+		if repr, ok := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr); ok {
+			for _, record := range repr.AST.ImportRecords {
+				if !record.SourceIndex.IsValid() || record.Flags.Has(ast.IsUnused) {
+					continue
+				}
+				otherIndex := record.SourceIndex.GetIndex()
+				if otherIndex == sourceIndex {
+					importsItself = true
+				} else if order[otherIndex] == 0 {
+					visit(otherIndex)
+					if lowLink[otherIndex] < lowLink[sourceIndex] {
+						lowLink[sourceIndex] = lowLink[otherIndex]
+					}
+				} else if onStack[otherIndex] && order[otherIndex] < lowLink[sourceIndex] {
+					lowLink[sourceIndex] = order[otherIndex]
+				}
+			}
+		}
+
+		// Pop off the strongly-connected component if this file is its root
+		if lowLink[sourceIndex] == order[sourceIndex] {
+			start := len(stack) - 1
+			for stack[start] != sourceIndex {
+				start--
+			}
+			component := stack[start:]
+			for _, otherIndex := range component {
+				onStack[otherIndex] = false
+				if len(component) > 1 || importsItself {
+					isInImportCycle[otherIndex] = true
+				}
+			}
+			stack = stack[:start]
+		}
+	}
+
+	for _, sourceIndex := range c.graph.ReachableFiles {
+		if order[sourceIndex] == 0 {
+			visit(sourceIndex)
+		}
+	}
+	return isInImportCycle
+}
+
 func (c *linkerContext) recursivelyWrapDependencies(sourceIndex uint32) {
 	repr := c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr)
 	if repr.Meta.DidWrapDependencies {
@@ -3092,8 +3773,9 @@ func (c *linkerContext) advanceImportTracker(tracker importTracker) (importTrack
 		return importTracker{sourceIndex: otherSourceIndex, importRef: otherRepr.AST.ExportsRef}, importDynamicFallback, nil
 	}
 
-	// Missing re-exports in TypeScript files are indistinguishable from types
-	if file.InputFile.Loader.IsTypeScript() && namedImport.IsExported {
+	// Missing re-exports in TypeScript files are indistinguishable from types.
+	// The same goes for imports that are only used by decorator metadata.
+	if file.InputFile.Loader.IsTypeScript() && (namedImport.IsExported || namedImport.IsOnlyUsedByDecoratorMetadata) {
 		return importTracker{}, importProbablyTypeScriptType, nil
 	}
 
@@ -3860,7 +4542,11 @@ func (c *linkerContext) shouldRemoveImportExportStmt(
 			// This currently evaluates sibling dependencies in serial instead of in
 			// parallel, which is incorrect. This should be changed to store a promise
 			// and await all stored promises after all imports but before any code.
-			value.Data = &js_ast.EAwait{Value: value}
+			if c.options.UnsupportedJSFeatures.Has(compat.AsyncAwait) {
+				value.Data = &js_ast.EYield{ValueOrNil: value}
+			} else {
+				value.Data = &js_ast.EAwait{Value: value}
+			}
 		}
 		stmtList.insideWrapperPrefix = append(stmtList.insideWrapperPrefix, js_ast.Stmt{Loc: loc, Data: &js_ast.SExpr{Value: value}})
 	}
@@ -4423,6 +5109,24 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 			}
 			stmts = stmts[:end]
 
+			// The parser has already turned "await" into "yield" if async functions
+			// aren't supported, so the closure body becomes a generator instead:
+			//
+			//   return __async(null, null, function* () { ... });
+			//
+			if isAsync && c.options.UnsupportedJSFeatures.Has(compat.AsyncAwait) {
+				runtimeMembers := c.graph.Files[runtime.SourceIndex].InputFile.Repr.(*graph.JSRepr).AST.ModuleScope.Members
+				stmts = []js_ast.Stmt{{Data: &js_ast.SReturn{ValueOrNil: js_ast.Expr{Data: &js_ast.ECall{
+					Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: ast.FollowSymbols(c.graph.Symbols, runtimeMembers["__async"].Ref)}},
+					Args: []js_ast.Expr{
+						{Data: js_ast.ENullShared},
+						{Data: js_ast.ENullShared},
+						{Data: &js_ast.EFunction{Fn: js_ast.Fn{Body: js_ast.FnBody{Block: js_ast.SBlock{Stmts: stmts}}, IsGenerator: true}}},
+					},
+				}}}}}
+				isAsync = false
+			}
+
 			var esmArgs []js_ast.Expr
 			if c.options.ProfilerNames {
 				// "__esm({ 'file.js'() { ... } })"
@@ -4489,12 +5193,16 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 		MinifyWhitespace:             c.options.MinifyWhitespace,
 		MinifySyntax:                 c.options.MinifySyntax,
 		LineLimit:                    c.options.LineLimit,
+		Formatting:                   c.options.Formatting,
 		ASCIIOnly:                    c.options.ASCIIOnly,
 		ToCommonJSRef:                toCommonJSRef,
 		ToESMRef:                     toESMRef,
 		RuntimeRequireRef:            runtimeRequireRef,
 		TSEnums:                      c.graph.TSEnums,
 		ConstValues:                  c.graph.ConstValues,
+		ConstObjects:                 c.graph.ConstObjects,
+		InlinedFunctions:             c.graph.InlinedFunctions,
+		CollapsedObjects:             c.graph.CollapsedObjects,
 		LegalComments:                c.options.LegalComments,
 		UnsupportedFeatures:          c.options.UnsupportedJSFeatures,
 		SourceMap:                    c.options.SourceMap,
@@ -4521,6 +5229,34 @@ func (c *linkerContext) generateCodeForFileInChunkJS(
 	waitGroup.Done()
 }
 
+// The exports of an entry point that uses top-level await are only available
+// once the async initializer has finished, so the "cjs" and "iife" formats
+// expose a promise for them instead:
+//
+//	init_foo().then(() => __toCommonJS(exports))
+func (c *linkerContext) asyncEntryPointExports(repr *graph.JSRepr, toCommonJSRef ast.Ref) js_ast.Expr {
+	body := js_ast.FnBody{Block: js_ast.SBlock{Stmts: []js_ast.Stmt{{Data: &js_ast.SReturn{
+		ValueOrNil: js_ast.Expr{Data: &js_ast.ECall{
+			Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: toCommonJSRef}},
+			Args:   []js_ast.Expr{{Data: &js_ast.EIdentifier{Ref: repr.AST.ExportsRef}}},
+		}},
+	}}}}}
+	var callback js_ast.Expr
+	if c.options.UnsupportedJSFeatures.Has(compat.Arrow) {
+		callback = js_ast.Expr{Data: &js_ast.EFunction{Fn: js_ast.Fn{Body: body}}}
+	} else {
+		callback = js_ast.Expr{Data: &js_ast.EArrow{Body: body, PreferExpr: true}}
+	}
+	initCall := js_ast.Expr{Data: &js_ast.ECall{
+		Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: repr.AST.WrapperRef}},
+	}}
+	return js_ast.Expr{Data: &js_ast.ECall{
+		Target: js_ast.Expr{Data: &js_ast.EDot{Target: initCall, Name: "then"}},
+		Args:   []js_ast.Expr{callback},
+		Kind:   js_ast.TargetWasOriginallyPropertyAccess,
+	}}
+}
+
 func (c *linkerContext) generateEntryPointTailJS(
 	r renamer.Renamer,
 	toCommonJSRef ast.Ref,
@@ -4554,6 +5290,20 @@ func (c *linkerContext) generateEntryPointTailJS(
 					Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: repr.AST.WrapperRef}},
 				}}}})
 			}
+		} else if repr.Meta.Wrap == graph.WrapESM && repr.Meta.IsAsyncOrHasAsyncDependency {
+			initCall := js_ast.Expr{Data: &js_ast.ECall{
+				Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: repr.AST.WrapperRef}},
+			}}
+
+			if repr.Meta.ForceIncludeExportsForEntryPoint {
+				// "return init_foo().then(() => __toCommonJS(exports));"
+				stmts = append(stmts, js_ast.Stmt{Data: &js_ast.SReturn{
+					ValueOrNil: c.asyncEntryPointExports(repr, toCommonJSRef),
+				}})
+			} else {
+				// "init_foo();"
+				stmts = append(stmts, js_ast.Stmt{Data: &js_ast.SExpr{Value: initCall}})
+			}
 		} else {
 			if repr.Meta.Wrap == graph.WrapESM {
 				// "init_foo();"
@@ -4585,6 +5335,15 @@ func (c *linkerContext) generateEntryPointTailJS(
 					Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: repr.AST.WrapperRef}},
 				}},
 			))
+		} else if repr.Meta.Wrap == graph.WrapESM && repr.Meta.IsAsyncOrHasAsyncDependency && repr.Meta.ForceIncludeExportsForEntryPoint {
+			// "module.exports = init_foo().then(() => __toCommonJS(exports));"
+			stmts = append(stmts, js_ast.AssignStmt(
+				js_ast.Expr{Data: &js_ast.EDot{
+					Target: js_ast.Expr{Data: &js_ast.EIdentifier{Ref: c.unboundModuleRef}},
+					Name:   "exports",
+				}},
+				c.asyncEntryPointExports(repr, toCommonJSRef),
+			))
 		} else {
 			if repr.Meta.Wrap == graph.WrapESM {
 				// "init_foo();"
@@ -4599,7 +5358,10 @@ func (c *linkerContext) generateEntryPointTailJS(
 		// of this parser, which the node project uses to detect named exports in
 		// CommonJS files: https://github.com/guybedford/cjs-module-lexer. Think of
 		// this code as an annotation for that parser.
-		if c.options.Platform == config.PlatformNode {
+		//
+		// This isn't done when "module.exports" is a promise because these names
+		// would then be wrong.
+		if c.options.Platform == config.PlatformNode && !(repr.Meta.Wrap == graph.WrapESM && repr.Meta.IsAsyncOrHasAsyncDependency) {
 			// Add a comment since otherwise people will surely wonder what this is.
 			// This annotation means you can do this and have it work:
 			//
@@ -4832,6 +5594,7 @@ func (c *linkerContext) generateEntryPointTailJS(
 		MinifyWhitespace:             c.options.MinifyWhitespace,
 		MinifySyntax:                 c.options.MinifySyntax,
 		LineLimit:                    c.options.LineLimit,
+		Formatting:                   c.options.Formatting,
 		ASCIIOnly:                    c.options.ASCIIOnly,
 		ToCommonJSRef:                toCommonJSRef,
 		ToESMRef:                     toESMRef,
@@ -4853,12 +5616,31 @@ func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []ui
 		defer timer.End("Rename symbols")
 	}
 
+	// Functions with a statement body that are inlined into this chunk still use
+	// their own symbols for their local variables, so they must be renamed along
+	// with this chunk even though the function itself isn't in it. Their names
+	// then can't collide with any top-level symbol at the call site.
+	var inlinedFunctions []ast.Ref
+	for ref, callSite := range c.graph.InlinedFunctionCallSites {
+		if chunk.filesWithPartsInChunk[callSite] {
+			inlinedFunctions = append(inlinedFunctions, ref)
+		}
+	}
+	sort.Slice(inlinedFunctions, func(i int, j int) bool {
+		a, b := inlinedFunctions[i], inlinedFunctions[j]
+		ai, bi := c.graph.StableSourceIndices[a.SourceIndex], c.graph.StableSourceIndices[b.SourceIndex]
+		return ai < bi || (ai == bi && a.InnerIndex < b.InnerIndex)
+	})
+
 	// Determine the reserved names (e.g. can't generate the name "if")
 	timer.Begin("Compute reserved names")
-	moduleScopes := make([]*js_ast.Scope, len(filesInOrder))
+	moduleScopes := make([]*js_ast.Scope, len(filesInOrder), len(filesInOrder)+len(inlinedFunctions))
 	for i, sourceIndex := range filesInOrder {
 		moduleScopes[i] = c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr).AST.ModuleScope
 	}
+	for _, ref := range inlinedFunctions {
+		moduleScopes = append(moduleScopes, c.graph.Files[ref.SourceIndex].InputFile.Repr.(*graph.JSRepr).AST.ModuleScope)
+	}
 	reservedNames := renamer.ComputeReservedNames(moduleScopes, c.graph.Symbols)
 
 	// These are used to implement bundling, and need to be free for use
@@ -4887,6 +5669,9 @@ func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []ui
 		for _, sourceIndex := range filesInOrder {
 			firstTopLevelSlots.UnionMax(c.graph.Files[sourceIndex].InputFile.Repr.(*graph.JSRepr).AST.NestedScopeSlotCounts)
 		}
+		for _, ref := range inlinedFunctions {
+			firstTopLevelSlots.UnionMax(c.graph.Files[ref.SourceIndex].InputFile.Repr.(*graph.JSRepr).AST.NestedScopeSlotCounts)
+		}
 		r := renamer.NewMinifyRenamer(c.graph.Symbols, firstTopLevelSlots, reservedNames)
 
 		// Accumulate nested symbol usage counts
@@ -4948,6 +5733,12 @@ func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []ui
 		for _, array := range allTopLevelSymbols {
 			topLevelSymbols = append(topLevelSymbols, array...)
 		}
+		for _, ref := range inlinedFunctions {
+			repr := c.graph.Files[ref.SourceIndex].InputFile.Repr.(*graph.JSRepr)
+			for _, partIndex := range repr.TopLevelSymbolToParts(ref) {
+				r.AccumulateSymbolUseCounts(&topLevelSymbols, repr.AST.Parts[partIndex].SymbolUses, stableSourceIndices)
+			}
+		}
 		r.AllocateTopLevelSymbolSlots(topLevelSymbols)
 		timer.End("Serial phase")
 		timer.End("Accumulate symbol counts")
@@ -5068,6 +5859,12 @@ func (c *linkerContext) renameSymbolsInChunk(chunk *chunkInfo, filesInOrder []ui
 
 		nestedScopes[sourceIndex] = scopes
 	}
+	for _, ref := range inlinedFunctions {
+		repr := c.graph.Files[ref.SourceIndex].InputFile.Repr.(*graph.JSRepr)
+		for _, partIndex := range repr.TopLevelSymbolToParts(ref) {
+			nestedScopes[ref.SourceIndex] = append(nestedScopes[ref.SourceIndex], repr.AST.Parts[partIndex].Scopes...)
+		}
+	}
 	timer.End("Add top-level symbols")
 
 	// Recursively rename symbols in child scopes now that all top-level
@@ -5154,6 +5951,7 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 			MinifyWhitespace:  c.options.MinifyWhitespace,
 			MinifySyntax:      c.options.MinifySyntax,
 			LineLimit:         c.options.LineLimit,
+			Formatting:        c.options.Formatting,
 			NeedsMetafile:     c.options.NeedsMetafile,
 		}
 		crossChunkImportRecords := make([]ast.ImportRecord, len(chunk.crossChunkImports))
@@ -5197,9 +5995,12 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 	indent := ""
 	space := " "
 	newline := "\n"
+	semicolon := ";"
 	if c.options.MinifyWhitespace {
 		space = ""
 		newline = ""
+	} else if c.options.Formatting.Semicolons == config.SemicolonsASI {
+		semicolon = ""
 	}
 	newlineBeforeComment := false
 	isExecutable := false
@@ -5233,7 +6034,13 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 		repr := c.graph.Files[chunk.sourceIndex].InputFile.Repr.(*graph.JSRepr)
 		for _, directive := range repr.AST.Directives {
 			if directive != "use strict" || c.options.OutputFormat != config.FormatESModule {
-				quoted := string(helpers.QuoteForJSON(directive, c.options.ASCIIOnly)) + ";" + newline
+				var quoted string
+				if c.options.Formatting.Quotes == config.QuotesSingle && !c.options.MinifyWhitespace {
+					quoted = string(helpers.QuoteSingle(directive, c.options.ASCIIOnly))
+				} else {
+					quoted = string(helpers.QuoteForJSON(directive, c.options.ASCIIOnly))
+				}
+				quoted += semicolon + newline
 				prevOffset.AdvanceString(quoted)
 				j.AddString(quoted)
 				newlineBeforeComment = true
@@ -5244,9 +6051,13 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 	// Optionally wrap with an IIFE
 	if c.options.OutputFormat == config.FormatIIFE {
 		var text string
-		indent = "  "
+		indent = c.options.Formatting.IndentText()
 		if len(c.options.GlobalName) > 0 {
 			text = c.generateGlobalNamePrefix()
+		} else if semicolon == "" {
+			// Without semicolons, something before this (e.g. a directive or a
+			// banner) could otherwise end up being called as a function
+			text = ";"
 		}
 		if c.options.UnsupportedJSFeatures.Has(compat.Arrow) {
 			text += "(function()" + space + "{" + newline
@@ -5439,7 +6250,7 @@ func (c *linkerContext) generateChunkJS(chunkIndex int, chunkWaitGroup *sync.Wai
 
 	// Optionally wrap with an IIFE
 	if c.options.OutputFormat == config.FormatIIFE {
-		j.AddString("})();" + newline)
+		j.AddString("})()" + semicolon + newline)
 	}
 
 	// Make sure the file ends with a newline
@@ -5665,6 +6476,7 @@ func (c *linkerContext) generateChunkCSS(chunkIndex int, chunkWaitGroup *sync.Wa
 			cssOptions := css_printer.Options{
 				MinifyWhitespace:    c.options.MinifyWhitespace,
 				LineLimit:           c.options.LineLimit,
+				Formatting:          c.options.Formatting,
 				ASCIIOnly:           c.options.ASCIIOnly,
 				LegalComments:       c.options.LegalComments,
 				SourceMap:           c.options.SourceMap,
@@ -5729,6 +6541,7 @@ func (c *linkerContext) generateChunkCSS(chunkIndex int, chunkWaitGroup *sync.Wa
 			result := css_printer.Print(tree, c.graph.Symbols, css_printer.Options{
 				MinifyWhitespace: c.options.MinifyWhitespace,
 				LineLimit:        c.options.LineLimit,
+				Formatting:       c.options.Formatting,
 				ASCIIOnly:        c.options.ASCIIOnly,
 				NeedsMetafile:    c.options.NeedsMetafile,
 			})
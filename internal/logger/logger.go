@@ -11,6 +11,7 @@ package logger
 // default.
 
 import (
+	"encoding/binary"
 	"fmt"
 	"os"
 	"runtime"
@@ -248,9 +249,75 @@ type Path struct {
 	// the output. This is supported by other bundlers, so we also support this.
 	IgnoredSuffix string
 
+	// Import attributes (the "with" keyword after an import) can affect how a
+	// path is resolved and loaded. In other words, two imports of the same path
+	// with different import attributes may result in two different modules.
+	ImportAttributes ImportAttributes
+
 	Flags PathFlags
 }
 
+// We rely on paths as map keys. Go doesn't support custom hash codes and only
+// implements hash codes for certain types. In particular, hash codes are
+// implemented for strings but not for maps or arrays of strings. So we have to
+// pack these import attributes into a string.
+type ImportAttributes struct {
+	packedData string
+}
+
+type ImportAttribute struct {
+	Key   string
+	Value string
+}
+
+func EncodeImportAttributes(value map[string]string) ImportAttributes {
+	if len(value) == 0 {
+		return ImportAttributes{}
+	}
+	keys := make([]string, 0, len(value))
+	for k := range value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	var n [4]byte
+	for _, k := range keys {
+		v := value[k]
+		binary.LittleEndian.PutUint32(n[:], uint32(len(k)))
+		sb.Write(n[:])
+		sb.WriteString(k)
+		binary.LittleEndian.PutUint32(n[:], uint32(len(v)))
+		sb.Write(n[:])
+		sb.WriteString(v)
+	}
+	return ImportAttributes{packedData: sb.String()}
+}
+
+// This returns a sorted array instead of a map to make determinism easier
+func (attrs ImportAttributes) DecodeIntoArray() (result []ImportAttribute) {
+	data := attrs.packedData
+	for len(data) > 0 {
+		kn := 4 + binary.LittleEndian.Uint32([]byte(data[:4]))
+		k := data[4:kn]
+		data = data[kn:]
+		vn := 4 + binary.LittleEndian.Uint32([]byte(data[:4]))
+		v := data[4:vn]
+		data = data[vn:]
+		result = append(result, ImportAttribute{Key: k, Value: v})
+	}
+	return
+}
+
+func (attrs ImportAttributes) DecodeIntoMap() (result map[string]string) {
+	if array := attrs.DecodeIntoArray(); len(array) > 0 {
+		result = make(map[string]string, len(array))
+		for _, attr := range array {
+			result[attr.Key] = attr.Value
+		}
+	}
+	return
+}
+
 type PathFlags uint8
 
 const (
@@ -266,7 +333,8 @@ func (a Path) ComesBeforeInSortedOrder(b Path) bool {
 	return a.Namespace > b.Namespace ||
 		(a.Namespace == b.Namespace && (a.Text < b.Text ||
 			(a.Text == b.Text && (a.Flags < b.Flags ||
-				(a.Flags == b.Flags && a.IgnoredSuffix < b.IgnoredSuffix)))))
+				(a.Flags == b.Flags && (a.IgnoredSuffix < b.IgnoredSuffix ||
+					(a.IgnoredSuffix == b.IgnoredSuffix && a.ImportAttributes.packedData < b.ImportAttributes.packedData)))))))
 }
 
 var noColorResult bool
@@ -38,9 +38,14 @@ const (
 	MsgID_JS_ThisIsUndefinedInESM
 	MsgID_JS_UnsupportedDynamicImport
 	MsgID_JS_UnsupportedJSXComment
+	MsgID_JS_UnsupportedLoopClosure
 	MsgID_JS_UnsupportedRegExp
 	MsgID_JS_UnsupportedRequireCall
 
+	// TypeScript
+	MsgID_TS_ErasableSyntaxOnly
+	MsgID_TS_MissingTypeModifier
+
 	// CSS
 	MsgID_CSS_CSSSyntaxError
 	MsgID_CSS_InvalidAtCharset
@@ -144,11 +149,19 @@ func StringToMsgIDs(str string, logLevel LogLevel, overrides map[MsgID]LogLevel)
 		overrides[MsgID_JS_UnsupportedDynamicImport] = logLevel
 	case "unsupported-jsx-comment":
 		overrides[MsgID_JS_UnsupportedJSXComment] = logLevel
+	case "unsupported-loop-closure":
+		overrides[MsgID_JS_UnsupportedLoopClosure] = logLevel
 	case "unsupported-regexp":
 		overrides[MsgID_JS_UnsupportedRegExp] = logLevel
 	case "unsupported-require-call":
 		overrides[MsgID_JS_UnsupportedRequireCall] = logLevel
 
+	// TypeScript
+	case "erasable-syntax-only":
+		overrides[MsgID_TS_ErasableSyntaxOnly] = logLevel
+	case "missing-type-modifier":
+		overrides[MsgID_TS_MissingTypeModifier] = logLevel
+
 	// CSS
 	case "css-syntax-error":
 		overrides[MsgID_CSS_CSSSyntaxError] = logLevel
@@ -268,11 +281,19 @@ func MsgIDToString(id MsgID) string {
 		return "unsupported-dynamic-import"
 	case MsgID_JS_UnsupportedJSXComment:
 		return "unsupported-jsx-comment"
+	case MsgID_JS_UnsupportedLoopClosure:
+		return "unsupported-loop-closure"
 	case MsgID_JS_UnsupportedRegExp:
 		return "unsupported-regexp"
 	case MsgID_JS_UnsupportedRequireCall:
 		return "unsupported-require-call"
 
+	// TypeScript
+	case MsgID_TS_ErasableSyntaxOnly:
+		return "erasable-syntax-only"
+	case MsgID_TS_MissingTypeModifier:
+		return "missing-type-modifier"
+
 	// CSS
 	case MsgID_CSS_CSSSyntaxError:
 		return "css-syntax-error"
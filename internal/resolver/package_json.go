@@ -350,7 +350,7 @@ func (r resolverQuery) parsePackageJSON(inputPath string) *packageJSON {
 			}
 		}
 	}
-	for _, field := range mainFieldsForFailure {
+	for _, field := range append(mainFieldsForFailure, mainFieldsForTypes...) {
 		if _, ok := packageJSON.mainFields[field]; !ok {
 			if mainJSON, mainLoc, ok := getProperty(json, field); ok {
 				if main, ok := getString(mainJSON); ok && main != "" {
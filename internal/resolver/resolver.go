@@ -57,6 +57,9 @@ var defaultMainFields = map[config.Platform][]string{
 // to something unusual, such as something without the "main" field.
 var mainFieldsForFailure = []string{"main", "module"}
 
+// These are checked before the other main fields for type imports
+var mainFieldsForTypes = []string{"types", "typings"}
+
 // Path resolution is a mess. One tricky issue is the "module" override for the
 // "main" field in "package.json" files. Bundlers generally prefer "module" over
 // "main" but that breaks packages that export a function in "main" for use with
@@ -170,6 +173,7 @@ type Resolver struct {
 	esmConditionsDefault map[string]bool
 	esmConditionsImport  map[string]bool
 	esmConditionsRequire map[string]bool
+	esmConditionsTypes   map[string]bool
 
 	// A special filtered import order for CSS "@import" imports.
 	//
@@ -211,10 +215,19 @@ type Resolver struct {
 	// compiled code, which is what will be loaded by node at run-time.
 	nodeModulesExtensionOrder []string
 
+	// A special import order for type imports in declaration files. These
+	// prefer declaration files, followed by the TypeScript files that
+	// declaration files can be generated from.
+	typesExtensionOrder []string
+
 	// This cache maps a directory path to information about that directory and
 	// all parent directories
 	dirCache map[string]*dirInfo
 
+	// This cache maps the path of a project in a "references" array to the
+	// parsed "tsconfig.json" file for that project (or nil if it's missing)
+	tsConfigReferenceCache map[string]*TSConfigJSON
+
 	pnpManifestWasChecked bool
 	pnpManifest           *pnpData
 
@@ -236,6 +249,11 @@ type resolverQuery struct {
 	debugMeta *DebugMeta
 	debugLogs *debugLogs
 	kind      ast.ImportKind
+
+	// The "tsconfig.json" file for the directory containing the importer. This
+	// is used for settings that affect every file probed during resolution
+	// (e.g. "moduleSuffixes"), not just the initial import path.
+	importerTSConfigJSON *TSConfigJSON
 }
 
 func NewResolver(call config.APICall, fs fs.FS, log logger.Log, caches *cache.CacheSet, options *config.Options) *Resolver {
@@ -261,10 +279,19 @@ func NewResolver(call config.APICall, fs fs.FS, log logger.Log, caches *cache.Ca
 		}
 	}
 
+	// Prefer declaration files over TypeScript files for type imports
+	typesExtensionOrder := []string{".d.ts", ".d.mts", ".d.cts"}
+	for _, ext := range options.ExtensionOrder {
+		if loader, ok := options.ExtensionToLoader[ext]; ok && loader.IsTypeScript() {
+			typesExtensionOrder = append(typesExtensionOrder, ext)
+		}
+	}
+
 	// Generate the condition sets for interpreting the "exports" field
 	esmConditionsDefault := map[string]bool{"default": true}
 	esmConditionsImport := map[string]bool{"import": true}
 	esmConditionsRequire := map[string]bool{"require": true}
+	esmConditionsTypes := map[string]bool{"import": true, "types": true}
 	for _, condition := range options.Conditions {
 		esmConditionsDefault[condition] = true
 	}
@@ -277,6 +304,7 @@ func NewResolver(call config.APICall, fs fs.FS, log logger.Log, caches *cache.Ca
 	for key := range esmConditionsDefault {
 		esmConditionsImport[key] = true
 		esmConditionsRequire[key] = true
+		esmConditionsTypes[key] = true
 	}
 
 	fs.Cwd()
@@ -287,11 +315,14 @@ func NewResolver(call config.APICall, fs fs.FS, log logger.Log, caches *cache.Ca
 		options:                   *options,
 		caches:                    caches,
 		dirCache:                  make(map[string]*dirInfo),
+		tsConfigReferenceCache:    make(map[string]*TSConfigJSON),
 		cssExtensionOrder:         cssExtensionOrder,
 		nodeModulesExtensionOrder: nodeModulesExtensionOrder,
+		typesExtensionOrder:       typesExtensionOrder,
 		esmConditionsDefault:      esmConditionsDefault,
 		esmConditionsImport:       esmConditionsImport,
 		esmConditionsRequire:      esmConditionsRequire,
+		esmConditionsTypes:        esmConditionsTypes,
 	}
 
 	// Handle the "tsconfig.json" override when the resolver is created. This
@@ -546,6 +577,9 @@ func (res *Resolver) Resolve(sourceDir string, importPath string, kind ast.Impor
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	sourceDirInfo := r.dirInfoCached(sourceDir)
+	if sourceDirInfo != nil {
+		r.importerTSConfigJSON = r.tsConfigForDir(sourceDirInfo)
+	}
 
 	// Check for the Yarn PnP manifest if it hasn't already been checked for
 	if !r.pnpManifestWasChecked {
@@ -874,6 +908,9 @@ func (r resolverQuery) resolveWithoutSymlinks(sourceDir string, sourceDirInfo *d
 			if absolute, ok, diffCase := r.loadAsFileOrDirectory(absPath); ok {
 				checkPackage = false
 				result = ResolveResult{PathPair: absolute, DifferentCase: diffCase}
+			} else if absolute, ok, diffCase := r.loadAsFileOrDirectoryUsingRootDirs(absPath); ok {
+				checkPackage = false
+				result = ResolveResult{PathPair: absolute, DifferentCase: diffCase}
 			} else if !checkPackage {
 				return nil
 			}
@@ -886,6 +923,21 @@ func (r resolverQuery) resolveWithoutSymlinks(sourceDir string, sourceDirInfo *d
 			return nil
 		}
 
+		// Generated declaration files import the types of other packages from
+		// those packages instead of inlining them, the same way that code that
+		// uses those packages would import them. Paths that a tsconfig "paths"
+		// or "baseUrl" setting maps to local files are still inlined, as are
+		// packages that were configured to be inlined.
+		if r.kind == ast.ImportTypes && !strings.HasPrefix(importPath, "#") && !r.isExternal(r.options.DeclarationsInline, importPath, r.kind) {
+			if absolute, ok, diffCase := r.loadTSConfigOverride(importPath, sourceDirInfo); ok {
+				return &ResolveResult{PathPair: absolute, DifferentCase: diffCase}
+			}
+			if r.debugLogs != nil {
+				r.debugLogs.addNote("Marking this path as external because it's a package path in a declaration file")
+			}
+			return &ResolveResult{PathPair: PathPair{Primary: logger.Path{Text: importPath}}, IsExternal: true}
+		}
+
 		// Support remapping one package path to another via the "browser" field
 		if remapped, ok := r.checkBrowserMap(sourceDirInfo, importPath, packagePathKind); ok {
 			if remapped == nil {
@@ -1295,6 +1347,24 @@ func (r resolverQuery) parseTSConfigFromSource(source logger.Source, visited map
 		result.BaseURLForPaths = r.fs.Join(fileDir, result.BaseURLForPaths)
 	}
 
+	// Note: Settings inherited via "extends" have already been made absolute
+	// relative to the file they came from, so they are left alone here
+	for i, dir := range result.RootDirs {
+		if !r.fs.IsAbs(dir) {
+			result.RootDirs[i] = r.fs.Join(fileDir, dir)
+		}
+	}
+	for _, dir := range []*string{&result.RootDir, &result.OutDir, &result.DeclarationDir} {
+		if *dir != "" && !r.fs.IsAbs(*dir) {
+			*dir = r.fs.Join(fileDir, *dir)
+		}
+	}
+	for i, path := range result.References {
+		if !r.fs.IsAbs(path) {
+			result.References[i] = r.fs.Join(fileDir, path)
+		}
+	}
+
 	// Now that we have parsed the entire "tsconfig.json" file, filter out any
 	// paths that are invalid due to being a package-style path without a base
 	// URL specified. This must be done here instead of when we're parsing the
@@ -1517,6 +1587,22 @@ var rewrittenFileExtensions = map[string][]string{
 	".cjs": {".cts"},
 }
 
+// Type imports in declaration files follow TypeScript's behavior more
+// closely, and also try the ".d.ts" file that describes a ".js" file
+var rewrittenFileExtensionsForTypes = map[string][]string{
+	".js":  {".d.ts", ".ts", ".tsx"},
+	".jsx": {".d.ts", ".ts", ".tsx"},
+	".mjs": {".d.mts", ".mts"},
+	".cjs": {".d.cts", ".cts"},
+}
+
+func (r resolverQuery) rewrittenFileExtensions() map[string][]string {
+	if r.kind == ast.ImportTypes {
+		return rewrittenFileExtensionsForTypes
+	}
+	return rewrittenFileExtensions
+}
+
 func (r resolverQuery) loadAsFile(path string, extensionOrder []string) (string, bool, *fs.DifferentCase) {
 	if r.debugLogs != nil {
 		r.debugLogs.addNote(fmt.Sprintf("Attempting to load %q as a file", path))
@@ -1524,6 +1610,12 @@ func (r resolverQuery) loadAsFile(path string, extensionOrder []string) (string,
 		defer r.debugLogs.decreaseIndent()
 	}
 
+	// TypeScript-specific behavior: if this is an output file of a project in
+	// "references", prefer the source file that it would be generated from
+	if absolute, ok, diffCase := r.loadFromReferencedProjectSource(path, extensionOrder); ok {
+		return absolute, ok, diffCase
+	}
+
 	// Read the directory entries once to minimize locking
 	dirPath := r.fs.Dir(path)
 	entries, err, originalError := r.fs.ReadDirectory(dirPath)
@@ -1601,30 +1693,68 @@ func (r resolverQuery) loadAsFile(path string, extensionOrder []string) (string,
 	// LOAD_INDEX together while node always does one LOAD_AS_FILE before one
 	// LOAD_INDEX.
 
-	// Try the plain path without any extensions
-	if absolute, ok, diffCase := tryFile(base); ok {
-		return absolute, ok, diffCase
+	// TypeScript-specific behavior: try rewriting ".js" to ".ts"
+	tryRewrittenExtensions := func(base string) (string, bool, *fs.DifferentCase) {
+		for old, exts := range r.rewrittenFileExtensions() {
+			if !strings.HasSuffix(base, old) {
+				continue
+			}
+			lastDot := strings.LastIndexByte(base, '.')
+			for _, ext := range exts {
+				if absolute, ok, diffCase := tryFile(base[:lastDot] + ext); ok {
+					return absolute, ok, diffCase
+				}
+			}
+			break
+		}
+		return "", false, nil
 	}
 
-	// Try the path with extensions
-	for _, ext := range extensionOrder {
-		if absolute, ok, diffCase := tryFile(base + ext); ok {
+	tryBase := func(base string) (string, bool, *fs.DifferentCase) {
+		// Type imports prefer the ".d.ts" file next to a ".js" file over the ".js"
+		// file itself, since the ".js" file doesn't contain any types
+		if r.kind == ast.ImportTypes {
+			if absolute, ok, diffCase := tryRewrittenExtensions(base); ok {
+				return absolute, ok, diffCase
+			}
+		}
+
+		// Try the plain path without any extensions
+		if absolute, ok, diffCase := tryFile(base); ok {
 			return absolute, ok, diffCase
 		}
-	}
 
-	// TypeScript-specific behavior: try rewriting ".js" to ".ts"
-	for old, exts := range rewrittenFileExtensions {
-		if !strings.HasSuffix(base, old) {
-			continue
+		// Try the path with extensions
+		for _, ext := range extensionOrder {
+			if absolute, ok, diffCase := tryFile(base + ext); ok {
+				return absolute, ok, diffCase
+			}
+		}
+
+		if r.kind != ast.ImportTypes {
+			if absolute, ok, diffCase := tryRewrittenExtensions(base); ok {
+				return absolute, ok, diffCase
+			}
 		}
-		lastDot := strings.LastIndexByte(base, '.')
-		for _, ext := range exts {
-			if absolute, ok, diffCase := tryFile(base[:lastDot] + ext); ok {
+
+		return "", false, nil
+	}
+
+	// TypeScript-specific behavior: "moduleSuffixes" replaces the plain path
+	// with one path per suffix. Note that the plain path is only checked if
+	// the empty string is one of the suffixes, which matches TypeScript.
+	if suffixes := r.moduleSuffixes(); suffixes != nil {
+		for _, suffix := range suffixes {
+			suffixedBase := r.insertModuleSuffix(base, suffix, extensionOrder)
+			if r.debugLogs != nil {
+				r.debugLogs.addNote(fmt.Sprintf("Checking module suffix %q using %q", suffix, suffixedBase))
+			}
+			if absolute, ok, diffCase := tryBase(suffixedBase); ok {
 				return absolute, ok, diffCase
 			}
 		}
-		break
+	} else if absolute, ok, diffCase := tryBase(base); ok {
+		return absolute, ok, diffCase
 	}
 
 	if r.debugLogs != nil {
@@ -1634,9 +1764,23 @@ func (r resolverQuery) loadAsFile(path string, extensionOrder []string) (string,
 }
 
 func (r resolverQuery) loadAsIndex(dirInfo *dirInfo, extensionOrder []string) (PathPair, bool, *fs.DifferentCase) {
+	// TypeScript-specific behavior: "moduleSuffixes" also applies to "index"
+	if suffixes := r.moduleSuffixes(); suffixes != nil {
+		for _, suffix := range suffixes {
+			if absolute, ok, diffCase := r.loadAsIndexWithSuffix(dirInfo, "index"+suffix, extensionOrder); ok {
+				return absolute, ok, diffCase
+			}
+		}
+		return PathPair{}, false, nil
+	}
+
+	return r.loadAsIndexWithSuffix(dirInfo, "index", extensionOrder)
+}
+
+func (r resolverQuery) loadAsIndexWithSuffix(dirInfo *dirInfo, index string, extensionOrder []string) (PathPair, bool, *fs.DifferentCase) {
 	// Try the "index" file with extensions
 	for _, ext := range extensionOrder {
-		base := "index" + ext
+		base := index + ext
 		if entry, diffCase := dirInfo.entries.Get(base); entry != nil && entry.Kind(r.fs) == fs.FileEntry {
 			if r.debugLogs != nil {
 				r.debugLogs.addNote(fmt.Sprintf("Found file %q", r.fs.Join(dirInfo.absPath, base)))
@@ -1710,6 +1854,9 @@ func (r resolverQuery) loadAsFileOrDirectory(path string) (PathPair, bool, *fs.D
 	if r.kind.MustResolveToCSS() {
 		// Use a special import order for CSS "@import" imports
 		extensionOrder = r.cssExtensionOrder
+	} else if r.kind == ast.ImportTypes {
+		// Use a special import order for type imports in declaration files
+		extensionOrder = r.typesExtensionOrder
 	} else if helpers.IsInsideNodeModules(path) {
 		// Use a special import order for imports inside "node_modules"
 		extensionOrder = r.nodeModulesExtensionOrder
@@ -1761,6 +1908,11 @@ func (r resolverQuery) loadAsMainField(dirInfo *dirInfo, path string, extensionO
 		autoMain = true
 	}
 
+	// Type imports in declaration files check the "types" fields first
+	if r.kind == ast.ImportTypes {
+		mainFieldKeys = append(append([]string{}, mainFieldsForTypes...), mainFieldKeys...)
+	}
+
 	loadMainField := func(fieldRelPath string, field string) (PathPair, bool, *fs.DifferentCase) {
 		if r.debugLogs != nil {
 			r.debugLogs.addNote(fmt.Sprintf("Found main field %q with path %q", field, fieldRelPath))
@@ -1935,7 +2087,7 @@ func (r resolverQuery) matchTSConfigPaths(tsConfigJSON *TSConfigJSON, path strin
 			}
 			for _, originalPath := range originalPaths {
 				// Ignore ".d.ts" files because this rule is obviously only here for type checking
-				if hasCaseInsensitiveSuffix(originalPath.Text, ".d.ts") {
+				if r.kind != ast.ImportTypes && hasCaseInsensitiveSuffix(originalPath.Text, ".d.ts") {
 					if r.debugLogs != nil {
 						r.debugLogs.addNote(fmt.Sprintf("Ignoring substitution %q because it ends in \".d.ts\"", originalPath.Text))
 					}
@@ -2000,7 +2152,7 @@ func (r resolverQuery) matchTSConfigPaths(tsConfigJSON *TSConfigJSON, path strin
 			originalPath := strings.Replace(originalPath.Text, "*", matchedText, 1)
 
 			// Ignore ".d.ts" files because this rule is obviously only here for type checking
-			if hasCaseInsensitiveSuffix(originalPath, ".d.ts") {
+			if r.kind != ast.ImportTypes && hasCaseInsensitiveSuffix(originalPath, ".d.ts") {
 				if r.debugLogs != nil {
 					r.debugLogs.addNote(fmt.Sprintf("Ignoring substitution %q because it ends in \".d.ts\"", originalPath))
 				}
@@ -2021,6 +2173,200 @@ func (r resolverQuery) matchTSConfigPaths(tsConfigJSON *TSConfigJSON, path strin
 	return PathPair{}, false, nil
 }
 
+// This returns the path of "path" relative to "dir" if "path" is inside "dir"
+func (r resolverQuery) relPathInsideDir(dir string, path string) (string, bool) {
+	if rel, ok := r.fs.Rel(dir, path); ok && rel != ".." &&
+		!strings.HasPrefix(rel, "../") && !strings.HasPrefix(rel, "..\\") && !r.fs.IsAbs(rel) {
+		return rel, true
+	}
+	return "", false
+}
+
+// TypeScript's "rootDirs" setting merges several directories into a single
+// virtual directory. A relative import that doesn't exist in the directory
+// that contains it is checked for in each of the other directories instead:
+// https://www.typescriptlang.org/docs/handbook/modules/reference.html#rootdirs
+func (r resolverQuery) loadAsFileOrDirectoryUsingRootDirs(absPath string) (PathPair, bool, *fs.DifferentCase) {
+	tsConfigJSON := r.importerTSConfigJSON
+	if tsConfigJSON == nil || len(tsConfigJSON.RootDirs) == 0 {
+		return PathPair{}, false, nil
+	}
+
+	// Find the longest root directory that contains this path
+	matchedDir := ""
+	matchedRel := ""
+	for _, dir := range tsConfigJSON.RootDirs {
+		if rel, ok := r.relPathInsideDir(dir, absPath); ok && len(dir) > len(matchedDir) {
+			matchedDir = dir
+			matchedRel = rel
+		}
+	}
+	if matchedDir == "" {
+		return PathPair{}, false, nil
+	}
+
+	if r.debugLogs != nil {
+		r.debugLogs.addNote(fmt.Sprintf("Checking for %q in the other directories in \"rootDirs\" from %q", matchedRel, tsConfigJSON.AbsPath))
+		r.debugLogs.increaseIndent()
+		defer r.debugLogs.decreaseIndent()
+	}
+
+	for _, dir := range tsConfigJSON.RootDirs {
+		if dir == matchedDir {
+			continue
+		}
+		if absolute, ok, diffCase := r.loadAsFileOrDirectory(r.fs.Join(dir, matchedRel)); ok {
+			return absolute, true, diffCase
+		}
+	}
+
+	return PathPair{}, false, nil
+}
+
+// TypeScript's "moduleSuffixes" setting is mainly used by React Native to
+// check for platform-specific files such as "./foo.ios.ts" before "./foo.ts"
+func (r resolverQuery) moduleSuffixes() []string {
+	if tsConfigJSON := r.importerTSConfigJSON; tsConfigJSON != nil && !r.kind.IsFromCSS() {
+		// The default value of [""] is the same as not having any suffixes
+		if suffixes := tsConfigJSON.ModuleSuffixes; len(suffixes) > 1 || (len(suffixes) == 1 && suffixes[0] != "") {
+			return suffixes
+		}
+	}
+	return nil
+}
+
+// The suffix goes before the file extension if there is a known one (e.g.
+// "foo.js" => "foo.ios.js") and at the end otherwise (e.g. "foo" => "foo.ios")
+func (r resolverQuery) insertModuleSuffix(base string, suffix string, extensionOrder []string) string {
+	if suffix == "" {
+		return base
+	}
+	if lastDot := strings.LastIndexByte(base, '.'); lastDot > 0 {
+		ext := base[lastDot:]
+		if _, ok := r.rewrittenFileExtensions()[ext]; ok {
+			return base[:lastDot] + suffix + ext
+		}
+		for _, known := range extensionOrder {
+			if ext == known {
+				return base[:lastDot] + suffix + ext
+			}
+		}
+	}
+	return base + suffix
+}
+
+// This returns the real path for a path that may not exist yet by resolving
+// symlinks in the closest parent directory that does exist. This is needed
+// to match paths inside symlinked packages against a referenced project.
+func (r resolverQuery) realPathOfPossiblyMissingPath(path string) string {
+	dir := path
+	rest := ""
+	for {
+		if dirInfo := r.dirInfoCached(dir); dirInfo != nil {
+			if dirInfo.absRealPath != "" {
+				return r.fs.Join(dirInfo.absRealPath, rest)
+			}
+			return path
+		}
+		parent := r.fs.Dir(dir)
+		if parent == dir {
+			return path
+		}
+		rest = r.fs.Join(r.fs.Base(dir), rest)
+		dir = parent
+	}
+}
+
+func (r resolverQuery) referencedTSConfig(absPath string) *TSConfigJSON {
+	if tsConfigJSON, ok := r.tsConfigReferenceCache[absPath]; ok {
+		return tsConfigJSON
+	}
+
+	// A reference can either be a directory or a path to a config file
+	file := absPath
+	if dirInfo := r.dirInfoCached(absPath); dirInfo != nil {
+		file = r.fs.Join(absPath, "tsconfig.json")
+
+		// Reuse the config file from the directory info cache if possible
+		if dirInfo.enclosingTSConfigJSON != nil && dirInfo.enclosingTSConfigJSON.AbsPath == file {
+			r.tsConfigReferenceCache[absPath] = dirInfo.enclosingTSConfigJSON
+			return dirInfo.enclosingTSConfigJSON
+		}
+	}
+
+	tsConfigJSON, err := r.parseTSConfig(file, make(map[string]bool))
+	if err != nil {
+		tsConfigJSON = nil
+		if err != errParseErrorAlreadyLogged {
+			r.log.AddID(logger.MsgID_TSConfigJSON_Missing, logger.Debug, nil, logger.Range{},
+				fmt.Sprintf("Cannot read referenced project %q: %s",
+					PrettyPath(r.fs, logger.Path{Text: file, Namespace: "file"}), err.Error()))
+		}
+	}
+	r.tsConfigReferenceCache[absPath] = tsConfigJSON
+	return tsConfigJSON
+}
+
+// These are removed from an output file path to get the source file path
+var outputFileExtensions = []string{".d.ts", ".d.mts", ".d.cts", ".js", ".jsx", ".mjs", ".cjs"}
+
+// TypeScript's project references let one project depend on another project
+// that is compiled separately. The importing project sees the other project's
+// compiled output (e.g. through the "main" field of its "package.json" file)
+// but we want to bundle the other project's source code instead, both so that
+// it doesn't need to be compiled first and so that our own settings apply:
+// https://www.typescriptlang.org/docs/handbook/project-references.html
+func (r resolverQuery) loadFromReferencedProjectSource(path string, extensionOrder []string) (string, bool, *fs.DifferentCase) {
+	tsConfigJSON := r.importerTSConfigJSON
+	if tsConfigJSON == nil || len(tsConfigJSON.References) == 0 {
+		return "", false, nil
+	}
+	realPath := r.realPathOfPossiblyMissingPath(path)
+
+	for _, ref := range tsConfigJSON.References {
+		project := r.referencedTSConfig(ref)
+		if project == nil {
+			continue
+		}
+		sourceDir := project.RootDir
+		if sourceDir == "" {
+			sourceDir = r.fs.Dir(project.AbsPath)
+		}
+
+		for _, outDir := range [2]string{project.OutDir, project.DeclarationDir} {
+			if outDir == "" || outDir == sourceDir {
+				continue
+			}
+			rel, ok := r.relPathInsideDir(outDir, realPath)
+			if !ok {
+				continue
+			}
+			for _, ext := range outputFileExtensions {
+				if strings.HasSuffix(rel, ext) {
+					rel = rel[:len(rel)-len(ext)]
+					break
+				}
+			}
+			sourcePath := r.fs.Join(sourceDir, rel)
+
+			if r.debugLogs != nil {
+				r.debugLogs.addNote(fmt.Sprintf("Mapping %q in the output directory of the referenced project %q to %q",
+					realPath, project.AbsPath, sourcePath))
+			}
+			if absolute, ok, diffCase := r.loadAsFile(sourcePath, extensionOrder); ok {
+				return absolute, true, diffCase
+			}
+			if dirInfo := r.dirInfoCached(sourcePath); dirInfo != nil {
+				if absolute, ok, diffCase := r.loadAsIndex(dirInfo, extensionOrder); ok {
+					return absolute.Primary.Text, true, diffCase
+				}
+			}
+		}
+	}
+
+	return "", false, nil
+}
+
 func (r resolverQuery) loadPackageImports(importPath string, dirInfoPackageJSON *dirInfo) (PathPair, bool, *fs.DifferentCase) {
 	packageJSON := dirInfoPackageJSON.packageJSON
 
@@ -2049,6 +2395,8 @@ func (r resolverQuery) loadPackageImports(importPath string, dirInfoPackageJSON
 		conditions = r.esmConditionsImport
 	case ast.ImportRequire, ast.ImportRequireResolve:
 		conditions = r.esmConditionsRequire
+	case ast.ImportTypes:
+		conditions = r.esmConditionsTypes
 	}
 
 	resolvedPath, status, debug := r.esmPackageImportsResolve(importPath, packageJSON.importsMap.root, conditions)
@@ -2090,6 +2438,8 @@ func (r resolverQuery) esmResolveAlgorithm(esmPackageName string, esmPackageSubp
 		conditions = r.esmConditionsImport
 	case ast.ImportRequire, ast.ImportRequireResolve:
 		conditions = r.esmConditionsRequire
+	case ast.ImportTypes:
+		conditions = r.esmConditionsTypes
 	case ast.ImportEntryPoint:
 		// Treat entry points as imports instead of requires for consistency with
 		// Webpack and Rollup. More information:
@@ -2117,14 +2467,7 @@ func (r resolverQuery) esmResolveAlgorithm(esmPackageName string, esmPackageSubp
 	)
 }
 
-func (r resolverQuery) loadNodeModules(importPath string, dirInfo *dirInfo, forbidImports bool) (PathPair, bool, *fs.DifferentCase) {
-	if r.debugLogs != nil {
-		r.debugLogs.addNote(fmt.Sprintf("Searching for %q in \"node_modules\" directories starting from %q", importPath, dirInfo.absPath))
-		r.debugLogs.increaseIndent()
-		defer r.debugLogs.decreaseIndent()
-	}
-
-	// First, check path overrides from the nearest enclosing TypeScript "tsconfig.json" file
+func (r resolverQuery) loadTSConfigOverride(importPath string, dirInfo *dirInfo) (PathPair, bool, *fs.DifferentCase) {
 	if tsConfigJSON := r.tsConfigForDir(dirInfo); tsConfigJSON != nil {
 		// Try path substitutions first
 		if tsConfigJSON.Paths != nil {
@@ -2141,6 +2484,20 @@ func (r resolverQuery) loadNodeModules(importPath string, dirInfo *dirInfo, forb
 			}
 		}
 	}
+	return PathPair{}, false, nil
+}
+
+func (r resolverQuery) loadNodeModules(importPath string, dirInfo *dirInfo, forbidImports bool) (PathPair, bool, *fs.DifferentCase) {
+	if r.debugLogs != nil {
+		r.debugLogs.addNote(fmt.Sprintf("Searching for %q in \"node_modules\" directories starting from %q", importPath, dirInfo.absPath))
+		r.debugLogs.increaseIndent()
+		defer r.debugLogs.decreaseIndent()
+	}
+
+	// First, check path overrides from the nearest enclosing TypeScript "tsconfig.json" file
+	if absolute, ok, diffCase := r.loadTSConfigOverride(importPath, dirInfo); ok {
+		return absolute, true, diffCase
+	}
 
 	// Find the parent directory with the "package.json" file
 	dirInfoPackageJSON := dirInfo
@@ -2320,6 +2677,14 @@ func (r resolverQuery) finalizeImportsExportsResult(
 			extensionOrder := r.options.ExtensionOrder
 			if r.kind.MustResolveToCSS() {
 				extensionOrder = r.cssExtensionOrder
+			} else if r.kind == ast.ImportTypes {
+				extensionOrder = r.typesExtensionOrder
+			}
+
+			// TypeScript-specific behavior: if this is an output file of a project
+			// in "references", prefer the source file that it would be generated from
+			if absolute, ok, diffCase := r.loadFromReferencedProjectSource(absResolvedPath, extensionOrder); ok {
+				return PathPair{Primary: logger.Path{Text: absolute, Namespace: "file"}}, true, diffCase
 			}
 
 			if resolvedDirInfo == nil {
@@ -2329,7 +2694,7 @@ func (r resolverQuery) finalizeImportsExportsResult(
 
 				// TypeScript-specific behavior: try rewriting ".js" to ".ts"
 				if entry == nil {
-					for old, exts := range rewrittenFileExtensions {
+					for old, exts := range r.rewrittenFileExtensions() {
 						if !strings.HasSuffix(base, old) {
 							continue
 						}
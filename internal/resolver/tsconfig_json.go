@@ -34,6 +34,28 @@ type TSConfigJSON struct {
 	// "baseUrl" value in the "tsconfig.json" file.
 	Paths *TSConfigPaths
 
+	// The absolute paths of "compilerOptions.rootDirs". Relative imports inside
+	// one of these directories are also checked in all of the other ones, as if
+	// their contents were merged into a single virtual directory.
+	RootDirs []string
+
+	// The verbatim values of "compilerOptions.moduleSuffixes". Each suffix is
+	// inserted before the file extension when probing for a file, in order. An
+	// empty string means to check for the file without a suffix.
+	ModuleSuffixes []string
+
+	// The absolute paths of "compilerOptions.rootDir", "compilerOptions.outDir",
+	// and "compilerOptions.declarationDir". These are only used to map the
+	// output files of a referenced project back to its source files.
+	RootDir        string
+	OutDir         string
+	DeclarationDir string
+
+	// The absolute paths from the "references" array. Each one is either a
+	// "tsconfig.json" file or a directory containing one. Unlike the other
+	// settings, these are not inherited via "extends" (to match TypeScript).
+	References []string
+
 	tsTargetKey    tsTargetKey
 	TSStrict       *config.TSAlwaysStrict
 	TSAlwaysStrict *config.TSAlwaysStrict
@@ -58,6 +80,21 @@ func (derived *TSConfigJSON) applyExtendedConfig(base TSConfigJSON) {
 		derived.Paths = base.Paths
 		derived.BaseURLForPaths = base.BaseURLForPaths
 	}
+	if base.RootDirs != nil {
+		derived.RootDirs = base.RootDirs
+	}
+	if base.ModuleSuffixes != nil {
+		derived.ModuleSuffixes = base.ModuleSuffixes
+	}
+	if base.RootDir != "" {
+		derived.RootDir = base.RootDir
+	}
+	if base.OutDir != "" {
+		derived.OutDir = base.OutDir
+	}
+	if base.DeclarationDir != "" {
+		derived.DeclarationDir = base.DeclarationDir
+	}
 	derived.JSXSettings.ApplyExtendedConfig(base.JSXSettings)
 	derived.Settings.ApplyExtendedConfig(base.Settings)
 }
@@ -133,6 +170,19 @@ func ParseTSConfigJSON(
 		}
 	}
 
+	// Parse "references"
+	if valueJSON, _, ok := getProperty(json, "references"); ok {
+		if array, ok := valueJSON.Data.(*js_ast.EArray); ok {
+			for _, item := range array.Items {
+				if pathJSON, _, ok := getProperty(item, "path"); ok {
+					if path, ok := getString(pathJSON); ok {
+						result.References = append(result.References, path)
+					}
+				}
+			}
+		}
+	}
+
 	// Parse "compilerOptions"
 	if compilerOptionsJSON, _, ok := getProperty(json, "compilerOptions"); ok {
 		// Parse "baseUrl"
@@ -142,6 +192,47 @@ func ParseTSConfigJSON(
 			}
 		}
 
+		// Parse "rootDirs"
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "rootDirs"); ok {
+			if array, ok := valueJSON.Data.(*js_ast.EArray); ok {
+				result.RootDirs = []string{}
+				for _, item := range array.Items {
+					if str, ok := getString(item); ok {
+						result.RootDirs = append(result.RootDirs, str)
+					}
+				}
+			}
+		}
+
+		// Parse "moduleSuffixes"
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "moduleSuffixes"); ok {
+			if array, ok := valueJSON.Data.(*js_ast.EArray); ok {
+				result.ModuleSuffixes = []string{}
+				for _, item := range array.Items {
+					if str, ok := getString(item); ok {
+						result.ModuleSuffixes = append(result.ModuleSuffixes, str)
+					}
+				}
+			}
+		}
+
+		// Parse "rootDir", "outDir", and "declarationDir"
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "rootDir"); ok {
+			if value, ok := getString(valueJSON); ok {
+				result.RootDir = value
+			}
+		}
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "outDir"); ok {
+			if value, ok := getString(valueJSON); ok {
+				result.OutDir = value
+			}
+		}
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "declarationDir"); ok {
+			if value, ok := getString(valueJSON); ok {
+				result.DeclarationDir = value
+			}
+		}
+
 		// Parse "jsx"
 		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "jsx"); ok {
 			if value, ok := getString(valueJSON); ok {
@@ -192,6 +283,17 @@ func ParseTSConfigJSON(
 			}
 		}
 
+		// Parse "emitDecoratorMetadata"
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "emitDecoratorMetadata"); ok {
+			if value, ok := getBool(valueJSON); ok {
+				if value {
+					result.Settings.EmitDecoratorMetadata = config.True
+				} else {
+					result.Settings.EmitDecoratorMetadata = config.False
+				}
+			}
+		}
+
 		// Parse "useDefineForClassFields"
 		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "useDefineForClassFields"); ok {
 			if value, ok := getBool(valueJSON); ok {
@@ -298,6 +400,17 @@ func ParseTSConfigJSON(
 			}
 		}
 
+		// Parse "erasableSyntaxOnly"
+		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "erasableSyntaxOnly"); ok {
+			if value, ok := getBool(valueJSON); ok {
+				if value {
+					result.Settings.ErasableSyntaxOnly = config.True
+				} else {
+					result.Settings.ErasableSyntaxOnly = config.False
+				}
+			}
+		}
+
 		// Parse "paths"
 		if valueJSON, _, ok := getProperty(compilerOptionsJSON, "paths"); ok {
 			if paths, ok := valueJSON.Data.(*js_ast.EObject); ok {
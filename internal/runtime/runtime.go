@@ -57,9 +57,6 @@ func Source(unsupportedJSFeatures compat.JSFeature) logger.Source {
 	//   __spreadArray
 	//   __spreadArrays
 	//   __values
-	//
-	// Note: The "__objRest" function has a for-of loop which requires ES6, but
-	// transforming destructuring to ES5 isn't even supported so it's ok.
 	text := `
 		var __create = Object.create
 		var __freeze = Object.freeze
@@ -72,13 +69,37 @@ func Source(unsupportedJSFeatures compat.JSFeature) logger.Source {
 		var __getProtoOf = Object.getPrototypeOf
 		var __hasOwnProp = Object.prototype.hasOwnProperty
 		var __propIsEnum = Object.prototype.propertyIsEnumerable
-		var __reflectGet = Reflect.get
-		var __reflectSet = Reflect.set
+	`
 
-		var __knownSymbol = (name, symbol) => {
-			if (symbol = Symbol[name]) return symbol
-			throw Error('Symbol.' + name + ' is not defined')
-		}
+	// Environments without classes may not have "Reflect" either
+	if !unsupportedJSFeatures.Has(compat.Class) {
+		text += `
+			var __reflectGet = Reflect.get
+			var __reflectSet = Reflect.set
+		`
+	} else {
+		text += `
+			var __reflectGet = typeof Reflect == 'object' && Reflect.get || ((target, key, receiver) => {
+				for (var desc; target; target = __getProtoOf(target))
+					if (desc = __getOwnPropDesc(target, key))
+						return desc.get ? desc.get.call(receiver) : desc.value
+			})
+			var __reflectSet = typeof Reflect == 'object' && Reflect.set || ((target, key, value, receiver) => {
+				for (var desc; target; target = __getProtoOf(target))
+					if (desc = __getOwnPropDesc(target, key)) {
+						if (desc.set) return desc.set.call(receiver, value), true
+						if (desc.get || !desc.writable) return false
+						break
+					}
+				receiver[key] = value
+				return true
+			})
+		`
+	}
+
+	text += `
+		var __knownSymbol = (name, symbol) => (symbol = Symbol[name]) ? symbol : Symbol.for('Symbol.' + name)
+		var __typeError = msg => { throw TypeError(msg) }
 
 		export var __pow = Math.pow
 
@@ -256,6 +277,76 @@ func Source(unsupportedJSFeatures compat.JSFeature) logger.Source {
 		}
 		export var __decorateParam = (index, decorator) => (target, key) => decorator(target, key, index)
 
+		// For TypeScript's "emitDecoratorMetadata" setting. This returns undefined
+		// (which "__decorateClass" skips) if "Reflect.metadata" doesn't exist.
+		export var __metadata = (key, value) => typeof Reflect === 'object' && typeof Reflect.metadata === 'function' ? Reflect.metadata(key, value) : void 0
+
+		// For JavaScript decorators. The "array" passed to these helpers is created
+		// by "__decoratorStart" and holds all state for the decorated class:
+		// - array[0]: class extra initializers (from "addInitializer")
+		// - array[1]: static method extra initializers
+		// - array[2]: instance method extra initializers
+		// - array[3]: the metadata object
+		// - array[4+]: an initializer list and an extra initializer list for each
+		//   decorated field and auto-accessor, in decorator application order
+		//
+		// The "flags" passed to "__decorateElement" are the element kind (0 for
+		// class, 1 for method, 2 for getter, 3 for setter, 4 for accessor, and 5
+		// for field) plus 8 for static elements and 16 for private elements. The
+		// "flags" passed to "__runInitializers" are the array index shifted left
+		// by one, plus 1 for extra initializers (which don't receive a value).
+		var __decoratorStrings = ['class', 'method', 'getter', 'setter', 'accessor', 'field', 'value', 'get', 'set']
+		var __expectFn = fn => fn !== void 0 && typeof fn !== 'function' ? __typeError('Function expected') : fn
+		var __decoratorContext = (kind, name, done, metadata, fns) => ({
+			kind: __decoratorStrings[kind],
+			name,
+			metadata,
+			addInitializer: fn => done._ ? __typeError('Already initialized') : fns.push(__expectFn(fn || null)),
+		})
+		export var __decoratorStart = base => [, , , __create(base?.[__knownSymbol('metadata')] ?? null)]
+		export var __decoratorMetadata = (array, target) => __defNormalProp(target, __knownSymbol('metadata'), array[3])
+		export var __runInitializers = (array, flags, self, value) => {
+			for (var i = 0, fns = array[flags >> 1], n = fns && fns.length; i < n; i++)
+				flags & 1 ? fns[i].call(self) : value = fns[i].call(self, value)
+			return value
+		}
+		export var __decorateElement = (array, flags, name, decorators, target, extra) => {
+			var fn, it, done, ctx, access, k = flags & 7, s = !!(flags & 8), p = !!(flags & 16)
+			var j = k > 3 ? array.length + 1 : k ? s ? 1 : 2 : 0, key = __decoratorStrings[k + 5]
+			var initializers = k > 3 && (array[j - 1] = []), extraInitializers = array[j] || (array[j] = [])
+
+			// Public members are read from (and written back to) the class or its
+			// prototype. Private auto-accessors are backed by the storage in "extra".
+			// Other private members pass their current function value in "extra".
+			var desc = k && (
+				!p && !s && (target = target.prototype),
+				k < 5 && (k > 3 || !p) && (p
+					? { get: function () { return __privateGet(this, extra) }, set: function (x) { __privateSet(this, extra, x) } }
+					: __getOwnPropDesc(target, name))
+			)
+			p && k < 4 && __name(extra, (k > 2 ? 'set ' : k > 1 ? 'get ' : '') + name)
+
+			for (var i = decorators.length - 1; i >= 0; i--) {
+				ctx = __decoratorContext(k, name, done = {}, array[3], extraInitializers)
+
+				if (k) {
+					ctx.static = s, ctx.private = p, access = ctx.access = { has: p ? x => __privateIn(target, x) : x => name in x }
+					if (k ^ 3) access.get = p ? x => (k ^ 1 ? __privateGet : __privateMethod)(x, target, k ^ 4 ? extra : desc.get) : x => x[name]
+					if (k > 2) access.set = p ? (x, y) => __privateSet(x, target, y, k ^ 4 ? extra : desc.set) : (x, y) => x[name] = y
+				}
+
+				it = (0, decorators[i])(k ? k < 4 ? p ? extra : desc[key] : k > 4 ? void 0 : { get: desc.get, set: desc.set } : target, ctx), done._ = 1
+
+				if (k ^ 4 || it === void 0) __expectFn(it) && (k > 4 ? initializers.unshift(it) : k ? p ? extra = it : desc[key] = it : target = it)
+				else if (typeof it !== 'object' || it === null) __typeError('Object expected')
+				else __expectFn(fn = it.get) && (desc.get = fn), __expectFn(fn = it.set) && (desc.set = fn), __expectFn(fn = it.init) && initializers.unshift(fn)
+			}
+
+			return k || __decoratorMetadata(array, target),
+				desc && !p && __defProp(target, name, desc),
+				p ? k ^ 4 ? extra : desc : target
+		}
+
 		// For class members
 		export var __publicField = (obj, key, value) => {
 			__defNormalProp(obj, typeof key !== 'symbol' ? key + '' : key, value)
@@ -284,6 +375,9 @@ func Source(unsupportedJSFeatures compat.JSFeature) logger.Source {
 		export var __earlyAccess = (name) => {
 			throw ReferenceError('Cannot access "' + name + '" before initialization')
 		}
+		export var __constAssign = (name) => {
+			throw TypeError('Assignment to constant variable "' + name + '"')
+		}
 	`
 
 	if !unsupportedJSFeatures.Has(compat.ObjectAccessors) {
@@ -330,9 +424,227 @@ func Source(unsupportedJSFeatures compat.JSFeature) logger.Source {
 	}
 
 	text += `
+		// For lowering classes to constructor functions
+		var __setProtoOf = Object.setPrototypeOf || ((obj, proto) => {
+			if ({ __proto__: [] } instanceof Array) obj.__proto__ = proto
+			else for (var key in proto)
+				if (__hasOwnProp.call(proto, key)) obj[key] = proto[key]
+			return obj
+		})
+		export var __inherits = (cls, base) => {
+			if (typeof base != 'function' && base !== null)
+				__typeError('Class extends value ' + String(base) + ' is not a constructor or null')
+			cls.prototype = __create(base && base.prototype, { constructor: { value: cls, writable: true, configurable: true } })
+			if (base) __setProtoOf(cls, base)
+		}
+		export var __classCallCheck = (self, cls) => self instanceof cls || __typeError('Cannot call a class as a function')
+		export var __callSuper = (self, cls, args) => {
+			// Use "Reflect.construct" when possible so built-in classes such as
+			// "Error" and "Array" can be subclassed. The base class constructor
+			// creates the object, so it may be different from "self".
+			var base = __getProtoOf(cls), result
+			if (typeof Reflect == 'object' && Reflect.construct)
+				return Reflect.construct(base, args, __getProtoOf(self).constructor)
+			result = base.apply(self, args)
+			return result !== null && (typeof result == 'object' || typeof result == 'function') ? result : self
+		}
+		export var __defMethod = (obj, key, fn, kind) => __defProp(obj, key, kind
+			? kind == 1 ? { get: fn, configurable: true } : { set: fn, configurable: true }
+			: { value: fn, writable: true, configurable: true })
+
 		// For lowering tagged template literals
 		export var __template = (cooked, raw) => __freeze(__defProp(cooked, 'raw', { value: __freeze(raw || cooked.slice()) }))
 
+		// For inlining React elements as object literals
+		export var __reactElement = /* @__PURE__ */ Symbol.for('react.element')
+
+		// These help for lowering generator functions
+		export var __iterator = (obj, i) => {
+			var it = typeof Symbol == 'function' && obj[__knownSymbol('iterator')]
+			if (it) return it.call(obj)
+			if (typeof obj.length != 'number') __typeError('Object is not iterable')
+			i = 0
+			return { next: () => ({ value: obj[i], done: i++ >= obj.length }) }
+		}
+		export var __makeGenerator = (self, body) => {
+			// The state machine returns an array where the first element is the
+			// operation: 0 = next, 1 = throw, 2 = return, 3 = jump, 4 = yield,
+			// 5 = yield*, 6 = error, 7 = end of finally
+			var isRunning, isStarted, delegate, received
+			var state = {
+				label: 0,
+				trys: [],
+				ops: [],
+				sent: () => {
+					if (received[0] & 1) throw received[1]
+					return received[1]
+				},
+			}
+			var step = op => {
+				if (isRunning) __typeError('Generator is already running')
+				if (!isStarted) {
+					isStarted = 1
+					if (op[0]) state = 0
+				}
+				while (state) try {
+					isRunning = 1
+					if (delegate) {
+						var fn = delegate[op[0] & 2 ? 'return' : op[0] ? 'throw' : 'next']
+						if (!fn && op[0] == 1) {
+							if (fn = delegate.return) fn.call(delegate)
+							fn = 0
+						}
+						if (fn) {
+							var result = fn.call(delegate, op[1])
+							if (!result.done) return result
+							op = [op[0] & 2, result.value]
+						}
+						delegate = 0
+					}
+					switch (op[0]) {
+						case 0:
+						case 1:
+							received = op
+							break
+						case 4:
+							state.label++
+							return { value: op[1], done: false }
+						case 5:
+							state.label++
+							delegate = __iterator(op[1])
+							op = [0]
+							continue
+						case 7:
+							op = state.ops.pop()
+							state.trys.pop()
+							continue
+						default:
+							// Each entry is [try, catch, finally, end] with holes for missing labels
+							var trys = state.trys, region = trys.length > 0 && trys[trys.length - 1]
+							if (!region && (op[0] == 6 || op[0] == 2)) {
+								state = 0
+								continue
+							}
+							if (op[0] == 3 && (!region || op[1] > region[0] && op[1] < region[3])) {
+								state.label = op[1]
+								break
+							}
+							if (op[0] == 6 && state.label < region[1]) {
+								state.label = region[1]
+								received = op
+								break
+							}
+							if (region && state.label < region[2]) {
+								state.label = region[2]
+								state.ops.push(op)
+								break
+							}
+							if (region[2]) state.ops.pop()
+							trys.pop()
+							continue
+					}
+					op = body.call(self, state)
+				} catch (e) {
+					op = [6, e]
+					delegate = 0
+				} finally {
+					isRunning = 0
+				}
+				if (op[0] & 5) throw op[1]
+				return { value: op[0] ? op[1] : void 0, done: true }
+			}
+			var it = {
+				next: value => step([0, value]),
+				throw: value => step([1, value]),
+				return: value => step([2, value]),
+			}
+			if (typeof Symbol == 'function') it[__knownSymbol('iterator')] = () => it
+			return it
+		}
+
+		// These help for lowering destructuring, spread, and for-of loops
+		export var __toArray = (value, count) => {
+			for (var it = __iterator(value), result = [], step; count === void 0 || result.length < count; result.push(step.value))
+				if ((step = it.next()).done) return result
+			if (it.return) it.return()
+			return result
+		}
+		export var __slice = (value, start) => [].slice.call(value, start)
+		export var __construct = (cls, args) => new (Function.prototype.bind.apply(cls, [null].concat(args)))()
+		export var __requireObject = value => value == null ? __typeError('Cannot destructure ' + value) : value
+		export var __defAccessor = (obj, key, fn, isSetter) => __defProp(obj, key, isSetter
+			? { set: fn, enumerable: true, configurable: true }
+			: { get: fn, enumerable: true, configurable: true })
+
+		// This helps for lowering named capture groups in regular expressions. The
+		// group names are kept by a subclass of "RegExp" so that copies made using
+		// "Symbol.species" (e.g. by "matchAll" and "split") also have them.
+		export var __wrapRegExp = (re, groups) => {
+			var proto = RegExp.prototype, replace
+			var RegExpWithGroups = function (pattern, flags) {
+				return __setProtoOf(new RegExp(pattern, flags), RegExpWithGroups.prototype)
+			}
+			var toGroups = match => {
+				var result = Object.create(null)
+				for (var name in groups) result[name] = match[groups[name]]
+				return result
+			}
+			__inherits(RegExpWithGroups, RegExp)
+			RegExpWithGroups.prototype.exec = function (str) {
+				var match = proto.exec.call(this, str)
+				if (match) match.groups = toGroups(match)
+				return match
+			}
+			if (typeof Symbol == 'function' && (replace = proto[Symbol.replace])) {
+				RegExpWithGroups.prototype[Symbol.replace] = function (str, replacer) {
+					if (typeof replacer == 'string')
+						replacer = replacer.replace(/\$<([^>]*)>/g, (_, name) => __hasOwnProp.call(groups, name) ? '$' + groups[name] : '')
+					else if (typeof replacer == 'function') {
+						var fn = replacer
+						replacer = function () {
+							var args = [].slice.call(arguments)
+							if (typeof args[args.length - 1] != 'object') args.push(toGroups(args))
+							return fn.apply(this, args)
+						}
+					}
+					return replace.call(this, str, replacer)
+				}
+			}
+			return RegExpWithGroups(re)
+		}
+
+		// These are for "import.meta" in CommonJS code for node and in IIFE code
+		// for the browser. Modules in a bundle may be evaluated lazily, after
+		// "document.currentScript" has been reset to null. So the script is saved
+		// when the bundle starts running since the runtime code comes first.
+		export var __importMetaNode = (filename, dirname) => {
+			var url = __require('url')
+			var href = url.pathToFileURL(filename).href
+			return {
+				url: href,
+				dirname: dirname,
+				filename: filename,
+				resolve: id => {
+					// Relative, absolute, and URL specifiers are resolved like in the
+					// browser without checking whether the file exists
+					if (/^(\.{0,2}\/|\.{1,2}$|[a-z][a-z\d+.-]*:)/i.test(id)) return new URL(id, href).href
+
+					// Bare specifiers are resolved using "require" conditions instead of
+					// "import" conditions, since this has to be synchronous
+					var path = __require.resolve(id)
+					return __require('path').isAbsolute(path) ? url.pathToFileURL(path).href : /^node:/.test(path) ? path : 'node:' + path
+				},
+			}
+		}
+		var __currentScript = /* @__PURE__ */ (() => typeof document != 'undefined' && document.currentScript)()
+		export var __importMetaBrowser = () => {
+			var url = __currentScript && __currentScript.src || (typeof location != 'undefined' ? location.href : '')
+			return {
+				url: url,
+				resolve: id => new URL(id, url).href,
+			}
+		}
+
 		// This helps for lowering async functions
 		export var __async = (__this, __arguments, generator) => {
 			return new Promise((resolve, reject) => {
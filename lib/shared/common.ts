@@ -133,6 +133,7 @@ function validateStringValue(value: unknown, what: string, key?: string): string
 
 function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKeys): void {
   let legalComments = getFlag(options, keys, 'legalComments', mustBeString)
+  let comments = getFlag(options, keys, 'comments', mustBeString)
   let sourceRoot = getFlag(options, keys, 'sourceRoot', mustBeString)
   let sourcesContent = getFlag(options, keys, 'sourcesContent', mustBeBoolean)
   let target = getFlag(options, keys, 'target', mustBeStringOrArray)
@@ -141,11 +142,13 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
   let mangleProps = getFlag(options, keys, 'mangleProps', mustBeRegExp)
   let reserveProps = getFlag(options, keys, 'reserveProps', mustBeRegExp)
   let mangleQuoted = getFlag(options, keys, 'mangleQuoted', mustBeBoolean)
+  let manglePrivate = getFlag(options, keys, 'manglePrivate', mustBeBoolean)
   let minify = getFlag(options, keys, 'minify', mustBeBoolean)
   let minifySyntax = getFlag(options, keys, 'minifySyntax', mustBeBoolean)
   let minifyWhitespace = getFlag(options, keys, 'minifyWhitespace', mustBeBoolean)
   let minifyIdentifiers = getFlag(options, keys, 'minifyIdentifiers', mustBeBoolean)
   let lineLimit = getFlag(options, keys, 'lineLimit', mustBeInteger)
+  let formatting = getFlag(options, keys, 'formatting', mustBeObject)
   let drop = getFlag(options, keys, 'drop', mustBeArray)
   let dropLabels = getFlag(options, keys, 'dropLabels', mustBeArray)
   let charset = getFlag(options, keys, 'charset', mustBeString)
@@ -157,15 +160,21 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
   let jsxImportSource = getFlag(options, keys, 'jsxImportSource', mustBeString)
   let jsxDev = getFlag(options, keys, 'jsxDev', mustBeBoolean)
   let jsxSideEffects = getFlag(options, keys, 'jsxSideEffects', mustBeBoolean)
+  let jsxConstantElements = getFlag(options, keys, 'jsxConstantElements', mustBeBoolean)
+  let jsxInlineElements = getFlag(options, keys, 'jsxInlineElements', mustBeBoolean)
   let define = getFlag(options, keys, 'define', mustBeObject)
   let logOverride = getFlag(options, keys, 'logOverride', mustBeObject)
   let supported = getFlag(options, keys, 'supported', mustBeObject)
+  let polyfill = getFlag(options, keys, 'polyfill', mustBeString)
   let pure = getFlag(options, keys, 'pure', mustBeArray)
   let keepNames = getFlag(options, keys, 'keepNames', mustBeBoolean)
+  let looseIteration = getFlag(options, keys, 'looseIteration', mustBeBoolean)
+  let erasableSyntaxOnly = getFlag(options, keys, 'erasableSyntaxOnly', mustBeBoolean)
   let platform = getFlag(options, keys, 'platform', mustBeString)
   let tsconfigRaw = getFlag(options, keys, 'tsconfigRaw', mustBeStringOrObject)
 
   if (legalComments) flags.push(`--legal-comments=${legalComments}`)
+  if (comments) flags.push(`--comments=${comments}`)
   if (sourceRoot !== void 0) flags.push(`--source-root=${sourceRoot}`)
   if (sourcesContent !== void 0) flags.push(`--sources-content=${sourcesContent}`)
   if (target) {
@@ -182,6 +191,23 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
   if (minifyWhitespace) flags.push('--minify-whitespace')
   if (minifyIdentifiers) flags.push('--minify-identifiers')
   if (lineLimit) flags.push(`--line-limit=${lineLimit}`)
+  if (formatting) {
+    let formattingKeys: OptionKeys = Object.create(null)
+    let indentWidth = getFlag(formatting, formattingKeys, 'indentWidth', mustBeInteger)
+    let useTabs = getFlag(formatting, formattingKeys, 'useTabs', mustBeBoolean)
+    let quotes = getFlag(formatting, formattingKeys, 'quotes', mustBeString)
+    let trailingCommas = getFlag(formatting, formattingKeys, 'trailingCommas', mustBeString)
+    let semicolons = getFlag(formatting, formattingKeys, 'semicolons', mustBeString)
+    let printWidth = getFlag(formatting, formattingKeys, 'printWidth', mustBeInteger)
+    checkForInvalidFlags(formatting, formattingKeys, 'in "formatting" object')
+
+    if (indentWidth !== void 0) flags.push(`--indent-width=${indentWidth}`)
+    if (useTabs) flags.push(`--use-tabs`)
+    if (quotes) flags.push(`--quotes=${quotes}`)
+    if (trailingCommas) flags.push(`--trailing-commas=${trailingCommas}`)
+    if (semicolons) flags.push(`--semicolons=${semicolons}`)
+    if (printWidth) flags.push(`--print-width=${printWidth}`)
+  }
   if (charset) flags.push(`--charset=${charset}`)
   if (treeShaking !== void 0) flags.push(`--tree-shaking=${treeShaking}`)
   if (ignoreAnnotations) flags.push(`--ignore-annotations`)
@@ -190,6 +216,7 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
   if (mangleProps) flags.push(`--mangle-props=${mangleProps.source}`)
   if (reserveProps) flags.push(`--reserve-props=${reserveProps.source}`)
   if (mangleQuoted !== void 0) flags.push(`--mangle-quoted=${mangleQuoted}`)
+  if (manglePrivate) flags.push(`--mangle-private`)
 
   if (jsx) flags.push(`--jsx=${jsx}`)
   if (jsxFactory) flags.push(`--jsx-factory=${jsxFactory}`)
@@ -197,6 +224,8 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
   if (jsxImportSource) flags.push(`--jsx-import-source=${jsxImportSource}`)
   if (jsxDev) flags.push(`--jsx-dev`)
   if (jsxSideEffects) flags.push(`--jsx-side-effects`)
+  if (jsxConstantElements) flags.push(`--jsx-constant-elements`)
+  if (jsxInlineElements) flags.push(`--jsx-inline-elements`)
 
   if (define) {
     for (let key in define) {
@@ -218,8 +247,11 @@ function pushCommonFlags(flags: string[], options: CommonOptions, keys: OptionKe
       flags.push(`--supported:${key}=${value}`)
     }
   }
+  if (polyfill) flags.push(`--polyfill=${polyfill}`)
   if (pure) for (let fn of pure) flags.push(`--pure:${validateStringValue(fn, 'pure')}`)
   if (keepNames) flags.push(`--keep-names`)
+  if (looseIteration) flags.push(`--loose-iteration`)
+  if (erasableSyntaxOnly) flags.push(`--erasable-syntax-only`)
 }
 
 function flagsForBuildOptions(
@@ -249,6 +281,11 @@ function flagsForBuildOptions(
   let sourcemap = getFlag(options, keys, 'sourcemap', mustBeStringOrBoolean)
   let bundle = getFlag(options, keys, 'bundle', mustBeBoolean)
   let splitting = getFlag(options, keys, 'splitting', mustBeBoolean)
+  let declarations = getFlag(options, keys, 'declarations', mustBeBoolean)
+  let declarationsInline = getFlag(options, keys, 'declarationsInline', mustBeArray)
+  let inlineFunctions = getFlag(options, keys, 'inlineFunctions', mustBeBoolean)
+  let inlineBudget = getFlag(options, keys, 'inlineBudget', mustBeInteger)
+  let collapseProperties = getFlag(options, keys, 'collapseProperties', mustBeBoolean)
   let preserveSymlinks = getFlag(options, keys, 'preserveSymlinks', mustBeBoolean)
   let metafile = getFlag(options, keys, 'metafile', mustBeBoolean)
   let outfile = getFlag(options, keys, 'outfile', mustBeString)
@@ -284,6 +321,11 @@ function flagsForBuildOptions(
   if (bundle) flags.push('--bundle')
   if (allowOverwrite) flags.push('--allow-overwrite')
   if (splitting) flags.push('--splitting')
+  if (declarations) flags.push('--declarations')
+  if (declarationsInline) for (let name of declarationsInline) flags.push(`--declarations-inline:${validateStringValue(name, 'declarationsInline')}`)
+  if (inlineFunctions) flags.push('--inline-functions')
+  if (inlineBudget !== void 0) flags.push(`--inline-budget=${inlineBudget}`)
+  if (collapseProperties) flags.push('--collapse-properties')
   if (preserveSymlinks) flags.push('--preserve-symlinks')
   if (metafile) flags.push(`--metafile`)
   if (outfile) flags.push(`--outfile=${outfile}`)
@@ -432,6 +474,7 @@ function flagsForTransformOptions(
   let loader = getFlag(options, keys, 'loader', mustBeString)
   let banner = getFlag(options, keys, 'banner', mustBeString)
   let footer = getFlag(options, keys, 'footer', mustBeString)
+  let stripTypesOnly = getFlag(options, keys, 'stripTypesOnly', mustBeBoolean)
   let mangleCache = getFlag(options, keys, 'mangleCache', mustBeObject)
   checkForInvalidFlags(options, keys, `in ${callName}() call`)
 
@@ -440,6 +483,7 @@ function flagsForTransformOptions(
   if (loader) flags.push(`--loader=${loader}`)
   if (banner) flags.push(`--banner=${banner}`)
   if (footer) flags.push(`--footer=${footer}`)
+  if (stripTypesOnly) flags.push('--strip-types-only')
 
   return {
     flags,
@@ -1245,6 +1289,7 @@ let handlePlugins = async (
         let resolveDir = getFlag(options, keys, 'resolveDir', mustBeString)
         let kind = getFlag(options, keys, 'kind', mustBeString)
         let pluginData = getFlag(options, keys, 'pluginData', canBeAnything)
+        let importAttributes = getFlag(options, keys, 'with', mustBeObject)
         checkForInvalidFlags(options, keys, 'in resolve() call')
 
         return new Promise((resolve, reject) => {
@@ -1261,6 +1306,7 @@ let handlePlugins = async (
           if (kind != null) request.kind = kind
           else throw new Error(`Must specify "kind" when calling "resolve"`)
           if (pluginData != null) request.pluginData = details.store(pluginData)
+          if (importAttributes != null) request.with = sanitizeStringMap(importAttributes, 'with')
 
           sendRequest<protocol.ResolveRequest, protocol.ResolveResponse>(refs, request, (error, response) => {
             if (error !== null) reject(new Error(error))
@@ -1378,6 +1424,7 @@ let handlePlugins = async (
           resolveDir: request.resolveDir,
           kind: request.kind,
           pluginData: details.load(request.pluginData),
+          with: request.with,
         })
 
         if (result != null) {
@@ -1428,6 +1475,7 @@ let handlePlugins = async (
           namespace: request.namespace,
           suffix: request.suffix,
           pluginData: details.load(request.pluginData),
+          with: request.with,
         })
 
         if (result != null) {
@@ -1750,6 +1798,16 @@ function sanitizeStringArray(values: any[], property: string): string[] {
   return result
 }
 
+function sanitizeStringMap(map: Record<string, any>, property: string): Record<string, string> {
+  const result: Record<string, string> = Object.create(null)
+  for (const key in map) {
+    const value = map[key]
+    if (typeof value !== 'string') throw new Error(`key ${quote(key)} in object ${quote(property)} must be a string`)
+    result[key] = value
+  }
+  return result
+}
+
 function convertOutputFiles({ path, contents, hash }: protocol.BuildOutputFile): types.OutputFile {
   // The text is lazily-generated for performance reasons. If no one asks for
   // it, then it never needs to be generated.
@@ -170,6 +170,7 @@ export interface ResolveRequest {
   resolveDir?: string
   kind?: string
   pluginData?: number
+  with?: Record<string, string>
 }
 
 export interface ResolveResponse {
@@ -182,6 +183,7 @@ export interface ResolveResponse {
   namespace: string
   suffix: string
   pluginData: number
+  with: Record<string, string>
 }
 
 export interface OnResolveRequest {
@@ -194,6 +196,7 @@ export interface OnResolveRequest {
   resolveDir: string
   kind: types.ImportKind
   pluginData: number
+  with: Record<string, string>
 }
 
 export interface OnResolveResponse {
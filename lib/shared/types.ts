@@ -1,6 +1,6 @@
 export type Platform = 'browser' | 'node' | 'neutral'
 export type Format = 'iife' | 'cjs' | 'esm'
-export type Loader = 'base64' | 'binary' | 'copy' | 'css' | 'dataurl' | 'default' | 'empty' | 'file' | 'js' | 'json' | 'jsx' | 'local-css' | 'text' | 'ts' | 'tsx'
+export type Loader = 'base64' | 'binary' | 'copy' | 'css' | 'dataurl' | 'default' | 'empty' | 'file' | 'flow' | 'flow-jsx' | 'js' | 'json' | 'jsx' | 'local-css' | 'text' | 'ts' | 'tsx'
 export type LogLevel = 'verbose' | 'debug' | 'info' | 'warning' | 'error' | 'silent'
 export type Charset = 'ascii' | 'utf8'
 export type Drop = 'console' | 'debugger'
@@ -10,6 +10,8 @@ interface CommonOptions {
   sourcemap?: boolean | 'linked' | 'inline' | 'external' | 'both'
   /** Documentation: https://esbuild.github.io/api/#legal-comments */
   legalComments?: 'none' | 'inline' | 'eof' | 'linked' | 'external'
+  /** Documentation: https://esbuild.github.io/api/#comments */
+  comments?: 'default' | 'preserve'
   /** Documentation: https://esbuild.github.io/api/#source-root */
   sourceRoot?: string
   /** Documentation: https://esbuild.github.io/api/#sources-content */
@@ -23,6 +25,8 @@ interface CommonOptions {
   target?: string | string[]
   /** Documentation: https://esbuild.github.io/api/#supported */
   supported?: Record<string, boolean>
+  /** Documentation: https://esbuild.github.io/api/#polyfill */
+  polyfill?: string
   /** Documentation: https://esbuild.github.io/api/#platform */
   platform?: Platform
 
@@ -32,6 +36,8 @@ interface CommonOptions {
   reserveProps?: RegExp
   /** Documentation: https://esbuild.github.io/api/#mangle-props */
   mangleQuoted?: boolean
+  /** Documentation: https://esbuild.github.io/api/#mangle-private */
+  manglePrivate?: boolean
   /** Documentation: https://esbuild.github.io/api/#mangle-props */
   mangleCache?: Record<string, string | false>
   /** Documentation: https://esbuild.github.io/api/#drop */
@@ -48,6 +54,8 @@ interface CommonOptions {
   minifySyntax?: boolean
   /** Documentation: https://esbuild.github.io/api/#line-limit */
   lineLimit?: number
+  /** Documentation: https://esbuild.github.io/api/#formatting */
+  formatting?: FormattingOptions
   /** Documentation: https://esbuild.github.io/api/#charset */
   charset?: Charset
   /** Documentation: https://esbuild.github.io/api/#tree-shaking */
@@ -56,7 +64,7 @@ interface CommonOptions {
   ignoreAnnotations?: boolean
 
   /** Documentation: https://esbuild.github.io/api/#jsx */
-  jsx?: 'transform' | 'preserve' | 'automatic'
+  jsx?: 'transform' | 'preserve' | 'automatic' | 'solid' | 'vue' | 'htm'
   /** Documentation: https://esbuild.github.io/api/#jsx-factory */
   jsxFactory?: string
   /** Documentation: https://esbuild.github.io/api/#jsx-fragment */
@@ -67,6 +75,10 @@ interface CommonOptions {
   jsxDev?: boolean
   /** Documentation: https://esbuild.github.io/api/#jsx-side-effects */
   jsxSideEffects?: boolean
+  /** Documentation: https://esbuild.github.io/api/#jsx-constant-elements */
+  jsxConstantElements?: boolean
+  /** Documentation: https://esbuild.github.io/api/#jsx-inline-elements */
+  jsxInlineElements?: boolean
 
   /** Documentation: https://esbuild.github.io/api/#define */
   define?: { [key: string]: string }
@@ -74,6 +86,10 @@ interface CommonOptions {
   pure?: string[]
   /** Documentation: https://esbuild.github.io/api/#keep-names */
   keepNames?: boolean
+  /** Documentation: https://esbuild.github.io/api/#loose-iteration */
+  looseIteration?: boolean
+  /** Documentation: https://esbuild.github.io/api/#erasable-syntax-only */
+  erasableSyntaxOnly?: boolean
 
   /** Documentation: https://esbuild.github.io/api/#color */
   color?: boolean
@@ -92,14 +108,18 @@ export interface TsconfigRaw {
   compilerOptions?: {
     alwaysStrict?: boolean
     baseUrl?: boolean
+    emitDecoratorMetadata?: boolean
+    erasableSyntaxOnly?: boolean
     experimentalDecorators?: boolean
     importsNotUsedAsValues?: 'remove' | 'preserve' | 'error'
     jsx?: 'preserve' | 'react-native' | 'react' | 'react-jsx' | 'react-jsxdev'
     jsxFactory?: string
     jsxFragmentFactory?: string
     jsxImportSource?: string
+    moduleSuffixes?: string[]
     paths?: Record<string, string[]>
     preserveValueImports?: boolean
+    rootDirs?: string[]
     strict?: boolean
     target?: string
     useDefineForClassFields?: boolean
@@ -112,6 +132,16 @@ export interface BuildOptions extends CommonOptions {
   bundle?: boolean
   /** Documentation: https://esbuild.github.io/api/#splitting */
   splitting?: boolean
+  /** Documentation: https://esbuild.github.io/api/#declarations */
+  declarations?: boolean
+  /** Documentation: https://esbuild.github.io/api/#declarations-inline */
+  declarationsInline?: string[]
+  /** Documentation: https://esbuild.github.io/api/#inline-functions */
+  inlineFunctions?: boolean
+  /** Documentation: https://esbuild.github.io/api/#inline-functions */
+  inlineBudget?: number
+  /** Documentation: https://esbuild.github.io/api/#collapse-properties */
+  collapseProperties?: boolean
   /** Documentation: https://esbuild.github.io/api/#preserve-symlinks */
   preserveSymlinks?: boolean
   /** Documentation: https://esbuild.github.io/api/#outfile */
@@ -170,6 +200,21 @@ export interface BuildOptions extends CommonOptions {
   nodePaths?: string[]; // The "NODE_PATH" variable from Node.js
 }
 
+export interface FormattingOptions {
+  /** Documentation: https://esbuild.github.io/api/#indent-width */
+  indentWidth?: number
+  /** Documentation: https://esbuild.github.io/api/#use-tabs */
+  useTabs?: boolean
+  /** Documentation: https://esbuild.github.io/api/#quotes */
+  quotes?: 'double' | 'single'
+  /** Documentation: https://esbuild.github.io/api/#trailing-commas */
+  trailingCommas?: 'none' | 'es5' | 'all'
+  /** Documentation: https://esbuild.github.io/api/#semicolons */
+  semicolons?: 'always' | 'asi'
+  /** Documentation: https://esbuild.github.io/api/#print-width */
+  printWidth?: number
+}
+
 export interface StdinOptions {
   contents: string | Uint8Array
   resolveDir?: string
@@ -268,6 +313,8 @@ export interface TransformOptions extends CommonOptions {
   banner?: string
   /** Documentation: https://esbuild.github.io/api/#footer */
   footer?: string
+  /** Documentation: https://esbuild.github.io/api/#strip-types-only */
+  stripTypesOnly?: boolean
 }
 
 export interface TransformResult<ProvidedOptions extends TransformOptions = TransformOptions> {
@@ -340,6 +387,7 @@ export interface ResolveOptions {
   resolveDir?: string
   kind?: ImportKind
   pluginData?: any
+  with?: Record<string, string>
 }
 
 /** Documentation: https://esbuild.github.io/plugins/#resolve-results */
@@ -379,6 +427,7 @@ export interface OnResolveArgs {
   resolveDir: string
   kind: ImportKind
   pluginData: any
+  with: Record<string, string>
 }
 
 export type ImportKind =
@@ -395,6 +444,9 @@ export type ImportKind =
   | 'composes-from'
   | 'url-token'
 
+  // TypeScript
+  | 'type-import'
+
 /** Documentation: https://esbuild.github.io/plugins/#on-resolve-results */
 export interface OnResolveResult {
   pluginName?: string
@@ -425,6 +477,7 @@ export interface OnLoadArgs {
   namespace: string
   suffix: string
   pluginData: any
+  with: Record<string, string>
 }
 
 /** Documentation: https://esbuild.github.io/plugins/#on-load-results */
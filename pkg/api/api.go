@@ -110,12 +110,58 @@ const (
 	LegalCommentsExternal
 )
 
+type Comments uint8
+
+const (
+	CommentsDefault Comments = iota
+	CommentsPreserve
+)
+
+type Quotes uint8
+
+const (
+	QuotesDefault Quotes = iota
+	QuotesDouble
+	QuotesSingle
+)
+
+type TrailingCommas uint8
+
+const (
+	TrailingCommasDefault TrailingCommas = iota
+	TrailingCommasNone
+	TrailingCommasES5
+	TrailingCommasAll
+)
+
+type Semicolons uint8
+
+const (
+	SemicolonsDefault Semicolons = iota
+	SemicolonsAlways
+	SemicolonsASI
+)
+
+// These only affect output that isn't minified. The same value can be used
+// for both build and transform so that they format code in the same way.
+type FormattingOptions struct {
+	IndentWidth    int            // Documentation: https://esbuild.github.io/api/#indent-width
+	UseTabs        bool           // Documentation: https://esbuild.github.io/api/#use-tabs
+	Quotes         Quotes         // Documentation: https://esbuild.github.io/api/#quotes
+	TrailingCommas TrailingCommas // Documentation: https://esbuild.github.io/api/#trailing-commas
+	Semicolons     Semicolons     // Documentation: https://esbuild.github.io/api/#semicolons
+	PrintWidth     int            // Documentation: https://esbuild.github.io/api/#print-width
+}
+
 type JSX uint8
 
 const (
 	JSXTransform JSX = iota
 	JSXPreserve
 	JSXAutomatic
+	JSXSolid
+	JSXVue
+	JSXHTM
 )
 
 type Target uint8
@@ -146,6 +192,8 @@ const (
 	LoaderDefault
 	LoaderEmpty
 	LoaderFile
+	LoaderFlow
+	LoaderFlowJSX
 	LoaderGlobalCSS
 	LoaderJS
 	LoaderJSON
@@ -278,10 +326,12 @@ type BuildOptions struct {
 	Target    Target          // Documentation: https://esbuild.github.io/api/#target
 	Engines   []Engine        // Documentation: https://esbuild.github.io/api/#target
 	Supported map[string]bool // Documentation: https://esbuild.github.io/api/#supported
+	Polyfill  string          // Documentation: https://esbuild.github.io/api/#polyfill
 
 	MangleProps       string                 // Documentation: https://esbuild.github.io/api/#mangle-props
 	ReserveProps      string                 // Documentation: https://esbuild.github.io/api/#mangle-props
 	MangleQuoted      MangleQuoted           // Documentation: https://esbuild.github.io/api/#mangle-props
+	ManglePrivate     bool                   // Documentation: https://esbuild.github.io/api/#mangle-private
 	MangleCache       map[string]interface{} // Documentation: https://esbuild.github.io/api/#mangle-props
 	Drop              Drop                   // Documentation: https://esbuild.github.io/api/#drop
 	DropLabels        []string               // Documentation: https://esbuild.github.io/api/#drop-labels
@@ -289,10 +339,12 @@ type BuildOptions struct {
 	MinifyIdentifiers bool                   // Documentation: https://esbuild.github.io/api/#minify
 	MinifySyntax      bool                   // Documentation: https://esbuild.github.io/api/#minify
 	LineLimit         int                    // Documentation: https://esbuild.github.io/api/#line-limit
+	Formatting        FormattingOptions      // Documentation: https://esbuild.github.io/api/#formatting
 	Charset           Charset                // Documentation: https://esbuild.github.io/api/#charset
 	TreeShaking       TreeShaking            // Documentation: https://esbuild.github.io/api/#tree-shaking
 	IgnoreAnnotations bool                   // Documentation: https://esbuild.github.io/api/#ignore-annotations
 	LegalComments     LegalComments          // Documentation: https://esbuild.github.io/api/#legal-comments
+	Comments          Comments               // Documentation: https://esbuild.github.io/api/#comments
 
 	JSX             JSX    // Documentation: https://esbuild.github.io/api/#jsx-mode
 	JSXFactory      string // Documentation: https://esbuild.github.io/api/#jsx-factory
@@ -301,36 +353,46 @@ type BuildOptions struct {
 	JSXDev          bool   // Documentation: https://esbuild.github.io/api/#jsx-dev
 	JSXSideEffects  bool   // Documentation: https://esbuild.github.io/api/#jsx-side-effects
 
-	Define    map[string]string // Documentation: https://esbuild.github.io/api/#define
-	Pure      []string          // Documentation: https://esbuild.github.io/api/#pure
-	KeepNames bool              // Documentation: https://esbuild.github.io/api/#keep-names
-
-	GlobalName        string            // Documentation: https://esbuild.github.io/api/#global-name
-	Bundle            bool              // Documentation: https://esbuild.github.io/api/#bundle
-	PreserveSymlinks  bool              // Documentation: https://esbuild.github.io/api/#preserve-symlinks
-	Splitting         bool              // Documentation: https://esbuild.github.io/api/#splitting
-	Outfile           string            // Documentation: https://esbuild.github.io/api/#outfile
-	Metafile          bool              // Documentation: https://esbuild.github.io/api/#metafile
-	Outdir            string            // Documentation: https://esbuild.github.io/api/#outdir
-	Outbase           string            // Documentation: https://esbuild.github.io/api/#outbase
-	AbsWorkingDir     string            // Documentation: https://esbuild.github.io/api/#working-directory
-	Platform          Platform          // Documentation: https://esbuild.github.io/api/#platform
-	Format            Format            // Documentation: https://esbuild.github.io/api/#format
-	External          []string          // Documentation: https://esbuild.github.io/api/#external
-	Packages          Packages          // Documentation: https://esbuild.github.io/api/#packages
-	Alias             map[string]string // Documentation: https://esbuild.github.io/api/#alias
-	MainFields        []string          // Documentation: https://esbuild.github.io/api/#main-fields
-	Conditions        []string          // Documentation: https://esbuild.github.io/api/#conditions
-	Loader            map[string]Loader // Documentation: https://esbuild.github.io/api/#loader
-	ResolveExtensions []string          // Documentation: https://esbuild.github.io/api/#resolve-extensions
-	Tsconfig          string            // Documentation: https://esbuild.github.io/api/#tsconfig
-	TsconfigRaw       string            // Documentation: https://esbuild.github.io/api/#tsconfig-raw
-	OutExtension      map[string]string // Documentation: https://esbuild.github.io/api/#out-extension
-	PublicPath        string            // Documentation: https://esbuild.github.io/api/#public-path
-	Inject            []string          // Documentation: https://esbuild.github.io/api/#inject
-	Banner            map[string]string // Documentation: https://esbuild.github.io/api/#banner
-	Footer            map[string]string // Documentation: https://esbuild.github.io/api/#footer
-	NodePaths         []string          // Documentation: https://esbuild.github.io/api/#node-paths
+	JSXConstantElements bool // Documentation: https://esbuild.github.io/api/#jsx-constant-elements
+	JSXInlineElements   bool // Documentation: https://esbuild.github.io/api/#jsx-inline-elements
+
+	Define             map[string]string // Documentation: https://esbuild.github.io/api/#define
+	Pure               []string          // Documentation: https://esbuild.github.io/api/#pure
+	KeepNames          bool              // Documentation: https://esbuild.github.io/api/#keep-names
+	LooseIteration     bool              // Documentation: https://esbuild.github.io/api/#loose-iteration
+	ErasableSyntaxOnly bool              // Documentation: https://esbuild.github.io/api/#erasable-syntax-only
+	CollapseProperties bool              // Documentation: https://esbuild.github.io/api/#collapse-properties
+
+	GlobalName         string            // Documentation: https://esbuild.github.io/api/#global-name
+	Bundle             bool              // Documentation: https://esbuild.github.io/api/#bundle
+	PreserveSymlinks   bool              // Documentation: https://esbuild.github.io/api/#preserve-symlinks
+	Splitting          bool              // Documentation: https://esbuild.github.io/api/#splitting
+	Declarations       bool              // Documentation: https://esbuild.github.io/api/#declarations
+	DeclarationsInline []string          // Documentation: https://esbuild.github.io/api/#declarations-inline
+	InlineFunctions    bool              // Documentation: https://esbuild.github.io/api/#inline-functions
+	InlineBudget       int               // Documentation: https://esbuild.github.io/api/#inline-functions
+	Outfile            string            // Documentation: https://esbuild.github.io/api/#outfile
+	Metafile           bool              // Documentation: https://esbuild.github.io/api/#metafile
+	Outdir             string            // Documentation: https://esbuild.github.io/api/#outdir
+	Outbase            string            // Documentation: https://esbuild.github.io/api/#outbase
+	AbsWorkingDir      string            // Documentation: https://esbuild.github.io/api/#working-directory
+	Platform           Platform          // Documentation: https://esbuild.github.io/api/#platform
+	Format             Format            // Documentation: https://esbuild.github.io/api/#format
+	External           []string          // Documentation: https://esbuild.github.io/api/#external
+	Packages           Packages          // Documentation: https://esbuild.github.io/api/#packages
+	Alias              map[string]string // Documentation: https://esbuild.github.io/api/#alias
+	MainFields         []string          // Documentation: https://esbuild.github.io/api/#main-fields
+	Conditions         []string          // Documentation: https://esbuild.github.io/api/#conditions
+	Loader             map[string]Loader // Documentation: https://esbuild.github.io/api/#loader
+	ResolveExtensions  []string          // Documentation: https://esbuild.github.io/api/#resolve-extensions
+	Tsconfig           string            // Documentation: https://esbuild.github.io/api/#tsconfig
+	TsconfigRaw        string            // Documentation: https://esbuild.github.io/api/#tsconfig-raw
+	OutExtension       map[string]string // Documentation: https://esbuild.github.io/api/#out-extension
+	PublicPath         string            // Documentation: https://esbuild.github.io/api/#public-path
+	Inject             []string          // Documentation: https://esbuild.github.io/api/#inject
+	Banner             map[string]string // Documentation: https://esbuild.github.io/api/#banner
+	Footer             map[string]string // Documentation: https://esbuild.github.io/api/#footer
+	NodePaths          []string          // Documentation: https://esbuild.github.io/api/#node-paths
 
 	EntryNames string // Documentation: https://esbuild.github.io/api/#entry-names
 	ChunkNames string // Documentation: https://esbuild.github.io/api/#chunk-names
@@ -409,6 +471,7 @@ type TransformOptions struct {
 	Target    Target          // Documentation: https://esbuild.github.io/api/#target
 	Engines   []Engine        // Documentation: https://esbuild.github.io/api/#target
 	Supported map[string]bool // Documentation: https://esbuild.github.io/api/#supported
+	Polyfill  string          // Documentation: https://esbuild.github.io/api/#polyfill
 
 	Platform   Platform // Documentation: https://esbuild.github.io/api/#platform
 	Format     Format   // Documentation: https://esbuild.github.io/api/#format
@@ -417,6 +480,7 @@ type TransformOptions struct {
 	MangleProps       string                 // Documentation: https://esbuild.github.io/api/#mangle-props
 	ReserveProps      string                 // Documentation: https://esbuild.github.io/api/#mangle-props
 	MangleQuoted      MangleQuoted           // Documentation: https://esbuild.github.io/api/#mangle-props
+	ManglePrivate     bool                   // Documentation: https://esbuild.github.io/api/#mangle-private
 	MangleCache       map[string]interface{} // Documentation: https://esbuild.github.io/api/#mangle-props
 	Drop              Drop                   // Documentation: https://esbuild.github.io/api/#drop
 	DropLabels        []string               // Documentation: https://esbuild.github.io/api/#drop-labels
@@ -424,10 +488,12 @@ type TransformOptions struct {
 	MinifyIdentifiers bool                   // Documentation: https://esbuild.github.io/api/#minify
 	MinifySyntax      bool                   // Documentation: https://esbuild.github.io/api/#minify
 	LineLimit         int                    // Documentation: https://esbuild.github.io/api/#line-limit
+	Formatting        FormattingOptions      // Documentation: https://esbuild.github.io/api/#formatting
 	Charset           Charset                // Documentation: https://esbuild.github.io/api/#charset
 	TreeShaking       TreeShaking            // Documentation: https://esbuild.github.io/api/#tree-shaking
 	IgnoreAnnotations bool                   // Documentation: https://esbuild.github.io/api/#ignore-annotations
 	LegalComments     LegalComments          // Documentation: https://esbuild.github.io/api/#legal-comments
+	Comments          Comments               // Documentation: https://esbuild.github.io/api/#comments
 
 	JSX             JSX    // Documentation: https://esbuild.github.io/api/#jsx
 	JSXFactory      string // Documentation: https://esbuild.github.io/api/#jsx-factory
@@ -436,16 +502,22 @@ type TransformOptions struct {
 	JSXDev          bool   // Documentation: https://esbuild.github.io/api/#jsx-dev
 	JSXSideEffects  bool   // Documentation: https://esbuild.github.io/api/#jsx-side-effects
 
+	JSXConstantElements bool // Documentation: https://esbuild.github.io/api/#jsx-constant-elements
+	JSXInlineElements   bool // Documentation: https://esbuild.github.io/api/#jsx-inline-elements
+
 	TsconfigRaw string // Documentation: https://esbuild.github.io/api/#tsconfig-raw
 	Banner      string // Documentation: https://esbuild.github.io/api/#banner
 	Footer      string // Documentation: https://esbuild.github.io/api/#footer
 
-	Define    map[string]string // Documentation: https://esbuild.github.io/api/#define
-	Pure      []string          // Documentation: https://esbuild.github.io/api/#pure
-	KeepNames bool              // Documentation: https://esbuild.github.io/api/#keep-names
+	Define             map[string]string // Documentation: https://esbuild.github.io/api/#define
+	Pure               []string          // Documentation: https://esbuild.github.io/api/#pure
+	KeepNames          bool              // Documentation: https://esbuild.github.io/api/#keep-names
+	LooseIteration     bool              // Documentation: https://esbuild.github.io/api/#loose-iteration
+	ErasableSyntaxOnly bool              // Documentation: https://esbuild.github.io/api/#erasable-syntax-only
 
-	Sourcefile string // Documentation: https://esbuild.github.io/api/#sourcefile
-	Loader     Loader // Documentation: https://esbuild.github.io/api/#loader
+	Sourcefile     string // Documentation: https://esbuild.github.io/api/#sourcefile
+	Loader         Loader // Documentation: https://esbuild.github.io/api/#loader
+	StripTypesOnly bool   // Documentation: https://esbuild.github.io/api/#strip-types-only
 }
 
 type TransformResult struct {
@@ -575,6 +647,7 @@ type ResolveOptions struct {
 	ResolveDir string
 	Kind       ResolveKind
 	PluginData interface{}
+	With       map[string]string
 }
 
 // Documentation: https://esbuild.github.io/plugins/#resolve-results
@@ -614,6 +687,7 @@ type OnResolveArgs struct {
 	ResolveDir string
 	Kind       ResolveKind
 	PluginData interface{}
+	With       map[string]string
 }
 
 // Documentation: https://esbuild.github.io/plugins/#on-resolve-results
@@ -646,6 +720,7 @@ type OnLoadArgs struct {
 	Namespace  string
 	Suffix     string
 	PluginData interface{}
+	With       map[string]string
 }
 
 // Documentation: https://esbuild.github.io/plugins/#on-load-results
@@ -676,6 +751,7 @@ const (
 	ResolveCSSImportRule
 	ResolveCSSComposesFrom
 	ResolveCSSURLToken
+	ResolveTSTypeImport
 )
 
 ////////////////////////////////////////////////////////////////////////////////
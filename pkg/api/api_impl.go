@@ -173,6 +173,52 @@ func validateLegalComments(value LegalComments, bundle bool) config.LegalComment
 	}
 }
 
+func validateFormatting(log logger.Log, value FormattingOptions) config.Formatting {
+	formatting := config.Formatting{
+		IndentWidth: value.IndentWidth,
+		UseTabs:     value.UseTabs,
+		PrintWidth:  value.PrintWidth,
+	}
+
+	if value.IndentWidth < 0 {
+		log.AddError(nil, logger.Range{}, fmt.Sprintf("Invalid indent width: %d", value.IndentWidth))
+	}
+	if value.PrintWidth < 0 {
+		log.AddError(nil, logger.Range{}, fmt.Sprintf("Invalid print width: %d", value.PrintWidth))
+	}
+
+	switch value.Quotes {
+	case QuotesDefault, QuotesDouble:
+		formatting.Quotes = config.QuotesDouble
+	case QuotesSingle:
+		formatting.Quotes = config.QuotesSingle
+	default:
+		panic("Invalid quotes")
+	}
+
+	switch value.TrailingCommas {
+	case TrailingCommasDefault, TrailingCommasNone:
+		formatting.TrailingCommas = config.TrailingCommasNone
+	case TrailingCommasES5:
+		formatting.TrailingCommas = config.TrailingCommasES5
+	case TrailingCommasAll:
+		formatting.TrailingCommas = config.TrailingCommasAll
+	default:
+		panic("Invalid trailing commas")
+	}
+
+	switch value.Semicolons {
+	case SemicolonsDefault, SemicolonsAlways:
+		formatting.Semicolons = config.SemicolonsAlways
+	case SemicolonsASI:
+		formatting.Semicolons = config.SemicolonsASI
+	default:
+		panic("Invalid semicolons")
+	}
+
+	return formatting
+}
+
 func validateColor(value StderrColor) logger.UseColor {
 	switch value {
 	case ColorIfTerminal:
@@ -253,6 +299,10 @@ func validateLoader(value Loader) config.Loader {
 		return config.LoaderEmpty
 	case LoaderFile:
 		return config.LoaderFile
+	case LoaderFlow:
+		return config.LoaderFlow
+	case LoaderFlowJSX:
+		return config.LoaderFlowJSX
 	case LoaderGlobalCSS:
 		return config.LoaderGlobalCSS
 	case LoaderJS:
@@ -298,9 +348,9 @@ func validateEngine(value EngineName) compat.Engine {
 var versionRegex = regexp.MustCompile(`^([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?$`)
 var preReleaseVersionRegex = regexp.MustCompile(`^([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?-`)
 
-func validateFeatures(log logger.Log, target Target, engines []Engine) (compat.JSFeature, compat.CSSFeature, map[css_ast.D]compat.CSSPrefix, string) {
+func validateFeatures(log logger.Log, target Target, engines []Engine) (compat.JSFeature, compat.CSSFeature, compat.BuiltIn, map[css_ast.D]compat.CSSPrefix, string) {
 	if target == DefaultTarget && len(engines) == 0 {
-		return 0, 0, nil, ""
+		return 0, 0, 0, nil, ""
 	}
 
 	constraints := make(map[compat.Engine][]int)
@@ -376,7 +426,8 @@ func validateFeatures(log logger.Log, target Target, engines []Engine) (compat.J
 	sort.Strings(targets)
 	targetEnv := helpers.StringArrayToQuotedCommaSeparatedString(targets)
 
-	return compat.UnsupportedJSFeatures(constraints), compat.UnsupportedCSSFeatures(constraints), compat.CSSPrefixData(constraints), targetEnv
+	return compat.UnsupportedJSFeatures(constraints), compat.UnsupportedCSSFeatures(constraints),
+		compat.UnsupportedBuiltIns(constraints), compat.CSSPrefixData(constraints), targetEnv
 }
 
 func validateSupported(log logger.Log, supported map[string]bool) (
@@ -403,6 +454,15 @@ func validateSupported(log logger.Log, supported map[string]bool) (
 	return
 }
 
+func validatePolyfill(log logger.Log, text string) string {
+	text = strings.TrimSuffix(text, "/")
+	if text != "" && (strings.HasPrefix(text, ".") || strings.HasPrefix(text, "/") || strings.Contains(text, "\\")) {
+		log.AddError(nil, logger.Range{}, fmt.Sprintf("Invalid polyfill package name: %q", text))
+		return ""
+	}
+	return text
+}
+
 func validateGlobalName(log logger.Log, text string) []string {
 	if text != "" {
 		source := logger.Source{
@@ -467,6 +527,29 @@ func validateExternals(log logger.Log, fs fs.FS, paths []string) config.External
 	return result
 }
 
+func validateDeclarationsInline(log logger.Log, paths []string) config.ExternalMatchers {
+	result := config.ExternalMatchers{Exact: make(map[string]bool)}
+
+	for _, path := range paths {
+		if !resolver.IsPackagePath(path) {
+			log.AddError(nil, logger.Range{}, fmt.Sprintf("Invalid package name %q for inlined declarations", path))
+		} else if index := strings.IndexByte(path, '*'); index != -1 {
+			// Wildcard behavior
+			if strings.ContainsRune(path[index+1:], '*') {
+				log.AddError(nil, logger.Range{}, fmt.Sprintf("Package name %q for inlined declarations cannot have more than one \"*\" wildcard", path))
+			} else {
+				result.Patterns = append(result.Patterns, config.WildcardPattern{Prefix: path[:index], Suffix: path[index+1:]})
+			}
+		} else {
+			// Non-wildcard behavior (subpaths of the package are also included)
+			result.Exact[path] = true
+			result.Patterns = append(result.Patterns, config.WildcardPattern{Prefix: path + "/"})
+		}
+	}
+
+	return result
+}
+
 func esmParsePackageName(packageSpecifier string) (packageName string, packageSubpath string, ok bool) {
 	if packageSpecifier == "" {
 		return
@@ -564,6 +647,19 @@ func validateLoaders(log logger.Log, loaders map[string]Loader) map[string]confi
 	return result
 }
 
+func validateJSXTarget(value JSX) config.JSXTarget {
+	switch value {
+	case JSXSolid:
+		return config.JSXTargetSolid
+	case JSXVue:
+		return config.JSXTargetVue
+	case JSXHTM:
+		return config.JSXTargetHTM
+	default:
+		return config.JSXTargetReact
+	}
+}
+
 func validateJSXExpr(log logger.Log, text string, name string) config.DefineExpr {
 	if text != "" {
 		if expr, _ := js_parser.ParseDefineExprOrJSON(text); len(expr.Parts) > 0 || (name == "fragment" && expr.Constant != nil) {
@@ -1255,7 +1351,7 @@ func validateBuildOptions(
 	options config.Options,
 	entryPoints []bundler.EntryPoint,
 ) {
-	jsFeatures, cssFeatures, cssPrefixData, targetEnv := validateFeatures(log, buildOpts.Target, buildOpts.Engines)
+	jsFeatures, cssFeatures, builtIns, cssPrefixData, targetEnv := validateFeatures(log, buildOpts.Target, buildOpts.Engines)
 	jsOverrides, jsMask, cssOverrides, cssMask := validateSupported(log, buildOpts.Supported)
 	outJS, outCSS := validateOutputExtensions(log, buildOpts.OutExtension)
 	bannerJS, bannerCSS := validateBannerOrFooter(log, "banner", buildOpts.Banner)
@@ -1271,30 +1367,38 @@ func validateBuildOptions(
 		UnsupportedJSFeatureOverridesMask:  jsMask,
 		UnsupportedCSSFeatureOverrides:     cssOverrides,
 		UnsupportedCSSFeatureOverridesMask: cssMask,
+		UnsupportedBuiltIns:                builtIns,
+		Polyfill:                           validatePolyfill(log, buildOpts.Polyfill),
 		OriginalTargetEnv:                  targetEnv,
 		JSX: config.JSXOptions{
 			Preserve:         buildOpts.JSX == JSXPreserve,
 			AutomaticRuntime: buildOpts.JSX == JSXAutomatic,
+			Target:           validateJSXTarget(buildOpts.JSX),
 			Factory:          validateJSXExpr(log, buildOpts.JSXFactory, "factory"),
 			Fragment:         validateJSXExpr(log, buildOpts.JSXFragment, "fragment"),
 			Development:      buildOpts.JSXDev,
 			ImportSource:     buildOpts.JSXImportSource,
 			SideEffects:      buildOpts.JSXSideEffects,
+			ConstantElements: buildOpts.JSXConstantElements,
+			InlineElements:   buildOpts.JSXInlineElements,
 		},
 		Defines:               defines,
 		InjectedDefines:       injectedDefines,
 		Platform:              platform,
 		SourceMap:             validateSourceMap(buildOpts.Sourcemap),
 		LegalComments:         validateLegalComments(buildOpts.LegalComments, buildOpts.Bundle),
+		PreserveComments:      buildOpts.Comments == CommentsPreserve,
 		SourceRoot:            buildOpts.SourceRoot,
 		ExcludeSourcesContent: buildOpts.SourcesContent == SourcesContentExclude,
 		MinifySyntax:          buildOpts.MinifySyntax,
 		MinifyWhitespace:      buildOpts.MinifyWhitespace,
 		MinifyIdentifiers:     buildOpts.MinifyIdentifiers,
 		LineLimit:             buildOpts.LineLimit,
+		Formatting:            validateFormatting(log, buildOpts.Formatting),
 		MangleProps:           validateRegex(log, "mangle props", buildOpts.MangleProps),
 		ReserveProps:          validateRegex(log, "reserve props", buildOpts.ReserveProps),
 		MangleQuoted:          buildOpts.MangleQuoted == MangleQuotedTrue,
+		ManglePrivate:         buildOpts.ManglePrivate,
 		DropLabels:            append([]string{}, buildOpts.DropLabels...),
 		DropDebugger:          (buildOpts.Drop & DropDebugger) != 0,
 		AllowOverwrite:        buildOpts.AllowOverwrite,
@@ -1303,6 +1407,11 @@ func validateBuildOptions(
 		TreeShaking:           validateTreeShaking(buildOpts.TreeShaking, buildOpts.Bundle, buildOpts.Format),
 		GlobalName:            validateGlobalName(log, buildOpts.GlobalName),
 		CodeSplitting:         buildOpts.Splitting,
+		Declarations:          buildOpts.Declarations,
+		DeclarationsInline:    validateDeclarationsInline(log, buildOpts.DeclarationsInline),
+		InlineFunctions:       buildOpts.InlineFunctions,
+		InlineBudget:          buildOpts.InlineBudget,
+		CollapseProperties:    buildOpts.CollapseProperties,
 		OutputFormat:          validateFormat(buildOpts.Format),
 		AbsOutputFile:         validatePath(log, realFS, buildOpts.Outfile, "outfile path"),
 		AbsOutputDir:          validatePath(log, realFS, buildOpts.Outdir, "outdir path"),
@@ -1320,9 +1429,11 @@ func validateBuildOptions(
 		PackageAliases:        validateAlias(log, realFS, buildOpts.Alias),
 		TSConfigPath:          validatePath(log, realFS, buildOpts.Tsconfig, "tsconfig path"),
 		TSConfigRaw:           buildOpts.TsconfigRaw,
+		TS:                    config.TSOptions{ErasableSyntaxOnly: buildOpts.ErasableSyntaxOnly},
 		MainFields:            buildOpts.MainFields,
 		PublicPath:            buildOpts.PublicPath,
 		KeepNames:             buildOpts.KeepNames,
+		LooseIteration:        buildOpts.LooseIteration,
 		InjectPaths:           append([]string{}, buildOpts.Inject...),
 		AbsNodePaths:          make([]string, len(buildOpts.NodePaths)),
 		JSBanner:              bannerJS,
@@ -1379,6 +1490,9 @@ func validateBuildOptions(
 		if options.LegalComments.HasExternalFile() {
 			log.AddError(nil, logger.Range{}, "Cannot use linked or external legal comments without an output path")
 		}
+		if options.Declarations {
+			log.AddError(nil, logger.Range{}, "Cannot generate declaration files without an output path")
+		}
 		for _, loader := range options.ExtensionToLoader {
 			if loader == config.LoaderFile {
 				log.AddError(nil, logger.Range{}, "Cannot use the \"file\" loader without an output path")
@@ -1432,6 +1546,10 @@ func validateBuildOptions(
 		log.AddError(nil, logger.Range{}, "Splitting currently only works with the \"esm\" format")
 	}
 
+	if options.InlineBudget < 0 {
+		log.AddError(nil, logger.Range{}, "The inline budget must be a non-negative integer")
+	}
+
 	// Code splitting is experimental and currently only enabled for ES6 modules
 	if options.TSConfigPath != "" && options.TSConfigRaw != "" {
 		log.AddError(nil, logger.Range{}, "Cannot provide \"tsconfig\" as both a raw string and a path")
@@ -1705,7 +1823,7 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 	}
 
 	// Convert and validate the transformOpts
-	jsFeatures, cssFeatures, cssPrefixData, targetEnv := validateFeatures(log, transformOpts.Target, transformOpts.Engines)
+	jsFeatures, cssFeatures, builtIns, cssPrefixData, targetEnv := validateFeatures(log, transformOpts.Target, transformOpts.Engines)
 	jsOverrides, jsMask, cssOverrides, cssMask := validateSupported(log, transformOpts.Supported)
 	platform := validatePlatform(transformOpts.Platform)
 	defines, injectedDefines := validateDefines(log, transformOpts.Define, transformOpts.Pure, platform, false /* isBuildAPI */, false /* minify */, transformOpts.Drop)
@@ -1718,22 +1836,29 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 		UnsupportedJSFeatureOverridesMask:  jsMask,
 		UnsupportedCSSFeatureOverrides:     cssOverrides,
 		UnsupportedCSSFeatureOverridesMask: cssMask,
+		UnsupportedBuiltIns:                builtIns,
+		Polyfill:                           validatePolyfill(log, transformOpts.Polyfill),
 		OriginalTargetEnv:                  targetEnv,
 		TSConfigRaw:                        transformOpts.TsconfigRaw,
+		TS:                                 config.TSOptions{ErasableSyntaxOnly: transformOpts.ErasableSyntaxOnly},
 		JSX: config.JSXOptions{
 			Preserve:         transformOpts.JSX == JSXPreserve,
 			AutomaticRuntime: transformOpts.JSX == JSXAutomatic,
+			Target:           validateJSXTarget(transformOpts.JSX),
 			Factory:          validateJSXExpr(log, transformOpts.JSXFactory, "factory"),
 			Fragment:         validateJSXExpr(log, transformOpts.JSXFragment, "fragment"),
 			Development:      transformOpts.JSXDev,
 			ImportSource:     transformOpts.JSXImportSource,
 			SideEffects:      transformOpts.JSXSideEffects,
+			ConstantElements: transformOpts.JSXConstantElements,
+			InlineElements:   transformOpts.JSXInlineElements,
 		},
 		Defines:               defines,
 		InjectedDefines:       injectedDefines,
 		Platform:              platform,
 		SourceMap:             validateSourceMap(transformOpts.Sourcemap),
 		LegalComments:         validateLegalComments(transformOpts.LegalComments, false /* bundle */),
+		PreserveComments:      transformOpts.Comments == CommentsPreserve,
 		SourceRoot:            transformOpts.SourceRoot,
 		ExcludeSourcesContent: transformOpts.SourcesContent == SourcesContentExclude,
 		OutputFormat:          validateFormat(transformOpts.Format),
@@ -1742,9 +1867,11 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 		MinifyWhitespace:      transformOpts.MinifyWhitespace,
 		MinifyIdentifiers:     transformOpts.MinifyIdentifiers,
 		LineLimit:             transformOpts.LineLimit,
+		Formatting:            validateFormatting(log, transformOpts.Formatting),
 		MangleProps:           validateRegex(log, "mangle props", transformOpts.MangleProps),
 		ReserveProps:          validateRegex(log, "reserve props", transformOpts.ReserveProps),
 		MangleQuoted:          transformOpts.MangleQuoted == MangleQuotedTrue,
+		ManglePrivate:         transformOpts.ManglePrivate,
 		DropLabels:            append([]string{}, transformOpts.DropLabels...),
 		DropDebugger:          (transformOpts.Drop & DropDebugger) != 0,
 		ASCIIOnly:             validateASCIIOnly(transformOpts.Charset),
@@ -1752,6 +1879,7 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 		TreeShaking:           validateTreeShaking(transformOpts.TreeShaking, false /* bundle */, transformOpts.Format),
 		AbsOutputFile:         transformOpts.Sourcefile + "-out",
 		KeepNames:             transformOpts.KeepNames,
+		LooseIteration:        transformOpts.LooseIteration,
 		Stdin: &config.StdinInfo{
 			Loader:     validateLoader(transformOpts.Loader),
 			Contents:   input,
@@ -1781,15 +1909,24 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 		log.AddError(nil, logger.Range{}, "Cannot transform with linked legal comments")
 	}
 
+	if transformOpts.StripTypesOnly {
+		validateStripTypesOnly(log, transformOpts, options)
+	}
+
 	// Set the output mode using other settings
 	if options.OutputFormat != config.FormatPreserve {
 		options.Mode = config.ModeConvertFormat
 	}
 
 	var results []graph.OutputFile
+	var code []byte
 
 	// Stop now if there were errors
-	if !log.HasErrors() {
+	if transformOpts.StripTypesOnly {
+		if !log.HasErrors() {
+			code = stripTypesOnly(log, caches, options)
+		}
+	} else if !log.HasErrors() {
 		var timer *helpers.Timer
 		if api_helpers.UseTimer {
 			timer = &helpers.Timer{}
@@ -1809,7 +1946,6 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 	}
 
 	// Return the results
-	var code []byte
 	var sourceMap []byte
 	var legalComments []byte
 
@@ -1848,6 +1984,78 @@ func transformImpl(input string, transformOpts TransformOptions) TransformResult
 	}
 }
 
+func validateStripTypesOnly(log logger.Log, transformOpts TransformOptions, options config.Options) {
+	switch options.Stdin.Loader {
+	case config.LoaderTS, config.LoaderTSNoAmbiguousLessThan, config.LoaderTSX:
+	default:
+		log.AddError(nil, logger.Range{}, "Must use the \"ts\" or \"tsx\" loader with \"strip-types-only\"")
+	}
+
+	// Only the type annotations are removed, so anything that would change the
+	// remaining code or move it to a different line or column is forbidden
+	var conflicts []string
+	if transformOpts.Sourcemap != SourceMapNone {
+		conflicts = append(conflicts, "sourcemap")
+	}
+	if transformOpts.Target != DefaultTarget || len(transformOpts.Engines) > 0 || len(transformOpts.Supported) > 0 {
+		conflicts = append(conflicts, "target")
+	}
+	if transformOpts.Format != FormatDefault {
+		conflicts = append(conflicts, "format")
+	}
+	if transformOpts.MinifyWhitespace || transformOpts.MinifyIdentifiers || transformOpts.MinifySyntax {
+		conflicts = append(conflicts, "minify")
+	}
+	if transformOpts.MangleProps != "" {
+		conflicts = append(conflicts, "mangle-props")
+	}
+	if transformOpts.ManglePrivate {
+		conflicts = append(conflicts, "mangle-private")
+	}
+	if transformOpts.Drop != 0 || len(transformOpts.DropLabels) > 0 {
+		conflicts = append(conflicts, "drop")
+	}
+	if transformOpts.JSX != JSXTransform && transformOpts.JSX != JSXPreserve {
+		conflicts = append(conflicts, "jsx")
+	}
+	if transformOpts.Banner != "" || transformOpts.Footer != "" {
+		conflicts = append(conflicts, "banner")
+	}
+	if len(transformOpts.Define) > 0 || len(transformOpts.Pure) > 0 {
+		conflicts = append(conflicts, "define")
+	}
+	if transformOpts.KeepNames {
+		conflicts = append(conflicts, "keep-names")
+	}
+	for _, name := range conflicts {
+		log.AddError(nil, logger.Range{}, fmt.Sprintf("Cannot use %q with \"strip-types-only\"", name))
+	}
+}
+
+func stripTypesOnly(log logger.Log, caches *cache.CacheSet, options config.Options) []byte {
+	// This applies the "tsconfig.json" override, if any
+	mockFS := fs.MockFS(make(map[string]string), fs.MockUnix, "/")
+	resolver.NewResolver(config.TransformCall, mockFS, log, caches, &options)
+
+	options.TS.Parse = true
+	options.TS.NoAmbiguousLessThan = options.Stdin.Loader == config.LoaderTSNoAmbiguousLessThan
+	options.JSX.Parse = options.Stdin.Loader == config.LoaderTSX
+
+	keyPath := logger.Path{Text: options.Stdin.SourceFile}
+	source := logger.Source{
+		KeyPath:        keyPath,
+		PrettyPath:     resolver.PrettyPath(mockFS, keyPath),
+		Contents:       options.Stdin.Contents,
+		IdentifierName: js_ast.EnsureValidIdentifier(options.Stdin.SourceFile),
+	}
+
+	result, ok := js_parser.StripTypes(log, source, js_parser.OptionsFromConfig(&options))
+	if !ok || log.HasErrors() {
+		return nil
+	}
+	return []byte(result)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Plugin API
 
@@ -1893,6 +2101,8 @@ func importKindToResolveKind(kind ast.ImportKind) ResolveKind {
 		return ResolveCSSComposesFrom
 	case ast.ImportURL:
 		return ResolveCSSURLToken
+	case ast.ImportTypes:
+		return ResolveTSTypeImport
 	default:
 		panic("Internal error")
 	}
@@ -1916,6 +2126,8 @@ func resolveKindToImportKind(kind ResolveKind) ast.ImportKind {
 		return ast.ImportComposesFrom
 	case ResolveCSSURLToken:
 		return ast.ImportURL
+	case ResolveTSTypeImport:
+		return ast.ImportTypes
 	default:
 		panic("Internal error")
 	}
@@ -1940,6 +2152,7 @@ func (impl *pluginImpl) onResolve(options OnResolveOptions, callback func(OnReso
 				ResolveDir: args.ResolveDir,
 				Kind:       importKindToResolveKind(args.Kind),
 				PluginData: args.PluginData,
+				With:       args.With.DecodeIntoMap(),
 			})
 			result.PluginName = response.PluginName
 			result.AbsWatchFiles = impl.validatePathsArray(response.WatchFiles, "watch file")
@@ -1987,6 +2200,7 @@ func (impl *pluginImpl) onLoad(options OnLoadOptions, callback func(OnLoadArgs)
 				Namespace:  args.Path.Namespace,
 				PluginData: args.PluginData,
 				Suffix:     args.Path.IgnoredSuffix,
+				With:       args.Path.ImportAttributes.DecodeIntoMap(),
 			})
 			result.PluginName = response.PluginName
 			result.AbsWatchFiles = impl.validatePathsArray(response.WatchFiles, "watch file")
@@ -2091,6 +2305,7 @@ func loadPlugins(initialOptions *BuildOptions, fs fs.FS, log logger.Log, caches
 				logger.Range{}, // importPathRange
 				logger.Path{Text: options.Importer, Namespace: options.Namespace},
 				path,
+				logger.EncodeImportAttributes(options.With),
 				kind,
 				absResolveDir,
 				options.PluginData,
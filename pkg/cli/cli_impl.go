@@ -113,6 +113,34 @@ func parseOptionsImpl(
 				buildOpts.Splitting = value
 			}
 
+		case isBoolFlag(arg, "--collapse-properties") && buildOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				buildOpts.CollapseProperties = value
+			}
+
+		case isBoolFlag(arg, "--inline-functions") && buildOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				buildOpts.InlineFunctions = value
+			}
+
+		case isBoolFlag(arg, "--declarations") && buildOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				buildOpts.Declarations = value
+			}
+
+		case isBoolFlag(arg, "--strip-types-only") && transformOpts != nil:
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else {
+				transformOpts.StripTypesOnly = value
+			}
+
 		case isBoolFlag(arg, "--allow-overwrite") && buildOpts != nil:
 			if value, err := parseBoolFlag(arg, true); err != nil {
 				return parseOptionsExtras{}, err
@@ -184,6 +212,15 @@ func parseOptionsImpl(
 				}
 			}
 
+		case isBoolFlag(arg, "--mangle-private"):
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else if buildOpts != nil {
+				buildOpts.ManglePrivate = value
+			} else {
+				transformOpts.ManglePrivate = value
+			}
+
 		case strings.HasPrefix(arg, "--mangle-props="):
 			value := arg[len("--mangle-props="):]
 			if buildOpts != nil {
@@ -259,6 +296,26 @@ func parseOptionsImpl(
 				transformOpts.LegalComments = legalComments
 			}
 
+		case strings.HasPrefix(arg, "--comments="):
+			value := arg[len("--comments="):]
+			var comments api.Comments
+			switch value {
+			case "default":
+				comments = api.CommentsDefault
+			case "preserve":
+				comments = api.CommentsPreserve
+			default:
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"Valid values are \"default\" or \"preserve\".",
+				)
+			}
+			if buildOpts != nil {
+				buildOpts.Comments = comments
+			} else {
+				transformOpts.Comments = comments
+			}
+
 		case strings.HasPrefix(arg, "--charset="):
 			var value *api.Charset
 			if buildOpts != nil {
@@ -314,6 +371,24 @@ func parseOptionsImpl(
 				transformOpts.KeepNames = value
 			}
 
+		case isBoolFlag(arg, "--erasable-syntax-only"):
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else if buildOpts != nil {
+				buildOpts.ErasableSyntaxOnly = value
+			} else {
+				transformOpts.ErasableSyntaxOnly = value
+			}
+
+		case isBoolFlag(arg, "--loose-iteration"):
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else if buildOpts != nil {
+				buildOpts.LooseIteration = value
+			} else {
+				transformOpts.LooseIteration = value
+			}
+
 		case arg == "--sourcemap":
 			if buildOpts != nil {
 				buildOpts.Sourcemap = api.SourceMapLinked
@@ -401,6 +476,13 @@ func parseOptionsImpl(
 				transformOpts.GlobalName = arg[len("--global-name="):]
 			}
 
+		case strings.HasPrefix(arg, "--polyfill="):
+			if buildOpts != nil {
+				buildOpts.Polyfill = arg[len("--polyfill="):]
+			} else {
+				transformOpts.Polyfill = arg[len("--polyfill="):]
+			}
+
 		case arg == "--metafile" && buildOpts != nil && kind == kindExternal:
 			buildOpts.Metafile = true
 
@@ -626,6 +708,9 @@ func parseOptionsImpl(
 		case strings.HasPrefix(arg, "--external:") && buildOpts != nil:
 			buildOpts.External = append(buildOpts.External, arg[len("--external:"):])
 
+		case strings.HasPrefix(arg, "--declarations-inline:") && buildOpts != nil:
+			buildOpts.DeclarationsInline = append(buildOpts.DeclarationsInline, arg[len("--declarations-inline:"):])
+
 		case strings.HasPrefix(arg, "--inject:") && buildOpts != nil:
 			buildOpts.Inject = append(buildOpts.Inject, arg[len("--inject:"):])
 
@@ -654,10 +739,16 @@ func parseOptionsImpl(
 				mode = api.JSXPreserve
 			case "automatic":
 				mode = api.JSXAutomatic
+			case "solid":
+				mode = api.JSXSolid
+			case "vue":
+				mode = api.JSXVue
+			case "htm":
+				mode = api.JSXHTM
 			default:
 				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
 					fmt.Sprintf("Invalid value %q in %q", value, arg),
-					"Valid values are \"transform\", \"automatic\", or \"preserve\".",
+					"Valid values are \"transform\", \"automatic\", \"preserve\", \"solid\", \"vue\", or \"htm\".",
 				)
 			}
 			if buildOpts != nil {
@@ -708,6 +799,24 @@ func parseOptionsImpl(
 				transformOpts.JSXSideEffects = value
 			}
 
+		case isBoolFlag(arg, "--jsx-constant-elements"):
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else if buildOpts != nil {
+				buildOpts.JSXConstantElements = value
+			} else {
+				transformOpts.JSXConstantElements = value
+			}
+
+		case isBoolFlag(arg, "--jsx-inline-elements"):
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else if buildOpts != nil {
+				buildOpts.JSXInlineElements = value
+			} else {
+				transformOpts.JSXInlineElements = value
+			}
+
 		case strings.HasPrefix(arg, "--banner=") && transformOpts != nil:
 			transformOpts.Banner = arg[len("--banner="):]
 
@@ -751,6 +860,17 @@ func parseOptionsImpl(
 				transformOpts.LogLimit = limit
 			}
 
+		case strings.HasPrefix(arg, "--inline-budget=") && buildOpts != nil:
+			value := arg[len("--inline-budget="):]
+			budget, err := strconv.Atoi(value)
+			if err != nil || budget < 0 {
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"The inline budget must be a non-negative integer.",
+				)
+			}
+			buildOpts.InlineBudget = budget
+
 		case strings.HasPrefix(arg, "--line-limit="):
 			value := arg[len("--line-limit="):]
 			limit, err := strconv.Atoi(value)
@@ -766,6 +886,107 @@ func parseOptionsImpl(
 				transformOpts.LineLimit = limit
 			}
 
+		case strings.HasPrefix(arg, "--indent-width="):
+			value := arg[len("--indent-width="):]
+			width, err := strconv.Atoi(value)
+			if err != nil || width < 0 {
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"The indent width must be a non-negative integer.",
+				)
+			}
+			if buildOpts != nil {
+				buildOpts.Formatting.IndentWidth = width
+			} else {
+				transformOpts.Formatting.IndentWidth = width
+			}
+
+		case isBoolFlag(arg, "--use-tabs"):
+			if value, err := parseBoolFlag(arg, true); err != nil {
+				return parseOptionsExtras{}, err
+			} else if buildOpts != nil {
+				buildOpts.Formatting.UseTabs = value
+			} else {
+				transformOpts.Formatting.UseTabs = value
+			}
+
+		case strings.HasPrefix(arg, "--quotes="):
+			value := arg[len("--quotes="):]
+			var quotes api.Quotes
+			switch value {
+			case "double":
+				quotes = api.QuotesDouble
+			case "single":
+				quotes = api.QuotesSingle
+			default:
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"Valid values are \"double\" or \"single\".",
+				)
+			}
+			if buildOpts != nil {
+				buildOpts.Formatting.Quotes = quotes
+			} else {
+				transformOpts.Formatting.Quotes = quotes
+			}
+
+		case strings.HasPrefix(arg, "--trailing-commas="):
+			value := arg[len("--trailing-commas="):]
+			var trailingCommas api.TrailingCommas
+			switch value {
+			case "none":
+				trailingCommas = api.TrailingCommasNone
+			case "es5":
+				trailingCommas = api.TrailingCommasES5
+			case "all":
+				trailingCommas = api.TrailingCommasAll
+			default:
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"Valid values are \"none\", \"es5\", or \"all\".",
+				)
+			}
+			if buildOpts != nil {
+				buildOpts.Formatting.TrailingCommas = trailingCommas
+			} else {
+				transformOpts.Formatting.TrailingCommas = trailingCommas
+			}
+
+		case strings.HasPrefix(arg, "--semicolons="):
+			value := arg[len("--semicolons="):]
+			var semicolons api.Semicolons
+			switch value {
+			case "always":
+				semicolons = api.SemicolonsAlways
+			case "asi":
+				semicolons = api.SemicolonsASI
+			default:
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"Valid values are \"always\" or \"asi\".",
+				)
+			}
+			if buildOpts != nil {
+				buildOpts.Formatting.Semicolons = semicolons
+			} else {
+				transformOpts.Formatting.Semicolons = semicolons
+			}
+
+		case strings.HasPrefix(arg, "--print-width="):
+			value := arg[len("--print-width="):]
+			width, err := strconv.Atoi(value)
+			if err != nil || width < 0 {
+				return parseOptionsExtras{}, cli_helpers.MakeErrorWithNote(
+					fmt.Sprintf("Invalid value %q in %q", value, arg),
+					"The print width must be a non-negative integer.",
+				)
+			}
+			if buildOpts != nil {
+				buildOpts.Formatting.PrintWidth = width
+			} else {
+				transformOpts.Formatting.PrintWidth = width
+			}
+
 			// Make sure this stays in sync with "PrintErrorToStderr"
 		case isBoolFlag(arg, "--color"):
 			if value, err := parseBoolFlag(arg, true); err != nil {
@@ -816,94 +1037,123 @@ func parseOptionsImpl(
 
 		default:
 			bare := map[string]bool{
-				"allow-overwrite":    true,
-				"bundle":             true,
-				"ignore-annotations": true,
-				"jsx-dev":            true,
-				"jsx-side-effects":   true,
-				"keep-names":         true,
-				"minify-identifiers": true,
-				"minify-syntax":      true,
-				"minify-whitespace":  true,
-				"minify":             true,
-				"preserve-symlinks":  true,
-				"sourcemap":          true,
-				"splitting":          true,
-				"watch":              true,
+				"allow-overwrite":       true,
+				"bundle":                true,
+				"collapse-properties":   true,
+				"declarations":          true,
+				"erasable-syntax-only":  true,
+				"ignore-annotations":    true,
+				"inline-functions":      true,
+				"jsx-constant-elements": true,
+				"jsx-dev":               true,
+				"jsx-inline-elements":   true,
+				"jsx-side-effects":      true,
+				"keep-names":            true,
+				"loose-iteration":       true,
+				"mangle-private":        true,
+				"minify-identifiers":    true,
+				"minify-syntax":         true,
+				"minify-whitespace":     true,
+				"minify":                true,
+				"preserve-symlinks":     true,
+				"sourcemap":             true,
+				"splitting":             true,
+				"strip-types-only":      true,
+				"use-tabs":              true,
+				"watch":                 true,
 			}
 
 			equals := map[string]bool{
-				"allow-overwrite":    true,
-				"asset-names":        true,
-				"banner":             true,
-				"bundle":             true,
-				"certfile":           true,
-				"charset":            true,
-				"chunk-names":        true,
-				"color":              true,
-				"conditions":         true,
-				"drop-labels":        true,
-				"entry-names":        true,
-				"footer":             true,
-				"format":             true,
-				"global-name":        true,
-				"ignore-annotations": true,
-				"jsx-factory":        true,
-				"jsx-fragment":       true,
-				"jsx-import-source":  true,
-				"jsx":                true,
-				"keep-names":         true,
-				"keyfile":            true,
-				"legal-comments":     true,
-				"loader":             true,
-				"log-level":          true,
-				"log-limit":          true,
-				"main-fields":        true,
-				"mangle-cache":       true,
-				"mangle-props":       true,
-				"mangle-quoted":      true,
-				"metafile":           true,
-				"minify-identifiers": true,
-				"minify-syntax":      true,
-				"minify-whitespace":  true,
-				"minify":             true,
-				"outbase":            true,
-				"outdir":             true,
-				"outfile":            true,
-				"packages":           true,
-				"platform":           true,
-				"preserve-symlinks":  true,
-				"public-path":        true,
-				"reserve-props":      true,
-				"resolve-extensions": true,
-				"serve-fallback":     true,
-				"serve":              true,
-				"servedir":           true,
-				"source-root":        true,
-				"sourcefile":         true,
-				"sourcemap":          true,
-				"sources-content":    true,
-				"splitting":          true,
-				"target":             true,
-				"tree-shaking":       true,
-				"tsconfig-raw":       true,
-				"tsconfig":           true,
-				"watch":              true,
+				"allow-overwrite":       true,
+				"asset-names":           true,
+				"banner":                true,
+				"bundle":                true,
+				"certfile":              true,
+				"charset":               true,
+				"chunk-names":           true,
+				"collapse-properties":   true,
+				"color":                 true,
+				"comments":              true,
+				"conditions":            true,
+				"declarations":          true,
+				"drop-labels":           true,
+				"entry-names":           true,
+				"erasable-syntax-only":  true,
+				"footer":                true,
+				"format":                true,
+				"global-name":           true,
+				"ignore-annotations":    true,
+				"indent-width":          true,
+				"inline-budget":         true,
+				"inline-functions":      true,
+				"jsx-constant-elements": true,
+				"jsx-factory":           true,
+				"jsx-fragment":          true,
+				"jsx-import-source":     true,
+				"jsx-inline-elements":   true,
+				"jsx":                   true,
+				"keep-names":            true,
+				"keyfile":               true,
+				"legal-comments":        true,
+				"loader":                true,
+				"loose-iteration":       true,
+				"log-level":             true,
+				"log-limit":             true,
+				"main-fields":           true,
+				"mangle-cache":          true,
+				"mangle-private":        true,
+				"mangle-props":          true,
+				"mangle-quoted":         true,
+				"metafile":              true,
+				"minify-identifiers":    true,
+				"minify-syntax":         true,
+				"minify-whitespace":     true,
+				"minify":                true,
+				"outbase":               true,
+				"outdir":                true,
+				"outfile":               true,
+				"packages":              true,
+				"platform":              true,
+				"polyfill":              true,
+				"preserve-symlinks":     true,
+				"print-width":           true,
+				"public-path":           true,
+				"quotes":                true,
+				"reserve-props":         true,
+				"resolve-extensions":    true,
+				"semicolons":            true,
+				"serve-fallback":        true,
+				"serve":                 true,
+				"servedir":              true,
+				"source-root":           true,
+				"sourcefile":            true,
+				"sourcemap":             true,
+				"sources-content":       true,
+				"splitting":             true,
+				"strip-types-only":      true,
+				"target":                true,
+				"trailing-commas":       true,
+				"tree-shaking":          true,
+				"tsconfig-raw":          true,
+				"tsconfig":              true,
+				"use-tabs":              true,
+				"watch":                 true,
 			}
 
 			colon := map[string]bool{
-				"alias":         true,
-				"banner":        true,
-				"define":        true,
-				"drop":          true,
-				"external":      true,
-				"footer":        true,
-				"inject":        true,
-				"loader":        true,
-				"log-override":  true,
-				"out-extension": true,
-				"pure":          true,
-				"supported":     true,
+				"alias":               true,
+				"banner":              true,
+				"declarations-inline": true,
+				"define":              true,
+				"drop":                true,
+				"external":            true,
+				"footer":              true,
+				"inject":              true,
+				"loader":              true,
+				"log-override":        true,
+				"out-extension":       true,
+				"pure":                true,
+				"supported":           true,
 			}
 
 			note := ""
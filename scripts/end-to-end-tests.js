@@ -1126,6 +1126,39 @@ tests.push(
   }),
 )
 
+// Check that lowering default and rest arguments doesn't change "length"
+tests.push(
+  test(['in.js', '--outfile=node.js', '--target=es5'], {
+    'in.js': `
+      function a(x, y = 1) { return [x, y] }
+      function b({ x } = {}, ...y) { return [x, y] }
+      function c([x], y = x, { z }, w) { return [x, y, z, w] }
+      const d = (x, y = 2, ...z) => [x, y, z]
+      async function e(x, y = 3) { return [x, y] }
+      if (a.length !== 1 || b.length !== 0 || c.length !== 1 || d.length !== 1 || e.length !== 1) throw 'fail: length'
+      if (a(0) + '' !== '0,1' || a(0, 5) + '' !== '0,5') throw 'fail: a'
+      if (b()[0] !== void 0 || b({ x: 1 }, 2, 3) + '' !== '1,2,3') throw 'fail: b'
+      if (c([1], void 0, { z: 2 }, 3) + '' !== '1,1,2,3') throw 'fail: c'
+      if (d(1) + '' !== '1,2,' || d(1, void 0, 3) + '' !== '1,2,3') throw 'fail: d'
+    `,
+  }),
+)
+
+// Check regular expression lowering
+for (const target of ['--target=es5', '--target=es2017']) {
+  tests.push(
+    test(['in.js', '--outfile=node.js', target], {
+      'in.js': `
+        const dates = [...'2020-01 2021-02'.matchAll(/(?<y>\\d{4})-(?<m>\\d{2})/g)].map(m => m.groups.m + '/' + m.groups.y)
+        if (dates + '' !== '01/2020,02/2021') throw 'fail: matchAll'
+        if ('2020-01'.replace(/(?<y>\\d+)-(?<m>\\d+)/, '$<m>.$<y>') !== '01.2020') throw 'fail: replace'
+        if ('a-b'.split(/(?<s>-)/) + '' !== 'a,-,b') throw 'fail: split'
+        if (!/^\\w\\b$/iu.test('\\u017F') || /\\W/iu.test('\\u212A')) throw 'fail: case folding'
+      `,
+    }),
+  )
+}
+
 // Check template literal lowering
 for (const target of ['--target=es5', '--target=es6', '--target=es2020']) {
   tests.push(
@@ -4200,6 +4233,19 @@ for (let [code, expected] of [
 }
 
 // Class lowering tests
+tests.push(
+  test(['in.js', '--outfile=node.js', '--target=es5'], {
+    'in.js': `
+      class Foo { constructor(x) { this.x = x } }
+      class Bar extends Foo { constructor() { super(1) } }
+      class Baz extends Bar {}
+      if (new Foo(2).x !== 2 || new Bar().x !== 1 || !(new Baz() instanceof Foo)) throw 'fail'
+      for (const cls of [Foo, Bar, Baz]) {
+        try { cls(); throw 'fail' } catch (e) { if (!(e instanceof TypeError)) throw e }
+      }
+    `,
+  }),
+)
 for (let flags of [['--target=es2022'], ['--target=es6'], ['--bundle', '--target=es2022'], ['--bundle', '--target=es6']]) {
   // Skip running these tests untransformed. I believe V8 actually has a bug
   // here and esbuild is correct, both because SpiderMonkey and JavaScriptCore
@@ -7824,6 +7870,92 @@ tests.push(
       import './out/in.js'
     `,
   }),
+  test(['in.js', '--outfile=node.js', '--format=cjs', '--bundle'], {
+    'in.js': `
+      import { value } from './a.js'
+      globalThis.tlaTrace.push(4)
+      if (value !== 123 || globalThis.tlaTrace.join(',') !== '1,2,3,4') throw 'fail'
+    `,
+    'a.js': `
+      export { default as value } from './b.js'
+      globalThis.tlaTrace.push(3)
+    `,
+    'b.js': `
+      globalThis.tlaTrace = [1]
+      export default await Promise.resolve(123)
+      globalThis.tlaTrace.push(2)
+    `,
+  }),
+  test(['in.js', '--outfile=out.js', '--format=cjs', '--bundle'], {
+    'in.js': `
+      export { value } from './a.js'
+    `,
+    'a.js': `
+      export const value = await Promise.resolve(123)
+    `,
+    'node.js': `
+      const promise = require('./out.js')
+      if (!(promise instanceof Promise)) throw 'fail'
+      promise.then(out => { if (out.value !== 123) throw 'fail' })
+    `,
+  }),
+  test(['in.js', '--outfile=out.js', '--format=cjs', '--bundle', '--target=es2016'], {
+    'in.js': `
+      import { value } from './a.js'
+      for await (const x of [Promise.resolve(1)]) globalThis.tlaTrace.push(x)
+      export const result = value + 1
+    `,
+    'a.js': `
+      globalThis.tlaTrace = []
+      export const value = await Promise.resolve(123)
+    `,
+    'node.js': `
+      require('./out.js').then(out => {
+        if (out.result !== 124 || globalThis.tlaTrace.join(',') !== '1') throw 'fail'
+      })
+    `,
+  }),
+)
+
+// Test the "import.meta" polyfill for CommonJS output in node
+tests.push(
+  test(['in.js', '--outfile=node.js', '--bundle', '--platform=node', '--format=cjs'], {
+    'in.js': `
+      import { pathToFileURL } from 'url'
+      import { join } from 'path'
+      import { dirname } from './dir.js'
+      if (import.meta.filename !== __filename) throw 'fail: filename'
+      if (dirname !== __dirname) throw 'fail: dirname'
+      if (import.meta.url !== pathToFileURL(__filename).href) throw 'fail: url'
+      if (import.meta.resolve('./node.js') !== import.meta.url) throw 'fail: resolve'
+      if (import.meta.resolve('fs') !== 'node:fs') throw 'fail: resolve builtin'
+      if (import.meta.resolve('./missing.js') !== pathToFileURL(join(__dirname, 'missing.js')).href) throw 'fail: resolve missing'
+      if (import.meta.resolve('../x.js') !== pathToFileURL(join(__dirname, '..', 'x.js')).href) throw 'fail: resolve parent'
+      if (import.meta.resolve('data:text/javascript,') !== 'data:text/javascript,') throw 'fail: resolve url'
+    `,
+    'dir.js': `
+      export const dirname = import.meta.dirname
+    `,
+  }),
+)
+
+// Test the "import.meta" polyfill for IIFE output in the browser. Modules that
+// are initialized lazily run after "document.currentScript" has been cleared.
+tests.push(
+  test(['in.js', '--outfile=node.js', '--bundle', '--platform=browser', '--format=iife',
+    '--banner:js=globalThis.document = { currentScript: { src: "https://example.com/js/out.js" } };'], {
+    'in.js': `
+      if (import.meta.url !== 'https://example.com/js/out.js') throw 'fail: url'
+      document.currentScript = null
+      const lazy = require('./lazy.js')
+      if (lazy.url !== 'https://example.com/js/out.js') throw 'fail: lazy url'
+      if (lazy.resolved !== 'https://example.com/x.js') throw 'fail: lazy resolve'
+    `,
+    'lazy.js': `
+      export const url = import.meta.url
+      export const resolved = import.meta.resolve('../x.js')
+    `,
+  }),
 )
 
 // Test the alias feature
@@ -688,6 +688,62 @@ let buildTests = {
     assert.strictEqual(3, new Function(result.outputFiles[0].text + '\nreturn exampleFn()')())
   },
 
+  async inlineFunctions({ esbuild, testDir }) {
+    const input = path.join(testDir, 'in.js')
+    const lib = path.join(testDir, 'lib.js')
+    await writeFileAsync(input, `
+      import { add, scale } from './lib'
+      let x = 1, y = 2
+      console.log(add(x, y), add(y, x), scale(x, y))
+    `)
+    await writeFileAsync(lib, `
+      export function add(a, b) { return a + b }
+      export function scale(a, b) { return [a * b, a / b, a - b, b - a] }
+    `)
+
+    const build = async options => {
+      const result = await esbuild.build({ entryPoints: [input], bundle: true, minifySyntax: true, write: false, format: 'esm', ...options })
+      return result.outputFiles[0].text
+    }
+
+    // Calls to "add" are inlined, and "scale" is inlined because it's only called once
+    const inlined = await build({ inlineFunctions: true })
+    assert(inlined.includes('console.log(x + y, y + x, [x * y, x / y, x - y, y - x]);'), inlined)
+    assert(!inlined.includes('function'), inlined)
+
+    // A lower budget means "add" is no longer inlined at every call site
+    assert.match(await build({ inlineFunctions: true, inlineBudget: 1 }), /console\.log\(add\(x, y\), add\(y, x\), \[x \* y/)
+
+    // Nothing is inlined without the flag
+    assert.match(await build({}), /console\.log\(add\(x, y\), add\(y, x\), scale\(x, y\)\)/)
+  },
+
+  async collapseProperties({ esbuild, testDir }) {
+    const input = path.join(testDir, 'in.js')
+    const lib = path.join(testDir, 'lib.js')
+    await writeFileAsync(input, `
+      import { Utils, Escapes } from './lib'
+      console.log(Utils.string.pad('x', 3), Utils.VERSION, Escapes)
+    `)
+    await writeFileAsync(lib, `
+      export const Utils = { string: { pad: (s, n) => s.padStart(n) }, VERSION: 1 }
+      export const Escapes = { a: 1 }
+    `)
+
+    const build = async options => {
+      const result = await esbuild.build({ entryPoints: [input], bundle: true, minifySyntax: true, write: false, format: 'esm', ...options })
+      return result.outputFiles[0].text
+    }
+
+    // The properties of "Utils" become separate variables, but "Escapes" is used directly
+    const collapsed = await build({ collapseProperties: true })
+    assert(collapsed.includes('console.log(Utils$string$pad("x", 3), Utils$VERSION, Escapes);'), collapsed)
+    assert(collapsed.includes('Escapes = { a: 1 }'), collapsed)
+
+    // Nothing is collapsed without the flag
+    assert.match(await build({}), /console\.log\(Utils\.string\.pad\("x", 3\), Utils\.VERSION, Escapes\)/)
+  },
+
   async mainFields({ esbuild, testDir }) {
     const input = path.join(testDir, 'in.js')
     const output = path.join(testDir, 'out.js')
@@ -923,8 +979,8 @@ export {
     const names2 = result2.outputFiles.map(x => path.basename(x.path)).sort()
 
     // Check that the public path is included in chunk hashes but not asset hashes
-    assert.deepStrictEqual(names1, ['data-BYATPJRB.bin', 'in-IN5VRZMW.js'])
-    assert.deepStrictEqual(names2, ['data-BYATPJRB.bin', 'in-7HV645WS.js'])
+    assert.deepStrictEqual(names1, ['data-BYATPJRB.bin', 'in-7SVE3DTC.js'])
+    assert.deepStrictEqual(names2, ['data-BYATPJRB.bin', 'in-E6EBO534.js'])
   },
 
   async fileLoaderPublicPath({ esbuild, testDir }) {
@@ -1044,6 +1100,18 @@ body {
     assert.strictEqual(value.outputFiles[1].text, '\uFFFD\uFFFD')
   },
 
+  async declarations({ esbuild, testDir }) {
+    const input = path.join(testDir, 'in.ts')
+    const outdir = path.join(testDir, 'out')
+    await writeFileAsync(input, `export function f(x: number): string { return x + '' }`)
+    const value = await esbuild.build({ entryPoints: [input], outdir, declarations: true, write: false, metafile: true })
+    assert.strictEqual(value.outputFiles.length, 2)
+    assert.strictEqual(value.outputFiles[0].path, path.join(outdir, 'in.d.ts'))
+    assert.strictEqual(value.outputFiles[0].text, `export declare function f(x: number): string;\n`)
+    const key = Object.keys(value.metafile.outputs).find(key => key.endsWith('/in.d.ts'))
+    assert.strictEqual(value.metafile.outputs[key].bytes, 46)
+  },
+
   async metafile({ esbuild, testDir }) {
     const entry = path.join(testDir, 'entry.js')
     const imported = path.join(testDir, 'imported.js')
@@ -5288,6 +5356,34 @@ let transformTests = {
     assert.deepStrictEqual(mangleCache, { x_: 'FIXED', y_: 'a', z_: false })
   },
 
+  async manglePrivateTransform({ esbuild }) {
+    var { code, mangleCache } = await esbuild.transform(`
+      class Foo {
+        private foo = 0
+        protected bar = 1
+        private baz_ = 2
+        #qux = 3
+        sum() { return this.foo + this.bar + this.baz_ + this.#qux }
+      }
+    `, {
+      loader: 'ts',
+      manglePrivate: true,
+      reserveProps: /_$/,
+      mangleCache: { bar: 'FIXED' },
+    })
+    assert.strictEqual(code, `class Foo {
+  a = 0;
+  FIXED = 1;
+  baz_ = 2;
+  #a = 3;
+  sum() {
+    return this.a + this.FIXED + this.baz_ + this.#a;
+  }
+}
+`)
+    assert.deepStrictEqual(mangleCache, { bar: 'FIXED', foo: 'a', '#qux': '#a' })
+  },
+
   async jsBannerTransform({ esbuild }) {
     var { code } = await esbuild.transform(`
       if (!bannerDefined) throw 'fail'
@@ -6004,6 +6100,44 @@ class Foo {
     assert.strictEqual(code, `React.createElement("b", null);\n`)
   },
 
+  async jsxConstantAndInlineElements({ esbuild }) {
+    const { code } = await esbuild.transform(`export const f = () => <b/>`, {
+      loader: 'jsx',
+      jsx: 'automatic',
+      jsxConstantElements: true,
+      jsxInlineElements: true,
+    })
+    assert.strictEqual(code, `var __reactElement = /* @__PURE__ */ Symbol.for("react.element");
+var _a;
+export const f = () => _a || (_a = { $$typeof: __reactElement, type: "b", key: null, ref: null, props: {}, _owner: null });
+`)
+  },
+
+  async jsxTargets({ esbuild }) {
+    const solid = await esbuild.transform(`<a b={c()}>d</a>`, { loader: 'jsx', jsx: 'solid' })
+    assert.strictEqual(solid.code, `import { effect, setAttribute, template } from "solid-js/web";
+var _a = /* @__PURE__ */ template("<a>d</a>");
+(() => {
+  var _el = _a();
+  effect(() => setAttribute(_el, "b", c()));
+  return _el;
+})();
+`)
+    const vue = await esbuild.transform(`<a b={c}>d</a>`, { loader: 'jsx', jsx: 'vue' })
+    assert.strictEqual(vue.code, `import { createVNode } from "vue";
+/* @__PURE__ */ createVNode("a", { b: c }, "d", 8, ["b"]);
+`)
+    const htm = await esbuild.transform(`<a b={c}>d</a>`, { loader: 'jsx', jsx: 'htm' })
+    assert.strictEqual(htm.code, "html`<a b=${c}>d</a>`;\n")
+  },
+
+  async polyfill({ esbuild }) {
+    const { code } = await esbuild.transform(`x.at(-1), Object.hasOwn(x, y)`, { polyfill: 'core-js', target: 'chrome92' })
+    assert.strictEqual(code, `import "core-js/modules/es.object.has-own.js";\nx.at(-1), Object.hasOwn(x, y);\n`)
+    const { code: code2 } = await esbuild.transform(`x.at(-1)`, { polyfill: 'core-js' })
+    assert.strictEqual(code2, `x.at(-1);\n`)
+  },
+
   async ts({ esbuild }) {
     const { code } = await esbuild.transform(`enum Foo { FOO }`, { loader: 'ts' })
     assert.strictEqual(code, `var Foo = /* @__PURE__ */ ((Foo2) => {\n  Foo2[Foo2["FOO"] = 0] = "FOO";\n  return Foo2;\n})(Foo || {});\n`)
@@ -6014,6 +6148,43 @@ class Foo {
     assert.strictEqual(code, `console.log(/* @__PURE__ */ React.createElement(Foo, null));\n`)
   },
 
+  async stripTypesOnly({ esbuild }) {
+    const { code } = await esbuild.transform(`let x: number = f<T>(y as any)\ntype T = U\n(z)`, { loader: 'ts', stripTypesOnly: true })
+    assert.strictEqual(code, `let x         = f   (y       )\n;         \n(z)`)
+    const { code: code2 } = await esbuild.transform(`let x = <div>{y!}</div>`, { loader: 'tsx', stripTypesOnly: true })
+    assert.strictEqual(code2, `let x = <div>{y }</div>`)
+    try {
+      await esbuild.transform(`enum Foo { FOO }`, { loader: 'ts', stripTypesOnly: true })
+      throw new Error('Expected an error to be thrown')
+    } catch (e) {
+      assert.strictEqual(e.errors[0].text, 'Enums are not supported when only stripping types because they generate code')
+    }
+    try {
+      await esbuild.transform(`let x`, { stripTypesOnly: true })
+      throw new Error('Expected an error to be thrown')
+    } catch (e) {
+      assert.strictEqual(e.errors[0].text, 'Must use the "ts" or "tsx" loader with "strip-types-only"')
+    }
+  },
+
+  async erasableSyntaxOnly({ esbuild }) {
+    try {
+      await esbuild.transform(`enum Foo { FOO }`, { loader: 'ts', erasableSyntaxOnly: true })
+      throw new Error('Expected an error to be thrown')
+    } catch (e) {
+      assert.strictEqual(e.errors[0].text, 'Enums are not allowed when "erasableSyntaxOnly" is enabled')
+      assert.strictEqual(e.errors[0].id, 'erasable-syntax-only')
+    }
+    const { code, warnings } = await esbuild.transform(`import { A } from 'a'; let x: A`, {
+      loader: 'ts',
+      tsconfigRaw: { compilerOptions: { erasableSyntaxOnly: true } },
+      logOverride: { 'missing-type-modifier': 'warning' },
+    })
+    assert.strictEqual(code, `let x;\n`)
+    assert.strictEqual(warnings.length, 1)
+    assert.strictEqual(warnings[0].id, 'missing-type-modifier')
+  },
+
   async minify({ esbuild }) {
     const { code } = await esbuild.transform(`console.log("a" + "b" + c)`, { minify: true })
     assert.strictEqual(code, `console.log("ab"+c);\n`)
@@ -6094,6 +6265,23 @@ class Foo {
 
   async defineImportMetaIIFE({ esbuild }) {
     const { code } = await esbuild.transform(`console.log(a, b); export {}`, { define: { a: 'import.meta', b: 'import.meta.foo' }, format: 'iife' })
+    assert.strictEqual(code, `(() => {
+  var __currentScript = /* @__PURE__ */ (() => typeof document != "undefined" && document.currentScript)();
+  var __importMetaBrowser = () => {
+    var url = __currentScript && __currentScript.src || (typeof location != "undefined" ? location.href : "");
+    return {
+      url,
+      resolve: (id) => new URL(id, url).href
+    };
+  };
+  const import_meta = __importMetaBrowser();
+  console.log(import_meta, import_meta.foo);
+})();
+`)
+  },
+
+  async defineImportMetaIIFENeutral({ esbuild }) {
+    const { code } = await esbuild.transform(`console.log(a, b); export {}`, { define: { a: 'import.meta', b: 'import.meta.foo' }, format: 'iife', platform: 'neutral' })
     assert.strictEqual(code, `(() => {\n  const import_meta = {};\n  console.log(import_meta, import_meta.foo);\n})();\n`)
   },
 
@@ -6382,6 +6570,32 @@ class Foo {
     }
   },
 
+  async transformPreserveComments({ esbuild }) {
+    const input = `/** a */\nfunction f(x: number) {\n  // b\n  return x // c\n}\n`
+    assert.strictEqual((await esbuild.transform(input, { loader: 'ts' })).code, `function f(x) {\n  return x;\n}\n`)
+    assert.strictEqual((await esbuild.transform(input, { loader: 'ts', comments: 'preserve' })).code, `/** a */\nfunction f(x) {\n  // b\n  return x; // c\n}\n`)
+    assert.strictEqual((await esbuild.transform(input, { loader: 'ts', comments: 'preserve', minifyWhitespace: true })).code, `function f(x){return x}\n`)
+  },
+
+  async transformFormatting({ esbuild }) {
+    const input = `function f(){if(a){b("x",[1,2])}}`
+    assert.strictEqual((await esbuild.transform(input, {})).code, `function f() {\n  if (a) {\n    b("x", [1, 2]);\n  }\n}\n`)
+    assert.strictEqual((await esbuild.transform(input, {
+      formatting: { indentWidth: 4, quotes: 'single', semicolons: 'asi' },
+    })).code, `function f() {\n    if (a) {\n        b('x', [1, 2])\n    }\n}\n`)
+    assert.strictEqual((await esbuild.transform(input, {
+      formatting: { useTabs: true, trailingCommas: 'all', printWidth: 10 },
+    })).code, `function f() {\n\tif (a) {\n\t\tb(\n\t\t\t"x",\n\t\t\t[1, 2],\n\t\t);\n\t}\n}\n`)
+
+    try {
+      await esbuild.transform(``, { formatting: { tabs: true } })
+      throw new Error('Expected a transform failure')
+    } catch (e) {
+      if (!e || !e.errors || !e.errors[0] || e.errors[0].text !== 'Invalid option in "formatting" object: "tabs"')
+        throw e
+    }
+  },
+
   async transformLegalCommentsCSS({ esbuild }) {
     assert.strictEqual((await esbuild.transform(`/*!x*/\ny{}`, { loader: 'css', legalComments: 'none' })).code, `y {\n}\n`)
     assert.strictEqual((await esbuild.transform(`/*!x*/\ny{}`, { loader: 'css', legalComments: 'inline' })).code, `/*!x*/\ny {\n}\n`)
@@ -6701,6 +6915,8 @@ class Foo {
   async propertyAccessBugWorkaroundForWebKit({ esbuild }) {
     const check = async (target, input, expected) =>
       assert.strictEqual((await esbuild.transform(input, { target })).code, expected)
+    const checkIncludes = async (target, input, expected) =>
+      assert((await esbuild.transform(input, { target })).code.includes(expected))
     await Promise.all([
       check('safari16.2', `x(class{}.y=z)`, `x((class {\n}).y = z);\n`),
       check('safari16.3', `x(class{}.y=z)`, `x(class {\n}.y = z);\n`),
@@ -6792,17 +7008,20 @@ class Foo {
   async regExpFeatures({ esbuild }) {
     const check = async (target, input, expected) =>
       assert.strictEqual((await esbuild.transform(input, { target })).code, expected)
+    const checkIncludes = async (target, input, expected) =>
+      assert((await esbuild.transform(input, { target })).code.includes(expected))
 
     await Promise.all([
       // RegExpStickyAndUnicodeFlags
       check('es6', `x1 = /./y`, `x1 = /./y;\n`),
       check('es6', `x2 = /./u`, `x2 = /./u;\n`),
       check('es5', `x3 = /./y`, `x3 = new RegExp(".", "y");\n`),
-      check('es5', `x4 = /./u`, `x4 = new RegExp(".", "u");\n`),
+      check('es5', `x4 = /./u`, `x4 = /(?:[\\uD800-\\uDBFF][\\uDC00-\\uDFFF]|[\\uD800-\\uDBFF](?![\\uDC00-\\uDFFF])|[^\\n\\r\\u2028\\u2029\\uD800-\\uDBFF])/;\n`),
+      check('es5', `x5 = /./uy`, `x5 = new RegExp(".", "uy");\n`),
 
       // RegExpDotAllFlag
       check('es2018', `x1 = /a.b/s`, `x1 = /a.b/s;\n`),
-      check('es2017', `x2 = /a.b/s`, `x2 = new RegExp("a.b", "s");\n`),
+      check('es2017', `x2 = /a.b/s`, `x2 = /a[\\s\\S]b/;\n`),
 
       // RegExpLookbehindAssertions
       check('es2018', `x1 = /(?<=x)/`, `x1 = /(?<=x)/;\n`),
@@ -6812,11 +7031,13 @@ class Foo {
 
       // RegExpNamedCaptureGroups
       check('es2018', `x1 = /(?<a>b)/`, `x1 = /(?<a>b)/;\n`),
-      check('es2017', `x2 = /(?<a>b)/`, `x2 = new RegExp("(?<a>b)");\n`),
+      checkIncludes('es2017', `x2 = /(?<a>b)/`, `x2 = /* @__PURE__ */ __wrapRegExp(/(b)/, {\n  a: 1\n});\n`),
+      check('es2017', `x3 = /(?<a>b)(?<=c)/`, `x3 = new RegExp("(?<a>b)(?<=c)");\n`),
 
       // RegExpUnicodePropertyEscapes
       check('es2018', `x1 = /\\p{Emoji}/u`, `x1 = /\\p{Emoji}/u;\n`),
       check('es2017', `x2 = /\\p{Emoji}/u`, `x2 = new RegExp("\\\\p{Emoji}", "u");\n`),
+      check('es2017', `x3 = /\\p{Script=Ogham}/u`, `x3 = /[\\u1680-\\u169C]/u;\n`),
 
       // RegExpMatchIndices
       check('es2022', `x1 = /y/d`, `x1 = /y/d;\n`),
@@ -6824,15 +7045,21 @@ class Foo {
 
       // RegExpSetNotation
       check('esnext', `x1 = /[\\p{White_Space}&&\\p{ASCII}]/v`, `x1 = /[\\p{White_Space}&&\\p{ASCII}]/v;\n`),
-      check('es2022', `x2 = /[\\p{White_Space}&&\\p{ASCII}]/v`, `x2 = new RegExp("[\\\\p{White_Space}&&\\\\p{ASCII}]", "v");\n`),
+      check('es2022', `x2 = /[\\p{White_Space}&&\\p{ASCII}]/v`, `x2 = /[\\t-\\r ]/u;\n`),
     ])
   },
 
+  async nonIdArrayRest({ esbuild }) {
+    // Patterns inside array rest elements are lowered instead of being an error
+    const { code } = await esbuild.transform('let [...[x, ...y]] = [1, 2, 3]; out = [x, y]', { target: 'es2015' })
+    assert(!code.includes('...['), code)
+    assert.deepStrictEqual(new Function('let out;' + code + 'return out')(), [1, [2, 3]])
+  },
+
   // Future syntax
   bigInt: ({ esbuild }) => futureSyntax(esbuild, '123n', 'es2019', 'es2020'),
   bigIntKey: ({ esbuild }) => futureSyntax(esbuild, '({123n: 0})', 'es2019', 'es2020'),
   bigIntPattern: ({ esbuild }) => futureSyntax(esbuild, 'let {123n: x} = y', 'es2019', 'es2020'),
-  nonIdArrayRest: ({ esbuild }) => futureSyntax(esbuild, 'let [...[x]] = y', 'es2015', 'es2016'),
   topLevelAwait: ({ esbuild }) => futureSyntax(esbuild, 'await foo', 'es2020', 'esnext'),
   topLevelForAwait: ({ esbuild }) => futureSyntax(esbuild, 'for await (foo of bar) ;', 'es2020', 'esnext'),
 }
@@ -1780,6 +1780,49 @@ let pluginTests = {
     assert.strictEqual(result.outputFiles[0].text, '// xyz:nested\nfoo();\n')
   },
 
+  async importAttributesOnResolveAndOnLoad({ esbuild }) {
+    const resolveArgs = []
+    const loadArgs = []
+    const result = await esbuild.build({
+      entryPoints: ['entry'],
+      write: false,
+      bundle: true,
+      format: 'esm',
+      plugins: [{
+        name: 'plugin',
+        setup(build) {
+          build.onResolve({ filter: /.*/ }, args => {
+            if (args.path === 'entry') return { path: 'entry', namespace: 'xyz' }
+            resolveArgs.push(args.with)
+            return { path: args.path, namespace: 'xyz' }
+          })
+          build.onLoad({ filter: /.*/ }, args => {
+            if (args.path === 'entry') return {
+              contents: `
+                import a from 'foo' with { type: 'json' }
+                import b from 'foo' with { type: 'text' }
+                console.log(a, b)
+              `,
+            }
+            loadArgs.push(JSON.stringify(args.with))
+            return { contents: '123', loader: 'default' }
+          })
+        },
+      }],
+    })
+    assert.deepStrictEqual(resolveArgs, [{ type: 'json' }, { type: 'text' }])
+    assert.deepStrictEqual(loadArgs.sort(), ['{"type":"json"}', '{"type":"text"}'])
+    assert.strictEqual(result.outputFiles[0].text, `// xyz:foo
+var foo_default = 123;
+
+// xyz:foo
+var foo_default2 = "123";
+
+// xyz:entry
+console.log(foo_default, foo_default2);
+`)
+  },
+
   async pluginDataLoadToResolve({ esbuild }) {
     const theObject = {}
     const result = await esbuild.build({